@@ -0,0 +1,74 @@
+// Command knuu-scenario runs a scenario file, see the scenario package for its format.
+// It exits with a non-zero code if the scenario fails.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/celestiaorg/knuu/pkg/knuu"
+	"github.com/celestiaorg/knuu/pkg/scenario"
+)
+
+func main() {
+	var (
+		scope     = flag.String("scope", "", "test scope of the instances, a new one is generated if empty")
+		timeout   = flag.Duration("timeout", 60*time.Minute, "time after which all the resources of the scope are deleted")
+		reportDir = flag.String("report", "", "directory the reports of the run and the JUnit and OTLP timings of the setup phases are written to")
+	)
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] <scenario.yaml>\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	s, err := scenario.Load(flag.Arg(0))
+	if err != nil {
+		logrus.Fatalf("Error loading the scenario: %v", err)
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	opts := []knuu.Option{knuu.WithTimeout(*timeout)}
+	if *scope != "" {
+		opts = append(opts, knuu.WithTestScope(*scope))
+	}
+	k, err := knuu.New(ctx, opts...)
+	if err != nil {
+		logrus.Fatalf("Error initializing knuu: %v", err)
+	}
+
+	result, runErr := scenario.NewRunner(k, scenario.WithReporter(k.Reporter)).Run(ctx, s)
+	for _, step := range result.Steps {
+		status := "ok"
+		if step.Err != nil {
+			status = "FAILED"
+		}
+		fmt.Printf("%-6s step %d: %s (%s)\n", status, step.Index, step.Step, step.Duration.Round(time.Millisecond))
+	}
+
+	if *reportDir != "" {
+		if err := k.SaveReport(*reportDir); err != nil {
+			logrus.Errorf("Error saving the report: %v", err)
+		}
+	}
+	if err := k.CleanUp(context.WithoutCancel(ctx)); err != nil {
+		logrus.Errorf("Error deleting the namespace: %v", err)
+	}
+	if runErr != nil {
+		logrus.Errorf("Scenario '%s' failed: %v", s.Name, runErr)
+		os.Exit(1)
+	}
+}
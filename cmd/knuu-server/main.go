@@ -0,0 +1,124 @@
+// Command knuu-server runs the knuu remote controller in the cluster.
+// Tests written in any language drive their instances through its HTTP API, see remote.Server,
+// without needing credentials for the cluster.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/celestiaorg/knuu/pkg/knuu"
+	"github.com/celestiaorg/knuu/pkg/remote"
+)
+
+const (
+	// tokenEnv is the environment variable the token is read from if no token file is given
+	tokenEnv = "KNUU_SERVER_TOKEN"
+
+	shutdownTimeout = 5 * time.Minute
+	// drainTimeout is the time the requests in flight are given to complete when the server stops,
+	// the streams are cancelled with the signal
+	drainTimeout = 10 * time.Second
+)
+
+func main() {
+	var (
+		addr      = flag.String("addr", ":8080", "address to listen on")
+		scope     = flag.String("scope", "", "test scope of the instances, a new one is generated if empty")
+		timeout   = flag.Duration("timeout", 60*time.Minute, "time after which all the resources of the scope are deleted")
+		tokenFile = flag.String("token-file", "", "file containing the token required from clients, "+tokenEnv+" is used if empty")
+		insecure  = flag.Bool("insecure", false, "accept requests without a token")
+		proxy     = flag.Bool("proxy", false, "deploy the proxy to expose the instances")
+		keep      = flag.Bool("keep", false, "keep the resources of the scope when the server stops")
+	)
+	flag.Parse()
+
+	token, err := readToken(*tokenFile)
+	if err != nil {
+		logrus.Fatalf("Error reading the token: %v", err)
+	}
+	if token == "" && !*insecure {
+		logrus.Fatalf("A token is required, set %s or -token-file, or use -insecure", tokenEnv)
+	}
+
+	ctx := context.Background()
+	opts := []knuu.Option{knuu.WithTimeout(*timeout)}
+	if *scope != "" {
+		opts = append(opts, knuu.WithTestScope(*scope))
+	}
+	if *proxy {
+		opts = append(opts, knuu.WithProxyEnabled())
+	}
+	k, err := knuu.New(ctx, opts...)
+	if err != nil {
+		logrus.Fatalf("Error initializing knuu: %v", err)
+	}
+
+	var serverOpts []remote.ServerOption
+	if token != "" {
+		serverOpts = append(serverOpts, remote.WithRequiredToken(token))
+	}
+	server := remote.NewServer(k, serverOpts...)
+
+	// the requests are cancelled on the signal, so that the streams of events and logs return
+	serveCtx, stop := signal.NotifyContext(ctx, syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	httpServer := &http.Server{
+		Addr:              *addr,
+		Handler:           server,
+		ReadHeaderTimeout: 10 * time.Second,
+		BaseContext:       func(net.Listener) context.Context { return serveCtx },
+	}
+
+	go func() {
+		<-serveCtx.Done()
+		logrus.Info("Received signal to stop, shutting down...")
+		shutdownCtx, cancel := context.WithTimeout(ctx, drainTimeout)
+		defer cancel()
+		if err := httpServer.Shutdown(shutdownCtx); err != nil {
+			logrus.Errorf("Error shutting down the server: %v", err)
+			if err := httpServer.Close(); err != nil {
+				logrus.Errorf("Error closing the server: %v", err)
+			}
+		}
+	}()
+
+	logrus.Infof("Serving scope '%s' on %s", k.Scope(), *addr)
+	if err := httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		logrus.Errorf("Error serving: %v", err)
+	}
+
+	if *keep {
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, shutdownTimeout)
+	defer cancel()
+	if err := server.Destroy(ctx); err != nil {
+		logrus.Errorf("Error destroying the instances: %v", err)
+	}
+	if err := k.CleanUp(ctx); err != nil {
+		logrus.Errorf("Error deleting the namespace: %v", err)
+	}
+}
+
+// readToken returns the token from the file if it is set, or from the environment
+func readToken(path string) (string, error) {
+	if path == "" {
+		return os.Getenv(tokenEnv), nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
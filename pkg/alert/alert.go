@@ -0,0 +1,130 @@
+// Package alert watches the instances during long running tests, such as soak tests, and raises alerts as soon as
+// a condition is met, e.g. a log line matching a pattern, a metric above a threshold or a restarted container,
+// so that the tests fail fast instead of at the end of a run of several hours.
+//
+//	ctx, cancel := context.WithCancelCause(ctx)
+//	w := alert.NewWatcher(
+//		alert.WithConditions(
+//			alert.LogPattern(validator, regexp.MustCompile(`CONSENSUS FAILURE`)),
+//			alert.MetricAbove(validator, 26660, "", "cometbft_consensus_rounds", nil, 5),
+//			alert.Restarts(k.Instances),
+//		),
+//		alert.OnAlert(alert.Slack(os.Getenv("SLACK_WEBHOOK_URL"))),
+//		alert.CancelOnAlert(cancel),
+//	)
+//	go w.Run(ctx)
+package alert
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/celestiaorg/knuu/pkg/clock"
+)
+
+const defaultInterval = 30 * time.Second
+
+// Alert is raised when a condition is met
+type Alert struct {
+	// Condition is the name of the condition that was met
+	Condition string    `json:"condition"`
+	Instance  string    `json:"instance,omitempty"`
+	Time      time.Time `json:"time"`
+	Message   string    `json:"message"`
+}
+
+// Handler is called for each alert, e.g. to notify a chat or an on-call service, see Webhook
+type Handler func(ctx context.Context, a Alert)
+
+// Watcher evaluates conditions during a test and calls its handlers for each alert
+type Watcher struct {
+	conditions []Condition
+	interval   time.Duration
+	handlers   []Handler
+	cancel     context.CancelCauseFunc
+
+	// mu serializes the handlers, the conditions are watched concurrently
+	mu     sync.Mutex
+	alerts []Alert
+}
+
+// Option configures a Watcher
+type Option func(*Watcher)
+
+// WithConditions adds the conditions watched by the watcher
+func WithConditions(conditions ...Condition) Option {
+	return func(w *Watcher) {
+		w.conditions = append(w.conditions, conditions...)
+	}
+}
+
+// WithInterval sets the interval between two checks of the polled conditions, 30s by default
+func WithInterval(interval time.Duration) Option {
+	return func(w *Watcher) {
+		w.interval = interval
+	}
+}
+
+// OnAlert adds a handler called for each alert
+// The handlers are called one at a time by the goroutines of Run, a slow handler delays the next alerts.
+func OnAlert(handler Handler) Option {
+	return func(w *Watcher) {
+		w.handlers = append(w.handlers, handler)
+	}
+}
+
+// CancelOnAlert cancels the context of the test with ErrAlertRaised as cause at the first alert, after its handlers
+func CancelOnAlert(cancel context.CancelCauseFunc) Option {
+	return func(w *Watcher) {
+		w.cancel = cancel
+	}
+}
+
+// NewWatcher returns a watcher of the given conditions
+func NewWatcher(opts ...Option) *Watcher {
+	w := &Watcher{interval: defaultInterval}
+	for _, opt := range opts {
+		opt(w)
+	}
+	return w
+}
+
+// Run watches the conditions until the context is done
+// It must not be called concurrently.
+func (w *Watcher) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, c := range w.conditions {
+		wg.Add(1)
+		go func(c Condition) {
+			defer wg.Done()
+			c.watch(ctx, w.interval, func(instance, message string) {
+				w.raise(ctx, Alert{Condition: c.Name, Instance: instance, Time: clock.FromContext(ctx).Now(), Message: message})
+			})
+		}(c)
+	}
+	wg.Wait()
+}
+
+// Alerts returns the alerts raised so far, in order
+func (w *Watcher) Alerts() []Alert {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return append([]Alert(nil), w.alerts...)
+}
+
+func (w *Watcher) raise(ctx context.Context, a Alert) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	logrus.Warnf("Alert '%s' raised for instance '%s': %s", a.Condition, a.Instance, a.Message)
+	w.alerts = append(w.alerts, a)
+	for _, handler := range w.handlers {
+		handler(ctx, a)
+	}
+	if w.cancel != nil {
+		w.cancel(ErrAlertRaised.WithParams(a.Condition, a.Instance, a.Message))
+	}
+}
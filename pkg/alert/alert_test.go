@@ -0,0 +1,140 @@
+package alert
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/celestiaorg/knuu/pkg/clock"
+)
+
+func TestWatcherCheck(t *testing.T) {
+	fake := clock.NewFake(time.Unix(0, 0))
+	ctx, cancel := context.WithCancelCause(clock.WithClock(context.Background(), fake))
+	defer cancel(nil)
+
+	states := []bool{false, true, true, false, true}
+	var checks atomic.Int32
+	condition := Check("height stalled", func(context.Context) (bool, string, error) {
+		n := checks.Add(1)
+		if int(n) > len(states) {
+			return false, "", errors.New("no more states")
+		}
+		return states[n-1], "no block for 5m", nil
+	})
+
+	var mu sync.Mutex
+	var handled []Alert
+	w := NewWatcher(
+		WithConditions(condition),
+		WithInterval(time.Minute),
+		OnAlert(func(_ context.Context, a Alert) {
+			mu.Lock()
+			defer mu.Unlock()
+			handled = append(handled, a)
+		}),
+	)
+	done := make(chan struct{})
+	go func() {
+		w.Run(ctx)
+		close(done)
+	}()
+
+	for index := range states {
+		require.Eventually(t, fake.HasWaiters, time.Second, time.Millisecond)
+		fake.Step(time.Minute)
+		require.Eventually(t, func() bool { return int(checks.Load()) == index+1 }, time.Second, time.Millisecond)
+	}
+	cancel(nil)
+	<-done
+
+	// the alert is raised when the condition becomes met, twice here
+	alerts := w.Alerts()
+	require.Len(t, alerts, 2)
+	assert.Equal(t, "height stalled", alerts[0].Condition)
+	assert.Equal(t, "no block for 5m", alerts[0].Message)
+	assert.Equal(t, time.Unix(0, 0).Add(2*time.Minute), alerts[0].Time, "the alert is timed by the clock")
+	assert.Equal(t, alerts, handled)
+}
+
+func TestMatchLogs(t *testing.T) {
+	t.Parallel()
+
+	since := time.Date(2024, 5, 1, 10, 0, 0, 500, time.UTC)
+	logs := strings.Join([]string{
+		// the stream resumes from the start of the second of the last line read
+		"2024-05-01T10:00:00.000000100Z ERROR already raised",
+		"2024-05-01T10:00:00.000000500Z ERROR last line read",
+		"2024-05-01T10:00:00.000000900Z INFO started",
+		"2024-05-01T10:00:01.000000000Z ERROR disk full",
+		"",
+	}, "\n")
+
+	var raised []string
+	last, err := matchLogs(strings.NewReader(logs), regexp.MustCompile("ERROR"), since, func(line string) {
+		raised = append(raised, line)
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ERROR disk full"}, raised)
+	assert.Equal(t, time.Date(2024, 5, 1, 10, 0, 1, 0, time.UTC), last)
+}
+
+func TestWatcherCancelOnAlert(t *testing.T) {
+	fake := clock.NewFake(time.Unix(0, 0))
+	ctx, cancel := context.WithCancelCause(clock.WithClock(context.Background(), fake))
+	defer cancel(nil)
+
+	w := NewWatcher(
+		WithConditions(Check("always", func(context.Context) (bool, string, error) {
+			return true, "boom", nil
+		})),
+		WithInterval(time.Second),
+		CancelOnAlert(cancel),
+	)
+	go w.Run(ctx)
+
+	require.Eventually(t, fake.HasWaiters, time.Second, time.Millisecond)
+	fake.Step(time.Second)
+	<-ctx.Done()
+	assert.ErrorIs(t, context.Cause(ctx), ErrAlertRaised)
+}
+
+func TestSlack(t *testing.T) {
+	t.Parallel()
+
+	bodies := make(chan map[string]string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]string
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		bodies <- body
+	}))
+	defer server.Close()
+
+	Slack(server.URL)(context.Background(), Alert{Condition: "container restart", Instance: "validator", Message: "OOMKilled"})
+	assert.Equal(t, map[string]string{"text": "[knuu] container restart in validator: OOMKilled"}, <-bodies)
+}
+
+func TestPagerDutyEvent(t *testing.T) {
+	t.Parallel()
+
+	event := pagerDutyEvent("key", Alert{Condition: "c", Time: time.Unix(0, 0), Message: "m"})
+	assert.Equal(t, "key", event["routing_key"])
+	assert.Equal(t, "trigger", event["event_action"])
+	assert.Equal(t, map[string]any{
+		"summary":   "[knuu] c: m",
+		"source":    "knuu",
+		"severity":  "critical",
+		"timestamp": "1970-01-01T00:00:00Z",
+	}, event["payload"])
+}
@@ -0,0 +1,173 @@
+package alert
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/celestiaorg/knuu/pkg/clock"
+	"github.com/celestiaorg/knuu/pkg/instance"
+)
+
+// Condition is a condition watched by a Watcher
+type Condition struct {
+	Name string
+	// watch evaluates the condition until the context is done and calls raise each time it is met
+	watch func(ctx context.Context, interval time.Duration, raise func(instance, message string))
+}
+
+// Check returns a condition checked at each interval by the given function, which returns whether the condition
+// is met and the message of the alert
+// An alert is raised when the condition becomes met, not at each check while it stays met. The errors of the
+// function are logged and do not change the state of the condition.
+func Check(name string, check func(ctx context.Context) (met bool, message string, err error)) Condition {
+	return Condition{Name: name, watch: func(ctx context.Context, interval time.Duration, raise func(string, string)) {
+		met := false
+		poll(ctx, interval, func() {
+			now, message, err := check(ctx)
+			if err != nil {
+				logrus.Debugf("Error checking condition '%s': %v", name, err)
+				return
+			}
+			if now && !met {
+				raise("", message)
+			}
+			met = now
+		})
+	}}
+}
+
+// LogPattern raises an alert for each line of the logs of the instance matching the pattern
+// The lines logged from the start of the watch are matched. When the stream ends, e.g. after a restart, it is
+// followed again after the last line read, so each line raises a single alert.
+func LogPattern(inst *instance.Instance, pattern *regexp.Regexp) Condition {
+	name := fmt.Sprintf("log pattern '%s'", pattern)
+	return Condition{Name: name, watch: func(ctx context.Context, interval time.Duration, raise func(string, string)) {
+		since := clock.FromContext(ctx).Now()
+		for {
+			var err error
+			if since, err = followLogs(ctx, inst, pattern, since, raise); err != nil {
+				logrus.Debugf("Error following the logs of instance '%s': %v", inst.Name(), err)
+			}
+			if err := clock.Sleep(ctx, interval); err != nil {
+				return
+			}
+		}
+	}}
+}
+
+// followLogs follows the logs of the instance logged after the given time and returns the time of the last line read
+func followLogs(ctx context.Context, inst *instance.Instance, pattern *regexp.Regexp, since time.Time, raise func(string, string)) (time.Time, error) {
+	logs, err := inst.LogsSince(ctx, since, true)
+	if err != nil {
+		return since, err
+	}
+	defer logs.Close()
+
+	return matchLogs(logs, pattern, since, func(line string) { raise(inst.Name(), line) })
+}
+
+// matchLogs calls raise for the lines of the logs matching the pattern and logged after the given time, the lines
+// being prefixed with their time, and returns the time of the last line read
+// The lines logged before the given time are skipped, as the stream resumes from the start of its second.
+func matchLogs(logs io.Reader, pattern *regexp.Regexp, since time.Time, raise func(string)) (time.Time, error) {
+	scanner := bufio.NewScanner(logs)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if timestamp, text, ok := strings.Cut(line, " "); ok {
+			if t, err := time.Parse(time.RFC3339Nano, timestamp); err == nil {
+				if !t.After(since) {
+					continue
+				}
+				since, line = t, text
+			}
+		}
+		if pattern.MatchString(line) {
+			raise(line)
+		}
+	}
+	return since, scanner.Err()
+}
+
+// MetricAbove raises an alert when the value of the metric of the instance rises above the threshold
+// The metric is scraped at each interval with Instance.ScrapeMetrics and its samples with the given labels are
+// summed, see instance.MetricFamilies.Value. An alert is raised again if the value falls and rises again.
+func MetricAbove(inst *instance.Instance, port int, path, metric string, labels map[string]string, threshold float64) Condition {
+	name := fmt.Sprintf("%s above %g", metric, threshold)
+	return metricCondition(name, inst, port, path, metric, labels, func(v float64) bool { return v > threshold })
+}
+
+// MetricBelow raises an alert when the value of the metric of the instance falls below the threshold, see MetricAbove
+func MetricBelow(inst *instance.Instance, port int, path, metric string, labels map[string]string, threshold float64) Condition {
+	name := fmt.Sprintf("%s below %g", metric, threshold)
+	return metricCondition(name, inst, port, path, metric, labels, func(v float64) bool { return v < threshold })
+}
+
+func metricCondition(name string, inst *instance.Instance, port int, path, metric string, labels map[string]string, met func(float64) bool) Condition {
+	return Condition{Name: name, watch: func(ctx context.Context, interval time.Duration, raise func(string, string)) {
+		reported := false
+		poll(ctx, interval, func() {
+			families, err := inst.ScrapeMetrics(ctx, port, path)
+			if err != nil {
+				logrus.Debugf("Error checking condition '%s': %v", name, err)
+				return
+			}
+			v, ok := families.Value(metric, labels)
+			if !ok {
+				return
+			}
+			if !met(v) {
+				reported = false
+				return
+			}
+			if !reported {
+				raise(inst.Name(), fmt.Sprintf("%s is %g", metric, v))
+				reported = true
+			}
+		})
+	}}
+}
+
+// Restarts raises an alert each time kubernetes restarts the container of one of the started instances returned
+// by the given function, e.g. knuu.Knuu.Instances or instance.Supervise
+func Restarts(instances func() []*instance.Instance) Condition {
+	return Condition{Name: "container restart", watch: func(ctx context.Context, interval time.Duration, raise func(string, string)) {
+		restarts := make(map[*instance.Instance]int32)
+		poll(ctx, interval, func() {
+			for _, inst := range instances() {
+				if !inst.IsInState(instance.Started) {
+					continue
+				}
+				health, err := inst.Health(ctx)
+				if err != nil {
+					logrus.Debugf("Error checking the health of instance '%s': %v", inst.Name(), err)
+					continue
+				}
+				if previous, ok := restarts[inst]; ok && health.Restarts > previous {
+					raise(inst.Name(), fmt.Sprintf("container restarted %d times: %s", health.Restarts, health.Reason))
+				}
+				restarts[inst] = health.Restarts
+			}
+		})
+	}}
+}
+
+// poll calls check at each interval of the clock of the context until the context is done
+func poll(ctx context.Context, interval time.Duration, check func()) {
+	ticker := clock.FromContext(ctx).NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C():
+			check()
+		}
+	}
+}
@@ -0,0 +1,11 @@
+package alert
+
+import (
+	"github.com/celestiaorg/knuu/pkg/errors"
+)
+
+type Error = errors.Error
+
+var (
+	ErrAlertRaised = errors.New("AlertRaised", "alert '%s' raised for instance '%s': %s")
+)
@@ -0,0 +1,94 @@
+package alert
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// PagerDutyEventsURL is the URL of the events API v2 of PagerDuty
+	PagerDutyEventsURL = "https://events.pagerduty.com/v2/enqueue"
+
+	webhookTimeout = 10 * time.Second
+)
+
+// Webhook returns a handler posting the alerts as JSON to the given URL, the alert itself if body is nil
+// The errors are logged, so that an unreachable webhook does not stop the watch.
+func Webhook(url string, body func(a Alert) any) Handler {
+	client := &http.Client{Timeout: webhookTimeout}
+	return func(ctx context.Context, a Alert) {
+		var payload any = a
+		if body != nil {
+			payload = body(a)
+		}
+		if err := post(ctx, client, url, payload); err != nil {
+			logrus.Warnf("Error sending alert '%s' to webhook: %v", a.Condition, err)
+		}
+	}
+}
+
+// Slack returns a handler posting the alerts to the given incoming webhook of Slack
+func Slack(webhookURL string) Handler {
+	return Webhook(webhookURL, func(a Alert) any {
+		return map[string]string{"text": a.String()}
+	})
+}
+
+// PagerDuty returns a handler triggering a critical PagerDuty event with the given routing key for each alert
+func PagerDuty(routingKey string) Handler {
+	return Webhook(PagerDutyEventsURL, func(a Alert) any {
+		return pagerDutyEvent(routingKey, a)
+	})
+}
+
+// String describes the alert in a single line
+func (a Alert) String() string {
+	if a.Instance == "" {
+		return fmt.Sprintf("[knuu] %s: %s", a.Condition, a.Message)
+	}
+	return fmt.Sprintf("[knuu] %s in %s: %s", a.Condition, a.Instance, a.Message)
+}
+
+func pagerDutyEvent(routingKey string, a Alert) map[string]any {
+	source := a.Instance
+	if source == "" {
+		source = "knuu"
+	}
+	return map[string]any{
+		"routing_key":  routingKey,
+		"event_action": "trigger",
+		"payload": map[string]any{
+			"summary":   a.String(),
+			"source":    source,
+			"severity":  "critical",
+			"timestamp": a.Time.UTC().Format(time.RFC3339),
+		},
+	}
+}
+
+func post(ctx context.Context, client *http.Client, url string, payload any) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(context.WithoutCancel(ctx), http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("status: %s", resp.Status)
+	}
+	return nil
+}
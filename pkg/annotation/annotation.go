@@ -0,0 +1,89 @@
+// Package annotation marks the faults injected by knuu on the timeline of the observability tools, e.g. as
+// Grafana annotations or OpenTelemetry span events, so that the dashboards show exactly when and where knuu
+// perturbed the system.
+//
+//	k, err := knuu.New(ctx, knuu.WithAnnotator(annotation.Grafana("http://grafana:3000", os.Getenv("GRAFANA_TOKEN"))))
+package annotation
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Kind is the kind of perturbation an annotation marks
+type Kind string
+
+const (
+	KindNetworkDisabled Kind = "network-disabled"
+	KindNetworkEnabled  Kind = "network-enabled"
+	KindLatency         Kind = "latency"
+	KindPacketLoss      Kind = "packet-loss"
+	KindBandwidth       Kind = "bandwidth"
+	KindStop            Kind = "stop"
+)
+
+// Annotation marks a perturbation of an instance on the timeline
+type Annotation struct {
+	Time time.Time
+	// End is the end of the perturbation if it is a range, zero otherwise
+	End  time.Time
+	Kind Kind
+	// Instance is the name of the perturbed instance
+	Instance string
+	// Scope is the test scope of the instance
+	Scope string
+	Text  string
+}
+
+// Tags returns the tags of the annotation, used to filter the annotations in the dashboards
+func (a Annotation) Tags() []string {
+	return []string{"knuu", string(a.Kind), "instance:" + a.Instance, "scope:" + a.Scope}
+}
+
+// String describes the annotation in a single line
+func (a Annotation) String() string {
+	if a.Text == "" {
+		return fmt.Sprintf("[knuu] %s of %s", a.Kind, a.Instance)
+	}
+	return fmt.Sprintf("[knuu] %s of %s: %s", a.Kind, a.Instance, a.Text)
+}
+
+// Annotator emits the annotations
+type Annotator interface {
+	Annotate(ctx context.Context, a Annotation) error
+}
+
+// AnnotatorFunc is a function implementing Annotator
+type AnnotatorFunc func(ctx context.Context, a Annotation) error
+
+func (f AnnotatorFunc) Annotate(ctx context.Context, a Annotation) error {
+	return f(ctx, a)
+}
+
+// Multi returns an annotator emitting the annotations with each of the given annotators
+// All the annotators are called even if some fail, the first error is returned.
+func Multi(annotators ...Annotator) Annotator {
+	return AnnotatorFunc(func(ctx context.Context, a Annotation) error {
+		var first error
+		for _, annotator := range annotators {
+			if err := annotator.Annotate(ctx, a); err != nil && first == nil {
+				first = err
+			}
+		}
+		return first
+	})
+}
+
+// Emit emits the annotation with the annotator, if not nil
+// The errors are logged and not returned, since a failure of the observability tools should not fail the test.
+func Emit(ctx context.Context, annotator Annotator, a Annotation) {
+	if annotator == nil {
+		return
+	}
+	if err := annotator.Annotate(ctx, a); err != nil {
+		logrus.Warnf("Error emitting annotation '%s': %v", a, err)
+	}
+}
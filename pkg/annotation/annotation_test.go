@@ -0,0 +1,100 @@
+package annotation
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+var testAnnotation = Annotation{
+	Time:     time.UnixMilli(1700000000000),
+	Kind:     KindLatency,
+	Instance: "validator-1",
+	Scope:    "test-scope",
+	Text:     "100ms, jitter 10ms",
+}
+
+func TestGrafana(t *testing.T) {
+	t.Parallel()
+
+	requests := make(chan *http.Request, 1)
+	bodies := make(chan grafanaAnnotation, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body grafanaAnnotation
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		requests <- r
+		bodies <- body
+	}))
+	defer server.Close()
+
+	a := testAnnotation
+	a.End = a.Time.Add(time.Minute)
+	err := Grafana(server.URL+"/", "token", WithDashboard("dashboard"), WithTags("soak")).Annotate(context.Background(), a)
+	require.NoError(t, err)
+
+	r := <-requests
+	assert.Equal(t, "/api/annotations", r.URL.Path)
+	assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+	assert.Equal(t, grafanaAnnotation{
+		DashboardUID: "dashboard",
+		Time:         1700000000000,
+		TimeEnd:      1700000060000,
+		Tags:         []string{"knuu", "latency", "instance:validator-1", "scope:test-scope", "soak"},
+		Text:         "[knuu] latency of validator-1: 100ms, jitter 10ms",
+	}, <-bodies)
+}
+
+func TestGrafanaRejected(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "invalid API key", http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	err := Grafana(server.URL, "").Annotate(context.Background(), testAnnotation)
+	require.ErrorIs(t, err, ErrGrafanaAnnotationRejected)
+	assert.Contains(t, err.Error(), "invalid API key")
+}
+
+func TestOTel(t *testing.T) {
+	t.Parallel()
+
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	require.NoError(t, OTel(provider).Annotate(context.Background(), testAnnotation))
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "knuu.latency", spans[0].Name)
+	assert.Equal(t, testAnnotation.Time, spans[0].StartTime)
+	require.Len(t, spans[0].Events, 1)
+	assert.Equal(t, "[knuu] latency of validator-1: 100ms, jitter 10ms", spans[0].Events[0].Name)
+	assert.Contains(t, spans[0].Attributes, attribute.String("knuu.instance", "validator-1"))
+}
+
+func TestMulti(t *testing.T) {
+	t.Parallel()
+
+	errFirst := errors.New("first")
+	var calls int
+	annotator := func(err error) Annotator {
+		return AnnotatorFunc(func(context.Context, Annotation) error {
+			calls++
+			return err
+		})
+	}
+	err := Multi(annotator(nil), annotator(errFirst), annotator(errors.New("second"))).Annotate(context.Background(), testAnnotation)
+	assert.Equal(t, errFirst, err)
+	assert.Equal(t, 3, calls)
+}
@@ -0,0 +1,12 @@
+package annotation
+
+import (
+	"github.com/celestiaorg/knuu/pkg/errors"
+)
+
+type Error = errors.Error
+
+var (
+	ErrCreatingGrafanaAnnotation = errors.New("CreatingGrafanaAnnotation", "error creating Grafana annotation of '%s'")
+	ErrGrafanaAnnotationRejected = errors.New("GrafanaAnnotationRejected", "Grafana rejected the annotation of '%s': %s: %s")
+)
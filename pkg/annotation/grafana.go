@@ -0,0 +1,95 @@
+package annotation
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const grafanaTimeout = 10 * time.Second
+
+// GrafanaOption configures the Grafana annotator
+type GrafanaOption func(*grafana)
+
+// WithDashboard restricts the annotations to the dashboard with the given UID, they are organization wide by default
+func WithDashboard(uid string) GrafanaOption {
+	return func(g *grafana) {
+		g.dashboardUID = uid
+	}
+}
+
+// WithTags adds the given tags to the annotations, e.g. to show them with a dedicated annotation query
+func WithTags(tags ...string) GrafanaOption {
+	return func(g *grafana) {
+		g.tags = append(g.tags, tags...)
+	}
+}
+
+type grafana struct {
+	url          string
+	token        string
+	dashboardUID string
+	tags         []string
+	client       *http.Client
+}
+
+// grafanaAnnotation is the body of a request to the annotations API of Grafana
+type grafanaAnnotation struct {
+	DashboardUID string   `json:"dashboardUID,omitempty"`
+	Time         int64    `json:"time"`
+	TimeEnd      int64    `json:"timeEnd,omitempty"`
+	Tags         []string `json:"tags"`
+	Text         string   `json:"text"`
+}
+
+// Grafana returns an annotator creating the annotations with the annotations API of the Grafana at the given URL
+// The token is a service account token, sent as bearer token, no authentication is used if it is empty.
+func Grafana(url, token string, opts ...GrafanaOption) Annotator {
+	g := &grafana{
+		url:    strings.TrimSuffix(url, "/") + "/api/annotations",
+		token:  token,
+		client: &http.Client{Timeout: grafanaTimeout},
+	}
+	for _, opt := range opts {
+		opt(g)
+	}
+	return g
+}
+
+func (g *grafana) Annotate(ctx context.Context, a Annotation) error {
+	body := grafanaAnnotation{
+		DashboardUID: g.dashboardUID,
+		Time:         a.Time.UnixMilli(),
+		Tags:         append(a.Tags(), g.tags...),
+		Text:         a.String(),
+	}
+	if !a.End.IsZero() {
+		body.TimeEnd = a.End.UnixMilli()
+	}
+	data, err := json.Marshal(body)
+	if err != nil {
+		return ErrCreatingGrafanaAnnotation.WithParams(a.Kind).Wrap(err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, g.url, bytes.NewReader(data))
+	if err != nil {
+		return ErrCreatingGrafanaAnnotation.WithParams(a.Kind).Wrap(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if g.token != "" {
+		req.Header.Set("Authorization", "Bearer "+g.token)
+	}
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return ErrCreatingGrafanaAnnotation.WithParams(a.Kind).Wrap(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return ErrGrafanaAnnotationRejected.WithParams(a.Kind, resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package annotation
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/celestiaorg/knuu/pkg/annotation"
+
+// OTel returns an annotator recording the annotations as spans named after their kind, e.g. 'knuu.latency',
+// with an event at the start of the perturbation
+// The spans are children of the span of the context, if any, and are created with the given tracer provider, the
+// global one if nil, so they are exported with the traces of the test.
+func OTel(provider trace.TracerProvider) Annotator {
+	if provider == nil {
+		provider = otel.GetTracerProvider()
+	}
+	tracer := provider.Tracer(tracerName)
+	return AnnotatorFunc(func(ctx context.Context, a Annotation) error {
+		attrs := trace.WithAttributes(
+			attribute.String("knuu.kind", string(a.Kind)),
+			attribute.String("knuu.instance", a.Instance),
+			attribute.String("knuu.scope", a.Scope),
+			attribute.String("knuu.text", a.Text),
+		)
+		_, span := tracer.Start(ctx, "knuu."+string(a.Kind), trace.WithTimestamp(a.Time), attrs)
+		span.AddEvent(a.String(), trace.WithTimestamp(a.Time), attrs)
+		end := a.End
+		if end.IsZero() {
+			end = a.Time
+		}
+		span.End(trace.WithTimestamp(end))
+		return nil
+	})
+}
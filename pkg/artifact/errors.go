@@ -0,0 +1,17 @@
+package artifact
+
+import (
+	"github.com/celestiaorg/knuu/pkg/errors"
+)
+
+type Error = errors.Error
+
+var (
+	ErrMinioNotInitialized = errors.New("MinioNotInitialized", "minio is not initialized")
+	ErrArtifactKeyEmpty    = errors.New("ArtifactKeyEmpty", "the key of an artifact cannot be empty")
+	ErrDeployingMinio      = errors.New("DeployingMinio", "error deploying minio to store the artifacts")
+	ErrPuttingArtifact     = errors.New("PuttingArtifact", "error putting artifact '%s'")
+	ErrGettingArtifact     = errors.New("GettingArtifact", "error getting artifact '%s'")
+	ErrListingArtifacts    = errors.New("ListingArtifacts", "error listing artifacts with prefix '%s'")
+	ErrGettingArtifactURL  = errors.New("GettingArtifactURL", "error getting the URL of artifact '%s'")
+)
@@ -0,0 +1,115 @@
+// Package artifact stores the outputs of the tests, e.g. block data, profiles or packet captures,
+// in the minio deployed by knuu, so they outlive the pods of the instances that produced them.
+package artifact
+
+import (
+	"context"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/celestiaorg/knuu/pkg/minio"
+)
+
+// Bucket is the minio bucket of the artifacts
+const Bucket = "artifacts"
+
+// Store puts and gets artifacts by key, keys can contain slashes to group the artifacts, e.g. 'validator-0/cpu.pprof'
+// Minio is deployed on the first use of the store.
+type Store struct {
+	minio minio.Client
+
+	mu       sync.Mutex
+	deployed bool
+}
+
+// NewStore returns a store of the artifacts in the given minio
+func NewStore(minioCli minio.Client) *Store {
+	return &Store{minio: minioCli}
+}
+
+// Put stores the content of the reader under the given key, an existing artifact with the same key is replaced
+func (s *Store) Put(ctx context.Context, key string, reader io.Reader) error {
+	key, err := s.prepare(ctx, key)
+	if err != nil {
+		return err
+	}
+	if err := s.minio.PushToMinio(ctx, reader, key, Bucket); err != nil {
+		return ErrPuttingArtifact.WithParams(key).Wrap(err)
+	}
+	logrus.Debugf("Stored artifact '%s'", key)
+	return nil
+}
+
+// Get returns the content of the artifact with the given key, the caller must close it
+func (s *Store) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	key, err := s.prepare(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	rc, err := s.minio.GetFromMinio(ctx, key, Bucket)
+	if err != nil {
+		return nil, ErrGettingArtifact.WithParams(key).Wrap(err)
+	}
+	return rc, nil
+}
+
+// List returns the keys of the artifacts that start with the given prefix, all of them if it is empty
+func (s *Store) List(ctx context.Context, prefix string) ([]string, error) {
+	if err := s.deploy(ctx); err != nil {
+		return nil, err
+	}
+	keys, err := s.minio.ListMinio(ctx, strings.TrimLeft(prefix, "/"), Bucket)
+	if err != nil {
+		return nil, ErrListingArtifacts.WithParams(prefix).Wrap(err)
+	}
+	return keys, nil
+}
+
+// URL returns a presigned URL to download the artifact with the given key, valid for 24 hours
+func (s *Store) URL(ctx context.Context, key string) (string, error) {
+	key, err := s.prepare(ctx, key)
+	if err != nil {
+		return "", err
+	}
+	url, err := s.minio.GetMinioURL(ctx, key, Bucket)
+	if err != nil {
+		return "", ErrGettingArtifactURL.WithParams(key).Wrap(err)
+	}
+	return url, nil
+}
+
+// prepare validates the key and deploys minio, it returns the key without its leading slashes
+func (s *Store) prepare(ctx context.Context, key string) (string, error) {
+	key = strings.TrimLeft(key, "/")
+	if key == "" {
+		return "", ErrArtifactKeyEmpty
+	}
+	return key, s.deploy(ctx)
+}
+
+func (s *Store) deploy(ctx context.Context) error {
+	if s.minio == nil {
+		return ErrMinioNotInitialized
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.deployed {
+		return nil
+	}
+
+	ok, err := s.minio.IsMinioDeployed(ctx)
+	if err != nil {
+		return ErrDeployingMinio.Wrap(err)
+	}
+	if !ok {
+		if err := s.minio.DeployMinio(ctx); err != nil {
+			return ErrDeployingMinio.Wrap(err)
+		}
+	}
+	s.deployed = true
+	return nil
+}
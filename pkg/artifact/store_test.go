@@ -0,0 +1,115 @@
+package artifact
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/celestiaorg/knuu/pkg/minio"
+)
+
+// fakeMinio keeps the files in memory
+type fakeMinio struct {
+	minio.Client
+	mu          sync.Mutex
+	deployCalls int
+	files       map[string]string
+}
+
+func (f *fakeMinio) IsMinioDeployed(context.Context) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.deployCalls > 0, nil
+}
+
+func (f *fakeMinio) DeployMinio(context.Context) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.deployCalls++
+	return nil
+}
+
+func (f *fakeMinio) PushToMinio(_ context.Context, localReader io.Reader, minioFilePath, bucketName string) error {
+	data, err := io.ReadAll(localReader)
+	if err != nil {
+		return err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.files == nil {
+		f.files = make(map[string]string)
+	}
+	f.files[bucketName+"/"+minioFilePath] = string(data)
+	return nil
+}
+
+func (f *fakeMinio) GetFromMinio(_ context.Context, minioFilePath, bucketName string) (io.ReadCloser, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	data, ok := f.files[bucketName+"/"+minioFilePath]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	return io.NopCloser(strings.NewReader(data)), nil
+}
+
+func (f *fakeMinio) ListMinio(_ context.Context, prefix, bucketName string) ([]string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var paths []string
+	for path := range f.files {
+		if p, ok := strings.CutPrefix(path, bucketName+"/"); ok && strings.HasPrefix(p, prefix) {
+			paths = append(paths, p)
+		}
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+func TestStore(t *testing.T) {
+	t.Parallel()
+
+	m := &fakeMinio{}
+	s := NewStore(m)
+	ctx := context.Background()
+
+	require.NoError(t, s.Put(ctx, "validator-0/cpu.pprof", strings.NewReader("cpu")))
+	require.NoError(t, s.Put(ctx, "/validator-0/heap.pprof", strings.NewReader("heap")))
+	require.NoError(t, s.Put(ctx, "validator-1/cpu.pprof", strings.NewReader("other")))
+	assert.Equal(t, 1, m.deployCalls, "minio is deployed once")
+	assert.Contains(t, m.files, Bucket+"/validator-0/heap.pprof")
+
+	rc, err := s.Get(ctx, "validator-0/cpu.pprof")
+	require.NoError(t, err)
+	data, err := io.ReadAll(rc)
+	require.NoError(t, err)
+	require.NoError(t, rc.Close())
+	assert.Equal(t, "cpu", string(data))
+
+	_, err = s.Get(ctx, "validator-2/cpu.pprof")
+	assert.ErrorIs(t, err, ErrGettingArtifact)
+
+	keys, err := s.List(ctx, "validator-0/")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"validator-0/cpu.pprof", "validator-0/heap.pprof"}, keys)
+
+	keys, err = s.List(ctx, "")
+	require.NoError(t, err)
+	assert.Len(t, keys, 3)
+
+	assert.ErrorIs(t, s.Put(ctx, "/", strings.NewReader("")), ErrArtifactKeyEmpty)
+}
+
+func TestStoreWithoutMinio(t *testing.T) {
+	t.Parallel()
+
+	_, err := NewStore(nil).List(context.Background(), "")
+	assert.ErrorIs(t, err, ErrMinioNotInitialized)
+}
@@ -0,0 +1,190 @@
+// Package benchmark runs performance tests: it sends load to the instances with a load generator, scrapes the
+// Prometheus endpoints of the instances before and after the load, and checks the results against service level
+// objectives, such as the p99 latency or the error rate, to produce a pass or fail and a report.
+//
+//	b := benchmark.New("tx-submission", gen, loadgen.Attack{
+//		Targets: []loadgen.Target{{Method: "POST", URL: "http://validator:26657/broadcast_tx_sync"}},
+//		Stages:  loadgen.Ramp(10, 200, 5*time.Minute, 5),
+//	},
+//		benchmark.WithMetrics(benchmark.MetricsSource{Instance: validator, Port: 26660}),
+//		benchmark.WithObjectives(
+//			benchmark.P99LatencyBelow(500*time.Millisecond),
+//			benchmark.ErrorRateBelow(0.01),
+//			benchmark.MetricIncreaseBelow("validator", "cometbft_consensus_rounds", 10),
+//		),
+//	)
+//	result, err := b.Run(ctx)
+package benchmark
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/celestiaorg/knuu/pkg/clock"
+	"github.com/celestiaorg/knuu/pkg/instance"
+	"github.com/celestiaorg/knuu/pkg/loadgen"
+	"github.com/celestiaorg/knuu/pkg/report"
+)
+
+// LoadGenerator sends the load of a benchmark, it is implemented by loadgen.Generator
+type LoadGenerator interface {
+	Run(ctx context.Context, attack loadgen.Attack) (*loadgen.Result, error)
+}
+
+// Benchmark sends a load to the instances and checks the results against its objectives
+type Benchmark struct {
+	name       string
+	generator  LoadGenerator
+	attack     loadgen.Attack
+	sources    []MetricsSource
+	objectives []Objective
+	reporter   *report.Recorder
+}
+
+// Option configures a Benchmark
+type Option func(*Benchmark)
+
+// WithMetrics scrapes the given Prometheus endpoints before and after the load
+func WithMetrics(sources ...MetricsSource) Option {
+	return func(b *Benchmark) {
+		b.sources = append(b.sources, sources...)
+	}
+}
+
+// WithObjectives checks the results of the benchmark against the given objectives
+func WithObjectives(objectives ...Objective) Option {
+	return func(b *Benchmark) {
+		b.objectives = append(b.objectives, objectives...)
+	}
+}
+
+// WithReporter records the benchmark in the given recorder
+func WithReporter(reporter *report.Recorder) Option {
+	return func(b *Benchmark) {
+		b.reporter = reporter
+	}
+}
+
+// Result is the outcome of a benchmark
+type Result struct {
+	Name     string        `json:"name"`
+	Start    time.Time     `json:"start"`
+	Duration time.Duration `json:"duration"`
+	// Passed is true if all the objectives were met
+	Passed bool            `json:"passed"`
+	Load   *loadgen.Result `json:"load"`
+	// Metrics are the metrics scraped from the instances, by instance name
+	Metrics    map[string]*Metrics `json:"metrics,omitempty"`
+	Objectives []ObjectiveResult   `json:"objectives"`
+}
+
+// ObjectiveResult is the outcome of an objective
+type ObjectiveResult struct {
+	Name string `json:"name"`
+	// Threshold describes the objective, e.g. '<= 500ms'
+	Threshold string `json:"threshold"`
+	// Measured is the value measured by the benchmark, empty if it could not be measured
+	Measured string `json:"measured"`
+	Passed   bool   `json:"passed"`
+	// Error explains why the value could not be measured
+	Error string `json:"error,omitempty"`
+}
+
+// New returns a benchmark sending the given attack with the given load generator
+func New(name string, generator LoadGenerator, attack loadgen.Attack, opts ...Option) *Benchmark {
+	b := &Benchmark{name: name, generator: generator, attack: attack}
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b
+}
+
+// Run scrapes the metrics of the instances, sends the load, scrapes the metrics again and checks the objectives
+// The result is returned even if an objective is not met, along with an error listing the failed objectives.
+func (b *Benchmark) Run(ctx context.Context) (result *Result, err error) {
+	c := clock.FromContext(ctx)
+	result = &Result{Name: b.name, Start: c.Now()}
+	defer func() {
+		result.Duration = c.Since(result.Start)
+		b.reporter.Record("", report.OperationBenchmark, result.Start, b.name, err)
+	}()
+
+	before := make([]instance.MetricFamilies, len(b.sources))
+	for index, source := range b.sources {
+		if before[index], err = source.Instance.ScrapeMetrics(ctx, source.Port, source.Path); err != nil {
+			return result, err
+		}
+	}
+
+	logrus.Infof("Benchmark '%s': sending the load", b.name)
+	result.Load, err = b.generator.Run(ctx, b.attack)
+	if err != nil {
+		return result, ErrSendingLoad.WithParams(b.name).Wrap(err)
+	}
+
+	if len(b.sources) != 0 {
+		result.Metrics = make(map[string]*Metrics, len(b.sources))
+	}
+	for index, source := range b.sources {
+		after, err := source.Instance.ScrapeMetrics(ctx, source.Port, source.Path)
+		if err != nil {
+			return result, err
+		}
+		result.Metrics[source.Instance.Name()] = &Metrics{Before: before[index], After: after}
+	}
+
+	result.Passed = true
+	var failed []string
+	for _, o := range b.objectives {
+		or := o.evaluate(result)
+		result.Objectives = append(result.Objectives, or)
+		if !or.Passed {
+			result.Passed = false
+			failed = append(failed, o.Name)
+		}
+	}
+	logrus.Infof("Benchmark '%s':\n%s", b.name, result.Summary())
+	if !result.Passed {
+		return result, ErrObjectivesNotMet.WithParams(b.name, strings.Join(failed, ", "))
+	}
+	return result, nil
+}
+
+// Summary describes the load and the objectives of the benchmark, one per line
+func (r *Result) Summary() string {
+	var sb strings.Builder
+	if r.Load != nil {
+		fmt.Fprintf(&sb, "%d requests at %.1f/s, success %.2f%%, latency p50 %s p99 %s max %s\n",
+			r.Load.Requests, r.Load.Rate, r.Load.Success*100, r.Load.Latencies.P50, r.Load.Latencies.P99, r.Load.Latencies.Max)
+	}
+	for _, o := range r.Objectives {
+		status := "PASS"
+		if !o.Passed {
+			status = "FAIL"
+		}
+		measured := o.Measured
+		if o.Error != "" {
+			measured = o.Error
+		}
+		fmt.Fprintf(&sb, "%s %s: %s (objective %s)\n", status, o.Name, measured, o.Threshold)
+	}
+	return sb.String()
+}
+
+// Save writes the result to the given path as JSON
+func (r *Result) Save(path string) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return ErrWritingResult.WithParams(path).Wrap(err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return ErrWritingResult.WithParams(path).Wrap(err)
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package benchmark
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/celestiaorg/knuu/pkg/loadgen"
+	"github.com/celestiaorg/knuu/pkg/report"
+)
+
+type fakeGenerator struct {
+	result *loadgen.Result
+	err    error
+}
+
+func (g fakeGenerator) Run(context.Context, loadgen.Attack) (*loadgen.Result, error) {
+	return g.result, g.err
+}
+
+func loadResult() *loadgen.Result {
+	return &loadgen.Result{
+		Requests:   1000,
+		Rate:       100,
+		Throughput: 98,
+		Success:    0.98,
+		Latencies:  loadgen.Latencies{P50: 20 * time.Millisecond, P99: 300 * time.Millisecond, Max: time.Second},
+	}
+}
+
+func TestBenchmarkRun(t *testing.T) {
+	t.Parallel()
+
+	reporter := report.NewRecorder("test")
+	b := New("submit", fakeGenerator{result: loadResult()}, loadgen.Attack{},
+		WithReporter(reporter),
+		WithObjectives(
+			P99LatencyBelow(500*time.Millisecond),
+			ErrorRateBelow(0.01),
+			ThroughputAbove(50),
+		),
+	)
+	result, err := b.Run(context.Background())
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ErrObjectivesNotMet))
+	assert.Contains(t, err.Error(), "error rate")
+
+	assert.False(t, result.Passed)
+	require.Len(t, result.Objectives, 3)
+	assert.Equal(t, ObjectiveResult{Name: "p99 latency", Threshold: "<= 500ms", Measured: "300ms", Passed: true}, result.Objectives[0])
+	assert.Equal(t, ObjectiveResult{Name: "error rate", Threshold: "<= 1%", Measured: "2.00%"}, result.Objectives[1])
+	assert.True(t, result.Objectives[2].Passed)
+	assert.True(t, strings.Contains(result.Summary(), "FAIL error rate"))
+
+	entries := reporter.Report().Entries
+	require.Len(t, entries, 1)
+	assert.Equal(t, report.OperationBenchmark, entries[0].Operation)
+
+	path := filepath.Join(t.TempDir(), "benchmark.json")
+	require.NoError(t, result.Save(path))
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var saved Result
+	require.NoError(t, json.Unmarshal(data, &saved))
+	assert.Equal(t, result.Objectives, saved.Objectives)
+	assert.Equal(t, uint64(1000), saved.Load.Requests)
+}
+
+func TestBenchmarkRunPassed(t *testing.T) {
+	t.Parallel()
+
+	result, err := New("submit", fakeGenerator{result: loadResult()}, loadgen.Attack{},
+		WithObjectives(P99LatencyBelow(time.Second)),
+	).Run(context.Background())
+	require.NoError(t, err)
+	assert.True(t, result.Passed)
+}
+
+func TestBenchmarkRunLoadFailed(t *testing.T) {
+	t.Parallel()
+
+	_, err := New("submit", fakeGenerator{err: errors.New("boom")}, loadgen.Attack{}).Run(context.Background())
+	assert.True(t, errors.Is(err, ErrSendingLoad))
+}
@@ -0,0 +1,15 @@
+package benchmark
+
+import (
+	"github.com/celestiaorg/knuu/pkg/errors"
+)
+
+type Error = errors.Error
+
+var (
+	ErrSendingLoad       = errors.New("SendingLoad", "error sending the load of benchmark '%s'")
+	ErrObjectivesNotMet  = errors.New("ObjectivesNotMet", "benchmark '%s' did not meet its objectives: %s")
+	ErrMetricsNotScraped = errors.New("MetricsNotScraped", "the metrics of instance '%s' were not scraped")
+	ErrMetricNotFound    = errors.New("MetricNotFound", "metric '%s' not found in the metrics of instance '%s'")
+	ErrWritingResult     = errors.New("WritingResult", "error writing benchmark result file '%s'")
+)
@@ -0,0 +1,35 @@
+package benchmark
+
+import (
+	"github.com/celestiaorg/knuu/pkg/instance"
+)
+
+// MetricsSource is a Prometheus endpoint of an instance, e.g. the one set with SetPrometheusEndpoint
+// It is scraped with Instance.ScrapeMetrics.
+type MetricsSource struct {
+	Instance *instance.Instance
+	Port     int
+	// Path is /metrics if empty
+	Path string
+}
+
+// Metrics are the metric families of an instance scraped before and after the load
+type Metrics struct {
+	Before instance.MetricFamilies `json:"before"`
+	After  instance.MetricFamilies `json:"after"`
+}
+
+// Value returns the sum of the samples with the given name after the load, e.g. 'http_requests_total'
+func (m *Metrics) Value(name string) (float64, bool) {
+	return m.After.Value(name, nil)
+}
+
+// Increase returns the increase of the sum of the samples with the given name during the load, e.g. for a counter
+func (m *Metrics) Increase(name string) (float64, bool) {
+	after, ok := m.After.Value(name, nil)
+	if !ok {
+		return 0, false
+	}
+	before, _ := m.Before.Value(name, nil)
+	return after - before, true
+}
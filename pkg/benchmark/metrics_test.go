@@ -0,0 +1,35 @@
+package benchmark
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/celestiaorg/knuu/pkg/instance"
+)
+
+func TestMetrics(t *testing.T) {
+	t.Parallel()
+
+	before, err := instance.ParseMetrics(strings.NewReader("errors_total{kind=\"a\"} 1\nerrors_total{kind=\"b\"} 2\n"))
+	require.NoError(t, err)
+	after, err := instance.ParseMetrics(strings.NewReader("errors_total{kind=\"a\"} 4\nerrors_total{kind=\"b\"} 2\nerrors_total{kind=\"c\"} 1\n"))
+	require.NoError(t, err)
+	m := &Metrics{Before: before, After: after}
+	v, ok := m.Value("errors_total")
+	require.True(t, ok)
+	assert.Equal(t, 7.0, v)
+	v, ok = m.Increase("errors_total")
+	require.True(t, ok)
+	assert.Equal(t, 4.0, v)
+	_, ok = m.Increase("missing")
+	assert.False(t, ok)
+
+	r := &Result{Metrics: map[string]*Metrics{"validator": m}}
+	assert.Equal(t, ObjectiveResult{Name: "increase of errors_total of validator", Threshold: "<= 3", Measured: "4"},
+		MetricIncreaseBelow("validator", "errors_total", 3).evaluate(r))
+	assert.True(t, MetricBelow("validator", "errors_total", 10).evaluate(r).Passed)
+	assert.Contains(t, MetricBelow("bridge", "errors_total", 10).evaluate(r).Error, "'bridge' were not scraped")
+}
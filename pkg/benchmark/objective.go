@@ -0,0 +1,91 @@
+package benchmark
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Objective is a service level objective checked against the result of a benchmark
+type Objective struct {
+	Name string
+	// Threshold describes the objective, e.g. '<= 500ms'
+	Threshold string
+	// measure returns the measured value and whether it meets the objective
+	measure func(r *Result) (string, bool, error)
+}
+
+// NewObjective returns an objective checked by the given function, e.g. on the status codes of the load
+// The function returns the measured value, whether it meets the objective and why it could not be measured.
+func NewObjective(name, threshold string, measure func(r *Result) (string, bool, error)) Objective {
+	return Objective{Name: name, Threshold: threshold, measure: measure}
+}
+
+func (o Objective) evaluate(r *Result) ObjectiveResult {
+	or := ObjectiveResult{Name: o.Name, Threshold: o.Threshold}
+	measured, passed, err := o.measure(r)
+	if err != nil {
+		or.Error = err.Error()
+		return or
+	}
+	or.Measured, or.Passed = measured, passed
+	return or
+}
+
+// P99LatencyBelow requires the 99th percentile of the latencies of the load to be at most the given latency
+func P99LatencyBelow(max time.Duration) Objective {
+	return NewObjective("p99 latency", "<= "+max.String(), func(r *Result) (string, bool, error) {
+		return r.Load.Latencies.P99.String(), r.Load.Latencies.P99 <= max, nil
+	})
+}
+
+// ErrorRateBelow requires the ratio of the failed requests of the load to be at most the given ratio, e.g. 0.01
+func ErrorRateBelow(max float64) Objective {
+	return NewObjective("error rate", fmt.Sprintf("<= %s%%", formatFloat(max*100)), func(r *Result) (string, bool, error) {
+		rate := 1 - r.Load.Success
+		return fmt.Sprintf("%.2f%%", rate*100), rate <= max, nil
+	})
+}
+
+// ThroughputAbove requires the rate of the successful requests of the load to be at least the given rate per second
+func ThroughputAbove(min float64) Objective {
+	return NewObjective("throughput", fmt.Sprintf(">= %s/s", formatFloat(min)), func(r *Result) (string, bool, error) {
+		return formatFloat(r.Load.Throughput) + "/s", r.Load.Throughput >= min, nil
+	})
+}
+
+// MetricBelow requires the value of the metric of the instance after the load to be at most the given value
+// The values of the samples of the metric are summed, see Metrics.Value.
+func MetricBelow(instance, metric string, max float64) Objective {
+	return NewObjective(fmt.Sprintf("%s of %s", metric, instance), "<= "+formatFloat(max), func(r *Result) (string, bool, error) {
+		m, ok := r.Metrics[instance]
+		if !ok {
+			return "", false, ErrMetricsNotScraped.WithParams(instance)
+		}
+		v, ok := m.Value(metric)
+		if !ok {
+			return "", false, ErrMetricNotFound.WithParams(metric, instance)
+		}
+		return formatFloat(v), v <= max, nil
+	})
+}
+
+// MetricIncreaseBelow requires the increase of the metric of the instance during the load to be at most the given
+// value, e.g. for the errors counted by the instance
+func MetricIncreaseBelow(instance, metric string, max float64) Objective {
+	return NewObjective(fmt.Sprintf("increase of %s of %s", metric, instance), "<= "+formatFloat(max), func(r *Result) (string, bool, error) {
+		m, ok := r.Metrics[instance]
+		if !ok {
+			return "", false, ErrMetricsNotScraped.WithParams(instance)
+		}
+		v, ok := m.Increase(metric)
+		if !ok {
+			return "", false, ErrMetricNotFound.WithParams(metric, instance)
+		}
+		return formatFloat(v), v <= max, nil
+	})
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
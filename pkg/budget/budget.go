@@ -0,0 +1,282 @@
+// Package budget enforces a time budget over the lifecycle of the instances of a test, so that a test that runs
+// out of time fails with the phase that overran, e.g. "build exceeded 5m0s", instead of the timeout of the CI job.
+// The budget is carried by the context: the instances bound their build, deploy, readiness and teardown phases by
+// the time left in the budget of their context, and they run as usual with a context without budget.
+package budget
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/celestiaorg/knuu/pkg/clock"
+)
+
+// Phase is a phase of the lifecycle of the instances whose time is accounted by the budget
+type Phase string
+
+const (
+	// PhaseBuild is the build and the push of the images of the instances
+	PhaseBuild Phase = "build"
+	// PhaseDeploy is the deployment of the resources and the pods of the instances
+	PhaseDeploy Phase = "deploy"
+	// PhaseReadiness lasts from the deployment of the instances until they are running
+	PhaseReadiness Phase = "readiness"
+	// PhaseTeardown is the destruction of the instances and the cleanup of the test
+	PhaseTeardown Phase = "teardown"
+)
+
+// phases are the phases in the order they are reported
+var phases = []Phase{PhaseBuild, PhaseDeploy, PhaseReadiness, PhaseTeardown}
+
+// Budget is the time allowed for a test and its allocation to the phases, 0 means unbounded
+// The time of a phase is the wall clock time during which at least one instance is in the phase, so the instances
+// started in parallel share the time of the phase. A phase without allocation is only bounded by the total.
+// The allocation of the teardown is reserved: the other phases must end before the total minus the teardown, and
+// the teardown runs even if the context of the test is done, within its allocation.
+type Budget struct {
+	Total     time.Duration
+	Build     time.Duration
+	Deploy    time.Duration
+	Readiness time.Duration
+	Teardown  time.Duration
+}
+
+// allocation returns the time allocated to the phase, 0 if it is only bounded by the total
+func (b Budget) allocation(phase Phase) time.Duration {
+	switch phase {
+	case PhaseBuild:
+		return b.Build
+	case PhaseDeploy:
+		return b.Deploy
+	case PhaseReadiness:
+		return b.Readiness
+	case PhaseTeardown:
+		return b.Teardown
+	default:
+		return 0
+	}
+}
+
+// Tracker accounts the time spent in each phase against a budget
+type Tracker struct {
+	budget Budget
+	clock  clock.Clock
+	start  time.Time
+
+	mu     sync.Mutex
+	phases map[Phase]*phaseState
+}
+
+// phaseState is the time spent in a phase, which is active while at least one instance is in it
+type phaseState struct {
+	active int
+	// since is the start of the current active period, spent is the time of the previous ones
+	since time.Time
+	spent time.Duration
+}
+
+func (s *phaseState) elapsed(now time.Time) time.Duration {
+	if s.active > 0 {
+		return s.spent + now.Sub(s.since)
+	}
+	return s.spent
+}
+
+type contextKey struct{}
+
+// WithBudget returns a copy of the context carrying a tracker of the given budget, starting now on the clock of the
+// context, which is cancelled when the total budget is spent
+func WithBudget(ctx context.Context, b Budget) (context.Context, context.CancelFunc) {
+	c := clock.FromContext(ctx)
+	t := &Tracker{
+		budget: b,
+		clock:  c,
+		start:  c.Now(),
+		phases: make(map[Phase]*phaseState),
+	}
+	ctx = context.WithValue(ctx, contextKey{}, t)
+	if b.Total <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return clock.WithTimeout(ctx, b.Total)
+}
+
+// FromContext returns the tracker carried by the context, nil if it carries none
+func FromContext(ctx context.Context) *Tracker {
+	t, _ := ctx.Value(contextKey{}).(*Tracker)
+	return t
+}
+
+// Budget returns the budget of the tracker
+func (t *Tracker) Budget() Budget {
+	return t.budget
+}
+
+// Spent returns the time spent in each phase so far
+func (t *Tracker) Spent() map[Phase]time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := t.clock.Now()
+	spent := make(map[Phase]time.Duration, len(phases))
+	for _, phase := range phases {
+		spent[phase] = t.state(phase).elapsed(now)
+	}
+	return spent
+}
+
+// Remaining returns the time left in the total budget, it is negative once the budget is overrun
+func (t *Tracker) Remaining() time.Duration {
+	return t.budget.Total - t.clock.Now().Sub(t.start)
+}
+
+// state returns the state of the phase, the caller must hold the lock
+func (t *Tracker) state(phase Phase) *phaseState {
+	s, ok := t.phases[phase]
+	if !ok {
+		s = &phaseState{}
+		t.phases[phase] = s
+	}
+	return s
+}
+
+// begin enters the phase and returns the time it is allowed to last, whether it is bounded and whether the bound
+// is the one of the phase or the total
+func (t *Tracker) begin(phase Phase) (limit time.Duration, bounded, byPhase bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := t.clock.Now()
+	s := t.state(phase)
+	if s.active == 0 {
+		s.since = now
+	}
+	s.active++
+
+	allocation := t.budget.allocation(phase)
+	if allocation > 0 {
+		limit, bounded, byPhase = allocation-s.elapsed(now), true, true
+	}
+	// the teardown is only bounded by the total if it has no allocation of its own
+	if t.budget.Total > 0 && (phase != PhaseTeardown || allocation <= 0) {
+		total := t.budget.Total - now.Sub(t.start)
+		if phase != PhaseTeardown {
+			total -= t.budget.Teardown
+		}
+		if !bounded || total < limit {
+			limit, bounded, byPhase = total, true, false
+		}
+	}
+	return limit, bounded, byPhase
+}
+
+// end leaves the phase and returns the time it was left
+func (t *Tracker) end(phase Phase) time.Time {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := t.clock.Now()
+	s := t.state(phase)
+	s.active--
+	if s.active == 0 {
+		s.spent += now.Sub(s.since)
+	}
+	return now
+}
+
+// Span is a phase of an operation accounted by the tracker of its context, see Begin
+type Span struct {
+	tracker  *Tracker
+	phase    Phase
+	bounded  bool
+	byPhase  bool
+	deadline time.Time
+	cancel   context.CancelFunc
+}
+
+// Begin enters the phase in the tracker of the context and returns a context bounded by the time left in the
+// budget of the phase, the span must be ended with End
+// It returns the context as is and a nil span, which can be ended, if the context carries no tracker. The context
+// of the teardown is not cancelled with the given one, so the instances are torn down once the test overran.
+func Begin(ctx context.Context, phase Phase) (context.Context, *Span) {
+	t := FromContext(ctx)
+	if t == nil {
+		return ctx, nil
+	}
+	if phase == PhaseTeardown {
+		ctx = context.WithoutCancel(ctx)
+	}
+	limit, bounded, byPhase := t.begin(phase)
+	s := &Span{tracker: t, phase: phase, bounded: bounded, byPhase: byPhase}
+	if !bounded {
+		ctx, s.cancel = context.WithCancel(ctx)
+		return ctx, s
+	}
+	if limit < 0 {
+		limit = 0
+	}
+	s.deadline = t.clock.Now().Add(limit)
+	ctx, s.cancel = clock.WithTimeout(ctx, limit)
+	return ctx, s
+}
+
+// End leaves the phase and returns the error of the operation, as an *OverrunError if the operation failed once
+// the budget of the phase was spent
+func (s *Span) End(err error) error {
+	if s == nil {
+		return err
+	}
+	s.cancel()
+	now := s.tracker.end(s.phase)
+	if err == nil || !s.bounded || now.Before(s.deadline) {
+		return err
+	}
+
+	overrun := &OverrunError{
+		Phase: s.phase,
+		Limit: s.tracker.budget.Total,
+		Total: !s.byPhase,
+		Spent: s.tracker.Spent(),
+		Err:   err,
+	}
+	if s.byPhase {
+		overrun.Limit = s.tracker.budget.allocation(s.phase)
+	}
+	logrus.Warnf("Time budget overrun: %s", overrun.summary())
+	return overrun
+}
+
+// OverrunError is the error of an operation that failed because the budget of its phase, or the total budget, was
+// spent, it reports the time spent in each phase
+type OverrunError struct {
+	Phase Phase
+	// Limit is the allocation of the phase, or the total budget if Total is true
+	Limit time.Duration
+	Total bool
+	Spent map[Phase]time.Duration
+	// Err is the error the operation failed with
+	Err error
+}
+
+// summary describes the overrun and the time spent in each phase
+func (e *OverrunError) summary() string {
+	spent := make([]string, 0, len(phases))
+	for _, phase := range phases {
+		spent = append(spent, fmt.Sprintf("%s %s", phase, e.Spent[phase].Round(time.Millisecond)))
+	}
+	if e.Total {
+		return fmt.Sprintf("budget of %s exceeded during %s (spent: %s)", e.Limit, e.Phase, strings.Join(spent, ", "))
+	}
+	return fmt.Sprintf("%s exceeded %s (spent: %s)", e.Phase, e.Limit, strings.Join(spent, ", "))
+}
+
+func (e *OverrunError) Error() string {
+	return e.summary() + ": " + e.Err.Error()
+}
+
+// Unwrap returns the error the operation failed with
+func (e *OverrunError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,98 @@
+package budget
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/celestiaorg/knuu/pkg/clock"
+)
+
+func isDone(ctx context.Context) func() bool {
+	return func() bool { return ctx.Err() != nil }
+}
+
+func TestBeginWithoutBudget(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	phaseCtx, span := Begin(ctx, PhaseBuild)
+	assert.Equal(t, ctx, phaseCtx)
+	assert.Nil(t, span)
+	err := errors.New("failed")
+	assert.Equal(t, err, span.End(err))
+}
+
+func TestPhaseOverrun(t *testing.T) {
+	t.Parallel()
+
+	fake := clock.NewFake(time.Unix(0, 0))
+	ctx, cancel := WithBudget(clock.WithClock(context.Background(), fake), Budget{Total: time.Hour, Build: 5 * time.Minute})
+	defer cancel()
+
+	// a build within its allocation is not an overrun
+	buildCtx, span := Begin(ctx, PhaseBuild)
+	fake.Step(3 * time.Minute)
+	require.NoError(t, buildCtx.Err())
+	err := errors.New("failed")
+	assert.Equal(t, err, span.End(err))
+
+	buildCtx, span = Begin(ctx, PhaseBuild)
+	fake.Step(2 * time.Minute)
+	require.Eventually(t, isDone(buildCtx), time.Second, time.Millisecond)
+	require.NoError(t, ctx.Err())
+
+	err = span.End(context.Cause(buildCtx))
+	var overrun *OverrunError
+	require.ErrorAs(t, err, &overrun)
+	assert.Equal(t, PhaseBuild, overrun.Phase)
+	assert.False(t, overrun.Total)
+	assert.Equal(t, 5*time.Minute, overrun.Limit)
+	assert.Equal(t, 5*time.Minute, overrun.Spent[PhaseBuild])
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Contains(t, err.Error(), "build exceeded 5m0s (spent: build 5m0s, deploy 0s, readiness 0s, teardown 0s)")
+}
+
+func TestTotalOverrun(t *testing.T) {
+	t.Parallel()
+
+	fake := clock.NewFake(time.Unix(0, 0))
+	ctx, cancel := WithBudget(clock.WithClock(context.Background(), fake), Budget{Total: 10 * time.Minute, Teardown: 2 * time.Minute})
+	defer cancel()
+
+	// the instances deployed in parallel share the time of the phase
+	deployA, spanA := Begin(ctx, PhaseDeploy)
+	fake.Step(time.Minute)
+	deployB, spanB := Begin(ctx, PhaseDeploy)
+	fake.Step(time.Minute)
+	require.NoError(t, spanA.End(deployA.Err()))
+	fake.Step(time.Minute)
+	require.NoError(t, spanB.End(deployB.Err()))
+	assert.Equal(t, 3*time.Minute, FromContext(ctx).Spent()[PhaseDeploy])
+
+	// the teardown is reserved, so the readiness is bounded by the 5m left before it
+	readyCtx, span := Begin(ctx, PhaseReadiness)
+	fake.Step(5 * time.Minute)
+	require.Eventually(t, isDone(readyCtx), time.Second, time.Millisecond)
+	var overrun *OverrunError
+	require.ErrorAs(t, span.End(readyCtx.Err()), &overrun)
+	assert.True(t, overrun.Total)
+	assert.Equal(t, PhaseReadiness, overrun.Phase)
+	assert.Contains(t, overrun.Error(), "budget of 10m0s exceeded during readiness")
+
+	// the teardown runs within its allocation once the test overran
+	fake.Step(2 * time.Minute)
+	require.Eventually(t, isDone(ctx), time.Second, time.Millisecond)
+	teardownCtx, span := Begin(ctx, PhaseTeardown)
+	require.NoError(t, teardownCtx.Err())
+	fake.Step(2 * time.Minute)
+	require.Eventually(t, isDone(teardownCtx), time.Second, time.Millisecond)
+	require.ErrorAs(t, span.End(teardownCtx.Err()), &overrun)
+	assert.Equal(t, PhaseTeardown, overrun.Phase)
+	assert.False(t, overrun.Total)
+	assert.Equal(t, -2*time.Minute, FromContext(ctx).Remaining())
+}
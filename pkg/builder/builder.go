@@ -11,6 +11,13 @@ type Builder interface {
 	Build(ctx context.Context, b *BuilderOptions) (logs string, err error)
 }
 
+// ImageLoader loads the archive of an image onto the nodes of the cluster, so that the images built by knuu
+// are run without being pushed to a registry, e.g. on air-gapped clusters
+// The archive is a tarball written by docker save, in the OCI or the docker format, which keeps the name of the image.
+type ImageLoader interface {
+	LoadImage(ctx context.Context, archive string) error
+}
+
 type BuilderOptions struct {
 	ImageName    string
 	BuildContext string
@@ -0,0 +1,96 @@
+// Package cosign signs and verifies images with the cosign CLI
+package cosign
+
+import (
+	"bytes"
+	"context"
+	"os/exec"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/celestiaorg/knuu/pkg/builder"
+)
+
+const defaultBinary = "cosign"
+
+// Cosign signs the images pushed by knuu and verifies the images the instances are based on
+// The keys are the references accepted by cosign: a file, a KMS URI or a kubernetes secret (k8s://namespace/name).
+// The password of the signing key is read by cosign from the COSIGN_PASSWORD environment variable.
+type Cosign struct {
+	binary    string
+	key       string
+	publicKey string
+}
+
+var (
+	_ builder.ImageSigner   = &Cosign{}
+	_ builder.ImageVerifier = &Cosign{}
+)
+
+// Option configures Cosign
+type Option func(*Cosign)
+
+// WithBinary sets the path of the cosign binary, 'cosign' from the PATH by default
+func WithBinary(path string) Option {
+	return func(c *Cosign) {
+		c.binary = path
+	}
+}
+
+// WithKey sets the key used to sign the images
+func WithKey(key string) Option {
+	return func(c *Cosign) {
+		c.key = key
+	}
+}
+
+// WithPublicKey sets the public key used to verify the images
+func WithPublicKey(key string) Option {
+	return func(c *Cosign) {
+		c.publicKey = key
+	}
+}
+
+// New returns a signer and verifier of images using the cosign CLI
+func New(opts ...Option) *Cosign {
+	c := &Cosign{binary: defaultBinary}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// SignImage signs the given image and pushes the signature to its registry
+func (c *Cosign) SignImage(ctx context.Context, imageName string) error {
+	if c.key == "" {
+		return ErrSigningKeyNotSet
+	}
+	if output, err := c.run(ctx, "sign", "--yes", "--key", c.key, imageName); err != nil {
+		return ErrSigningImage.WithParams(imageName, output).Wrap(err)
+	}
+	logrus.Debugf("Signed image '%s'", imageName)
+	return nil
+}
+
+// VerifyImage verifies that the given image is signed by the public key
+func (c *Cosign) VerifyImage(ctx context.Context, imageName string) error {
+	if c.publicKey == "" {
+		return ErrPublicKeyNotSet
+	}
+	if output, err := c.run(ctx, "verify", "--key", c.publicKey, imageName); err != nil {
+		return ErrVerifyingSignature.WithParams(imageName, output).Wrap(err)
+	}
+	logrus.Debugf("Verified the signature of image '%s'", imageName)
+	return nil
+}
+
+// run runs cosign with the given arguments and returns its combined output
+func (c *Cosign) run(ctx context.Context, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, c.binary, args...)
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	err := cmd.Run()
+	return strings.TrimSpace(output.String()), err
+}
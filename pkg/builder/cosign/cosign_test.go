@@ -0,0 +1,44 @@
+package cosign
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeCosign writes a script that records its arguments and fails for the images named 'unsigned'
+func fakeCosign(t *testing.T) (binary, calls string) {
+	dir := t.TempDir()
+	binary = filepath.Join(dir, "cosign")
+	calls = filepath.Join(dir, "calls")
+	script := "#!/bin/sh\necho \"$@\" >> " + calls + "\ncase \"$@\" in *unsigned*) echo 'no matching signatures'; exit 1;; esac\n"
+	require.NoError(t, os.WriteFile(binary, []byte(script), 0o755))
+	return binary, calls
+}
+
+func TestCosign(t *testing.T) {
+	t.Parallel()
+
+	binary, calls := fakeCosign(t)
+	ctx := context.Background()
+	c := New(WithBinary(binary), WithKey("k8s://ci/cosign"), WithPublicKey("cosign.pub"))
+
+	require.NoError(t, c.SignImage(ctx, "ttl.sh/image:24h"))
+	require.NoError(t, c.VerifyImage(ctx, "ghcr.io/celestiaorg/celestia-app:v1"))
+	err := c.VerifyImage(ctx, "ghcr.io/unsigned:v1")
+	assert.ErrorIs(t, err, ErrVerifyingSignature)
+	assert.ErrorContains(t, err, "no matching signatures")
+
+	content, err := os.ReadFile(calls)
+	require.NoError(t, err)
+	assert.Equal(t, "sign --yes --key k8s://ci/cosign ttl.sh/image:24h\n"+
+		"verify --key cosign.pub ghcr.io/celestiaorg/celestia-app:v1\n"+
+		"verify --key cosign.pub ghcr.io/unsigned:v1\n", string(content))
+
+	assert.ErrorIs(t, New().SignImage(ctx, "ttl.sh/image:24h"), ErrSigningKeyNotSet)
+	assert.ErrorIs(t, New().VerifyImage(ctx, "ttl.sh/image:24h"), ErrPublicKeyNotSet)
+}
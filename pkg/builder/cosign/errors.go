@@ -0,0 +1,14 @@
+package cosign
+
+import (
+	"github.com/celestiaorg/knuu/pkg/errors"
+)
+
+type Error = errors.Error
+
+var (
+	ErrSigningKeyNotSet   = errors.New("SigningKeyNotSet", "the key to sign images is not set")
+	ErrPublicKeyNotSet    = errors.New("PublicKeyNotSet", "the public key to verify images is not set")
+	ErrSigningImage       = errors.New("SigningImage", "failed to sign image %s: %s")
+	ErrVerifyingSignature = errors.New("VerifyingSignature", "failed to verify the signature of image %s: %s")
+)
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
 
 	"github.com/sirupsen/logrus"
@@ -17,11 +18,13 @@ import (
 type Docker struct {
 	K8sClientset kubernetes.Interface
 	K8sNamespace string
+	// Loader loads the built images onto the nodes instead of pushing them, nil to push them
+	Loader builder.ImageLoader
 }
 
 var _ builder.Builder = &Docker{}
 
-func (d *Docker) Build(_ context.Context, b *builder.BuilderOptions) (logs string, err error) {
+func (d *Docker) Build(ctx context.Context, b *builder.BuilderOptions) (logs string, err error) {
 	if builder.IsGitContext(b.BuildContext) {
 		return "", ErrGitContextNotSupported
 	}
@@ -57,14 +60,20 @@ func (d *Docker) Build(_ context.Context, b *builder.BuilderOptions) (logs strin
 	logrus.Debug("built docker image: ", b.Destination)
 	logrus.Debug("logs: ", cmdLogs)
 
-	cmd = exec.Command("docker", "push", b.Destination)
-	cmdLogs, err = runCommand(cmd)
-	if err != nil {
-		return "", ErrFailedToPushImage.Wrap(err)
+	if d.Loader != nil {
+		if err := d.loadImage(ctx, b.Destination); err != nil {
+			return "", err
+		}
+	} else {
+		cmd = exec.Command("docker", "push", b.Destination)
+		cmdLogs, err = runCommand(cmd)
+		if err != nil {
+			return "", ErrFailedToPushImage.Wrap(err)
+		}
+		logs += cmdLogs + "\n"
+		logrus.Debug("pushed docker image: ", b.Destination)
+		logrus.Debug("logs: ", cmdLogs)
 	}
-	logs += cmdLogs + "\n"
-	logrus.Debug("pushed docker image: ", b.Destination)
-	logrus.Debug("logs: ", cmdLogs)
 
 	if err := os.RemoveAll(b.BuildContext); err != nil {
 		return "", ErrFailedToRemoveContextDir.Wrap(err)
@@ -73,6 +82,26 @@ func (d *Docker) Build(_ context.Context, b *builder.BuilderOptions) (logs strin
 	return logs, nil
 }
 
+// loadImage saves the image into an archive and loads it onto the nodes with the loader
+func (d *Docker) loadImage(ctx context.Context, image string) error {
+	dir, err := os.MkdirTemp("", "knuu-image-*")
+	if err != nil {
+		return ErrFailedToSaveImage.Wrap(err)
+	}
+	defer os.RemoveAll(dir)
+
+	archive := filepath.Join(dir, "image.tar")
+	cmd := exec.Command("docker", "save", "--output", archive, image)
+	if _, err := runCommand(cmd); err != nil {
+		return ErrFailedToSaveImage.Wrap(err)
+	}
+	if err := d.Loader.LoadImage(ctx, archive); err != nil {
+		return ErrFailedToLoadImage.Wrap(err)
+	}
+	logrus.Debug("loaded docker image onto the nodes: ", image)
+	return nil
+}
+
 func runCommand(cmd *exec.Cmd) (logs string, err error) {
 	var stdout, stderr bytes.Buffer
 	cmd.Stdout = &stdout
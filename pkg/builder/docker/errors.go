@@ -14,4 +14,6 @@ var (
 	ErrFailedToPushImage          = errors.New("FailedToPushImage", "failed to push image")
 	ErrFailedToRemoveContextDir   = errors.New("FailedToRemoveContextDir", "failed to remove context directory")
 	ErrGitContextNotSupported     = errors.New("GitContextNotSupported", "git context is not supported in the docker builder")
+	ErrFailedToSaveImage          = errors.New("FailedToSaveImage", "failed to save image to an archive")
+	ErrFailedToLoadImage          = errors.New("FailedToLoadImage", "failed to load image onto the nodes")
 )
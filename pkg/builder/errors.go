@@ -8,4 +8,5 @@ type Error = errors.Error
 
 var (
 	ErrBuildContextEmpty = errors.New("BuildContextEmpty", "build context cannot be empty")
+	ErrImageRejected     = errors.New("ImageRejected", "image %s rejected by %s: %s")
 )
@@ -0,0 +1,48 @@
+package builder
+
+import (
+	"bytes"
+	"context"
+	"os/exec"
+	"strings"
+)
+
+// PostBuildHook checks the images built and pushed by knuu, e.g. to scan them with trivy or grype
+// An error rejects the image, so the commit of the instance fails.
+type PostBuildHook interface {
+	CheckImage(ctx context.Context, imageName string) error
+}
+
+// PostBuildHookFunc is a function used as a PostBuildHook
+type PostBuildHookFunc func(ctx context.Context, imageName string) error
+
+func (f PostBuildHookFunc) CheckImage(ctx context.Context, imageName string) error {
+	return f(ctx, imageName)
+}
+
+// CommandHook returns a hook that runs the given command with the name of the image as last argument
+// The image is rejected if the command exits with a non-zero code, e.g.:
+//
+//	CommandHook("trivy", "image", "--exit-code", "1", "--severity", "CRITICAL")
+func CommandHook(name string, args ...string) PostBuildHook {
+	return PostBuildHookFunc(func(ctx context.Context, imageName string) error {
+		cmd := exec.CommandContext(ctx, name, append(append([]string(nil), args...), imageName)...)
+		var output bytes.Buffer
+		cmd.Stdout = &output
+		cmd.Stderr = &output
+		if err := cmd.Run(); err != nil {
+			return ErrImageRejected.WithParams(imageName, name, strings.TrimSpace(output.String())).Wrap(err)
+		}
+		return nil
+	})
+}
+
+// ImageSigner signs the images built and pushed by knuu, so that the clusters requiring signed images run them
+type ImageSigner interface {
+	SignImage(ctx context.Context, imageName string) error
+}
+
+// ImageVerifier verifies the signature of the images the instances are based on
+type ImageVerifier interface {
+	VerifyImage(ctx context.Context, imageName string) error
+}
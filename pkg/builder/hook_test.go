@@ -0,0 +1,20 @@
+package builder
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommandHook(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	require.NoError(t, CommandHook("sh", "-c", `test "$0" = registry/image:v1`).CheckImage(ctx, "registry/image:v1"))
+
+	err := CommandHook("sh", "-c", `echo "CRITICAL: 2 in $0"; exit 1`).CheckImage(ctx, "registry/image:v1")
+	assert.ErrorIs(t, err, ErrImageRejected)
+	assert.ErrorContains(t, err, "CRITICAL: 2 in registry/image:v1")
+}
@@ -6,6 +6,7 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
+	"strings"
 
 	batchv1 "k8s.io/api/batch/v1"
 	v1 "k8s.io/api/core/v1"
@@ -33,12 +34,34 @@ const (
 type Kaniko struct {
 	K8sClientset kubernetes.Interface
 	K8sNamespace string
-	Minio        *minio.Minio // Minio service to store the build context if it's a directory
+	Minio        minio.Client // Minio service to store the build context if it's a directory
 	ContentName  string       // Name of the content pushed to Minio
+
+	// RegistryAliases maps the registries of the destinations to the registries kaniko pushes to with http,
+	// e.g. the in-cluster registry that the nodes pull from on localhost but the pods reach by its service
+	RegistryAliases map[string]string
 }
 
 var _ builder.Builder = &Kaniko{}
 
+// New returns a builder that runs kaniko jobs in the given namespace
+// The minio client stores the build contexts that are directories, it can be nil if they are not used.
+func New(clientset kubernetes.Interface, namespace string, minioCli minio.Client) *Kaniko {
+	return &Kaniko{
+		K8sClientset: clientset,
+		K8sNamespace: namespace,
+		Minio:        minioCli,
+	}
+}
+
+// AddRegistryAlias pushes the images of the given registry to the alias, which is reached with http
+func (k *Kaniko) AddRegistryAlias(registry, alias string) {
+	if k.RegistryAliases == nil {
+		k.RegistryAliases = make(map[string]string)
+	}
+	k.RegistryAliases[registry] = alias
+}
+
 func (k *Kaniko) Build(ctx context.Context, b *builder.BuilderOptions) (logs string, err error) {
 	job, err := k.prepareJob(ctx, b)
 	if err != nil {
@@ -179,6 +202,8 @@ func (k *Kaniko) prepareJob(ctx context.Context, b *builder.BuilderOptions) (*ba
 		return nil, ErrParsingQuantity.Wrap(err)
 	}
 
+	destination, insecureRegistry := k.pushDestination(b.Destination)
+
 	parallelism := DefaultParallelism
 	backoffLimit := DefaultBackoffLimit
 	job := &batchv1.Job{
@@ -200,7 +225,7 @@ func (k *Kaniko) prepareJob(ctx context.Context, b *builder.BuilderOptions) (*ba
 								// --git gitoptions    Branch to clone if build context is a git repository (default branch=,single-branch=false,recurse-submodules=false)
 
 								// TODO: we might need to add some options to get the auth token for the registry
-								"--destination=" + b.Destination,
+								"--destination=" + destination,
 								// "--verbosity=debug", // log level
 							},
 							Resources: v1.ResourceRequirements{
@@ -235,12 +260,27 @@ func (k *Kaniko) prepareJob(ctx context.Context, b *builder.BuilderOptions) (*ba
 		job.Spec.Template.Spec.Containers[0].Args = append(job.Spec.Template.Spec.Containers[0].Args, cacheArgs...)
 	}
 
+	if insecureRegistry != "" {
+		job.Spec.Template.Spec.Containers[0].Args = append(job.Spec.Template.Spec.Containers[0].Args,
+			"--insecure-registry="+insecureRegistry)
+	}
+
 	// Add extra args
 	job.Spec.Template.Spec.Containers[0].Args = append(job.Spec.Template.Spec.Containers[0].Args, b.Args...)
 
 	return job, nil
 }
 
+// pushDestination returns the destination with the alias of its registry and the alias, if it has one
+func (k *Kaniko) pushDestination(destination string) (string, string) {
+	for registry, alias := range k.RegistryAliases {
+		if strings.HasPrefix(destination, registry+"/") {
+			return alias + strings.TrimPrefix(destination, registry), alias
+		}
+	}
+	return destination, ""
+}
+
 // mountDir mounts the build context directory to the Kaniko container
 // Since we cannot really mount a local directory to a k8s Pod,
 // we create a tar.gz archive of the directory and upload it to Minio
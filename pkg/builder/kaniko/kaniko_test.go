@@ -2,6 +2,7 @@ package kaniko
 
 import (
 	"context"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -161,3 +162,29 @@ func TestGetDefaultCacheOptions(t *testing.T) {
 		})
 	}
 }
+
+func TestRegistryAlias(t *testing.T) {
+	t.Parallel()
+
+	kb := New(fake.NewSimpleClientset(), k8sNamespace, nil)
+	kb.AddRegistryAlias("localhost:30500", "registry.knuu-registry.svc.cluster.local:5000")
+
+	job, err := kb.prepareJob(context.Background(), &builder.BuilderOptions{
+		BuildContext: "git://github.com/mojtaba-esk/sample-docker",
+		Destination:  "localhost:30500/test-image:latest",
+	})
+	require.NoError(t, err)
+	args := job.Spec.Template.Spec.Containers[0].Args
+	assert.Contains(t, args, "--destination=registry.knuu-registry.svc.cluster.local:5000/test-image:latest")
+	assert.Contains(t, args, "--insecure-registry=registry.knuu-registry.svc.cluster.local:5000")
+
+	// the other registries are pushed to as is
+	job, err = kb.prepareJob(context.Background(), &builder.BuilderOptions{
+		BuildContext: "git://github.com/mojtaba-esk/sample-docker",
+		Destination:  "ttl.sh/test-image:24h",
+	})
+	require.NoError(t, err)
+	args = job.Spec.Template.Spec.Containers[0].Args
+	assert.Contains(t, args, "--destination=ttl.sh/test-image:24h")
+	assert.NotContains(t, strings.Join(args, " "), "--insecure-registry")
+}
@@ -0,0 +1,83 @@
+package kaniko
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/celestiaorg/knuu/pkg/builder"
+	"github.com/celestiaorg/knuu/pkg/minio"
+)
+
+// fakeMinio keeps the pushed files in memory
+type fakeMinio struct {
+	minio.Client
+	deployed bool
+	files    map[string][]byte
+}
+
+func (f *fakeMinio) DeployMinio(_ context.Context) error {
+	f.deployed = true
+	return nil
+}
+
+func (f *fakeMinio) PushToMinio(_ context.Context, localReader io.Reader, minioFilePath, bucketName string) error {
+	data, err := io.ReadAll(localReader)
+	if err != nil {
+		return err
+	}
+	if f.files == nil {
+		f.files = make(map[string][]byte)
+	}
+	f.files[bucketName+"/"+minioFilePath] = data
+	return nil
+}
+
+func (f *fakeMinio) GetMinioURL(_ context.Context, minioFilePath, bucketName string) (string, error) {
+	return "http://minio.test/" + bucketName + "/" + minioFilePath, nil
+}
+
+func TestPrepareJobWithDirContext(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "Dockerfile"), []byte("FROM alpine"), 0o644))
+
+	m := &fakeMinio{}
+	kb := New(fake.NewSimpleClientset(), k8sNamespace, m)
+
+	job, err := kb.prepareJob(context.Background(), &builder.BuilderOptions{
+		ImageName:    "test-image",
+		BuildContext: builder.DirContext{Path: dir}.BuildContext(),
+		Destination:  "registry.example.com/test-image:latest",
+	})
+	require.NoError(t, err)
+
+	assert.True(t, m.deployed)
+	require.Contains(t, m.files, MinioBucketName+"/"+kb.ContentName)
+	assert.NotEmpty(t, m.files[MinioBucketName+"/"+kb.ContentName])
+
+	spec := job.Spec.Template.Spec
+	require.Len(t, spec.InitContainers, 1)
+	assert.Contains(t, spec.InitContainers[0].Args[0], "http://minio.test/"+MinioBucketName+"/"+kb.ContentName)
+	assert.Contains(t, spec.Containers[0].Args, "--context=tar:///workspace/archive.tar.gz")
+}
+
+func TestPrepareJobWithDirContextWithoutMinio(t *testing.T) {
+	t.Parallel()
+
+	kb := New(fake.NewSimpleClientset(), k8sNamespace, nil)
+	_, err := kb.prepareJob(context.Background(), &builder.BuilderOptions{
+		ImageName:    "test-image",
+		BuildContext: builder.DirContext{Path: t.TempDir()}.BuildContext(),
+		Destination:  "registry.example.com/test-image:latest",
+	})
+	assert.ErrorIs(t, err, ErrMountingDir)
+	assert.ErrorContains(t, err, ErrMinioNotConfigured.Error())
+}
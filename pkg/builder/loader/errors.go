@@ -0,0 +1,14 @@
+package loader
+
+import (
+	"github.com/celestiaorg/knuu/pkg/errors"
+)
+
+type Error = errors.Error
+
+var (
+	ErrOpeningArchive      = errors.New("OpeningArchive", "error opening the image archive '%s'")
+	ErrUploadingArchive    = errors.New("UploadingArchive", "error uploading the image archive '%s'")
+	ErrCreatingDaemonSet   = errors.New("CreatingDaemonSet", "error creating the daemon set '%s' loading the image archive")
+	ErrWaitingForDaemonSet = errors.New("WaitingForDaemonSet", "error waiting for the daemon set '%s' to load the image archive on the nodes")
+)
@@ -0,0 +1,192 @@
+// Package loader loads the images built by knuu onto the nodes of the cluster, so that they are not pushed to a registry
+package loader
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	appv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/utils/ptr"
+
+	"github.com/celestiaorg/knuu/pkg/builder"
+	"github.com/celestiaorg/knuu/pkg/minio"
+	"github.com/celestiaorg/knuu/pkg/names"
+	"github.com/celestiaorg/knuu/pkg/retry"
+)
+
+const (
+	MinioBucketName = "images"
+
+	daemonSetPrefix = "knuu-image-loader"
+
+	archiveDir      = "/archive"
+	archivePath     = archiveDir + "/image.tar"
+	archiveVolName  = "archive"
+	hostDir         = "/host"
+	hostVolName     = "host"
+	containerdSpace = "k8s.io"
+)
+
+// Images are the images of the containers of the daemon sets, e.g. to pull them from a mirror
+type Images struct {
+	// Download downloads the archive, it must have curl
+	Download string
+	// Import imports the archive on the node, it must have sh and chroot
+	Import string
+	// Pause keeps the pods running once the archive is imported
+	Pause string
+}
+
+// DefaultImages are the images of the daemon sets unless set with SetImages
+var DefaultImages = Images{
+	Download: "docker.io/curlimages/curl:8.7.1",
+	Import:   "docker.io/library/busybox:1.36",
+	Pause:    "registry.k8s.io/pause:3.9",
+}
+
+// loadedPolicy bounds the time to download and import the archive on every node
+var loadedPolicy = retry.Constant(1 * time.Second).WithTimeout(10 * time.Minute)
+
+// DaemonSet loads the archives of the images onto the nodes with a privileged daemon set importing them
+// into the containerd of the nodes with ctr, so it needs the nodes to run containerd and ctr
+// The archive is uploaded to minio and downloaded by the pods of the daemon set, which is deleted once the
+// archive is imported on every node. The nodes joining the cluster afterwards do not have the image.
+type DaemonSet struct {
+	clientset kubernetes.Interface
+	namespace string
+	minio     minio.Client
+	images    Images
+}
+
+var _ builder.ImageLoader = &DaemonSet{}
+
+// NewDaemonSet returns a loader running its daemon sets in the given namespace
+func NewDaemonSet(clientset kubernetes.Interface, namespace string, minioCli minio.Client) *DaemonSet {
+	return &DaemonSet{clientset: clientset, namespace: namespace, minio: minioCli, images: DefaultImages}
+}
+
+// SetImages sets the images of the containers of the daemon sets, the empty ones are left as is
+func (d *DaemonSet) SetImages(images Images) {
+	if images.Download != "" {
+		d.images.Download = images.Download
+	}
+	if images.Import != "" {
+		d.images.Import = images.Import
+	}
+	if images.Pause != "" {
+		d.images.Pause = images.Pause
+	}
+}
+
+// LoadImage loads the image archive onto every node of the cluster
+func (d *DaemonSet) LoadImage(ctx context.Context, archive string) error {
+	name, err := names.NewRandomK8(daemonSetPrefix)
+	if err != nil {
+		return ErrCreatingDaemonSet.WithParams(daemonSetPrefix).Wrap(err)
+	}
+
+	file, err := os.Open(archive)
+	if err != nil {
+		return ErrOpeningArchive.WithParams(archive).Wrap(err)
+	}
+	defer file.Close()
+
+	if err := d.minio.DeployMinio(ctx); err != nil {
+		return ErrUploadingArchive.WithParams(archive).Wrap(err)
+	}
+	if err := d.minio.PushToMinio(ctx, file, name, MinioBucketName); err != nil {
+		return ErrUploadingArchive.WithParams(archive).Wrap(err)
+	}
+	defer func() {
+		if err := d.minio.DeleteFromMinio(ctx, name, MinioBucketName); err != nil {
+			logrus.Warnf("Error deleting the image archive '%s' from minio: %v", name, err)
+		}
+	}()
+	url, err := d.minio.GetMinioURL(ctx, name, MinioBucketName)
+	if err != nil {
+		return ErrUploadingArchive.WithParams(archive).Wrap(err)
+	}
+
+	daemonSets := d.clientset.AppsV1().DaemonSets(d.namespace)
+	if _, err := daemonSets.Create(ctx, d.daemonSet(name, url), metav1.CreateOptions{}); err != nil {
+		return ErrCreatingDaemonSet.WithParams(name).Wrap(err)
+	}
+	defer func() {
+		err := daemonSets.Delete(ctx, name, metav1.DeleteOptions{PropagationPolicy: ptr.To(metav1.DeletePropagationBackground)})
+		if err != nil {
+			logrus.Warnf("Error deleting the daemon set '%s': %v", name, err)
+		}
+	}()
+
+	err = retry.Until(ctx, loadedPolicy, func(ctx context.Context) (bool, error) {
+		ds, err := daemonSets.Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		return loaded(ds), nil
+	})
+	if err != nil {
+		return ErrWaitingForDaemonSet.WithParams(name).Wrap(err)
+	}
+	logrus.Debugf("Loaded the image archive '%s' on the nodes", archive)
+	return nil
+}
+
+// loaded returns true once the pods of every node imported the archive, their init containers being done
+func loaded(ds *appv1.DaemonSet) bool {
+	return ds.Status.ObservedGeneration >= ds.Generation &&
+		ds.Status.DesiredNumberScheduled > 0 &&
+		ds.Status.NumberReady == ds.Status.DesiredNumberScheduled
+}
+
+// daemonSet returns the daemon set downloading the archive from the given url and importing it on every node
+func (d *DaemonSet) daemonSet(name, url string) *appv1.DaemonSet {
+	labels := map[string]string{"app": name, "k8s.kubernetes.io/managed-by": "knuu"}
+	archiveMount := v1.VolumeMount{Name: archiveVolName, MountPath: archiveDir}
+	return &appv1.DaemonSet{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: d.namespace, Labels: labels},
+		Spec: appv1.DaemonSetSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: labels},
+			Template: v1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec: v1.PodSpec{
+					InitContainers: []v1.Container{
+						{
+							Name:            "download",
+							Image:           d.images.Download,
+							ImagePullPolicy: v1.PullIfNotPresent,
+							Command:         []string{"curl", "--fail", "--silent", "--show-error", "--location", "--output", archivePath, url},
+							VolumeMounts:    []v1.VolumeMount{archiveMount},
+						},
+						{
+							// the archive is read before the chroot into the root of the node, which has ctr
+							Name:            "import",
+							Image:           d.images.Import,
+							ImagePullPolicy: v1.PullIfNotPresent,
+							Command: []string{"sh", "-c", fmt.Sprintf("chroot %s ctr --namespace %s images import - < %s",
+								hostDir, containerdSpace, archivePath)},
+							SecurityContext: &v1.SecurityContext{Privileged: ptr.To(true)},
+							VolumeMounts: []v1.VolumeMount{
+								archiveMount,
+								{Name: hostVolName, MountPath: hostDir},
+							},
+						},
+					},
+					Containers: []v1.Container{{Name: "pause", Image: d.images.Pause, ImagePullPolicy: v1.PullIfNotPresent}},
+					// the images are loaded on the tainted nodes too, e.g. the control plane of a single node cluster
+					Tolerations: []v1.Toleration{{Operator: v1.TolerationOpExists}},
+					Volumes: []v1.Volume{
+						{Name: archiveVolName, VolumeSource: v1.VolumeSource{EmptyDir: &v1.EmptyDirVolumeSource{}}},
+						{Name: hostVolName, VolumeSource: v1.VolumeSource{HostPath: &v1.HostPathVolumeSource{Path: "/"}}},
+					},
+				},
+			},
+		},
+	}
+}
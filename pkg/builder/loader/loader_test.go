@@ -0,0 +1,106 @@
+package loader
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/celestiaorg/knuu/pkg/minio"
+)
+
+// fakeMinio keeps the pushed files in memory
+type fakeMinio struct {
+	minio.Client
+	files map[string][]byte
+}
+
+func (f *fakeMinio) DeployMinio(_ context.Context) error {
+	return nil
+}
+
+func (f *fakeMinio) PushToMinio(_ context.Context, localReader io.Reader, minioFilePath, bucketName string) error {
+	data, err := io.ReadAll(localReader)
+	if err != nil {
+		return err
+	}
+	f.files[bucketName+"/"+minioFilePath] = data
+	return nil
+}
+
+func (f *fakeMinio) GetMinioURL(_ context.Context, minioFilePath, bucketName string) (string, error) {
+	return "http://minio.test/" + bucketName + "/" + minioFilePath, nil
+}
+
+func (f *fakeMinio) DeleteFromMinio(_ context.Context, minioFilePath, bucketName string) error {
+	delete(f.files, bucketName+"/"+minioFilePath)
+	return nil
+}
+
+func TestDaemonSet(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	archive := filepath.Join(t.TempDir(), "image.tar")
+	require.NoError(t, os.WriteFile(archive, []byte("image"), 0o644))
+
+	clientset := fake.NewSimpleClientset()
+	m := &fakeMinio{files: make(map[string][]byte)}
+	l := NewDaemonSet(clientset, "test", m)
+
+	// the images are imported once the pods of the daemon set are ready
+	go func() {
+		for ctx.Err() == nil {
+			list, err := clientset.AppsV1().DaemonSets("test").List(ctx, metav1.ListOptions{})
+			if err == nil && len(list.Items) == 1 {
+				ds := &list.Items[0]
+				assert.Len(t, m.files, 1)
+				assert.True(t, strings.HasPrefix(ds.Name, daemonSetPrefix))
+				download := ds.Spec.Template.Spec.InitContainers[0]
+				assert.Equal(t, "http://minio.test/images/"+ds.Name, download.Command[len(download.Command)-1])
+				assert.Contains(t, ds.Spec.Template.Spec.InitContainers[1].Command[2], "chroot /host ctr --namespace k8s.io images import -")
+
+				ds.Status.DesiredNumberScheduled = 2
+				ds.Status.NumberReady = 2
+				_, _ = clientset.AppsV1().DaemonSets("test").UpdateStatus(ctx, ds, metav1.UpdateOptions{})
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}()
+	require.NoError(t, l.LoadImage(ctx, archive))
+
+	// the daemon set and the uploaded archive are deleted once the image is loaded
+	list, err := clientset.AppsV1().DaemonSets("test").List(ctx, metav1.ListOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, list.Items)
+	assert.Empty(t, m.files)
+
+	assert.ErrorIs(t, l.LoadImage(ctx, filepath.Join(t.TempDir(), "missing.tar")), ErrOpeningArchive)
+}
+
+func TestDaemonSetImages(t *testing.T) {
+	t.Parallel()
+
+	l := NewDaemonSet(fake.NewSimpleClientset(), "test", &fakeMinio{})
+	l.SetImages(Images{Download: "mirror.test/curl:8.7.1"})
+
+	spec := l.daemonSet("loader", "http://minio.test/images/loader").Spec.Template.Spec
+	containers := append(spec.InitContainers, spec.Containers...)
+	require.Len(t, containers, 3)
+	assert.Equal(t, "mirror.test/curl:8.7.1", containers[0].Image)
+	assert.Equal(t, DefaultImages.Import, containers[1].Image)
+	assert.Equal(t, DefaultImages.Pause, containers[2].Image)
+	for _, c := range containers {
+		assert.Equal(t, v1.PullIfNotPresent, c.ImagePullPolicy)
+	}
+}
@@ -0,0 +1,21 @@
+package registry
+
+import (
+	"github.com/celestiaorg/knuu/pkg/errors"
+)
+
+type Error = errors.Error
+
+var (
+	ErrInvalidReference            = errors.New("InvalidReference", "invalid image reference '%s'")
+	ErrRequestingRegistry          = errors.New("RequestingRegistry", "error requesting %s")
+	ErrUnexpectedStatus            = errors.New("UnexpectedStatus", "unexpected status %d requesting %s: %s")
+	ErrAuthenticating              = errors.New("Authenticating", "error authenticating to registry %s")
+	ErrDecodingResponse            = errors.New("DecodingResponse", "error decoding the response of %s")
+	ErrUnsupportedManifest         = errors.New("UnsupportedManifest", "unsupported manifest type '%s' of image '%s'")
+	ErrPlatformNotFound            = errors.New("PlatformNotFound", "image '%s' has no manifest for platform '%s'")
+	ErrInvalidExposedPort          = errors.New("InvalidExposedPort", "invalid exposed port '%s' of image '%s'")
+	ErrGettingImageConfigFrom      = errors.New("GettingImageConfigFrom", "error getting the config of image '%s'")
+	ErrDeployingInClusterRegistry  = errors.New("DeployingInClusterRegistry", "error deploying the in-cluster registry")
+	ErrWaitingForInClusterRegistry = errors.New("WaitingForInClusterRegistry", "error waiting for the in-cluster registry to be ready")
+)
@@ -0,0 +1,135 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	appv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/utils/ptr"
+
+	"github.com/celestiaorg/knuu/pkg/k8s"
+	"github.com/celestiaorg/knuu/pkg/retry"
+)
+
+const (
+	// InClusterNamespace is the namespace of the in-cluster registry, it is shared by the tests
+	// so that the images pushed by a test are reused by the next ones
+	InClusterNamespace = "knuu-registry"
+	// DefaultNodePort is the port the nodes reach the in-cluster registry on
+	DefaultNodePort = 30500
+
+	inClusterName  = "registry"
+	inClusterImage = "docker.io/library/registry:2"
+	inClusterPort  = 5000
+)
+
+// inClusterReadyPolicy bounds the time to pull the image of the registry and start it
+var inClusterReadyPolicy = retry.Constant(1 * time.Second).WithTimeout(2 * time.Minute)
+
+// InCluster is a registry running in the cluster, so that the images built for the instances are not pushed
+// to a remote registry
+// The nodes pull the images from localhost:<node port>, which kube-proxy forwards to the registry, and the pods
+// of the cluster, e.g. the kaniko builds, push them to its service. The images are stored in an emptyDir volume,
+// they are lost when the pod of the registry is deleted.
+type InCluster struct {
+	clientset kubernetes.Interface
+	nodePort  int32
+}
+
+// NewInCluster returns the in-cluster registry reached by the nodes on the given port, DefaultNodePort if 0
+func NewInCluster(clientset kubernetes.Interface, nodePort int32) *InCluster {
+	if nodePort == 0 {
+		nodePort = DefaultNodePort
+	}
+	return &InCluster{clientset: clientset, nodePort: nodePort}
+}
+
+// Host returns the registry of the images as pulled by the nodes, localhost:<node port>
+func (r *InCluster) Host() string {
+	return fmt.Sprintf("localhost:%d", r.nodePort)
+}
+
+// ServiceHost returns the registry as reached by the pods of the cluster, which do not reach it on localhost
+// The registry serves http, so the pods pushing to it must allow it as an insecure registry.
+func (r *InCluster) ServiceHost() string {
+	return fmt.Sprintf("%s.%s.svc.cluster.local:%d", inClusterName, InClusterNamespace, inClusterPort)
+}
+
+// Deploy deploys the registry if it is not deployed and waits until it is ready
+func (r *InCluster) Deploy(ctx context.Context) error {
+	namespace := &v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: InClusterNamespace}}
+	_, err := r.clientset.CoreV1().Namespaces().Create(ctx, namespace, metav1.CreateOptions{})
+	if err != nil && !apierrs.IsAlreadyExists(err) {
+		return ErrDeployingInClusterRegistry.Wrap(err)
+	}
+
+	labels := map[string]string{"app": inClusterName, "k8s.kubernetes.io/managed-by": "knuu"}
+	deployment := &appv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: inClusterName, Namespace: InClusterNamespace, Labels: labels},
+		Spec: appv1.DeploymentSpec{
+			Replicas: ptr.To(int32(1)),
+			Selector: &metav1.LabelSelector{MatchLabels: labels},
+			Template: v1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec: v1.PodSpec{
+					Containers: []v1.Container{{
+						Name:  inClusterName,
+						Image: inClusterImage,
+						Ports: []v1.ContainerPort{{ContainerPort: inClusterPort}},
+						VolumeMounts: []v1.VolumeMount{{
+							Name:      inClusterName,
+							MountPath: "/var/lib/registry",
+						}},
+						ReadinessProbe: &v1.Probe{ProbeHandler: v1.ProbeHandler{
+							HTTPGet: &v1.HTTPGetAction{Path: "/v2/", Port: intstr.FromInt(inClusterPort)},
+						}},
+					}},
+					Volumes: []v1.Volume{{
+						Name:         inClusterName,
+						VolumeSource: v1.VolumeSource{EmptyDir: &v1.EmptyDirVolumeSource{}},
+					}},
+				},
+			},
+		},
+	}
+	if _, err := k8s.Apply(ctx, r.clientset.AppsV1().Deployments(InClusterNamespace), deployment); err != nil {
+		return ErrDeployingInClusterRegistry.Wrap(err)
+	}
+
+	service := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: inClusterName, Namespace: InClusterNamespace, Labels: labels},
+		Spec: v1.ServiceSpec{
+			Type:     v1.ServiceTypeNodePort,
+			Selector: labels,
+			Ports: []v1.ServicePort{{
+				Name:       "registry",
+				Port:       inClusterPort,
+				TargetPort: intstr.FromInt(inClusterPort),
+				NodePort:   r.nodePort,
+			}},
+		},
+	}
+	if _, err := k8s.Apply(ctx, r.clientset.CoreV1().Services(InClusterNamespace), service); err != nil {
+		return ErrDeployingInClusterRegistry.Wrap(err)
+	}
+
+	err = retry.Until(ctx, inClusterReadyPolicy, func(ctx context.Context) (bool, error) {
+		d, err := r.clientset.AppsV1().Deployments(InClusterNamespace).Get(ctx, inClusterName, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		return d.Status.ReadyReplicas > 0, nil
+	})
+	if err != nil {
+		return ErrWaitingForInClusterRegistry.Wrap(err)
+	}
+	logrus.Debugf("In-cluster registry ready on %s", r.Host())
+	return nil
+}
@@ -0,0 +1,48 @@
+package registry
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/celestiaorg/knuu/pkg/k8s/fake"
+)
+
+func TestInCluster(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	k8sCli, err := fake.New(ctx, "test")
+	require.NoError(t, err)
+	clientset := k8sCli.FakeClientset
+
+	r := NewInCluster(clientset, 0)
+	assert.Equal(t, "localhost:30500", r.Host())
+	assert.Equal(t, "registry.knuu-registry.svc.cluster.local:5000", r.ServiceHost())
+
+	// the registry becomes ready once its deployment is applied
+	go func() {
+		for ctx.Err() == nil {
+			d, err := clientset.AppsV1().Deployments(InClusterNamespace).Get(ctx, inClusterName, metav1.GetOptions{})
+			if err == nil {
+				d.Status.ReadyReplicas = 1
+				_, _ = clientset.AppsV1().Deployments(InClusterNamespace).UpdateStatus(ctx, d, metav1.UpdateOptions{})
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}()
+	require.NoError(t, r.Deploy(ctx))
+
+	svc, err := clientset.CoreV1().Services(InClusterNamespace).Get(ctx, inClusterName, metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, v1.ServiceTypeNodePort, svc.Spec.Type)
+	assert.Equal(t, int32(DefaultNodePort), svc.Spec.Ports[0].NodePort)
+
+	// deploying again reuses the namespace and the registry
+	require.NoError(t, r.Deploy(ctx))
+}
@@ -0,0 +1,382 @@
+// Package registry inspects the images of container registries with the Docker Registry HTTP API V2
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	dockerHubDomain   = "docker.io"
+	dockerHubRegistry = "registry-1.docker.io"
+
+	mediaTypeDockerManifest     = "application/vnd.docker.distribution.manifest.v2+json"
+	mediaTypeDockerManifestList = "application/vnd.docker.distribution.manifest.list.v2+json"
+	mediaTypeOCIManifest        = "application/vnd.oci.image.manifest.v1+json"
+	mediaTypeOCIIndex           = "application/vnd.oci.image.index.v1+json"
+)
+
+var (
+	// DefaultPlatform is the platform of the nodes of most clusters
+	DefaultPlatform = Platform{OS: "linux", Architecture: "amd64"}
+
+	acceptedManifests = strings.Join([]string{
+		mediaTypeOCIIndex, mediaTypeDockerManifestList, mediaTypeOCIManifest, mediaTypeDockerManifest,
+	}, ", ")
+	challengeParam = regexp.MustCompile(`(\w+)="([^"]*)"`)
+)
+
+// Platform selects a manifest among the ones of a multi-platform image
+type Platform struct {
+	OS           string `json:"os"`
+	Architecture string `json:"architecture"`
+}
+
+func (p Platform) String() string {
+	return p.OS + "/" + p.Architecture
+}
+
+// Port is a port exposed by an image with the EXPOSE directive
+type Port struct {
+	Port int
+	// Protocol is tcp, udp or sctp, as written in the image
+	Protocol string
+}
+
+// ImageConfig is the configuration of an image used to run its containers
+type ImageConfig struct {
+	Entrypoint   []string
+	Cmd          []string
+	ExposedPorts []Port
+	Env          map[string]string
+	User         string
+	WorkingDir   string
+}
+
+// Client gets the configuration of the images from their registry
+// The registries on localhost are reached with http, the others with https. The images are pulled anonymously
+// unless credentials are set, the tokens of the registries are requested when they challenge a request.
+type Client struct {
+	httpClient *http.Client
+	platform   Platform
+	username   string
+	password   string
+}
+
+// Option configures Client
+type Option func(*Client)
+
+// WithHTTPClient sets the client of the requests to the registries, http.DefaultClient by default
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithPlatform sets the platform of the images, DefaultPlatform by default
+func WithPlatform(platform Platform) Option {
+	return func(c *Client) {
+		c.platform = platform
+	}
+}
+
+// WithCredentials sets the credentials used to authenticate to the registries
+func WithCredentials(username, password string) Option {
+	return func(c *Client) {
+		c.username = username
+		c.password = password
+	}
+}
+
+// New returns a client of the registries
+func New(opts ...Option) *Client {
+	c := &Client{
+		httpClient: http.DefaultClient,
+		platform:   DefaultPlatform,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// ImageConfig returns the configuration of the given image, e.g. 'nginx:1.25' or 'ghcr.io/org/app@sha256:...'
+// The configuration of a multi-platform image is the one of the platform of the client.
+func (c *Client) ImageConfig(ctx context.Context, image string) (*ImageConfig, error) {
+	ref, err := parseReference(image)
+	if err != nil {
+		return nil, err
+	}
+	s := &session{client: c, ref: ref}
+
+	m, err := s.manifest(ctx, ref.reference)
+	if err != nil {
+		return nil, ErrGettingImageConfigFrom.WithParams(image).Wrap(err)
+	}
+	if m.MediaType == mediaTypeOCIIndex || m.MediaType == mediaTypeDockerManifestList {
+		digest := ""
+		for _, d := range m.Manifests {
+			if d.Platform == c.platform {
+				digest = d.Digest
+				break
+			}
+		}
+		if digest == "" {
+			return nil, ErrPlatformNotFound.WithParams(image, c.platform.String())
+		}
+		if m, err = s.manifest(ctx, digest); err != nil {
+			return nil, ErrGettingImageConfigFrom.WithParams(image).Wrap(err)
+		}
+	}
+	if m.MediaType != mediaTypeOCIManifest && m.MediaType != mediaTypeDockerManifest {
+		return nil, ErrUnsupportedManifest.WithParams(m.MediaType, image)
+	}
+
+	var blob struct {
+		Config struct {
+			Entrypoint   []string
+			Cmd          []string
+			ExposedPorts map[string]struct{}
+			Env          []string
+			User         string
+			WorkingDir   string
+		} `json:"config"`
+	}
+	if err := s.get(ctx, "blobs/"+m.Config.Digest, "", &blob); err != nil {
+		return nil, ErrGettingImageConfigFrom.WithParams(image).Wrap(err)
+	}
+
+	config := &ImageConfig{
+		Entrypoint: blob.Config.Entrypoint,
+		Cmd:        blob.Config.Cmd,
+		Env:        make(map[string]string, len(blob.Config.Env)),
+		User:       blob.Config.User,
+		WorkingDir: blob.Config.WorkingDir,
+	}
+	for _, env := range blob.Config.Env {
+		key, value, _ := strings.Cut(env, "=")
+		config.Env[key] = value
+	}
+	for exposed := range blob.Config.ExposedPorts {
+		port, err := parsePort(exposed)
+		if err != nil {
+			return nil, ErrInvalidExposedPort.WithParams(exposed, image).Wrap(err)
+		}
+		config.ExposedPorts = append(config.ExposedPorts, port)
+	}
+	sort.Slice(config.ExposedPorts, func(a, b int) bool {
+		pa, pb := config.ExposedPorts[a], config.ExposedPorts[b]
+		if pa.Port != pb.Port {
+			return pa.Port < pb.Port
+		}
+		return pa.Protocol < pb.Protocol
+	})
+	return config, nil
+}
+
+// parsePort parses an exposed port such as '8080/tcp', the protocol defaults to tcp
+func parsePort(exposed string) (Port, error) {
+	number, protocol, found := strings.Cut(exposed, "/")
+	if !found {
+		protocol = "tcp"
+	}
+	port, err := strconv.Atoi(number)
+	if err != nil {
+		return Port{}, err
+	}
+	return Port{Port: port, Protocol: strings.ToLower(protocol)}, nil
+}
+
+// reference is an image reference split into the parts of the registry API
+type reference struct {
+	registry   string
+	repository string
+	// reference is the tag or the digest
+	reference string
+}
+
+// parseReference parses an image reference the way docker does: the images without a registry are on
+// Docker Hub, its official images are in the library repository and the images without a tag are the latest
+func parseReference(image string) (reference, error) {
+	name, ref := image, "latest"
+	if i := strings.Index(image, "@"); i >= 0 {
+		name, ref = image[:i], image[i+1:]
+		// a tag alongside a digest is ignored by the registry
+		if j := strings.LastIndex(name, ":"); j > strings.LastIndex(name, "/") {
+			name = name[:j]
+		}
+	} else if j := strings.LastIndex(image, ":"); j > strings.LastIndex(image, "/") {
+		name, ref = image[:j], image[j+1:]
+	}
+	if name == "" || ref == "" {
+		return reference{}, ErrInvalidReference.WithParams(image)
+	}
+
+	registry, repository := dockerHubDomain, name
+	if domain, rest, found := strings.Cut(name, "/"); found &&
+		(strings.ContainsAny(domain, ".:") || domain == "localhost") {
+		registry, repository = domain, rest
+	}
+	if registry == dockerHubDomain {
+		registry = dockerHubRegistry
+		if !strings.Contains(repository, "/") {
+			repository = "library/" + repository
+		}
+	}
+	if repository == "" || repository != strings.ToLower(repository) {
+		return reference{}, ErrInvalidReference.WithParams(image)
+	}
+	return reference{registry: registry, repository: repository, reference: ref}, nil
+}
+
+func (r reference) url(path string) string {
+	scheme := "https"
+	host := strings.Split(r.registry, ":")[0]
+	if host == "localhost" || host == "127.0.0.1" {
+		scheme = "http"
+	}
+	return fmt.Sprintf("%s://%s/v2/%s/%s", scheme, r.registry, r.repository, path)
+}
+
+// manifest is a manifest of an image or an index of the manifests of a multi-platform image
+type manifest struct {
+	MediaType string `json:"mediaType"`
+	Config    struct {
+		Digest string `json:"digest"`
+	} `json:"config"`
+	Manifests []struct {
+		Digest   string   `json:"digest"`
+		Platform Platform `json:"platform"`
+	} `json:"manifests"`
+}
+
+// session makes the requests of an image, it keeps the token the registry granted to pull it
+type session struct {
+	client *Client
+	ref    reference
+	token  string
+}
+
+func (s *session) manifest(ctx context.Context, ref string) (*manifest, error) {
+	m := &manifest{}
+	if err := s.get(ctx, "manifests/"+ref, acceptedManifests, m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// get decodes the JSON response of the given path of the repository, authenticating if challenged
+func (s *session) get(ctx context.Context, path, accept string, v interface{}) error {
+	resp, err := s.do(ctx, s.ref.url(path), accept)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode == http.StatusUnauthorized && s.token == "" {
+		challenge := resp.Header.Get("WWW-Authenticate")
+		resp.Body.Close()
+		if err := s.authenticate(ctx, challenge); err != nil {
+			return err
+		}
+		if resp, err = s.do(ctx, s.ref.url(path), accept); err != nil {
+			return err
+		}
+	}
+	defer resp.Body.Close()
+
+	if err := checkStatus(resp); err != nil {
+		return err
+	}
+	if m, ok := v.(*manifest); ok {
+		// the media type is optional in the manifests, the content type is not
+		m.MediaType = strings.Split(resp.Header.Get("Content-Type"), ";")[0]
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return ErrDecodingResponse.WithParams(resp.Request.URL).Wrap(err)
+	}
+	return nil
+}
+
+func (s *session) do(ctx context.Context, url, accept string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, ErrRequestingRegistry.WithParams(url).Wrap(err)
+	}
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	switch {
+	case s.token != "":
+		req.Header.Set("Authorization", "Bearer "+s.token)
+	case s.client.username != "":
+		req.SetBasicAuth(s.client.username, s.client.password)
+	}
+	resp, err := s.client.httpClient.Do(req)
+	if err != nil {
+		return nil, ErrRequestingRegistry.WithParams(url).Wrap(err)
+	}
+	return resp, nil
+}
+
+// authenticate requests a token to pull the image from the realm of the given bearer challenge
+func (s *session) authenticate(ctx context.Context, challenge string) error {
+	scheme, params, _ := strings.Cut(challenge, " ")
+	if !strings.EqualFold(scheme, "Bearer") {
+		return ErrAuthenticating.WithParams(s.ref.registry).
+			Wrap(fmt.Errorf("unsupported challenge '%s'", challenge))
+	}
+	values := make(map[string]string)
+	for _, match := range challengeParam.FindAllStringSubmatch(params, -1) {
+		values[strings.ToLower(match[1])] = match[2]
+	}
+	realm, err := url.Parse(values["realm"])
+	if err != nil || values["realm"] == "" {
+		return ErrAuthenticating.WithParams(s.ref.registry).Wrap(fmt.Errorf("invalid realm in challenge '%s'", challenge))
+	}
+	query := realm.Query()
+	if values["service"] != "" {
+		query.Set("service", values["service"])
+	}
+	query.Set("scope", fmt.Sprintf("repository:%s:pull", s.ref.repository))
+	realm.RawQuery = query.Encode()
+
+	resp, err := s.do(ctx, realm.String(), "")
+	if err != nil {
+		return ErrAuthenticating.WithParams(s.ref.registry).Wrap(err)
+	}
+	defer resp.Body.Close()
+	if err := checkStatus(resp); err != nil {
+		return ErrAuthenticating.WithParams(s.ref.registry).Wrap(err)
+	}
+
+	var token struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return ErrAuthenticating.WithParams(s.ref.registry).Wrap(err)
+	}
+	s.token = token.Token
+	if s.token == "" {
+		s.token = token.AccessToken
+	}
+	if s.token == "" {
+		return ErrAuthenticating.WithParams(s.ref.registry).Wrap(fmt.Errorf("no token granted"))
+	}
+	return nil
+}
+
+func checkStatus(resp *http.Response) error {
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return ErrUnexpectedStatus.WithParams(resp.StatusCode, resp.Request.URL, strings.TrimSpace(string(body)))
+}
@@ -0,0 +1,102 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newRegistry returns a registry serving a multi-platform image 'org/app:v1' to the clients with a token
+func newRegistry(t *testing.T) *httptest.Server {
+	mux := http.NewServeMux()
+	var srv *httptest.Server
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "repository:org/app:pull", r.URL.Query().Get("scope"))
+		assert.Equal(t, "test-registry", r.URL.Query().Get("service"))
+		fmt.Fprint(w, `{"token":"secret"}`)
+	})
+	mux.HandleFunc("/v2/org/app/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.Header().Set("WWW-Authenticate",
+				fmt.Sprintf(`Bearer realm="%s/token",service="test-registry",scope="repository:org/app:pull"`, srv.URL))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch strings.TrimPrefix(r.URL.Path, "/v2/org/app/") {
+		case "manifests/v1":
+			w.Header().Set("Content-Type", mediaTypeOCIIndex)
+			fmt.Fprint(w, `{"manifests":[
+				{"digest":"sha256:arm","platform":{"os":"linux","architecture":"arm64"}},
+				{"digest":"sha256:amd","platform":{"os":"linux","architecture":"amd64"}}]}`)
+		case "manifests/sha256:amd":
+			w.Header().Set("Content-Type", mediaTypeDockerManifest+"; charset=utf-8")
+			fmt.Fprint(w, `{"config":{"digest":"sha256:config"}}`)
+		case "blobs/sha256:config":
+			fmt.Fprint(w, `{"architecture":"amd64","config":{
+				"Entrypoint":["/entrypoint.sh"],"Cmd":["serve","--verbose"],
+				"ExposedPorts":{"9090/udp":{},"26656/tcp":{},"8080":{}},
+				"Env":["PATH=/usr/bin:/bin","HOME=/home/app","EMPTY="],"User":"1000:1000","WorkingDir":"/app"}}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"errors":[{"code":"MANIFEST_UNKNOWN"}]}`)
+		}
+	})
+	srv = httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestImageConfig(t *testing.T) {
+	t.Parallel()
+	srv := newRegistry(t)
+	registry := strings.TrimPrefix(srv.URL, "http://")
+	ctx := context.Background()
+
+	config, err := New().ImageConfig(ctx, registry+"/org/app:v1")
+	require.NoError(t, err)
+	assert.Equal(t, &ImageConfig{
+		Entrypoint:   []string{"/entrypoint.sh"},
+		Cmd:          []string{"serve", "--verbose"},
+		ExposedPorts: []Port{{Port: 8080, Protocol: "tcp"}, {Port: 9090, Protocol: "udp"}, {Port: 26656, Protocol: "tcp"}},
+		Env:          map[string]string{"PATH": "/usr/bin:/bin", "HOME": "/home/app", "EMPTY": ""},
+		User:         "1000:1000",
+		WorkingDir:   "/app",
+	}, config)
+
+	_, err = New(WithPlatform(Platform{OS: "linux", Architecture: "s390x"})).ImageConfig(ctx, registry+"/org/app:v1")
+	assert.ErrorIs(t, err, ErrPlatformNotFound)
+
+	_, err = New().ImageConfig(ctx, registry+"/org/app:v2")
+	assert.ErrorContains(t, err, "unexpected status 404")
+}
+
+func TestParseReference(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		image string
+		want  reference
+	}{
+		{"nginx", reference{dockerHubRegistry, "library/nginx", "latest"}},
+		{"docker.io/nginx:1.25", reference{dockerHubRegistry, "library/nginx", "1.25"}},
+		{"celestiaorg/celestia-app:v1.0.0", reference{dockerHubRegistry, "celestiaorg/celestia-app", "v1.0.0"}},
+		{"ghcr.io/celestiaorg/celestia-node", reference{"ghcr.io", "celestiaorg/celestia-node", "latest"}},
+		{"localhost:5001/app:dev", reference{"localhost:5001", "app", "dev"}},
+		{"ttl.sh/1234:24h@sha256:abcd", reference{"ttl.sh", "1234", "sha256:abcd"}},
+	}
+	for _, tt := range tests {
+		got, err := parseReference(tt.image)
+		require.NoError(t, err, tt.image)
+		assert.Equal(t, tt.want, got, tt.image)
+	}
+
+	for _, image := range []string{"", "nginx:", "Nginx", "ghcr.io/"} {
+		_, err := parseReference(image)
+		assert.ErrorIs(t, err, ErrInvalidReference, image)
+	}
+}
@@ -0,0 +1,75 @@
+// Package clock abstracts the time of the waits of knuu, so that they can be driven by a fake clock in unit tests
+// The clock is carried by the context: the retries, the scenario steps and the supervisor use the clock
+// of their context, the real one by default.
+package clock
+
+import (
+	"context"
+	"time"
+
+	"k8s.io/utils/clock"
+	testingclock "k8s.io/utils/clock/testing"
+)
+
+// Clock tells the time and creates the timers of the waits
+type Clock = clock.WithTicker
+
+// Fake is a clock that only moves when it is stepped, see Fake.Step and Fake.HasWaiters
+type Fake = testingclock.FakeClock
+
+// Real is the clock of the system
+var Real Clock = clock.RealClock{}
+
+// NewFake returns a fake clock set to the given time
+func NewFake(now time.Time) *Fake {
+	return testingclock.NewFakeClock(now)
+}
+
+type contextKey struct{}
+
+// WithClock returns a copy of the context carrying the given clock
+func WithClock(ctx context.Context, c Clock) context.Context {
+	return context.WithValue(ctx, contextKey{}, c)
+}
+
+// FromContext returns the clock carried by the context, Real if it carries none
+func FromContext(ctx context.Context) Clock {
+	if c, ok := ctx.Value(contextKey{}).(Clock); ok {
+		return c
+	}
+	return Real
+}
+
+// Sleep waits for the given duration on the clock of the context, it returns the error of the context if it is done first
+func Sleep(ctx context.Context, d time.Duration) error {
+	timer := FromContext(ctx).NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C():
+		return nil
+	}
+}
+
+// WithTimeout returns a copy of the context that is cancelled after the given duration on its clock
+// With the real clock it is context.WithTimeout. With another clock the context is cancelled with
+// context.DeadlineExceeded as cause when the timer fires, so context.Cause reports the timeout.
+func WithTimeout(ctx context.Context, d time.Duration) (context.Context, context.CancelFunc) {
+	c := FromContext(ctx)
+	if _, ok := c.(clock.RealClock); ok {
+		return context.WithTimeout(ctx, d)
+	}
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	timer := c.NewTimer(d)
+	go func() {
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+		case <-timer.C():
+			cancel(context.DeadlineExceeded)
+		}
+	}()
+	return ctx, func() { cancel(context.Canceled) }
+}
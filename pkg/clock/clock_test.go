@@ -0,0 +1,59 @@
+package clock
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFromContext(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, Real, FromContext(context.Background()))
+
+	fake := NewFake(time.Unix(0, 0))
+	assert.Same(t, fake, FromContext(WithClock(context.Background(), fake)))
+}
+
+func TestSleep(t *testing.T) {
+	t.Parallel()
+
+	fake := NewFake(time.Unix(0, 0))
+	ctx := WithClock(context.Background(), fake)
+
+	done := make(chan error)
+	go func() { done <- Sleep(ctx, time.Hour) }()
+
+	require.Eventually(t, fake.HasWaiters, time.Second, time.Millisecond)
+	fake.Step(59 * time.Minute)
+	select {
+	case <-done:
+		t.Fatal("woke up before the duration elapsed")
+	default:
+	}
+	fake.Step(time.Minute)
+	require.NoError(t, <-done)
+}
+
+func TestWithTimeout(t *testing.T) {
+	t.Parallel()
+
+	fake := NewFake(time.Unix(0, 0))
+	ctx, cancel := WithTimeout(WithClock(context.Background(), fake), time.Minute)
+	defer cancel()
+
+	require.Eventually(t, fake.HasWaiters, time.Second, time.Millisecond)
+	assert.NoError(t, ctx.Err())
+	fake.Step(time.Minute)
+	<-ctx.Done()
+	assert.ErrorIs(t, context.Cause(ctx), context.DeadlineExceeded)
+
+	// the real clock keeps the deadline of the context
+	ctx, cancel = WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	_, ok := ctx.Deadline()
+	assert.True(t, ok)
+}
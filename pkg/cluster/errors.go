@@ -0,0 +1,21 @@
+package cluster
+
+import (
+	"github.com/celestiaorg/knuu/pkg/errors"
+)
+
+type Error = errors.Error
+
+var (
+	ErrInvalidPortMapping  = errors.New("InvalidPortMapping", "invalid port mapping %d:%d, the ports must be between 1 and 65535")
+	ErrListingClusters     = errors.New("ListingClusters", "error listing the kind clusters: %s")
+	ErrWritingConfig       = errors.New("WritingConfig", "error writing the config of the kind cluster")
+	ErrCreatingCluster     = errors.New("CreatingCluster", "error creating the kind cluster '%s': %s")
+	ErrDeletingCluster     = errors.New("DeletingCluster", "error deleting the kind cluster '%s': %s")
+	ErrStartingRegistry    = errors.New("StartingRegistry", "error starting the registry '%s': %s")
+	ErrConnectingRegistry  = errors.New("ConnectingRegistry", "error connecting the registry '%s' to the network of the kind nodes: %s")
+	ErrConfiguringRegistry = errors.New("ConfiguringRegistry", "error configuring the registry on the node '%s': %s")
+	ErrDeletingRegistry    = errors.New("DeletingRegistry", "error deleting the registry '%s': %s")
+	ErrInvalidRegistryPort = errors.New("InvalidRegistryPort", "invalid node port %d of the in-cluster registry, it must be between 1 and 65535")
+	ErrLoadingImage        = errors.New("LoadingImage", "error loading the image archive '%s' onto the nodes: %s")
+)
@@ -0,0 +1,388 @@
+// Package cluster creates local kubernetes clusters, so that the tests only need docker to run on a developer machine
+//
+// A typical use is to create the cluster once for the tests of a package:
+//
+//	func TestMain(m *testing.M) {
+//		kind := cluster.NewKind(cluster.WithRegistry(5001))
+//		if err := kind.Create(context.Background()); err != nil {
+//			log.Fatal(err)
+//		}
+//		code := m.Run()
+//		if err := kind.Delete(context.Background()); err != nil {
+//			log.Print(err)
+//		}
+//		os.Exit(code)
+//	}
+package cluster
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	DefaultName = "knuu"
+
+	defaultKindBinary   = "kind"
+	defaultDockerBinary = "docker"
+	// kindNetwork is the docker network of the kind nodes
+	kindNetwork = "kind"
+
+	registryImage = "docker.io/library/registry:2"
+	registryPort  = 5000
+	// registryConfigDir is where containerd reads the configuration of the registries on the kind nodes
+	registryConfigDir = "/etc/containerd/certs.d"
+)
+
+// PortMapping maps a port of the host to a port of the control plane node, e.g. to reach a NodePort service
+type PortMapping struct {
+	ContainerPort int
+	HostPort      int
+	// Protocol is TCP, UDP or SCTP, TCP if empty
+	Protocol string
+}
+
+// Kind creates and deletes a kind cluster with the kind and docker CLIs
+// The kubeconfig of the cluster is merged into the one of the user and made the current context,
+// so knuu uses the cluster once it is created.
+type Kind struct {
+	name         string
+	kindBinary   string
+	dockerBinary string
+	nodeImage    string
+	portMappings []PortMapping
+	// registryPort is the port of the host the local registry listens on, 0 without registry
+	registryPort int
+	// inClusterRegistryPort is the node port of the registry deployed in the cluster by knuu, 0 without registry
+	inClusterRegistryPort int
+}
+
+// Option configures Kind
+type Option func(*Kind)
+
+// WithName sets the name of the cluster, DefaultName by default
+func WithName(name string) Option {
+	return func(k *Kind) {
+		k.name = name
+	}
+}
+
+// WithKindBinary sets the path of the kind binary, 'kind' from the PATH by default
+func WithKindBinary(path string) Option {
+	return func(k *Kind) {
+		k.kindBinary = path
+	}
+}
+
+// WithDockerBinary sets the path of the docker binary, 'docker' from the PATH by default
+func WithDockerBinary(path string) Option {
+	return func(k *Kind) {
+		k.dockerBinary = path
+	}
+}
+
+// WithNodeImage sets the image of the nodes, e.g. kindest/node:v1.28.0, to test another version of kubernetes
+func WithNodeImage(image string) Option {
+	return func(k *Kind) {
+		k.nodeImage = image
+	}
+}
+
+// WithPortMapping maps the given port of the host to the given port of the control plane node
+func WithPortMapping(mapping PortMapping) Option {
+	return func(k *Kind) {
+		k.portMappings = append(k.portMappings, mapping)
+	}
+}
+
+// WithRegistry runs a local registry listening on the given port of the host, e.g. 5001
+// The images pushed to localhost:<port> can be pulled by the nodes with the same name, see Kind.Registry.
+func WithRegistry(port int) Option {
+	return func(k *Kind) {
+		k.registryPort = port
+	}
+}
+
+// WithInClusterRegistry makes the nodes pull the images of localhost:<nodePort> with http, so that they pull the
+// images from the registry knuu deploys in the cluster with knuu.WithInClusterRegistry and the same node port
+func WithInClusterRegistry(nodePort int) Option {
+	return func(k *Kind) {
+		k.inClusterRegistryPort = nodePort
+	}
+}
+
+// NewKind returns a kind cluster to create, it is not created until Create is called
+func NewKind(opts ...Option) *Kind {
+	k := &Kind{
+		name:         DefaultName,
+		kindBinary:   defaultKindBinary,
+		dockerBinary: defaultDockerBinary,
+	}
+	for _, opt := range opts {
+		opt(k)
+	}
+	return k
+}
+
+// Name returns the name of the cluster
+func (k *Kind) Name() string {
+	return k.name
+}
+
+// Context returns the name of the context of the cluster in the kubeconfig
+func (k *Kind) Context() string {
+	return "kind-" + k.name
+}
+
+// Registry returns the address of the local registry in the form localhost:port, empty without registry
+func (k *Kind) Registry() string {
+	if k.registryPort == 0 {
+		return ""
+	}
+	return fmt.Sprintf("localhost:%d", k.registryPort)
+}
+
+// Exists returns true if the cluster exists
+func (k *Kind) Exists(ctx context.Context) (bool, error) {
+	output, err := k.run(ctx, nil, k.kindBinary, "get", "clusters")
+	if err != nil {
+		return false, ErrListingClusters.WithParams(output).Wrap(err)
+	}
+	for _, name := range strings.Fields(output) {
+		if name == k.name {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Create creates the cluster and the local registry and waits until the nodes are ready
+// An existing cluster with the same name is reused, so the tests can be run again without recreating it.
+func (k *Kind) Create(ctx context.Context) error {
+	for _, m := range k.portMappings {
+		if !validPort(m.ContainerPort) || !validPort(m.HostPort) {
+			return ErrInvalidPortMapping.WithParams(m.HostPort, m.ContainerPort)
+		}
+	}
+	if k.inClusterRegistryPort != 0 && !validPort(k.inClusterRegistryPort) {
+		return ErrInvalidRegistryPort.WithParams(k.inClusterRegistryPort)
+	}
+
+	if k.registryPort != 0 {
+		if err := k.startRegistry(ctx); err != nil {
+			return err
+		}
+	}
+
+	exists, err := k.Exists(ctx)
+	if err != nil {
+		return err
+	}
+	if exists {
+		logrus.Infof("Reusing the kind cluster '%s'", k.name)
+	} else if err := k.createCluster(ctx); err != nil {
+		return err
+	}
+
+	if k.registryPort != 0 {
+		if err := k.connectRegistry(ctx); err != nil {
+			return err
+		}
+	}
+	if k.inClusterRegistryPort != 0 {
+		return k.configureInClusterRegistry(ctx)
+	}
+	return nil
+}
+
+// LoadImage loads the image archive onto the nodes of the cluster with kind, so that the images are not pushed
+// to a registry, see knuu.WithImageLoader
+func (k *Kind) LoadImage(ctx context.Context, archive string) error {
+	if output, err := k.run(ctx, nil, k.kindBinary, "load", "image-archive", archive, "--name", k.name); err != nil {
+		return ErrLoadingImage.WithParams(archive, output).Wrap(err)
+	}
+	return nil
+}
+
+// Delete deletes the cluster and the local registry
+func (k *Kind) Delete(ctx context.Context) error {
+	if output, err := k.run(ctx, nil, k.kindBinary, "delete", "cluster", "--name", k.name); err != nil {
+		return ErrDeletingCluster.WithParams(k.name, output).Wrap(err)
+	}
+	if k.registryPort != 0 {
+		if output, err := k.run(ctx, nil, k.dockerBinary, "rm", "--force", k.registryName()); err != nil {
+			return ErrDeletingRegistry.WithParams(k.registryName(), output).Wrap(err)
+		}
+	}
+	logrus.Infof("Deleted the kind cluster '%s'", k.name)
+	return nil
+}
+
+func (k *Kind) createCluster(ctx context.Context) error {
+	config, err := os.CreateTemp("", "kind-config-*.yaml")
+	if err != nil {
+		return ErrWritingConfig.Wrap(err)
+	}
+	defer os.Remove(config.Name())
+	if _, err := config.WriteString(k.config()); err != nil {
+		config.Close()
+		return ErrWritingConfig.Wrap(err)
+	}
+	if err := config.Close(); err != nil {
+		return ErrWritingConfig.Wrap(err)
+	}
+
+	args := []string{"create", "cluster", "--name", k.name, "--config", config.Name(), "--wait", "5m"}
+	if k.nodeImage != "" {
+		args = append(args, "--image", k.nodeImage)
+	}
+	logrus.Infof("Creating the kind cluster '%s'", k.name)
+	if output, err := k.run(ctx, nil, k.kindBinary, args...); err != nil {
+		return ErrCreatingCluster.WithParams(k.name, output).Wrap(err)
+	}
+	return nil
+}
+
+// config returns the configuration of the cluster, a single node with the port mappings
+// and containerd reading the configuration of the registries from registryConfigDir
+func (k *Kind) config() string {
+	var sb strings.Builder
+	sb.WriteString("kind: Cluster\napiVersion: kind.x-k8s.io/v1alpha4\n")
+	if k.registryPort != 0 || k.inClusterRegistryPort != 0 {
+		sb.WriteString("containerdConfigPatches:\n- |-\n")
+		sb.WriteString("  [plugins.\"io.containerd.grpc.v1.cri\".registry]\n")
+		fmt.Fprintf(&sb, "    config_path = %q\n", registryConfigDir)
+	}
+	sb.WriteString("nodes:\n- role: control-plane\n")
+	if len(k.portMappings) != 0 {
+		sb.WriteString("  extraPortMappings:\n")
+		for _, m := range k.portMappings {
+			protocol := m.Protocol
+			if protocol == "" {
+				protocol = "TCP"
+			}
+			fmt.Fprintf(&sb, "  - containerPort: %d\n    hostPort: %d\n    protocol: %s\n", m.ContainerPort, m.HostPort, protocol)
+		}
+	}
+	return sb.String()
+}
+
+func (k *Kind) registryName() string {
+	return k.name + "-registry"
+}
+
+// startRegistry starts the registry container if it is not running
+func (k *Kind) startRegistry(ctx context.Context) error {
+	output, err := k.run(ctx, nil, k.dockerBinary, "inspect", "--format", "{{.State.Running}}", k.registryName())
+	if err == nil && output == "true" {
+		return nil
+	}
+	output, err = k.run(ctx, nil, k.dockerBinary, "run", "--detach", "--restart", "always",
+		"--publish", fmt.Sprintf("127.0.0.1:%d:%d", k.registryPort, registryPort),
+		"--name", k.registryName(), registryImage)
+	if err != nil {
+		return ErrStartingRegistry.WithParams(k.registryName(), output).Wrap(err)
+	}
+	logrus.Infof("Started the registry '%s' on %s", k.registryName(), k.Registry())
+	return nil
+}
+
+// connectRegistry makes the nodes pull the images of localhost:<port> from the registry container
+// and documents the registry in the cluster, as described by the KEP 1755
+func (k *Kind) connectRegistry(ctx context.Context) error {
+	output, err := k.run(ctx, nil, k.dockerBinary, "network", "connect", kindNetwork, k.registryName())
+	if err != nil && !strings.Contains(output, "already exists") {
+		return ErrConnectingRegistry.WithParams(k.registryName(), output).Wrap(err)
+	}
+
+	nodes, err := k.nodes(ctx)
+	if err != nil {
+		return err
+	}
+	if err := k.writeRegistryHosts(ctx, nodes, k.Registry(), fmt.Sprintf("%s:%d", k.registryName(), registryPort)); err != nil {
+		return err
+	}
+
+	if len(nodes) == 0 {
+		return nil
+	}
+	// the nodes run kubectl with the admin kubeconfig, so kubectl is not needed on the host
+	output, err = k.run(ctx, strings.NewReader(k.registryConfigMap()), k.dockerBinary, "exec", "--interactive", nodes[0],
+		"kubectl", "--kubeconfig", "/etc/kubernetes/admin.conf", "apply", "--filename", "-")
+	if err != nil {
+		return ErrConfiguringRegistry.WithParams(nodes[0], output).Wrap(err)
+	}
+	return nil
+}
+
+// configureInClusterRegistry makes the nodes pull the images of localhost:<node port> with http,
+// kube-proxy forwarding the node port to the registry deployed in the cluster
+func (k *Kind) configureInClusterRegistry(ctx context.Context) error {
+	nodes, err := k.nodes(ctx)
+	if err != nil {
+		return err
+	}
+	host := fmt.Sprintf("localhost:%d", k.inClusterRegistryPort)
+	return k.writeRegistryHosts(ctx, nodes, host, host)
+}
+
+// nodes returns the names of the node containers of the cluster
+func (k *Kind) nodes(ctx context.Context) ([]string, error) {
+	output, err := k.run(ctx, nil, k.kindBinary, "get", "nodes", "--name", k.name)
+	if err != nil {
+		return nil, ErrListingClusters.WithParams(output).Wrap(err)
+	}
+	return strings.Fields(output), nil
+}
+
+// writeRegistryHosts makes containerd on the given nodes pull the images of the given registry from the given server with http
+func (k *Kind) writeRegistryHosts(ctx context.Context, nodes []string, registry, server string) error {
+	dir := fmt.Sprintf("%s/%s", registryConfigDir, registry)
+	hosts := fmt.Sprintf("[host.\"http://%s\"]\n", server)
+	for _, node := range nodes {
+		if output, err := k.run(ctx, nil, k.dockerBinary, "exec", node, "mkdir", "-p", dir); err != nil {
+			return ErrConfiguringRegistry.WithParams(node, output).Wrap(err)
+		}
+		output, err := k.run(ctx, strings.NewReader(hosts), k.dockerBinary, "exec", "--interactive", node, "cp", "/dev/stdin", dir+"/hosts.toml")
+		if err != nil {
+			return ErrConfiguringRegistry.WithParams(node, output).Wrap(err)
+		}
+	}
+	return nil
+}
+
+// registryConfigMap returns the config map documenting the local registry to the tools, see KEP 1755
+func (k *Kind) registryConfigMap() string {
+	return fmt.Sprintf(`apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: local-registry-hosting
+  namespace: kube-public
+data:
+  localRegistryHosting.v1: |
+    host: "%s"
+    help: "https://kind.sigs.k8s.io/docs/user/local-registry/"
+`, k.Registry())
+}
+
+// run runs the given binary with the given arguments and returns its combined output
+func (k *Kind) run(ctx context.Context, stdin io.Reader, binary string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, binary, args...)
+	var output bytes.Buffer
+	cmd.Stdin = stdin
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	err := cmd.Run()
+	return strings.TrimSpace(output.String()), err
+}
+
+func validPort(port int) bool {
+	return port > 0 && port <= 65535
+}
@@ -0,0 +1,130 @@
+package cluster
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeBinaries writes kind and docker scripts recording their arguments in the same file
+// kind lists the given clusters and a control plane node, docker reports that no container runs.
+func fakeBinaries(t *testing.T, clusters string) (kind, docker, calls string) {
+	dir := t.TempDir()
+	kind = filepath.Join(dir, "kind")
+	docker = filepath.Join(dir, "docker")
+	calls = filepath.Join(dir, "calls")
+
+	kindScript := "#!/bin/sh\necho kind \"$@\" >> " + calls + "\n" +
+		"case \"$*\" in\n" +
+		"  'get clusters') echo '" + clusters + "';;\n" +
+		"  'get nodes'*) echo 'knuu-control-plane';;\n" +
+		"esac\n"
+	dockerScript := "#!/bin/sh\necho docker \"$@\" >> " + calls + "\n" +
+		"case \"$*\" in\n" +
+		"  inspect*) echo 'No such object'; exit 1;;\n" +
+		"  *--interactive*) cat >> " + calls + ";;\n" +
+		"esac\n"
+	require.NoError(t, os.WriteFile(kind, []byte(kindScript), 0o755))
+	require.NoError(t, os.WriteFile(docker, []byte(dockerScript), 0o755))
+	return kind, docker, calls
+}
+
+func readCalls(t *testing.T, calls string) []string {
+	content, err := os.ReadFile(calls)
+	require.NoError(t, err)
+	return strings.Split(strings.TrimSpace(string(content)), "\n")
+}
+
+func TestKindConfig(t *testing.T) {
+	t.Parallel()
+
+	k := NewKind(WithRegistry(5001), WithPortMapping(PortMapping{ContainerPort: 30080, HostPort: 8080}))
+	assert.Equal(t, `kind: Cluster
+apiVersion: kind.x-k8s.io/v1alpha4
+containerdConfigPatches:
+- |-
+  [plugins."io.containerd.grpc.v1.cri".registry]
+    config_path = "/etc/containerd/certs.d"
+nodes:
+- role: control-plane
+  extraPortMappings:
+  - containerPort: 30080
+    hostPort: 8080
+    protocol: TCP
+`, k.config())
+
+	assert.Equal(t, "kind: Cluster\napiVersion: kind.x-k8s.io/v1alpha4\nnodes:\n- role: control-plane\n", NewKind().config())
+}
+
+func TestKind(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	kind, docker, calls := fakeBinaries(t, "other")
+	k := NewKind(WithKindBinary(kind), WithDockerBinary(docker), WithRegistry(5001), WithNodeImage("kindest/node:v1.28.0"))
+	assert.Equal(t, "localhost:5001", k.Registry())
+	assert.Equal(t, "kind-knuu", k.Context())
+
+	require.NoError(t, k.Create(ctx))
+	lines := readCalls(t, calls)
+	assert.Equal(t, "docker inspect --format {{.State.Running}} knuu-registry", lines[0])
+	assert.Equal(t, "docker run --detach --restart always --publish 127.0.0.1:5001:5000 --name knuu-registry docker.io/library/registry:2", lines[1])
+	assert.Equal(t, "kind get clusters", lines[2])
+	assert.Regexp(t, `^kind create cluster --name knuu --config .*kind-config-.*\.yaml --wait 5m --image kindest/node:v1\.28\.0$`, lines[3])
+	assert.Equal(t, "docker network connect kind knuu-registry", lines[4])
+	assert.Equal(t, "kind get nodes --name knuu", lines[5])
+	assert.Equal(t, "docker exec knuu-control-plane mkdir -p /etc/containerd/certs.d/localhost:5001", lines[6])
+	assert.Equal(t, "docker exec --interactive knuu-control-plane cp /dev/stdin /etc/containerd/certs.d/localhost:5001/hosts.toml", lines[7])
+	assert.Equal(t, `[host."http://knuu-registry:5000"]`, lines[8])
+	assert.Contains(t, strings.Join(lines[9:], "\n"), `host: "localhost:5001"`)
+
+	require.NoError(t, k.Delete(ctx))
+	lines = readCalls(t, calls)
+	assert.Equal(t, []string{"kind delete cluster --name knuu", "docker rm --force knuu-registry"}, lines[len(lines)-2:])
+}
+
+func TestKindInClusterRegistry(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	kind, docker, calls := fakeBinaries(t, "other")
+	k := NewKind(WithKindBinary(kind), WithDockerBinary(docker), WithInClusterRegistry(30500))
+	assert.Empty(t, k.Registry())
+	assert.Contains(t, k.config(), `config_path = "/etc/containerd/certs.d"`)
+
+	require.NoError(t, k.Create(ctx))
+	lines := readCalls(t, calls)
+	require.Len(t, lines, 6)
+	assert.Equal(t, "kind get nodes --name knuu", lines[2])
+	assert.Equal(t, "docker exec knuu-control-plane mkdir -p /etc/containerd/certs.d/localhost:30500", lines[3])
+	assert.Equal(t, "docker exec --interactive knuu-control-plane cp /dev/stdin /etc/containerd/certs.d/localhost:30500/hosts.toml", lines[4])
+	assert.Equal(t, `[host."http://localhost:30500"]`, lines[5])
+
+	require.NoError(t, k.LoadImage(ctx, "/tmp/image.tar"))
+	assert.Equal(t, "kind load image-archive /tmp/image.tar --name knuu", readCalls(t, calls)[6])
+
+	k = NewKind(WithKindBinary(kind), WithInClusterRegistry(70000))
+	assert.ErrorIs(t, k.Create(ctx), ErrInvalidRegistryPort)
+}
+
+func TestKindReuse(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	kind, docker, calls := fakeBinaries(t, "knuu")
+	k := NewKind(WithKindBinary(kind), WithDockerBinary(docker))
+	exists, err := k.Exists(ctx)
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	require.NoError(t, k.Create(ctx))
+	assert.Equal(t, []string{"kind get clusters", "kind get clusters"}, readCalls(t, calls))
+
+	k = NewKind(WithKindBinary(kind), WithPortMapping(PortMapping{ContainerPort: 80, HostPort: 70000}))
+	assert.ErrorIs(t, k.Create(ctx), ErrInvalidPortMapping)
+}
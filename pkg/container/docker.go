@@ -167,6 +167,12 @@ func (f *BuilderFactory) Changed() bool {
 // PushBuilderImage pushes the image from the given builder to a registry.
 // The image is identified by the provided name.
 func (f *BuilderFactory) PushBuilderImage(imageName string) error {
+	return f.PushBuilderImageWithContext(context.Background(), imageName)
+}
+
+// PushBuilderImageWithContext pushes the image from the given builder to a registry like PushBuilderImage, the
+// build is cancelled when the context is done.
+func (f *BuilderFactory) PushBuilderImageWithContext(ctx context.Context, imageName string) error {
 	if !f.Changed() {
 		logrus.Debugf("No changes made to image %s, skipping push", f.imageNameFrom)
 		return nil
@@ -188,7 +194,7 @@ func (f *BuilderFactory) PushBuilderImage(imageName string) error {
 		return ErrFailedToWriteDockerfile.Wrap(err)
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), DefaultTimeout)
+	ctx, cancel := context.WithTimeout(ctx, DefaultTimeout)
 	defer cancel()
 	logs, err := f.imageBuilder.Build(ctx, &builder.BuilderOptions{
 		ImageName:    f.imageNameTo,
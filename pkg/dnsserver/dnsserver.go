@@ -0,0 +1,279 @@
+// Package dnsserver provides a DNS server instance to test how instances handle resolution failures.
+// The server answers the registered records, can inject NXDOMAIN, SERVFAIL, latency and timeouts
+// for specific names and forwards the other queries to the cluster DNS.
+package dnsserver
+
+import (
+	"context"
+	_ "embed"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/celestiaorg/knuu/pkg/instance"
+	"github.com/celestiaorg/knuu/pkg/system"
+)
+
+const (
+	// DefaultImage is the image of the DNS server, the server only needs python 3
+	DefaultImage = "docker.io/library/python:3.12-alpine"
+
+	serverName  = "knuu-dns"
+	dnsPort     = 53
+	recordsDir  = "/tmp/knuu-dns"
+	recordsFile = recordsDir + "/records.json"
+
+	rcodeServFail = 2
+	rcodeNXDomain = 3
+
+	// clusterDomain is the default domain of the services of a cluster
+	clusterDomain = "cluster.local"
+)
+
+//go:embed server.py
+var serverScript string
+
+// Record is the answer of the DNS server for a name
+type Record struct {
+	// Addresses are the IPv4 and IPv6 addresses of the name, returned as A and AAAA records
+	Addresses []string
+	// TTL is the time to live of the answers, 0 by default so that the clients do not cache them
+	TTL time.Duration
+}
+
+// entry is the configuration of a name in the records file read by the server
+type entry struct {
+	A       []string `json:"a,omitempty"`
+	AAAA    []string `json:"aaaa,omitempty"`
+	TTL     uint32   `json:"ttl,omitempty"`
+	RCode   int      `json:"rcode,omitempty"`
+	DelayMs int64    `json:"delayMs,omitempty"`
+	Drop    bool     `json:"drop,omitempty"`
+}
+
+func (e *entry) empty() bool {
+	return len(e.A) == 0 && len(e.AAAA) == 0 && e.RCode == 0 && e.DelayMs == 0 && !e.Drop
+}
+
+// Server is a DNS server running in the cluster
+// Names are absolute, e.g. 'api.example.test', and a name starting with '*.' matches all its subdomains.
+// The server only listens on UDP, which is what the resolvers use for short answers.
+type Server struct {
+	instance  *instance.Instance
+	namespace string
+
+	mu      sync.Mutex
+	entries map[string]*entry
+}
+
+// New creates and starts a DNS server with the given image, DefaultImage if empty
+func New(ctx context.Context, sysDeps system.SystemDependencies, image string) (*Server, error) {
+	if image == "" {
+		image = DefaultImage
+	}
+	inst, err := instance.New(serverName, sysDeps,
+		instance.WithImage(image),
+		instance.WithCommand("python3", "-c", serverScript),
+		instance.WithPortsUDP(dnsPort),
+	)
+	if err != nil {
+		return nil, ErrCreatingDNSServer.Wrap(err)
+	}
+	if err := inst.Commit(); err != nil {
+		return nil, ErrStartingDNSServer.WithParams(inst.Name()).Wrap(err)
+	}
+	// the server is destroyed if it fails to start after its pod was deployed
+	if err := instance.StartAllWithPolicy(ctx, instance.StartFailureDestroy, inst); err != nil {
+		return nil, ErrStartingDNSServer.WithParams(inst.Name()).Wrap(err)
+	}
+	return &Server{
+		instance:  inst,
+		namespace: sysDeps.K8sCli.Namespace(),
+		entries:   make(map[string]*entry),
+	}, nil
+}
+
+// Instance returns the instance of the DNS server
+func (s *Server) Instance() *instance.Instance {
+	return s.instance
+}
+
+// IP returns the IP of the service of the DNS server
+func (s *Server) IP(ctx context.Context) (string, error) {
+	ip, err := s.instance.GetIP(ctx)
+	if err != nil {
+		return "", ErrGettingDNSServerIP.WithParams(s.instance.Name()).Wrap(err)
+	}
+	return ip, nil
+}
+
+// Configure makes the given instance resolve names with the DNS server
+// The instance keeps the search domains of the cluster, so the names of the services still resolve.
+// This function can only be called before the instance is started.
+func (s *Server) Configure(ctx context.Context, inst *instance.Instance) error {
+	ip, err := s.IP(ctx)
+	if err != nil {
+		return err
+	}
+	searches := []string{
+		fmt.Sprintf("%s.svc.%s", s.namespace, clusterDomain),
+		"svc." + clusterDomain,
+		clusterDomain,
+	}
+	if err := inst.SetDNSPolicy(v1.DNSNone); err != nil {
+		return ErrConfiguringInstanceDNS.WithParams(inst.Name()).Wrap(err)
+	}
+	if err := inst.SetDNSConfig([]string{ip}, searches, map[string]string{"ndots": "5"}); err != nil {
+		return ErrConfiguringInstanceDNS.WithParams(inst.Name()).Wrap(err)
+	}
+	return nil
+}
+
+// SetRecord registers or replaces the addresses of the given name, its faults are kept
+func (s *Server) SetRecord(ctx context.Context, name string, record Record) error {
+	if len(record.Addresses) == 0 {
+		return ErrRecordWithoutAddresses.WithParams(name)
+	}
+	var a, aaaa []string
+	for _, address := range record.Addresses {
+		ip := net.ParseIP(address)
+		switch {
+		case ip == nil:
+			return ErrInvalidRecordAddress.WithParams(address, name)
+		case ip.To4() != nil:
+			a = append(a, ip.String())
+		default:
+			aaaa = append(aaaa, ip.String())
+		}
+	}
+	return s.update(ctx, name, func(e *entry) {
+		e.A, e.AAAA = a, aaaa
+		e.TTL = uint32(record.TTL / time.Second)
+	})
+}
+
+// RemoveRecord removes the addresses of the given name, it is forwarded to the cluster DNS again unless it has faults
+func (s *Server) RemoveRecord(ctx context.Context, name string) error {
+	return s.update(ctx, name, func(e *entry) {
+		e.A, e.AAAA, e.TTL = nil, nil, 0
+	})
+}
+
+// InjectNXDOMAIN makes the queries of the given name fail with NXDOMAIN, as if it did not exist
+func (s *Server) InjectNXDOMAIN(ctx context.Context, name string) error {
+	return s.update(ctx, name, func(e *entry) {
+		e.RCode = rcodeNXDomain
+	})
+}
+
+// InjectServFail makes the queries of the given name fail with SERVFAIL, as if the server failed
+func (s *Server) InjectServFail(ctx context.Context, name string) error {
+	return s.update(ctx, name, func(e *entry) {
+		e.RCode = rcodeServFail
+	})
+}
+
+// InjectLatency delays the answers of the given name by the given duration
+// It can be combined with the other faults and with the records of the name.
+func (s *Server) InjectLatency(ctx context.Context, name string, latency time.Duration) error {
+	if latency < 0 {
+		return ErrNegativeLatency.WithParams(name)
+	}
+	return s.update(ctx, name, func(e *entry) {
+		e.DelayMs = latency.Milliseconds()
+	})
+}
+
+// InjectTimeout makes the server ignore the queries of the given name, so the clients time out
+func (s *Server) InjectTimeout(ctx context.Context, name string) error {
+	return s.update(ctx, name, func(e *entry) {
+		e.Drop = true
+	})
+}
+
+// ClearFaults removes the faults injected for the given name, its record is kept
+func (s *Server) ClearFaults(ctx context.Context, name string) error {
+	return s.update(ctx, name, func(e *entry) {
+		e.RCode, e.DelayMs, e.Drop = 0, 0, false
+	})
+}
+
+// Destroy destroys the DNS server
+func (s *Server) Destroy(ctx context.Context) error {
+	if err := s.instance.Destroy(ctx); err != nil {
+		return ErrDestroyingDNSServer.WithParams(s.instance.Name()).Wrap(err)
+	}
+	return nil
+}
+
+// update applies the given change to the entry of the name and writes the records to the server
+// The server reads the records again on the next query.
+func (s *Server) update(ctx context.Context, name string, change func(e *entry)) error {
+	fqdn, err := normalizeName(name)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	previous, ok := s.entries[fqdn]
+	e := &entry{}
+	if ok {
+		*e = *previous
+	}
+	change(e)
+	if e.empty() {
+		delete(s.entries, fqdn)
+	} else {
+		s.entries[fqdn] = e
+	}
+
+	command, err := writeRecordsCommand(s.entries)
+	if err == nil {
+		_, err = s.instance.ExecuteCommand(ctx, command)
+	}
+	if err != nil {
+		// keep the records in sync with the ones of the server
+		if ok {
+			s.entries[fqdn] = previous
+		} else {
+			delete(s.entries, fqdn)
+		}
+		return ErrUpdatingRecords.WithParams(s.instance.Name()).Wrap(err)
+	}
+	logrus.Debugf("Updated DNS record '%s' of DNS server '%s'", fqdn, s.instance.Name())
+	return nil
+}
+
+// writeRecordsCommand returns the shell command replacing the records file of the server
+// The file is replaced with a rename, so the server never reads a partially written file.
+func writeRecordsCommand(entries map[string]*entry) (string, error) {
+	records, err := json.Marshal(entries)
+	if err != nil {
+		return "", ErrMarshallingRecords.Wrap(err)
+	}
+	encoded := base64.StdEncoding.EncodeToString(records)
+	return fmt.Sprintf("mkdir -p %s && echo %s | base64 -d > %s.tmp && mv %s.tmp %s",
+		recordsDir, encoded, recordsFile, recordsFile, recordsFile), nil
+}
+
+// normalizeName returns the lower case absolute form of the name, e.g. 'api.example.test.'
+func normalizeName(name string) (string, error) {
+	fqdn := strings.ToLower(strings.TrimSpace(name))
+	if !strings.HasSuffix(fqdn, ".") {
+		fqdn += "."
+	}
+	if fqdn == "." || strings.Contains(fqdn, "..") || strings.ContainsAny(fqdn, " \t/") ||
+		strings.Contains(strings.TrimPrefix(fqdn, "*."), "*") {
+		return "", ErrInvalidRecordName.WithParams(name)
+	}
+	return fqdn, nil
+}
@@ -0,0 +1,68 @@
+package dnsserver
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeName(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		want    string
+		wantErr bool
+	}{
+		{name: "API.Example.test", want: "api.example.test."},
+		{name: "api.example.test.", want: "api.example.test."},
+		{name: "*.example.test", want: "*.example.test."},
+		{name: "", wantErr: true},
+		{name: ".", wantErr: true},
+		{name: "api..test", wantErr: true},
+		{name: "a.*.test", wantErr: true},
+		{name: "api test", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := normalizeName(tt.name)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidRecordName)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestWriteRecordsCommand(t *testing.T) {
+	t.Parallel()
+
+	command, err := writeRecordsCommand(map[string]*entry{
+		"api.example.test.":  {A: []string{"10.0.0.1"}, TTL: 5, DelayMs: 200},
+		"gone.example.test.": {RCode: rcodeNXDomain},
+	})
+	require.NoError(t, err)
+
+	fields := strings.Fields(command)
+	require.Greater(t, len(fields), 5)
+	records, err := base64.StdEncoding.DecodeString(fields[5])
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"api.example.test.": {"a": ["10.0.0.1"], "ttl": 5, "delayMs": 200},
+		"gone.example.test.": {"rcode": 3}
+	}`, string(records))
+	assert.True(t, strings.HasSuffix(command, "mv "+recordsFile+".tmp "+recordsFile))
+}
+
+func TestEntryEmpty(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, (&entry{}).empty())
+	assert.False(t, (&entry{Drop: true}).empty())
+	assert.False(t, (&entry{AAAA: []string{"::1"}}).empty())
+}
@@ -0,0 +1,21 @@
+package dnsserver
+
+import (
+	"github.com/celestiaorg/knuu/pkg/errors"
+)
+
+type Error = errors.Error
+
+var (
+	ErrCreatingDNSServer      = errors.New("CreatingDNSServer", "error creating the DNS server")
+	ErrStartingDNSServer      = errors.New("StartingDNSServer", "error starting the DNS server '%s'")
+	ErrGettingDNSServerIP     = errors.New("GettingDNSServerIP", "error getting the IP of the DNS server '%s'")
+	ErrInvalidRecordName      = errors.New("InvalidRecordName", "invalid record name '%s'")
+	ErrRecordWithoutAddresses = errors.New("RecordWithoutAddresses", "record '%s' has no addresses")
+	ErrInvalidRecordAddress   = errors.New("InvalidRecordAddress", "invalid address '%s' of record '%s'")
+	ErrNegativeLatency        = errors.New("NegativeLatency", "latency of '%s' must not be negative")
+	ErrUpdatingRecords        = errors.New("UpdatingRecords", "error updating the records of the DNS server '%s'")
+	ErrMarshallingRecords     = errors.New("MarshallingRecords", "error marshalling the records of the DNS server")
+	ErrConfiguringInstanceDNS = errors.New("ConfiguringInstanceDNS", "error configuring the DNS of instance '%s'")
+	ErrDestroyingDNSServer    = errors.New("DestroyingDNSServer", "error destroying the DNS server '%s'")
+)
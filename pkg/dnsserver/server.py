@@ -0,0 +1,134 @@
+# DNS server of the knuu DNS fixture, it only depends on the python standard library.
+# It answers the names of the records file and forwards the other queries to the
+# nameserver of the pod. The records file is reloaded when it is replaced.
+import json
+import os
+import socket
+import struct
+import threading
+import time
+
+RECORDS = "/tmp/knuu-dns/records.json"
+PORT = 53
+TYPE_A, TYPE_AAAA, TYPE_ANY = 1, 28, 255
+RCODE_SERVFAIL = 2
+
+
+def upstream():
+    try:
+        with open("/etc/resolv.conf") as f:
+            for line in f:
+                fields = line.split()
+                if len(fields) > 1 and fields[0] == "nameserver":
+                    return fields[1]
+    except OSError:
+        pass
+    return None
+
+
+class Records:
+    def __init__(self):
+        self.lock = threading.Lock()
+        self.version = None
+        self.entries = {}
+
+    def reload(self):
+        try:
+            st = os.stat(RECORDS)
+            version = (st.st_ino, st.st_mtime_ns)
+            if version != self.version:
+                with open(RECORDS) as f:
+                    self.entries = json.load(f)
+                self.version = version
+        except (OSError, ValueError):
+            pass
+
+    def lookup(self, name):
+        with self.lock:
+            self.reload()
+            if name in self.entries:
+                return self.entries[name]
+            # wildcards, e.g. *.example.test. matches a.b.example.test.
+            labels = name.split(".")
+            for n in range(1, len(labels) - 1):
+                entry = self.entries.get("*." + ".".join(labels[n:]))
+                if entry is not None:
+                    return entry
+            return {}
+
+
+def question(query):
+    labels, off = [], 12
+    while query[off]:
+        n = query[off]
+        labels.append(query[off + 1:off + 1 + n].decode("ascii", "replace").lower())
+        off += 1 + n
+    (qtype,) = struct.unpack(">H", query[off + 1:off + 3])
+    return ".".join(labels) + ".", qtype, off + 5
+
+
+def response(query, qend, rcode, answers):
+    (flags,) = struct.unpack(">H", query[2:4])
+    # response, authoritative, recursion available, recursion desired copied from the query
+    flags = 0x8000 | 0x0400 | 0x0080 | (flags & 0x0100) | rcode
+    header = query[:2] + struct.pack(">HHHHH", flags, 1, len(answers), 0, 0)
+    return header + query[12:qend] + b"".join(answers)
+
+
+def answer(rtype, ttl, rdata):
+    # 0xc00c points to the name of the question
+    return struct.pack(">HHHIH", 0xC00C, rtype, 1, ttl, len(rdata)) + rdata
+
+
+def forward(query, server):
+    if server is None:
+        return None
+    family = socket.AF_INET6 if ":" in server else socket.AF_INET
+    with socket.socket(family, socket.SOCK_DGRAM) as s:
+        s.settimeout(5)
+        try:
+            s.sendto(query, (server, PORT))
+            return s.recv(65535)
+        except OSError:
+            return None
+
+
+def handle(sock, records, server, query, addr):
+    try:
+        name, qtype, qend = question(query)
+    except (IndexError, struct.error):
+        return
+    entry = records.lookup(name)
+    if entry.get("drop"):
+        return
+    if entry.get("delayMs"):
+        time.sleep(entry["delayMs"] / 1000)
+
+    if entry.get("rcode"):
+        reply = response(query, qend, entry["rcode"], [])
+    elif entry.get("a") or entry.get("aaaa"):
+        ttl = entry.get("ttl", 0)
+        answers = []
+        if qtype in (TYPE_A, TYPE_ANY):
+            answers += [answer(TYPE_A, ttl, socket.inet_pton(socket.AF_INET, a)) for a in entry.get("a", [])]
+        if qtype in (TYPE_AAAA, TYPE_ANY):
+            answers += [answer(TYPE_AAAA, ttl, socket.inet_pton(socket.AF_INET6, a)) for a in entry.get("aaaa", [])]
+        reply = response(query, qend, 0, answers)
+    else:
+        reply = forward(query, server)
+        if reply is None:
+            reply = response(query, qend, RCODE_SERVFAIL, [])
+    sock.sendto(reply, addr)
+
+
+def main():
+    records = Records()
+    server = upstream()
+    sock = socket.socket(socket.AF_INET, socket.SOCK_DGRAM)
+    sock.bind(("0.0.0.0", PORT))
+    while True:
+        query, addr = sock.recvfrom(65535)
+        threading.Thread(target=handle, args=(sock, records, server, query, addr), daemon=True).start()
+
+
+main()
@@ -0,0 +1,16 @@
+package identity
+
+import (
+	"github.com/celestiaorg/knuu/pkg/errors"
+)
+
+type Error = errors.Error
+
+var (
+	ErrGeneratingKey         = errors.New("GeneratingKey", "error generating the %s key of '%s'")
+	ErrEncodingKey           = errors.New("EncodingKey", "error encoding the %s key of '%s'")
+	ErrCreatingCertificate   = errors.New("CreatingCertificate", "error creating the certificate of '%s'")
+	ErrGeneratingSerial      = errors.New("GeneratingSerial", "error generating the serial number of a certificate")
+	ErrAddingIdentityFiles   = errors.New("AddingIdentityFiles", "error adding the identity files to '%s' of instance '%s'")
+	ErrLoadingTLSCertificate = errors.New("LoadingTLSCertificate", "error loading the TLS certificate of '%s'")
+)
@@ -0,0 +1,257 @@
+// Package identity generates the keys and the certificates of the instances of a network, e.g. the ed25519 keys
+// of the nodes and their TLS certificates signed by a certificate authority of the test, and adds them to the
+// instances as files with consistent names. The files are delivered through secrets, so the private keys are not
+// part of the images pushed to the registry.
+package identity
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// DefaultDir is the directory of the identity files in the instances
+	DefaultDir = "/etc/knuu/identity"
+
+	// NodeKeyFile is the PEM encoded ed25519 private key of the node, in PKCS #8
+	NodeKeyFile = "node.key"
+	// NodePubFile is the PEM encoded ed25519 public key of the node, in PKIX
+	NodePubFile = "node.pub"
+	// CertFile is the PEM encoded TLS certificate of the instance, signed by the CA
+	CertFile = "tls.crt"
+	// KeyFile is the PEM encoded private key of the TLS certificate, in PKCS #8
+	KeyFile = "tls.key"
+	// CAFile is the PEM encoded certificate of the CA, to verify the certificates of the other instances
+	CAFile = "ca.crt"
+
+	// validity is the validity of the certificates, far longer than a test
+	validity = 365 * 24 * time.Hour
+	// clockSkew backdates the certificates, so they are valid on nodes whose clock is slightly late
+	clockSkew = 5 * time.Minute
+)
+
+// Target is an instance the identities are added to, e.g. an *instance.Instance
+type Target interface {
+	Name() string
+	HostName() string
+	AddSecretFiles(dir string, files map[string][]byte) error
+}
+
+// CA is a certificate authority that issues the identities of the instances of a test
+type CA struct {
+	cert    *x509.Certificate
+	key     crypto.Signer
+	certPEM []byte
+}
+
+// NewCA returns a new self signed certificate authority with the given common name
+func NewCA(commonName string) (*CA, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, ErrGeneratingKey.WithParams("CA", commonName).Wrap(err)
+	}
+	template, err := certificateTemplate(commonName)
+	if err != nil {
+		return nil, err
+	}
+	template.IsCA = true
+	template.BasicConstraintsValid = true
+	template.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	if err != nil {
+		return nil, ErrCreatingCertificate.WithParams(commonName).Wrap(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, ErrCreatingCertificate.WithParams(commonName).Wrap(err)
+	}
+	return &CA{
+		cert:    cert,
+		key:     key,
+		certPEM: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+	}, nil
+}
+
+// CertPEM returns the PEM encoded certificate of the CA
+func (ca *CA) CertPEM() []byte {
+	return ca.certPEM
+}
+
+// CertPool returns a pool with the certificate of the CA, e.g. to verify the instances from the test
+func (ca *CA) CertPool() *x509.CertPool {
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.cert)
+	return pool
+}
+
+// Issue generates the identity of the given name: a node key and a TLS certificate valid for the name and the
+// given hosts, which are IP addresses or DNS names
+// The certificate can authenticate both a server and a client, for mutual TLS between the nodes.
+func (ca *CA) Issue(name string, hosts ...string) (*Identity, error) {
+	_, nodeKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, ErrGeneratingKey.WithParams("node", name).Wrap(err)
+	}
+	tlsKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, ErrGeneratingKey.WithParams("TLS", name).Wrap(err)
+	}
+
+	template, err := certificateTemplate(name)
+	if err != nil {
+		return nil, err
+	}
+	template.KeyUsage = x509.KeyUsageDigitalSignature
+	template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth}
+	for _, host := range append([]string{name}, hosts...) {
+		if ip := net.ParseIP(host); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, host)
+		}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, tlsKey.Public(), ca.key)
+	if err != nil {
+		return nil, ErrCreatingCertificate.WithParams(name).Wrap(err)
+	}
+	nodeKeyPEM, err := encodePrivateKey(nodeKey)
+	if err != nil {
+		return nil, ErrEncodingKey.WithParams("node", name).Wrap(err)
+	}
+	nodePubDER, err := x509.MarshalPKIXPublicKey(nodeKey.Public())
+	if err != nil {
+		return nil, ErrEncodingKey.WithParams("node", name).Wrap(err)
+	}
+	tlsKeyPEM, err := encodePrivateKey(tlsKey)
+	if err != nil {
+		return nil, ErrEncodingKey.WithParams("TLS", name).Wrap(err)
+	}
+
+	return &Identity{
+		Name:       name,
+		NodeKey:    nodeKey,
+		NodeKeyPEM: nodeKeyPEM,
+		NodePubPEM: pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: nodePubDER}),
+		CertPEM:    pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		KeyPEM:     tlsKeyPEM,
+		CAPEM:      ca.certPEM,
+	}, nil
+}
+
+// Inject issues the identity of the instance, valid for its name and the host name of its service, and adds its
+// files to the given directory of the instance, DefaultDir if empty, see AddTo
+func (ca *CA) Inject(i Target, dir string) (*Identity, error) {
+	id, err := ca.Issue(i.Name(), i.HostName(), "localhost", "127.0.0.1")
+	if err != nil {
+		return nil, err
+	}
+	if err := id.AddTo(i, dir); err != nil {
+		return nil, err
+	}
+	return id, nil
+}
+
+// InjectAll injects an identity in each of the instances, see Inject, and returns them in the same order
+func (ca *CA) InjectAll(instances []Target, dir string) ([]*Identity, error) {
+	ids := make([]*Identity, 0, len(instances))
+	for _, i := range instances {
+		id, err := ca.Inject(i, dir)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// Identity is the node key and the TLS certificate of an instance
+type Identity struct {
+	Name string
+	// NodeKey is the ed25519 key of the node, e.g. its p2p identity
+	NodeKey ed25519.PrivateKey
+	// NodeKeyPEM and NodePubPEM are the PEM encoded node key and its public key
+	NodeKeyPEM []byte
+	NodePubPEM []byte
+	// CertPEM and KeyPEM are the PEM encoded TLS certificate and its key, CAPEM is the certificate of the CA
+	CertPEM []byte
+	KeyPEM  []byte
+	CAPEM   []byte
+}
+
+// NodePublicKey returns the ed25519 public key of the node
+func (id *Identity) NodePublicKey() ed25519.PublicKey {
+	return id.NodeKey.Public().(ed25519.PublicKey)
+}
+
+// TLSCertificate returns the TLS certificate of the identity, e.g. for a client of the test
+func (id *Identity) TLSCertificate() (tls.Certificate, error) {
+	cert, err := tls.X509KeyPair(id.CertPEM, id.KeyPEM)
+	if err != nil {
+		return tls.Certificate{}, ErrLoadingTLSCertificate.WithParams(id.Name).Wrap(err)
+	}
+	return cert, nil
+}
+
+// Files returns the content of the files of the identity, by file name
+func (id *Identity) Files() map[string][]byte {
+	return map[string][]byte{
+		NodeKeyFile: id.NodeKeyPEM,
+		NodePubFile: id.NodePubPEM,
+		CertFile:    id.CertPEM,
+		KeyFile:     id.KeyPEM,
+		CAFile:      id.CAPEM,
+	}
+}
+
+// AddTo adds the files of the identity to the given directory of the instance, DefaultDir if empty
+// The files are delivered through a secret mounted when the instance starts, see instance.AddSecretFiles, so the
+// directory only holds the identity.
+// This function can only be called in the states 'Preparing' and 'Committed'
+func (id *Identity) AddTo(i Target, dir string) error {
+	if dir == "" {
+		dir = DefaultDir
+	}
+	if err := i.AddSecretFiles(dir, id.Files()); err != nil {
+		return ErrAddingIdentityFiles.WithParams(dir, i.Name()).Wrap(err)
+	}
+	logrus.Debugf("Added identity '%s' to '%s' of instance '%s'", id.Name, dir, i.Name())
+	return nil
+}
+
+// certificateTemplate returns the template of a certificate with the given common name, valid from now
+func certificateTemplate(commonName string) (*x509.Certificate, error) {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, ErrGeneratingSerial.Wrap(err)
+	}
+	now := time.Now()
+	return &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    now.Add(-clockSkew),
+		NotAfter:     now.Add(validity),
+	}, nil
+}
+
+// encodePrivateKey returns the PEM encoded PKCS #8 form of the key
+func encodePrivateKey(key crypto.PrivateKey) ([]byte, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+}
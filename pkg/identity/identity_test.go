@@ -0,0 +1,110 @@
+package identity
+
+import (
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIssue(t *testing.T) {
+	t.Parallel()
+
+	ca, err := NewCA("knuu-test")
+	require.NoError(t, err)
+	id, err := ca.Issue("validator-0", "validator-0-abcd", "10.0.0.1")
+	require.NoError(t, err)
+
+	block, _ := pem.Decode(id.CertPEM)
+	require.NotNil(t, block)
+	cert, err := x509.ParseCertificate(block.Bytes)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"validator-0", "validator-0-abcd"}, cert.DNSNames)
+	assert.Equal(t, "10.0.0.1", cert.IPAddresses[0].String())
+	for _, usage := range []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth} {
+		_, err = cert.Verify(x509.VerifyOptions{
+			DNSName:   "validator-0-abcd",
+			Roots:     ca.CertPool(),
+			KeyUsages: []x509.ExtKeyUsage{usage},
+		})
+		assert.NoError(t, err)
+	}
+
+	other, err := NewCA("other")
+	require.NoError(t, err)
+	_, err = cert.Verify(x509.VerifyOptions{Roots: other.CertPool()})
+	assert.Error(t, err, "the certificate is only trusted by its CA")
+
+	_, err = id.TLSCertificate()
+	require.NoError(t, err)
+
+	block, _ = pem.Decode(id.NodeKeyPEM)
+	require.NotNil(t, block)
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	require.NoError(t, err)
+	assert.Equal(t, id.NodeKey, key)
+	block, _ = pem.Decode(id.NodePubPEM)
+	require.NotNil(t, block)
+	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	require.NoError(t, err)
+	assert.Equal(t, id.NodePublicKey(), pub.(ed25519.PublicKey))
+
+	second, err := ca.Issue("validator-1")
+	require.NoError(t, err)
+	assert.NotEqual(t, id.NodeKeyPEM, second.NodeKeyPEM)
+	assert.Equal(t, id.CAPEM, second.CAPEM)
+	assert.Equal(t, ca.CertPEM(), id.Files()[CAFile])
+}
+
+// fakeTarget records the files added to it
+type fakeTarget struct {
+	name  string
+	files map[string][]byte
+	err   error
+}
+
+func (t *fakeTarget) Name() string     { return t.name }
+func (t *fakeTarget) HostName() string { return t.name + "-svc" }
+
+func (t *fakeTarget) AddSecretFiles(dir string, files map[string][]byte) error {
+	if t.err != nil {
+		return t.err
+	}
+	if t.files == nil {
+		t.files = make(map[string][]byte)
+	}
+	for name, content := range files {
+		t.files[path.Join(dir, name)] = content
+	}
+	return nil
+}
+
+func TestInjectAll(t *testing.T) {
+	t.Parallel()
+
+	ca, err := NewCA("knuu-test")
+	require.NoError(t, err)
+	targets := []*fakeTarget{{name: "validator-0"}, {name: "validator-1"}}
+
+	ids, err := ca.InjectAll([]Target{targets[0], targets[1]}, "")
+	require.NoError(t, err)
+	require.Len(t, ids, 2)
+	assert.Equal(t, "validator-1", ids[1].Name)
+	assert.NotEqual(t, ids[0].NodeKeyPEM, ids[1].NodeKeyPEM)
+	assert.Equal(t, ids[0].CertPEM, targets[0].files[DefaultDir+"/"+CertFile])
+	assert.Len(t, targets[1].files, 5)
+	cert, err := ids[0].TLSCertificate()
+	require.NoError(t, err)
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	require.NoError(t, err)
+	assert.Contains(t, leaf.DNSNames, targets[0].HostName())
+
+	failing := &fakeTarget{name: "light", err: errors.New("not preparing")}
+	_, err = ca.Inject(failing, "/keys")
+	assert.ErrorIs(t, err, ErrAddingIdentityFiles)
+}
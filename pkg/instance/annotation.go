@@ -0,0 +1,26 @@
+package instance
+
+import (
+	"context"
+	"time"
+
+	"github.com/celestiaorg/knuu/pkg/annotation"
+)
+
+// annotateFault marks a fault injected in the instance at the given time on the timeline of the observability
+// tools, see annotation.Annotator
+// It is meant to be deferred with a pointer to the error returned by the operation, the failed operations did not
+// perturb the instance and are not annotated.
+func (i *Instance) annotateFault(ctx context.Context, kind annotation.Kind, start time.Time, text string, err *error) {
+	// the pods of the attached workloads are not modified
+	if i.Annotator == nil || *err != nil || i.attached != nil {
+		return
+	}
+	annotation.Emit(context.WithoutCancel(ctx), i.Annotator, annotation.Annotation{
+		Time:     start,
+		Kind:     kind,
+		Instance: i.k8sName,
+		Scope:    i.TestScope,
+		Text:     text,
+	})
+}
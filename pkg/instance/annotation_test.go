@@ -0,0 +1,44 @@
+package instance
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/celestiaorg/knuu/pkg/annotation"
+)
+
+func TestAnnotateFault(t *testing.T) {
+	t.Parallel()
+
+	i, _ := startedInstanceWithArchive(t, "")
+	i.TestScope = "test-scope"
+	var (
+		mu          sync.Mutex
+		annotations []annotation.Annotation
+	)
+	i.Annotator = annotation.AnnotatorFunc(func(_ context.Context, a annotation.Annotation) error {
+		mu.Lock()
+		defer mu.Unlock()
+		annotations = append(annotations, a)
+		return nil
+	})
+
+	ctx := context.Background()
+	require.NoError(t, i.DisableNetwork(ctx))
+	require.NoError(t, i.EnableNetwork(ctx))
+	// bitTwister is not enabled, so the fault fails and is not annotated
+	assert.Error(t, i.SetLatencyAndJitter(100, 10))
+
+	require.Len(t, annotations, 2)
+	assert.Equal(t, annotation.KindNetworkDisabled, annotations[0].Kind)
+	assert.Equal(t, annotation.KindNetworkEnabled, annotations[1].Kind)
+	for _, a := range annotations {
+		assert.Equal(t, i.k8sName, a.Instance)
+		assert.Equal(t, "test-scope", a.Scope)
+		assert.False(t, a.Time.IsZero())
+	}
+}
@@ -0,0 +1,255 @@
+package instance
+
+import (
+	"context"
+	_ "embed"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/celestiaorg/knuu/pkg/k8s"
+)
+
+const (
+	apiProxyName  = "api-proxy"
+	apiProxyImage = "docker.io/library/python:3.12-alpine"
+	// apiProxyPort is the port of the proxy on the localhost of the pod
+	apiProxyPort      = 8001
+	apiProxyRulesDir  = "/tmp/knuu-api-proxy"
+	apiProxyRulesFile = apiProxyRulesDir + "/rules.json"
+
+	// kubeconfigDir holds the kubeconfig pointing the clients of the instance to the proxy
+	kubeconfigDir  = "/etc/knuu/kube"
+	kubeconfigFile = "config"
+	kubeconfigEnv  = "KUBECONFIG"
+)
+
+//go:embed apiproxy.py
+var apiProxyScript string
+
+// APIFault is a fault injected in the requests of an instance to the Kubernetes API, see EnableAPIFaults
+type APIFault struct {
+	// Methods are the HTTP methods of the requests, e.g. PUT or PATCH, all if empty
+	// A watch is a GET request with the parameter 'watch=true'.
+	Methods []string
+	// Path is a regular expression matching the paths of the requests with their query, e.g.
+	// '^/api/v1/namespaces/[^/]+/configmaps', all if empty
+	Path string
+	// Status is the status code of the failure returned instead of forwarding the request, e.g. 429 to simulate
+	// the throttling of the API server or 503 an outage, the request is forwarded if 0
+	Status int
+	// Delay delays the request before it fails or is forwarded
+	Delay time.Duration
+	// Drop closes the connection without a response, like an unreachable API server
+	Drop bool
+	// Probability is the probability of a matching request to be faulted, between 0 and 1, always if 0
+	Probability float64
+}
+
+func (f APIFault) validate() error {
+	if _, err := regexp.Compile(f.Path); err != nil {
+		return ErrInvalidAPIFault.WithParams(fmt.Sprintf("invalid path '%s'", f.Path)).Wrap(err)
+	}
+	if f.Status != 0 && (f.Status < 100 || f.Status > 599) {
+		return ErrInvalidAPIFault.WithParams(fmt.Sprintf("invalid status %d", f.Status))
+	}
+	if f.Delay < 0 {
+		return ErrInvalidAPIFault.WithParams("the delay must not be negative")
+	}
+	if f.Probability < 0 || f.Probability > 1 {
+		return ErrInvalidAPIFault.WithParams(fmt.Sprintf("the probability %g is not between 0 and 1", f.Probability))
+	}
+	return nil
+}
+
+// apiFaultRule is a fault in the rules file read by the proxy, the first matching rule applies
+type apiFaultRule struct {
+	Methods     []string `json:"methods,omitempty"`
+	Path        string   `json:"path,omitempty"`
+	Status      int      `json:"status,omitempty"`
+	DelayMs     int64    `json:"delayMs,omitempty"`
+	Drop        bool     `json:"drop,omitempty"`
+	Probability float64  `json:"probability"`
+}
+
+// EnableAPIFaults routes the requests of the instance to the Kubernetes API through a proxy sidecar, so that
+// faults can be injected in them with InjectAPIFaults, e.g. to test an operator under the throttling or a partial
+// outage of the API server
+// The proxy authenticates with the service account of the instance, see AddPolicyRule. The instance reaches it with
+// the kubeconfig file given by the environment variable KUBECONFIG, which the clients built with clientcmd or
+// controller-runtime read; the clients using only the in-cluster configuration bypass the proxy. The proxy listens
+// on the port 8001 of localhost.
+// This function can only be called in the states 'Preparing' and 'Committed'
+func (i *Instance) EnableAPIFaults() error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if !i.allows(ActionConfigure) {
+		return ErrEnablingAPIFaultsNotAllowed.WithParams(i.getState().String())
+	}
+	if i.isSidecar {
+		return ErrEnablingAPIFaultsOfSidecar
+	}
+
+	kubeconfig := kubeconfigDir + "/" + kubeconfigFile
+	if i.IsInState(Preparing) {
+		builder, err := i.builder()
+		if err != nil {
+			return err
+		}
+		if err := builder.SetEnvVar(kubeconfigEnv, kubeconfig); err != nil {
+			return ErrEnablingAPIFaults.WithParams(i.name).Wrap(err)
+		}
+	} else {
+		i.env[kubeconfigEnv] = kubeconfig
+	}
+	i.apiFaultsEnabled = true
+	logrus.Debugf("Enabled API faults for instance '%s'", i.name)
+	return nil
+}
+
+// InjectAPIFaults replaces the faults injected in the requests of the instance to the Kubernetes API, the first
+// fault matching a request applies. Calling it without faults clears them.
+// This function can only be called in the state 'Started'
+func (i *Instance) InjectAPIFaults(ctx context.Context, faults ...APIFault) error {
+	if !i.allows(ActionOperate) {
+		return ErrInjectingAPIFaultsNotAllowed.WithParams(i.getState().String())
+	}
+	i.mu.Lock()
+	proxy := i.apiProxy
+	i.mu.Unlock()
+	if proxy == nil {
+		return ErrAPIFaultsNotEnabled.WithParams(i.name)
+	}
+
+	command, err := writeAPIFaultRulesCommand(faults)
+	if err != nil {
+		return err
+	}
+	if _, err := proxy.ExecuteCommand(ctx, command); err != nil {
+		return ErrInjectingAPIFaults.WithParams(i.name).Wrap(err)
+	}
+	logrus.Debugf("Injected %d API faults in instance '%s'", len(faults), i.name)
+	return nil
+}
+
+// ClearAPIFaults removes the faults injected in the requests of the instance to the Kubernetes API
+// This function can only be called in the state 'Started'
+func (i *Instance) ClearAPIFaults(ctx context.Context) error {
+	return i.InjectAPIFaults(ctx)
+}
+
+// addAPIProxySidecar adds the proxy of the Kubernetes API to the pod of the instance
+func (i *Instance) addAPIProxySidecar(ctx context.Context) error {
+	proxy, err := New(apiProxyName, i.SystemDependencies)
+	if err != nil {
+		return ErrCreatingAPIProxyInstance.Wrap(err)
+	}
+	if err := proxy.SetImage(ctx, apiProxyImage); err != nil {
+		return ErrCreatingAPIProxyInstance.Wrap(err)
+	}
+	if err := proxy.Commit(); err != nil {
+		return ErrCreatingAPIProxyInstance.Wrap(err)
+	}
+	if err := proxy.SetCommand("python3", "-c", apiProxyScript, strconv.Itoa(apiProxyPort), apiProxyRulesFile); err != nil {
+		return ErrCreatingAPIProxyInstance.Wrap(err)
+	}
+	if err := i.addSidecar(proxy); err != nil {
+		return ErrAddingAPIProxySidecar.WithParams(i.k8sName).Wrap(err)
+	}
+	i.apiProxy = proxy
+	return nil
+}
+
+// kubeconfigSecretName returns the name of the secret holding the kubeconfig of the instance
+func (i *Instance) kubeconfigSecretName() string {
+	return i.k8sName + "-kubeconfig"
+}
+
+// deployKubeconfigSecret deploys the kubeconfig pointing the clients of the instance to the proxy
+func (i *Instance) deployKubeconfigSecret(ctx context.Context) error {
+	data := map[string][]byte{kubeconfigFile: []byte(apiProxyKubeconfig(i.K8sCli.Namespace()))}
+	if _, err := i.K8sCli.CreateSecret(ctx, i.kubeconfigSecretName(), i.getLabels(), data); err != nil {
+		return ErrDeployingKubeconfigSecret.WithParams(i.name).Wrap(err)
+	}
+	logrus.Debugf("Deployed kubeconfig secret '%s'", i.kubeconfigSecretName())
+	return nil
+}
+
+// destroyKubeconfigSecret destroys the secret holding the kubeconfig of the instance
+func (i *Instance) destroyKubeconfigSecret(ctx context.Context) error {
+	if err := i.K8sCli.DeleteSecret(ctx, i.kubeconfigSecretName()); err != nil {
+		return ErrDestroyingKubeconfigSecret.WithParams(i.name).Wrap(err)
+	}
+	logrus.Debugf("Destroyed kubeconfig secret '%s'", i.kubeconfigSecretName())
+	return nil
+}
+
+// kubeconfigSecretMounts returns the mount of the kubeconfig secret in the container of the instance, if the API
+// faults are enabled
+func (i *Instance) kubeconfigSecretMounts() []*k8s.SecretMount {
+	if !i.apiFaultsEnabled {
+		return nil
+	}
+	return []*k8s.SecretMount{{Secret: i.kubeconfigSecretName(), Path: kubeconfigDir}}
+}
+
+// apiProxyKubeconfig returns the kubeconfig of the proxy on localhost, in the given namespace
+func apiProxyKubeconfig(namespace string) string {
+	return fmt.Sprintf(`apiVersion: v1
+kind: Config
+clusters:
+- name: knuu-api-proxy
+  cluster:
+    server: http://127.0.0.1:%d
+users:
+- name: knuu-api-proxy
+  user: {}
+contexts:
+- name: knuu-api-proxy
+  context:
+    cluster: knuu-api-proxy
+    user: knuu-api-proxy
+    namespace: %s
+current-context: knuu-api-proxy
+`, apiProxyPort, namespace)
+}
+
+// writeAPIFaultRulesCommand returns the shell command replacing the rules file of the proxy
+// The file is replaced with a rename, so the proxy never reads a partially written file.
+func writeAPIFaultRulesCommand(faults []APIFault) (string, error) {
+	rules := make([]apiFaultRule, 0, len(faults))
+	for _, fault := range faults {
+		if err := fault.validate(); err != nil {
+			return "", err
+		}
+		methods := make([]string, 0, len(fault.Methods))
+		for _, method := range fault.Methods {
+			methods = append(methods, strings.ToUpper(method))
+		}
+		probability := fault.Probability
+		if probability == 0 {
+			probability = 1
+		}
+		rules = append(rules, apiFaultRule{
+			Methods:     methods,
+			Path:        fault.Path,
+			Status:      fault.Status,
+			DelayMs:     fault.Delay.Milliseconds(),
+			Drop:        fault.Drop,
+			Probability: probability,
+		})
+	}
+	encoded, err := json.Marshal(rules)
+	if err != nil {
+		return "", ErrMarshallingAPIFaults.Wrap(err)
+	}
+	return fmt.Sprintf("mkdir -p %s && echo %s | base64 -d > %s.tmp && mv %s.tmp %s",
+		apiProxyRulesDir, base64.StdEncoding.EncodeToString(encoded), apiProxyRulesFile, apiProxyRulesFile, apiProxyRulesFile), nil
+}
@@ -0,0 +1,70 @@
+package instance
+
+import (
+	"context"
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/celestiaorg/knuu/pkg/k8s/fake"
+	"github.com/celestiaorg/knuu/pkg/system"
+)
+
+func TestWriteAPIFaultRulesCommand(t *testing.T) {
+	t.Parallel()
+
+	command, err := writeAPIFaultRulesCommand([]APIFault{
+		{Methods: []string{"put", "PATCH"}, Path: "^/api/v1/namespaces/[^/]+/configmaps", Status: 429, Probability: 0.5},
+		{Delay: 2 * time.Second, Drop: true},
+	})
+	require.NoError(t, err)
+
+	fields := strings.Fields(command)
+	require.Greater(t, len(fields), 5)
+	rules, err := base64.StdEncoding.DecodeString(fields[5])
+	require.NoError(t, err)
+	assert.JSONEq(t, `[
+		{"methods": ["PUT", "PATCH"], "path": "^/api/v1/namespaces/[^/]+/configmaps", "status": 429, "probability": 0.5},
+		{"delayMs": 2000, "drop": true, "probability": 1}
+	]`, string(rules))
+	assert.True(t, strings.HasSuffix(command, "mv "+apiProxyRulesFile+".tmp "+apiProxyRulesFile))
+
+	for _, fault := range []APIFault{{Path: "("}, {Status: 42}, {Delay: -time.Second}, {Probability: 1.5}} {
+		_, err := writeAPIFaultRulesCommand([]APIFault{fault})
+		assert.ErrorIs(t, err, ErrInvalidAPIFault, fault)
+	}
+}
+
+func TestEnableAPIFaults(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	k8sCli, err := fake.New(ctx, "test")
+	require.NoError(t, err)
+	i, err := New("operator", system.SystemDependencies{K8sCli: k8sCli}, WithImage("alpine"))
+	require.NoError(t, err)
+	require.NoError(t, i.Commit())
+	require.NoError(t, i.EnableAPIFaults())
+	assert.Equal(t, kubeconfigDir+"/"+kubeconfigFile, i.env[kubeconfigEnv])
+	assert.ErrorIs(t, i.InjectAPIFaults(ctx), ErrInjectingAPIFaultsNotAllowed)
+
+	tracker := newResourceTracker(i.k8sName)
+	i.deployResources(ctx, tracker)
+	require.False(t, tracker.hasFailures())
+	secret, err := k8sCli.FakeClientset.CoreV1().Secrets(k8sCli.Namespace()).Get(ctx, i.kubeconfigSecretName(), metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Contains(t, string(secret.Data[kubeconfigFile]), "server: http://127.0.0.1:8001")
+	assert.Contains(t, string(secret.Data[kubeconfigFile]), "namespace: "+k8sCli.Namespace())
+
+	pod, err := k8sCli.DeployPod(ctx, i.preparePodConfig(), false)
+	require.NoError(t, err)
+	assert.Contains(t, pod.Spec.Containers[0].VolumeMounts, v1.VolumeMount{Name: i.kubeconfigSecretName(), MountPath: kubeconfigDir, ReadOnly: true})
+
+	assert.True(t, i.cloneWithSuffix("-1").apiFaultsEnabled)
+}
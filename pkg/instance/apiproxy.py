@@ -0,0 +1,127 @@
+# Proxy of the Kubernetes API for the containers of the pod, injecting the faults of the rules file.
+# It listens on localhost without TLS and authenticates to the API server with the service account of the pod.
+# Usage: python3 apiproxy.py <port> <rules file>
+import http.client
+import http.server
+import json
+import os
+import random
+import re
+import ssl
+import sys
+import threading
+import time
+
+PORT = int(sys.argv[1])
+RULES_FILE = sys.argv[2]
+SERVICE_ACCOUNT = "/var/run/secrets/kubernetes.io/serviceaccount"
+UPSTREAM_HOST = os.environ["KUBERNETES_SERVICE_HOST"]
+UPSTREAM_PORT = int(os.environ.get("KUBERNETES_SERVICE_PORT", "443"))
+TLS = ssl.create_default_context(cafile=SERVICE_ACCOUNT + "/ca.crt")
+
+# headers of a single connection, and the ones set by the proxy
+SKIPPED_HEADERS = {
+    "connection", "keep-alive", "proxy-authenticate", "proxy-authorization", "te", "trailers",
+    "transfer-encoding", "upgrade", "host", "authorization", "content-length",
+}
+REASONS = {
+    403: "Forbidden", 404: "NotFound", 409: "Conflict", 410: "Expired", 429: "TooManyRequests",
+    500: "InternalError", 503: "ServiceUnavailable", 504: "Timeout",
+}
+
+lock = threading.Lock()
+loaded = {"version": None, "rules": []}
+
+
+def rules():
+    # the file is replaced with a rename, so a new inode means new rules
+    try:
+        st = os.stat(RULES_FILE)
+    except FileNotFoundError:
+        return []
+    version = (st.st_ino, st.st_mtime_ns)
+    with lock:
+        if version != loaded["version"]:
+            with open(RULES_FILE) as f:
+                parsed = json.load(f)
+            for rule in parsed:
+                rule["re"] = re.compile(rule.get("path") or "")
+            loaded["version"], loaded["rules"] = version, parsed
+        return loaded["rules"]
+
+
+def match(method, path):
+    for rule in rules():
+        if rule.get("methods") and method not in rule["methods"]:
+            continue
+        if not rule["re"].search(path):
+            continue
+        if random.random() >= rule.get("probability", 1):
+            continue
+        return rule
+    return None
+
+
+class Proxy(http.server.BaseHTTPRequestHandler):
+    protocol_version = "HTTP/1.1"
+
+    def log_message(self, *args):
+        pass
+
+    def proxy(self):
+        length = int(self.headers.get("Content-Length") or 0)
+        body = self.rfile.read(length) if length else None
+
+        rule = match(self.command, self.path)
+        if rule:
+            if rule.get("delayMs"):
+                time.sleep(rule["delayMs"] / 1000)
+            if rule.get("drop"):
+                self.close_connection = True
+                return
+            if rule.get("status"):
+                self.fail(rule["status"])
+                return
+
+        with open(SERVICE_ACCOUNT + "/token") as f:
+            token = f.read().strip()
+        headers = {k: v for k, v in self.headers.items() if k.lower() not in SKIPPED_HEADERS}
+        headers["Authorization"] = "Bearer " + token
+        conn = http.client.HTTPSConnection(UPSTREAM_HOST, UPSTREAM_PORT, context=TLS)
+        try:
+            conn.request(self.command, self.path, body=body, headers=headers)
+            resp = conn.getresponse()
+            self.send_response(resp.status)
+            for k, v in resp.getheaders():
+                if k.lower() not in SKIPPED_HEADERS:
+                    self.send_header(k, v)
+            # the body is streamed, e.g. the events of a watch
+            self.send_header("Transfer-Encoding", "chunked")
+            self.end_headers()
+            while True:
+                chunk = resp.read1(65536)
+                if not chunk:
+                    break
+                self.wfile.write(b"%x\r\n%s\r\n" % (len(chunk), chunk))
+                self.wfile.flush()
+            self.wfile.write(b"0\r\n\r\n")
+        finally:
+            conn.close()
+
+    def fail(self, status):
+        body = json.dumps({
+            "kind": "Status", "apiVersion": "v1", "metadata": {}, "status": "Failure",
+            "message": "fault injected by knuu", "reason": REASONS.get(status, ""), "code": status,
+        }).encode()
+        self.send_response(status)
+        self.send_header("Content-Type", "application/json")
+        self.send_header("Content-Length", str(len(body)))
+        if status == 429:
+            self.send_header("Retry-After", "1")
+        self.end_headers()
+        self.wfile.write(body)
+
+    do_GET = do_POST = do_PUT = do_PATCH = do_DELETE = proxy
+
+
+http.server.ThreadingHTTPServer(("127.0.0.1", PORT), Proxy).serve_forever()
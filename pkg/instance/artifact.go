@@ -0,0 +1,31 @@
+package instance
+
+import (
+	"context"
+
+	"github.com/sirupsen/logrus"
+)
+
+// UploadArtifact stores the file at the given path of the running instance in the artifact store under the given key,
+// so it can be retrieved after the pod of the instance is gone. See artifact.Store for the keys.
+// This function can only be called in the state 'Started'
+func (i *Instance) UploadArtifact(ctx context.Context, path, key string) error {
+	if !i.allows(ActionOperate) {
+		return ErrUploadingArtifactNotAllowed.WithParams(i.getState().String())
+	}
+	if i.Artifacts == nil {
+		return ErrArtifactStoreNotInitialized
+	}
+
+	rc, err := i.ReadFileFromRunningInstance(ctx, path)
+	if err != nil {
+		return ErrUploadingArtifact.WithParams(path, i.k8sName).Wrap(err)
+	}
+	defer rc.Close()
+
+	if err := i.Artifacts.Put(ctx, key, rc); err != nil {
+		return ErrUploadingArtifact.WithParams(path, i.k8sName).Wrap(err)
+	}
+	logrus.Debugf("Uploaded file '%s' of instance '%s' as artifact '%s'", path, i.k8sName, key)
+	return nil
+}
@@ -0,0 +1,71 @@
+package instance
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/celestiaorg/knuu/pkg/artifact"
+	"github.com/celestiaorg/knuu/pkg/k8s"
+	"github.com/celestiaorg/knuu/pkg/minio"
+	"github.com/celestiaorg/knuu/pkg/system"
+)
+
+// fakeFiles serves the content of the files of the pods with cat, the other methods of the interface are not implemented
+type fakeFiles struct {
+	k8s.KubeManager
+	files map[string]string
+}
+
+func (f *fakeFiles) GetFirstPodFromReplicaSet(_ context.Context, name string) (*v1.Pod, error) {
+	return &v1.Pod{}, nil
+}
+
+func (f *fakeFiles) RunCommandInPod(_ context.Context, _, _ string, cmd []string) (string, error) {
+	return f.files[strings.TrimPrefix(cmd[len(cmd)-1], "cat ")], nil
+}
+
+// fakeArtifacts keeps the pushed files in memory
+type fakeArtifacts struct {
+	minio.Client
+	files map[string]string
+}
+
+func (f *fakeArtifacts) IsMinioDeployed(context.Context) (bool, error) {
+	return true, nil
+}
+
+func (f *fakeArtifacts) PushToMinio(_ context.Context, localReader io.Reader, minioFilePath, bucketName string) error {
+	data, err := io.ReadAll(localReader)
+	if err != nil {
+		return err
+	}
+	f.files[bucketName+"/"+minioFilePath] = string(data)
+	return nil
+}
+
+func TestUploadArtifact(t *testing.T) {
+	t.Parallel()
+
+	store := &fakeArtifacts{files: map[string]string{}}
+	i, err := New("validator", system.SystemDependencies{
+		K8sCli:    &fakeFiles{files: map[string]string{"/tmp/cpu.pprof": "profile"}},
+		Artifacts: artifact.NewStore(store),
+	}, WithImage("alpine"))
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	assert.ErrorIs(t, i.UploadArtifact(ctx, "/tmp/cpu.pprof", "validator/cpu.pprof"), ErrUploadingArtifactNotAllowed)
+
+	i.state = Started
+	require.NoError(t, i.UploadArtifact(ctx, "/tmp/cpu.pprof", "validator/cpu.pprof"))
+	assert.Equal(t, "profile", store.files[artifact.Bucket+"/validator/cpu.pprof"])
+
+	i.Artifacts = nil
+	assert.ErrorIs(t, i.UploadArtifact(ctx, "/tmp/cpu.pprof", "validator/cpu.pprof"), ErrArtifactStoreNotInitialized)
+}
@@ -0,0 +1,127 @@
+package instance
+
+import (
+	"context"
+	"slices"
+
+	"github.com/sirupsen/logrus"
+	appv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/celestiaorg/knuu/pkg/k8s"
+	"github.com/celestiaorg/knuu/pkg/system"
+)
+
+const (
+	workloadDeployment  = "Deployment"
+	workloadStatefulSet = "StatefulSet"
+
+	// attachedNetworkPolicyPrefix prefixes the name of the network policy disabling the network of a workload
+	attachedNetworkPolicyPrefix = "knuu-disable-network-"
+)
+
+// attachedWorkload is a workload not created by knuu that an attached instance is a handle on
+type attachedWorkload struct {
+	// kind is the kind of the workload, Deployment or StatefulSet
+	kind string
+	// selector selects the pods of the workload
+	selector map[string]string
+	// container is the container of the pods the commands are executed in and the logs are read from
+	container string
+}
+
+// AttachDeployment returns a handle on a Deployment not created by knuu, e.g. infrastructure deployed by other
+// tools, in the state 'Attached'
+// The handle can only execute commands, read the logs, forward the ports and disable the network of a running pod
+// of the Deployment, see AllowedActions. The Deployment is never modified, stopped or destroyed by knuu.
+// The commands are executed in the first container of the pods, the ports of all their containers can be
+// forwarded. The bandwidth, latency and packet loss cannot be shaped, as it needs the BitTwister sidecar knuu
+// injects in its own instances.
+func AttachDeployment(deployment *appv1.Deployment, sysDeps system.SystemDependencies) (*Instance, error) {
+	return attach(workloadDeployment, deployment.Name, deployment.Spec.Selector, deployment.Spec.Template.Spec, sysDeps)
+}
+
+// AttachStatefulSet returns a handle on a StatefulSet not created by knuu in the state 'Attached', see
+// AttachDeployment
+func AttachStatefulSet(statefulSet *appv1.StatefulSet, sysDeps system.SystemDependencies) (*Instance, error) {
+	return attach(workloadStatefulSet, statefulSet.Name, statefulSet.Spec.Selector, statefulSet.Spec.Template.Spec, sysDeps)
+}
+
+func attach(kind, name string, selector *metav1.LabelSelector, spec v1.PodSpec, sysDeps system.SystemDependencies) (*Instance, error) {
+	// the network policies select the pods by their labels only
+	podSelector, err := metav1.LabelSelectorAsMap(selector)
+	if err != nil {
+		return nil, ErrAttachingWorkload.WithParams(kind, name).Wrap(err)
+	}
+	if len(podSelector) == 0 {
+		return nil, ErrAttachingWorkloadWithoutSelector.WithParams(kind, name)
+	}
+	if len(spec.Containers) == 0 {
+		return nil, ErrAttachingWorkloadWithoutContainer.WithParams(kind, name)
+	}
+
+	i, err := New(name, sysDeps)
+	if err != nil {
+		return nil, ErrAttachingWorkload.WithParams(kind, name).Wrap(err)
+	}
+	// the handle is named after the workload, which knuu does not own
+	i.k8sName = name
+	i.state = Attached
+	i.attached = &attachedWorkload{
+		kind:      kind,
+		selector:  podSelector,
+		container: spec.Containers[0].Name,
+	}
+	for _, container := range spec.Containers {
+		for _, port := range container.Ports {
+			switch {
+			case port.Protocol == v1.ProtocolUDP && !slices.Contains(i.portsUDP, int(port.ContainerPort)):
+				i.portsUDP = append(i.portsUDP, int(port.ContainerPort))
+			case port.Protocol != v1.ProtocolUDP && !slices.Contains(i.portsTCP, int(port.ContainerPort)):
+				i.portsTCP = append(i.portsTCP, int(port.ContainerPort))
+			}
+		}
+	}
+
+	logrus.Debugf("Attached to %s '%s'", kind, name)
+	return i, nil
+}
+
+// IsAttached returns true if the instance is a handle on a workload not created by knuu, see AttachDeployment
+func (i *Instance) IsAttached() bool {
+	return i.attached != nil
+}
+
+// pod returns a running pod of the workload, or its first pod if none is running
+func (w *attachedWorkload) pod(ctx context.Context, k8sCli k8s.KubeManager) (*v1.Pod, error) {
+	pods, err := k8sCli.ListPods(ctx, w.selector)
+	if err != nil {
+		return nil, err
+	}
+	if len(pods) == 0 {
+		return nil, ErrAttachedWorkloadWithoutPod.WithParams(w.kind, w.selector)
+	}
+	for _, pod := range pods {
+		if pod.Status.Phase == v1.PodRunning && pod.DeletionTimestamp == nil {
+			return &pod, nil
+		}
+	}
+	return &pods[0], nil
+}
+
+// containerName returns the name of the container of the instance in its pod
+func (i *Instance) containerName() string {
+	if i.attached != nil {
+		return i.attached.container
+	}
+	return i.k8sName
+}
+
+// podSelector returns the labels selecting the pods of the instance
+func (i *Instance) podSelector() map[string]string {
+	if i.attached != nil {
+		return i.attached.selector
+	}
+	return i.getLabels()
+}
@@ -0,0 +1,134 @@
+package instance
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/celestiaorg/knuu/pkg/annotation"
+	"github.com/celestiaorg/knuu/pkg/k8s/fake"
+	"github.com/celestiaorg/knuu/pkg/system"
+)
+
+func TestAttachDeployment(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	podLabels := map[string]string{"app.kubernetes.io/name": "postgres"}
+	pending := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "postgres-pending", Namespace: "test", Labels: podLabels},
+		Status:     v1.PodStatus{Phase: v1.PodPending},
+	}
+	running := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "postgres-running", Namespace: "test", Labels: podLabels},
+		Status:     v1.PodStatus{Phase: v1.PodRunning},
+	}
+	k8sCli, err := fake.New(ctx, "test", pending, running)
+	require.NoError(t, err)
+
+	deployment := &appv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "postgres", Namespace: "test"},
+		Spec: appv1.DeploymentSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: podLabels},
+			Template: v1.PodTemplateSpec{Spec: v1.PodSpec{Containers: []v1.Container{
+				{Name: "postgres", Ports: []v1.ContainerPort{{ContainerPort: 5432}}},
+				{Name: "exporter", Ports: []v1.ContainerPort{{ContainerPort: 9187, Protocol: v1.ProtocolTCP}, {ContainerPort: 8125, Protocol: v1.ProtocolUDP}}},
+			}}},
+		},
+	}
+	i, err := AttachDeployment(deployment, system.SystemDependencies{K8sCli: k8sCli})
+	require.NoError(t, err)
+	assert.True(t, i.IsAttached())
+	assert.Equal(t, Attached, i.State())
+	assert.Equal(t, "postgres", i.k8sName)
+	assert.Equal(t, []int{5432, 9187}, i.portsTCP)
+	assert.Equal(t, []int{8125}, i.portsUDP)
+	assert.Equal(t, []InstanceAction{ActionExecute, ActionLogs, ActionPortForward, ActionShapeNetwork}, i.AllowedActions())
+
+	pod, err := i.getPod(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "postgres-running", pod.Name)
+
+	logs, err := i.Logs(ctx, false)
+	require.NoError(t, err)
+	_, err = io.ReadAll(logs)
+	require.NoError(t, err)
+	require.NoError(t, logs.Close())
+
+	_, err = i.PortForwardTCP(ctx, 6543)
+	assert.ErrorIs(t, err, ErrPortNotRegistered)
+
+	// the network policy selects the pods of the deployment, which are not annotated
+	i.Annotator = annotation.AnnotatorFunc(func(context.Context, annotation.Annotation) error {
+		t.Error("the attached instance is annotated")
+		return nil
+	})
+	require.NoError(t, i.DisableNetwork(ctx))
+	assert.False(t, k8sCli.NetworkPolicyExists(ctx, "postgres"), "the policy is not named after the workload")
+	policy, err := k8sCli.GetNetworkPolicy(ctx, "knuu-disable-network-postgres")
+	require.NoError(t, err)
+	assert.Equal(t, podLabels, policy.Spec.PodSelector.MatchLabels)
+	assert.Equal(t, "knuu", policy.Labels["k8s.kubernetes.io/managed-by"])
+	disabled, err := i.NetworkIsDisabled(ctx)
+	require.NoError(t, err)
+	assert.True(t, disabled)
+	require.NoError(t, i.EnableNetwork(ctx))
+
+	// a policy of the same name not created by knuu is neither taken over nor deleted
+	require.NoError(t, k8sCli.CreateNetworkPolicy(ctx, "knuu-disable-network-postgres", podLabels, nil, nil))
+	assert.ErrorIs(t, i.DisableNetwork(ctx), ErrNetworkPolicyNotOwned)
+	assert.ErrorIs(t, i.EnableNetwork(ctx), ErrNetworkPolicyNotOwned)
+	disabled, err = i.NetworkIsDisabled(ctx)
+	require.NoError(t, err)
+	assert.False(t, disabled)
+	assert.True(t, k8sCli.NetworkPolicyExists(ctx, "knuu-disable-network-postgres"))
+	assert.ErrorIs(t, i.SetBandwidthLimit(1000), ErrSettingBandwidthLimitNotAllowedBitTwister)
+
+	// the workload is not owned by knuu
+	assert.ErrorIs(t, i.Stop(ctx), ErrStoppingNotAllowed)
+	assert.ErrorIs(t, i.Destroy(ctx), ErrDestroyingNotAllowed)
+	assert.ErrorIs(t, i.SetImage(ctx, "alpine"), ErrSettingImageNotAllowed)
+	assert.ErrorIs(t, i.SetCommand("sh"), ErrSettingCommand)
+	_, err = k8sCli.Clientset().CoreV1().Pods("test").Get(ctx, "postgres-running", metav1.GetOptions{})
+	assert.NoError(t, err)
+}
+
+func TestAttachStatefulSetInvalid(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	k8sCli, err := fake.New(ctx, "test")
+	require.NoError(t, err)
+	sysDeps := system.SystemDependencies{K8sCli: k8sCli}
+	containers := []v1.Container{{Name: "etcd"}}
+
+	statefulSet := &appv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "etcd"},
+		Spec: appv1.StatefulSetSpec{
+			Selector: &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{
+				{Key: "app", Operator: metav1.LabelSelectorOpExists},
+			}},
+			Template: v1.PodTemplateSpec{Spec: v1.PodSpec{Containers: containers}},
+		},
+	}
+	_, err = AttachStatefulSet(statefulSet, sysDeps)
+	assert.ErrorIs(t, err, ErrAttachingWorkload)
+
+	statefulSet.Spec.Selector = &metav1.LabelSelector{}
+	_, err = AttachStatefulSet(statefulSet, sysDeps)
+	assert.ErrorIs(t, err, ErrAttachingWorkloadWithoutSelector)
+
+	// the statefulSet has no pod yet
+	statefulSet.Spec.Selector = &metav1.LabelSelector{MatchLabels: map[string]string{"app": "etcd"}}
+	i, err := AttachStatefulSet(statefulSet, sysDeps)
+	require.NoError(t, err)
+	_, err = i.Logs(ctx, false)
+	assert.ErrorIs(t, err, ErrGettingPodFromReplicaSet)
+	assert.ErrorContains(t, err, "no pod of the StatefulSet")
+}
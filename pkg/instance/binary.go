@@ -0,0 +1,47 @@
+package instance
+
+import (
+	"archive/tar"
+	"context"
+	"fmt"
+	"path"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ReplaceBinary uploads the binary at localPath to remotePath of the running instance and runs the restart command
+// with the shell of the instance, e.g. to iterate on a freshly built binary without rebuilding the image
+// The binary is uploaded next to remotePath and renamed over it, so that a running binary is replaced without
+// a 'text file busy' error, which needs sh and tar in the container. The restart command, e.g. 'pkill -HUP app',
+// must restart the process within the container: the replaced binary is lost when the container is restarted,
+// unless remotePath is on a volume. No command is run if the restart command is empty.
+// This function can only be called in the state 'Started'
+func (i *Instance) ReplaceBinary(ctx context.Context, localPath, remotePath string, restartCommand ...string) error {
+	if !i.allows(ActionOperate) {
+		return ErrReplacingBinaryNotAllowed.WithParams(i.getState().String())
+	}
+	pod, err := i.getPod(ctx)
+	if err != nil {
+		return ErrGettingPodFromReplicaSet.WithParams(i.k8sName).Wrap(err)
+	}
+
+	dir, name := path.Split(remotePath)
+	tmpName := "." + name + ".knuu"
+	script := fmt.Sprintf("%s && chmod 755 %s && mv -f %s %s", extractScript(path.Clean(dir)),
+		quoteShell(path.Join(dir, tmpName)), quoteShell(path.Join(dir, tmpName)), quoteShell(remotePath))
+	err = i.uploadTar(ctx, pod.Name, script, func(tw *tar.Writer) error {
+		return writeTarFile(tw, localPath, tmpName)
+	})
+	if err != nil {
+		return ErrReplacingBinary.WithParams(remotePath, i.k8sName).Wrap(err)
+	}
+	logrus.Debugf("Replaced binary '%s' of instance '%s' with '%s'", remotePath, i.k8sName, localPath)
+
+	if len(restartCommand) == 0 {
+		return nil
+	}
+	if _, err := i.ExecuteCommand(ctx, restartCommand...); err != nil {
+		return ErrRestartingReplacedBinary.WithParams(remotePath, i.k8sName).Wrap(err)
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+package instance
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReplaceBinary(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	i, k8sCli := startedInstanceWithArchive(t, "")
+	binary := filepath.Join(t.TempDir(), "celestia-appd")
+	require.NoError(t, os.WriteFile(binary, []byte("binary"), 0o755))
+
+	require.NoError(t, i.ReplaceBinary(ctx, binary, "/bin/celestia-appd", "pkill", "-HUP", "celestia-appd"))
+	commands := k8sCli.FakeExecutor.Commands()
+	require.Len(t, commands, 2)
+	assert.Equal(t, []string{"sh", "-c", "mkdir -p '/bin' && tar -C '/bin' -xf - && chmod 755 '/bin/.celestia-appd.knuu' && " +
+		"mv -f '/bin/.celestia-appd.knuu' '/bin/celestia-appd'"}, commands[0].Command)
+	assert.Equal(t, map[string]string{".celestia-appd.knuu": "binary"}, tarNames(t, commands[0].Stdin))
+	assert.Equal(t, i.shellCommand([]string{"pkill", "-HUP", "celestia-appd"}), commands[1].Command)
+
+	// without restart command the binary is only replaced
+	require.NoError(t, i.ReplaceBinary(ctx, binary, "/bin/celestia-appd"))
+	assert.Len(t, k8sCli.FakeExecutor.Commands(), 3)
+
+	assert.ErrorIs(t, i.ReplaceBinary(ctx, filepath.Join(t.TempDir(), "missing"), "/bin/app"), ErrReplacingBinary)
+
+	i.state = Stopped
+	assert.ErrorIs(t, i.ReplaceBinary(ctx, binary, "/bin/celestia-appd"), ErrReplacingBinaryNotAllowed)
+}
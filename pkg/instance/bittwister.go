@@ -7,6 +7,9 @@ import (
 	"github.com/sirupsen/logrus"
 
 	"github.com/celestiaorg/bittwister/sdk"
+
+	"github.com/celestiaorg/knuu/pkg/k8s"
+	"github.com/celestiaorg/knuu/pkg/retry"
 )
 
 const (
@@ -22,6 +25,9 @@ type btConfig struct {
 	networkInterface string
 	client           *sdk.Client
 	enabled          bool // if true, BitTwister is enabled and will be deployed as a sidecar
+	privileged       bool // if false, BitTwister runs with the NET_ADMIN capability only
+	request          Resources
+	limit            Resources
 }
 
 func getBitTwisterDefaultConfig() *btConfig {
@@ -29,6 +35,7 @@ func getBitTwisterDefaultConfig() *btConfig {
 		port:             btDefaultPort,
 		image:            btDefaultImage,
 		networkInterface: btDefaultNetworkInterface,
+		privileged:       true,
 	}
 }
 
@@ -53,6 +60,14 @@ func (c *btConfig) SetNewClientByURL(url string) {
 	logrus.Debugf("BitTwister address '%s'", url)
 }
 
+// SetPrivileged sets whether BitTwister runs privileged, the default, or with the NET_ADMIN capability only, for the
+// clusters forbidding the privileged pods
+// With the NET_ADMIN capability only, the latency and jitter can be shaped, but neither the bandwidth nor the
+// packet loss: SetBandwidthLimit and SetPacketLoss fail right away.
+func (c *btConfig) SetPrivileged(privileged bool) {
+	c.privileged = privileged
+}
+
 func (c *btConfig) Port() int {
 	return c.port
 }
@@ -69,6 +84,10 @@ func (c *btConfig) Client() *sdk.Client {
 	return c.client
 }
 
+func (c *btConfig) Privileged() bool {
+	return c.privileged
+}
+
 func (c *btConfig) Enabled() bool {
 	return c.enabled
 }
@@ -87,17 +106,24 @@ func (c *btConfig) Started() bool {
 }
 
 func (c *btConfig) WaitForStart(ctx context.Context) error {
-	ticker := time.NewTicker(btWaitToStartInterval)
-	defer ticker.Stop()
-	for range ticker.C {
-		if c.Started() {
-			return nil
-		}
-		select {
-		case <-ctx.Done():
-			return ErrBitTwisterFailedToStart
-		default:
-		}
+	err := retry.Until(ctx, retry.Constant(btWaitToStartInterval), func(ctx context.Context) (bool, error) {
+		return c.Started(), nil
+	})
+	if err != nil {
+		return ErrBitTwisterFailedToStart.Wrap(err)
 	}
 	return nil
 }
+
+// checkPodSecurity returns an error describing why BitTwister cannot run if the pod security probed by the
+// preflight forbids it, nil if the pod security was not probed
+func (c *btConfig) checkPodSecurity(ps *k8s.PodSecurity) error {
+	switch {
+	case ps == nil, c.privileged && ps.Privileged, !c.privileged && ps.NetAdmin:
+		return nil
+	case c.privileged && ps.NetAdmin:
+		return ErrBitTwisterPrivilegedForbidden.WithParams(ps.PrivilegedDenial)
+	default:
+		return ErrBitTwisterForbidden.WithParams(ps.NetAdminDenial)
+	}
+}
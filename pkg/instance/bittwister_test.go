@@ -0,0 +1,70 @@
+package instance
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/celestiaorg/knuu/pkg/k8s"
+	"github.com/celestiaorg/knuu/pkg/system"
+)
+
+func TestEnableBitTwisterPodSecurity(t *testing.T) {
+	t.Parallel()
+
+	newInstance := func(ps *k8s.PodSecurity) *Instance {
+		i, err := New("node", system.SystemDependencies{PodSecurity: ps}, WithImage("alpine"))
+		require.NoError(t, err)
+		return i
+	}
+
+	// the pod security is not probed
+	i := newInstance(nil)
+	require.NoError(t, i.EnableBitTwister())
+	assert.True(t, i.BitTwister.Enabled())
+	assert.True(t, i.BitTwister.Privileged())
+
+	baseline := &k8s.PodSecurity{NetAdmin: true, PrivilegedDenial: `violates PodSecurity "baseline:latest"`}
+	i = newInstance(baseline)
+	err := i.EnableBitTwister()
+	assert.ErrorIs(t, err, ErrBitTwisterPrivilegedForbidden)
+	assert.ErrorContains(t, err, `violates PodSecurity "baseline:latest"`)
+	assert.ErrorContains(t, err, "SetPrivileged(false)")
+	assert.False(t, i.BitTwister.Enabled())
+
+	// the suggested backend runs with the NET_ADMIN capability only
+	i.BitTwister.SetPrivileged(false)
+	require.NoError(t, i.EnableBitTwister())
+	assert.False(t, i.cloneWithSuffix("-clone").BitTwister.Privileged())
+
+	// the pod security is checked again when the sidecar is added
+	i.BitTwister.SetPrivileged(true)
+	err = i.addBitTwisterSidecar(context.Background())
+	assert.ErrorIs(t, err, ErrBitTwisterPrivilegedForbidden)
+	assert.Empty(t, i.sidecars)
+
+	restricted := &k8s.PodSecurity{NetAdminDenial: `violates PodSecurity "restricted:latest"`}
+	i = newInstance(restricted)
+	i.BitTwister.SetPrivileged(false)
+	err = i.EnableBitTwister()
+	assert.ErrorIs(t, err, ErrBitTwisterForbidden)
+	assert.ErrorContains(t, err, `violates PodSecurity "restricted:latest"`)
+}
+
+func TestShapeNetworkWithoutPrivileges(t *testing.T) {
+	t.Parallel()
+
+	i, err := New("node", system.SystemDependencies{}, WithImage("alpine"))
+	require.NoError(t, err)
+	i.BitTwister.SetPrivileged(false)
+	require.NoError(t, i.EnableBitTwister())
+	i.setState(Started)
+
+	// the packets are neither dropped nor rate limited with the NET_ADMIN capability only
+	err = i.SetBandwidthLimit(1000)
+	assert.ErrorIs(t, err, ErrSettingBandwidthLimitNotPrivileged)
+	assert.ErrorContains(t, err, "latency and jitter")
+	assert.ErrorIs(t, i.SetPacketLoss(10), ErrSettingPacketLossNotPrivileged)
+}
@@ -0,0 +1,125 @@
+package instance
+
+import (
+	"context"
+	"strings"
+
+	"github.com/celestiaorg/knuu/pkg/k8s"
+	"github.com/celestiaorg/knuu/pkg/names"
+)
+
+const (
+	// DefaultDebugImage is the image of the debuggers, it contains tools such as strace, tcpdump, curl and dig
+	DefaultDebugImage = "docker.io/nicolaka/netshoot:latest"
+
+	debuggerPrefix = "debugger"
+	// debuggerDoneFile stops the debugger when it is created, the debugger shares the processes
+	// of the instance so it cannot be stopped with a signal
+	debuggerDoneFile = "/tmp/knuu-debugger-done"
+)
+
+// Debugger is an ephemeral container that shares the network and the processes of an instance, like kubectl debug
+type Debugger struct {
+	instance *Instance
+	name     string
+}
+
+// Debug attaches a debugger with the given image to the instance, DefaultDebugImage if empty
+// The debugger can trace the processes of the instance and capture its traffic. It stays attached until it is closed.
+// This function can only be called in the state 'Started'
+func (i *Instance) Debug(ctx context.Context, image string) (*Debugger, error) {
+	if image == "" {
+		image = DefaultDebugImage
+	}
+	name, err := names.NewRandomK8(debuggerPrefix)
+	if err != nil {
+		return nil, ErrGeneratingDebuggerName.Wrap(err)
+	}
+
+	err = i.AddEphemeralSidecar(ctx, EphemeralSidecar{
+		Name:           name,
+		Image:          image,
+		Command:        []string{"/bin/sh", "-c", "while [ ! -f " + debuggerDoneFile + " ]; do sleep 1; done"},
+		ShareProcesses: true,
+		Capabilities:   []string{"NET_ADMIN", "NET_RAW", "SYS_PTRACE"},
+	})
+	if err != nil {
+		return nil, ErrStartingDebugger.WithParams(i.k8sName).Wrap(err)
+	}
+	return &Debugger{instance: i, name: name}, nil
+}
+
+// Name returns the name of the container of the debugger
+func (d *Debugger) Name() string {
+	return d.name
+}
+
+// ExecuteCommand executes the given command in the debugger
+func (d *Debugger) ExecuteCommand(ctx context.Context, command ...string) (string, error) {
+	return d.instance.ExecuteCommandInEphemeralSidecar(ctx, d.name, command...)
+}
+
+// Close stops the debugger, its terminated container stays in the pod of the instance
+func (d *Debugger) Close(ctx context.Context) error {
+	if _, err := d.ExecuteCommand(ctx, "touch", debuggerDoneFile); err != nil {
+		return ErrStoppingDebugger.WithParams(d.name).Wrap(err)
+	}
+	return nil
+}
+
+// NodeDebugger is a privileged pod that shares the namespaces of the node of an instance, like kubectl debug node
+// The root filesystem of the node is mounted at k8s.NodeDebuggerHostPath.
+type NodeDebugger struct {
+	k8sCli  k8s.KubeManager
+	podName string
+	node    string
+}
+
+// DebugNode starts a debugger with the given image on the node the instance runs on, DefaultDebugImage if empty
+// This function can only be called in the state 'Started'
+func (i *Instance) DebugNode(ctx context.Context, image string) (*NodeDebugger, error) {
+	if !i.allows(ActionOperate) {
+		return nil, ErrDebuggingNodeNotAllowed.WithParams(i.getState().String())
+	}
+	if image == "" {
+		image = DefaultDebugImage
+	}
+
+	pod, err := i.getPod(ctx)
+	if err != nil {
+		return nil, ErrGettingPodFromReplicaSet.WithParams(i.k8sName).Wrap(err)
+	}
+	if pod.Spec.NodeName == "" {
+		return nil, ErrInstanceNotScheduled.WithParams(i.k8sName)
+	}
+
+	debugger, err := i.K8sCli.DebugNode(ctx, pod.Spec.NodeName, image)
+	if err != nil {
+		return nil, ErrStartingNodeDebugger.WithParams(pod.Spec.NodeName).Wrap(err)
+	}
+	return &NodeDebugger{k8sCli: i.K8sCli, podName: debugger.Name, node: pod.Spec.NodeName}, nil
+}
+
+// Node returns the name of the debugged node
+func (d *NodeDebugger) Node() string {
+	return d.node
+}
+
+// ExecuteCommand executes the given command in the debugger
+// Prefix the command with 'chroot /host' to run the binaries of the node.
+func (d *NodeDebugger) ExecuteCommand(ctx context.Context, command ...string) (string, error) {
+	commandWithShell := []string{"/bin/sh", "-c", strings.Join(command, " ")}
+	output, err := d.k8sCli.RunCommandInPod(ctx, d.podName, d.podName, commandWithShell)
+	if err != nil {
+		return "", ErrExecutingCommandInNodeDebugger.WithParams(command, d.node).Wrap(err)
+	}
+	return output, nil
+}
+
+// Close deletes the debugger
+func (d *NodeDebugger) Close(ctx context.Context) error {
+	if err := d.k8sCli.DeletePod(ctx, d.podName); err != nil {
+		return ErrStoppingNodeDebugger.WithParams(d.node).Wrap(err)
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package instance
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/celestiaorg/knuu/pkg/system"
+)
+
+func TestDebug(t *testing.T) {
+	t.Parallel()
+
+	kubelet := &fakeKubelet{pod: &v1.Pod{}}
+	i, err := New("main", system.SystemDependencies{K8sCli: kubelet}, WithImage("alpine"))
+	require.NoError(t, err)
+	i.state = Started
+
+	debugger, err := i.Debug(context.Background(), "")
+	require.NoError(t, err)
+
+	require.Len(t, kubelet.pod.Spec.EphemeralContainers, 1)
+	container := kubelet.pod.Spec.EphemeralContainers[0]
+	assert.Equal(t, debugger.Name(), container.Name)
+	assert.Equal(t, DefaultDebugImage, container.Image)
+	// the debugger shares the processes of the instance
+	assert.Equal(t, i.k8sName, container.TargetContainerName)
+
+	require.NoError(t, debugger.Close(context.Background()))
+}
+
+func TestDebugNodeNotScheduled(t *testing.T) {
+	t.Parallel()
+
+	kubelet := &fakeKubelet{pod: &v1.Pod{}}
+	i, err := New("main", system.SystemDependencies{K8sCli: kubelet}, WithImage("alpine"))
+	require.NoError(t, err)
+
+	_, err = i.DebugNode(context.Background(), "")
+	assert.ErrorIs(t, err, ErrDebuggingNodeNotAllowed)
+
+	i.state = Started
+	_, err = i.DebugNode(context.Background(), "")
+	assert.ErrorIs(t, err, ErrInstanceNotScheduled)
+}
@@ -3,19 +3,37 @@ package instance
 import (
 	"context"
 	"os"
+	"time"
 
 	"github.com/sirupsen/logrus"
+
+	"github.com/celestiaorg/knuu/pkg/budget"
+	"github.com/celestiaorg/knuu/pkg/report"
 )
 
 // Destroy destroys the instance
-// This function can only be called in the state 'Started' or 'Destroyed'
-func (i *Instance) Destroy(ctx context.Context) error {
-	if i.state == Destroyed {
+// The destruction is accounted in the teardown phase of the budget of the context, see budget.Begin.
+// This function can only be called in the states 'Started', 'Stopped' and 'Destroyed'
+func (i *Instance) Destroy(ctx context.Context) (err error) {
+	ctx, span := budget.Begin(ctx, budget.PhaseTeardown)
+	defer func() { err = span.End(err) }()
+
+	if i.IsInState(Started, Stopped) {
+		if err := i.runLifecycleHooks(ctx, BeforeDestroy); err != nil {
+			return err
+		}
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if i.IsInState(Destroyed) {
 		return nil
 	}
+	defer i.recordOperation(report.OperationDestroy, time.Now(), "", &err)
 
-	if !i.IsInState(Started, Stopped, Destroyed) {
-		return ErrDestroyingNotAllowed.WithParams(i.state.String())
+	if !i.allows(ActionDestroy) {
+		return ErrDestroyingNotAllowed.WithParams(i.getState().String())
 	}
 
 	if err := i.destroyPod(ctx); err != nil {
@@ -25,7 +43,10 @@ func (i *Instance) Destroy(ctx context.Context) error {
 		return ErrDestroyingResourcesForInstance.WithParams(i.k8sName).Wrap(err)
 	}
 
-	err := applyFunctionToInstances(i.sidecars, func(sidecar Instance) error {
+	err = applyFunctionToInstances(i.sidecars, func(sidecar *Instance) error {
+		sidecar.mu.Lock()
+		defer sidecar.mu.Unlock()
+
 		logrus.Debugf("Destroying sidecar resources from '%s'", sidecar.k8sName)
 		return sidecar.destroyResources(ctx)
 	})
@@ -33,9 +54,10 @@ func (i *Instance) Destroy(ctx context.Context) error {
 		return ErrDestroyingResourcesForSidecars.WithParams(i.k8sName).Wrap(err)
 	}
 
-	i.state = Destroyed
+	if err := i.transition(Destroyed); err != nil {
+		return err
+	}
 	setStateForSidecars(i.sidecars, Destroyed)
-	logrus.Debugf("Set state of instance '%s' to '%s'", i.k8sName, i.state.String())
 
 	return nil
 }
@@ -0,0 +1,94 @@
+package instance
+
+import (
+	"net"
+	"sort"
+
+	"github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+)
+
+// Limits of the DNS config of a pod enforced by kubernetes
+const (
+	maxDNSNameservers = 3
+	maxDNSSearches    = 32
+)
+
+// SetDNSPolicy sets the DNS policy of the pod of the instance, ClusterFirst by default
+// With the policy None, the DNS config must be set with SetDNSConfig.
+// This function can only be called in the state 'Preparing' or 'Committed'
+func (i *Instance) SetDNSPolicy(policy v1.DNSPolicy) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if !i.allows(ActionConfigure) {
+		return ErrSettingDNSNotAllowed.WithParams(i.getState().String())
+	}
+	if i.isSidecar {
+		return ErrSettingDNSForSidecar
+	}
+	switch policy {
+	case v1.DNSClusterFirst, v1.DNSClusterFirstWithHostNet, v1.DNSDefault, v1.DNSNone:
+	default:
+		return ErrInvalidDNSPolicy.WithParams(policy)
+	}
+	i.dnsPolicy = policy
+	logrus.Debugf("Set DNS policy to '%s' for instance '%s'", policy, i.name)
+	return nil
+}
+
+// SetDNSConfig sets the nameservers, search domains and resolver options of the pod of the instance
+// They are merged with the ones of the DNS policy, or used alone with the policy None.
+// An option with an empty value is rendered without value (e.g. 'rotate').
+// This function can only be called in the state 'Preparing' or 'Committed'
+func (i *Instance) SetDNSConfig(nameservers, searches []string, options map[string]string) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if !i.allows(ActionConfigure) {
+		return ErrSettingDNSNotAllowed.WithParams(i.getState().String())
+	}
+	if i.isSidecar {
+		return ErrSettingDNSForSidecar
+	}
+	if len(nameservers) > maxDNSNameservers {
+		return ErrTooManyDNSNameservers.WithParams(len(nameservers), maxDNSNameservers)
+	}
+	for _, ns := range nameservers {
+		if net.ParseIP(ns) == nil {
+			return ErrInvalidDNSNameserver.WithParams(ns)
+		}
+	}
+	if len(searches) > maxDNSSearches {
+		return ErrTooManyDNSSearches.WithParams(len(searches), maxDNSSearches)
+	}
+
+	config := &v1.PodDNSConfig{
+		Nameservers: nameservers,
+		Searches:    searches,
+	}
+	names := make([]string, 0, len(options))
+	for name := range options {
+		names = append(names, name)
+	}
+	// sorted to render the same pod spec for the same options
+	sort.Strings(names)
+	for _, name := range names {
+		option := v1.PodDNSConfigOption{Name: name}
+		if value := options[name]; value != "" {
+			option.Value = &value
+		}
+		config.Options = append(config.Options, option)
+	}
+	i.dnsConfig = config
+	logrus.Debugf("Set DNS config for instance '%s'", i.name)
+	return nil
+}
+
+// validateDNS checks that a DNS config is set when the DNS policy is None
+func (i *Instance) validateDNS() error {
+	if i.dnsPolicy == v1.DNSNone && (i.dnsConfig == nil || len(i.dnsConfig.Nameservers) == 0) {
+		return ErrDNSConfigRequired.WithParams(i.k8sName)
+	}
+	return nil
+}
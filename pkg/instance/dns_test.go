@@ -0,0 +1,71 @@
+package instance
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/celestiaorg/knuu/pkg/system"
+)
+
+func TestSetDNSConfig(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		nameservers []string
+		searches    []string
+		wantErr     error
+	}{
+		{name: "valid", nameservers: []string{"10.0.0.10", "fd00::10"}, searches: []string{"test.local"}},
+		{name: "nameserver is not an IP", nameservers: []string{"dns.local"}, wantErr: ErrInvalidDNSNameserver},
+		{name: "too many nameservers", nameservers: []string{"10.0.0.1", "10.0.0.2", "10.0.0.3", "10.0.0.4"}, wantErr: ErrTooManyDNSNameservers},
+		{name: "too many searches", searches: make([]string, maxDNSSearches+1), wantErr: ErrTooManyDNSSearches},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			i, err := New("test", system.SystemDependencies{}, WithImage("alpine"))
+			require.NoError(t, err)
+			err = i.SetDNSConfig(tt.nameservers, tt.searches, nil)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.nameservers, i.dnsConfig.Nameservers)
+		})
+	}
+}
+
+func TestDNSOptions(t *testing.T) {
+	t.Parallel()
+
+	i, err := New("test", system.SystemDependencies{}, WithImage("alpine"))
+	require.NoError(t, err)
+	require.NoError(t, i.SetDNSConfig(nil, nil, map[string]string{"ndots": "2", "rotate": ""}))
+
+	options := i.dnsConfig.Options
+	require.Len(t, options, 2)
+	assert.Equal(t, "ndots", options[0].Name)
+	assert.Equal(t, "2", *options[0].Value)
+	assert.Equal(t, "rotate", options[1].Name)
+	assert.Nil(t, options[1].Value)
+}
+
+func TestDNSPolicyNoneRequiresNameservers(t *testing.T) {
+	t.Parallel()
+
+	i, err := New("test", system.SystemDependencies{}, WithImage("alpine"))
+	require.NoError(t, err)
+
+	assert.ErrorIs(t, i.SetDNSPolicy("Sometimes"), ErrInvalidDNSPolicy)
+	require.NoError(t, i.SetDNSPolicy(v1.DNSNone))
+	assert.ErrorIs(t, i.validateDNS(), ErrDNSConfigRequired)
+
+	require.NoError(t, i.SetDNSConfig([]string{"10.0.0.10"}, nil, nil))
+	assert.NoError(t, i.validateDNS())
+}
@@ -0,0 +1,140 @@
+package instance
+
+import (
+	"archive/tar"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// DownloadProgress reports the progress of a download, it is updated after each file
+type DownloadProgress struct {
+	// File is the path of the last downloaded file, relative to the downloaded folder
+	File  string
+	Files int
+	Bytes int64
+}
+
+// DownloadOption configures a download
+type DownloadOption func(*downloadOptions)
+
+type downloadOptions struct {
+	onProgress []func(DownloadProgress)
+}
+
+// OnDownloadProgress calls the given function after each downloaded file
+func OnDownloadProgress(fn func(DownloadProgress)) DownloadOption {
+	return func(o *downloadOptions) {
+		o.onProgress = append(o.onProgress, fn)
+	}
+}
+
+// DownloadFolder downloads the folder at remotePath of the running instance to localPath, e.g. to harvest its data after a test
+// It is the counterpart of AddFolder: the folder is streamed as a tar archive, so the container must have tar.
+// The local folder is created if needed and the existing files are overwritten. Only the directories and
+// the regular files are downloaded, the symlinks and the other special files are skipped.
+// This function can only be called in the state 'Started'
+func (i *Instance) DownloadFolder(ctx context.Context, remotePath, localPath string, opts ...DownloadOption) error {
+	if !i.allows(ActionOperate) {
+		return ErrDownloadingFolderNotAllowed.WithParams(i.getState().String())
+	}
+	o := &downloadOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	pod, err := i.getPod(ctx)
+	if err != nil {
+		return ErrGettingPodFromReplicaSet.WithParams(i.k8sName).Wrap(err)
+	}
+	if err := os.MkdirAll(localPath, 0o755); err != nil {
+		return ErrCreatingDirectory.Wrap(err)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	pr, pw := io.Pipe()
+	streamErr := make(chan error, 1)
+	go func() {
+		cmd := []string{"tar", "-C", remotePath, "-cf", "-", "."}
+		err := i.K8sCli.StreamCommandInPod(ctx, pod.Name, i.k8sName, cmd, pw)
+		pw.CloseWithError(err)
+		streamErr <- err
+	}()
+
+	// the archive is read until the command exits, so an error of the command is returned by the extraction too
+	err = extractTar(pr, localPath, o.onProgress)
+	// stop the command if the extraction failed before reading the whole archive
+	cancel()
+	pr.Close()
+	if sErr := <-streamErr; err == nil {
+		err = sErr
+	}
+	if err != nil {
+		return ErrDownloadingFolder.WithParams(remotePath, i.k8sName).Wrap(err)
+	}
+	logrus.Debugf("Downloaded folder '%s' of instance '%s' to '%s'", remotePath, i.k8sName, localPath)
+	return nil
+}
+
+// extractTar extracts the directories and the regular files of the archive to dir
+func extractTar(r io.Reader, dir string, onProgress []func(DownloadProgress)) error {
+	var progress DownloadProgress
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		name := filepath.Clean(hdr.Name)
+		if name == "." {
+			continue
+		}
+		if filepath.IsAbs(name) || name == ".." || strings.HasPrefix(name, ".."+string(filepath.Separator)) {
+			return ErrUnsafeArchivePath.WithParams(hdr.Name)
+		}
+		path := filepath.Join(dir, name)
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(path, 0o755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			n, err := writeFile(path, tr, hdr.FileInfo().Mode().Perm())
+			if err != nil {
+				return err
+			}
+			progress.File = name
+			progress.Files++
+			progress.Bytes += n
+			for _, fn := range onProgress {
+				fn(progress)
+			}
+		default:
+			logrus.Debugf("Skipping '%s' of type '%c' while downloading a folder", hdr.Name, hdr.Typeflag)
+		}
+	}
+}
+
+func writeFile(path string, r io.Reader, perm os.FileMode) (int64, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return 0, err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, perm)
+	if err != nil {
+		return 0, err
+	}
+	n, err := io.Copy(f, r)
+	return n, errors.Join(err, f.Close())
+}
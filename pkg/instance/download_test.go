@@ -0,0 +1,98 @@
+package instance
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/celestiaorg/knuu/pkg/k8s/fake"
+	"github.com/celestiaorg/knuu/pkg/system"
+)
+
+func tarArchive(t *testing.T, headers ...*tar.Header) string {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, hdr := range headers {
+		content := hdr.Name
+		if hdr.Typeflag == tar.TypeReg {
+			hdr.Size = int64(len(content))
+		}
+		require.NoError(t, tw.WriteHeader(hdr))
+		if hdr.Typeflag == tar.TypeReg {
+			_, err := tw.Write([]byte(content))
+			require.NoError(t, err)
+		}
+	}
+	require.NoError(t, tw.Close())
+	return buf.String()
+}
+
+func startedInstanceWithArchive(t *testing.T, archive string) (*Instance, *fake.Client) {
+	ctx := context.Background()
+	k8sCli, err := fake.New(ctx, "test")
+	require.NoError(t, err)
+	i, err := New("validator", system.SystemDependencies{K8sCli: k8sCli}, WithImage("alpine"))
+	require.NoError(t, err)
+	i.restartPolicy = v1.RestartPolicyNever
+	i.state = Started
+	_, err = k8sCli.FakeClientset.CoreV1().Pods("test").Create(ctx, &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: i.k8sName}}, metav1.CreateOptions{})
+	require.NoError(t, err)
+	k8sCli.FakeExecutor.Handler = func(fake.Command) (string, string, error) {
+		return archive, "", nil
+	}
+	return i, k8sCli
+}
+
+func TestDownloadFolder(t *testing.T) {
+	t.Parallel()
+
+	archive := tarArchive(t,
+		&tar.Header{Name: "./", Typeflag: tar.TypeDir, Mode: 0o755},
+		&tar.Header{Name: "./config.toml", Typeflag: tar.TypeReg, Mode: 0o644},
+		&tar.Header{Name: "./data/", Typeflag: tar.TypeDir, Mode: 0o755},
+		&tar.Header{Name: "./data/blockstore.db", Typeflag: tar.TypeReg, Mode: 0o600},
+		&tar.Header{Name: "./latest", Typeflag: tar.TypeSymlink, Linkname: "/etc/passwd"},
+	)
+	i, k8sCli := startedInstanceWithArchive(t, archive)
+
+	var progress []DownloadProgress
+	dir := filepath.Join(t.TempDir(), "harvest")
+	err := i.DownloadFolder(context.Background(), "/home/celestia", dir, OnDownloadProgress(func(p DownloadProgress) {
+		progress = append(progress, p)
+	}))
+	require.NoError(t, err)
+
+	content, err := os.ReadFile(filepath.Join(dir, "data", "blockstore.db"))
+	require.NoError(t, err)
+	assert.Equal(t, "./data/blockstore.db", string(content))
+	assert.FileExists(t, filepath.Join(dir, "config.toml"))
+	assert.NoFileExists(t, filepath.Join(dir, "latest"))
+
+	require.Len(t, progress, 2)
+	assert.Equal(t, DownloadProgress{File: "data/blockstore.db", Files: 2, Bytes: int64(len("./config.toml") + len("./data/blockstore.db"))}, progress[1])
+
+	commands := k8sCli.FakeExecutor.Commands()
+	require.Len(t, commands, 1)
+	assert.Equal(t, []string{"tar", "-C", "/home/celestia", "-cf", "-", "."}, commands[0].Command)
+}
+
+func TestDownloadFolderRejectsUnsafePaths(t *testing.T) {
+	t.Parallel()
+
+	i, _ := startedInstanceWithArchive(t, tarArchive(t, &tar.Header{Name: "../escape", Typeflag: tar.TypeReg, Mode: 0o644}))
+	dir := t.TempDir()
+	err := i.DownloadFolder(context.Background(), "/home/celestia", filepath.Join(dir, "harvest"))
+	assert.ErrorIs(t, err, ErrDownloadingFolder)
+	assert.NoFileExists(t, filepath.Join(dir, "escape"))
+
+	i.state = Stopped
+	assert.ErrorIs(t, i.DownloadFolder(context.Background(), "/", dir), ErrDownloadingFolderNotAllowed)
+}
@@ -0,0 +1,76 @@
+package instance
+
+import (
+	"context"
+	"net"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/celestiaorg/knuu/pkg/system"
+)
+
+// egressFirewallSuffix names the network policy of the egress firewall of an instance,
+// the network policy of DisableNetwork has the name of the instance
+const egressFirewallSuffix = "-egress"
+
+// BlockExternalEgress cuts the access of the instance to the addresses outside of the cluster, except to the given CIDRs
+// The instance can still reach the pods of the cluster, including the DNS servers, and its incoming traffic is not
+// restricted. Calling it again replaces the allowed CIDRs. The cluster must run a network plugin enforcing network policies.
+// Network policies only add allowed traffic, so the firewall cannot be combined with DisableNetwork, nor with the
+// policies of a service mesh.
+// This function can only be called in the states 'Committed' and 'Started'
+func (i *Instance) BlockExternalEgress(ctx context.Context, allowCIDRs []string) error {
+	if !i.allows(ActionBlockEgress) {
+		return ErrBlockingExternalEgressNotAllowed.WithParams(i.getState().String())
+	}
+	if i.isSidecar {
+		return ErrBlockingExternalEgressOfSidecar
+	}
+	if i.ServiceMesh != system.ServiceMeshNone {
+		return ErrBlockingExternalEgressWithServiceMesh.WithParams(i.k8sName, i.ServiceMesh)
+	}
+	for _, cidr := range allowCIDRs {
+		if _, _, err := net.ParseCIDR(cidr); err != nil {
+			return ErrInvalidCIDR.WithParams(cidr).Wrap(err)
+		}
+	}
+	if i.K8sCli.NetworkPolicyExists(ctx, i.networkPolicyName()) {
+		return ErrBlockingExternalEgressWithNetworkDisabled.WithParams(i.k8sName)
+	}
+
+	if err := i.K8sCli.CreateEgressFirewall(ctx, i.egressFirewallName(), i.getLabels(), allowCIDRs); err != nil {
+		return ErrBlockingExternalEgress.WithParams(i.k8sName).Wrap(err)
+	}
+	logrus.Debugf("Blocked external egress of instance '%s', allowed CIDRs: %v", i.k8sName, allowCIDRs)
+	return nil
+}
+
+// UnblockExternalEgress removes the egress firewall of the instance
+// This function can only be called in the states 'Committed' and 'Started'
+func (i *Instance) UnblockExternalEgress(ctx context.Context) error {
+	if !i.allows(ActionBlockEgress) {
+		return ErrUnblockingExternalEgressNotAllowed.WithParams(i.getState().String())
+	}
+	if err := i.K8sCli.DeleteNetworkPolicy(ctx, i.egressFirewallName()); err != nil {
+		return ErrUnblockingExternalEgress.WithParams(i.k8sName).Wrap(err)
+	}
+	logrus.Debugf("Unblocked external egress of instance '%s'", i.k8sName)
+	return nil
+}
+
+// ExternalEgressBlocked returns true if the egress firewall of the instance is set
+func (i *Instance) ExternalEgressBlocked(ctx context.Context) bool {
+	return i.K8sCli.NetworkPolicyExists(ctx, i.egressFirewallName())
+}
+
+func (i *Instance) egressFirewallName() string {
+	return i.k8sName + egressFirewallSuffix
+}
+
+// destroyEgressFirewall removes the egress firewall of the instance if it is set
+func (i *Instance) destroyEgressFirewall(ctx context.Context) error {
+	if !i.ExternalEgressBlocked(ctx) {
+		return nil
+	}
+	return i.K8sCli.DeleteNetworkPolicy(ctx, i.egressFirewallName())
+}
@@ -0,0 +1,83 @@
+package instance
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/celestiaorg/knuu/pkg/k8s"
+	"github.com/celestiaorg/knuu/pkg/k8s/fake"
+	"github.com/celestiaorg/knuu/pkg/system"
+)
+
+// fakePolicies records the network policies, the allowed CIDRs of the egress firewalls are kept
+type fakePolicies struct {
+	k8s.KubeManager
+	policies map[string][]string
+}
+
+func (f *fakePolicies) CreateEgressFirewall(_ context.Context, name string, _ map[string]string, allowCIDRs []string) error {
+	f.policies[name] = allowCIDRs
+	return nil
+}
+
+func (f *fakePolicies) DeleteNetworkPolicy(_ context.Context, name string) error {
+	delete(f.policies, name)
+	return nil
+}
+
+func (f *fakePolicies) NetworkPolicyExists(_ context.Context, name string) bool {
+	_, ok := f.policies[name]
+	return ok
+}
+
+func TestBlockExternalEgress(t *testing.T) {
+	t.Parallel()
+
+	policies := &fakePolicies{policies: map[string][]string{}}
+	i := &Instance{k8sName: "app", state: Started, SystemDependencies: system.SystemDependencies{K8sCli: policies}}
+	ctx := context.Background()
+
+	assert.ErrorIs(t, i.BlockExternalEgress(ctx, []string{"10.0.0.0"}), ErrInvalidCIDR)
+	assert.False(t, i.ExternalEgressBlocked(ctx))
+
+	require.NoError(t, i.BlockExternalEgress(ctx, []string{"203.0.113.0/24"}))
+	assert.True(t, i.ExternalEgressBlocked(ctx))
+	assert.Equal(t, []string{"203.0.113.0/24"}, policies.policies["app-egress"])
+
+	// the policies only add allowed traffic, so the firewall would weaken DisableNetwork
+	assert.ErrorIs(t, i.DisableNetwork(ctx), ErrDisablingNetworkWithExternalEgressBlocked)
+
+	require.NoError(t, i.UnblockExternalEgress(ctx))
+	assert.False(t, i.ExternalEgressBlocked(ctx))
+
+	policies.policies["app"] = nil
+	assert.ErrorIs(t, i.BlockExternalEgress(ctx, nil), ErrBlockingExternalEgressWithNetworkDisabled)
+
+	i.state = Stopped
+	assert.ErrorIs(t, i.BlockExternalEgress(ctx, nil), ErrBlockingExternalEgressNotAllowed)
+}
+
+func TestBlockExternalEgressNetworkPolicy(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	k8sCli, err := fake.New(ctx, "test")
+	require.NoError(t, err)
+	i, err := New("app", system.SystemDependencies{K8sCli: k8sCli}, WithImage("alpine"))
+	require.NoError(t, err)
+	i.state = Started
+
+	require.NoError(t, i.BlockExternalEgress(ctx, []string{"203.0.113.0/24"}))
+
+	np, err := k8sCli.FakeClientset.NetworkingV1().NetworkPolicies("test").Get(ctx, i.egressFirewallName(), metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, i.getLabels(), np.Spec.PodSelector.MatchLabels)
+	assert.Equal(t, []networkingv1.PolicyType{networkingv1.PolicyTypeEgress}, np.Spec.PolicyTypes)
+	require.Len(t, np.Spec.Egress, 2)
+	assert.Equal(t, "203.0.113.0/24", np.Spec.Egress[1].To[0].IPBlock.CIDR)
+}
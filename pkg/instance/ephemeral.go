@@ -0,0 +1,184 @@
+package instance
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/celestiaorg/knuu/pkg/k8s"
+	"github.com/celestiaorg/knuu/pkg/retry"
+)
+
+// EphemeralSidecar is a container attached to the pod of a started instance, e.g. to debug or observe it
+// Ephemeral sidecars share the network of the instance. They are never restarted and
+// they disappear when the pod of the instance is recreated, e.g. when the instance is stopped.
+type EphemeralSidecar struct {
+	Name    string
+	Image   string
+	Command []string
+	Args    []string
+	Env     map[string]string
+	// ShareProcesses shares the process namespace of the main container of the instance, so its processes can be inspected
+	ShareProcesses bool
+	// Capabilities are added to the container, e.g. NET_ADMIN or SYS_PTRACE
+	Capabilities []string
+	// Privileged runs the container in privileged mode
+	Privileged bool
+}
+
+// AddEphemeralSidecar attaches the given container to the pod of the instance and waits until it is running
+// This function can only be called in the state 'Started'
+func (i *Instance) AddEphemeralSidecar(ctx context.Context, sidecar EphemeralSidecar) error {
+	if !i.allows(ActionOperate) {
+		return ErrAddingEphemeralSidecarNotAllowed.WithParams(i.getState().String())
+	}
+	if i.isSidecar {
+		return ErrAddingEphemeralSidecarToSidecar
+	}
+	if sidecar.Name == "" || sidecar.Image == "" {
+		return ErrEphemeralSidecarNameOrImageEmpty
+	}
+	name := k8s.SanitizeName(sidecar.Name)
+
+	pod, err := i.getPod(ctx)
+	if err != nil {
+		return ErrGettingPodFromReplicaSet.WithParams(i.k8sName).Wrap(err)
+	}
+	if _, ok := ephemeralContainer(pod, name); ok {
+		return ErrEphemeralSidecarAlreadyExists.WithParams(name, i.k8sName)
+	}
+
+	config := k8s.EphemeralContainerConfig{
+		Name:            name,
+		Image:           sidecar.Image,
+		Command:         sidecar.Command,
+		Args:            sidecar.Args,
+		Env:             sidecar.Env,
+		SecurityContext: ephemeralSecurityContext(sidecar),
+	}
+	if sidecar.ShareProcesses {
+		config.TargetContainer = i.k8sName
+	}
+	if _, err := i.K8sCli.AddEphemeralContainer(ctx, pod.Name, config); err != nil {
+		return ErrAddingEphemeralSidecar.WithParams(name, i.k8sName).Wrap(err)
+	}
+
+	err = retry.Until(ctx, waitRunningPolicy, func(ctx context.Context) (bool, error) {
+		pod, err := i.getPod(ctx)
+		if err != nil {
+			return false, ErrGettingPodFromReplicaSet.WithParams(i.k8sName).Wrap(err)
+		}
+		status, ok := ephemeralContainerStatus(pod, name)
+		// a sidecar with a short command can terminate before it is seen running
+		return ok && (status.State.Running != nil || status.State.Terminated != nil), nil
+	})
+	if err != nil {
+		return ErrWaitingForEphemeralSidecar.WithParams(name, i.k8sName).Wrap(err)
+	}
+	logrus.Debugf("Added ephemeral sidecar '%s' to instance '%s'", name, i.k8sName)
+	return nil
+}
+
+// RemoveEphemeralSidecar stops the ephemeral sidecar with the given name
+// Kubernetes does not allow to remove a container from a pod, so the main process of the sidecar
+// receives SIGTERM and the terminated container stays in the pod. Sidecars that share the processes
+// of the instance cannot be stopped this way, as their first process is the one of the instance.
+// This function can only be called in the state 'Started'
+func (i *Instance) RemoveEphemeralSidecar(ctx context.Context, name string) error {
+	if !i.allows(ActionOperate) {
+		return ErrRemovingEphemeralSidecarNotAllowed.WithParams(i.getState().String())
+	}
+	name = k8s.SanitizeName(name)
+
+	pod, err := i.getPod(ctx)
+	if err != nil {
+		return ErrGettingPodFromReplicaSet.WithParams(i.k8sName).Wrap(err)
+	}
+	container, ok := ephemeralContainer(pod, name)
+	if !ok {
+		return ErrEphemeralSidecarNotFound.WithParams(name, i.k8sName)
+	}
+	if container.TargetContainerName != "" {
+		return ErrRemovingEphemeralSidecarSharingProcesses.WithParams(name)
+	}
+	if status, ok := ephemeralContainerStatus(pod, name); ok && status.State.Terminated != nil {
+		return nil
+	}
+
+	_, signalErr := i.K8sCli.RunCommandInPod(ctx, pod.Name, name, terminateMainProcessCommand)
+	err = retry.Until(ctx, waitRunningPolicy, func(ctx context.Context) (bool, error) {
+		pod, err := i.getPod(ctx)
+		if err != nil {
+			return false, ErrGettingPodFromReplicaSet.WithParams(i.k8sName).Wrap(err)
+		}
+		status, ok := ephemeralContainerStatus(pod, name)
+		return ok && status.State.Terminated != nil, nil
+	})
+	if err != nil {
+		return ErrRemovingEphemeralSidecar.WithParams(name, i.k8sName).Wrap(errors.Join(signalErr, err))
+	}
+	logrus.Debugf("Removed ephemeral sidecar '%s' from instance '%s'", name, i.k8sName)
+	return nil
+}
+
+// ExecuteCommandInEphemeralSidecar executes the given command in the ephemeral sidecar with the given name
+// This function can only be called in the state 'Started'
+func (i *Instance) ExecuteCommandInEphemeralSidecar(ctx context.Context, name string, command ...string) (string, error) {
+	if !i.allows(ActionOperate) {
+		return "", ErrExecutingCommandNotAllowed.WithParams(i.getState().String())
+	}
+	name = k8s.SanitizeName(name)
+
+	pod, err := i.getPod(ctx)
+	if err != nil {
+		return "", ErrGettingPodFromReplicaSet.WithParams(i.k8sName).Wrap(err)
+	}
+	if _, ok := ephemeralContainer(pod, name); !ok {
+		return "", ErrEphemeralSidecarNotFound.WithParams(name, i.k8sName)
+	}
+
+	commandWithShell := []string{"/bin/sh", "-c", strings.Join(command, " ")}
+	output, err := i.K8sCli.RunCommandInPod(ctx, pod.Name, name, commandWithShell)
+	if err != nil {
+		return "", ErrExecutingCommandInEphemeralSidecar.WithParams(command, name, i.k8sName).Wrap(err)
+	}
+	return output, nil
+}
+
+func ephemeralSecurityContext(sidecar EphemeralSidecar) *v1.SecurityContext {
+	if !sidecar.Privileged && len(sidecar.Capabilities) == 0 {
+		return nil
+	}
+	sc := &v1.SecurityContext{}
+	if sidecar.Privileged {
+		sc.Privileged = &sidecar.Privileged
+	}
+	if len(sidecar.Capabilities) > 0 {
+		sc.Capabilities = &v1.Capabilities{}
+		for _, c := range sidecar.Capabilities {
+			sc.Capabilities.Add = append(sc.Capabilities.Add, v1.Capability(c))
+		}
+	}
+	return sc
+}
+
+func ephemeralContainer(pod *v1.Pod, name string) (v1.EphemeralContainer, bool) {
+	for _, c := range pod.Spec.EphemeralContainers {
+		if c.Name == name {
+			return c, true
+		}
+	}
+	return v1.EphemeralContainer{}, false
+}
+
+func ephemeralContainerStatus(pod *v1.Pod, name string) (v1.ContainerStatus, bool) {
+	for _, cs := range pod.Status.EphemeralContainerStatuses {
+		if cs.Name == name {
+			return cs, true
+		}
+	}
+	return v1.ContainerStatus{}, false
+}
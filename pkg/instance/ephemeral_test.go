@@ -0,0 +1,52 @@
+package instance
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/celestiaorg/knuu/pkg/k8s"
+	"github.com/celestiaorg/knuu/pkg/system"
+)
+
+func (f *fakeKubelet) AddEphemeralContainer(_ context.Context, _ string, config k8s.EphemeralContainerConfig) (*v1.Pod, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.pod.Spec.EphemeralContainers = append(f.pod.Spec.EphemeralContainers, v1.EphemeralContainer{
+		EphemeralContainerCommon: v1.EphemeralContainerCommon{Name: config.Name, Image: config.Image, Command: config.Command},
+		TargetContainerName:      config.TargetContainer,
+	})
+	f.pod.Status.EphemeralContainerStatuses = append(f.pod.Status.EphemeralContainerStatuses, v1.ContainerStatus{
+		Name:  config.Name,
+		State: v1.ContainerState{Running: &v1.ContainerStateRunning{}},
+	})
+	return f.pod.DeepCopy(), nil
+}
+
+func TestEphemeralSidecars(t *testing.T) {
+	t.Parallel()
+
+	kubelet := &fakeKubelet{pod: &v1.Pod{}}
+	i, err := New("main", system.SystemDependencies{K8sCli: kubelet}, WithImage("alpine"))
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	assert.ErrorIs(t, i.AddEphemeralSidecar(ctx, EphemeralSidecar{Name: "debug", Image: "busybox"}), ErrAddingEphemeralSidecarNotAllowed)
+
+	i.state = Started
+	assert.ErrorIs(t, i.AddEphemeralSidecar(ctx, EphemeralSidecar{Name: "debug"}), ErrEphemeralSidecarNameOrImageEmpty)
+	require.NoError(t, i.AddEphemeralSidecar(ctx, EphemeralSidecar{Name: "debug", Image: "busybox"}))
+	assert.ErrorIs(t, i.AddEphemeralSidecar(ctx, EphemeralSidecar{Name: "debug", Image: "busybox"}), ErrEphemeralSidecarAlreadyExists)
+
+	require.NoError(t, i.AddEphemeralSidecar(ctx, EphemeralSidecar{Name: "Tracer", Image: "busybox", ShareProcesses: true}))
+	assert.Equal(t, i.k8sName, kubelet.pod.Spec.EphemeralContainers[1].TargetContainerName)
+	assert.ErrorIs(t, i.RemoveEphemeralSidecar(ctx, "tracer"), ErrRemovingEphemeralSidecarSharingProcesses)
+	assert.ErrorIs(t, i.RemoveEphemeralSidecar(ctx, "unknown"), ErrEphemeralSidecarNotFound)
+
+	// the sidecar already terminated
+	kubelet.pod.Status.EphemeralContainerStatuses[0].State = v1.ContainerState{Terminated: &v1.ContainerStateTerminated{}}
+	assert.NoError(t, i.RemoveEphemeralSidecar(ctx, "debug"))
+}
@@ -28,6 +28,10 @@ var (
 	ErrFailedToDeleteServiceAccount              = errors.New("FailedToDeleteServiceAccount", "failed to delete service account")
 	ErrFailedToDeleteRole                        = errors.New("FailedToDeleteRole", "failed to delete role")
 	ErrFailedToDeleteRoleBinding                 = errors.New("FailedToDeleteRoleBinding", "failed to delete role binding")
+	ErrFailedToCreateClusterRole                 = errors.New("FailedToCreateClusterRole", "failed to create cluster role")
+	ErrFailedToCreateClusterRoleBinding          = errors.New("FailedToCreateClusterRoleBinding", "failed to create cluster role binding")
+	ErrFailedToDeleteClusterRole                 = errors.New("FailedToDeleteClusterRole", "failed to delete cluster role")
+	ErrFailedToDeleteClusterRoleBinding          = errors.New("FailedToDeleteClusterRoleBinding", "failed to delete cluster role binding")
 	ErrDeployingServiceForInstance               = errors.New("DeployingServiceForInstance", "error deploying service for instance '%s'")
 	ErrPatchingServiceForInstance                = errors.New("PatchingServiceForInstance", "error patching service for instance '%s'")
 	ErrFailedToOpenFile                          = errors.New("FailedToOpenFile", "failed to open file")
@@ -60,6 +64,8 @@ var (
 	ErrGettingInstanceIP                         = errors.New("GettingInstanceIP", "error getting IP of instance '%s'")
 	ErrCommittingBitTwisterInstance              = errors.New("CommittingBitTwisterInstance", "error committing bit-twister instance")
 	ErrSettingBitTwisterEnv                      = errors.New("SettingBitTwisterEnv", "error setting environment variable for bit-twister instance")
+	ErrSettingBitTwisterResources                = errors.New("SettingBitTwisterResources", "error setting resources for bit-twister instance")
+	ErrSettingBitTwisterResourcesNotAllowed      = errors.New("SettingBitTwisterResourcesNotAllowed", "setting BitTwister resources is not allowed in state '%s'")
 	ErrCreatingBitTwisterInstance                = errors.New("CreatingBitTwisterInstance", "error creating bit-twister instance '%s'")
 	ErrSettingBitTwisterPrivileged               = errors.New("SettingBitTwisterPrivileged", "error setting privileged for bit-twister instance '%s'")
 	ErrAddingBitTwisterCapability                = errors.New("AddingBitTwisterCapability", "error adding capability for bit-twister instance '%s'")
@@ -67,8 +73,7 @@ var (
 	ErrCreatingOtelAgentInstance                 = errors.New("CreatingOtelAgentInstance", "error creating otel-agent instance")
 	ErrSettingOtelAgentImage                     = errors.New("SettingOtelAgentImage", "error setting image for otel-agent instance")
 	ErrAddingOtelAgentPort                       = errors.New("AddingOtelAgentPort", "error adding port for otel-agent instance")
-	ErrSettingOtelAgentCPU                       = errors.New("SettingOtelAgentCPU", "error setting CPU for otel-agent instance")
-	ErrSettingOtelAgentMemory                    = errors.New("SettingOtelAgentMemory", "error setting memory for otel-agent instance")
+	ErrSettingOtelAgentResources                 = errors.New("SettingOtelAgentResources", "error setting resources for otel-agent instance")
 	ErrCommittingOtelAgentInstance               = errors.New("CommittingOtelAgentInstance", "error committing otel-agent instance")
 	ErrMarshalingYAML                            = errors.New("MarshalingYAML", "error marshaling YAML")
 	ErrAddingOtelAgentConfigFile                 = errors.New("AddingOtelAgentConfigFile", "error adding otel-agent config file")
@@ -198,4 +203,246 @@ var (
 	ErrAddingToProxy                             = errors.New("AddingToProxy", "error adding '%s' to traefik proxy for service '%s'")
 	ErrGettingProxyURL                           = errors.New("GettingProxyURL", "error getting proxy URL for service '%s'")
 	ErrProxyNotInitialized                       = errors.New("ProxyNotInitialized", "proxy not initialized")
+	ErrInvalidOptions                            = errors.New("InvalidOptions", "invalid options for instance '%s'")
+	ErrApplyingOptions                           = errors.New("ApplyingOptions", "error applying options to instance '%s'")
+	ErrImageRequiredForOptions                   = errors.New("ImageRequiredForOptions", "an image is required to configure the instance")
+	ErrPortsOutOfRange                           = errors.New("PortsOutOfRange", "ports %v are out of range")
+	ErrPortsDuplicated                           = errors.New("PortsDuplicated", "ports %v are registered more than once")
+	ErrInvalidResourceQuantities                 = errors.New("InvalidResourceQuantities", "invalid resource quantities %v")
+	ErrBuildingInstance                          = errors.New("BuildingInstance", "error building instance '%s'")
+	ErrProbeCommandEmpty                         = errors.New("ProbeCommandEmpty", "the command of an exec probe cannot be empty")
+	ErrParsingResourceQuantity                   = errors.New("ParsingResourceQuantity", "error parsing resource quantity '%s'")
+	ErrMemoryLimitLowerThanRequest               = errors.New("MemoryLimitLowerThanRequest", "memory limit '%s' is lower than the request '%s'")
+	ErrCPULimitLowerThanRequest                  = errors.New("CPULimitLowerThanRequest", "CPU limit '%s' is lower than the request '%s'")
+	ErrGettingLogsNotAllowed                     = errors.New("GettingLogsNotAllowed", "getting logs is only allowed in state 'Started'. Current state is '%s'")
+	ErrGettingLogs                               = errors.New("GettingLogs", "error getting logs of instance '%s'")
+	ErrCheckingHealthNotAllowed                  = errors.New("CheckingHealthNotAllowed", "checking health is only allowed in state 'Started'. Current state is '%s'")
+	ErrRestartingInstance                        = errors.New("RestartingInstance", "error restarting instance '%s'")
+	ErrMaxRestartsReached                        = errors.New("MaxRestartsReached", "instance '%s' was restarted %d times, it is not restarted anymore")
+	ErrSettingRestartPolicyNotAllowed            = errors.New("SettingRestartPolicyNotAllowed", "setting restart policy is only allowed in state 'Preparing' or 'Committed'. Current state is '%s'")
+	ErrSettingRestartPolicyForSidecar            = errors.New("SettingRestartPolicyForSidecar", "the restart policy of a sidecar is the one of its parent instance")
+	ErrInvalidRestartPolicy                      = errors.New("InvalidRestartPolicy", "invalid restart policy '%s', it must be Always, OnFailure or Never")
+	ErrWaitingForTerminationNotAllowed           = errors.New("WaitingForTerminationNotAllowed", "waiting for termination is only allowed in state 'Started'. Current state is '%s'")
+	ErrWaitingForTerminationTimeout              = errors.New("WaitingForTerminationTimeout", "timeout while waiting for instance '%s' to terminate")
+	ErrRestartingSidecarNotAllowed               = errors.New("RestartingSidecarNotAllowed", "restarting a sidecar is only allowed in state 'Started'. Current state is '%s'")
+	ErrRestartingSidecarWithPolicyNever          = errors.New("RestartingSidecarWithPolicyNever", "sidecar '%s' cannot be restarted as instance '%s' uses the restart policy Never")
+	ErrRestartingSidecar                         = errors.New("RestartingSidecar", "error restarting sidecar '%s'")
+	ErrStoppingSidecarNotAllowed                 = errors.New("StoppingSidecarNotAllowed", "stopping a sidecar is only allowed in state 'Started'. Current state is '%s'")
+	ErrStoppingSidecarRequiresPolicyNever        = errors.New("StoppingSidecarRequiresPolicyNever", "sidecar '%s' cannot be stopped as instance '%s' does not use the restart policy Never, its container would be restarted")
+	ErrStoppingSidecar                           = errors.New("StoppingSidecar", "error stopping sidecar '%s'")
+	ErrSidecarNotFound                           = errors.New("SidecarNotFound", "sidecar '%s' not found in instance '%s'")
+	ErrSidecarContainerNotFound                  = errors.New("SidecarContainerNotFound", "container of sidecar '%s' not found in pod '%s'")
+	ErrAddingEphemeralSidecarNotAllowed          = errors.New("AddingEphemeralSidecarNotAllowed", "adding an ephemeral sidecar is only allowed in state 'Started'. Current state is '%s'")
+	ErrAddingEphemeralSidecarToSidecar           = errors.New("AddingEphemeralSidecarToSidecar", "ephemeral sidecars can only be added to the parent instance of a sidecar")
+	ErrEphemeralSidecarNameOrImageEmpty          = errors.New("EphemeralSidecarNameOrImageEmpty", "an ephemeral sidecar needs a name and an image")
+	ErrEphemeralSidecarAlreadyExists             = errors.New("EphemeralSidecarAlreadyExists", "ephemeral sidecar '%s' already exists in instance '%s'")
+	ErrAddingEphemeralSidecar                    = errors.New("AddingEphemeralSidecar", "error adding ephemeral sidecar '%s' to instance '%s'")
+	ErrWaitingForEphemeralSidecar                = errors.New("WaitingForEphemeralSidecar", "error waiting for ephemeral sidecar '%s' of instance '%s' to run")
+	ErrRemovingEphemeralSidecarNotAllowed        = errors.New("RemovingEphemeralSidecarNotAllowed", "removing an ephemeral sidecar is only allowed in state 'Started'. Current state is '%s'")
+	ErrEphemeralSidecarNotFound                  = errors.New("EphemeralSidecarNotFound", "ephemeral sidecar '%s' not found in instance '%s'")
+	ErrRemovingEphemeralSidecarSharingProcesses  = errors.New("RemovingEphemeralSidecarSharingProcesses", "ephemeral sidecar '%s' shares the processes of the instance, it stops when its command exits")
+	ErrRemovingEphemeralSidecar                  = errors.New("RemovingEphemeralSidecar", "error removing ephemeral sidecar '%s' from instance '%s'")
+	ErrExecutingCommandInEphemeralSidecar        = errors.New("ExecutingCommandInEphemeralSidecar", "error executing command '%s' in ephemeral sidecar '%s' of instance '%s'")
+	ErrGeneratingDebuggerName                    = errors.New("GeneratingDebuggerName", "error generating the name of the debugger")
+	ErrStartingDebugger                          = errors.New("StartingDebugger", "error starting a debugger for instance '%s'")
+	ErrStoppingDebugger                          = errors.New("StoppingDebugger", "error stopping debugger '%s'")
+	ErrDebuggingNodeNotAllowed                   = errors.New("DebuggingNodeNotAllowed", "debugging the node is only allowed in state 'Started'. Current state is '%s'")
+	ErrInstanceNotScheduled                      = errors.New("InstanceNotScheduled", "instance '%s' is not scheduled on a node")
+	ErrStartingNodeDebugger                      = errors.New("StartingNodeDebugger", "error starting a debugger on node '%s'")
+	ErrExecutingCommandInNodeDebugger            = errors.New("ExecutingCommandInNodeDebugger", "error executing command '%s' in the debugger of node '%s'")
+	ErrStoppingNodeDebugger                      = errors.New("StoppingNodeDebugger", "error stopping the debugger of node '%s'")
+	ErrSettingDNSNotAllowed                      = errors.New("SettingDNSNotAllowed", "setting DNS is only allowed in state 'Preparing' or 'Committed'. Current state is '%s'")
+	ErrSettingDNSForSidecar                      = errors.New("SettingDNSForSidecar", "the DNS of a sidecar is the one of its parent instance")
+	ErrInvalidDNSPolicy                          = errors.New("InvalidDNSPolicy", "invalid DNS policy '%s'")
+	ErrTooManyDNSNameservers                     = errors.New("TooManyDNSNameservers", "%d nameservers are set, kubernetes allows at most %d")
+	ErrInvalidDNSNameserver                      = errors.New("InvalidDNSNameserver", "nameserver '%s' is not an IP address")
+	ErrTooManyDNSSearches                        = errors.New("TooManyDNSSearches", "%d search domains are set, kubernetes allows at most %d")
+	ErrDNSConfigRequired                         = errors.New("DNSConfigRequired", "instance '%s' uses the DNS policy None, it needs a DNS config with nameservers")
+	ErrSettingHostNetworkNotAllowed              = errors.New("SettingHostNetworkNotAllowed", "setting host network is only allowed in state 'Preparing' or 'Committed'. Current state is '%s'")
+	ErrSettingHostNetworkForSidecar              = errors.New("SettingHostNetworkForSidecar", "the network of a sidecar is the one of its parent instance")
+	ErrAddingHostPortNotAllowed                  = errors.New("AddingHostPortNotAllowed", "adding host port is only allowed in state 'Preparing' or 'Committed'. Current state is '%s'")
+	ErrAddingHostPortToSidecar                   = errors.New("AddingHostPortToSidecar", "host ports can only be added to the parent instance of a sidecar")
+	ErrHostPortNotRegistered                     = errors.New("HostPortNotRegistered", "port '%d' must be added as TCP or UDP port before it is exposed on the node")
+	ErrHostPortAlreadyAdded                      = errors.New("HostPortAlreadyAdded", "host port '%d' is already added")
+	ErrPortNameAlreadyRegistered                 = errors.New("PortNameAlreadyRegistered", "port name '%s' is already registered")
+	ErrInvalidPortProtocol                       = errors.New("InvalidPortProtocol", "invalid port protocol '%s', it must be TCP, UDP or SCTP")
+	ErrInvalidPortName                           = errors.New("InvalidPortName", "invalid port name '%s': %s")
+	ErrAddingServiceNotAllowed                   = errors.New("AddingServiceNotAllowed", "adding service is only allowed in state 'Preparing' or 'Committed'. Current state is '%s'")
+	ErrAddingServiceToSidecar                    = errors.New("AddingServiceToSidecar", "services can only be added to the parent instance of a sidecar")
+	ErrInvalidServiceName                        = errors.New("InvalidServiceName", "invalid service name '%s': %s")
+	ErrServiceAlreadyAdded                       = errors.New("ServiceAlreadyAdded", "service '%s' is already added")
+	ErrInvalidServiceType                        = errors.New("InvalidServiceType", "invalid service type '%s', it must be ClusterIP, NodePort or LoadBalancer")
+	ErrServiceWithoutPorts                       = errors.New("ServiceWithoutPorts", "service '%s' has no ports")
+	ErrServicePortDuplicated                     = errors.New("ServicePortDuplicated", "port '%s' of service '%s' is duplicated")
+	ErrGettingServiceIPNotAllowed                = errors.New("GettingServiceIPNotAllowed", "getting the IP of a service is only allowed in state 'Started'. Current state is '%s'")
+	ErrServiceNotFound                           = errors.New("ServiceNotFound", "service '%s' not found in instance '%s'")
+	ErrDestroyingService                         = errors.New("DestroyingService", "error destroying service '%s'")
+	ErrAddingTCPHostToProxy                      = errors.New("AddingTCPHostToProxy", "error adding TCP port '%d' of instance '%s' to the proxy")
+	ErrBlockingExternalEgressNotAllowed          = errors.New("BlockingExternalEgressNotAllowed", "blocking external egress is only allowed in states 'Committed' and 'Started'. Current state is '%s'")
+	ErrBlockingExternalEgressOfSidecar           = errors.New("BlockingExternalEgressOfSidecar", "the external egress of a sidecar cannot be blocked, block the one of its instance")
+	ErrInvalidCIDR                               = errors.New("InvalidCIDR", "invalid CIDR '%s'")
+	ErrBlockingExternalEgressWithNetworkDisabled = errors.New("BlockingExternalEgressWithNetworkDisabled", "the network of instance '%s' is disabled, blocking its external egress would allow more traffic")
+	ErrBlockingExternalEgress                    = errors.New("BlockingExternalEgress", "error blocking external egress of instance '%s'")
+	ErrUnblockingExternalEgressNotAllowed        = errors.New("UnblockingExternalEgressNotAllowed", "unblocking external egress is only allowed in states 'Committed' and 'Started'. Current state is '%s'")
+	ErrUnblockingExternalEgress                  = errors.New("UnblockingExternalEgress", "error unblocking external egress of instance '%s'")
+	ErrDisablingNetworkWithExternalEgressBlocked = errors.New("DisablingNetworkWithExternalEgressBlocked", "the external egress of instance '%s' is blocked, remove the firewall before disabling the network")
+	ErrDestroyingEgressFirewall                  = errors.New("DestroyingEgressFirewall", "error destroying the egress firewall of instance '%s'")
+	ErrStartingNetworkAccountingNotAllowed       = errors.New("StartingNetworkAccountingNotAllowed", "starting network accounting is only allowed in state 'Started'. Current state is '%s'")
+	ErrStartingNetworkAccountingOfSidecar        = errors.New("StartingNetworkAccountingOfSidecar", "the traffic of a sidecar is counted with the one of its instance")
+	ErrGettingPeerAddresses                      = errors.New("GettingPeerAddresses", "error getting the addresses of peer '%s'")
+	ErrStartingNetworkAccounting                 = errors.New("StartingNetworkAccounting", "error starting network accounting of instance '%s'")
+	ErrNetworkAccountingSidecarNotRunning        = errors.New("NetworkAccountingSidecarNotRunning", "the network accounting sidecar of instance '%s' is not running")
+	ErrGettingNetworkStatsNotAllowed             = errors.New("GettingNetworkStatsNotAllowed", "getting network stats is only allowed in state 'Started'. Current state is '%s'")
+	ErrNetworkAccountingNotStarted               = errors.New("NetworkAccountingNotStarted", "network accounting of instance '%s' is not started")
+	ErrGettingNetworkStats                       = errors.New("GettingNetworkStats", "error getting network stats of instance '%s'")
+	ErrStoppingNetworkAccountingNotAllowed       = errors.New("StoppingNetworkAccountingNotAllowed", "stopping network accounting is only allowed in state 'Started'. Current state is '%s'")
+	ErrStoppingNetworkAccounting                 = errors.New("StoppingNetworkAccounting", "error stopping network accounting of instance '%s'")
+	ErrUploadingArtifactNotAllowed               = errors.New("UploadingArtifactNotAllowed", "uploading an artifact is only allowed in state 'Started'. Current state is '%s'")
+	ErrArtifactStoreNotInitialized               = errors.New("ArtifactStoreNotInitialized", "the artifact store is not initialized")
+	ErrUploadingArtifact                         = errors.New("UploadingArtifact", "error uploading file '%s' of instance '%s' as artifact")
+	ErrAddingRemoteFileNotAllowed                = errors.New("AddingRemoteFileNotAllowed", "adding a remote file is only allowed in states 'Preparing' and 'Committed'. Current state is '%s'")
+	ErrAddingRemoteFileToSidecar                 = errors.New("AddingRemoteFileToSidecar", "remote files can only be added to the parent instance of a sidecar")
+	ErrInvalidFileURL                            = errors.New("InvalidFileURL", "invalid file URL '%s', it must be an http or https URL")
+	ErrInvalidFileChecksum                       = errors.New("InvalidFileChecksum", "invalid checksum '%s', it must be a hex encoded SHA-256")
+	ErrInvalidRemoteFileDest                     = errors.New("InvalidRemoteFileDest", "invalid dest '%s', it must be a clean absolute path")
+	ErrRemoteFileAlreadyAdded                    = errors.New("RemoteFileAlreadyAdded", "a remote file is already added at '%s'")
+	ErrGettingArtifactURL                        = errors.New("GettingArtifactURL", "error getting the URL of artifact '%s'")
+	ErrDownloadingFolderNotAllowed               = errors.New("DownloadingFolderNotAllowed", "downloading a folder is only allowed in state 'Started'. Current state is '%s'")
+	ErrDownloadingFolder                         = errors.New("DownloadingFolder", "error downloading folder '%s' of instance '%s'")
+	ErrUnsafeArchivePath                         = errors.New("UnsafeArchivePath", "the path '%s' of the archive is outside of the downloaded folder")
+	ErrInspectingFilesNotAllowed                 = errors.New("InspectingFilesNotAllowed", "inspecting files is only allowed in state 'Started'. Current state is '%s'")
+	ErrCheckingFileExists                        = errors.New("CheckingFileExists", "error checking if '%s' exists in instance '%s'")
+	ErrComputingFileSHA256                       = errors.New("ComputingFileSHA256", "error computing the SHA-256 of file '%s' in instance '%s'")
+	ErrComputingDirSize                          = errors.New("ComputingDirSize", "error computing the size of directory '%s' in instance '%s'")
+	ErrUnexpectedCommandOutput                   = errors.New("UnexpectedCommandOutput", "unexpected output of the command: '%s'")
+	ErrCommandEmpty                              = errors.New("CommandEmpty", "the command cannot be empty")
+	ErrInvalidEnvName                            = errors.New("InvalidEnvName", "invalid environment variable name '%s'")
+	ErrExecutingCommandWithOptionsNotAllowed     = errors.New("ExecutingCommandWithOptionsNotAllowed", "executing a command with options is only allowed in state 'Started'. Current state is '%s'")
+	ErrSettingShellNotAllowed                    = errors.New("SettingShellNotAllowed", "setting the shell is only allowed in states 'Preparing', 'Committed' and 'Started'. Current state is '%s'")
+	ErrInstanceWithoutShell                      = errors.New("InstanceWithoutShell", "instance '%s' has no shell, the command cannot use a shell, environment variables or a working directory")
+	ErrInstanceShellNotFound                     = errors.New("InstanceShellNotFound", "the shell '%s' is not found in instance '%s', call SetShell without arguments for images without a shell")
+	ErrCreatingGroupInstance                     = errors.New("CreatingGroupInstance", "error creating instance '%s' of group '%s'")
+	ErrInvalidBatchSize                          = errors.New("InvalidBatchSize", "invalid batch size %d, it must be at least 1")
+	ErrRollingOperation                          = errors.New("RollingOperation", "error during the rolling %s of group '%s'")
+	ErrDistributingGroupNotAllowed               = errors.New("DistributingGroupNotAllowed", "distributing group '%s' across zones is only allowed when its instances are in state 'Preparing' or 'Committed'. Instance '%s' is in state '%s'")
+	ErrGettingInstanceZone                       = errors.New("GettingInstanceZone", "error getting the zone of instance '%s'")
+	ErrSimulatingZoneOutage                      = errors.New("SimulatingZoneOutage", "error simulating the outage of zone '%s' for group '%s'")
+	ErrCheckingLeader                            = errors.New("CheckingLeader", "error checking the leader")
+	ErrMultipleLeaders                           = errors.New("MultipleLeaders", "multiple instances claim to be the leader: %v")
+	ErrNoLeader                                  = errors.New("NoLeader", "no instance is the leader")
+	ErrKillingLeader                             = errors.New("KillingLeader", "error killing leader '%s'")
+	ErrWaitingForLeader                          = errors.New("WaitingForLeader", "error waiting for a leader")
+	ErrGettingResourceUsageNotAllowed            = errors.New("GettingResourceUsageNotAllowed", "getting the resource usage is only allowed in state 'Started'. Current state is '%s'")
+	ErrImageRejectedByHook                       = errors.New("ImageRejectedByHook", "image '%s' of instance '%s' rejected by a post build hook")
+	ErrSigningImage                              = errors.New("SigningImage", "error signing image '%s' of instance '%s'")
+	ErrVerifyingImage                            = errors.New("VerifyingImage", "error verifying the signature of image '%s' of instance '%s'")
+	ErrGettingImageConfigNotAllowed              = errors.New("GettingImageConfigNotAllowed", "getting the image config is only allowed in states 'Preparing', 'Committed' and 'Started'. Current state is '%s'")
+	ErrGettingImageConfig                        = errors.New("GettingImageConfig", "error getting the config of image '%s' of instance '%s'")
+	ErrAddingExposedPort                         = errors.New("AddingExposedPort", "error adding port '%d/%s' exposed by the image of instance '%s'")
+	ErrSettingEntrypointNotAllowed               = errors.New("SettingEntrypointNotAllowed", "setting the entrypoint is only allowed in state 'Preparing' or 'Committed'. Current state is '%s'")
+	ErrSettingWorkingDirNotAllowed               = errors.New("SettingWorkingDirNotAllowed", "setting the working directory is only allowed in state 'Preparing' or 'Committed'. Current state is '%s'")
+	ErrWorkingDirNotAbsolute                     = errors.New("WorkingDirNotAbsolute", "working directory '%s' is not an absolute path")
+	ErrResolvingImageCommand                     = errors.New("ResolvingImageCommand", "error reading the command of the image of instance '%s' to pass it to its entrypoint")
+	ErrSettingTTYNotAllowed                      = errors.New("SettingTTYNotAllowed", "setting the TTY is only allowed in state 'Preparing' or 'Committed'. Current state is '%s'")
+	ErrSettingStdinNotAllowed                    = errors.New("SettingStdinNotAllowed", "setting the stdin is only allowed in state 'Preparing' or 'Committed'. Current state is '%s'")
+	ErrSharingProcessNamespaceNotAllowed         = errors.New("SharingProcessNamespaceNotAllowed", "sharing the process namespace is only allowed in state 'Preparing' or 'Committed'. Current state is '%s'")
+	ErrSharingProcessNamespaceForSidecar         = errors.New("SharingProcessNamespaceForSidecar", "the process namespace of a sidecar is the one of its parent instance")
+	ErrGettingNodeNotAllowed                     = errors.New("GettingNodeNotAllowed", "getting the node is only allowed in state 'Started'. Current state is '%s'")
+	ErrGettingInstanceNode                       = errors.New("GettingInstanceNode", "error getting the node of instance '%s'")
+	ErrSyncingFolderNotAllowed                   = errors.New("SyncingFolderNotAllowed", "syncing a folder is only allowed in state 'Started'. Current state is '%s'")
+	ErrSyncingFolder                             = errors.New("SyncingFolder", "error syncing folder '%s' to instance '%s'")
+	ErrReplacingBinaryNotAllowed                 = errors.New("ReplacingBinaryNotAllowed", "replacing a binary is only allowed in state 'Started'. Current state is '%s'")
+	ErrReplacingBinary                           = errors.New("ReplacingBinary", "error replacing binary '%s' of instance '%s'")
+	ErrRestartingReplacedBinary                  = errors.New("RestartingReplacedBinary", "error restarting the process of the replaced binary '%s' of instance '%s'")
+	ErrScrapingMetricsNotAllowed                 = errors.New("ScrapingMetricsNotAllowed", "scraping metrics is only allowed in state 'Started'. Current state is '%s'")
+	ErrScrapingMetrics                           = errors.New("ScrapingMetrics", "error scraping the metrics on port %d of instance '%s'")
+	ErrInvalidOtelExporter                       = errors.New("InvalidOtelExporter", "invalid OpenTelemetry exporter '%s': %s")
+	ErrOtelExporterAlreadyExists                 = errors.New("OtelExporterAlreadyExists", "OpenTelemetry exporter '%s' already exists in instance '%s'")
+	ErrInvalidLogsCollection                     = errors.New("InvalidLogsCollection", "invalid logs collection: %s")
+	ErrStartingInstances                         = errors.New("StartingInstances", "%d of %d instances failed to start")
+	ErrInvalidLifecycleEvent                     = errors.New("InvalidLifecycleEvent", "invalid lifecycle event '%s'")
+	ErrLifecycleHook                             = errors.New("LifecycleHook", "%s hook of instance '%s' failed")
+	ErrEnablingTLSNotAllowed                     = errors.New("EnablingTLSNotAllowed", "enabling TLS is only allowed in state 'Preparing' or 'Committed'. Current state is '%s'")
+	ErrCANotSet                                  = errors.New("CANotSet", "no CA is set to issue the TLS certificate of instance '%s'")
+	ErrIssuingTLSCertificate                     = errors.New("IssuingTLSCertificate", "error issuing the TLS certificate of instance '%s'")
+	ErrDeployingTLSSecret                        = errors.New("DeployingTLSSecret", "error deploying the TLS secret of instance '%s'")
+	ErrDestroyingTLSSecret                       = errors.New("DestroyingTLSSecret", "error destroying the TLS secret of instance '%s'")
+	ErrDisablingNetworkWithServiceMesh           = errors.New("DisablingNetworkWithServiceMesh", "cannot disable the network of instance '%s' under the service mesh '%s', which enforces its own policies")
+	ErrBlockingExternalEgressWithServiceMesh     = errors.New("BlockingExternalEgressWithServiceMesh", "cannot block the external egress of instance '%s' under the service mesh '%s', which enforces its own policies")
+	ErrStartingFlowLogsNotAllowed                = errors.New("StartingFlowLogsNotAllowed", "starting flow logs is only allowed in state 'Started'. Current state is '%s'")
+	ErrStartingFlowLogsOfSidecar                 = errors.New("StartingFlowLogsOfSidecar", "the connections of a sidecar are logged with the ones of its instance")
+	ErrStartingFlowLogs                          = errors.New("StartingFlowLogs", "error starting flow logs of instance '%s'")
+	ErrFlowLogsSidecarNotRunning                 = errors.New("FlowLogsSidecarNotRunning", "the flow logs sidecar of instance '%s' is not running")
+	ErrGettingFlowLogsNotAllowed                 = errors.New("GettingFlowLogsNotAllowed", "getting flow logs is only allowed in state 'Started'. Current state is '%s'")
+	ErrGettingFlowLogs                           = errors.New("GettingFlowLogs", "error getting flow logs of instance '%s'")
+	ErrStoppingFlowLogsNotAllowed                = errors.New("StoppingFlowLogsNotAllowed", "stopping flow logs is only allowed in state 'Started'. Current state is '%s'")
+	ErrStoppingFlowLogs                          = errors.New("StoppingFlowLogs", "error stopping flow logs of instance '%s'")
+	ErrEnablingAPIFaultsNotAllowed               = errors.New("EnablingAPIFaultsNotAllowed", "enabling API faults is only allowed in state 'Preparing' or 'Committed'. Current state is '%s'")
+	ErrEnablingAPIFaultsOfSidecar                = errors.New("EnablingAPIFaultsOfSidecar", "the API faults of a sidecar are injected with the ones of its instance")
+	ErrEnablingAPIFaults                         = errors.New("EnablingAPIFaults", "error enabling API faults for instance '%s'")
+	ErrCreatingAPIProxyInstance                  = errors.New("CreatingAPIProxyInstance", "error creating the API proxy instance")
+	ErrAddingAPIProxySidecar                     = errors.New("AddingAPIProxySidecar", "error adding the API proxy sidecar to instance '%s'")
+	ErrDeployingKubeconfigSecret                 = errors.New("DeployingKubeconfigSecret", "error deploying the kubeconfig secret of instance '%s'")
+	ErrDestroyingKubeconfigSecret                = errors.New("DestroyingKubeconfigSecret", "error destroying the kubeconfig secret of instance '%s'")
+	ErrInjectingAPIFaultsNotAllowed              = errors.New("InjectingAPIFaultsNotAllowed", "injecting API faults is only allowed in state 'Started'. Current state is '%s'")
+	ErrAPIFaultsNotEnabled                       = errors.New("APIFaultsNotEnabled", "API faults are not enabled for instance '%s'")
+	ErrInvalidAPIFault                           = errors.New("InvalidAPIFault", "invalid API fault: %s")
+	ErrMarshallingAPIFaults                      = errors.New("MarshallingAPIFaults", "error marshalling the API faults")
+	ErrInjectingAPIFaults                        = errors.New("InjectingAPIFaults", "error injecting the API faults in instance '%s'")
+	ErrWaitingForMetric                          = errors.New("WaitingForMetric", "error waiting for metric '%s' on port %d of instance '%s' to satisfy the condition")
+	ErrAddingReadinessGateNotAllowed             = errors.New("AddingReadinessGateNotAllowed", "adding readiness gate is only allowed in state 'Preparing' or 'Committed'. Current state is '%s'")
+	ErrAddingReadinessGateToSidecar              = errors.New("AddingReadinessGateToSidecar", "readiness gates cannot be added to sidecar '%s', they are added to the pod of its parent instance")
+	ErrInvalidReadinessGate                      = errors.New("InvalidReadinessGate", "invalid readiness gate '%s': %s")
+	ErrSettingReadinessGate                      = errors.New("SettingReadinessGate", "error setting readiness gate '%s' of instance '%s'")
+	ErrEnablingVPANotAllowed                     = errors.New("EnablingVPANotAllowed", "enabling the VerticalPodAutoscaler is only allowed in state 'Preparing' or 'Committed'. Current state is '%s'")
+	ErrEnablingVPAOfSidecar                      = errors.New("EnablingVPAOfSidecar", "the VerticalPodAutoscaler cannot be enabled for sidecar '%s', it scales the main container of its parent instance")
+	ErrInvalidVPAMode                            = errors.New("InvalidVPAMode", "invalid VerticalPodAutoscaler mode '%s', it must be 'Off', 'Initial', 'Recreate' or 'Auto'")
+	ErrVPARequiresReplicaSet                     = errors.New("VPARequiresReplicaSet", "the VerticalPodAutoscaler of instance '%s' targets its ReplicaSet, which is not used with the restart policy '%s'")
+	ErrDeployingVPA                              = errors.New("DeployingVPA", "error deploying the VerticalPodAutoscaler of instance '%s'")
+	ErrDestroyingVPA                             = errors.New("DestroyingVPA", "error destroying the VerticalPodAutoscaler of instance '%s'")
+	ErrGettingVPARecommendationNotAllowed        = errors.New("GettingVPARecommendationNotAllowed", "getting the VerticalPodAutoscaler recommendation is only allowed in state 'Started'. Current state is '%s'")
+	ErrVPANotEnabled                             = errors.New("VPANotEnabled", "the VerticalPodAutoscaler is not enabled for instance '%s'")
+	ErrGettingVPARecommendation                  = errors.New("GettingVPARecommendation", "error getting the VerticalPodAutoscaler recommendation of instance '%s'")
+	ErrEnablingHPANotAllowed                     = errors.New("EnablingHPANotAllowed", "enabling the HorizontalPodAutoscaler is only allowed in state 'Preparing' or 'Committed'. Current state is '%s'")
+	ErrEnablingHPAOfSidecar                      = errors.New("EnablingHPAOfSidecar", "the HorizontalPodAutoscaler cannot be enabled for sidecar '%s', it scales the pod of its parent instance")
+	ErrInvalidHPAReplicas                        = errors.New("InvalidHPAReplicas", "invalid HorizontalPodAutoscaler replicas from %d to %d, the minimum must be at least 1 and at most the maximum")
+	ErrInvalidHPAMetric                          = errors.New("InvalidHPAMetric", "invalid HorizontalPodAutoscaler metric: %s")
+	ErrHPARequiresReplicaSet                     = errors.New("HPARequiresReplicaSet", "the HorizontalPodAutoscaler of instance '%s' scales its ReplicaSet, which is not used with the restart policy '%s'")
+	ErrHPARequiresCPURequest                     = errors.New("HPARequiresCPURequest", "the HorizontalPodAutoscaler of instance '%s' scales on the CPU utilization, which needs the CPU request of the instance, see SetCPU")
+	ErrDeployingHPA                              = errors.New("DeployingHPA", "error deploying the HorizontalPodAutoscaler of instance '%s'")
+	ErrDestroyingHPA                             = errors.New("DestroyingHPA", "error destroying the HorizontalPodAutoscaler of instance '%s'")
+	ErrGettingHPAReplicasNotAllowed              = errors.New("GettingHPAReplicasNotAllowed", "getting the HorizontalPodAutoscaler replicas is only allowed in state 'Started'. Current state is '%s'")
+	ErrHPANotEnabled                             = errors.New("HPANotEnabled", "the HorizontalPodAutoscaler is not enabled for instance '%s'")
+	ErrGettingHPAReplicas                        = errors.New("GettingHPAReplicas", "error getting the HorizontalPodAutoscaler replicas of instance '%s'")
+	ErrHPAWithReadinessGates                     = errors.New("HPAWithReadinessGates", "the HorizontalPodAutoscaler and the readiness gates cannot be combined for instance '%s', the checks of the gates only target one of the replicas")
+	ErrSettingPreemptibleNotAllowed              = errors.New("SettingPreemptibleNotAllowed", "setting preemptible is only allowed in state 'Preparing' or 'Committed'. Current state is '%s'")
+	ErrSettingPreemptibleSidecar                 = errors.New("SettingPreemptibleSidecar", "sidecar '%s' cannot be set preemptible, it is preempted with the pod of its parent instance")
+	ErrPreemptingNotAllowed                      = errors.New("PreemptingNotAllowed", "preempting is only allowed in state 'Started'. Current state is '%s'")
+	ErrPreemptingSidecar                         = errors.New("PreemptingSidecar", "sidecar '%s' cannot be preempted, it is preempted with the pod of its parent instance")
+	ErrPreemptingInstance                        = errors.New("PreemptingInstance", "error preempting instance '%s'")
+	ErrPreemptibleWithoutReplicaSet              = errors.New("PreemptibleWithoutReplicaSet", "instance '%s' with restart policy '%s' runs a plain pod, which would not be rescheduled once preempted")
+	ErrInvalidStateTransition                    = errors.New("InvalidStateTransition", "instance '%s' cannot move from state '%s' to state '%s'")
+	ErrAttachingWorkload                         = errors.New("AttachingWorkload", "error attaching %s '%s'")
+	ErrAttachingWorkloadWithoutSelector          = errors.New("AttachingWorkloadWithoutSelector", "%s '%s' cannot be attached, its pods are not selected by labels")
+	ErrAttachingWorkloadWithoutContainer         = errors.New("AttachingWorkloadWithoutContainer", "%s '%s' cannot be attached, its pods have no container")
+	ErrAttachedWorkloadWithoutPod                = errors.New("AttachedWorkloadWithoutPod", "no pod of the %s selected by '%v' exists")
+	ErrSettingPrebuiltImageNotAllowed            = errors.New("SettingPrebuiltImageNotAllowed", "setting a prebuilt image is only allowed in state 'None'. Current state is '%s'")
+	ErrPrebuiltImageEmpty                        = errors.New("PrebuiltImageEmpty", "the prebuilt image of instance '%s' is empty")
+	ErrBitTwisterPrivilegedForbidden             = errors.New("BitTwisterPrivilegedForbidden", "the cluster forbids the privileged pods BitTwister runs in (%s), call BitTwister.SetPrivileged(false) before EnableBitTwister to run it with the NET_ADMIN capability only, which shapes the latency and jitter only")
+	ErrBitTwisterForbidden                       = errors.New("BitTwisterForbidden", "the cluster forbids the NET_ADMIN capability BitTwister needs to shape the traffic (%s)")
+	ErrInstanceWithoutBuilder                    = errors.New("InstanceWithoutBuilder", "instance '%s' has no builder, its image '%s' is prebuilt and cannot be modified or read from before it is started")
+	ErrSettingBandwidthLimitNotPrivileged        = errors.New("SettingBandwidthLimitNotPrivileged", "setting the bandwidth limit of instance '%s' needs BitTwister to run privileged, only the latency and jitter can be shaped with the NET_ADMIN capability")
+	ErrSettingPacketLossNotPrivileged            = errors.New("SettingPacketLossNotPrivileged", "setting the packet loss of instance '%s' needs BitTwister to run privileged, only the latency and jitter can be shaped with the NET_ADMIN capability")
+	ErrSignalingSidecarWithSharedProcesses       = errors.New("SignalingSidecarWithSharedProcesses", "sidecar '%s' cannot be signaled as instance '%s' shares its process namespace, PID 1 is the pause container")
+	ErrReadingFileWithSharedProcesses            = errors.New("ReadingFileWithSharedProcesses", "instance '%s' has no cat and shares its process namespace, its files cannot be read through /proc/1/root of the pause container")
+	ErrAddingSecretFilesNotAllowed               = errors.New("AddingSecretFilesNotAllowed", "adding secret files is only allowed in state 'Preparing' or 'Committed'. Current state is '%s'")
+	ErrSecretFilesDirNotAbsolute                 = errors.New("SecretFilesDirNotAbsolute", "the directory '%s' of the secret files must be absolute")
+	ErrInvalidSecretFileName                     = errors.New("InvalidSecretFileName", "invalid secret file name '%s': %v")
+	ErrDeployingSecretFiles                      = errors.New("DeployingSecretFiles", "error deploying the secret files of '%s' of instance '%s'")
+	ErrDestroyingSecretFiles                     = errors.New("DestroyingSecretFiles", "error destroying the secret files of '%s' of instance '%s'")
+	ErrOtelReceiverPortConflict                  = errors.New("OtelReceiverPortConflict", "port %d of the OpenTelemetry collector is used by the receivers '%s' and '%s'")
+	ErrInstanceFailed                            = errors.New("InstanceFailed", "the pod of instance '%s' failed: %s")
+	ErrSidecarExitedWithoutRestart               = errors.New("SidecarExitedWithoutRestart", "sidecar '%s' exited with the code 0 and is not restarted under the restart policy OnFailure")
+	ErrRemovingTCPHostsFromProxy                 = errors.New("RemovingTCPHostsFromProxy", "error removing the TCP hosts of instance '%s' from the proxy")
+	ErrNetworkPolicyNotOwned                     = errors.New("NetworkPolicyNotOwned", "network policy '%s' of instance '%s' was not created by knuu")
 )
@@ -0,0 +1,112 @@
+package instance
+
+import (
+	"context"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// envNameRegex matches the names of the environment variables that can be exported by sh
+var envNameRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// ExecOptions controls how a command is executed in the container of an instance
+type ExecOptions struct {
+	// Shell runs the command with the shell of the instance like ExecuteCommand: the arguments are joined with spaces,
+	// so they can use pipes and redirections but the caller must quote them.
+	// Without it the arguments are passed to the command as is.
+	Shell bool
+	// Env sets environment variables for the command
+	Env map[string]string
+	// WorkDir is the working directory of the command, the one of the container if empty
+	WorkDir string
+}
+
+// ExecuteCommandDirect executes the given command in the instance without a shell
+// The arguments are passed to the command as is, so they are neither split nor interpreted.
+// This function can only be called in the state 'Started'
+func (i *Instance) ExecuteCommandDirect(ctx context.Context, command ...string) (string, error) {
+	return i.ExecuteCommandWithOptions(ctx, ExecOptions{}, command...)
+}
+
+// ExecuteCommandWithOptions executes the given command in the instance as controlled by the options
+// Env and WorkDir need a POSIX shell in the container even without the option Shell,
+// the arguments are then quoted so they are still not interpreted by the shell.
+// This function can only be called in the state 'Started'
+func (i *Instance) ExecuteCommandWithOptions(ctx context.Context, opts ExecOptions, command ...string) (string, error) {
+	if !i.allows(ActionOperate) {
+		return "", ErrExecutingCommandWithOptionsNotAllowed.WithParams(i.getState().String())
+	}
+	if len(command) == 0 {
+		return "", ErrCommandEmpty
+	}
+	shell := i.Shell()
+	if shell == nil && (opts.Shell || len(opts.Env) != 0 || opts.WorkDir != "") {
+		return "", ErrInstanceWithoutShell.WithParams(i.k8sName)
+	}
+	cmd, err := opts.command(shell, command)
+	if err != nil {
+		return "", err
+	}
+	return i.execInContainer(ctx, command, cmd)
+}
+
+// execInContainer runs cmd in the container of the instance, command is the command requested by the caller for the errors
+func (i *Instance) execInContainer(ctx context.Context, command, cmd []string) (string, error) {
+	var eErr *Error
+	if i.isSidecar {
+		eErr = ErrExecutingCommandInSidecar.WithParams(command, i.k8sName, i.parentInstance.k8sName)
+	} else {
+		eErr = ErrExecutingCommandInInstance.WithParams(command, i.k8sName)
+	}
+
+	pod, err := i.getPod(ctx)
+	if err != nil {
+		return "", ErrGettingPodFromReplicaSet.WithParams(i.k8sName).Wrap(err)
+	}
+
+	output, err := i.K8sCli.RunCommandInPod(ctx, pod.Name, i.containerName(), cmd)
+	if err != nil {
+		return "", eErr.Wrap(err)
+	}
+	return output, nil
+}
+
+// command returns the command to run in the container for the given arguments with the given shell
+func (o ExecOptions) command(shell, args []string) ([]string, error) {
+	if !o.Shell && len(o.Env) == 0 && o.WorkDir == "" {
+		return args, nil
+	}
+
+	var script []string
+	if o.WorkDir != "" {
+		script = append(script, "cd "+quoteShell(o.WorkDir))
+	}
+	names := make([]string, 0, len(o.Env))
+	for name := range o.Env {
+		if !envNameRegex.MatchString(name) {
+			return nil, ErrInvalidEnvName.WithParams(name)
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		script = append(script, "export "+name+"="+quoteShell(o.Env[name]))
+	}
+
+	if o.Shell {
+		script = append(script, strings.Join(args, " "))
+	} else {
+		quoted := make([]string, len(args))
+		for j, arg := range args {
+			quoted[j] = quoteShell(arg)
+		}
+		script = append(script, "exec "+strings.Join(quoted, " "))
+	}
+	return append(append([]string(nil), shell...), strings.Join(script, " && ")), nil
+}
+
+// quoteShell quotes the given string for sh
+func quoteShell(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'"'"'`) + "'"
+}
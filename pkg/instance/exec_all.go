@@ -0,0 +1,110 @@
+package instance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// defaultExecParallelism is the number of commands run concurrently by ExecOnAll by default
+const defaultExecParallelism = 16
+
+// ExecResult is the result of a command executed in an instance by ExecOnAll
+type ExecResult struct {
+	Instance *Instance
+	Output   string
+	Err      error
+}
+
+// ExecResults are the results of ExecOnAll, in the order of the instances
+type ExecResults []ExecResult
+
+// Err returns the errors of the instances where the command failed joined, nil if it succeeded everywhere
+func (r ExecResults) Err() error {
+	var errs []error
+	for _, result := range r {
+		if result.Err != nil {
+			errs = append(errs, fmt.Errorf("instance '%s': %w", result.Instance.Name(), result.Err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Outputs returns the outputs of the command by name of instance, for the instances where it succeeded
+func (r ExecResults) Outputs() map[string]string {
+	outputs := make(map[string]string, len(r))
+	for _, result := range r {
+		if result.Err == nil {
+			outputs[result.Instance.Name()] = result.Output
+		}
+	}
+	return outputs
+}
+
+// ExecOption configures ExecOnAll
+type ExecOption func(*execAllOptions)
+
+type execAllOptions struct {
+	parallelism int
+	execOpts    *ExecOptions
+}
+
+// WithExecParallelism sets the maximum number of commands run at the same time, 16 by default
+func WithExecParallelism(parallelism int) ExecOption {
+	return func(o *execAllOptions) {
+		o.parallelism = parallelism
+	}
+}
+
+// WithExecOptions runs the command with ExecuteCommandWithOptions and the given options instead of ExecuteCommand
+func WithExecOptions(opts ExecOptions) ExecOption {
+	return func(o *execAllOptions) {
+		o.execOpts = &opts
+	}
+}
+
+// ExecOnAll executes the command in the instances concurrently and returns the result of each instance
+// e.g. to push a configuration to or to query the state of all the nodes of a network.
+// At most 16 commands run at the same time by default, see WithExecParallelism. The commands that did not start
+// when the context is done fail with the error of the context.
+// The instances must be in the state 'Started'
+func ExecOnAll(ctx context.Context, instances []*Instance, command []string, opts ...ExecOption) ExecResults {
+	o := &execAllOptions{parallelism: defaultExecParallelism}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return execOnAll(ctx, instances, o.parallelism, func(ctx context.Context, i *Instance) (string, error) {
+		if o.execOpts != nil {
+			return i.ExecuteCommandWithOptions(ctx, *o.execOpts, command...)
+		}
+		return i.ExecuteCommand(ctx, command...)
+	})
+}
+
+// execOnAll calls exec for each instance with at most parallelism calls at the same time
+func execOnAll(ctx context.Context, instances []*Instance, parallelism int, exec func(context.Context, *Instance) (string, error)) ExecResults {
+	if parallelism < 1 {
+		parallelism = 1
+	}
+	results := make(ExecResults, len(instances))
+	slots := make(chan struct{}, parallelism)
+	var wg sync.WaitGroup
+	for j, i := range instances {
+		results[j].Instance = i
+		wg.Add(1)
+		go func(result *ExecResult) {
+			defer wg.Done()
+			select {
+			case slots <- struct{}{}:
+			case <-ctx.Done():
+				result.Err = ctx.Err()
+				return
+			}
+			defer func() { <-slots }()
+			result.Output, result.Err = exec(ctx, result.Instance)
+		}(&results[j])
+	}
+	wg.Wait()
+	return results
+}
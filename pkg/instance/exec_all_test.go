@@ -0,0 +1,71 @@
+package instance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExecOnAll(t *testing.T) {
+	t.Parallel()
+
+	instances := make([]*Instance, 10)
+	for j := range instances {
+		instances[j] = &Instance{name: fmt.Sprintf("validator-%d", j), state: Started}
+	}
+
+	var running, maxRunning atomic.Int32
+	results := execOnAll(context.Background(), instances, 3, func(_ context.Context, i *Instance) (string, error) {
+		n := running.Add(1)
+		defer running.Add(-1)
+		for {
+			m := maxRunning.Load()
+			if n <= m || maxRunning.CompareAndSwap(m, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		if i.name == "validator-4" {
+			return "", errors.New("connection refused")
+		}
+		return "height " + i.name, nil
+	})
+
+	assert.LessOrEqual(t, maxRunning.Load(), int32(3))
+	require.Len(t, results, len(instances))
+	for j, result := range results {
+		assert.Same(t, instances[j], result.Instance, "the results are in the order of the instances")
+	}
+	assert.Equal(t, "height validator-0", results[0].Output)
+
+	err := results.Err()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "instance 'validator-4': connection refused")
+	outputs := results.Outputs()
+	assert.Len(t, outputs, 9)
+	assert.NotContains(t, outputs, "validator-4")
+}
+
+func TestExecOnAllCanceled(t *testing.T) {
+	t.Parallel()
+
+	instances := []*Instance{{name: "a", state: Started}, {name: "b", state: Started}}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	results := execOnAll(ctx, instances, 1, func(ctx context.Context, _ *Instance) (string, error) {
+		return "", ctx.Err()
+	})
+	for _, result := range results {
+		assert.ErrorIs(t, result.Err, context.Canceled)
+	}
+
+	results = ExecOnAll(context.Background(), []*Instance{{name: "c", state: Committed}}, []string{"true"})
+	assert.Error(t, results.Err(), "the instance is not started")
+	assert.Nil(t, ExecResults{{Instance: instances[0], Output: "ok"}}.Err())
+}
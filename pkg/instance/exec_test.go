@@ -0,0 +1,80 @@
+package instance
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExecOptionsCommand(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		opts ExecOptions
+		args []string
+		want []string
+	}{
+		{
+			name: "direct",
+			args: []string{"echo", "a b", "$HOME"},
+			want: []string{"echo", "a b", "$HOME"},
+		},
+		{
+			name: "shell",
+			opts: ExecOptions{Shell: true},
+			args: []string{"echo", "$HOME", "|", "wc -c"},
+			want: []string{"/bin/sh", "-c", "echo $HOME | wc -c"},
+		},
+		{
+			name: "env and workdir without shell",
+			opts: ExecOptions{Env: map[string]string{"B": "it's", "A": "1"}, WorkDir: "/home/my dir"},
+			args: []string{"ls", "$HOME"},
+			want: []string{"/bin/sh", "-c", `cd '/home/my dir' && export A='1' && export B='it'"'"'s' && exec 'ls' '$HOME'`},
+		},
+		{
+			name: "env with shell",
+			opts: ExecOptions{Shell: true, Env: map[string]string{"A": "1"}},
+			args: []string{"echo", "$A"},
+			want: []string{"/bin/sh", "-c", `export A='1' && echo $A`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := tt.opts.command(defaultShell, tt.args)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	_, err := ExecOptions{Env: map[string]string{"A;rm": "1"}}.command(defaultShell, []string{"ls"})
+	assert.ErrorIs(t, err, ErrInvalidEnvName)
+}
+
+func TestExecuteCommandDirect(t *testing.T) {
+	t.Parallel()
+
+	i, k8sCli := startedInstanceWithArchive(t, "")
+	ctx := context.Background()
+
+	_, err := i.ExecuteCommandDirect(ctx, "touch", "/tmp/a b; rm -rf /")
+	require.NoError(t, err)
+	_, err = i.ExecuteCommand(ctx, "touch", "/tmp/a")
+	require.NoError(t, err)
+
+	commands := k8sCli.FakeExecutor.Commands()
+	require.Len(t, commands, 2)
+	assert.Equal(t, []string{"touch", "/tmp/a b; rm -rf /"}, commands[0].Command)
+	assert.Equal(t, []string{"/bin/sh", "-c", "touch /tmp/a"}, commands[1].Command)
+	assert.Equal(t, i.k8sName, commands[0].Container)
+
+	_, err = i.ExecuteCommandDirect(ctx)
+	assert.ErrorIs(t, err, ErrCommandEmpty)
+
+	i.state = Committed
+	_, err = i.ExecuteCommandDirect(ctx, "ls")
+	assert.ErrorIs(t, err, ErrExecutingCommandWithOptionsNotAllowed)
+}
@@ -0,0 +1,96 @@
+package instance
+
+import (
+	"context"
+	"encoding/hex"
+	"strconv"
+	"strings"
+)
+
+// The paths are passed to the scripts as arguments, so they do not need to be quoted
+const (
+	fileExistsScript = `if [ -e "$1" ]; then echo true; else echo false; fi`
+	fileSHA256Script = `sha256sum "$1"`
+	dirSizeScript    = `du -sk "$1"`
+)
+
+// FileExists returns true if a file or a directory exists at the given path in the container of the instance
+// This function can only be called in the state 'Started'
+func (i *Instance) FileExists(ctx context.Context, path string) (bool, error) {
+	if !i.allows(ActionOperate) {
+		return false, ErrInspectingFilesNotAllowed.WithParams(i.getState().String())
+	}
+	output, err := i.runScript(ctx, fileExistsScript, path)
+	if err != nil {
+		return false, ErrCheckingFileExists.WithParams(path, i.k8sName).Wrap(err)
+	}
+	exists, err := strconv.ParseBool(strings.TrimSpace(output))
+	if err != nil {
+		return false, ErrCheckingFileExists.WithParams(path, i.k8sName).Wrap(ErrUnexpectedCommandOutput.WithParams(output))
+	}
+	return exists, nil
+}
+
+// FileSHA256 returns the hex encoded SHA-256 checksum of the file at the given path in the container of the instance
+// The container must have sha256sum, e.g. from coreutils or busybox.
+// This function can only be called in the state 'Started'
+func (i *Instance) FileSHA256(ctx context.Context, path string) (string, error) {
+	if !i.allows(ActionOperate) {
+		return "", ErrInspectingFilesNotAllowed.WithParams(i.getState().String())
+	}
+	output, err := i.runScript(ctx, fileSHA256Script, path)
+	if err != nil {
+		return "", ErrComputingFileSHA256.WithParams(path, i.k8sName).Wrap(err)
+	}
+	fields := strings.Fields(output)
+	if len(fields) == 0 {
+		return "", ErrComputingFileSHA256.WithParams(path, i.k8sName).Wrap(ErrUnexpectedCommandOutput.WithParams(output))
+	}
+	if b, err := hex.DecodeString(fields[0]); err != nil || len(b) != 32 {
+		return "", ErrComputingFileSHA256.WithParams(path, i.k8sName).Wrap(ErrUnexpectedCommandOutput.WithParams(output))
+	}
+	return fields[0], nil
+}
+
+// DirSize returns the disk usage in bytes of the directory at the given path in the container of the instance, including its subdirectories
+// The usage is counted in blocks of 1KiB by du, so it can be larger than the sum of the sizes of the files.
+// This function can only be called in the state 'Started'
+func (i *Instance) DirSize(ctx context.Context, path string) (int64, error) {
+	if !i.allows(ActionOperate) {
+		return 0, ErrInspectingFilesNotAllowed.WithParams(i.getState().String())
+	}
+	output, err := i.runScript(ctx, dirSizeScript, path)
+	if err != nil {
+		return 0, ErrComputingDirSize.WithParams(path, i.k8sName).Wrap(err)
+	}
+	fields := strings.Fields(output)
+	if len(fields) == 0 {
+		return 0, ErrComputingDirSize.WithParams(path, i.k8sName).Wrap(ErrUnexpectedCommandOutput.WithParams(output))
+	}
+	kib, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil {
+		return 0, ErrComputingDirSize.WithParams(path, i.k8sName).Wrap(ErrUnexpectedCommandOutput.WithParams(output))
+	}
+	return kib * 1024, nil
+}
+
+// runScript runs the shell script with the given arguments in the container of the instance, with its shell
+// Unlike ExecuteCommand, the arguments are not joined into the script, so they are not interpreted by the shell.
+func (i *Instance) runScript(ctx context.Context, script string, args ...string) (string, error) {
+	shell := i.Shell()
+	if shell == nil {
+		return "", ErrInstanceWithoutShell.WithParams(i.k8sName)
+	}
+
+	pod, err := i.getPod(ctx)
+	if err != nil {
+		return "", ErrGettingPodFromReplicaSet.WithParams(i.k8sName).Wrap(err)
+	}
+	// the first argument after the script is $0
+	cmd := append(append(shell, script, "sh"), args...)
+	output, err := i.K8sCli.RunCommandInPod(ctx, pod.Name, i.containerName(), cmd)
+	if isExecutableNotFound(err, shell[0]) {
+		return "", ErrInstanceShellNotFound.WithParams(shell[0], i.k8sName).Wrap(err)
+	}
+	return output, err
+}
@@ -0,0 +1,74 @@
+package instance
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/celestiaorg/knuu/pkg/k8s/fake"
+)
+
+func TestFileHelpers(t *testing.T) {
+	t.Parallel()
+
+	i, k8sCli := startedInstanceWithArchive(t, "")
+	k8sCli.FakeExecutor.Handler = func(cmd fake.Command) (string, string, error) {
+		path := cmd.Command[len(cmd.Command)-1]
+		switch cmd.Command[2] {
+		case fileExistsScript:
+			if path == "/home/genesis.json" {
+				return "true\n", "", nil
+			}
+			return "false\n", "", nil
+		case fileSHA256Script:
+			if path == "/home/missing.json" {
+				return "", "", errors.New("command terminated with exit code 1")
+			}
+			return genesisChecksum + "  " + path + "\n", "", nil
+		case dirSizeScript:
+			return "2048\t" + path + "\n", "", nil
+		}
+		return "", "", errors.New("unexpected command")
+	}
+	ctx := context.Background()
+
+	exists, err := i.FileExists(ctx, "/home/genesis.json")
+	require.NoError(t, err)
+	assert.True(t, exists)
+	exists, err = i.FileExists(ctx, "/home/missing.json")
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	sum, err := i.FileSHA256(ctx, "/home/genesis.json")
+	require.NoError(t, err)
+	assert.Equal(t, genesisChecksum, sum)
+	_, err = i.FileSHA256(ctx, "/home/missing.json")
+	assert.ErrorIs(t, err, ErrComputingFileSHA256)
+
+	size, err := i.DirSize(ctx, "/home/data dir")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2048*1024), size)
+
+	commands := k8sCli.FakeExecutor.Commands()
+	assert.Equal(t, []string{"/bin/sh", "-c", dirSizeScript, "sh", "/home/data dir"}, commands[len(commands)-1].Command)
+
+	// the scripts run with the shell of the instance
+	require.NoError(t, i.SetShell("/bin/bash", "-c"))
+	_, err = i.DirSize(ctx, "/home/data")
+	require.NoError(t, err)
+	commands = k8sCli.FakeExecutor.Commands()
+	assert.Equal(t, []string{"/bin/bash", "-c", dirSizeScript, "sh", "/home/data"}, commands[len(commands)-1].Command)
+
+	k8sCli.FakeExecutor.Handler = func(cmd fake.Command) (string, string, error) {
+		return "", "", errors.New(`exec: "/bin/bash": executable file not found in $PATH`)
+	}
+	_, err = i.FileExists(ctx, "/home/genesis.json")
+	assert.ErrorContains(t, err, "the shell '/bin/bash' is not found")
+
+	i.state = Stopped
+	_, err = i.FileExists(ctx, "/home/genesis.json")
+	assert.ErrorIs(t, err, ErrInspectingFilesNotAllowed)
+}
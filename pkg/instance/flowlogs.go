@@ -0,0 +1,237 @@
+package instance
+
+import (
+	"context"
+	"net/netip"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// DefaultFlowLogsImage is the image of the flow logs sidecar, it contains bpftrace
+	DefaultFlowLogsImage = "quay.io/iovisor/bpftrace:latest"
+
+	// flowLogsSidecar is the ephemeral sidecar tracing the connections of the instance with eBPF,
+	// it stays attached until the pod of the instance is recreated
+	flowLogsSidecar = "knuu-flowlogs"
+	flowLogsFile    = "/tmp/knuu-flows.log"
+	flowLogsErrFile = "/tmp/knuu-flows.err"
+	flowLogsPidFile = "/tmp/knuu-flows.pid"
+	// flowLogsScriptEnv passes the bpftrace program to the sidecar, so it does not have to be quoted in the commands
+	flowLogsScriptEnv = "KNUU_FLOWS_SCRIPT"
+	// flowLogPrefix starts the lines of the flows, the other lines of the output of bpftrace are ignored
+	flowLogPrefix = "flow"
+)
+
+// flowLogsScript logs a line for each TCP connection of the network namespace given as first parameter when it is
+// closed, in the same way as the tcplife tool of bpftrace
+// The states are the ones of include/net/tcp_states.h: SYN_SENT 2, FIN_WAIT1 4, CLOSE 7 and LAST_ACK 9.
+const flowLogsScript = `kprobe:tcp_set_state
+{
+	$sk = (struct sock *)arg0;
+	if ($sk->__sk_common.skc_net.net->ns.inum != $1) { return; }
+	$state = arg1;
+	if ($state < 4 && @birth[$sk] == 0) { @birth[$sk] = nsecs; }
+	if ($state == 2) { @outgoing[$sk] = 1; }
+	if ($state == 2 || $state == 9) { @skpid[$sk] = pid; @skcomm[$sk] = comm; }
+	if ($state == 7 && @birth[$sk] != 0) {
+		$tp = (struct tcp_sock *)$sk;
+		$rport = $sk->__sk_common.skc_dport;
+		$rport = (($rport & 0xff) << 8) | ($rport >> 8);
+		$pid = @skpid[$sk];
+		$comm = @skcomm[$sk];
+		if ($pid == 0) { $pid = pid; $comm = comm; }
+		if ($sk->__sk_common.skc_family == 2) {
+			$laddr = ntop(2, $sk->__sk_common.skc_rcv_saddr);
+			$raddr = ntop(2, $sk->__sk_common.skc_daddr);
+		} else {
+			$laddr = ntop(10, $sk->__sk_common.skc_v6_rcv_saddr.in6_u.u6_addr8);
+			$raddr = ntop(10, $sk->__sk_common.skc_v6_daddr.in6_u.u6_addr8);
+		}
+		printf("flow %s %d %d %d %s %d %s %d %d %d %s\n", strftime("%s", nsecs), (nsecs - @birth[$sk]) / 1000,
+			@outgoing[$sk], $pid, $laddr, $sk->__sk_common.skc_num, $raddr, $rport,
+			$tp->bytes_acked, $tp->bytes_received, $comm);
+		delete(@birth[$sk]);
+		delete(@outgoing[$sk]);
+		delete(@skpid[$sk]);
+		delete(@skcomm[$sk]);
+	}
+}`
+
+// Flow is a TCP connection of an instance, logged when it is closed
+type Flow struct {
+	// Closed is the time the connection was closed, with a precision of a second
+	Closed   time.Time
+	Duration time.Duration
+	// Outgoing is true if the connection was opened by the instance
+	Outgoing bool
+	// PID and Command are the process of the instance that opened or closed the connection
+	PID           int
+	Command       string
+	Local         netip.AddrPort
+	Remote        netip.AddrPort
+	BytesSent     uint64
+	BytesReceived uint64
+}
+
+// StartFlowLogs starts logging the TCP connections of the instance, with the number of bytes sent and received
+// and the process of each connection, see FlowLogs
+// The connections are traced with eBPF by a privileged ephemeral sidecar with the given image, DefaultFlowLogsImage
+// if empty, which must contain bpftrace. The kernel of the nodes must expose its BTF type information, like the
+// kernels of most distributions. Unlike a packet capture, the cost does not grow with the throughput of the instance.
+// Calling it again clears the logged flows.
+// This function can only be called in the state 'Started'
+func (i *Instance) StartFlowLogs(ctx context.Context, image string) error {
+	if !i.allows(ActionOperate) {
+		return ErrStartingFlowLogsNotAllowed.WithParams(i.getState().String())
+	}
+	if i.isSidecar {
+		return ErrStartingFlowLogsOfSidecar
+	}
+	if image == "" {
+		image = DefaultFlowLogsImage
+	}
+
+	if err := i.attachFlowLogs(ctx, image); err != nil {
+		return err
+	}
+	if _, err := i.ExecuteCommandInEphemeralSidecar(ctx, flowLogsSidecar, flowLogsStartCommand()); err != nil {
+		return ErrStartingFlowLogs.WithParams(i.k8sName).Wrap(err)
+	}
+	logrus.Debugf("Started flow logs of instance '%s'", i.k8sName)
+	return nil
+}
+
+// FlowLogs returns the TCP connections of the instance closed since StartFlowLogs was called, in the order they
+// were closed
+// The flows logged before StopFlowLogs was called can still be fetched.
+// This function can only be called in the state 'Started'
+func (i *Instance) FlowLogs(ctx context.Context) ([]Flow, error) {
+	if !i.allows(ActionOperate) {
+		return nil, ErrGettingFlowLogsNotAllowed.WithParams(i.getState().String())
+	}
+	output, err := i.ExecuteCommandInEphemeralSidecar(ctx, flowLogsSidecar, "cat", flowLogsFile)
+	if err != nil {
+		return nil, ErrGettingFlowLogs.WithParams(i.k8sName).Wrap(err)
+	}
+	return parseFlows(output), nil
+}
+
+// StopFlowLogs stops logging the TCP connections of the instance
+// This function can only be called in the state 'Started'
+func (i *Instance) StopFlowLogs(ctx context.Context) error {
+	if !i.allows(ActionOperate) {
+		return ErrStoppingFlowLogsNotAllowed.WithParams(i.getState().String())
+	}
+	if _, err := i.ExecuteCommandInEphemeralSidecar(ctx, flowLogsSidecar, flowLogsStopCommand()); err != nil {
+		return ErrStoppingFlowLogs.WithParams(i.k8sName).Wrap(err)
+	}
+	logrus.Debugf("Stopped flow logs of instance '%s'", i.k8sName)
+	return nil
+}
+
+// attachFlowLogs attaches the sidecar of the flow logs unless it is already running
+func (i *Instance) attachFlowLogs(ctx context.Context, image string) error {
+	pod, err := i.getPod(ctx)
+	if err != nil {
+		return ErrGettingPodFromReplicaSet.WithParams(i.k8sName).Wrap(err)
+	}
+	if _, ok := ephemeralContainer(pod, flowLogsSidecar); ok {
+		if status, ok := ephemeralContainerStatus(pod, flowLogsSidecar); ok && status.State.Running != nil {
+			return nil
+		}
+		return ErrFlowLogsSidecarNotRunning.WithParams(i.k8sName)
+	}
+
+	err = i.AddEphemeralSidecar(ctx, EphemeralSidecar{
+		Name:    flowLogsSidecar,
+		Image:   image,
+		Command: []string{"/bin/sh", "-c", "trap 'exit 0' TERM; while true; do sleep 1; done"},
+		Env:     map[string]string{flowLogsScriptEnv: flowLogsScript},
+		// loading eBPF programs needs more than the capabilities BPF and PERFMON on most clusters
+		Privileged: true,
+	})
+	if err != nil {
+		return ErrStartingFlowLogs.WithParams(i.k8sName).Wrap(err)
+	}
+	return nil
+}
+
+// flowLogsStartCommand returns the shell command (re)starting bpftrace in the background, filtered on the network
+// namespace of the pod, which the sidecar shares
+// It fails with the errors of bpftrace if bpftrace exits right away, e.g. when the kernel has no BTF.
+func flowLogsStartCommand() string {
+	return strings.Join([]string{
+		flowLogsStopCommand(),
+		"rm -f " + flowLogsFile + " " + flowLogsErrFile,
+		"{ nohup bpftrace -B line -o " + flowLogsFile + ` -e "$` + flowLogsScriptEnv + `" $(stat -L -c %i /proc/self/ns/net) > ` + flowLogsErrFile + " 2>&1 & echo $! > " + flowLogsPidFile + "; }",
+		"sleep 1",
+		"{ kill -0 $(cat " + flowLogsPidFile + ") || { cat " + flowLogsErrFile + " >&2; exit 1; }; }",
+	}, " && ")
+}
+
+// flowLogsStopCommand returns the shell command stopping bpftrace, if it is running
+func flowLogsStopCommand() string {
+	return "{ [ ! -f " + flowLogsPidFile + " ] || kill -INT $(cat " + flowLogsPidFile + ") 2>/dev/null; rm -f " + flowLogsPidFile + "; true; }"
+}
+
+// parseFlows reads the flows in the output of the bpftrace program, the lines that are not flows are ignored
+func parseFlows(output string) []Flow {
+	var flows []Flow
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 12 || fields[0] != flowLogPrefix {
+			continue
+		}
+		flow, ok := parseFlow(fields[1:])
+		if !ok {
+			continue
+		}
+		flows = append(flows, flow)
+	}
+	return flows
+}
+
+// parseFlow parses the fields of a flow: closed at, duration in µs, outgoing, pid, local address and port,
+// remote address and port, bytes sent and received, command
+func parseFlow(fields []string) (Flow, bool) {
+	var ints [8]uint64
+	for j, idx := range []int{0, 1, 2, 3, 5, 7, 8, 9} {
+		v, err := strconv.ParseUint(fields[idx], 10, 64)
+		if err != nil {
+			return Flow{}, false
+		}
+		ints[j] = v
+	}
+	local, err := parseAddrPort(fields[4], ints[4])
+	if err != nil {
+		return Flow{}, false
+	}
+	remote, err := parseAddrPort(fields[6], ints[5])
+	if err != nil {
+		return Flow{}, false
+	}
+	return Flow{
+		Closed:        time.Unix(int64(ints[0]), 0),
+		Duration:      time.Duration(ints[1]) * time.Microsecond,
+		Outgoing:      ints[2] == 1,
+		PID:           int(ints[3]),
+		Local:         local,
+		Remote:        remote,
+		BytesSent:     ints[6],
+		BytesReceived: ints[7],
+		// the name of a process can contain spaces
+		Command: strings.Join(fields[10:], " "),
+	}, true
+}
+
+func parseAddrPort(addr string, port uint64) (netip.AddrPort, error) {
+	ip, err := netip.ParseAddr(addr)
+	if err != nil {
+		return netip.AddrPort{}, err
+	}
+	return netip.AddrPortFrom(ip.Unmap(), uint16(port)), nil
+}
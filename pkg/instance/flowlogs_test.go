@@ -0,0 +1,48 @@
+package instance
+
+import (
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFlows(t *testing.T) {
+	t.Parallel()
+
+	output := `Attaching 1 probe...
+flow 1760601600 2500 1 42 10.244.0.7 41234 10.96.0.12 26656 1048576 2048 celestia-appd
+flow 1760601601 120 0 7 ::ffff:10.244.0.7 26657 10.244.0.9 50000 512 64 tendermint rpc
+flow not-a-number 120 0 7 10.244.0.7 26657 10.244.0.9 50000 512 64 app
+flow 1760601602 120 0 7 10.244.0.7 26657
+`
+	flows := parseFlows(output)
+
+	require.Len(t, flows, 2)
+	assert.Equal(t, Flow{
+		Closed:        time.Unix(1760601600, 0),
+		Duration:      2500 * time.Microsecond,
+		Outgoing:      true,
+		PID:           42,
+		Command:       "celestia-appd",
+		Local:         netip.MustParseAddrPort("10.244.0.7:41234"),
+		Remote:        netip.MustParseAddrPort("10.96.0.12:26656"),
+		BytesSent:     1048576,
+		BytesReceived: 2048,
+	}, flows[0])
+	assert.False(t, flows[1].Outgoing)
+	assert.Equal(t, "tendermint rpc", flows[1].Command)
+	assert.Equal(t, netip.MustParseAddrPort("10.244.0.7:26657"), flows[1].Local, "the IPv4-mapped addresses are unmapped")
+}
+
+func TestFlowLogsStartCommand(t *testing.T) {
+	t.Parallel()
+
+	cmd := flowLogsStartCommand()
+	assert.Contains(t, cmd, `-e "$`+flowLogsScriptEnv+`"`)
+	assert.Contains(t, cmd, "/proc/self/ns/net")
+	assert.Less(t, strings.Index(cmd, flowLogsStopCommand()), strings.Index(cmd, "nohup"), "a running capture is stopped first")
+}
@@ -0,0 +1,238 @@
+package instance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/celestiaorg/knuu/pkg/clock"
+)
+
+// groupLabel is the label of the pods of the instances created by a group, its value is the name of the group
+const groupLabel = "knuu.sh/group"
+
+// Constructor creates an instance with the given options, e.g. knuu.NewInstance
+type Constructor func(name string, opts ...Option) (*Instance, error)
+
+// InstanceGroup creates instances that share a configuration, e.g. the validators of a network
+// The options of the group are applied before the ones of each instance, so the instances can override them:
+// the single values such as the image, the command or the resources are replaced,
+// the environment variables are merged and the ports and the setup functions are added.
+type InstanceGroup struct {
+	name        string
+	newInstance Constructor
+	opts        []Option
+
+	mu        sync.Mutex
+	instances []*Instance
+	zoneLabel string
+}
+
+// NewGroup returns a group that creates its instances with the given constructor and options
+func NewGroup(name string, newInstance Constructor, opts ...Option) *InstanceGroup {
+	return &InstanceGroup{
+		name:        name,
+		newInstance: newInstance,
+		opts:        opts,
+	}
+}
+
+// Name returns the name of the group
+func (g *InstanceGroup) Name() string {
+	return g.name
+}
+
+// NewInstance creates an instance with the options of the group followed by the given overrides
+func (g *InstanceGroup) NewInstance(name string, overrides ...Option) (*Instance, error) {
+	opts := make([]Option, 0, len(g.opts)+len(overrides))
+	opts = append(opts, g.opts...)
+	opts = append(opts, overrides...)
+
+	i, err := g.newInstance(name, opts...)
+	if err != nil {
+		return nil, ErrCreatingGroupInstance.WithParams(name, g.name).Wrap(err)
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	i.mu.Lock()
+	i.group = g.name
+	i.zoneLabel = g.zoneLabel
+	i.mu.Unlock()
+	g.instances = append(g.instances, i)
+	logrus.Debugf("Created instance '%s' in group '%s'", name, g.name)
+	return i, nil
+}
+
+// NewInstances creates the given amount of instances named after the group and their index, e.g. 'validator-0'
+// The overrides function returns the options of the instance with the given index, it can be nil.
+func (g *InstanceGroup) NewInstances(amount int, overrides func(index int) []Option) ([]*Instance, error) {
+	instances := make([]*Instance, 0, amount)
+	for j := 0; j < amount; j++ {
+		var opts []Option
+		if overrides != nil {
+			opts = overrides(j)
+		}
+		i, err := g.NewInstance(fmt.Sprintf("%s-%d", g.name, j), opts...)
+		if err != nil {
+			return nil, err
+		}
+		instances = append(instances, i)
+	}
+	return instances, nil
+}
+
+// Instances returns the instances created by the group
+func (g *InstanceGroup) Instances() []*Instance {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return append([]*Instance(nil), g.instances...)
+}
+
+// RollingRestart restarts the started instances of the group in batches of batchSize, e.g. to simulate a maintenance wave
+// The instances of a batch are stopped and started concurrently, and the next batch is restarted interval after
+// the instances of the batch are running again. It stops at the first batch that fails.
+func (g *InstanceGroup) RollingRestart(ctx context.Context, batchSize int, interval time.Duration) error {
+	return g.rolling(ctx, "restart", batchSize, interval, func(ctx context.Context, i *Instance) error {
+		if err := i.Stop(ctx); err != nil {
+			return err
+		}
+		return i.Start(ctx)
+	})
+}
+
+// RollingStop stops the started instances of the group in batches of batchSize, waiting interval between the batches
+// It stops at the first batch that fails.
+func (g *InstanceGroup) RollingStop(ctx context.Context, batchSize int, interval time.Duration) error {
+	return g.rolling(ctx, "stop", batchSize, interval, func(ctx context.Context, i *Instance) error {
+		return i.Stop(ctx)
+	})
+}
+
+// rolling applies op to the instances of the group that are started, in batches of batchSize
+// The interval is waited on the clock of the context, so a fake clock can drive the waves.
+func (g *InstanceGroup) rolling(ctx context.Context, name string, batchSize int, interval time.Duration, op func(context.Context, *Instance) error) error {
+	if batchSize < 1 {
+		return ErrInvalidBatchSize.WithParams(batchSize)
+	}
+
+	var started []*Instance
+	for _, i := range g.Instances() {
+		if i.IsInState(Started) {
+			started = append(started, i)
+		}
+	}
+
+	for first := 0; first < len(started); first += batchSize {
+		if first > 0 {
+			if err := clock.Sleep(ctx, interval); err != nil {
+				return ErrRollingOperation.WithParams(name, g.name).Wrap(err)
+			}
+		}
+		batch := started[first:min(first+batchSize, len(started))]
+
+		errs := make([]error, len(batch))
+		var wg sync.WaitGroup
+		for j, i := range batch {
+			wg.Add(1)
+			go func(j int, i *Instance) {
+				defer wg.Done()
+				errs[j] = op(ctx, i)
+			}(j, i)
+		}
+		wg.Wait()
+		if err := errors.Join(errs...); err != nil {
+			return ErrRollingOperation.WithParams(name, g.name).Wrap(err)
+		}
+		logrus.Debugf("Rolling %s of group '%s': %d/%d instances done", name, g.name, first+len(batch), len(started))
+	}
+	return nil
+}
+
+// DistributeAcrossZones spreads the pods of the instances of the group evenly across the zones of the nodes
+// The zone of a node is the value of its label zoneLabel, topology.kubernetes.io/zone if empty.
+// The pods are still scheduled when the spread cannot be satisfied, e.g. on a cluster with a single zone.
+// It applies to the instances created afterwards too.
+// The instances of the group must be in the state 'Preparing' or 'Committed'
+func (g *InstanceGroup) DistributeAcrossZones(zoneLabel string) error {
+	if zoneLabel == "" {
+		zoneLabel = v1.LabelTopologyZone
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for _, i := range g.instances {
+		if !i.allows(ActionConfigure) {
+			return ErrDistributingGroupNotAllowed.WithParams(g.name, i.name, i.getState().String())
+		}
+	}
+	for _, i := range g.instances {
+		i.mu.Lock()
+		i.zoneLabel = zoneLabel
+		i.mu.Unlock()
+	}
+	g.zoneLabel = zoneLabel
+	logrus.Debugf("Distributing group '%s' across the zones of label '%s'", g.name, zoneLabel)
+	return nil
+}
+
+// ZoneOutage disables the network of the started instances of the group that run in the given zone, to simulate its failure
+// The zone of a node is read from the label given to DistributeAcrossZones, topology.kubernetes.io/zone by default.
+// It returns the isolated instances, the outage ends when their network is enabled again.
+func (g *InstanceGroup) ZoneOutage(ctx context.Context, zone string) ([]*Instance, error) {
+	g.mu.Lock()
+	zoneLabel := g.zoneLabel
+	g.mu.Unlock()
+	if zoneLabel == "" {
+		zoneLabel = v1.LabelTopologyZone
+	}
+
+	var isolated []*Instance
+	for _, i := range g.Instances() {
+		if !i.IsInState(Started) {
+			continue
+		}
+		pod, err := i.getPod(ctx)
+		if err != nil {
+			return isolated, ErrGettingPodFromReplicaSet.WithParams(i.k8sName).Wrap(err)
+		}
+		if pod.Spec.NodeName == "" {
+			continue
+		}
+		node, err := i.K8sCli.GetNode(ctx, pod.Spec.NodeName)
+		if err != nil {
+			return isolated, ErrGettingInstanceZone.WithParams(i.k8sName).Wrap(err)
+		}
+		if node.Labels[zoneLabel] != zone {
+			continue
+		}
+		if err := i.DisableNetwork(ctx); err != nil {
+			return isolated, ErrSimulatingZoneOutage.WithParams(zone, g.name).Wrap(err)
+		}
+		isolated = append(isolated, i)
+	}
+	logrus.Debugf("Zone '%s' is down for group '%s': %d instances isolated", zone, g.name, len(isolated))
+	return isolated, nil
+}
+
+// topologySpreadConstraints spreads the pod of the instance with the pods of its group across the zones
+func (i *Instance) topologySpreadConstraints() []v1.TopologySpreadConstraint {
+	if i.group == "" || i.zoneLabel == "" {
+		return nil
+	}
+	return []v1.TopologySpreadConstraint{{
+		MaxSkew:           1,
+		TopologyKey:       i.zoneLabel,
+		WhenUnsatisfiable: v1.ScheduleAnyway,
+		LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{
+			groupLabel:      i.group,
+			"knuu.sh/scope": i.TestScope,
+		}},
+	}}
+}
@@ -0,0 +1,156 @@
+package instance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/celestiaorg/knuu/pkg/clock"
+	"github.com/celestiaorg/knuu/pkg/k8s/fake"
+	"github.com/celestiaorg/knuu/pkg/system"
+)
+
+func TestInstanceGroup(t *testing.T) {
+	t.Parallel()
+
+	newInstance := func(name string, opts ...Option) (*Instance, error) {
+		return New(name, system.SystemDependencies{}, opts...)
+	}
+	g := NewGroup("validator", newInstance,
+		WithImage("ghcr.io/celestiaorg/celestia-app:v1"),
+		WithPorts(26656),
+		WithResources("1Gi", "2Gi", "500m"),
+		WithSetup(func(i *Instance) error { return i.SetOtelEndpoint(4318) }),
+	)
+
+	instances, err := g.NewInstances(3, func(index int) []Option {
+		if index == 2 {
+			return []Option{WithResources("4Gi", "8Gi", "2"), WithPorts(9090)}
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	require.Len(t, instances, 3)
+
+	for j, i := range instances {
+		spec := i.Spec()
+		assert.Equal(t, fmt.Sprintf("validator-%d", j), spec.Name)
+		assert.Equal(t, 4318, i.obsyConfig.otlpPort)
+		if j < 2 {
+			assert.Equal(t, ResourcesSpec{MemoryRequest: "1Gi", MemoryLimit: "2Gi", CPURequest: "500m"}, spec.Resources)
+			assert.Equal(t, []int{26656}, spec.PortsTCP)
+		}
+	}
+	assert.Equal(t, ResourcesSpec{MemoryRequest: "4Gi", MemoryLimit: "8Gi", CPURequest: "2"}, instances[2].Spec().Resources)
+	assert.Equal(t, []int{26656, 9090}, instances[2].Spec().PortsTCP)
+
+	_, err = g.NewInstance("validator-3", WithPorts(26656))
+	assert.ErrorIs(t, err, ErrCreatingGroupInstance)
+	assert.Len(t, g.Instances(), 3)
+}
+
+func TestInstanceGroupRolling(t *testing.T) {
+	t.Parallel()
+
+	newInstance := func(name string, opts ...Option) (*Instance, error) {
+		return New(name, system.SystemDependencies{}, opts...)
+	}
+	g := NewGroup("validator", newInstance, WithImage("alpine"))
+	instances, err := g.NewInstances(5, nil)
+	require.NoError(t, err)
+	for _, i := range instances[:4] {
+		i.state = Started
+	}
+
+	fakeClock := clock.NewFake(time.Now())
+	ctx := clock.WithClock(context.Background(), fakeClock)
+
+	var (
+		mu      sync.Mutex
+		batches [][]string
+	)
+	op := func(_ context.Context, i *Instance) error {
+		mu.Lock()
+		defer mu.Unlock()
+		if len(batches) == 0 || len(batches[len(batches)-1]) == 3 {
+			batches = append(batches, nil)
+		}
+		batches[len(batches)-1] = append(batches[len(batches)-1], i.Name())
+		return nil
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- g.rolling(ctx, "restart", 3, time.Minute, op)
+	}()
+
+	// the second batch waits for the interval
+	require.Eventually(t, fakeClock.HasWaiters, time.Second, time.Millisecond)
+	mu.Lock()
+	assert.Len(t, batches, 1)
+	assert.Len(t, batches[0], 3)
+	mu.Unlock()
+
+	fakeClock.Step(time.Minute)
+	require.NoError(t, <-done)
+	require.Len(t, batches, 2)
+	assert.Equal(t, []string{"validator-3"}, batches[1], "the instances that are not started are skipped")
+
+	assert.ErrorIs(t, g.RollingStop(ctx, 0, time.Minute), ErrInvalidBatchSize)
+
+	failing := func(context.Context, *Instance) error { return errors.New("stop failed") }
+	assert.ErrorIs(t, g.rolling(ctx, "stop", 5, time.Minute, failing), ErrRollingOperation)
+}
+
+func TestInstanceGroupZones(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	k8sCli, err := fake.New(ctx, "test",
+		&v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-a", Labels: map[string]string{v1.LabelTopologyZone: "zone-a"}}},
+		&v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-b", Labels: map[string]string{v1.LabelTopologyZone: "zone-b"}}},
+	)
+	require.NoError(t, err)
+	newInstance := func(name string, opts ...Option) (*Instance, error) {
+		return New(name, system.SystemDependencies{K8sCli: k8sCli, TestScope: "test"}, opts...)
+	}
+	g := NewGroup("validator", newInstance, WithImage("alpine"))
+	first, err := g.NewInstance("validator-a")
+	require.NoError(t, err)
+	assert.Nil(t, first.preparePodConfig().TopologySpreadConstraints)
+
+	require.NoError(t, g.DistributeAcrossZones(""))
+	second, err := g.NewInstance("validator-b")
+	require.NoError(t, err)
+	for _, i := range []*Instance{first, second} {
+		config := i.preparePodConfig()
+		assert.Equal(t, "validator", config.Labels[groupLabel])
+		require.Len(t, config.TopologySpreadConstraints, 1)
+		constraint := config.TopologySpreadConstraints[0]
+		assert.Equal(t, v1.LabelTopologyZone, constraint.TopologyKey)
+		assert.Equal(t, map[string]string{groupLabel: "validator", "knuu.sh/scope": "test"}, constraint.LabelSelector.MatchLabels)
+	}
+
+	for node, i := range map[string]*Instance{"node-a": first, "node-b": second} {
+		i.restartPolicy = v1.RestartPolicyNever
+		i.state = Started
+		pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: i.k8sName}, Spec: v1.PodSpec{NodeName: node}}
+		_, err := k8sCli.FakeClientset.CoreV1().Pods("test").Create(ctx, pod, metav1.CreateOptions{})
+		require.NoError(t, err)
+	}
+	assert.ErrorIs(t, g.DistributeAcrossZones("zone"), ErrDistributingGroupNotAllowed)
+
+	isolated, err := g.ZoneOutage(ctx, "zone-b")
+	require.NoError(t, err)
+	require.Equal(t, []*Instance{second}, isolated)
+	assert.True(t, k8sCli.NetworkPolicyExists(ctx, second.k8sName))
+	assert.False(t, k8sCli.NetworkPolicyExists(ctx, first.k8sName))
+}
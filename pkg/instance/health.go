@@ -0,0 +1,52 @@
+package instance
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Health is the state of the container of an instance as reported by kubernetes
+type Health struct {
+	// Ready is true if the container is running and passes its readiness checks
+	Ready bool
+	// Restarts is the number of times kubernetes restarted the container
+	Restarts int32
+	// Reason explains why the container is not ready, or why it last terminated (e.g. CrashLoopBackOff, OOMKilled)
+	Reason string
+}
+
+// Health returns the health of the container of the instance
+// This function can only be called in the state 'Started'
+func (i *Instance) Health(ctx context.Context) (*Health, error) {
+	if !i.allows(ActionOperate) {
+		return nil, ErrCheckingHealthNotAllowed.WithParams(i.getState().String())
+	}
+
+	pod, err := i.getPod(ctx)
+	if err != nil {
+		return nil, ErrGettingPodFromReplicaSet.WithParams(i.k8sName).Wrap(err)
+	}
+	for _, cs := range pod.Status.ContainerStatuses {
+		if cs.Name == i.k8sName {
+			return containerHealth(cs), nil
+		}
+	}
+	return &Health{Reason: string(pod.Status.Phase)}, nil
+}
+
+func containerHealth(cs corev1.ContainerStatus) *Health {
+	h := &Health{
+		Ready:    cs.Ready,
+		Restarts: cs.RestartCount,
+	}
+	switch {
+	case cs.State.Waiting != nil:
+		h.Reason = cs.State.Waiting.Reason
+	case cs.State.Terminated != nil:
+		h.Reason = cs.State.Terminated.Reason
+	case cs.LastTerminationState.Terminated != nil:
+		h.Reason = cs.LastTerminationState.Terminated.Reason
+	}
+	return h
+}
@@ -7,6 +7,7 @@ import (
 	"net"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 
 	v1 "k8s.io/api/core/v1"
@@ -23,15 +24,55 @@ func (i *Instance) getImageRegistry() (string, error) {
 	if i.imageName != "" {
 		return i.imageName, nil
 	}
-	// If not already set, generate a random name using ttl.sh
+	// If not already set, generate a random name using ttl.sh or the registry of the test
 	uuid, err := uuid.NewRandom()
 	if err != nil {
 		return "", fmt.Errorf("error generating UUID: %w", err)
 	}
+	if i.ImageRegistry != "" {
+		// not latest, so that the nodes do not pull again the images they have, e.g. the loaded ones
+		return fmt.Sprintf("%s/%s:knuu", i.ImageRegistry, uuid.String()), nil
+	}
 	imageName := fmt.Sprintf("ttl.sh/%s:24h", uuid.String())
 	return imageName, nil
 }
 
+// imageCacheKey returns the key of the image cache for the given hash of the image built for the instance, the
+// images are only reused from the registry they were pushed to
+func (i *Instance) imageCacheKey(imageHash string) string {
+	if i.ImageRegistry == "" {
+		return imageHash
+	}
+	return i.ImageRegistry + "@" + imageHash
+}
+
+// checkImage runs the post build hooks on the given image built for the instance and signs it
+func (i *Instance) checkImage(ctx context.Context, imageName string) error {
+	for _, hook := range i.PostBuildHooks {
+		if err := hook.CheckImage(ctx, imageName); err != nil {
+			return ErrImageRejectedByHook.WithParams(imageName, i.name).Wrap(err)
+		}
+	}
+	if i.ImageSigner == nil {
+		return nil
+	}
+	if err := i.ImageSigner.SignImage(ctx, imageName); err != nil {
+		return ErrSigningImage.WithParams(imageName, i.name).Wrap(err)
+	}
+	return nil
+}
+
+// verifyImage verifies the signature of the given image before it is used by the instance
+func (i *Instance) verifyImage(ctx context.Context, imageName string) error {
+	if i.ImageVerifier == nil {
+		return nil
+	}
+	if err := i.ImageVerifier.VerifyImage(ctx, imageName); err != nil {
+		return ErrVerifyingImage.WithParams(imageName, i.name).Wrap(err)
+	}
+	return nil
+}
+
 // validatePort validates the port
 func validatePort(port int) error {
 	if port < 1 || port > 65535 {
@@ -62,17 +103,30 @@ func (i *Instance) isUDPPortRegistered(port int) bool {
 	return false
 }
 
+const (
+	// managedByLabel marks the objects created by knuu, see getLabels
+	managedByLabel = "k8s.kubernetes.io/managed-by"
+	managedByKnuu  = "knuu"
+)
+
 // getLabels returns the labels for the instance
 func (i *Instance) getLabels() map[string]string {
-	return map[string]string{
-		"app":                          i.k8sName,
-		"k8s.kubernetes.io/managed-by": "knuu",
-		"knuu.sh/scope":                i.TestScope,
-		"knuu.sh/test-started":         i.StartTime,
-		"knuu.sh/name":                 i.name,
-		"knuu.sh/k8s-name":             i.k8sName,
-		"knuu.sh/type":                 i.instanceType.String(),
+	labels := map[string]string{
+		"app":                  i.k8sName,
+		managedByLabel:         managedByKnuu,
+		"knuu.sh/scope":        i.TestScope,
+		"knuu.sh/test-started": i.StartTime,
+		"knuu.sh/name":         i.name,
+		"knuu.sh/k8s-name":     i.k8sName,
+		"knuu.sh/type":         i.instanceType.String(),
 	}
+	if i.group != "" {
+		labels[groupLabel] = i.group
+	}
+	if i.preemptible {
+		labels[preemptibleLabel] = "true"
+	}
+	return labels
 }
 
 // Labels returns the labels for the instance
@@ -81,7 +135,7 @@ func (i *Instance) Labels() map[string]string {
 }
 
 // deployService deploys the service for the instance
-func (i *Instance) deployService(ctx context.Context, portsTCP, portsUDP []int) error {
+func (i *Instance) deployService(ctx context.Context, ports []k8s.ServicePort) error {
 	// a sidecar instance should use the parent instance's service
 	if i.isSidecar {
 		return ErrDeployingServiceForSidecar.WithParams(i.k8sName)
@@ -91,7 +145,7 @@ func (i *Instance) deployService(ctx context.Context, portsTCP, portsUDP []int)
 	labels := i.getLabels()
 	labelSelectors := labels
 
-	service, err := i.K8sCli.CreateService(ctx, serviceName, labels, labelSelectors, portsTCP, portsUDP)
+	service, err := i.K8sCli.DeployService(ctx, k8s.ServiceConfig{Name: serviceName, Labels: labels, Selector: labelSelectors, Ports: ports})
 	if err != nil {
 		return ErrDeployingService.WithParams(i.k8sName).Wrap(err)
 	}
@@ -101,7 +155,7 @@ func (i *Instance) deployService(ctx context.Context, portsTCP, portsUDP []int)
 }
 
 // patchService patches the service for the instance
-func (i *Instance) patchService(ctx context.Context, portsTCP, portsUDP []int) error {
+func (i *Instance) patchService(ctx context.Context, ports []k8s.ServicePort) error {
 	// a sidecar instance should use the parent instance's service
 	if i.isSidecar {
 		return ErrPatchingServiceForSidecar.WithParams(i.k8sName)
@@ -111,7 +165,7 @@ func (i *Instance) patchService(ctx context.Context, portsTCP, portsUDP []int) e
 	labels := i.getLabels()
 	labelSelectors := labels
 
-	service, err := i.K8sCli.PatchService(ctx, serviceName, labels, labelSelectors, portsTCP, portsUDP)
+	service, err := i.K8sCli.ApplyService(ctx, k8s.ServiceConfig{Name: serviceName, Labels: labels, Selector: labelSelectors, Ports: ports})
 	if err != nil {
 		return ErrPatchingService.WithParams(serviceName).Wrap(err)
 	}
@@ -126,39 +180,116 @@ func (i *Instance) destroyService(ctx context.Context) error {
 }
 
 // deployPod deploys the pod for the instance
-func (i *Instance) deployPod(ctx context.Context) error {
+// The created resources are registered in the given tracker, so that they can be rolled back on failure.
+func (i *Instance) deployPod(ctx context.Context, tracker *resourceTracker) error {
 	// Get labels for the pod
 	labels := i.getLabels()
 
 	// create a service account for the pod
-	if err := i.K8sCli.CreateServiceAccount(ctx, i.k8sName, labels); err != nil {
-		return ErrFailedToCreateServiceAccount.Wrap(err)
+	if !tracker.run(resourceServiceAccount, i.k8sName, func() (rollbackFunc, error) {
+		if err := i.K8sCli.CreateServiceAccount(ctx, i.k8sName, labels); err != nil {
+			return nil, ErrFailedToCreateServiceAccount.Wrap(err)
+		}
+		return func(ctx context.Context) error {
+			return i.K8sCli.DeleteServiceAccount(ctx, i.k8sName)
+		}, nil
+	}) {
+		return tracker.rollback(ctx)
 	}
 
 	// create a role and role binding for the pod if there are policy rules
 	if len(i.policyRules) > 0 {
-		if err := i.K8sCli.CreateRole(ctx, i.k8sName, labels, i.policyRules); err != nil {
-			return ErrFailedToCreateRole.Wrap(err)
+		if !tracker.run(resourceRole, i.k8sName, func() (rollbackFunc, error) {
+			if err := i.K8sCli.CreateRole(ctx, i.k8sName, labels, i.policyRules); err != nil {
+				return nil, ErrFailedToCreateRole.Wrap(err)
+			}
+			return func(ctx context.Context) error {
+				return i.K8sCli.DeleteRole(ctx, i.k8sName)
+			}, nil
+		}) {
+			return tracker.rollback(ctx)
+		}
+		if !tracker.run(resourceRoleBinding, i.k8sName, func() (rollbackFunc, error) {
+			if err := i.K8sCli.CreateRoleBinding(ctx, i.k8sName, labels, i.k8sName, i.k8sName); err != nil {
+				return nil, ErrFailedToCreateRoleBinding.Wrap(err)
+			}
+			return func(ctx context.Context) error {
+				return i.K8sCli.DeleteRoleBinding(ctx, i.k8sName)
+			}, nil
+		}) {
+			return tracker.rollback(ctx)
+		}
+	}
+
+	// grant the kubeletstats receiver of the otel collector to read the stats of the nodes
+	if i.obsyConfig.kubeletStatsInterval != 0 {
+		if !tracker.run(resourceClusterRole, i.kubeletStatsRoleName(), func() (rollbackFunc, error) {
+			if err := i.K8sCli.CreateClusterRole(ctx, i.kubeletStatsRoleName(), labels, kubeletStatsPolicyRules); err != nil {
+				return nil, ErrFailedToCreateClusterRole.Wrap(err)
+			}
+			return func(ctx context.Context) error {
+				return i.K8sCli.DeleteClusterRole(ctx, i.kubeletStatsRoleName())
+			}, nil
+		}) {
+			return tracker.rollback(ctx)
 		}
-		if err := i.K8sCli.CreateRoleBinding(ctx, i.k8sName, labels, i.k8sName, i.k8sName); err != nil {
-			return ErrFailedToCreateRoleBinding.Wrap(err)
+		if !tracker.run(resourceClusterRoleBinding, i.kubeletStatsRoleName(), func() (rollbackFunc, error) {
+			if err := i.K8sCli.CreateClusterRoleBinding(ctx, i.kubeletStatsRoleName(), labels, i.kubeletStatsRoleName(), i.k8sName); err != nil {
+				return nil, ErrFailedToCreateClusterRoleBinding.Wrap(err)
+			}
+			return func(ctx context.Context) error {
+				return i.K8sCli.DeleteClusterRoleBinding(ctx, i.kubeletStatsRoleName())
+			}, nil
+		}) {
+			return tracker.rollback(ctx)
+		}
+	}
+
+	// grant the use of the SecurityContextConstraints on OpenShift, the restricted ones reject most images
+	if i.SecurityContextConstraints != "" {
+		if !tracker.run(resourceRoleBinding, i.sccRoleBindingName(), func() (rollbackFunc, error) {
+			clusterRole := k8s.SCCClusterRole(i.SecurityContextConstraints)
+			if err := i.K8sCli.CreateRoleBindingToClusterRole(ctx, i.sccRoleBindingName(), labels, clusterRole, i.k8sName); err != nil {
+				return nil, ErrFailedToCreateRoleBinding.Wrap(err)
+			}
+			return func(ctx context.Context) error {
+				return i.K8sCli.DeleteRoleBinding(ctx, i.sccRoleBindingName())
+			}, nil
+		}) {
+			return tracker.rollback(ctx)
+		}
+	}
+
+	if !i.usesReplicaSet() {
+		if !tracker.run(resourcePod, i.k8sName, func() (rollbackFunc, error) {
+			if _, err := i.K8sCli.DeployPod(ctx, i.preparePodConfig(), true); err != nil {
+				return nil, ErrFailedToDeployPod.Wrap(err)
+			}
+			return nil, nil
+		}) {
+			return tracker.rollback(ctx)
 		}
+		logrus.Debugf("Started pod '%s'", i.k8sName)
+		return nil
 	}
 
 	replicaSetSetConfig := i.prepareReplicaSetConfig()
 
 	// Deploy the statefulSet
-	replicaSet, err := i.K8sCli.CreateReplicaSet(ctx, replicaSetSetConfig, true)
-	if err != nil {
-		return ErrFailedToDeployPod.Wrap(err)
+	if !tracker.run(resourceReplicaSet, i.k8sName, func() (rollbackFunc, error) {
+		replicaSet, err := i.K8sCli.CreateReplicaSet(ctx, replicaSetSetConfig, true)
+		if err != nil {
+			return nil, ErrFailedToDeployPod.Wrap(err)
+		}
+		i.kubernetesReplicaSet = replicaSet
+		return nil, nil
+	}) {
+		return tracker.rollback(ctx)
 	}
 
-	// Set the state of the instance to started
-	i.kubernetesReplicaSet = replicaSet
-
 	// Log the deployment of the pod
 	logrus.Debugf("Started statefulSet '%s'", i.k8sName)
-	logrus.Debugf("Set state of instance '%s' to '%s'", i.k8sName, i.state.String())
+	logrus.Debugf("Set state of instance '%s' to '%s'", i.k8sName, i.getState().String())
 
 	return nil
 }
@@ -166,9 +297,13 @@ func (i *Instance) deployPod(ctx context.Context) error {
 // destroyPod destroys the pod for the instance (no grace period)
 // Skips if the pod is already destroyed
 func (i *Instance) destroyPod(ctx context.Context) error {
+	i.stopReadinessGates()
 	grace := int64(0)
-	err := i.K8sCli.DeleteReplicaSetWithGracePeriod(ctx, i.k8sName, &grace)
-	if err != nil {
+	deleteWorkload := i.K8sCli.DeleteReplicaSetWithGracePeriod
+	if !i.usesReplicaSet() {
+		deleteWorkload = i.K8sCli.DeletePodWithGracePeriod
+	}
+	if err := deleteWorkload(ctx, i.k8sName, &grace); err != nil {
 		return ErrFailedToDeletePod.Wrap(err)
 	}
 
@@ -185,28 +320,54 @@ func (i *Instance) destroyPod(ctx context.Context) error {
 			return ErrFailedToDeleteRoleBinding.Wrap(err)
 		}
 	}
+	if i.SecurityContextConstraints != "" {
+		if err := i.K8sCli.DeleteRoleBinding(ctx, i.sccRoleBindingName()); err != nil {
+			return ErrFailedToDeleteRoleBinding.Wrap(err)
+		}
+	}
+	if i.obsyConfig.kubeletStatsInterval != 0 {
+		if err := i.K8sCli.DeleteClusterRoleBinding(ctx, i.kubeletStatsRoleName()); err != nil {
+			return ErrFailedToDeleteClusterRoleBinding.Wrap(err)
+		}
+		if err := i.K8sCli.DeleteClusterRole(ctx, i.kubeletStatsRoleName()); err != nil {
+			return ErrFailedToDeleteClusterRole.Wrap(err)
+		}
+	}
 
 	return nil
 }
 
-// deployService deploys the service for the instance
-func (i *Instance) deployOrPatchService(ctx context.Context, portsTCP, portsUDP []int) error {
-	if len(portsTCP) != 0 || len(portsUDP) != 0 {
-		logrus.Debugf("Ports not empty, deploying service for instance '%s'", i.k8sName)
-		svc, _ := i.K8sCli.GetService(ctx, i.k8sName)
-		if svc == nil {
-			err := i.deployService(ctx, portsTCP, portsUDP)
-			if err != nil {
-				return ErrDeployingServiceForInstance.WithParams(i.k8sName).Wrap(err)
-			}
-		} else if svc != nil {
-			err := i.patchService(ctx, portsTCP, portsUDP)
-			if err != nil {
-				return ErrPatchingServiceForInstance.WithParams(i.k8sName).Wrap(err)
-			}
+// kubeletStatsRoleName returns the name of the cluster role and its binding granting the kubeletstats receiver
+// of the otel collector of the instance to read the stats of the nodes
+func (i *Instance) kubeletStatsRoleName() string {
+	return i.k8sName + "-kubeletstats"
+}
+
+// sccRoleBindingName returns the name of the role binding granting the SecurityContextConstraints to the instance
+func (i *Instance) sccRoleBindingName() string {
+	return i.k8sName + "-scc"
+}
+
+// deployOrPatchService deploys the service for the instance or patches it if it already exists
+// It returns true if a new service has been created.
+func (i *Instance) deployOrPatchService(ctx context.Context, ports []k8s.ServicePort) (bool, error) {
+	if len(ports) == 0 {
+		return false, nil
+	}
+
+	logrus.Debugf("Ports not empty, deploying service for instance '%s'", i.k8sName)
+	svc, _ := i.K8sCli.GetService(ctx, i.k8sName)
+	if svc == nil {
+		if err := i.deployService(ctx, ports); err != nil {
+			return false, ErrDeployingServiceForInstance.WithParams(i.k8sName).Wrap(err)
 		}
+		return true, nil
 	}
-	return nil
+
+	if err := i.patchService(ctx, ports); err != nil {
+		return false, ErrPatchingServiceForInstance.WithParams(i.k8sName).Wrap(err)
+	}
+	return false, nil
 }
 
 // deployVolume deploys the volume for the instance
@@ -215,7 +376,9 @@ func (i *Instance) deployVolume(ctx context.Context) error {
 	for _, volume := range i.volumes {
 		size.Add(resource.MustParse(volume.Size))
 	}
-	i.K8sCli.CreatePersistentVolumeClaim(ctx, i.k8sName, i.getLabels(), size)
+	if err := i.K8sCli.CreatePersistentVolumeClaim(ctx, i.k8sName, i.getLabels(), size); err != nil {
+		return err
+	}
 	logrus.Debugf("Deployed persistent volume '%s'", i.k8sName)
 
 	return nil
@@ -277,33 +440,85 @@ func (i *Instance) destroyFiles(ctx context.Context) error {
 }
 
 // deployResources deploys the resources for the instance
-func (i *Instance) deployResources(ctx context.Context) error {
+// All resources are attempted, even if the creation of one of them fails, and registered
+// in the given tracker. The caller is responsible for rolling back on failure.
+func (i *Instance) deployResources(ctx context.Context, tracker *resourceTracker) {
 	// only a non-sidecar instance should deploy a service, all sidecars will use the parent instance's service
 	if !i.isSidecar {
-		portsTCP := i.portsTCP
-		portsUDP := i.portsUDP
+		ports := i.servicePorts()
 		for _, sidecar := range i.sidecars {
-			portsTCP = append(portsTCP, sidecar.portsTCP...)
-			portsUDP = append(portsUDP, sidecar.portsUDP...)
+			ports = append(ports, sidecar.servicePorts()...)
 		}
-		if len(portsTCP) != 0 || len(portsUDP) != 0 {
-			if err := i.deployOrPatchService(ctx, portsTCP, portsUDP); err != nil {
-				return ErrFailedToDeployOrPatchService.Wrap(err)
-			}
+		if len(ports) != 0 {
+			tracker.run(resourceService, i.k8sName, func() (rollbackFunc, error) {
+				created, err := i.deployOrPatchService(ctx, ports)
+				if err != nil {
+					return nil, ErrFailedToDeployOrPatchService.Wrap(err)
+				}
+				if !created {
+					return nil, nil
+				}
+				return i.destroyService, nil
+			})
 		}
+		i.deployServices(ctx, tracker)
 	}
 	if len(i.volumes) != 0 {
-		if err := i.deployVolume(ctx); err != nil {
-			return ErrDeployingVolumeForInstance.WithParams(i.k8sName).Wrap(err)
-		}
+		tracker.run(resourceVolume, i.k8sName, func() (rollbackFunc, error) {
+			if err := i.deployVolume(ctx); err != nil {
+				return nil, ErrDeployingVolumeForInstance.WithParams(i.k8sName).Wrap(err)
+			}
+			return i.destroyVolume, nil
+		})
 	}
 	if len(i.files) != 0 {
-		if err := i.deployFiles(ctx); err != nil {
-			return ErrDeployingFilesForInstance.WithParams(i.k8sName).Wrap(err)
-		}
+		tracker.run(resourceConfigMap, i.k8sName, func() (rollbackFunc, error) {
+			if err := i.deployFiles(ctx); err != nil {
+				return nil, ErrDeployingFilesForInstance.WithParams(i.k8sName).Wrap(err)
+			}
+			return i.destroyFiles, nil
+		})
+	}
+	if i.isTLSEnabled() {
+		tracker.run(resourceSecret, i.tlsSecretName(), func() (rollbackFunc, error) {
+			if err := i.deployTLSSecret(ctx); err != nil {
+				return nil, err
+			}
+			return i.destroyTLSSecret, nil
+		})
+	}
+	for n := range i.secretDirs {
+		tracker.run(resourceSecret, i.secretFilesName(n), func() (rollbackFunc, error) {
+			if err := i.deploySecretFiles(ctx, n); err != nil {
+				return nil, err
+			}
+			return func(ctx context.Context) error { return i.destroySecretFiles(ctx, n) }, nil
+		})
+	}
+	if i.apiFaultsEnabled {
+		tracker.run(resourceSecret, i.kubeconfigSecretName(), func() (rollbackFunc, error) {
+			if err := i.deployKubeconfigSecret(ctx); err != nil {
+				return nil, err
+			}
+			return i.destroyKubeconfigSecret, nil
+		})
+	}
+	if i.vpaMode != "" {
+		tracker.run(resourceVerticalPodAutoscaler, i.k8sName, func() (rollbackFunc, error) {
+			if err := i.deployVPA(ctx); err != nil {
+				return nil, err
+			}
+			return i.destroyVPA, nil
+		})
+	}
+	if i.hpa != nil {
+		tracker.run(resourceHorizontalPodAutoscaler, i.k8sName, func() (rollbackFunc, error) {
+			if err := i.deployHPA(ctx); err != nil {
+				return nil, err
+			}
+			return i.destroyHPA, nil
+		})
 	}
-
-	return nil
 }
 
 // destroyResources destroys the resources for the instance
@@ -320,12 +535,46 @@ func (i *Instance) destroyResources(ctx context.Context) error {
 			return ErrDestroyingFilesForInstance.WithParams(i.k8sName).Wrap(err)
 		}
 	}
+	if i.isTLSEnabled() {
+		if err := i.destroyTLSSecret(ctx); err != nil {
+			return err
+		}
+	}
+	for n := range i.secretDirs {
+		if err := i.destroySecretFiles(ctx, n); err != nil {
+			return err
+		}
+	}
+	if i.apiFaultsEnabled {
+		if err := i.destroyKubeconfigSecret(ctx); err != nil {
+			return err
+		}
+	}
+	if i.vpaMode != "" {
+		if err := i.destroyVPA(ctx); err != nil {
+			return err
+		}
+	}
+	if i.hpa != nil {
+		if err := i.destroyHPA(ctx); err != nil {
+			return err
+		}
+	}
 	if i.kubernetesService != nil {
 		err := i.destroyService(ctx)
 		if err != nil {
 			return ErrDestroyingServiceForInstance.WithParams(i.k8sName).Wrap(err)
 		}
 	}
+	if err := i.destroyServices(ctx); err != nil {
+		return ErrDestroyingServiceForInstance.WithParams(i.k8sName).Wrap(err)
+	}
+	if i.tcpHosts && i.Proxy != nil {
+		if err := i.Proxy.RemoveTCPHosts(ctx, i.k8sName); err != nil {
+			return ErrRemovingTCPHostsFromProxy.WithParams(i.k8sName).Wrap(err)
+		}
+		i.tcpHosts = false
+	}
 
 	// disable network only for non-sidecar instances
 	if !i.isSidecar {
@@ -342,6 +591,9 @@ func (i *Instance) destroyResources(ctx context.Context) error {
 				return ErrEnablingNetworkForInstance.WithParams(i.k8sName).Wrap(err)
 			}
 		}
+		if err := i.destroyEgressFirewall(ctx); err != nil {
+			return ErrDestroyingEgressFirewall.WithParams(i.k8sName).Wrap(err)
+		}
 	}
 
 	return nil
@@ -364,20 +616,42 @@ func (i *Instance) cloneWithSuffix(suffix string) *Instance {
 		name:                 i.name + suffix,
 		k8sName:              i.k8sName + suffix,
 		imageName:            i.imageName,
-		state:                i.state,
+		state:                i.getState(),
 		instanceType:         i.instanceType,
 		kubernetesService:    i.kubernetesService,
 		builderFactory:       i.builderFactory,
 		kubernetesReplicaSet: i.kubernetesReplicaSet,
+		restartPolicy:        i.restartPolicy,
+		dnsPolicy:            i.dnsPolicy,
+		dnsConfig:            i.dnsConfig.DeepCopy(),
+		hostNetwork:          i.hostNetwork,
+		hostPorts:            i.hostPorts,
+		ports:                i.ports,
+		services:             i.services,
 		portsTCP:             i.portsTCP,
 		portsUDP:             i.portsUDP,
 		command:              i.command,
 		args:                 i.args,
+		workingDir:           i.workingDir,
+		tty:                  i.tty,
+		stdin:                i.stdin,
+		shareProcesses:       i.shareProcesses,
+		keepImageCmd:         i.keepImageCmd,
+		imageCmd:             i.imageCmd,
+		cmdImage:             i.cmdImage,
 		env:                  i.env,
+		fieldEnv:             i.fieldEnv,
 		volumes:              i.volumes,
+		remoteFiles:          i.remoteFiles,
+		sharedDirs:           i.sharedDirs,
+		shell:                i.shell,
+		noShell:              i.noShell,
+		group:                i.group,
+		zoneLabel:            i.zoneLabel,
 		memoryRequest:        i.memoryRequest,
 		memoryLimit:          i.memoryLimit,
 		cpuRequest:           i.cpuRequest,
+		cpuLimit:             i.cpuLimit,
 		policyRules:          i.policyRules,
 		livenessProbe:        i.livenessProbe,
 		readinessProbe:       i.readinessProbe,
@@ -389,6 +663,16 @@ func (i *Instance) cloneWithSuffix(suffix string) *Instance {
 		securityContext:      &clonedSecurityContext,
 		BitTwister:           &clonedBitTwister,
 		SystemDependencies:   i.SystemDependencies,
+		hooks:                cloneLifecycleHooks(i.hooks),
+		stateHandlers:        i.cloneStateHandlers(),
+		tlsHosts:             i.tlsHosts,
+		secretDirs:           slices.Clone(i.secretDirs),
+		apiFaultsEnabled:     i.apiFaultsEnabled,
+		readinessGates:       append([]readinessGate(nil), i.readinessGates...),
+		vpaMode:              i.vpaMode,
+		hpa:                  i.hpa,
+		preemptible:          i.preemptible,
+		tolerations:          append([]v1.Toleration(nil), i.tolerations...),
 	}
 }
 
@@ -437,8 +721,11 @@ func (i *Instance) validateFileArgs(src, dest, chown string) error {
 // addFileToBuilder adds a file to the builder
 func (i *Instance) addFileToBuilder(src, dest, chown string) error {
 	// dest is the same as src here, as we copy the file to the build dir with the subfolder structure of dest
-	err := i.builderFactory.AddToBuilder(dest, dest, chown)
+	builder, err := i.builder()
 	if err != nil {
+		return err
+	}
+	if err := builder.AddToBuilder(dest, dest, chown); err != nil {
 		return ErrAddingFileToInstance.WithParams(dest, i.name).Wrap(err)
 	}
 	return nil
@@ -466,25 +753,95 @@ func prepareSecurityContext(config *SecurityContext) *v1.SecurityContext {
 	return securityContext
 }
 
-// prepareConfig prepares the config for the instance
+// usesReplicaSet returns true if the instance is deployed as a ReplicaSet, i.e. its containers are always restarted
+func (i *Instance) usesReplicaSet() bool {
+	return i.restartPolicy == "" || i.restartPolicy == v1.RestartPolicyAlways
+}
+
+// getPod returns the pod of the instance, sidecars share the pod of their parent
+func (i *Instance) getPod(ctx context.Context) (*v1.Pod, error) {
+	if i.attached != nil {
+		return i.attached.pod(ctx, i.K8sCli)
+	}
+	owner := i
+	if i.isSidecar {
+		owner = i.parentInstance
+	}
+	if owner.usesReplicaSet() {
+		return i.K8sCli.GetFirstPodFromReplicaSet(ctx, owner.k8sName)
+	}
+	return i.K8sCli.GetPod(ctx, owner.k8sName)
+}
+
+// containerArgs returns the arguments of the container, the command of the image if only the entrypoint is set
+func (i *Instance) containerArgs() []string {
+	if len(i.args) == 0 && i.keepImageCmd {
+		return i.imageCmd
+	}
+	return i.args
+}
+
+// resolveImageCmd reads the command of the images of the instance and its sidecars whose entrypoint is replaced,
+// the caller must hold the locks of the instance and its sidecars
+func (i *Instance) resolveImageCmd(ctx context.Context) error {
+	for _, inst := range append([]*Instance{i}, i.sidecars...) {
+		if !inst.keepImageCmd || len(inst.args) != 0 || inst.imageCmd != nil {
+			continue
+		}
+		image := inst.cmdImage
+		if image == "" {
+			image = inst.currentImage()
+		}
+		config, err := inst.imageConfig(ctx, image)
+		if err != nil {
+			return ErrResolvingImageCommand.WithParams(inst.k8sName).Wrap(err)
+		}
+		inst.imageCmd = append([]string{}, config.Cmd...)
+	}
+	return nil
+}
+
+// prepareReplicaSetConfig prepares the ReplicaSet config for the instance
 func (i *Instance) prepareReplicaSetConfig() k8s.ReplicaSetConfig {
+	return k8s.ReplicaSetConfig{
+		Namespace: i.K8sCli.Namespace(),
+		Name:      i.k8sName,
+		Labels:    i.getLabels(),
+		Replicas:  1,
+		PodConfig: i.preparePodConfig(),
+		// the replicas are scaled by the HorizontalPodAutoscaler of the instance
+		Autoscaled: i.hpa != nil,
+	}
+}
+
+// preparePodConfig prepares the pod config for the instance
+func (i *Instance) preparePodConfig() k8s.PodConfig {
 
 	// Generate the container configuration
 	containerConfig := k8s.ContainerConfig{
 		Name:            i.k8sName,
 		Image:           i.imageName,
 		Command:         i.command,
-		Args:            i.args,
+		Args:            i.containerArgs(),
+		WorkingDir:      i.workingDir,
+		TTY:             i.tty,
+		Stdin:           i.stdin,
 		Env:             i.env,
+		FieldEnv:        i.fieldEnv,
 		Volumes:         i.volumes,
 		MemoryRequest:   i.memoryRequest,
 		MemoryLimit:     i.memoryLimit,
 		CPURequest:      i.cpuRequest,
+		CPULimit:        i.cpuLimit,
 		LivenessProbe:   i.livenessProbe,
 		ReadinessProbe:  i.readinessProbe,
 		StartupProbe:    i.startupProbe,
 		Files:           i.files,
 		SecurityContext: prepareSecurityContext(i.securityContext),
+		Ports:           i.containerPorts(i.hostNetwork),
+		RemoteFiles:     i.remoteFiles,
+		SharedDirs:      i.sharedDirs,
+		Secrets:         slices.Concat(i.tlsSecretMounts(), i.secretFilesMounts(), i.kubeconfigSecretMounts()),
 	}
 	// Generate the sidecar configurations
 	sidecarConfigs := make([]k8s.ContainerConfig, 0)
@@ -493,17 +850,25 @@ func (i *Instance) prepareReplicaSetConfig() k8s.ReplicaSetConfig {
 			Name:            sidecar.k8sName,
 			Image:           sidecar.imageName,
 			Command:         sidecar.command,
-			Args:            sidecar.args,
+			Args:            sidecar.containerArgs(),
+			WorkingDir:      sidecar.workingDir,
+			TTY:             sidecar.tty,
+			Stdin:           sidecar.stdin,
 			Env:             sidecar.env,
+			FieldEnv:        sidecar.fieldEnv,
 			Volumes:         sidecar.volumes,
 			MemoryRequest:   sidecar.memoryRequest,
 			MemoryLimit:     sidecar.memoryLimit,
 			CPURequest:      sidecar.cpuRequest,
+			CPULimit:        sidecar.cpuLimit,
 			LivenessProbe:   sidecar.livenessProbe,
 			ReadinessProbe:  sidecar.readinessProbe,
 			StartupProbe:    sidecar.startupProbe,
 			Files:           sidecar.files,
 			SecurityContext: prepareSecurityContext(sidecar.securityContext),
+			Ports:           sidecar.containerPorts(i.hostNetwork),
+			SharedDirs:      sidecar.sharedDirs,
+			Secrets:         append(sidecar.tlsSecretMounts(), sidecar.secretFilesMounts()...),
 		})
 	}
 	// Generate the pod configuration
@@ -515,27 +880,40 @@ func (i *Instance) prepareReplicaSetConfig() k8s.ReplicaSetConfig {
 		FsGroup:            i.fsGroup,
 		ContainerConfig:    containerConfig,
 		SidecarConfigs:     sidecarConfigs,
-	}
-	// Generate the ReplicaSet configuration
-	statefulSetConfig := k8s.ReplicaSetConfig{
-		Namespace: i.K8sCli.Namespace(),
-		Name:      i.k8sName,
-		Labels:    i.getLabels(),
-		Replicas:  1,
-		PodConfig: podConfig,
+		RestartPolicy:      i.restartPolicy,
+		DNSPolicy:          i.podDNSPolicy(),
+		DNSConfig:          i.dnsConfig,
+		HostNetwork:        i.hostNetwork,
+		ShareProcesses:     i.shareProcesses,
+		Annotations:        i.serviceMeshAnnotations(),
+		ReadinessGates:     i.readinessGateTypes(),
+		Tolerations:        i.tolerations,
+
+		TopologySpreadConstraints: i.topologySpreadConstraints(),
 	}
 
-	return statefulSetConfig
+	return podConfig
 }
 
 // setImageWithGracePeriod sets the image of the instance with a grace period
 func (i *Instance) setImageWithGracePeriod(ctx context.Context, imageName string, gracePeriod *int64) error {
+	if err := i.verifyImage(ctx, imageName); err != nil {
+		return err
+	}
 	i.imageName = imageName
-
-	replicaSetConfig := i.prepareReplicaSetConfig()
+	i.imageCmd = nil
+	i.cmdImage = ""
+	if err := i.resolveImageCmd(ctx); err != nil {
+		return err
+	}
 
 	// Replace the pod with a new one, using the given image
-	_, err := i.K8sCli.ReplaceReplicaSetWithGracePeriod(ctx, replicaSetConfig, gracePeriod)
+	var err error
+	if i.usesReplicaSet() {
+		_, err = i.K8sCli.ReplaceReplicaSetWithGracePeriod(ctx, i.prepareReplicaSetConfig(), gracePeriod)
+	} else {
+		_, err = i.K8sCli.ReplacePodWithGracePeriod(ctx, i.preparePodConfig(), gracePeriod)
+	}
 	if err != nil {
 		return ErrReplacingPod.Wrap(err)
 	}
@@ -547,23 +925,39 @@ func (i *Instance) setImageWithGracePeriod(ctx context.Context, imageName string
 }
 
 // applyFunctionToInstances applies a function to all instances
-func applyFunctionToInstances(instances []*Instance, function func(sidecar Instance) error) error {
+func applyFunctionToInstances(instances []*Instance, function func(sidecar *Instance) error) error {
 	for _, i := range instances {
-		if err := function(*i); err != nil {
+		if err := function(i); err != nil {
 			return ErrApplyingFunctionToInstance.WithParams(i.k8sName).Wrap(err)
 		}
 	}
 	return nil
 }
 
+// setStateForSidecars moves the sidecars to the state of their parent instance
+// The sidecars already in the state are left as is, the ones that cannot move to it are only logged, as the
+// parent instance has already moved.
 func setStateForSidecars(sidecars []*Instance, state InstanceState) {
-	// We don't handle errors here, as the function can't return an error
-	err := applyFunctionToInstances(sidecars, func(sidecar Instance) error {
-		sidecar.state = state
-		return nil
-	})
-	if err != nil {
-		return
+	for _, sidecar := range sidecars {
+		if sidecar.IsInState(state) {
+			continue
+		}
+		if err := sidecar.transition(state); err != nil {
+			logrus.Warnf("Error setting state of sidecar '%s': %v", sidecar.k8sName, err)
+		}
+	}
+}
+
+// lockInstances locks all the given instances and returns a function that unlocks them
+// Instances are always locked after their parent, e.g. sidecars after the instance they belong to
+func lockInstances(instances []*Instance) func() {
+	for _, i := range instances {
+		i.mu.Lock()
+	}
+	return func() {
+		for _, i := range instances {
+			i.mu.Unlock()
+		}
 	}
 }
 
@@ -573,12 +967,15 @@ func (i *Instance) isObservabilityEnabled() bool {
 		i.obsyConfig.prometheusEndpointPort != 0 ||
 		i.obsyConfig.jaegerGrpcPort != 0 ||
 		i.obsyConfig.jaegerThriftCompactPort != 0 ||
-		i.obsyConfig.jaegerThriftHttpPort != 0
+		i.obsyConfig.jaegerThriftHttpPort != 0 ||
+		i.obsyConfig.hostMetricsInterval != 0 ||
+		i.obsyConfig.kubeletStatsInterval != 0 ||
+		len(i.obsyConfig.logsPaths) != 0
 }
 
 func (i *Instance) validateStateForObsy(endpoint string) error {
-	if !i.IsInState(Preparing, Committed) {
-		return ErrSettingNotAllowed.WithParams(endpoint, i.state.String())
+	if !i.allows(ActionConfigure) {
+		return ErrSettingNotAllowed.WithParams(endpoint, i.getState().String())
 	}
 	return nil
 }
@@ -588,9 +985,12 @@ func (i *Instance) addOtelCollectorSidecar(ctx context.Context) error {
 	if err != nil {
 		return ErrCreatingOtelCollectorInstance.WithParams(i.k8sName).Wrap(err)
 	}
-	if err := i.AddSidecar(otelSidecar); err != nil {
+	if err := i.addSidecar(otelSidecar); err != nil {
 		return ErrAddingOtelCollectorSidecar.WithParams(i.k8sName).Wrap(err)
 	}
+	for _, source := range i.otelSources() {
+		source.sharedDirs = append(source.sharedDirs, source.logsSharedDirs()...)
+	}
 	return nil
 }
 
@@ -608,6 +1008,9 @@ func (i *Instance) createBitTwisterInstance(ctx context.Context) (*Instance, err
 	if err := bt.AddPortTCP(i.BitTwister.Port()); err != nil {
 		return nil, ErrAddingBitTwisterPort.Wrap(err)
 	}
+	if err := bt.setResources(i.BitTwister.request, i.BitTwister.limit); err != nil {
+		return nil, ErrSettingBitTwisterResources.Wrap(err)
+	}
 	serviceName := i.k8sName // the main instance name
 	btURL, err := i.AddHost(ctx, i.BitTwister.Port())
 	if err != nil {
@@ -628,13 +1031,19 @@ func (i *Instance) createBitTwisterInstance(ctx context.Context) (*Instance, err
 	return bt, nil
 }
 
+// addBitTwisterSidecar adds the BitTwister sidecar to the instance
+// The pod security is checked again, BitTwister.SetPrivileged can be called after EnableBitTwister.
 func (i *Instance) addBitTwisterSidecar(ctx context.Context) error {
+	if err := i.BitTwister.checkPodSecurity(i.PodSecurity); err != nil {
+		return err
+	}
+
 	networkConfigSidecar, err := i.createBitTwisterInstance(ctx)
 	if err != nil {
 		return ErrCreatingBitTwisterInstance.WithParams(i.k8sName).Wrap(err)
 	}
 
-	if err := networkConfigSidecar.SetPrivileged(true); err != nil {
+	if err := networkConfigSidecar.SetPrivileged(i.BitTwister.Privileged()); err != nil {
 		return ErrSettingBitTwisterPrivileged.WithParams(i.k8sName).Wrap(err)
 	}
 
@@ -642,7 +1051,7 @@ func (i *Instance) addBitTwisterSidecar(ctx context.Context) error {
 		return ErrAddingBitTwisterCapability.WithParams(i.k8sName).Wrap(err)
 	}
 
-	if err := i.AddSidecar(networkConfigSidecar); err != nil {
+	if err := i.addSidecar(networkConfigSidecar); err != nil {
 		return ErrAddingBitTwisterSidecar.WithParams(i.k8sName).Wrap(err)
 	}
 	return nil
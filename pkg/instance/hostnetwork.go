@@ -0,0 +1,109 @@
+package instance
+
+import (
+	"github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+)
+
+// SetHostNetwork makes the pod of the instance use the network namespace of its node
+// All the ports of the instance and its sidecars are then bound on the node, so two instances
+// with the same port are not scheduled on the same node. The DNS policy defaults to ClusterFirstWithHostNet.
+// This function can only be called in the state 'Preparing' or 'Committed'
+func (i *Instance) SetHostNetwork(hostNetwork bool) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if !i.allows(ActionConfigure) {
+		return ErrSettingHostNetworkNotAllowed.WithParams(i.getState().String())
+	}
+	if i.isSidecar {
+		return ErrSettingHostNetworkForSidecar
+	}
+	i.hostNetwork = hostNetwork
+	logrus.Debugf("Set host network to '%t' for instance '%s'", hostNetwork, i.name)
+	return nil
+}
+
+// AddHostPort exposes the given port of the instance on the same port of its node
+// The port must be added with AddPortTCP, AddPortUDP or as target port with AddPort first,
+// it is exposed for each of its protocols.
+// The instance is only scheduled on a node where the port is free.
+// This function can only be called in the state 'Preparing' or 'Committed'
+func (i *Instance) AddHostPort(port int) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if !i.allows(ActionConfigure) {
+		return ErrAddingHostPortNotAllowed.WithParams(i.getState().String())
+	}
+	if i.isSidecar {
+		return ErrAddingHostPortToSidecar
+	}
+	if err := validatePort(port); err != nil {
+		return err
+	}
+	if !i.isContainerPortRegistered(v1.ProtocolTCP, port) && !i.isContainerPortRegistered(v1.ProtocolUDP, port) &&
+		!i.isContainerPortRegistered(v1.ProtocolSCTP, port) {
+		return ErrHostPortNotRegistered.WithParams(port)
+	}
+	for _, p := range i.hostPorts {
+		if p == port {
+			return ErrHostPortAlreadyAdded.WithParams(port)
+		}
+	}
+	i.hostPorts = append(i.hostPorts, port)
+	logrus.Debugf("Added host port '%d' to instance '%s'", port, i.name)
+	return nil
+}
+
+// containerPorts returns the ports declared in the container of the instance
+// These are the named ports, so probes can refer to them, and the ports bound on the node, so the
+// scheduler avoids the nodes where they are in use. With the host network all the ports are bound.
+func (i *Instance) containerPorts(hostNetwork bool) []v1.ContainerPort {
+	bound := func(port int) bool {
+		if hostNetwork {
+			return true
+		}
+		for _, p := range i.hostPorts {
+			if p == port {
+				return true
+			}
+		}
+		return false
+	}
+
+	var ports []v1.ContainerPort
+	add := func(name string, protocol v1.Protocol, port int) {
+		for _, p := range ports {
+			if p.ContainerPort == int32(port) && p.Protocol == protocol {
+				return
+			}
+		}
+		cp := v1.ContainerPort{Name: name, ContainerPort: int32(port), Protocol: protocol}
+		if bound(port) {
+			cp.HostPort = int32(port)
+		} else if name == "" {
+			return
+		}
+		ports = append(ports, cp)
+	}
+	for _, p := range i.ports {
+		add(p.Name, p.Protocol, p.TargetPort)
+	}
+	for _, p := range i.portsTCP {
+		add("", v1.ProtocolTCP, p)
+	}
+	for _, p := range i.portsUDP {
+		add("", v1.ProtocolUDP, p)
+	}
+	return ports
+}
+
+// podDNSPolicy returns the DNS policy of the pod of the instance
+// Pods on the host network use the DNS of the node with ClusterFirst, so ClusterFirstWithHostNet is used by default.
+func (i *Instance) podDNSPolicy() v1.DNSPolicy {
+	if i.hostNetwork && i.dnsPolicy == "" {
+		return v1.DNSClusterFirstWithHostNet
+	}
+	return i.dnsPolicy
+}
@@ -0,0 +1,49 @@
+package instance
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/celestiaorg/knuu/pkg/system"
+)
+
+func TestAddHostPort(t *testing.T) {
+	t.Parallel()
+
+	i, err := New("test", system.SystemDependencies{}, WithImage("alpine"), WithPorts(8080, 9090), WithPortsUDP(9090))
+	require.NoError(t, err)
+
+	assert.ErrorIs(t, i.AddHostPort(7070), ErrHostPortNotRegistered)
+	require.NoError(t, i.AddHostPort(9090))
+	assert.ErrorIs(t, i.AddHostPort(9090), ErrHostPortAlreadyAdded)
+
+	// the port is bound for both of its protocols
+	assert.Equal(t, []v1.ContainerPort{
+		{ContainerPort: 9090, HostPort: 9090, Protocol: v1.ProtocolTCP},
+		{ContainerPort: 9090, HostPort: 9090, Protocol: v1.ProtocolUDP},
+	}, i.containerPorts(false))
+
+	// with the host network all the ports are bound
+	assert.Len(t, i.containerPorts(true), 3)
+}
+
+func TestSetHostNetwork(t *testing.T) {
+	t.Parallel()
+
+	i, err := New("test", system.SystemDependencies{}, WithImage("alpine"))
+	require.NoError(t, err)
+	assert.Equal(t, v1.DNSPolicy(""), i.podDNSPolicy())
+
+	require.NoError(t, i.SetHostNetwork(true))
+	assert.Equal(t, v1.DNSClusterFirstWithHostNet, i.podDNSPolicy())
+
+	// an explicit DNS policy is kept
+	require.NoError(t, i.SetDNSPolicy(v1.DNSDefault))
+	assert.Equal(t, v1.DNSDefault, i.podDNSPolicy())
+
+	i.state = Started
+	assert.ErrorIs(t, i.SetHostNetwork(false), ErrSettingHostNetworkNotAllowed)
+}
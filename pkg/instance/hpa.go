@@ -0,0 +1,185 @@
+package instance
+
+import (
+	"context"
+
+	"github.com/sirupsen/logrus"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"github.com/celestiaorg/knuu/pkg/k8s"
+)
+
+// HPAMetric is the metric on which the HorizontalPodAutoscaler of an instance scales it, see HPACPUUtilization and
+// HPAPodsMetric
+type HPAMetric struct {
+	cpuUtilization int32
+	name           string
+	averageValue   string
+}
+
+// HPACPUUtilization scales an instance to keep the average CPU usage of its pods at the given percentage of their
+// CPU request, which must be set with SetCPU. The usage is served by the metrics server.
+func HPACPUUtilization(percent int32) HPAMetric {
+	return HPAMetric{cpuUtilization: percent}
+}
+
+// HPAPodsMetric scales an instance to keep the average of the given metric of its pods at the given quantity, e.g.
+// HPAPodsMetric("http_requests_per_second", "100"). The metric is served by a custom metrics adapter, e.g.
+// prometheus-adapter exposing the metrics scraped from the instance.
+func HPAPodsMetric(name, averageValue string) HPAMetric {
+	return HPAMetric{name: name, averageValue: averageValue}
+}
+
+// validate returns the reason why the metric is invalid, empty if it is valid
+func (m HPAMetric) validate() string {
+	if m.name == "" {
+		if m.cpuUtilization <= 0 {
+			return "the CPU utilization must be a positive percentage"
+		}
+		return ""
+	}
+	quantity, err := resource.ParseQuantity(m.averageValue)
+	if err != nil {
+		return "the average value of metric '" + m.name + "' is not a quantity"
+	}
+	if quantity.Sign() <= 0 {
+		return "the average value of metric '" + m.name + "' must be positive"
+	}
+	return ""
+}
+
+// capability returns the capability of the cluster serving the metric
+func (m HPAMetric) capability() k8s.Capability {
+	if m.name == "" {
+		return k8s.CapabilityMetrics
+	}
+	return k8s.CapabilityCustomMetrics
+}
+
+// spec returns the specification of the metric, the metric must be valid
+func (m HPAMetric) spec() autoscalingv2.MetricSpec {
+	if m.name == "" {
+		return autoscalingv2.MetricSpec{
+			Type: autoscalingv2.ResourceMetricSourceType,
+			Resource: &autoscalingv2.ResourceMetricSource{
+				Name: v1.ResourceCPU,
+				Target: autoscalingv2.MetricTarget{
+					Type:               autoscalingv2.UtilizationMetricType,
+					AverageUtilization: &m.cpuUtilization,
+				},
+			},
+		}
+	}
+	averageValue := resource.MustParse(m.averageValue)
+	return autoscalingv2.MetricSpec{
+		Type: autoscalingv2.PodsMetricSourceType,
+		Pods: &autoscalingv2.PodsMetricSource{
+			Metric: autoscalingv2.MetricIdentifier{Name: m.name},
+			Target: autoscalingv2.MetricTarget{
+				Type:         autoscalingv2.AverageValueMetricType,
+				AverageValue: &averageValue,
+			},
+		},
+	}
+}
+
+// hpaConfig is the configuration of the HorizontalPodAutoscaler of an instance
+type hpaConfig struct {
+	minReplicas int32
+	maxReplicas int32
+	metric      HPAMetric
+}
+
+// EnableHPA deploys a HorizontalPodAutoscaler for the instance when it is started, which scales its ReplicaSet
+// between the given numbers of replicas on the given metric, so the autoscaling of a service can be validated by
+// loading it and waiting for HPAReplicas to reach the expected number of replicas.
+// The replicas share the configuration, the volumes and the service of the instance, the methods targeting a pod,
+// e.g. ExecuteCommand, target one of them. The cluster must serve the metric, i.e. run the metrics server for the
+// CPU utilization or a custom metrics adapter for the metrics of the pods.
+// The instance must be deployed as a ReplicaSet, i.e. with the restart policy 'Always', and must not have readiness
+// gates, see AddReadinessGate, their checks target a single pod and would never pass for the other replicas.
+// This function can only be called in the states 'Preparing' and 'Committed'
+func (i *Instance) EnableHPA(minReplicas, maxReplicas int32, metric HPAMetric) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if !i.allows(ActionConfigure) {
+		return ErrEnablingHPANotAllowed.WithParams(i.getState().String())
+	}
+	if i.isSidecar {
+		return ErrEnablingHPAOfSidecar.WithParams(i.k8sName)
+	}
+	if len(i.readinessGates) != 0 {
+		return ErrHPAWithReadinessGates.WithParams(i.k8sName)
+	}
+	if minReplicas < 1 || maxReplicas < minReplicas {
+		return ErrInvalidHPAReplicas.WithParams(minReplicas, maxReplicas)
+	}
+	if reason := metric.validate(); reason != "" {
+		return ErrInvalidHPAMetric.WithParams(reason)
+	}
+
+	i.hpa = &hpaConfig{minReplicas: minReplicas, maxReplicas: maxReplicas, metric: metric}
+	logrus.Debugf("Enabled HorizontalPodAutoscaler with %d to %d replicas for instance '%s'", minReplicas, maxReplicas, i.k8sName)
+	return nil
+}
+
+// HPAReplicas returns the current number of replicas of the instance and the number of replicas desired by its
+// HorizontalPodAutoscaler
+// This function can only be called in the state 'Started'
+func (i *Instance) HPAReplicas(ctx context.Context) (current, desired int32, err error) {
+	if !i.allows(ActionOperate) {
+		return 0, 0, ErrGettingHPAReplicasNotAllowed.WithParams(i.getState().String())
+	}
+	if i.hpa == nil {
+		return 0, 0, ErrHPANotEnabled.WithParams(i.k8sName)
+	}
+
+	hpa, err := i.K8sCli.GetHorizontalPodAutoscaler(ctx, i.k8sName)
+	if err != nil {
+		return 0, 0, ErrGettingHPAReplicas.WithParams(i.k8sName).Wrap(err)
+	}
+	return hpa.Status.CurrentReplicas, hpa.Status.DesiredReplicas, nil
+}
+
+// deployHPA deploys the HorizontalPodAutoscaler of the instance, targeting its ReplicaSet
+func (i *Instance) deployHPA(ctx context.Context) error {
+	if !i.usesReplicaSet() {
+		return ErrHPARequiresReplicaSet.WithParams(i.k8sName, i.restartPolicy)
+	}
+	if i.hpa.metric.name == "" && i.cpuRequest == "" {
+		return ErrHPARequiresCPURequest.WithParams(i.k8sName)
+	}
+	if err := i.K8sCli.RequireCapability(ctx, i.hpa.metric.capability()); err != nil {
+		return ErrDeployingHPA.WithParams(i.k8sName).Wrap(err)
+	}
+
+	spec := autoscalingv2.HorizontalPodAutoscalerSpec{
+		ScaleTargetRef: autoscalingv2.CrossVersionObjectReference{
+			APIVersion: "apps/v1",
+			Kind:       "ReplicaSet",
+			Name:       i.k8sName,
+		},
+		MinReplicas: &i.hpa.minReplicas,
+		MaxReplicas: i.hpa.maxReplicas,
+		Metrics:     []autoscalingv2.MetricSpec{i.hpa.metric.spec()},
+	}
+	if _, err := i.K8sCli.CreateHorizontalPodAutoscaler(ctx, i.k8sName, i.getLabels(), spec); err != nil {
+		return ErrDeployingHPA.WithParams(i.k8sName).Wrap(err)
+	}
+
+	logrus.Debugf("Deployed HorizontalPodAutoscaler '%s'", i.k8sName)
+	return nil
+}
+
+// destroyHPA destroys the HorizontalPodAutoscaler of the instance
+func (i *Instance) destroyHPA(ctx context.Context) error {
+	if err := i.K8sCli.DeleteHorizontalPodAutoscaler(ctx, i.k8sName); err != nil {
+		return ErrDestroyingHPA.WithParams(i.k8sName).Wrap(err)
+	}
+
+	logrus.Debugf("Destroyed HorizontalPodAutoscaler '%s'", i.k8sName)
+	return nil
+}
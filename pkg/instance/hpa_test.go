@@ -0,0 +1,141 @@
+package instance
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/celestiaorg/knuu/pkg/k8s/fake"
+	"github.com/celestiaorg/knuu/pkg/system"
+)
+
+func TestEnableHPA(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	k8sCli, err := fake.New(ctx, "test")
+	require.NoError(t, err)
+	i, err := New("node", system.SystemDependencies{K8sCli: k8sCli}, WithImage("alpine"))
+	require.NoError(t, err)
+
+	assert.ErrorIs(t, i.EnableHPA(0, 3, HPACPUUtilization(80)), ErrInvalidHPAReplicas)
+	assert.ErrorIs(t, i.EnableHPA(3, 2, HPACPUUtilization(80)), ErrInvalidHPAReplicas)
+	assert.ErrorIs(t, i.EnableHPA(1, 3, HPACPUUtilization(0)), ErrInvalidHPAMetric)
+	assert.ErrorIs(t, i.EnableHPA(1, 3, HPAPodsMetric("requests", "many")), ErrInvalidHPAMetric)
+	assert.ErrorIs(t, i.EnableHPA(1, 3, HPAPodsMetric("requests", "-1")), ErrInvalidHPAMetric)
+	assert.False(t, i.prepareReplicaSetConfig().Autoscaled)
+
+	require.NoError(t, i.EnableHPA(1, 3, HPACPUUtilization(80)))
+	assert.True(t, i.prepareReplicaSetConfig().Autoscaled)
+
+	sidecar, err := New("sidecar", system.SystemDependencies{K8sCli: k8sCli}, WithImage("alpine"))
+	require.NoError(t, err)
+	sidecar.isSidecar = true
+	assert.ErrorIs(t, sidecar.EnableHPA(1, 3, HPACPUUtilization(80)), ErrEnablingHPAOfSidecar)
+
+	// the checks of the readiness gates only target one of the replicas
+	ready := func(context.Context, *Instance) (bool, error) { return true, nil }
+	assert.ErrorIs(t, i.AddReadinessGate("knuu.sh/synced", ready), ErrHPAWithReadinessGates)
+	gated, err := New("gated", system.SystemDependencies{K8sCli: k8sCli}, WithImage("alpine"))
+	require.NoError(t, err)
+	require.NoError(t, gated.AddReadinessGate("knuu.sh/synced", ready))
+	assert.ErrorIs(t, gated.EnableHPA(1, 3, HPACPUUtilization(80)), ErrHPAWithReadinessGates)
+
+	i.setState(Started)
+	assert.ErrorIs(t, i.EnableHPA(1, 3, HPACPUUtilization(80)), ErrEnablingHPANotAllowed)
+}
+
+func TestDeployHPA(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	k8sCli, err := fake.New(ctx, "test")
+	require.NoError(t, err)
+	k8sCli.AddAPIResources("metrics.k8s.io/v1beta1", "pods")
+	i, err := New("node", system.SystemDependencies{K8sCli: k8sCli}, WithImage("alpine"))
+	require.NoError(t, err)
+	require.NoError(t, i.EnableHPA(2, 5, HPACPUUtilization(80)))
+
+	assert.ErrorIs(t, i.deployHPA(ctx), ErrHPARequiresCPURequest)
+
+	require.NoError(t, i.SetCPU("500m"))
+	require.NoError(t, i.deployHPA(ctx))
+
+	hpas := k8sCli.FakeClientset.AutoscalingV2().HorizontalPodAutoscalers("test")
+	hpa, err := hpas.Get(ctx, i.k8sName, metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, autoscalingv2.CrossVersionObjectReference{APIVersion: "apps/v1", Kind: "ReplicaSet", Name: i.k8sName}, hpa.Spec.ScaleTargetRef)
+	assert.Equal(t, int32(2), *hpa.Spec.MinReplicas)
+	assert.Equal(t, int32(5), hpa.Spec.MaxReplicas)
+	require.Len(t, hpa.Spec.Metrics, 1)
+	assert.Equal(t, v1.ResourceCPU, hpa.Spec.Metrics[0].Resource.Name)
+	assert.Equal(t, int32(80), *hpa.Spec.Metrics[0].Resource.Target.AverageUtilization)
+
+	i.setState(Started)
+	hpa.Status = autoscalingv2.HorizontalPodAutoscalerStatus{CurrentReplicas: 2, DesiredReplicas: 4}
+	_, err = hpas.Update(ctx, hpa, metav1.UpdateOptions{})
+	require.NoError(t, err)
+	current, desired, err := i.HPAReplicas(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), current)
+	assert.Equal(t, int32(4), desired)
+
+	// destroying the autoscaler is idempotent, e.g. after a rollback
+	require.NoError(t, i.destroyHPA(ctx))
+	require.NoError(t, i.destroyHPA(ctx))
+	_, err = hpas.Get(ctx, i.k8sName, metav1.GetOptions{})
+	assert.Error(t, err)
+}
+
+func TestDeployHPACustomMetric(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	k8sCli, err := fake.New(ctx, "test")
+	require.NoError(t, err)
+	i, err := New("node", system.SystemDependencies{K8sCli: k8sCli}, WithImage("alpine"))
+	require.NoError(t, err)
+	require.NoError(t, i.EnableHPA(1, 3, HPAPodsMetric("http_requests_per_second", "100")))
+
+	// the custom metrics adapter is not installed
+	err = i.deployHPA(ctx)
+	assert.ErrorIs(t, err, ErrDeployingHPA)
+	assert.ErrorContains(t, err, "does not support CustomMetrics")
+
+	k8sCli, err = fake.New(ctx, "test")
+	require.NoError(t, err)
+	k8sCli.AddAPIResources("custom.metrics.k8s.io/v1beta1")
+	i.K8sCli = k8sCli
+	require.NoError(t, i.deployHPA(ctx))
+
+	hpa, err := k8sCli.FakeClientset.AutoscalingV2().HorizontalPodAutoscalers("test").Get(ctx, i.k8sName, metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Len(t, hpa.Spec.Metrics, 1)
+	assert.Equal(t, autoscalingv2.PodsMetricSourceType, hpa.Spec.Metrics[0].Type)
+	assert.Equal(t, "http_requests_per_second", hpa.Spec.Metrics[0].Pods.Metric.Name)
+	assert.Equal(t, "100", hpa.Spec.Metrics[0].Pods.Target.AverageValue.String())
+}
+
+func TestPoolEnableHPA(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	k8sCli, err := fake.New(ctx, "test")
+	require.NoError(t, err)
+	i, err := New("node", system.SystemDependencies{K8sCli: k8sCli}, WithImage("alpine"))
+	require.NoError(t, err)
+	i.setState(Committed)
+	pool, err := i.NewPool(2)
+	require.NoError(t, err)
+
+	require.NoError(t, pool.EnableHPA(1, 4, HPACPUUtilization(50)))
+	for _, instance := range pool.Instances() {
+		require.NotNil(t, instance.hpa)
+		assert.Equal(t, int32(4), instance.hpa.maxReplicas)
+	}
+}
@@ -0,0 +1,88 @@
+package instance
+
+import (
+	"context"
+
+	"github.com/celestiaorg/knuu/pkg/builder/registry"
+	"github.com/celestiaorg/knuu/pkg/container"
+)
+
+// SetPrebuiltImage sets an image the instance runs as is: no builder is created, nor a new image built and pushed,
+// and the instance moves straight to the state 'Committed', so the builder does not need to be available.
+// The configuration can still be changed in the state 'Committed', e.g. the environment or the files in volumes,
+// but not the image itself, e.g. with SetUser; use SetImage for that.
+// The signature of the image is verified if an image verifier is set, see knuu.WithImageVerifier.
+// This function can only be called in the state 'None'
+func (i *Instance) SetPrebuiltImage(ctx context.Context, image string) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if !i.allows(ActionSetPrebuiltImage) {
+		return ErrSettingPrebuiltImageNotAllowed.WithParams(i.getState().String())
+	}
+	if err := i.usePrebuiltImage(ctx, image); err != nil {
+		return err
+	}
+	i.recordCommit()
+	return nil
+}
+
+// usePrebuiltImage sets the image of the instance without a builder and moves the instance to the state
+// 'Committed'
+func (i *Instance) usePrebuiltImage(ctx context.Context, image string) error {
+	if image == "" {
+		return ErrPrebuiltImageEmpty.WithParams(i.name)
+	}
+	if err := i.verifyImage(ctx, image); err != nil {
+		return err
+	}
+	i.imageName = image
+	i.cmdImage = ""
+	return i.transition(Committed)
+}
+
+// ImageConfig returns the configuration of the image of the instance read from its registry: its entrypoint,
+// command, exposed ports, environment and user, e.g. to override the command only if the image has none
+// In the state 'Preparing' it is the configuration of the image the instance is built from.
+// This function can only be called in the states 'Preparing', 'Committed' and 'Started'
+func (i *Instance) ImageConfig(ctx context.Context) (*registry.ImageConfig, error) {
+	i.mu.Lock()
+	if !i.allows(ActionReadFiles) {
+		i.mu.Unlock()
+		return nil, ErrGettingImageConfigNotAllowed.WithParams(i.getState().String())
+	}
+	image := i.currentImage()
+	i.mu.Unlock()
+
+	return i.imageConfig(ctx, image)
+}
+
+// builder returns the builder of the image of the instance, the instances running a prebuilt image have none, see
+// SetPrebuiltImage
+func (i *Instance) builder() (*container.BuilderFactory, error) {
+	if i.builderFactory == nil {
+		return nil, ErrInstanceWithoutBuilder.WithParams(i.name, i.imageName)
+	}
+	return i.builderFactory, nil
+}
+
+// currentImage returns the image of the instance, the image it is built from until it is committed
+func (i *Instance) currentImage() string {
+	if i.imageName == "" && i.builderFactory != nil {
+		return i.builderFactory.ImageNameFrom()
+	}
+	return i.imageName
+}
+
+// imageConfig reads the configuration of the given image from its registry
+func (i *Instance) imageConfig(ctx context.Context, image string) (*registry.ImageConfig, error) {
+	client := i.Registry
+	if client == nil {
+		client = registry.New()
+	}
+	config, err := client.ImageConfig(ctx, image)
+	if err != nil {
+		return nil, ErrGettingImageConfig.WithParams(image, i.name).Wrap(err)
+	}
+	return config, nil
+}
@@ -0,0 +1,136 @@
+package instance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/celestiaorg/knuu/pkg/builder/registry"
+	"github.com/celestiaorg/knuu/pkg/k8s/fake"
+	"github.com/celestiaorg/knuu/pkg/system"
+)
+
+// newTestRegistry returns the host of a registry serving the image 'app:v1' with the given config
+func newTestRegistry(t *testing.T, config string) string {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v2/app/manifests/v1", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/vnd.oci.image.manifest.v1+json")
+		fmt.Fprint(w, `{"config":{"digest":"sha256:config"}}`)
+	})
+	mux.HandleFunc("/v2/app/blobs/sha256:config", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"config":%s}`, config)
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return strings.TrimPrefix(srv.URL, "http://")
+}
+
+func TestImageConfig(t *testing.T) {
+	t.Parallel()
+	host := newTestRegistry(t, `{"Entrypoint":["/bin/app"],"Cmd":["start"],"User":"app"}`)
+
+	i := &Instance{name: "app", k8sName: "app", state: Committed, imageName: host + "/app:v1"}
+	config, err := i.ImageConfig(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, &registry.ImageConfig{
+		Entrypoint: []string{"/bin/app"},
+		Cmd:        []string{"start"},
+		Env:        map[string]string{},
+		User:       "app",
+	}, config)
+
+	i.imageName = host + "/app:v2"
+	_, err = i.ImageConfig(context.Background())
+	assert.ErrorContains(t, err, "error getting the config of image")
+
+	i = &Instance{name: "app", k8sName: "app", state: None}
+	_, err = i.ImageConfig(context.Background())
+	assert.ErrorIs(t, err, ErrGettingImageConfigNotAllowed)
+}
+
+func TestSetEntrypoint(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	host := newTestRegistry(t, `{"Entrypoint":["/bin/app"],"Cmd":["start","--home","/data"]}`)
+	k8sCli, err := fake.New(ctx, "test")
+	require.NoError(t, err)
+
+	i, err := New("app", system.SystemDependencies{K8sCli: k8sCli}, WithImage(host+"/app:v1"))
+	require.NoError(t, err)
+	require.NoError(t, i.SetEntrypoint("/scripts/wrap.sh", "/bin/app"))
+	require.NoError(t, i.SetWorkingDir("/data"))
+	assert.ErrorIs(t, i.SetWorkingDir("data"), ErrWorkingDirNotAbsolute)
+
+	// the command of the image is passed to the entrypoint
+	require.NoError(t, i.resolveImageCmd(ctx))
+	container := i.preparePodConfig().ContainerConfig
+	assert.Equal(t, []string{"/scripts/wrap.sh", "/bin/app"}, container.Command)
+	assert.Equal(t, []string{"start", "--home", "/data"}, container.Args)
+	assert.Equal(t, "/data", container.WorkingDir)
+
+	// the arguments replace the command of the image
+	require.NoError(t, i.SetArgs("version"))
+	assert.Equal(t, []string{"version"}, i.preparePodConfig().ContainerConfig.Args)
+
+	// the command replaces both the entrypoint and the command of the image
+	require.NoError(t, i.SetArgs())
+	require.NoError(t, i.SetCommand("/bin/sh"))
+	container = i.preparePodConfig().ContainerConfig
+	assert.Equal(t, []string{"/bin/sh"}, container.Command)
+	assert.Empty(t, container.Args)
+
+	// the command of a built image is read from its base image, the registry it is pushed to is not reachable
+	require.NoError(t, i.SetEntrypoint("/scripts/wrap.sh", "/bin/app"))
+	i.imageName, i.cmdImage, i.imageCmd = "knuu.local/app:knuu", host+"/app:v1", nil
+	require.NoError(t, i.resolveImageCmd(ctx))
+	assert.Equal(t, []string{"start", "--home", "/data"}, i.preparePodConfig().ContainerConfig.Args)
+}
+
+func TestSetPrebuiltImage(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	signer := &fakeSigner{}
+	k8sCli, err := fake.New(ctx, "test")
+	require.NoError(t, err)
+	sysDeps := system.SystemDependencies{K8sCli: k8sCli, ImageVerifier: signer}
+
+	i, err := New("app", sysDeps)
+	require.NoError(t, err)
+	assert.ErrorIs(t, i.SetPrebuiltImage(ctx, ""), ErrPrebuiltImageEmpty)
+	require.NoError(t, i.SetPrebuiltImage(ctx, "ghcr.io/celestiaorg/celestia-app:v1"))
+	assert.True(t, i.IsInState(Committed))
+	assert.Nil(t, i.builderFactory)
+	assert.Equal(t, []string{"ghcr.io/celestiaorg/celestia-app:v1"}, signer.verified)
+	assert.Equal(t, "ghcr.io/celestiaorg/celestia-app:v1", i.preparePodConfig().ContainerConfig.Image)
+	assert.ErrorIs(t, i.SetPrebuiltImage(ctx, "alpine"), ErrSettingPrebuiltImageNotAllowed)
+
+	// the image is not modified anymore, only the configuration of the container
+	require.NoError(t, i.SetEnvironmentVariable("CHAIN_ID", "test"))
+	assert.Equal(t, "test", i.env["CHAIN_ID"])
+	assert.ErrorIs(t, i.SetUser("1000"), ErrSettingUserNotAllowed)
+	// the files can only be read from the container once started, there is no builder to read them from
+	_, err = i.GetFileBytes(ctx, "/etc/hostname")
+	assert.ErrorIs(t, err, ErrGettingFile)
+	assert.ErrorContains(t, err, "has no builder")
+
+	// the options are applied to the committed instance
+	i, err = New("app", sysDeps, WithPrebuiltImage("alpine"), WithEnv(map[string]string{"CHAIN_ID": "test"}), WithPorts(26656))
+	require.NoError(t, err)
+	assert.True(t, i.IsInState(Committed))
+	assert.Equal(t, "test", i.env["CHAIN_ID"])
+	assert.Equal(t, []int{26656}, i.portsTCP)
+
+	signer.err = errors.New("no matching signatures")
+	i, err = New("app", sysDeps)
+	require.NoError(t, err)
+	assert.ErrorIs(t, i.SetPrebuiltImage(ctx, "ghcr.io/unsigned:v1"), ErrVerifyingImage)
+	assert.True(t, i.IsInState(None))
+}
@@ -2,12 +2,15 @@ package instance
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
+	"path"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	appv1 "k8s.io/api/apps/v1"
@@ -19,10 +22,17 @@ import (
 
 	"github.com/celestiaorg/bittwister/sdk"
 
+	"github.com/celestiaorg/knuu/pkg/annotation"
+	"github.com/celestiaorg/knuu/pkg/budget"
 	"github.com/celestiaorg/knuu/pkg/builder"
 	"github.com/celestiaorg/knuu/pkg/container"
+	"github.com/celestiaorg/knuu/pkg/identity"
 	"github.com/celestiaorg/knuu/pkg/k8s"
 	"github.com/celestiaorg/knuu/pkg/names"
+	"github.com/celestiaorg/knuu/pkg/proxy"
+	"github.com/celestiaorg/knuu/pkg/recording"
+	"github.com/celestiaorg/knuu/pkg/report"
+	"github.com/celestiaorg/knuu/pkg/retry"
 	"github.com/celestiaorg/knuu/pkg/system"
 )
 
@@ -32,7 +42,19 @@ const (
 	retryInterval = 5 * time.Second
 )
 
+var (
+	// portForwardPolicy is the retry policy used to forward a port to the instance
+	portForwardPolicy = retry.Constant(retryInterval).WithMaxAttempts(maxRetries)
+	// waitRunningPolicy is the policy used to wait for the instance to be running
+	waitRunningPolicy = retry.Constant(1 * time.Second).WithTimeout(1 * time.Minute)
+	// waitStoppedPolicy is the policy used to wait for the instance to be stopped, it is only bound by the context
+	waitStoppedPolicy = retry.Constant(1 * time.Second)
+)
+
 // ObsyConfig represents the configuration for the obsy sidecar
+// The observability of a sidecar is collected by the obsy sidecar of its instance: the endpoints and the log files
+// of the sidecar are added to the receivers of the collector, while its exporters, resources, version and host and
+// kubelet metrics are the ones of the instance.
 type ObsyConfig struct {
 	// otelCollectorVersion is the version of the otel collector to use
 	otelCollectorVersion string
@@ -67,6 +89,26 @@ type ObsyConfig struct {
 
 	// prometheusRemoteWriteExporterEndpoint is the endpoint of the prometheus remote write
 	prometheusRemoteWriteExporterEndpoint string
+
+	// hostMetricsInterval and kubeletStatsInterval are the collection intervals of the hostmetrics and
+	// kubeletstats receivers, which are disabled if zero
+	hostMetricsInterval  time.Duration
+	kubeletStatsInterval time.Duration
+
+	// logsPaths are the paths of the log files tailed by the filelog receiver, which is disabled if empty, and
+	// logsParser is the parser of their lines
+	logsPaths  []string
+	logsParser OtelLogParser
+
+	// exporters are the exporters added with AddOtelExporter
+	exporters []OtelExporter
+	// exporterTLS are the TLS settings of the exporters set with SetOtlpExporter, SetJaegerExporter and
+	// SetPrometheusRemoteWriteExporter, by type
+	exporterTLS map[OtelExporterType]OtelTLS
+
+	// otelCollectorRequest and otelCollectorLimit are the resources of the otel collector sidecar
+	otelCollectorRequest Resources
+	otelCollectorLimit   Resources
 }
 
 // SecurityContext represents the security settings for a container
@@ -79,8 +121,22 @@ type SecurityContext struct {
 }
 
 // Instance represents a instance
+// An Instance is safe for concurrent use by multiple goroutines.
+// Methods that change the configuration or the state of the instance are serialized,
+// while methods that only interact with a started instance (e.g. ExecuteCommand) run concurrently.
+// The configuration of an instance can only be changed before it is started,
+// so it is never modified while it is in use by the cluster.
 type Instance struct {
 	system.SystemDependencies
+
+	// mu guards the configuration of the instance and serializes the lifecycle operations
+	// When both are needed, the lock of an instance is acquired before the locks of its sidecars
+	mu sync.Mutex
+	// stateMu guards state and stateHandlers, so that the state can be checked while mu is held
+	stateMu sync.RWMutex
+	// stateHandlers are called at the transitions of the instance, see OnStateChange
+	stateHandlers []StateChangeHandler
+
 	name                 string
 	imageName            string
 	k8sName              string
@@ -89,20 +145,39 @@ type Instance struct {
 	kubernetesService    *v1.Service
 	builderFactory       *container.BuilderFactory
 	kubernetesReplicaSet *appv1.ReplicaSet
+	restartPolicy        v1.RestartPolicy
+	dnsPolicy            v1.DNSPolicy
+	dnsConfig            *v1.PodDNSConfig
+	hostNetwork          bool
+	hostPorts            []int
 	portsTCP             []int
 	portsUDP             []int
+	ports                []Port
+	services             []ServiceSpec
 	command              []string
 	args                 []string
+	workingDir           string
+	tty                  bool
+	stdin                bool
+	shareProcesses       bool
 	env                  map[string]string
+	fieldEnv             map[string]string
 	volumes              []*k8s.Volume
 	memoryRequest        string
 	memoryLimit          string
 	cpuRequest           string
+	cpuLimit             string
 	policyRules          []rbacv1.PolicyRule
 	livenessProbe        *v1.Probe
 	readinessProbe       *v1.Probe
 	startupProbe         *v1.Probe
 	files                []*k8s.File
+	shell                []string
+	noShell              bool
+	remoteFiles          []*k8s.RemoteFile
+	sharedDirs           []*k8s.SharedDir
+	group                string
+	zoneLabel            string
 	isSidecar            bool
 	parentInstance       *Instance
 	sidecars             []*Instance
@@ -110,9 +185,69 @@ type Instance struct {
 	obsyConfig           *ObsyConfig
 	securityContext      *SecurityContext
 	BitTwister           *btConfig
+
+	// keepImageCmd passes the command of the image as the arguments of the entrypoint set with SetEntrypoint,
+	// imageCmd is the command of the image resolved when the instance is started
+	keepImageCmd bool
+	imageCmd     []string
+	// cmdImage is the image the command is read from, the base image of the images built by knuu: they keep its
+	// command, and the registry they are pushed to may not be reachable from the test, e.g. the in-cluster
+	// registry. Empty to read it from the image of the instance.
+	cmdImage string
+
+	// netAccountingPeers maps the k8s names of the peers counted by the network accounting to their names
+	netAccountingPeers map[string]string
+
+	// hooks are the lifecycle hooks of the instance, by event
+	hooks map[LifecycleEvent][]LifecycleHook
+
+	// tlsHosts are the additional hosts of the TLS certificate of the instance, TLS is enabled if not nil,
+	// tlsIdentity is the identity issued when the instance is deployed
+	tlsHosts    []string
+	tlsIdentity *identity.Identity
+
+	// secretDirs are the directories whose files are delivered through secrets, see AddSecretFiles
+	secretDirs []secretDir
+
+	// apiFaultsEnabled routes the requests of the instance to the Kubernetes API through apiProxy, its sidecar
+	apiFaultsEnabled bool
+	apiProxy         *Instance
+
+	// tcpHosts is true once a TCP port of the instance is exposed by the proxy, its ports of the proxy are
+	// released when the instance is destroyed
+	tcpHosts bool
+
+	// readinessGates are the readiness gates of the pod, evaluated until readinessCancel is called
+	readinessGates  []readinessGate
+	readinessCancel context.CancelFunc
+
+	// vpaMode is the update mode of the VerticalPodAutoscaler of the instance, which is deployed if it is set
+	vpaMode VPAMode
+	// hpa is the configuration of the HorizontalPodAutoscaler of the instance, which is deployed if it is not nil
+	hpa *hpaConfig
+
+	// preemptible makes the pod of the instance schedulable on the spot nodes, whose taints are tolerated by
+	// tolerations, and lets the PreemptionSimulator preempt it
+	preemptible bool
+	tolerations []v1.Toleration
+
+	// attached is the workload not created by knuu the instance is a handle on, see AttachDeployment
+	attached *attachedWorkload
 }
 
-func New(name string, sysDeps system.SystemDependencies) (*Instance, error) {
+// New creates a new instance with the given name
+// The options are validated together and all problems are returned in a single error.
+// When an image is given, the instance is returned in the state 'Preparing', or 'Committed' for a prebuilt image,
+// otherwise in the state 'None'.
+func New(name string, sysDeps system.SystemDependencies, opts ...Option) (*Instance, error) {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if err := o.validate(); err != nil {
+		return nil, ErrInvalidOptions.WithParams(name).Wrap(err)
+	}
+
 	k8sName, err := names.NewRandomK8(name)
 	if err != nil {
 		return nil, ErrGeneratingK8sName.WithParams(name).Wrap(err)
@@ -120,6 +255,8 @@ func New(name string, sysDeps system.SystemDependencies) (*Instance, error) {
 
 	obsyConfig := &ObsyConfig{
 		otelCollectorVersion:                  "0.83.0",
+		otelCollectorRequest:                  Resources{CPU: "100m", Memory: "100Mi"},
+		otelCollectorLimit:                    Resources{Memory: "200Mi"},
 		otlpPort:                              0,
 		prometheusEndpointPort:                0,
 		prometheusEndpointJobName:             "",
@@ -140,7 +277,7 @@ func New(name string, sysDeps system.SystemDependencies) (*Instance, error) {
 	}
 
 	// Create the instance
-	return &Instance{
+	i := &Instance{
 		name:               name,
 		k8sName:            k8sName,
 		imageName:          "",
@@ -167,18 +304,53 @@ func New(name string, sysDeps system.SystemDependencies) (*Instance, error) {
 		securityContext:    securityContext,
 		BitTwister:         getBitTwisterDefaultConfig(),
 		SystemDependencies: sysDeps,
-	}, nil
+	}
+
+	if err := o.apply(i); err != nil {
+		return nil, ErrApplyingOptions.WithParams(name).Wrap(err)
+	}
+	return i, nil
 }
 
+// EnableBitTwister deploys BitTwister as a sidecar of the instance to shape its traffic, see SetBandwidthLimit
+// It fails right away if the pod security probed by Knuu.Preflight forbids the pods BitTwister runs in, see
+// BitTwister.SetPrivileged for the clusters forbidding the privileged pods.
 func (i *Instance) EnableBitTwister() error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
 	if i.IsInState(Started) {
 		return ErrEnablingBitTwister
 	}
+	if err := i.BitTwister.checkPodSecurity(i.PodSecurity); err != nil {
+		return err
+	}
 	i.BitTwister.enable()
 	return nil
 }
 
+// SetBitTwisterResources sets the resources requested by the BitTwister sidecar and its limits, none by default
+// This function can only be called in the states 'Preparing' and 'Committed'
+func (i *Instance) SetBitTwisterResources(request, limit Resources) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if !i.allows(ActionConfigure) {
+		return ErrSettingBitTwisterResourcesNotAllowed.WithParams(i.getState().String())
+	}
+	if err := validateResources(request, limit); err != nil {
+		return err
+	}
+	i.BitTwister.request = request
+	i.BitTwister.limit = limit
+	logrus.Debugf("Set BitTwister resources to '%s' and limit to '%s' for instance '%s'", request, limit, i.name)
+	return nil
+}
+
 func (i *Instance) DisableBitTwister() error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
 	i.BitTwister.disable()
 	return nil
 }
@@ -188,28 +360,33 @@ func (i *Instance) Name() string {
 	return i.name
 }
 
+// HostName returns the host name of the service of the instance in its namespace, e.g. for the certificates of the
+// instance
+func (i *Instance) HostName() string {
+	return i.k8sName
+}
+
 func (i *Instance) SetInstanceType(instanceType InstanceType) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
 	i.instanceType = instanceType
 }
 
 // SetImage sets the image of the instance.
 // When calling in state 'Started', make sure to call AddVolume() before.
+// The signature of the image is verified if an image verifier is set, see knuu.WithImageVerifier.
 // It is only allowed in the 'None' and 'Started' states.
 func (i *Instance) SetImage(ctx context.Context, image string) error {
-	if !i.IsInState(None, Started) {
-		return ErrSettingImageNotAllowed.WithParams(i.state.String())
-	}
+	i.mu.Lock()
+	defer i.mu.Unlock()
 
-	if i.state == None {
-		// Use the builder to build a new image
-		factory, err := container.NewBuilderFactory(image, i.getBuildDir(), i.ImageBuilder)
-		if err != nil {
-			return ErrCreatingBuilder.Wrap(err)
-		}
-		i.builderFactory = factory
-		i.state = Preparing
+	if !i.allows(ActionSetImage) {
+		return ErrSettingImageNotAllowed.WithParams(i.getState().String())
+	}
 
-		return nil
+	if i.IsInState(None) {
+		return i.prepareImage(ctx, image)
 	}
 
 	if i.isSidecar {
@@ -218,11 +395,32 @@ func (i *Instance) SetImage(ctx context.Context, image string) error {
 	return i.setImageWithGracePeriod(ctx, image, nil)
 }
 
+// prepareImage creates the builder of a new image based on the given image
+// and moves the instance to the state 'Preparing'
+func (i *Instance) prepareImage(ctx context.Context, image string) error {
+	if err := i.verifyImage(ctx, image); err != nil {
+		return err
+	}
+	factory, err := container.NewBuilderFactory(image, i.getBuildDir(), i.ImageBuilder)
+	if err != nil {
+		return ErrCreatingBuilder.Wrap(err)
+	}
+	i.builderFactory = factory
+	if err := i.transition(Preparing); err != nil {
+		return err
+	}
+	return nil
+}
+
 // SetGitRepo builds the image from the given git repo, pushes it
 // to the registry under the given name and sets the image of the instance.
+// The image is checked by the post build hooks, see builder.PostBuildHook.
 func (i *Instance) SetGitRepo(ctx context.Context, gitContext builder.GitContext) error {
-	if !i.IsInState(None) {
-		return ErrSettingGitRepo.WithParams(i.state.String())
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if !i.allows(ActionSetGitRepo) {
+		return ErrSettingGitRepo.WithParams(i.getState().String())
 	}
 
 	bCtx, err := gitContext.BuildContext()
@@ -239,17 +437,26 @@ func (i *Instance) SetGitRepo(ctx context.Context, gitContext builder.GitContext
 		return ErrCreatingBuilder.Wrap(err)
 	}
 	i.builderFactory = factory
-	i.state = Preparing
+	if err := i.transition(Preparing); err != nil {
+		return err
+	}
 
-	return i.builderFactory.BuildImageFromGitRepo(ctx, gitContext, imageName)
+	ctx, span := budget.Begin(ctx, budget.PhaseBuild)
+	if err := i.builderFactory.BuildImageFromGitRepo(ctx, gitContext, imageName); err != nil {
+		return span.End(err)
+	}
+	return span.End(i.checkImage(ctx, imageName))
 }
 
 // SetImageInstant sets the image of the instance without a grace period.
 // Instant means that the pod is replaced without a grace period of 1 second.
 // It is only allowed in the 'Running' state.
 func (i *Instance) SetImageInstant(ctx context.Context, image string) error {
-	if !i.IsInState(Started) {
-		return ErrSettingImageNotAllowedForSidecarsStarted.WithParams(i.state.String())
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if !i.allows(ActionOperate) {
+		return ErrSettingImageNotAllowedForSidecarsStarted.WithParams(i.getState().String())
 	}
 
 	if i.isSidecar {
@@ -263,18 +470,107 @@ func (i *Instance) SetImageInstant(ctx context.Context, image string) error {
 // SetCommand sets the command to run in the instance
 // This function can only be called when the instance is in state 'Preparing' or 'Committed'
 func (i *Instance) SetCommand(command ...string) error {
-	if !i.IsInState(Preparing, Committed) {
-		return ErrSettingCommand.WithParams(i.state.String())
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if !i.allows(ActionConfigure) {
+		return ErrSettingCommand.WithParams(i.getState().String())
 	}
 	i.command = command
+	i.keepImageCmd = false
+	return nil
+}
+
+// SetEntrypoint replaces the entrypoint of the image, e.g. to wrap it with a script of the test
+// Unlike SetCommand, the command of the image is kept as the arguments of the entrypoint unless SetArgs is used,
+// as with 'docker run --entrypoint'. The command of the image is read from its registry, see ImageConfig.
+// This function can only be called in the states 'Preparing' or 'Committed'
+func (i *Instance) SetEntrypoint(entrypoint ...string) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if !i.allows(ActionConfigure) {
+		return ErrSettingEntrypointNotAllowed.WithParams(i.getState().String())
+	}
+	i.command = entrypoint
+	i.keepImageCmd = true
+	return nil
+}
+
+// SetWorkingDir sets the directory the command of the instance runs in, instead of the one of the image
+// This function can only be called in the states 'Preparing' or 'Committed'
+func (i *Instance) SetWorkingDir(dir string) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if !i.allows(ActionConfigure) {
+		return ErrSettingWorkingDirNotAllowed.WithParams(i.getState().String())
+	}
+	if !path.IsAbs(dir) {
+		return ErrWorkingDirNotAbsolute.WithParams(dir)
+	}
+	i.workingDir = dir
+	return nil
+}
+
+// SetTTY allocates a terminal for the instance, e.g. for the processes that only handle the signals or print
+// their output when attached to a terminal
+// This function can only be called in the states 'Preparing' or 'Committed'
+func (i *Instance) SetTTY(tty bool) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if !i.allows(ActionConfigure) {
+		return ErrSettingTTYNotAllowed.WithParams(i.getState().String())
+	}
+	i.tty = tty
+	logrus.Debugf("Set TTY to '%t' for instance '%s'", tty, i.name)
+	return nil
+}
+
+// SetStdin keeps the stdin of the instance open, e.g. for REPL-like processes that exit at the end of their input
+// This function can only be called in the states 'Preparing' or 'Committed'
+func (i *Instance) SetStdin(stdin bool) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if !i.allows(ActionConfigure) {
+		return ErrSettingStdinNotAllowed.WithParams(i.getState().String())
+	}
+	i.stdin = stdin
+	logrus.Debugf("Set stdin to '%t' for instance '%s'", stdin, i.name)
+	return nil
+}
+
+// EnableShareProcessNamespace makes the instance and its sidecars share a process namespace, so that the sidecars
+// can signal the processes of the instance and the probes can inspect the whole process tree, e.g. to test the
+// order of the shutdown. The processes of the instance do not run as PID 1 anymore, the pause container does.
+// As the main processes cannot be told apart from PID 1 anymore, the sidecars cannot be restarted or stopped with
+// RestartSidecar and StopSidecar, and the files of the images without cat cannot be read.
+// This function can only be called in the states 'Preparing' or 'Committed'
+func (i *Instance) EnableShareProcessNamespace() error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if !i.allows(ActionConfigure) {
+		return ErrSharingProcessNamespaceNotAllowed.WithParams(i.getState().String())
+	}
+	if i.isSidecar {
+		return ErrSharingProcessNamespaceForSidecar
+	}
+	i.shareProcesses = true
+	logrus.Debugf("Enabled sharing the process namespace for instance '%s'", i.name)
 	return nil
 }
 
 // SetArgs sets the arguments passed to the instance
 // This function can only be called in the states 'Preparing' or 'Committed'
 func (i *Instance) SetArgs(args ...string) error {
-	if !i.IsInState(Preparing, Committed) {
-		return ErrSettingArgsNotAllowed.WithParams(i.state.String())
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if !i.allows(ActionConfigure) {
+		return ErrSettingArgsNotAllowed.WithParams(i.getState().String())
 	}
 	i.args = args
 	return nil
@@ -283,32 +579,38 @@ func (i *Instance) SetArgs(args ...string) error {
 // AddPortTCP adds a TCP port to the instance
 // This function can be called in the states 'Preparing' and 'Committed'
 func (i *Instance) AddPortTCP(port int) error {
-	if !i.IsInState(Preparing, Committed) {
-		return ErrAddingPortNotAllowed.WithParams(i.state.String())
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if !i.allows(ActionConfigure) {
+		return ErrAddingPortNotAllowed.WithParams(i.getState().String())
 	}
 	err := validatePort(port)
 	if err != nil {
 		return err
 	}
-	if i.isTCPPortRegistered(port) {
+	if i.isTCPPortRegistered(port) || i.isServicePortRegistered(v1.ProtocolTCP, port) {
 		return ErrPortAlreadyRegistered.WithParams(port)
 	}
+	if name := defaultPortName(v1.ProtocolTCP, port); i.isPortNameRegistered(name) {
+		return ErrPortNameAlreadyRegistered.WithParams(name)
+	}
 	i.portsTCP = append(i.portsTCP, port)
 	logrus.Debugf("Added TCP port '%d' to instance '%s'", port, i.name)
 	return nil
 }
 
 // PortForwardTCP forwards the given port to a random port on the host
-// This function can only be called in the state 'Started'
+// This function can only be called in the states 'Started' and 'Attached'
 func (i *Instance) PortForwardTCP(ctx context.Context, port int) (int, error) {
-	if !i.IsInState(Started) {
-		return -1, ErrRandomPortForwardingNotAllowed.WithParams(i.state.String())
+	if !i.allows(ActionPortForward) {
+		return -1, ErrRandomPortForwardingNotAllowed.WithParams(i.getState().String())
 	}
 	err := validatePort(port)
 	if err != nil {
 		return 0, err
 	}
-	if !i.isTCPPortRegistered(port) {
+	if !i.isContainerPortRegistered(v1.ProtocolTCP, port) {
 		return -1, ErrPortNotRegistered.WithParams(port)
 	}
 	// Get a random port on the host
@@ -318,21 +620,20 @@ func (i *Instance) PortForwardTCP(ctx context.Context, port int) (int, error) {
 	}
 
 	// Forward the port
-	pod, err := i.K8sCli.GetFirstPodFromReplicaSet(ctx, i.k8sName)
+	pod, err := i.getPod(ctx)
 	if err != nil {
 		return -1, ErrGettingPodFromReplicaSet.WithParams(i.k8sName).Wrap(err)
 	}
 
-	for attempt := 1; attempt <= maxRetries; attempt++ {
+	err = retry.Do(ctx, portForwardPolicy, func(ctx context.Context) error {
 		err := i.K8sCli.PortForwardPod(ctx, pod.Name, localPort, port)
-		if err == nil {
-			break
-		}
-		if attempt == maxRetries {
-			return -1, ErrForwardingPort.WithParams(maxRetries)
+		if err != nil {
+			logrus.Debugf("Forwarding port %d failed, cause: %v, retrying after %v", port, err, retryInterval)
 		}
-		logrus.Debugf("Forwarding port %d failed, cause: %v, retrying after %v (retry %d/%d)", port, err, retryInterval, attempt, maxRetries)
-		time.Sleep(retryInterval)
+		return err
+	})
+	if err != nil {
+		return -1, ErrForwardingPort.WithParams(maxRetries).Wrap(err)
 	}
 	return localPort, nil
 }
@@ -340,68 +641,106 @@ func (i *Instance) PortForwardTCP(ctx context.Context, port int) (int, error) {
 // AddPortUDP adds a UDP port to the instance
 // This function can be called in the states 'Preparing' and 'Committed'
 func (i *Instance) AddPortUDP(port int) error {
-	if !i.IsInState(Preparing, Committed) {
-		return ErrAddingPortNotAllowed.WithParams(i.state.String())
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if !i.allows(ActionConfigure) {
+		return ErrAddingPortNotAllowed.WithParams(i.getState().String())
 	}
 	err := validatePort(port)
 	if err != nil {
 		return err
 	}
-	if i.isUDPPortRegistered(port) {
+	if i.isUDPPortRegistered(port) || i.isServicePortRegistered(v1.ProtocolUDP, port) {
 		return ErrUDPPortAlreadyRegistered.WithParams(port)
 	}
+	if name := defaultPortName(v1.ProtocolUDP, port); i.isPortNameRegistered(name) {
+		return ErrPortNameAlreadyRegistered.WithParams(name)
+	}
 	i.portsUDP = append(i.portsUDP, port)
 	logrus.Debugf("Added UDP port '%d' to instance '%s'", port, i.k8sName)
 	return nil
 }
 
 // ExecuteCommand executes the given command in the instance
-// This function can only be called in the states 'Preparing' and 'Started'
+// The arguments are joined with spaces and run with the shell of the instance, '/bin/sh -c' by default,
+// use ExecuteCommandDirect to pass them as is. Without a shell, see SetShell, the command is run as is.
+// This function can only be called in the states 'Preparing', 'Started' and 'Attached'
 // The context can be used to cancel the command and it is only possible in start state
-func (i *Instance) ExecuteCommand(ctx context.Context, command ...string) (string, error) {
-	if !i.IsInState(Preparing, Started) {
-		return "", ErrExecutingCommandNotAllowed.WithParams(i.state.String())
+func (i *Instance) ExecuteCommand(ctx context.Context, command ...string) (output string, err error) {
+	// the state is checked under the lock, so that the builder is not committed while the command runs in it
+	i.mu.Lock()
+	if !i.allows(ActionExecute) {
+		i.mu.Unlock()
+		return "", ErrExecutingCommandNotAllowed.WithParams(i.getState().String())
 	}
 
 	if i.IsInState(Preparing) {
-		output, err := i.builderFactory.ExecuteCmdInBuilder(command)
+		defer i.mu.Unlock()
+		builder, err := i.builder()
+		if err != nil {
+			return "", err
+		}
+		output, err := builder.ExecuteCmdInBuilder(command)
 		if err != nil {
 			return "", ErrExecutingCommandInInstance.WithParams(command, i.name).Wrap(err)
 		}
 		return output, nil
 	}
+	// the commands run in the container do not change the instance, they run concurrently
+	i.mu.Unlock()
 
-	var (
-		instanceName  string
-		eErr          *Error
-		containerName = i.k8sName
-	)
+	// the commands run while preparing are part of the image, only the ones run in the container are replayed
+	defer i.recordReplayable(recording.Operation{Kind: recording.KindExec, Command: append([]string(nil), command...)}, time.Now(), &err)
 
-	if i.isSidecar {
-		instanceName = i.parentInstance.k8sName
-		eErr = ErrExecutingCommandInSidecar.WithParams(command, i.k8sName, i.parentInstance.k8sName)
-	} else {
-		instanceName = i.k8sName
-		eErr = ErrExecutingCommandInInstance.WithParams(command, i.k8sName)
+	output, err = i.execInContainer(ctx, command, i.shellCommand(command))
+	if shell := i.Shell(); shell != nil && isExecutableNotFound(err, shell[0]) {
+		return "", ErrInstanceShellNotFound.WithParams(shell[0], i.k8sName).Wrap(err)
 	}
+	return output, err
+}
+
+// Logs returns a stream of the logs of the instance
+// If follow is true, the stream stays open until the instance stops or the context is cancelled.
+// The caller must close the stream.
+// This function can only be called in the states 'Started' and 'Attached'
+func (i *Instance) Logs(ctx context.Context, follow bool) (io.ReadCloser, error) {
+	return i.logs(ctx, func(podName string) (io.ReadCloser, error) {
+		return i.K8sCli.StreamPodLogs(ctx, podName, i.containerName(), follow)
+	})
+}
 
-	pod, err := i.K8sCli.GetFirstPodFromReplicaSet(ctx, instanceName)
+// LogsSince returns a stream of the logs of the instance logged at or after the given time, each line prefixed with
+// the RFC3339Nano time it was logged at and a space, e.g. to resume reading the logs after the last line read
+// The lines logged in the same second before the given time are streamed too, see k8s.Client.StreamPodLogsSince.
+// This function can only be called in the states 'Started' and 'Attached'
+func (i *Instance) LogsSince(ctx context.Context, since time.Time, follow bool) (io.ReadCloser, error) {
+	return i.logs(ctx, func(podName string) (io.ReadCloser, error) {
+		return i.K8sCli.StreamPodLogsSince(ctx, podName, i.containerName(), since, follow)
+	})
+}
+
+func (i *Instance) logs(ctx context.Context, stream func(podName string) (io.ReadCloser, error)) (io.ReadCloser, error) {
+	if !i.allows(ActionLogs) {
+		return nil, ErrGettingLogsNotAllowed.WithParams(i.getState().String())
+	}
+
+	pod, err := i.getPod(ctx)
 	if err != nil {
-		return "", ErrGettingPodFromReplicaSet.WithParams(i.k8sName).Wrap(err)
+		return nil, ErrGettingPodFromReplicaSet.WithParams(i.k8sName).Wrap(err)
 	}
 
-	commandWithShell := []string{"/bin/sh", "-c", strings.Join(command, " ")}
-	output, err := i.K8sCli.RunCommandInPod(ctx, pod.Name, containerName, commandWithShell)
+	logs, err := stream(pod.Name)
 	if err != nil {
-		return "", eErr.Wrap(err)
+		return nil, ErrGettingLogs.WithParams(i.k8sName).Wrap(err)
 	}
-	return output, nil
+	return logs, nil
 }
 
 // checkStateForAddingFile checks if the current state allows adding a file
 func (i *Instance) checkStateForAddingFile() error {
-	if !i.IsInState(Preparing, Committed) {
-		return ErrAddingFileNotAllowed.WithParams(i.state.String())
+	if !i.allows(ActionConfigure) {
+		return ErrAddingFileNotAllowed.WithParams(i.getState().String())
 	}
 	return nil
 }
@@ -409,6 +748,9 @@ func (i *Instance) checkStateForAddingFile() error {
 // AddFile adds a file to the instance
 // This function can only be called in the state 'Preparing'
 func (i *Instance) AddFile(src string, dest string, chown string) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
 	if err := i.checkStateForAddingFile(); err != nil {
 		return err
 	}
@@ -451,7 +793,7 @@ func (i *Instance) AddFile(src string, dest string, chown string) error {
 		return ErrFailedToCopyFile.WithParams(src, dstPath).Wrap(err)
 	}
 
-	switch i.state {
+	switch i.getState() {
 	case Preparing:
 		err := i.addFileToBuilder(src, dest, chown)
 		if err != nil {
@@ -498,8 +840,8 @@ func (i *Instance) AddFile(src string, dest string, chown string) error {
 // AddFolder adds a folder to the instance
 // This function can only be called in the state 'Preparing' or 'Committed'
 func (i *Instance) AddFolder(src string, dest string, chown string) error {
-	if !i.IsInState(Preparing, Committed) {
-		return ErrAddingFolderNotAllowed.WithParams(i.state.String())
+	if !i.allows(ActionConfigure) {
+		return ErrAddingFolderNotAllowed.WithParams(i.getState().String())
 	}
 
 	i.validateFileArgs(src, dest, chown)
@@ -568,38 +910,46 @@ func (i *Instance) AddFileBytes(bytes []byte, dest string, chown string) error {
 // SetUser sets the user for the instance
 // This function can only be called in the state 'Preparing'
 func (i *Instance) SetUser(user string) error {
-	if !i.IsInState(Preparing) {
-		return ErrSettingUserNotAllowed.WithParams(i.state.String())
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if !i.allows(ActionBuild) {
+		return ErrSettingUserNotAllowed.WithParams(i.getState().String())
 	}
-	err := i.builderFactory.SetUser(user)
+	builder, err := i.builder()
 	if err != nil {
+		return err
+	}
+	if err := builder.SetUser(user); err != nil {
 		return ErrSettingUser.WithParams(user, i.name).Wrap(err)
 	}
 	logrus.Debugf("Set user '%s' for instance '%s'", user, i.name)
 	return nil
 }
 
-// imageCache maps image hash values to image names
-var imageCache = make(map[string]string)
-
-// checkImageHashInCache checks if the given image hash exists in the cache.
-func checkImageHashInCache(imageHash string) (imageName string, exists bool) {
-	imageName, exists = imageCache[imageHash]
-	return imageName, exists
-}
-
-// updateImageCacheWithHash adds or updates the image cache with the given hash and image name.
-func updateImageCacheWithHash(imageHash, imageName string) {
-	imageCache[imageHash] = imageName // Update the cache with the new hash and image name
-}
-
 // Commit commits the instance
+// A new image built for the instance is checked by the post build hooks, see builder.PostBuildHook.
 // This function can only be called in the state 'Preparing'
 func (i *Instance) Commit() error {
-	if !i.IsInState(Preparing) {
-		return ErrCommittingNotAllowed.WithParams(i.state.String())
+	return i.CommitWithContext(context.Background())
+}
+
+// CommitWithContext commits the instance like Commit, the build of its image is cancelled when the context is done
+// and accounted in the build phase of the budget of the context, see budget.Begin
+// This function can only be called in the state 'Preparing'
+func (i *Instance) CommitWithContext(ctx context.Context) (err error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if !i.allows(ActionCommit) {
+		return ErrCommittingNotAllowed.WithParams(i.getState().String())
 	}
 	if i.builderFactory.Changed() {
+		i.cmdImage = i.builderFactory.ImageNameFrom()
+		defer func(start time.Time) {
+			i.Reporter.Record(i.k8sName, report.OperationBuild, start, i.imageName, err)
+		}(time.Now())
+
 		// TODO: To speed up the process, the image name could be dependent on the hash of the image
 		imageName, err := i.getImageRegistry()
 		if err != nil {
@@ -613,17 +963,29 @@ func (i *Instance) Commit() error {
 		}
 
 		// Check if the generated image hash already exists in the cache, otherwise, we build it.
-		cachedImageName, exists := checkImageHashInCache(imageHash)
+		imageHash = i.imageCacheKey(imageHash)
+		cachedImageName, exists := i.ImageCache.Get(imageHash)
 		if exists {
 			i.imageName = cachedImageName
 			logrus.Debugf("Using cached image for instance '%s'", i.name)
 		} else {
 			logrus.Debugf("Cannot use any cached image for instance '%s'", i.name)
-			err = i.builderFactory.PushBuilderImage(imageName)
+			buildStart := time.Now()
+			buildCtx, span := budget.Begin(ctx, budget.PhaseBuild)
+			err = i.builderFactory.PushBuilderImageWithContext(buildCtx, imageName)
+			i.Reporter.RecordPhase(i.k8sName, report.PhaseBuild, buildStart, time.Now(), err)
 			if err != nil {
-				return ErrPushingImage.WithParams(i.name).Wrap(err)
+				return span.End(ErrPushingImage.WithParams(i.name).Wrap(err))
+			}
+			// a rejected image is not cached, so it is checked and signed again by the next commit
+			if err := span.End(i.checkImage(buildCtx, imageName)); err != nil {
+				return err
+			}
+			if i.TransientImageRegistry {
+				i.ImageCache.SetTransient(imageHash, imageName)
+			} else {
+				i.ImageCache.Set(imageHash, imageName)
 			}
-			updateImageCacheWithHash(imageHash, imageName)
 			i.imageName = imageName
 			logrus.Debugf("Pushed new image for instance '%s'", i.name)
 		}
@@ -631,8 +993,10 @@ func (i *Instance) Commit() error {
 		i.imageName = i.builderFactory.ImageNameFrom()
 		logrus.Debugf("No need to build and push image for instance '%s'", i.name)
 	}
-	i.state = Committed
-	logrus.Debugf("Set state of instance '%s' to '%s'", i.name, i.state.String())
+	if err := i.transition(Committed); err != nil {
+		return err
+	}
+	i.recordCommit()
 
 	return nil
 }
@@ -641,20 +1005,17 @@ func (i *Instance) Commit() error {
 // The owner of the volume is set to 0, if you want to set a custom owner use AddVolumeWithOwner
 // This function can only be called in the states 'Preparing' and 'Committed'
 func (i *Instance) AddVolume(path, size string) error {
-	// temporary feat, we will remove it once we can add multiple volumes
-	if len(i.volumes) > 0 {
-		logrus.Debugf("Maximum volumes exceeded for instance '%s', volumes: %d", i.name, len(i.volumes))
-		return ErrMaximumVolumesExceeded.WithParams(i.name)
-	}
-	i.AddVolumeWithOwner(path, size, 0)
-	return nil
+	return i.AddVolumeWithOwner(path, size, 0)
 }
 
 // AddVolumeWithOwner adds a volume to the instance with the given owner
 // This function can only be called in the states 'Preparing' and 'Committed'
 func (i *Instance) AddVolumeWithOwner(path, size string, owner int64) error {
-	if !i.IsInState(Preparing, Committed) {
-		return ErrAddingVolumeNotAllowed.WithParams(i.state.String())
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if !i.allows(ActionConfigure) {
+		return ErrAddingVolumeNotAllowed.WithParams(i.getState().String())
 	}
 	// temporary feat, we will remove it once we can add multiple volumes
 	if len(i.volumes) > 0 {
@@ -668,10 +1029,17 @@ func (i *Instance) AddVolumeWithOwner(path, size string, owner int64) error {
 }
 
 // SetMemory sets the memory of the instance
+// The request and limit are quantities like '100Mi' or '2Gi', an empty string leaves them unset.
 // This function can only be called in the states 'Preparing' and 'Committed'
 func (i *Instance) SetMemory(request, limit string) error {
-	if !i.IsInState(Preparing, Committed) {
-		return ErrSettingMemoryNotAllowed.WithParams(i.state.String())
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if !i.allows(ActionConfigure) {
+		return ErrSettingMemoryNotAllowed.WithParams(i.getState().String())
+	}
+	if err := validateMemory(request, limit); err != nil {
+		return err
 	}
 	i.memoryRequest = request
 	i.memoryLimit = limit
@@ -680,28 +1048,59 @@ func (i *Instance) SetMemory(request, limit string) error {
 }
 
 // SetCPU sets the CPU of the instance
+// The request is a quantity like '500m' or '2', an empty string leaves it unset.
 // This function can only be called in the states 'Preparing' and 'Committed'
 func (i *Instance) SetCPU(request string) error {
-	if !i.IsInState(Preparing, Committed) {
-		return ErrSettingCPUNotAllowed.WithParams(i.state.String())
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if !i.allows(ActionConfigure) {
+		return ErrSettingCPUNotAllowed.WithParams(i.getState().String())
+	}
+	if err := validateCPU(request, i.cpuLimit); err != nil {
+		return err
 	}
 	i.cpuRequest = request
 	logrus.Debugf("Set cpu to '%s' in instance '%s'", request, i.name)
 	return nil
 }
 
+// SetCPULimit sets the CPU limit of the instance, above which its container is throttled
+// The limit is a quantity like '500m' or '2', an empty string leaves it unset.
+// This function can only be called in the states 'Preparing' and 'Committed'
+func (i *Instance) SetCPULimit(limit string) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if !i.allows(ActionConfigure) {
+		return ErrSettingCPUNotAllowed.WithParams(i.getState().String())
+	}
+	if err := validateCPU(i.cpuRequest, limit); err != nil {
+		return err
+	}
+	i.cpuLimit = limit
+	logrus.Debugf("Set cpu limit to '%s' in instance '%s'", limit, i.name)
+	return nil
+}
+
 // SetEnvironmentVariable sets the given environment variable in the instance
 // This function can only be called in the states 'Preparing' and 'Committed'
 func (i *Instance) SetEnvironmentVariable(key, value string) error {
-	if !i.IsInState(Preparing, Committed) {
-		return ErrSettingEnvNotAllowed.WithParams(i.state.String())
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if !i.allows(ActionConfigure) {
+		return ErrSettingEnvNotAllowed.WithParams(i.getState().String())
 	}
-	if i.state == Preparing {
-		err := i.builderFactory.SetEnvVar(key, value)
+	if i.IsInState(Preparing) {
+		builder, err := i.builder()
 		if err != nil {
 			return err
 		}
-	} else if i.state == Committed {
+		if err := builder.SetEnvVar(key, value); err != nil {
+			return err
+		}
+	} else if i.IsInState(Committed) {
 		i.env[key] = value
 	}
 	logrus.Debugf("Set environment variable '%s' to '%s' in instance '%s'", key, value, i.name)
@@ -711,6 +1110,9 @@ func (i *Instance) SetEnvironmentVariable(key, value string) error {
 // GetIP returns the IP of the instance
 // This function can only be called in the states 'Preparing' and 'Started'
 func (i *Instance) GetIP(ctx context.Context) (string, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
 	// Check if i.kubernetesService already has the IP
 	if i.kubernetesService != nil && i.kubernetesService.Spec.ClusterIP != "" {
 		return i.kubernetesService.Spec.ClusterIP, nil
@@ -719,7 +1121,7 @@ func (i *Instance) GetIP(ctx context.Context) (string, error) {
 	svc, err := i.K8sCli.GetService(ctx, i.k8sName)
 	if err != nil || svc == nil {
 		// Service does not exist, so we need to deploy it
-		err := i.deployService(ctx, i.portsTCP, i.portsUDP)
+		err := i.deployService(ctx, i.servicePorts())
 		if err != nil {
 			return "", ErrDeployingServiceForInstance.WithParams(i.k8sName).Wrap(err)
 		}
@@ -741,19 +1143,27 @@ func (i *Instance) GetIP(ctx context.Context) (string, error) {
 }
 
 // GetFileBytes returns the content of the given file
-// This function can only be called in the states 'Preparing' and 'Committed'
+// This function can only be called in the states 'Preparing', 'Committed' and 'Started'
 func (i *Instance) GetFileBytes(ctx context.Context, file string) ([]byte, error) {
-	if !i.IsInState(Preparing, Committed, Started) {
-		return nil, ErrGettingFileNotAllowed.WithParams(i.state.String())
+	i.mu.Lock()
+	if !i.allows(ActionReadFiles) {
+		i.mu.Unlock()
+		return nil, ErrGettingFileNotAllowed.WithParams(i.getState().String())
 	}
 
-	if i.state != Started {
-		bytes, err := i.builderFactory.ReadFileFromBuilder(file)
+	if !i.IsInState(Started) {
+		defer i.mu.Unlock()
+		builder, err := i.builder()
+		if err != nil {
+			return nil, ErrGettingFile.WithParams(file, i.name).Wrap(err)
+		}
+		bytes, err := builder.ReadFileFromBuilder(file)
 		if err != nil {
 			return nil, ErrGettingFile.WithParams(file, i.name).Wrap(err)
 		}
 		return bytes, nil
 	}
+	i.mu.Unlock()
 
 	rc, err := i.ReadFileFromRunningInstance(ctx, file)
 	if err != nil {
@@ -765,13 +1175,30 @@ func (i *Instance) GetFileBytes(ctx context.Context, file string) ([]byte, error
 }
 
 func (i *Instance) ReadFileFromRunningInstance(ctx context.Context, filePath string) (io.ReadCloser, error) {
-	if !i.IsInState(Started) {
-		return nil, ErrReadingFileNotAllowed.WithParams(i.state.String())
+	if !i.allows(ActionOperate) {
+		return nil, ErrReadingFileNotAllowed.WithParams(i.getState().String())
+	}
+
+	// the images without a shell, e.g. distroless ones, usually have no cat either
+	if i.Shell() == nil {
+		rc, err := i.readFileWithoutCat(ctx, filePath)
+		if err != nil {
+			return nil, ErrReadingFileFromInstance.WithParams(filePath, i.name).Wrap(err)
+		}
+		return rc, nil
 	}
 
 	// Not the best solution, we need to find a better one.
 	// Tested with a 110MB+ file and it worked.
-	fileContent, err := i.ExecuteCommand(ctx, "cat", filePath)
+	fileContent, err := i.execInContainer(ctx, []string{"cat", filePath}, []string{"cat", filePath})
+	if isExecutableNotFound(err, "cat") {
+		logrus.Debugf("Instance '%s' has no cat, reading '%s' through an ephemeral sidecar", i.k8sName, filePath)
+		rc, err := i.readFileWithoutCat(ctx, filePath)
+		if err != nil {
+			return nil, ErrReadingFileFromInstance.WithParams(filePath, i.name).Wrap(err)
+		}
+		return rc, nil
+	}
 	if err != nil {
 		return nil, ErrReadingFileFromInstance.WithParams(filePath, i.name).Wrap(err)
 	}
@@ -781,8 +1208,11 @@ func (i *Instance) ReadFileFromRunningInstance(ctx context.Context, filePath str
 // AddPolicyRule adds a policy rule to the instance
 // This function can only be called in the states 'Preparing' and 'Committed'
 func (i *Instance) AddPolicyRule(rule rbacv1.PolicyRule) error {
-	if !i.IsInState(Preparing, Committed) {
-		return ErrAddingPolicyRuleNotAllowed.WithParams(i.state.String())
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if !i.allows(ActionConfigure) {
+		return ErrAddingPolicyRuleNotAllowed.WithParams(i.getState().String())
 	}
 	i.policyRules = append(i.policyRules, rule)
 	return nil
@@ -790,8 +1220,8 @@ func (i *Instance) AddPolicyRule(rule rbacv1.PolicyRule) error {
 
 // checkStateForProbe checks if the current state is allowed for setting a probe
 func (i *Instance) checkStateForProbe() error {
-	if !i.IsInState(Preparing, Committed) {
-		return ErrSettingProbeNotAllowed.WithParams(i.state.String())
+	if !i.allows(ActionConfigure) {
+		return ErrSettingProbeNotAllowed.WithParams(i.getState().String())
 	}
 	return nil
 }
@@ -801,6 +1231,9 @@ func (i *Instance) checkStateForProbe() error {
 // See usage documentation: https://pkg.go.dev/i.K8sCli.io/api/core/v1@v0.27.3#Probe
 // This function can only be called in the states 'Preparing' and 'Committed'
 func (i *Instance) SetLivenessProbe(livenessProbe *v1.Probe) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
 	if err := i.checkStateForProbe(); err != nil {
 		return err
 	}
@@ -814,6 +1247,9 @@ func (i *Instance) SetLivenessProbe(livenessProbe *v1.Probe) error {
 // See usage documentation: https://pkg.go.dev/i.K8sCli.io/api/core/v1@v0.27.3#Probe
 // This function can only be called in the states 'Preparing' and 'Committed'
 func (i *Instance) SetReadinessProbe(readinessProbe *v1.Probe) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
 	if err := i.checkStateForProbe(); err != nil {
 		return err
 	}
@@ -827,6 +1263,9 @@ func (i *Instance) SetReadinessProbe(readinessProbe *v1.Probe) error {
 // See usage documentation: https://pkg.go.dev/i.K8sCli.io/api/core/v1@v0.27.3#Probe
 // This function can only be called in the states 'Preparing' and 'Committed'
 func (i *Instance) SetStartupProbe(startupProbe *v1.Probe) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
 	if err := i.checkStateForProbe(); err != nil {
 		return err
 	}
@@ -838,9 +1277,16 @@ func (i *Instance) SetStartupProbe(startupProbe *v1.Probe) error {
 // AddSidecar adds a sidecar to the instance
 // This function can only be called in the state 'Preparing' or 'Committed'
 func (i *Instance) AddSidecar(sidecar *Instance) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	return i.addSidecar(sidecar)
+}
 
-	if !i.IsInState(Preparing, Committed) {
-		return ErrAddingSidecarNotAllowed.WithParams(i.state.String())
+// addSidecar adds a sidecar to the instance, the caller must hold the lock of the instance
+func (i *Instance) addSidecar(sidecar *Instance) error {
+	if !i.allows(ActionConfigure) {
+		return ErrAddingSidecarNotAllowed.WithParams(i.getState().String())
 	}
 	if sidecar == nil {
 		return ErrSidecarIsNil
@@ -848,7 +1294,11 @@ func (i *Instance) AddSidecar(sidecar *Instance) error {
 	if sidecar == i {
 		return ErrSidecarCannotBeSameInstance
 	}
-	if sidecar.state != Committed {
+
+	sidecar.mu.Lock()
+	defer sidecar.mu.Unlock()
+
+	if !sidecar.IsInState(Committed) {
 		return ErrSidecarNotCommitted.WithParams(sidecar.name)
 	}
 	if i.isSidecar {
@@ -868,6 +1318,9 @@ func (i *Instance) AddSidecar(sidecar *Instance) error {
 // SetOtelCollectorVersion sets the OpenTelemetry collector version for the instance
 // This function can only be called in the state 'Preparing' or 'Committed'
 func (i *Instance) SetOtelCollectorVersion(version string) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
 	if err := i.validateStateForObsy("OpenTelemetry collector version"); err != nil {
 		return err
 	}
@@ -876,9 +1329,32 @@ func (i *Instance) SetOtelCollectorVersion(version string) error {
 	return nil
 }
 
+// SetOtelCollectorResources sets the resources requested by the OpenTelemetry collector sidecar and its limits,
+// 100m of CPU and 100Mi of memory limited to 200Mi by default
+// The collector is throttled above its CPU limit, so that it does not starve the main container under load.
+// This function can only be called in the state 'Preparing' or 'Committed'
+func (i *Instance) SetOtelCollectorResources(request, limit Resources) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if err := i.validateStateForObsy("OpenTelemetry collector resources"); err != nil {
+		return err
+	}
+	if err := validateResources(request, limit); err != nil {
+		return err
+	}
+	i.obsyConfig.otelCollectorRequest = request
+	i.obsyConfig.otelCollectorLimit = limit
+	logrus.Debugf("Set OpenTelemetry collector resources to '%s' and limit to '%s' for instance '%s'", request, limit, i.name)
+	return nil
+}
+
 // SetOtelEndpoint sets the OpenTelemetry endpoint for the instance
 // This function can only be called in the state 'Preparing' or 'Committed'
 func (i *Instance) SetOtelEndpoint(port int) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
 	if err := i.validateStateForObsy("OpenTelemetry endpoint"); err != nil {
 		return err
 	}
@@ -890,6 +1366,9 @@ func (i *Instance) SetOtelEndpoint(port int) error {
 // SetPrometheusEndpoint sets the Prometheus endpoint for the instance
 // This function can only be called in the state 'Preparing' or 'Committed'
 func (i *Instance) SetPrometheusEndpoint(port int, jobName, scapeInterval string) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
 	if err := i.validateStateForObsy("Prometheus endpoint"); err != nil {
 		return err
 	}
@@ -900,9 +1379,76 @@ func (i *Instance) SetPrometheusEndpoint(port int, jobName, scapeInterval string
 	return nil
 }
 
+// EnableOtelHostMetrics collects the CPU, memory, load, disk, filesystem and network metrics of the node of the
+// instance every interval, 30s if zero, with the hostmetrics receiver of the OpenTelemetry collector sidecar, so
+// that the node level context is exported along with the metrics of the instance
+// The metrics are read from the /proc and /sys of the sidecar container: the CPU, memory, load and disk ones are the
+// ones of the node, while the network and filesystem ones are the ones of the pod.
+// This function can only be called in the state 'Preparing' or 'Committed'
+func (i *Instance) EnableOtelHostMetrics(interval time.Duration) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if err := i.validateStateForObsy("OpenTelemetry host metrics"); err != nil {
+		return err
+	}
+	if interval <= 0 {
+		interval = defaultOtelCollectionInterval
+	}
+	i.obsyConfig.hostMetricsInterval = interval
+	logrus.Debugf("Enabled OpenTelemetry host metrics every '%s' for instance '%s'", interval, i.name)
+	return nil
+}
+
+// EnableOtelKubeletStats collects the CPU, memory, filesystem and network usage of the node, the pods and the
+// containers reported by the kubelet of the node of the instance every interval, 30s if zero, with the kubeletstats
+// receiver of the OpenTelemetry collector sidecar
+// The service account of the instance is granted to read the stats of the nodes with a ClusterRole and a
+// ClusterRoleBinding, created when the instance is started and deleted when it is stopped.
+// This function can only be called in the state 'Preparing' or 'Committed'
+func (i *Instance) EnableOtelKubeletStats(interval time.Duration) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if err := i.validateStateForObsy("OpenTelemetry kubelet stats"); err != nil {
+		return err
+	}
+	if interval <= 0 {
+		interval = defaultOtelCollectionInterval
+	}
+	i.obsyConfig.kubeletStatsInterval = interval
+	logrus.Debugf("Enabled OpenTelemetry kubelet stats every '%s' for instance '%s'", interval, i.name)
+	return nil
+}
+
+// SetLogsCollection tails the log files of the instance matching the given paths, e.g. '/var/log/app/*.log', with
+// the filelog receiver of the OpenTelemetry collector sidecar and ships them with the OTLP exporters, so that the
+// logs can be correlated with the traces and metrics of the instance
+// The directories of the paths are emptyDir volumes shared by the instance and the sidecar, so they must not be
+// in a volume of the instance and their content at the start of the instance is not kept.
+// This function can only be called in the state 'Preparing' or 'Committed'
+func (i *Instance) SetLogsCollection(paths []string, parser OtelLogParser) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if err := i.validateStateForObsy("logs collection"); err != nil {
+		return err
+	}
+	if err := validateLogsCollection(paths, parser); err != nil {
+		return err
+	}
+	i.obsyConfig.logsPaths = paths
+	i.obsyConfig.logsParser = parser
+	logrus.Debugf("Set logs collection of '%s' for instance '%s'", strings.Join(paths, ", "), i.name)
+	return nil
+}
+
 // SetJaegerEndpoint sets the Jaeger endpoint for the instance
 // This function can only be called in the state 'Preparing' or 'Committed'
 func (i *Instance) SetJaegerEndpoint(grpcPort, thriftCompactPort, thriftHttpPort int) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
 	if err := i.validateStateForObsy("Jaeger endpoint"); err != nil {
 		return err
 	}
@@ -916,6 +1462,9 @@ func (i *Instance) SetJaegerEndpoint(grpcPort, thriftCompactPort, thriftHttpPort
 // SetOtlpExporter sets the OTLP exporter for the instance
 // This function can only be called in the state 'Preparing' or 'Committed'
 func (i *Instance) SetOtlpExporter(endpoint, username, password string) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
 	if err := i.validateStateForObsy("OTLP exporter"); err != nil {
 		return err
 	}
@@ -929,6 +1478,9 @@ func (i *Instance) SetOtlpExporter(endpoint, username, password string) error {
 // SetJaegerExporter sets the Jaeger exporter for the instance
 // This function can only be called in the state 'Preparing' or 'Committed'
 func (i *Instance) SetJaegerExporter(endpoint string) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
 	if err := i.validateStateForObsy("Jaeger exporter"); err != nil {
 		return err
 	}
@@ -940,6 +1492,9 @@ func (i *Instance) SetJaegerExporter(endpoint string) error {
 // SetPrometheusExporter sets the Prometheus exporter for the instance
 // This function can only be called in the state 'Preparing' or 'Committed'
 func (i *Instance) SetPrometheusExporter(endpoint string) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
 	if err := i.validateStateForObsy("Prometheus exporter"); err != nil {
 		return err
 	}
@@ -951,6 +1506,9 @@ func (i *Instance) SetPrometheusExporter(endpoint string) error {
 // SetPrometheusRemoteWriteExporter sets the Prometheus remote write exporter for the instance
 // This function can only be called in the state 'Preparing' or 'Committed'
 func (i *Instance) SetPrometheusRemoteWriteExporter(endpoint string) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
 	if err := i.validateStateForObsy("Prometheus remote write exporter"); err != nil {
 		return err
 	}
@@ -959,11 +1517,92 @@ func (i *Instance) SetPrometheusRemoteWriteExporter(endpoint string) error {
 	return nil
 }
 
+// SetOtelExporterTLS sets the TLS settings of the exporter of the given type set with SetOtlpExporter,
+// SetJaegerExporter or SetPrometheusRemoteWriteExporter, e.g. a CA certificate or a client certificate, the
+// certificates are mounted into the collector sidecar
+// The Jaeger and Prometheus remote write exporters do not use TLS by default.
+// This function can only be called in the state 'Preparing' or 'Committed'
+func (i *Instance) SetOtelExporterTLS(exporterType OtelExporterType, tls OtelTLS) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if err := i.validateStateForObsy("OpenTelemetry exporter TLS"); err != nil {
+		return err
+	}
+	switch exporterType {
+	case OtelExporterOTLPHTTP, OtelExporterJaeger, OtelExporterPrometheusRemoteWrite:
+	default:
+		return ErrInvalidOtelExporter.WithParams(exporterType, "TLS can only be set for the otlphttp, jaeger and prometheusremotewrite exporters")
+	}
+	if err := tls.validate(); err != nil {
+		return ErrInvalidOtelExporter.WithParams(exporterType, err.Error())
+	}
+	if i.obsyConfig.exporterTLS == nil {
+		i.obsyConfig.exporterTLS = make(map[OtelExporterType]OtelTLS)
+	}
+	i.obsyConfig.exporterTLS[exporterType] = tls
+	logrus.Debugf("Set TLS of OpenTelemetry exporter '%s' for instance '%s'", exporterType, i.name)
+	return nil
+}
+
+// AddOtelExporter adds an exporter to the OpenTelemetry collector of the instance, with its own authentication
+// and TLS configuration, and routes the given signals to it
+// e.g. to send the traces to Jaeger and the metrics to two Prometheus remote writes.
+// This function can only be called in the state 'Preparing' or 'Committed'
+func (i *Instance) AddOtelExporter(exporter OtelExporter) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if err := i.validateStateForObsy("OpenTelemetry exporter"); err != nil {
+		return err
+	}
+	if err := exporter.validate(); err != nil {
+		return err
+	}
+	for _, e := range i.obsyConfig.exporters {
+		if e.Name == exporter.Name {
+			return ErrOtelExporterAlreadyExists.WithParams(exporter.Name, i.name)
+		}
+	}
+	i.obsyConfig.exporters = append(i.obsyConfig.exporters, exporter)
+	logrus.Debugf("Added OpenTelemetry exporter '%s' to '%s' for instance '%s'", exporter.id(), exporter.Endpoint, i.name)
+	return nil
+}
+
+// SetRestartPolicy sets the restart policy of the containers of the instance, Always by default
+// With Always, the instance is deployed as a ReplicaSet that recreates its pod if it is deleted.
+// With Never or OnFailure, it is deployed as a single pod, so exited containers stay dead and
+// their exit code can be inspected. The crash-loop backoff between restarts is applied by the kubelet
+// and cannot be configured per pod.
+// This function can only be called in the state 'Preparing' or 'Committed'
+func (i *Instance) SetRestartPolicy(policy v1.RestartPolicy) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if !i.allows(ActionConfigure) {
+		return ErrSettingRestartPolicyNotAllowed.WithParams(i.getState().String())
+	}
+	if i.isSidecar {
+		return ErrSettingRestartPolicyForSidecar
+	}
+	switch policy {
+	case v1.RestartPolicyAlways, v1.RestartPolicyOnFailure, v1.RestartPolicyNever:
+	default:
+		return ErrInvalidRestartPolicy.WithParams(policy)
+	}
+	i.restartPolicy = policy
+	logrus.Debugf("Set restart policy to '%s' for instance '%s'", policy, i.name)
+	return nil
+}
+
 // SetPrivileged sets the privileged status for the instance
 // This function can only be called in the state 'Preparing' or 'Committed'
 func (i *Instance) SetPrivileged(privileged bool) error {
-	if !i.IsInState(Preparing, Committed) {
-		return ErrSettingPrivilegedNotAllowed.WithParams(i.state.String())
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if !i.allows(ActionConfigure) {
+		return ErrSettingPrivilegedNotAllowed.WithParams(i.getState().String())
 	}
 	i.securityContext.privileged = privileged
 	logrus.Debugf("Set privileged to '%t' for instance '%s'", privileged, i.name)
@@ -973,8 +1612,11 @@ func (i *Instance) SetPrivileged(privileged bool) error {
 // AddCapability adds a capability to the instance
 // This function can only be called in the state 'Preparing' or 'Committed'
 func (i *Instance) AddCapability(capability string) error {
-	if !i.IsInState(Preparing, Committed) {
-		return ErrAddingCapabilityNotAllowed.WithParams(i.state.String())
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if !i.allows(ActionConfigure) {
+		return ErrAddingCapabilityNotAllowed.WithParams(i.getState().String())
 	}
 	i.securityContext.capabilitiesAdd = append(i.securityContext.capabilitiesAdd, capability)
 	logrus.Debugf("Added capability '%s' to instance '%s'", capability, i.name)
@@ -984,8 +1626,11 @@ func (i *Instance) AddCapability(capability string) error {
 // AddCapabilities adds multiple capabilities to the instance
 // This function can only be called in the state 'Preparing' or 'Committed'
 func (i *Instance) AddCapabilities(capabilities []string) error {
-	if !i.IsInState(Preparing, Committed) {
-		return ErrAddingCapabilitiesNotAllowed.WithParams(i.state.String())
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if !i.allows(ActionConfigure) {
+		return ErrAddingCapabilitiesNotAllowed.WithParams(i.getState().String())
 	}
 	for _, capability := range capabilities {
 		i.securityContext.capabilitiesAdd = append(i.securityContext.capabilitiesAdd, capability)
@@ -1006,13 +1651,26 @@ func (i *Instance) StartAsync(ctx context.Context) error {
 
 // StartWithoutWait starts the instance without waiting for it to be ready
 // This function can only be called in the state 'Committed' or 'Stopped'
-func (i *Instance) StartWithoutWait(ctx context.Context) error {
-	if !i.IsInState(Committed, Stopped) {
-		return ErrStartingNotAllowed.WithParams(i.state.String())
+func (i *Instance) StartWithoutWait(ctx context.Context) (err error) {
+	defer i.recordOperation(report.OperationStart, time.Now(), "deployed without waiting", &err)
+	defer i.recordReplayable(recording.Operation{Kind: recording.KindStart}, time.Now(), &err)
+	if err := i.runBeforeStartHooks(ctx); err != nil {
+		return err
 	}
-	if err := applyFunctionToInstances(i.sidecars, func(sidecar Instance) error {
-		if !sidecar.IsInState(Committed, Stopped) {
-			return ErrStartingNotAllowedForSidecar.WithParams(sidecar.name, sidecar.state.String())
+	deployCtx, span := budget.Begin(ctx, budget.PhaseDeploy)
+	return span.End(i.startWithoutWait(deployCtx))
+}
+
+func (i *Instance) startWithoutWait(ctx context.Context) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if !i.allows(ActionStart) {
+		return ErrStartingNotAllowed.WithParams(i.getState().String())
+	}
+	if err := applyFunctionToInstances(i.sidecars, func(sidecar *Instance) error {
+		if !sidecar.allows(ActionStart) {
+			return ErrStartingNotAllowedForSidecar.WithParams(sidecar.name, sidecar.getState().String())
 		}
 		return nil
 	}); err != nil {
@@ -1021,10 +1679,13 @@ func (i *Instance) StartWithoutWait(ctx context.Context) error {
 	if i.isSidecar {
 		return ErrStartingSidecarNotAllowed
 	}
+	if err := i.validateDNS(); err != nil {
+		return err
+	}
 
-	if i.state == Committed {
+	if i.IsInState(Committed) {
 		// deploy otel collector if observability is enabled
-		if i.isObservabilityEnabled() {
+		if i.isPodObservabilityEnabled() {
 			if err := i.addOtelCollectorSidecar(ctx); err != nil {
 				return ErrAddingOtelCollectorSidecar.WithParams(i.k8sName).Wrap(err)
 			}
@@ -1036,89 +1697,153 @@ func (i *Instance) StartWithoutWait(ctx context.Context) error {
 			}
 		}
 
-		if err := i.deployResources(ctx); err != nil {
-			return ErrDeployingResourcesForInstance.WithParams(i.k8sName).Wrap(err)
+		if i.apiFaultsEnabled {
+			if err := i.addAPIProxySidecar(ctx); err != nil {
+				return err
+			}
+		}
+	}
+
+	// all resources created from here on are rolled back if the instance fails to start,
+	// the returned *ResourceDeploymentError describes what was created, what failed and why
+	tracker := newResourceTracker(i.k8sName)
+	defer lockInstances(i.sidecars)()
+	if err := i.resolveImageCmd(ctx); err != nil {
+		return err
+	}
+	if i.IsInState(Committed) {
+		i.deployResources(ctx, tracker)
+		for _, sidecar := range i.sidecars {
+			sidecar.deployResources(ctx, tracker)
 		}
-		if err := applyFunctionToInstances(i.sidecars, func(sidecar Instance) error {
-			return sidecar.deployResources(ctx)
-		}); err != nil {
-			return ErrDeployingResourcesForSidecars.WithParams(i.k8sName).Wrap(err)
+		if tracker.hasFailures() {
+			return tracker.rollback(ctx)
 		}
 	}
 
-	err := i.deployPod(ctx)
-	if err != nil {
-		return ErrDeployingPodForInstance.WithParams(i.k8sName).Wrap(err)
+	if err := i.deployPod(ctx, tracker); err != nil {
+		return err
+	}
+	if err := i.transition(Started); err != nil {
+		return err
 	}
-	i.state = Started
 	setStateForSidecars(i.sidecars, Started)
-	logrus.Debugf("Set state of instance '%s' to '%s'", i.k8sName, i.state.String())
+	i.startReadinessGates(ctx)
 
 	return nil
 }
 
 // Start starts the instance and waits for it to be ready
+// The deployment and the wait are accounted in the deploy and readiness phases of the budget of the context, see
+// budget.Begin.
 // This function can only be called in the state 'Committed' and 'Stopped'
-func (i *Instance) Start(ctx context.Context) error {
-	if err := i.StartWithoutWait(ctx); err != nil {
+func (i *Instance) Start(ctx context.Context) (err error) {
+	defer i.recordOperation(report.OperationStart, time.Now(), "", &err)
+	defer i.recordReplayable(recording.Operation{Kind: recording.KindStart}, time.Now(), &err)
+
+	if err := i.runBeforeStartHooks(ctx); err != nil {
+		return err
+	}
+	deployStart := time.Now()
+	deployCtx, span := budget.Begin(ctx, budget.PhaseDeploy)
+	if err := span.End(i.startWithoutWait(deployCtx)); err != nil {
 		return err
 	}
 
-	err := i.WaitInstanceIsRunning(ctx)
+	readyCtx, span := budget.Begin(ctx, budget.PhaseReadiness)
+	err = i.WaitInstanceIsRunning(readyCtx)
+	i.recordStartPhases(ctx, deployStart, err)
 	if err != nil {
-		return ErrWaitingForInstanceRunning.WithParams(i.k8sName).Wrap(err)
+		return span.End(ErrWaitingForInstanceRunning.WithParams(i.k8sName).Wrap(err))
 	}
+	span.End(nil)
 
-	return nil
+	return i.runLifecycleHooks(ctx, AfterStart)
 }
 
 // IsRunning returns true if the instance is running
-// This function can only be called in the state 'Started'
+// The instances with a plain pod count as running once they succeeded, and return an error once they failed.
+// This function can only be called in the states 'Started' and 'Stopped'
 func (i *Instance) IsRunning(ctx context.Context) (bool, error) {
-	if !i.IsInState(Started, Stopped) {
-		return false, ErrCheckingIfInstanceRunningNotAllowed.WithParams(i.state.String())
+	if !i.allows(ActionCheckRunning) {
+		return false, ErrCheckingIfInstanceRunningNotAllowed.WithParams(i.getState().String())
 	}
 
-	return i.K8sCli.IsReplicaSetRunning(ctx, i.k8sName)
+	if i.usesReplicaSet() {
+		return i.K8sCli.IsReplicaSetRunning(ctx, i.k8sName)
+	}
+	// a pod that is not restarted is running until its containers exit, after that it stays finished so that its
+	// exit code can be inspected, unless it failed, which is not waited for
+	pod, err := i.K8sCli.GetPod(ctx, i.k8sName)
+	if err != nil {
+		return false, err
+	}
+	switch pod.Status.Phase {
+	case v1.PodSucceeded:
+		return true, nil
+	case v1.PodFailed:
+		reason := pod.Status.Reason
+		if termination := i.terminationFromPod(pod); termination != nil {
+			reason = fmt.Sprintf("%s, exit code %d", termination.Reason, termination.ExitCode)
+		}
+		return false, ErrInstanceFailed.WithParams(i.k8sName, reason)
+	case v1.PodRunning:
+		for _, cs := range pod.Status.ContainerStatuses {
+			if !cs.Ready {
+				return false, nil
+			}
+		}
+		return readinessGatesPassed(pod), nil
+	}
+	return false, nil
 }
 
 // WaitInstanceIsRunning waits until the instance is running
 // This function can only be called in the state 'Started'
 func (i *Instance) WaitInstanceIsRunning(ctx context.Context) error {
-	if !i.IsInState(Started) {
-		return ErrWaitingForInstanceNotAllowed.WithParams(i.state.String())
+	if !i.allows(ActionOperate) {
+		return ErrWaitingForInstanceNotAllowed.WithParams(i.getState().String())
 	}
-	timeout := time.After(1 * time.Minute)
-	tick := time.NewTicker(1 * time.Second)
 
-	for {
-		select {
-		case <-timeout:
-			return ErrWaitingForInstanceTimeout.WithParams(i.k8sName)
-		case <-tick.C:
-			running, err := i.IsRunning(ctx)
-			if err != nil {
-				return ErrCheckingIfInstanceRunning.WithParams(i.k8sName).Wrap(err)
-			}
-			if running {
-				return nil
-			}
+	err := retry.Until(ctx, waitRunningPolicy, func(ctx context.Context) (bool, error) {
+		running, err := i.IsRunning(ctx)
+		if err != nil {
+			return false, ErrCheckingIfInstanceRunning.WithParams(i.k8sName).Wrap(err)
 		}
+		return running, nil
+	})
+	if errors.Is(err, retry.ErrContextDone) {
+		return ErrWaitingForInstanceTimeout.WithParams(i.k8sName).Wrap(err)
 	}
+	return err
 }
 
 // DisableNetwork disables the network of the instance
 // This does not apply to executor instances
-// This function can only be called in the state 'Started'
-func (i *Instance) DisableNetwork(ctx context.Context) error {
-	if !i.IsInState(Started) {
-		return ErrDisablingNetworkNotAllowed.WithParams(i.state.String())
+// The network policy would conflict with the policies of the service mesh, so it fails under a mesh.
+// This function can only be called in the states 'Started' and 'Attached'
+func (i *Instance) DisableNetwork(ctx context.Context) (err error) {
+	defer i.annotateFault(ctx, annotation.KindNetworkDisabled, time.Now(), "", &err)
+	defer i.recordReplayable(recording.Operation{Kind: recording.KindNetworkDisabled}, time.Now(), &err)
+
+	if !i.allows(ActionShapeNetwork) {
+		return ErrDisablingNetworkNotAllowed.WithParams(i.getState().String())
+	}
+	if i.ServiceMesh != system.ServiceMeshNone {
+		return ErrDisablingNetworkWithServiceMesh.WithParams(i.k8sName, i.ServiceMesh)
 	}
 	executorSelectorMap := map[string]string{
 		"knuu.sh/type": ExecutorInstance.String(),
 	}
+	if i.ExternalEgressBlocked(ctx) {
+		return ErrDisablingNetworkWithExternalEgressBlocked.WithParams(i.k8sName)
+	}
 
-	err := i.K8sCli.CreateNetworkPolicy(ctx, i.k8sName, i.getLabels(), executorSelectorMap, executorSelectorMap)
+	if !i.ownsNetworkPolicy(ctx) {
+		return ErrNetworkPolicyNotOwned.WithParams(i.networkPolicyName(), i.k8sName)
+	}
+
+	err = i.K8sCli.CreateNetworkPolicyWithLabels(ctx, i.networkPolicyName(), i.getLabels(), i.podSelector(), executorSelectorMap, executorSelectorMap)
 	if err != nil {
 		return ErrDisablingNetwork.WithParams(i.k8sName).Wrap(err)
 	}
@@ -1128,14 +1853,22 @@ func (i *Instance) DisableNetwork(ctx context.Context) error {
 // SetBandwidthLimit sets the bandwidth limit of the instance
 // bandwidth limit in bps (e.g. 1000 for 1Kbps)
 // Currently, only one of bandwidth, jitter, latency or packet loss can be set
-// This function can only be called in the state 'Commited'
-func (i *Instance) SetBandwidthLimit(limit int64) error {
-	if !i.IsInState(Started) {
-		return ErrSettingBandwidthLimitNotAllowed.WithParams(i.state.String())
+// The bandwidth can only be limited if BitTwister runs privileged, see BitTwister.SetPrivileged
+// This function can only be called in the state 'Started'
+func (i *Instance) SetBandwidthLimit(limit int64) (err error) {
+	defer i.recordOperation(report.OperationFault, time.Now(), fmt.Sprintf("bandwidth limit %d bps", limit), &err)
+	defer i.annotateFault(context.Background(), annotation.KindBandwidth, time.Now(), fmt.Sprintf("%d bps", limit), &err)
+	defer i.recordReplayable(recording.Operation{Kind: recording.KindBandwidth, Bandwidth: limit}, time.Now(), &err)
+
+	if !i.allows(ActionShapeNetwork) {
+		return ErrSettingBandwidthLimitNotAllowed.WithParams(i.getState().String())
 	}
 	if !i.BitTwister.Enabled() {
 		return ErrSettingBandwidthLimitNotAllowedBitTwister
 	}
+	if !i.BitTwister.Privileged() {
+		return ErrSettingBandwidthLimitNotPrivileged.WithParams(i.k8sName)
+	}
 
 	// We first need to stop it, otherwise we get an error
 	if err := i.BitTwister.Client().BandwidthStop(); err != nil {
@@ -1146,7 +1879,7 @@ func (i *Instance) SetBandwidthLimit(limit int64) error {
 		}
 	}
 
-	err := i.BitTwister.Client().BandwidthStart(sdk.BandwidthStartRequest{
+	err = i.BitTwister.Client().BandwidthStart(sdk.BandwidthStartRequest{
 		NetworkInterfaceName: i.BitTwister.NetworkInterface(),
 		Limit:                limit,
 	})
@@ -1162,10 +1895,18 @@ func (i *Instance) SetBandwidthLimit(limit int64) error {
 // latency in ms (e.g. 1000 for 1s)
 // jitter in ms (e.g. 1000 for 1s)
 // Currently, only one of bandwidth, jitter, latency or packet loss can be set
-// This function can only be called in the state 'Commited'
-func (i *Instance) SetLatencyAndJitter(latency, jitter int64) error {
-	if !i.IsInState(Started) {
-		return ErrSettingLatencyJitterNotAllowed.WithParams(i.state.String())
+// This function can only be called in the state 'Started'
+func (i *Instance) SetLatencyAndJitter(latency, jitter int64) (err error) {
+	defer i.recordOperation(report.OperationFault, time.Now(), fmt.Sprintf("latency %dms, jitter %dms", latency, jitter), &err)
+	defer i.annotateFault(context.Background(), annotation.KindLatency, time.Now(), fmt.Sprintf("%dms, jitter %dms", latency, jitter), &err)
+	defer i.recordReplayable(recording.Operation{
+		Kind:    recording.KindLatency,
+		Latency: time.Duration(latency) * time.Millisecond,
+		Jitter:  time.Duration(jitter) * time.Millisecond,
+	}, time.Now(), &err)
+
+	if !i.allows(ActionShapeNetwork) {
+		return ErrSettingLatencyJitterNotAllowed.WithParams(i.getState().String())
 	}
 	if !i.BitTwister.Enabled() {
 		return ErrSettingLatencyJitterNotAllowedBitTwister
@@ -1180,7 +1921,7 @@ func (i *Instance) SetLatencyAndJitter(latency, jitter int64) error {
 		}
 	}
 
-	err := i.BitTwister.Client().LatencyStart(sdk.LatencyStartRequest{
+	err = i.BitTwister.Client().LatencyStart(sdk.LatencyStartRequest{
 		NetworkInterfaceName: i.BitTwister.NetworkInterface(),
 		Latency:              latency,
 		Jitter:               jitter,
@@ -1196,14 +1937,22 @@ func (i *Instance) SetLatencyAndJitter(latency, jitter int64) error {
 // SetPacketLoss sets the packet loss of the instance
 // packet loss in percent (e.g. 10 for 10%)
 // Currently, only one of bandwidth, jitter, latency or packet loss can be set
-// This function can only be called in the state 'Commited'
-func (i *Instance) SetPacketLoss(packetLoss int32) error {
-	if !i.IsInState(Started) {
-		return ErrSettingPacketLossNotAllowed.WithParams(i.state.String())
+// The packets can only be dropped if BitTwister runs privileged, see BitTwister.SetPrivileged
+// This function can only be called in the state 'Started'
+func (i *Instance) SetPacketLoss(packetLoss int32) (err error) {
+	defer i.recordOperation(report.OperationFault, time.Now(), fmt.Sprintf("packet loss %d%%", packetLoss), &err)
+	defer i.annotateFault(context.Background(), annotation.KindPacketLoss, time.Now(), fmt.Sprintf("%d%%", packetLoss), &err)
+	defer i.recordReplayable(recording.Operation{Kind: recording.KindPacketLoss, PacketLoss: packetLoss}, time.Now(), &err)
+
+	if !i.allows(ActionShapeNetwork) {
+		return ErrSettingPacketLossNotAllowed.WithParams(i.getState().String())
 	}
 	if !i.BitTwister.Enabled() {
 		return ErrSettingPacketLossNotAllowedBitTwister
 	}
+	if !i.BitTwister.Privileged() {
+		return ErrSettingPacketLossNotPrivileged.WithParams(i.k8sName)
+	}
 
 	// We first need to stop it, otherwise we get an error
 	if err := i.BitTwister.Client().PacketlossStop(); err != nil {
@@ -1214,7 +1963,7 @@ func (i *Instance) SetPacketLoss(packetLoss int32) error {
 		}
 	}
 
-	err := i.BitTwister.Client().PacketlossStart(sdk.PacketLossStartRequest{
+	err = i.BitTwister.Client().PacketlossStart(sdk.PacketLossStartRequest{
 		NetworkInterfaceName: i.BitTwister.NetworkInterface(),
 		PacketLossRate:       packetLoss,
 	})
@@ -1227,13 +1976,20 @@ func (i *Instance) SetPacketLoss(packetLoss int32) error {
 }
 
 // EnableNetwork enables the network of the instance
-// This function can only be called in the state 'Started'
-func (i *Instance) EnableNetwork(ctx context.Context) error {
-	if !i.IsInState(Started) {
-		return ErrEnablingNetworkNotAllowed.WithParams(i.state.String())
+// This function can only be called in the states 'Started' and 'Attached'
+func (i *Instance) EnableNetwork(ctx context.Context) (err error) {
+	defer i.annotateFault(ctx, annotation.KindNetworkEnabled, time.Now(), "", &err)
+	defer i.recordReplayable(recording.Operation{Kind: recording.KindNetworkEnabled}, time.Now(), &err)
+
+	if !i.allows(ActionShapeNetwork) {
+		return ErrEnablingNetworkNotAllowed.WithParams(i.getState().String())
+	}
+
+	if !i.ownsNetworkPolicy(ctx) {
+		return ErrNetworkPolicyNotOwned.WithParams(i.networkPolicyName(), i.k8sName)
 	}
 
-	err := i.K8sCli.DeleteNetworkPolicy(ctx, i.k8sName)
+	err = i.K8sCli.DeleteNetworkPolicy(ctx, i.networkPolicyName())
 	if err != nil {
 		return ErrEnablingNetwork.WithParams(i.k8sName).Wrap(err)
 	}
@@ -1241,49 +1997,85 @@ func (i *Instance) EnableNetwork(ctx context.Context) error {
 }
 
 // NetworkIsDisabled returns true if the network of the instance is disabled
-// This function can only be called in the state 'Started'
+// This function can only be called in the states 'Started' and 'Attached'
 func (i *Instance) NetworkIsDisabled(ctx context.Context) (bool, error) {
-	if !i.IsInState(Started) {
-		return false, ErrCheckingIfNetworkDisabledNotAllowed.WithParams(i.state.String())
+	if !i.allows(ActionShapeNetwork) {
+		return false, ErrCheckingIfNetworkDisabledNotAllowed.WithParams(i.getState().String())
+	}
+
+	return i.K8sCli.NetworkPolicyExists(ctx, i.networkPolicyName()) && i.ownsNetworkPolicy(ctx), nil
+}
+
+// networkPolicyName returns the name of the network policy disabling the network of the instance, the handles on
+// workloads not created by knuu do not name it after the workload, which may have a policy of the same name
+func (i *Instance) networkPolicyName() string {
+	if i.attached != nil {
+		return attachedNetworkPolicyPrefix + i.k8sName
 	}
+	return i.k8sName
+}
 
-	return i.K8sCli.NetworkPolicyExists(ctx, i.k8sName), nil
+// ownsNetworkPolicy returns false if the network policy of the instance exists and was not created by knuu
+func (i *Instance) ownsNetworkPolicy(ctx context.Context) bool {
+	name := i.networkPolicyName()
+	if !i.K8sCli.NetworkPolicyExists(ctx, name) {
+		return true
+	}
+	policy, err := i.K8sCli.GetNetworkPolicy(ctx, name)
+	if err != nil {
+		return false
+	}
+	return policy.Labels[managedByLabel] == managedByKnuu
 }
 
 // WaitInstanceIsStopped waits until the instance is not running anymore
 // This function can only be called in the state 'Stopped'
 func (i *Instance) WaitInstanceIsStopped(ctx context.Context) error {
-	if !i.IsInState(Stopped) {
-		return ErrWaitingForInstanceStoppedNotAllowed.WithParams(i.state.String())
+	if !i.allows(ActionWaitStopped) {
+		return ErrWaitingForInstanceStoppedNotAllowed.WithParams(i.getState().String())
 	}
-	for {
+
+	return retry.Until(ctx, waitStoppedPolicy, func(ctx context.Context) (bool, error) {
 		running, err := i.IsRunning(ctx)
 		if !running {
-			break
+			return true, nil
 		}
 		if err != nil {
-			return ErrCheckingIfInstanceStopped.WithParams(i.k8sName).Wrap(err)
+			return false, ErrCheckingIfInstanceStopped.WithParams(i.k8sName).Wrap(err)
 		}
-	}
-
-	return nil
+		return false, nil
+	})
 }
 
 // Stop stops the instance
 // CAUTION: In order to keep data of the instance, you need to use AddVolume() before.
 // This function can only be called in the state 'Started'
-func (i *Instance) Stop(ctx context.Context) error {
-	if !i.IsInState(Started) {
-		return ErrStoppingNotAllowed.WithParams(i.state.String())
+func (i *Instance) Stop(ctx context.Context) (err error) {
+	defer i.recordOperation(report.OperationStop, time.Now(), "", &err)
+	defer i.annotateFault(ctx, annotation.KindStop, time.Now(), "", &err)
+	defer i.recordReplayable(recording.Operation{Kind: recording.KindStop}, time.Now(), &err)
+
+	if i.IsInState(Started) {
+		if err := i.runLifecycleHooks(ctx, BeforeStop); err != nil {
+			return err
+		}
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if !i.allows(ActionStop) {
+		return ErrStoppingNotAllowed.WithParams(i.getState().String())
 
 	}
 
 	if err := i.destroyPod(ctx); err != nil {
 		return ErrDestroyingPod.WithParams(i.k8sName).Wrap(err)
 	}
-	i.state = Stopped
+	if err := i.transition(Stopped); err != nil {
+		return err
+	}
 	setStateForSidecars(i.sidecars, Stopped)
-	logrus.Debugf("Set state of instance '%s' to '%s'", i.k8sName, i.state.String())
 
 	return nil
 }
@@ -1293,8 +2085,11 @@ func (i *Instance) Stop(ctx context.Context) error {
 // When cloning an instance that is a sidecar, the clone will be not a sidecar
 // When cloning an instance with sidecars, the sidecars will be cloned as well
 func (i *Instance) Clone() (*Instance, error) {
-	if !i.IsInState(Committed) {
-		return nil, ErrCloningNotAllowed.WithParams(i.state.String())
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if !i.allows(ActionClone) {
+		return nil, ErrCloningNotAllowed.WithParams(i.getState().String())
 	}
 
 	newK8sName, err := names.NewRandomK8(i.name)
@@ -1302,7 +2097,9 @@ func (i *Instance) Clone() (*Instance, error) {
 		return nil, ErrGeneratingK8sName.WithParams(i.name).Wrap(err)
 	}
 	// Create a new instance with the same attributes as the original instance
+	unlock := lockInstances(i.sidecars)
 	ins := i.cloneWithSuffix("")
+	unlock()
 	ins.k8sName = newK8sName
 	return ins, nil
 }
@@ -1312,8 +2109,11 @@ func (i *Instance) Clone() (*Instance, error) {
 // When cloning an instance that is a sidecar, the clone will be not a sidecar
 // When cloning an instance with sidecars, the sidecars will be cloned as well
 func (i *Instance) CloneWithName(name string) (*Instance, error) {
-	if !i.IsInState(Committed) {
-		return nil, ErrCloningNotAllowedForSidecar.WithParams(i.state.String())
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if !i.allows(ActionClone) {
+		return nil, ErrCloningNotAllowedForSidecar.WithParams(i.getState().String())
 	}
 
 	newK8sName, err := names.NewRandomK8(name)
@@ -1321,7 +2121,9 @@ func (i *Instance) CloneWithName(name string) (*Instance, error) {
 		return nil, ErrGeneratingK8sNameForSidecar.WithParams(name).Wrap(err)
 	}
 	// Create a new instance with the same attributes as the original instance
+	unlock := lockInstances(i.sidecars)
 	ins := i.cloneWithSuffix("")
+	unlock()
 	ins.name = name
 	ins.k8sName = newK8sName
 	return ins, nil
@@ -1346,18 +2148,42 @@ func (i *Instance) CustomResourceDefinitionExists(ctx context.Context, gvr *sche
 	return i.K8sCli.CustomResourceDefinitionExists(ctx, gvr), nil
 }
 
-func (i *Instance) AddHost(ctx context.Context, port int) (host string, err error) {
+// AddHost exposes the given port of the instance through the proxy and returns its URL
+// By default the port is served over plain HTTP without authentication, the options enable TLS and authentication.
+func (i *Instance) AddHost(ctx context.Context, port int, opts ...proxy.HostOption) (host string, err error) {
 	if i.Proxy == nil {
 		return "", ErrProxyNotInitialized
 	}
 
 	prefix := fmt.Sprintf("%s-%d", i.k8sName, port)
-	if err := i.Proxy.AddHost(ctx, i.k8sName, prefix, port); err != nil {
+	if err := i.Proxy.AddHost(ctx, i.k8sName, prefix, port, opts...); err != nil {
 		return "", ErrAddingToProxy.WithParams(i.k8sName).Wrap(err)
 	}
-	host, err = i.Proxy.URL(ctx, prefix)
+	if proxy.NewHostOptions(opts...).TLS != nil {
+		host, err = i.Proxy.SecureURL(ctx, prefix)
+	} else {
+		host, err = i.Proxy.URL(ctx, prefix)
+	}
 	if err != nil {
 		return "", ErrGettingProxyURL.WithParams(i.k8sName).Wrap(err)
 	}
 	return host, nil
 }
+
+// AddTCPHost exposes the given TCP port of the instance on a dedicated port of the proxy and returns its address
+// in the form host:port. The connections are forwarded as they are, e.g. for RPC or P2P endpoints.
+func (i *Instance) AddTCPHost(ctx context.Context, port int) (string, error) {
+	if i.Proxy == nil {
+		return "", ErrProxyNotInitialized
+	}
+
+	address, err := i.Proxy.AddTCPHost(ctx, i.k8sName, fmt.Sprintf("%s-%d", i.k8sName, port), port)
+	if err != nil {
+		return "", ErrAddingTCPHostToProxy.WithParams(port, i.k8sName).Wrap(err)
+	}
+
+	i.mu.Lock()
+	i.tcpHosts = true
+	i.mu.Unlock()
+	return address, nil
+}
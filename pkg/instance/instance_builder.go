@@ -0,0 +1,315 @@
+package instance
+
+import (
+	"errors"
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+
+	"github.com/celestiaorg/knuu/pkg/system"
+)
+
+// InstanceBuilder configures an instance with chained calls.
+// Errors are not returned by the calls but collected, and all of them are returned at once by Build or Commit.
+type InstanceBuilder struct {
+	name    string
+	sysDeps system.SystemDependencies
+	image   string
+	steps   []builderStep
+}
+
+type builderStep struct {
+	call string
+	fn   func(*Instance) error
+}
+
+// builderStepError is the error of a single call of an InstanceBuilder
+// The message is captured when the call fails, so that it is kept when the same error is returned by another call.
+type builderStepError struct {
+	call string
+	msg  string
+	err  error
+}
+
+func (e *builderStepError) Error() string {
+	return fmt.Sprintf("%s: %s", e.call, e.msg)
+}
+
+func (e *builderStepError) Unwrap() error {
+	return e.err
+}
+
+// NewBuilder creates a builder for an instance with the given name
+func NewBuilder(name string, sysDeps system.SystemDependencies) *InstanceBuilder {
+	return &InstanceBuilder{
+		name:    name,
+		sysDeps: sysDeps,
+	}
+}
+
+func (b *InstanceBuilder) step(call string, fn func(*Instance) error) *InstanceBuilder {
+	b.steps = append(b.steps, builderStep{call: call, fn: fn})
+	return b
+}
+
+// SetImage sets the image the instance is built from, it is required
+func (b *InstanceBuilder) SetImage(image string) *InstanceBuilder {
+	b.image = image
+	return b
+}
+
+// SetCommand sets the command to run in the instance
+func (b *InstanceBuilder) SetCommand(command ...string) *InstanceBuilder {
+	return b.step(fmt.Sprintf("SetCommand(%v)", command), func(i *Instance) error {
+		return i.SetCommand(command...)
+	})
+}
+
+// SetArgs sets the arguments passed to the instance
+func (b *InstanceBuilder) SetArgs(args ...string) *InstanceBuilder {
+	return b.step(fmt.Sprintf("SetArgs(%v)", args), func(i *Instance) error {
+		return i.SetArgs(args...)
+	})
+}
+
+// SetEntrypoint replaces the entrypoint of the image, keeping the command of the image as its arguments
+func (b *InstanceBuilder) SetEntrypoint(entrypoint ...string) *InstanceBuilder {
+	return b.step(fmt.Sprintf("SetEntrypoint(%v)", entrypoint), func(i *Instance) error {
+		return i.SetEntrypoint(entrypoint...)
+	})
+}
+
+// SetWorkingDir sets the directory the command of the instance runs in
+func (b *InstanceBuilder) SetWorkingDir(dir string) *InstanceBuilder {
+	return b.step(fmt.Sprintf("SetWorkingDir(%s)", dir), func(i *Instance) error {
+		return i.SetWorkingDir(dir)
+	})
+}
+
+// SetTTY allocates a terminal for the instance
+func (b *InstanceBuilder) SetTTY(tty bool) *InstanceBuilder {
+	return b.step(fmt.Sprintf("SetTTY(%t)", tty), func(i *Instance) error {
+		return i.SetTTY(tty)
+	})
+}
+
+// SetStdin keeps the stdin of the instance open
+func (b *InstanceBuilder) SetStdin(stdin bool) *InstanceBuilder {
+	return b.step(fmt.Sprintf("SetStdin(%t)", stdin), func(i *Instance) error {
+		return i.SetStdin(stdin)
+	})
+}
+
+// EnableShareProcessNamespace makes the instance and its sidecars share a process namespace
+func (b *InstanceBuilder) EnableShareProcessNamespace() *InstanceBuilder {
+	return b.step("EnableShareProcessNamespace()", func(i *Instance) error {
+		return i.EnableShareProcessNamespace()
+	})
+}
+
+// AddPortTCP adds a TCP port to the instance
+func (b *InstanceBuilder) AddPortTCP(port int) *InstanceBuilder {
+	return b.step(fmt.Sprintf("AddPortTCP(%d)", port), func(i *Instance) error {
+		return i.AddPortTCP(port)
+	})
+}
+
+// AddPortUDP adds a UDP port to the instance
+func (b *InstanceBuilder) AddPortUDP(port int) *InstanceBuilder {
+	return b.step(fmt.Sprintf("AddPortUDP(%d)", port), func(i *Instance) error {
+		return i.AddPortUDP(port)
+	})
+}
+
+// AddPort adds the given port to the instance
+func (b *InstanceBuilder) AddPort(port Port) *InstanceBuilder {
+	return b.step(fmt.Sprintf("AddPort(%+v)", port), func(i *Instance) error {
+		return i.AddPort(port)
+	})
+}
+
+// AddService adds an additional service to the instance
+func (b *InstanceBuilder) AddService(spec ServiceSpec) *InstanceBuilder {
+	return b.step(fmt.Sprintf("AddService(%s)", spec.Name), func(i *Instance) error {
+		return i.AddService(spec)
+	})
+}
+
+// SetEnvironmentVariable sets the given environment variable in the instance
+func (b *InstanceBuilder) SetEnvironmentVariable(key, value string) *InstanceBuilder {
+	return b.step(fmt.Sprintf("SetEnvironmentVariable(%s)", key), func(i *Instance) error {
+		return i.SetEnvironmentVariable(key, value)
+	})
+}
+
+// SetMemory sets the memory request and limit of the instance
+func (b *InstanceBuilder) SetMemory(request, limit string) *InstanceBuilder {
+	return b.step(fmt.Sprintf("SetMemory(%s, %s)", request, limit), func(i *Instance) error {
+		return i.SetMemory(request, limit)
+	})
+}
+
+// SetCPU sets the CPU request of the instance
+func (b *InstanceBuilder) SetCPU(request string) *InstanceBuilder {
+	return b.step(fmt.Sprintf("SetCPU(%s)", request), func(i *Instance) error {
+		return i.SetCPU(request)
+	})
+}
+
+// AddVolume adds a volume to the instance
+func (b *InstanceBuilder) AddVolume(path, size string) *InstanceBuilder {
+	return b.step(fmt.Sprintf("AddVolume(%s, %s)", path, size), func(i *Instance) error {
+		return i.AddVolume(path, size)
+	})
+}
+
+// AddVolumeWithOwner adds a volume to the instance with the given owner
+func (b *InstanceBuilder) AddVolumeWithOwner(path, size string, owner int64) *InstanceBuilder {
+	return b.step(fmt.Sprintf("AddVolumeWithOwner(%s, %s, %d)", path, size, owner), func(i *Instance) error {
+		return i.AddVolumeWithOwner(path, size, owner)
+	})
+}
+
+// AddFile adds a file to the instance
+func (b *InstanceBuilder) AddFile(src, dest, chown string) *InstanceBuilder {
+	return b.step(fmt.Sprintf("AddFile(%s, %s)", src, dest), func(i *Instance) error {
+		return i.AddFile(src, dest, chown)
+	})
+}
+
+// AddFolder adds a folder to the instance
+func (b *InstanceBuilder) AddFolder(src, dest, chown string) *InstanceBuilder {
+	return b.step(fmt.Sprintf("AddFolder(%s, %s)", src, dest), func(i *Instance) error {
+		return i.AddFolder(src, dest, chown)
+	})
+}
+
+// AddFileBytes adds a file with the given content to the instance
+func (b *InstanceBuilder) AddFileBytes(bytes []byte, dest, chown string) *InstanceBuilder {
+	return b.step(fmt.Sprintf("AddFileBytes(%s)", dest), func(i *Instance) error {
+		return i.AddFileBytes(bytes, dest, chown)
+	})
+}
+
+// SetUser sets the user for the instance
+func (b *InstanceBuilder) SetUser(user string) *InstanceBuilder {
+	return b.step(fmt.Sprintf("SetUser(%s)", user), func(i *Instance) error {
+		return i.SetUser(user)
+	})
+}
+
+// AddPolicyRule adds a policy rule to the instance
+func (b *InstanceBuilder) AddPolicyRule(rule rbacv1.PolicyRule) *InstanceBuilder {
+	return b.step("AddPolicyRule", func(i *Instance) error {
+		return i.AddPolicyRule(rule)
+	})
+}
+
+// SetLivenessProbe sets the liveness probe of the instance
+func (b *InstanceBuilder) SetLivenessProbe(probe *v1.Probe) *InstanceBuilder {
+	return b.step("SetLivenessProbe", func(i *Instance) error {
+		return i.SetLivenessProbe(probe)
+	})
+}
+
+// SetReadinessProbe sets the readiness probe of the instance
+func (b *InstanceBuilder) SetReadinessProbe(probe *v1.Probe) *InstanceBuilder {
+	return b.step("SetReadinessProbe", func(i *Instance) error {
+		return i.SetReadinessProbe(probe)
+	})
+}
+
+// SetStartupProbe sets the startup probe of the instance
+func (b *InstanceBuilder) SetStartupProbe(probe *v1.Probe) *InstanceBuilder {
+	return b.step("SetStartupProbe", func(i *Instance) error {
+		return i.SetStartupProbe(probe)
+	})
+}
+
+// SetPrivileged sets the privileged status for the instance
+func (b *InstanceBuilder) SetPrivileged(privileged bool) *InstanceBuilder {
+	return b.step(fmt.Sprintf("SetPrivileged(%t)", privileged), func(i *Instance) error {
+		return i.SetPrivileged(privileged)
+	})
+}
+
+// AddCapability adds a capability to the instance
+func (b *InstanceBuilder) AddCapability(capability string) *InstanceBuilder {
+	return b.step(fmt.Sprintf("AddCapability(%s)", capability), func(i *Instance) error {
+		return i.AddCapability(capability)
+	})
+}
+
+// SetRestartPolicy sets the restart policy of the containers of the instance
+func (b *InstanceBuilder) SetRestartPolicy(policy v1.RestartPolicy) *InstanceBuilder {
+	return b.step(fmt.Sprintf("SetRestartPolicy(%s)", policy), func(i *Instance) error {
+		return i.SetRestartPolicy(policy)
+	})
+}
+
+// SetDNSPolicy sets the DNS policy of the pod of the instance
+func (b *InstanceBuilder) SetDNSPolicy(policy v1.DNSPolicy) *InstanceBuilder {
+	return b.step(fmt.Sprintf("SetDNSPolicy(%s)", policy), func(i *Instance) error {
+		return i.SetDNSPolicy(policy)
+	})
+}
+
+// SetDNSConfig sets the nameservers, search domains and resolver options of the pod of the instance
+func (b *InstanceBuilder) SetDNSConfig(nameservers, searches []string, options map[string]string) *InstanceBuilder {
+	return b.step(fmt.Sprintf("SetDNSConfig(%v, %v, %v)", nameservers, searches, options), func(i *Instance) error {
+		return i.SetDNSConfig(nameservers, searches, options)
+	})
+}
+
+// SetHostNetwork makes the pod of the instance use the network namespace of its node
+func (b *InstanceBuilder) SetHostNetwork(hostNetwork bool) *InstanceBuilder {
+	return b.step(fmt.Sprintf("SetHostNetwork(%t)", hostNetwork), func(i *Instance) error {
+		return i.SetHostNetwork(hostNetwork)
+	})
+}
+
+// AddHostPort exposes the given port of the instance on the same port of its node
+func (b *InstanceBuilder) AddHostPort(port int) *InstanceBuilder {
+	return b.step(fmt.Sprintf("AddHostPort(%d)", port), func(i *Instance) error {
+		return i.AddHostPort(port)
+	})
+}
+
+// Build creates the instance and applies all the calls in order
+// All the calls are applied even if some of them fail, and all the failures are returned in a single error.
+// The instance is returned in the state 'Preparing'.
+func (b *InstanceBuilder) Build() (*Instance, error) {
+	if b.image == "" {
+		return nil, ErrBuildingInstance.WithParams(b.name).Wrap(ErrImageRequiredForOptions)
+	}
+
+	i, err := New(b.name, b.sysDeps, WithImage(b.image))
+	if err != nil {
+		return nil, ErrBuildingInstance.WithParams(b.name).Wrap(err)
+	}
+
+	var errs []error
+	for _, s := range b.steps {
+		if err := s.fn(i); err != nil {
+			errs = append(errs, &builderStepError{call: s.call, msg: err.Error(), err: err})
+		}
+	}
+	if len(errs) != 0 {
+		return nil, ErrBuildingInstance.WithParams(b.name).Wrap(errors.Join(errs...))
+	}
+	return i, nil
+}
+
+// Commit builds the instance and commits it
+// The instance is returned in the state 'Committed'.
+func (b *InstanceBuilder) Commit() (*Instance, error) {
+	i, err := b.Build()
+	if err != nil {
+		return nil, err
+	}
+	if err := i.Commit(); err != nil {
+		return nil, ErrCommittingInstance.Wrap(err)
+	}
+	return i, nil
+}
@@ -0,0 +1,57 @@
+package instance
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/celestiaorg/knuu/pkg/system"
+)
+
+// The tests are not parallel, as the errors are package level variables that are modified when they are returned
+
+func TestInstanceBuilderWithoutImage(t *testing.T) {
+	_, err := NewBuilder("test", system.SystemDependencies{}).
+		AddPortTCP(8080).
+		Build()
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ErrBuildingInstance))
+	assert.Contains(t, err.Error(), ErrImageRequiredForOptions.Error())
+}
+
+func TestInstanceBuilderCollectsErrors(t *testing.T) {
+	i, err := NewBuilder("test", system.SystemDependencies{}).
+		SetImage("alpine:latest").
+		AddPortTCP(0).
+		AddPortTCP(8080).
+		AddPortTCP(8080).
+		AddPortUDP(70000).
+		SetMemory("100Mi", "200Mi").
+		Build()
+	require.Error(t, err)
+	assert.Nil(t, i)
+
+	// every failed call is reported with its own message
+	msg := err.Error()
+	assert.Contains(t, msg, "AddPortTCP(0): port number '0' is out of range")
+	assert.Contains(t, msg, "AddPortTCP(8080): TCP port '8080' is already in registered")
+	assert.Contains(t, msg, "AddPortUDP(70000): port number '70000' is out of range")
+	assert.NotContains(t, msg, "SetMemory")
+}
+
+func TestInstanceBuilderTTY(t *testing.T) {
+	i, err := NewBuilder("repl", system.SystemDependencies{}).
+		SetImage("python:3.12").
+		SetTTY(true).
+		SetStdin(true).
+		Build()
+	require.NoError(t, err)
+	spec := i.Spec()
+	assert.True(t, spec.TTY)
+	assert.True(t, spec.Stdin)
+
+	require.NoError(t, i.SetTTY(false))
+	assert.False(t, i.Spec().TTY)
+}
@@ -0,0 +1,220 @@
+package instance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/celestiaorg/knuu/pkg/builder"
+	"github.com/celestiaorg/knuu/pkg/k8s/fake"
+	"github.com/celestiaorg/knuu/pkg/system"
+)
+
+func TestInstanceConcurrentConfiguration(t *testing.T) {
+	t.Parallel()
+
+	const workers = 50
+
+	i := &Instance{
+		name:     "test-instance",
+		k8sName:  "test-instance",
+		state:    Committed,
+		portsTCP: make([]int, 0),
+		env:      make(map[string]string),
+	}
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			assert.NoError(t, i.AddPortTCP(1000+w))
+			assert.NoError(t, i.SetEnvironmentVariable(fmt.Sprintf("KEY_%d", w), "value"))
+			assert.True(t, i.IsInState(Committed))
+		}(w)
+	}
+	wg.Wait()
+
+	require.Len(t, i.portsTCP, workers)
+	require.Len(t, i.env, workers)
+}
+
+func TestInstanceConcurrentSidecars(t *testing.T) {
+	t.Parallel()
+
+	const workers = 20
+
+	parent := &Instance{name: "parent", k8sName: "parent", state: Committed}
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			name := fmt.Sprintf("sidecar-%d", w)
+			sidecar := &Instance{name: name, k8sName: name, state: Committed}
+			assert.NoError(t, parent.AddSidecar(sidecar))
+		}(w)
+	}
+	wg.Wait()
+
+	require.Len(t, parent.sidecars, workers)
+	setStateForSidecars(parent.sidecars, Started)
+	for _, sidecar := range parent.sidecars {
+		assert.True(t, sidecar.isSidecar)
+		assert.True(t, sidecar.IsInState(Started), "the state must be set on the sidecar itself, not on a copy")
+	}
+}
+
+func TestEnableShareProcessNamespace(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	k8sCli, err := fake.New(ctx, "test")
+	require.NoError(t, err)
+	i, err := New("app", system.SystemDependencies{K8sCli: k8sCli}, WithImage("alpine"))
+	require.NoError(t, err)
+	sidecar, err := New("killer", system.SystemDependencies{K8sCli: k8sCli}, WithImage("alpine"))
+	require.NoError(t, err)
+	sidecar.state = Committed
+	require.NoError(t, i.AddSidecar(sidecar))
+
+	require.NoError(t, i.EnableShareProcessNamespace())
+	assert.ErrorIs(t, sidecar.EnableShareProcessNamespace(), ErrSharingProcessNamespaceForSidecar)
+
+	_, err = k8sCli.DeployPod(ctx, i.preparePodConfig(), true)
+	require.NoError(t, err)
+	pod, err := k8sCli.FakeClientset.CoreV1().Pods("test").Get(ctx, i.k8sName, metav1.GetOptions{})
+	require.NoError(t, err)
+	require.NotNil(t, pod.Spec.ShareProcessNamespace)
+	assert.True(t, *pod.Spec.ShareProcessNamespace)
+	assert.Len(t, pod.Spec.Containers, 2)
+}
+
+func TestSetRestartPolicy(t *testing.T) {
+	t.Parallel()
+
+	i, err := New("test", system.SystemDependencies{}, WithImage("alpine"))
+	require.NoError(t, err)
+	assert.True(t, i.usesReplicaSet())
+
+	require.NoError(t, i.SetRestartPolicy(v1.RestartPolicyNever))
+	assert.False(t, i.usesReplicaSet())
+	assert.Equal(t, v1.RestartPolicyNever, i.restartPolicy)
+
+	assert.ErrorIs(t, i.SetRestartPolicy("Sometimes"), ErrInvalidRestartPolicy)
+
+	require.NoError(t, i.SetRestartPolicy(v1.RestartPolicyAlways))
+	assert.True(t, i.usesReplicaSet())
+
+	i.state = Started
+	assert.ErrorIs(t, i.SetRestartPolicy(v1.RestartPolicyNever), ErrSettingRestartPolicyNotAllowed)
+}
+
+func TestIsRunningWithoutRestarts(t *testing.T) {
+	t.Parallel()
+
+	pods := &fakePods{}
+	i, err := New("test", system.SystemDependencies{K8sCli: pods}, WithImage("alpine"))
+	require.NoError(t, err)
+	require.NoError(t, i.SetRestartPolicy(v1.RestartPolicyNever))
+	i.state = Started
+
+	tests := []struct {
+		name    string
+		phase   v1.PodPhase
+		ready   bool
+		running bool
+		err     error
+	}{
+		{name: "pending", phase: v1.PodPending, running: false},
+		{name: "running but not ready", phase: v1.PodRunning, running: false},
+		{name: "running and ready", phase: v1.PodRunning, ready: true, running: true},
+		// a finished pod is not restarted, it counts as started so that its exit code can be inspected
+		{name: "succeeded", phase: v1.PodSucceeded, running: true},
+		// a failed pod is not waited for
+		{name: "failed", phase: v1.PodFailed, running: false, err: ErrInstanceFailed},
+	}
+	for _, tt := range tests {
+		pods.setPod(i.k8sName, tt.phase, tt.ready)
+		running, err := i.IsRunning(context.Background())
+		if tt.err != nil {
+			assert.ErrorIs(t, err, tt.err, tt.name)
+		} else {
+			require.NoError(t, err, tt.name)
+		}
+		assert.Equal(t, tt.running, running, tt.name)
+	}
+}
+
+func TestCheckImage(t *testing.T) {
+	t.Parallel()
+
+	var checked []string
+	record := builder.PostBuildHookFunc(func(_ context.Context, imageName string) error {
+		checked = append(checked, imageName)
+		return nil
+	})
+	reject := builder.PostBuildHookFunc(func(context.Context, string) error {
+		return errors.New("CVE-2024-3094")
+	})
+
+	i := &Instance{name: "validator", SystemDependencies: system.SystemDependencies{PostBuildHooks: []builder.PostBuildHook{record}}}
+	require.NoError(t, i.checkImage(context.Background(), "ttl.sh/image:24h"))
+	assert.Equal(t, []string{"ttl.sh/image:24h"}, checked)
+
+	i.PostBuildHooks = append(i.PostBuildHooks, reject, record)
+	err := i.checkImage(context.Background(), "ttl.sh/image:24h")
+	assert.ErrorIs(t, err, ErrImageRejectedByHook)
+	assert.Len(t, checked, 2, "the hooks after the rejecting one are not run")
+}
+
+type fakeSigner struct {
+	signed, verified []string
+	err              error
+}
+
+func (f *fakeSigner) SignImage(_ context.Context, imageName string) error {
+	f.signed = append(f.signed, imageName)
+	return f.err
+}
+
+func (f *fakeSigner) VerifyImage(_ context.Context, imageName string) error {
+	f.verified = append(f.verified, imageName)
+	return f.err
+}
+
+func TestImageSigning(t *testing.T) {
+	t.Parallel()
+
+	signer := &fakeSigner{}
+	reject := builder.PostBuildHookFunc(func(context.Context, string) error {
+		return errors.New("CVE-2024-3094")
+	})
+	sysDeps := system.SystemDependencies{ImageSigner: signer, ImageVerifier: signer}
+
+	i, err := New("validator", sysDeps, WithImage("ghcr.io/celestiaorg/celestia-app:v1"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ghcr.io/celestiaorg/celestia-app:v1"}, signer.verified)
+
+	require.NoError(t, i.checkImage(context.Background(), "ttl.sh/image:24h"))
+	assert.Equal(t, []string{"ttl.sh/image:24h"}, signer.signed)
+
+	// the images rejected by the hooks are not signed
+	i.PostBuildHooks = []builder.PostBuildHook{reject}
+	assert.ErrorIs(t, i.checkImage(context.Background(), "ttl.sh/other:24h"), ErrImageRejectedByHook)
+	assert.Len(t, signer.signed, 1)
+
+	signer.err = errors.New("no matching signatures")
+	_, err = New("bridge", sysDeps, WithImage("ghcr.io/unsigned:v1"))
+	assert.ErrorContains(t, err, "error verifying the signature of image 'ghcr.io/unsigned:v1'")
+	i.PostBuildHooks = nil
+	assert.ErrorIs(t, i.checkImage(context.Background(), "ttl.sh/image:24h"), ErrSigningImage)
+}
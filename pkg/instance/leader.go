@@ -0,0 +1,244 @@
+package instance
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/celestiaorg/knuu/pkg/clock"
+	"github.com/celestiaorg/knuu/pkg/retry"
+)
+
+const defaultLeaderCheckInterval = 2 * time.Second
+
+// LeaderCheck returns true if the given instance is the leader, e.g. of a consensus protocol
+type LeaderCheck func(ctx context.Context, i *Instance) (bool, error)
+
+// LeaderFromLogs returns a check that reads the logs of the instances
+// An instance is the leader if the last line of its logs matching elected or lost matches elected.
+// lost can be nil, the instances then stay leaders once elected until their pod is recreated.
+func LeaderFromLogs(elected, lost *regexp.Regexp) LeaderCheck {
+	return func(ctx context.Context, i *Instance) (bool, error) {
+		logs, err := i.Logs(ctx, false)
+		if err != nil {
+			return false, err
+		}
+		defer logs.Close()
+
+		leader := false
+		scanner := bufio.NewScanner(logs)
+		for scanner.Scan() {
+			line := scanner.Bytes()
+			switch {
+			case elected.Match(line):
+				leader = true
+			case lost != nil && lost.Match(line):
+				leader = false
+			}
+		}
+		return leader, scanner.Err()
+	}
+}
+
+// LeaderFromHTTP returns a check that requests the given path on the given port of the instances
+// The port is forwarded for each check and isLeader is called with the status code and the body of the response.
+func LeaderFromHTTP(port int, path string, isLeader func(status int, body []byte) bool) LeaderCheck {
+	return func(ctx context.Context, i *Instance) (bool, error) {
+		// the port is forwarded until the context is cancelled
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		localPort, err := i.PortForwardTCP(ctx, port)
+		if err != nil {
+			return false, err
+		}
+		url := fmt.Sprintf("http://localhost:%d%s", localPort, path)
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return false, err
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return false, err
+		}
+		defer resp.Body.Close()
+
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return false, err
+		}
+		return isLeader(resp.StatusCode, body), nil
+	}
+}
+
+// LeaderChange is a change of the leader observed by a LeaderTracker
+type LeaderChange struct {
+	// Leader is the new leader, nil if no instance was the leader
+	Leader *Instance
+	Time   time.Time
+}
+
+// LeaderTracker tracks which instance is the leader over time, e.g. to test the failover of a consensus protocol
+// An instance that fails to be checked, e.g. because it is restarting, is not the leader.
+type LeaderTracker struct {
+	instances func() []*Instance
+	check     LeaderCheck
+	interval  time.Duration
+
+	mu      sync.Mutex
+	leader  *Instance
+	history []LeaderChange
+}
+
+// NewLeaderTracker returns a tracker of the leader among the instances returned by the given function, e.g. InstanceGroup.Instances
+// The instances are checked every interval when waiting for a leader, 2s if 0.
+// Only the instances in the state 'Started' are checked.
+func NewLeaderTracker(instances func() []*Instance, check LeaderCheck, interval time.Duration) *LeaderTracker {
+	if interval <= 0 {
+		interval = defaultLeaderCheckInterval
+	}
+	return &LeaderTracker{
+		instances: instances,
+		check:     check,
+		interval:  interval,
+	}
+}
+
+// Leader checks the instances and returns the current leader, nil if there is none
+// It returns ErrMultipleLeaders if more than one instance claims to be the leader.
+func (t *LeaderTracker) Leader(ctx context.Context) (*Instance, error) {
+	var leaders []*Instance
+	for _, i := range t.instances() {
+		if !i.IsInState(Started) {
+			continue
+		}
+		isLeader, err := t.check(ctx, i)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ErrCheckingLeader.Wrap(ctx.Err())
+			}
+			logrus.Debugf("Error checking if instance '%s' is the leader: %v", i.k8sName, err)
+			continue
+		}
+		if isLeader {
+			leaders = append(leaders, i)
+		}
+	}
+	if len(leaders) > 1 {
+		names := make([]string, len(leaders))
+		for j, l := range leaders {
+			names[j] = l.name
+		}
+		return nil, ErrMultipleLeaders.WithParams(names)
+	}
+
+	var leader *Instance
+	if len(leaders) == 1 {
+		leader = leaders[0]
+	}
+	t.observe(ctx, leader)
+	return leader, nil
+}
+
+// Run checks the leader at each interval of the clock of the context until the context is done,
+// so that the history records the changes that happen while no one waits for them
+func (t *LeaderTracker) Run(ctx context.Context) {
+	ticker := clock.FromContext(ctx).NewTicker(t.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C():
+			if _, err := t.Leader(ctx); err != nil {
+				logrus.Debugf("Error tracking the leader: %v", err)
+			}
+		}
+	}
+}
+
+// WaitForLeader waits until an instance is the leader and returns it
+func (t *LeaderTracker) WaitForLeader(ctx context.Context) (*Instance, error) {
+	return t.waitFor(ctx, func(*Instance) bool { return true })
+}
+
+// WaitForNewLeader waits until an instance other than the last observed leader is the leader and returns it
+// If no leader was observed yet, it waits for any leader.
+func (t *LeaderTracker) WaitForNewLeader(ctx context.Context) (*Instance, error) {
+	previous := t.lastLeader()
+	return t.waitFor(ctx, func(leader *Instance) bool { return leader != previous })
+}
+
+// KillLeader stops the current leader and returns it, the instance can be started again to rejoin
+// It returns ErrNoLeader if no instance is the leader.
+func (t *LeaderTracker) KillLeader(ctx context.Context) (*Instance, error) {
+	leader, err := t.Leader(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if leader == nil {
+		return nil, ErrNoLeader
+	}
+	if err := leader.Stop(ctx); err != nil {
+		return nil, ErrKillingLeader.WithParams(leader.k8sName).Wrap(err)
+	}
+	logrus.Debugf("Killed leader '%s'", leader.k8sName)
+	return leader, nil
+}
+
+// History returns the changes of the leader observed so far, the first one is the first observation
+func (t *LeaderTracker) History() []LeaderChange {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]LeaderChange(nil), t.history...)
+}
+
+func (t *LeaderTracker) waitFor(ctx context.Context, accept func(*Instance) bool) (*Instance, error) {
+	var leader *Instance
+	err := retry.Until(ctx, retry.Constant(t.interval), func(ctx context.Context) (bool, error) {
+		l, err := t.Leader(ctx)
+		if errors.Is(err, ErrMultipleLeaders) {
+			// the leaders can overlap during an election
+			logrus.Debugf("Waiting for the leader: %v", err)
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		leader = l
+		return l != nil && accept(l), nil
+	})
+	if err != nil {
+		return nil, ErrWaitingForLeader.Wrap(err)
+	}
+	return leader, nil
+}
+
+// observe records the given leader if it changed
+func (t *LeaderTracker) observe(ctx context.Context, leader *Instance) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if len(t.history) != 0 && t.history[len(t.history)-1].Leader == leader {
+		return
+	}
+	t.history = append(t.history, LeaderChange{Leader: leader, Time: clock.FromContext(ctx).Now()})
+	if leader != nil {
+		t.leader = leader
+		logrus.Debugf("Instance '%s' is the leader", leader.k8sName)
+	}
+}
+
+// lastLeader returns the last instance observed as the leader
+func (t *LeaderTracker) lastLeader() *Instance {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.leader
+}
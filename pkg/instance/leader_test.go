@@ -0,0 +1,117 @@
+package instance
+
+import (
+	"context"
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/celestiaorg/knuu/pkg/clock"
+	"github.com/celestiaorg/knuu/pkg/k8s/fake"
+	"github.com/celestiaorg/knuu/pkg/system"
+)
+
+// fakeElection elects the instances with the given names
+type fakeElection struct {
+	mu      sync.Mutex
+	leaders map[string]bool
+}
+
+func (f *fakeElection) elect(names ...string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.leaders = make(map[string]bool, len(names))
+	for _, name := range names {
+		f.leaders[name] = true
+	}
+}
+
+func (f *fakeElection) check(_ context.Context, i *Instance) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.leaders[i.Name()], nil
+}
+
+func startedInstances(t *testing.T, names ...string) []*Instance {
+	ctx := context.Background()
+	k8sCli, err := fake.New(ctx, "test")
+	require.NoError(t, err)
+
+	instances := make([]*Instance, 0, len(names))
+	for _, name := range names {
+		i, err := New(name, system.SystemDependencies{K8sCli: k8sCli}, WithImage("alpine"))
+		require.NoError(t, err)
+		i.restartPolicy = v1.RestartPolicyNever
+		i.state = Started
+		_, err = k8sCli.FakeClientset.CoreV1().Pods("test").Create(ctx, &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: i.k8sName}}, metav1.CreateOptions{})
+		require.NoError(t, err)
+		require.NoError(t, k8sCli.CreateServiceAccount(ctx, i.k8sName, nil))
+		instances = append(instances, i)
+	}
+	return instances
+}
+
+func TestLeaderTracker(t *testing.T) {
+	t.Parallel()
+
+	instances := startedInstances(t, "node-0", "node-1", "node-2")
+	election := &fakeElection{}
+	tracker := NewLeaderTracker(Supervise(instances...), election.check, time.Second)
+
+	fakeClock := clock.NewFake(time.Now())
+	ctx := clock.WithClock(context.Background(), fakeClock)
+
+	_, err := tracker.KillLeader(ctx)
+	assert.ErrorIs(t, err, ErrNoLeader)
+
+	election.elect("node-0", "node-1")
+	_, err = tracker.Leader(ctx)
+	assert.ErrorIs(t, err, ErrMultipleLeaders)
+
+	election.elect("node-1")
+	killed, err := tracker.KillLeader(ctx)
+	require.NoError(t, err)
+	assert.Same(t, instances[1], killed)
+	assert.True(t, killed.IsInState(Stopped))
+
+	done := make(chan *Instance, 1)
+	go func() {
+		leader, err := tracker.WaitForNewLeader(ctx)
+		assert.NoError(t, err)
+		done <- leader
+	}()
+
+	require.Eventually(t, fakeClock.HasWaiters, time.Second, time.Millisecond)
+	election.elect("node-2")
+	fakeClock.Step(time.Second)
+	assert.Same(t, instances[2], <-done)
+
+	history := tracker.History()
+	require.Len(t, history, 4)
+	assert.Nil(t, history[0].Leader)
+	assert.Same(t, instances[1], history[1].Leader)
+	assert.Nil(t, history[2].Leader)
+	assert.Same(t, instances[2], history[3].Leader)
+}
+
+func TestLeaderFromLogs(t *testing.T) {
+	t.Parallel()
+
+	i := startedInstances(t, "node-0")[0]
+	ctx := context.Background()
+
+	// the fake clientset streams "fake logs"
+	leader, err := LeaderFromLogs(regexp.MustCompile("fake"), nil)(ctx, i)
+	require.NoError(t, err)
+	assert.True(t, leader)
+
+	leader, err = LeaderFromLogs(regexp.MustCompile("elected"), nil)(ctx, i)
+	require.NoError(t, err)
+	assert.False(t, leader)
+}
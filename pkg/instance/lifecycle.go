@@ -0,0 +1,103 @@
+package instance
+
+import (
+	"context"
+
+	"github.com/sirupsen/logrus"
+)
+
+// LifecycleEvent is a point of the lifecycle of an instance where its lifecycle hooks are called
+type LifecycleEvent string
+
+const (
+	// BeforeStart hooks are called by Start and StartWithoutWait before the instance is deployed, e.g. to generate
+	// keys or to render configuration files
+	BeforeStart LifecycleEvent = "before-start"
+	// AfterStart hooks are called by Start once the instance is running, e.g. to seed a dataset
+	AfterStart LifecycleEvent = "after-start"
+	// BeforeStop hooks are called by Stop while the instance is still running, e.g. to export its state
+	BeforeStop LifecycleEvent = "before-stop"
+	// BeforeDestroy hooks are called by Destroy before the pod and the resources of the instance are deleted, e.g. to
+	// unregister the instance from services outside of its namespace
+	BeforeDestroy LifecycleEvent = "before-destroy"
+)
+
+// LifecycleHook is called with the instance at an event of its lifecycle
+// An error of a hook fails the operation of the event, the following hooks are not called.
+type LifecycleHook func(ctx context.Context, i *Instance) error
+
+func (e LifecycleEvent) validate() error {
+	switch e {
+	case BeforeStart, AfterStart, BeforeStop, BeforeDestroy:
+		return nil
+	default:
+		return ErrInvalidLifecycleEvent.WithParams(e)
+	}
+}
+
+// WithLifecycleHook adds a hook called at the given event of the lifecycle of the instance, see AddLifecycleHook
+// Unlike the other options, it does not require an image.
+func WithLifecycleHook(event LifecycleEvent, hook LifecycleHook) Option {
+	return func(o *options) {
+		o.hooks = append(o.hooks, lifecycleHook{event: event, hook: hook})
+	}
+}
+
+type lifecycleHook struct {
+	event LifecycleEvent
+	hook  LifecycleHook
+}
+
+// AddLifecycleHook adds a hook called at the given event of the lifecycle of the instance, after the hooks
+// already added for the event
+// The hooks are called with the instance unlocked, so they can use all its methods, e.g. ExecuteCommand.
+func (i *Instance) AddLifecycleHook(event LifecycleEvent, hook LifecycleHook) error {
+	if err := event.validate(); err != nil {
+		return err
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if i.hooks == nil {
+		i.hooks = make(map[LifecycleEvent][]LifecycleHook)
+	}
+	i.hooks[event] = append(i.hooks[event], hook)
+	logrus.Debugf("Added %s hook to instance '%s'", event, i.name)
+	return nil
+}
+
+// cloneLifecycleHooks copies the hooks of an instance for its clone, so that the hooks added to one of them are not
+// added to the other
+func cloneLifecycleHooks(hooks map[LifecycleEvent][]LifecycleHook) map[LifecycleEvent][]LifecycleHook {
+	if hooks == nil {
+		return nil
+	}
+	cloned := make(map[LifecycleEvent][]LifecycleHook, len(hooks))
+	for event, eventHooks := range hooks {
+		cloned[event] = append([]LifecycleHook(nil), eventHooks...)
+	}
+	return cloned
+}
+
+// runBeforeStartHooks calls the BeforeStart hooks if the instance can be started, otherwise starting it fails
+// without calling them
+func (i *Instance) runBeforeStartHooks(ctx context.Context) error {
+	if i.isSidecar || !i.IsInState(Committed, Stopped) {
+		return nil
+	}
+	return i.runLifecycleHooks(ctx, BeforeStart)
+}
+
+// runLifecycleHooks calls the hooks of the event in the order they were added
+func (i *Instance) runLifecycleHooks(ctx context.Context, event LifecycleEvent) error {
+	i.mu.Lock()
+	hooks := append([]LifecycleHook(nil), i.hooks[event]...)
+	i.mu.Unlock()
+
+	for _, hook := range hooks {
+		if err := hook(ctx, i); err != nil {
+			return ErrLifecycleHook.WithParams(event, i.name).Wrap(err)
+		}
+	}
+	return nil
+}
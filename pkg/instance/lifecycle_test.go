@@ -0,0 +1,67 @@
+package instance
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/celestiaorg/knuu/pkg/system"
+)
+
+func TestLifecycleHooks(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	var calls []string
+	hook := func(name string, err error) LifecycleHook {
+		return func(_ context.Context, _ *Instance) error {
+			calls = append(calls, name)
+			return err
+		}
+	}
+
+	_, err := New("validator", system.SystemDependencies{}, WithLifecycleHook("after-destroy", hook("invalid", nil)))
+	assert.Error(t, err)
+
+	i, err := New("validator", system.SystemDependencies{}, WithLifecycleHook(BeforeStart, hook("keys", nil)))
+	require.NoError(t, err)
+	require.NoError(t, i.AddLifecycleHook(BeforeStart, hook("config", errors.New("template error"))))
+	require.NoError(t, i.AddLifecycleHook(BeforeStart, hook("never", nil)))
+	require.NoError(t, i.AddLifecycleHook(BeforeStop, hook("export", errors.New("export failed"))))
+	assert.Error(t, i.AddLifecycleHook("", hook("invalid", nil)))
+
+	clone := i.cloneWithSuffix("-clone")
+	require.NoError(t, clone.AddLifecycleHook(AfterStart, hook("seed", nil)))
+	assert.Empty(t, i.hooks[AfterStart], "the hooks added to a clone are not added to the original")
+
+	err = i.StartWithoutWait(ctx)
+	assert.Empty(t, calls, "the hooks are not called when the instance cannot be started")
+	assert.Error(t, err)
+
+	i.state = Committed
+	err = i.StartWithoutWait(ctx)
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ErrLifecycleHook))
+	assert.Contains(t, err.Error(), "template error")
+	assert.Equal(t, []string{"keys", "config"}, calls, "a failed hook stops the hooks and the start")
+	assert.True(t, i.IsInState(Committed))
+
+	calls = nil
+	i.state = Started
+	err = i.Stop(ctx)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "export failed")
+	assert.Equal(t, []string{"export"}, calls)
+	assert.True(t, i.IsInState(Started), "a failed hook stops the stop")
+
+	calls = nil
+	require.NoError(t, i.AddLifecycleHook(BeforeDestroy, hook("unregister", errors.New("unregister failed"))))
+	err = i.Destroy(ctx)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unregister failed")
+	assert.Equal(t, []string{"unregister"}, calls)
+	assert.True(t, i.IsInState(Started), "a failed hook stops the destroy")
+}
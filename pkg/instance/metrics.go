@@ -0,0 +1,323 @@
+package instance
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/celestiaorg/knuu/pkg/retry"
+)
+
+const defaultMetricsPath = "/metrics"
+
+// waitMetricInterval is the interval between the scrapes of WaitForMetric
+var waitMetricInterval = 1 * time.Second
+
+// MetricType is the type of a metric family, as declared by the TYPE comment of the Prometheus text format
+type MetricType string
+
+const (
+	MetricCounter   MetricType = "counter"
+	MetricGauge     MetricType = "gauge"
+	MetricHistogram MetricType = "histogram"
+	MetricSummary   MetricType = "summary"
+	MetricUntyped   MetricType = "untyped"
+)
+
+// MetricFamily is a metric and its samples
+// The samples of a histogram or a summary have the suffixes of their series, e.g. '_bucket', '_sum' and '_count'.
+type MetricFamily struct {
+	Name    string         `json:"name"`
+	Help    string         `json:"help,omitempty"`
+	Type    MetricType     `json:"type"`
+	Samples []MetricSample `json:"samples"`
+}
+
+// MetricSample is a sample of a metric family
+type MetricSample struct {
+	Name   string            `json:"name"`
+	Labels map[string]string `json:"labels,omitempty"`
+	Value  float64           `json:"value"`
+}
+
+// MetricFamilies are the metric families scraped from an instance, in the order they were exposed
+type MetricFamilies []MetricFamily
+
+// Family returns the metric family with the given name
+func (m MetricFamilies) Family(name string) (*MetricFamily, bool) {
+	for index := range m {
+		if m[index].Name == name {
+			return &m[index], true
+		}
+	}
+	return nil, false
+}
+
+// Value returns the sum of the samples with the given name that have the given labels, all of them if labels is nil
+// e.g. Value("http_requests_total", map[string]string{"code": "500"}) for the requests that failed.
+// It returns false if no sample matches.
+func (m MetricFamilies) Value(name string, labels map[string]string) (float64, bool) {
+	var sum float64
+	found := false
+	for _, family := range m {
+		for _, sample := range family.Samples {
+			if sample.Name == name && hasLabels(sample.Labels, labels) {
+				sum += sample.Value
+				found = true
+			}
+		}
+	}
+	return sum, found
+}
+
+func hasLabels(labels, want map[string]string) bool {
+	for k, v := range want {
+		if labels[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+// ScrapeMetrics reads the metrics exposed in the Prometheus text format on the given port and path of the instance,
+// /metrics if the path is empty, so that the tests can assert on the counters and gauges without running Prometheus
+// The endpoint is read through the API server, so the port does not need to be exposed, but the endpoint must
+// listen on the IP of the pod, not only on localhost.
+// This function can only be called in the state 'Started'
+func (i *Instance) ScrapeMetrics(ctx context.Context, port int, path string) (MetricFamilies, error) {
+	if !i.allows(ActionOperate) {
+		return nil, ErrScrapingMetricsNotAllowed.WithParams(i.getState().String())
+	}
+	if err := validatePort(port); err != nil {
+		return nil, err
+	}
+	if path == "" {
+		path = defaultMetricsPath
+	}
+	pod, err := i.getPod(ctx)
+	if err != nil {
+		return nil, ErrGettingPodFromReplicaSet.WithParams(i.k8sName).Wrap(err)
+	}
+	body, err := i.K8sCli.ProxyGetPod(ctx, pod.Name, port, path)
+	if err != nil {
+		return nil, ErrScrapingMetrics.WithParams(port, i.k8sName).Wrap(err)
+	}
+	families, err := ParseMetrics(bytes.NewReader(body))
+	if err != nil {
+		return nil, ErrScrapingMetrics.WithParams(port, i.k8sName).Wrap(err)
+	}
+	logrus.Debugf("Scraped %d metric families of instance '%s'", len(families), i.k8sName)
+	return families, nil
+}
+
+// WaitForMetric scrapes the metrics on the given port of the instance, see ScrapeMetrics, until the value of the
+// metric satisfies the predicate, and returns that value
+// The value is the sum of the samples with the given name, see MetricFamilies.Value, e.g.
+// WaitForMetric(ctx, 26660, "block_height", func(v float64) bool { return v > 10 }, time.Minute) waits for the
+// node to be past the height 10. The failed scrapes and the scrapes without the metric are retried, so it can be
+// called while the node is still starting. A timeout of 0 means that only the context bounds the wait.
+// This function can only be called in the state 'Started'
+func (i *Instance) WaitForMetric(ctx context.Context, port int, metricName string, predicate func(value float64) bool, timeout time.Duration) (float64, error) {
+	if !i.allows(ActionOperate) {
+		return 0, ErrScrapingMetricsNotAllowed.WithParams(i.getState().String())
+	}
+	if err := validatePort(port); err != nil {
+		return 0, err
+	}
+
+	var (
+		value   float64
+		found   bool
+		lastErr error
+	)
+	err := retry.Until(ctx, retry.Constant(waitMetricInterval).WithTimeout(timeout), func(ctx context.Context) (bool, error) {
+		families, err := i.ScrapeMetrics(ctx, port, defaultMetricsPath)
+		if errors.Is(err, ErrScrapingMetricsNotAllowed) {
+			// the instance was stopped, the condition can no longer be satisfied
+			return false, err
+		}
+		if err != nil {
+			lastErr = err
+			return false, nil
+		}
+		lastErr = nil
+		value, found = families.Value(metricName, nil)
+		return found && predicate(value), nil
+	})
+	if err != nil {
+		switch {
+		case lastErr != nil:
+			err = errors.Join(err, lastErr)
+		case found:
+			err = fmt.Errorf("last value %g: %w", value, err)
+		default:
+			err = fmt.Errorf("metric not found: %w", err)
+		}
+		return 0, ErrWaitingForMetric.WithParams(metricName, port, i.k8sName).Wrap(err)
+	}
+	logrus.Debugf("Metric '%s' of instance '%s' satisfied the condition with value %g", metricName, i.k8sName, value)
+	return value, nil
+}
+
+// PrometheusEndpointPort returns the port of the Prometheus endpoint set with SetPrometheusEndpoint, 0 if not set
+func (i *Instance) PrometheusEndpointPort() int {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return i.obsyConfig.prometheusEndpointPort
+}
+
+// ParseMetrics parses the metric families of the Prometheus text format
+// The samples without HELP or TYPE comment are untyped families of their own, and the timestamps are ignored.
+func ParseMetrics(r io.Reader) (MetricFamilies, error) {
+	var families MetricFamilies
+	// current is the index of the family of the last comment or sample, -1 if there is none
+	current := -1
+	family := func(name string) int {
+		if current >= 0 && families[current].Name == name {
+			return current
+		}
+		families = append(families, MetricFamily{Name: name, Type: MetricUntyped})
+		current = len(families) - 1
+		return current
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "#") {
+			fields := strings.SplitN(strings.TrimSpace(line[1:]), " ", 3)
+			if len(fields) < 3 {
+				continue
+			}
+			switch fields[0] {
+			case "HELP":
+				families[family(fields[1])].Help = fields[2]
+			case "TYPE":
+				families[family(fields[1])].Type = MetricType(fields[2])
+			}
+			continue
+		}
+
+		sample, err := parseSample(line)
+		if err != nil {
+			return nil, err
+		}
+		if current < 0 || !belongsTo(sample.Name, families[current]) {
+			family(sample.Name)
+		}
+		families[current].Samples = append(families[current].Samples, sample)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return families, nil
+}
+
+// belongsTo returns true if the sample with the given name is a series of the family
+func belongsTo(name string, family MetricFamily) bool {
+	if name == family.Name {
+		return true
+	}
+	suffix, ok := strings.CutPrefix(name, family.Name)
+	if !ok {
+		return false
+	}
+	switch family.Type {
+	case MetricCounter:
+		return suffix == "_total" || suffix == "_created"
+	case MetricHistogram:
+		return suffix == "_bucket" || suffix == "_sum" || suffix == "_count"
+	case MetricSummary:
+		return suffix == "_sum" || suffix == "_count"
+	}
+	return false
+}
+
+// parseSample parses a sample line, e.g. 'http_requests_total{code="200"} 1027 1395066363000'
+func parseSample(line string) (MetricSample, error) {
+	sample := MetricSample{}
+	rest := line
+	if brace := strings.IndexByte(line, '{'); brace >= 0 {
+		sample.Name = line[:brace]
+		labels, n, err := parseLabels(line[brace+1:])
+		if err != nil {
+			return sample, fmt.Errorf("invalid labels of sample %q: %w", line, err)
+		}
+		sample.Labels = labels
+		rest = line[brace+1+n:]
+	} else {
+		fields := strings.Fields(line)
+		sample.Name, rest = fields[0], strings.TrimPrefix(line, fields[0])
+	}
+
+	fields := strings.Fields(rest)
+	if sample.Name == "" || len(fields) == 0 {
+		return sample, fmt.Errorf("invalid sample %q", line)
+	}
+	value, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return sample, fmt.Errorf("invalid value of sample %q: %w", line, err)
+	}
+	sample.Value = value
+	return sample, nil
+}
+
+// parseLabels parses the labels following the opening brace until the closing one
+// It returns the labels and the number of bytes read, including the closing brace.
+func parseLabels(s string) (map[string]string, int, error) {
+	labels := make(map[string]string)
+	pos := 0
+	for {
+		for pos < len(s) && (s[pos] == ' ' || s[pos] == ',') {
+			pos++
+		}
+		if pos >= len(s) {
+			return nil, 0, fmt.Errorf("missing closing brace")
+		}
+		if s[pos] == '}' {
+			return labels, pos + 1, nil
+		}
+
+		eq := strings.IndexByte(s[pos:], '=')
+		if eq < 0 || pos+eq+1 >= len(s) || s[pos+eq+1] != '"' {
+			return nil, 0, fmt.Errorf("missing quoted value")
+		}
+		name := strings.TrimSpace(s[pos : pos+eq])
+		pos += eq + 2
+
+		var value strings.Builder
+		for {
+			if pos >= len(s) {
+				return nil, 0, fmt.Errorf("unterminated value of label '%s'", name)
+			}
+			c := s[pos]
+			pos++
+			if c == '"' {
+				break
+			}
+			if c == '\\' && pos < len(s) {
+				switch s[pos] {
+				case 'n':
+					c = '\n'
+				default:
+					c = s[pos]
+				}
+				pos++
+			}
+			value.WriteByte(c)
+		}
+		labels[name] = value.String()
+	}
+}
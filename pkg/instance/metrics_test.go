@@ -0,0 +1,140 @@
+package instance
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/rest"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+const testMetrics = `# HELP http_requests_total The requests.
+# TYPE http_requests_total counter
+http_requests_total{code="200",path="/a b"} 1027 1395066363000
+http_requests_total{code="500",path="/a \"b\""} 3
+# TYPE request_duration_seconds histogram
+request_duration_seconds_bucket{le="0.1"} 10
+request_duration_seconds_bucket{le="+Inf"} 12
+request_duration_seconds_sum 1.5
+request_duration_seconds_count 12
+process_open_fds 12
+`
+
+// proxyResponse is the response of the fake API server to a proxied request
+type proxyResponse string
+
+func (r proxyResponse) DoRaw(context.Context) ([]byte, error) {
+	return []byte(r), nil
+}
+
+func (r proxyResponse) Stream(context.Context) (io.ReadCloser, error) {
+	return io.NopCloser(strings.NewReader(string(r))), nil
+}
+
+// proxyError is the response of the fake API server to a proxied request to an endpoint that is not up
+type proxyError struct{}
+
+func (proxyError) DoRaw(context.Context) ([]byte, error) {
+	return nil, fmt.Errorf("connection refused")
+}
+
+func (proxyError) Stream(context.Context) (io.ReadCloser, error) {
+	return nil, fmt.Errorf("connection refused")
+}
+
+func TestParseMetrics(t *testing.T) {
+	t.Parallel()
+
+	families, err := ParseMetrics(strings.NewReader(testMetrics))
+	require.NoError(t, err)
+	require.Len(t, families, 3)
+
+	requests, ok := families.Family("http_requests_total")
+	require.True(t, ok)
+	assert.Equal(t, MetricCounter, requests.Type)
+	assert.Equal(t, "The requests.", requests.Help)
+	require.Len(t, requests.Samples, 2)
+	assert.Equal(t, map[string]string{"code": "500", "path": `/a "b"`}, requests.Samples[1].Labels)
+
+	duration, ok := families.Family("request_duration_seconds")
+	require.True(t, ok)
+	assert.Equal(t, MetricHistogram, duration.Type)
+	assert.Len(t, duration.Samples, 4)
+
+	fds, ok := families.Family("process_open_fds")
+	require.True(t, ok)
+	assert.Equal(t, MetricUntyped, fds.Type)
+
+	v, ok := families.Value("http_requests_total", nil)
+	require.True(t, ok)
+	assert.Equal(t, 1030.0, v)
+	v, ok = families.Value("http_requests_total", map[string]string{"code": "500"})
+	require.True(t, ok)
+	assert.Equal(t, 3.0, v)
+	_, ok = families.Value("http_requests_total", map[string]string{"code": "404"})
+	assert.False(t, ok)
+
+	for _, invalid := range []string{"process_open_fds\n", "http_requests_total{code=\"200\" 1\n", "process_open_fds abc\n"} {
+		_, err := ParseMetrics(strings.NewReader(invalid))
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestScrapeMetrics(t *testing.T) {
+	t.Parallel()
+
+	i, k8sCli := startedInstanceWithArchive(t, "")
+	var path string
+	k8sCli.FakeClientset.PrependProxyReactor("pods", func(action k8stesting.Action) (bool, rest.ResponseWrapper, error) {
+		path = action.(k8stesting.ProxyGetAction).GetPath()
+		return true, proxyResponse(testMetrics), nil
+	})
+
+	families, err := i.ScrapeMetrics(context.Background(), 9090, "")
+	require.NoError(t, err)
+	assert.Equal(t, "/metrics", path)
+	assert.Len(t, families, 3)
+
+	i.state = Stopped
+	_, err = i.ScrapeMetrics(context.Background(), 9090, "")
+	assert.ErrorIs(t, err, ErrScrapingMetricsNotAllowed)
+}
+
+func TestWaitForMetric(t *testing.T) {
+	t.Parallel()
+
+	i, k8sCli := startedInstanceWithArchive(t, "")
+	var scrapes atomic.Int32
+	k8sCli.FakeClientset.PrependProxyReactor("pods", func(k8stesting.Action) (bool, rest.ResponseWrapper, error) {
+		// the endpoint is not up at the first scrape, then the height increases by 5 at each scrape
+		n := scrapes.Add(1)
+		if n == 1 {
+			return true, proxyError{}, nil
+		}
+		return true, proxyResponse(fmt.Sprintf("block_height %d\n", 5*(n-1))), nil
+	})
+
+	height, err := i.WaitForMetric(context.Background(), 26660, "block_height", func(v float64) bool { return v > 10 }, time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, 15.0, height)
+	assert.EqualValues(t, 4, scrapes.Load())
+
+	_, err = i.WaitForMetric(context.Background(), 26660, "missing", func(float64) bool { return true }, 100*time.Millisecond)
+	assert.ErrorIs(t, err, ErrWaitingForMetric)
+	assert.ErrorContains(t, err, "metric not found")
+
+	_, err = i.WaitForMetric(context.Background(), 26660, "block_height", func(v float64) bool { return v < 0 }, 100*time.Millisecond)
+	assert.ErrorIs(t, err, ErrWaitingForMetric)
+	assert.ErrorContains(t, err, "last value")
+
+	i.state = Stopped
+	_, err = i.WaitForMetric(context.Background(), 26660, "block_height", func(float64) bool { return true }, time.Minute)
+	assert.ErrorIs(t, err, ErrScrapingMetricsNotAllowed)
+}
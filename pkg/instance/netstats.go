@@ -0,0 +1,274 @@
+package instance
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// netAccountingSidecar is the ephemeral sidecar counting the traffic of the instance with iptables,
+	// it stays attached until the pod of the instance is recreated
+	netAccountingSidecar  = "knuu-netstats"
+	netAccountingInChain  = "KNUU_ACCT_IN"
+	netAccountingOutChain = "KNUU_ACCT_OUT"
+	// netAccountingTotal is the comment of the rules counting all the traffic
+	netAccountingTotal = "knuu-total"
+)
+
+// Traffic is the number of bytes and packets exchanged by an instance
+type Traffic struct {
+	BytesSent       uint64
+	BytesReceived   uint64
+	PacketsSent     uint64
+	PacketsReceived uint64
+}
+
+// NetworkStats is the traffic of an instance since the accounting started
+type NetworkStats struct {
+	// Total is the traffic with all the addresses
+	Total Traffic
+	// Peers is the traffic with each of the peers given to StartNetworkAccounting, by name
+	Peers map[string]Traffic
+}
+
+// StartNetworkAccounting starts counting the traffic of the instance, in total and with each of the given peers
+// The traffic is counted by iptables rules set by an ephemeral sidecar, with the addresses of the pods and of the
+// services of the peers, so the peers must be started. Calling it again resets the counters and replaces the peers.
+// This function can only be called in the state 'Started'
+func (i *Instance) StartNetworkAccounting(ctx context.Context, peers ...*Instance) error {
+	if !i.allows(ActionOperate) {
+		return ErrStartingNetworkAccountingNotAllowed.WithParams(i.getState().String())
+	}
+	if i.isSidecar {
+		return ErrStartingNetworkAccountingOfSidecar
+	}
+
+	names := make(map[string]string, len(peers))
+	addresses := make(map[string][]string, len(peers))
+	for _, peer := range peers {
+		ips, err := peer.addresses(ctx)
+		if err != nil {
+			return ErrGettingPeerAddresses.WithParams(peer.k8sName).Wrap(err)
+		}
+		names[peer.k8sName] = peer.name
+		addresses[peer.k8sName] = ips
+	}
+
+	if err := i.attachNetworkAccounting(ctx); err != nil {
+		return err
+	}
+	if _, err := i.ExecuteCommandInEphemeralSidecar(ctx, netAccountingSidecar, netAccountingRulesCommand(addresses)); err != nil {
+		return ErrStartingNetworkAccounting.WithParams(i.k8sName).Wrap(err)
+	}
+
+	i.mu.Lock()
+	i.netAccountingPeers = names
+	i.mu.Unlock()
+	logrus.Debugf("Started network accounting of instance '%s' with %d peers", i.k8sName, len(peers))
+	return nil
+}
+
+// NetworkStats returns the traffic of the instance since StartNetworkAccounting was called
+// This function can only be called in the state 'Started'
+func (i *Instance) NetworkStats(ctx context.Context) (*NetworkStats, error) {
+	if !i.allows(ActionOperate) {
+		return nil, ErrGettingNetworkStatsNotAllowed.WithParams(i.getState().String())
+	}
+	i.mu.Lock()
+	names := i.netAccountingPeers
+	i.mu.Unlock()
+	if names == nil {
+		return nil, ErrNetworkAccountingNotStarted.WithParams(i.k8sName)
+	}
+
+	output, err := i.ExecuteCommandInEphemeralSidecar(ctx, netAccountingSidecar, "iptables-save", "-c", "-t", "filter")
+	if err != nil {
+		return nil, ErrGettingNetworkStats.WithParams(i.k8sName).Wrap(err)
+	}
+	return parseNetworkStats(output, names), nil
+}
+
+// StopNetworkAccounting removes the rules counting the traffic of the instance
+// This function can only be called in the state 'Started'
+func (i *Instance) StopNetworkAccounting(ctx context.Context) error {
+	if !i.allows(ActionOperate) {
+		return ErrStoppingNetworkAccountingNotAllowed.WithParams(i.getState().String())
+	}
+	i.mu.Lock()
+	started := i.netAccountingPeers != nil
+	i.mu.Unlock()
+	if !started {
+		return nil
+	}
+
+	if _, err := i.ExecuteCommandInEphemeralSidecar(ctx, netAccountingSidecar, netAccountingCleanupCommand()); err != nil {
+		return ErrStoppingNetworkAccounting.WithParams(i.k8sName).Wrap(err)
+	}
+	i.mu.Lock()
+	i.netAccountingPeers = nil
+	i.mu.Unlock()
+	logrus.Debugf("Stopped network accounting of instance '%s'", i.k8sName)
+	return nil
+}
+
+// attachNetworkAccounting attaches the sidecar of the network accounting unless it is already running
+func (i *Instance) attachNetworkAccounting(ctx context.Context) error {
+	pod, err := i.getPod(ctx)
+	if err != nil {
+		return ErrGettingPodFromReplicaSet.WithParams(i.k8sName).Wrap(err)
+	}
+	if _, ok := ephemeralContainer(pod, netAccountingSidecar); ok {
+		if status, ok := ephemeralContainerStatus(pod, netAccountingSidecar); ok && status.State.Running != nil {
+			return nil
+		}
+		return ErrNetworkAccountingSidecarNotRunning.WithParams(i.k8sName)
+	}
+
+	err = i.AddEphemeralSidecar(ctx, EphemeralSidecar{
+		Name:         netAccountingSidecar,
+		Image:        DefaultDebugImage,
+		Command:      []string{"/bin/sh", "-c", "trap 'exit 0' TERM; while true; do sleep 1; done"},
+		Capabilities: []string{"NET_ADMIN", "NET_RAW"},
+	})
+	if err != nil {
+		return ErrStartingNetworkAccounting.WithParams(i.k8sName).Wrap(err)
+	}
+	return nil
+}
+
+// addresses returns the IP of the pod of the instance and the cluster IP of its service if it has one
+// The traffic sent to a service leaves the pod with the cluster IP as destination, the one received
+// from a peer comes from the IP of its pod.
+func (i *Instance) addresses(ctx context.Context) ([]string, error) {
+	pod, err := i.getPod(ctx)
+	if err != nil {
+		return nil, ErrGettingPodFromReplicaSet.WithParams(i.k8sName).Wrap(err)
+	}
+	if pod.Status.PodIP == "" {
+		return nil, ErrInstanceNotScheduled.WithParams(i.k8sName)
+	}
+	ips := []string{pod.Status.PodIP}
+
+	i.mu.Lock()
+	svc := i.kubernetesService
+	i.mu.Unlock()
+	if svc != nil && svc.Spec.ClusterIP != "" && svc.Spec.ClusterIP != "None" {
+		ips = append(ips, svc.Spec.ClusterIP)
+	}
+	return ips, nil
+}
+
+// netAccountingRulesCommand returns the shell command (re)creating the chains counting the traffic
+// The rules have no target, so they only count the packets, and their comment names the counted peer.
+func netAccountingRulesCommand(addresses map[string][]string) string {
+	cmds := []string{netAccountingCleanupCommand()}
+	for _, chain := range []string{netAccountingInChain, netAccountingOutChain} {
+		cmds = append(cmds, "iptables -N "+chain)
+	}
+	cmds = append(cmds,
+		"iptables -I INPUT -j "+netAccountingInChain,
+		"iptables -I OUTPUT -j "+netAccountingOutChain,
+		fmt.Sprintf("iptables -A %s -m comment --comment %s", netAccountingInChain, netAccountingTotal),
+		fmt.Sprintf("iptables -A %s -m comment --comment %s", netAccountingOutChain, netAccountingTotal),
+	)
+	peers := make([]string, 0, len(addresses))
+	for peer := range addresses {
+		peers = append(peers, peer)
+	}
+	sort.Strings(peers)
+	for _, peer := range peers {
+		for _, ip := range addresses[peer] {
+			cmds = append(cmds,
+				fmt.Sprintf("iptables -A %s -s %s -m comment --comment %s", netAccountingInChain, ip, peer),
+				fmt.Sprintf("iptables -A %s -d %s -m comment --comment %s", netAccountingOutChain, ip, peer),
+			)
+		}
+	}
+	return strings.Join(cmds, " && ")
+}
+
+// netAccountingCleanupCommand returns the shell command removing the chains counting the traffic, if they exist
+func netAccountingCleanupCommand() string {
+	var cmds []string
+	for _, hook := range [][2]string{{"INPUT", netAccountingInChain}, {"OUTPUT", netAccountingOutChain}} {
+		chain := hook[1]
+		cmds = append(cmds, fmt.Sprintf("{ iptables -D %s -j %s 2>/dev/null; iptables -F %s 2>/dev/null; iptables -X %s 2>/dev/null; true; }", hook[0], chain, chain, chain))
+	}
+	return strings.Join(cmds, " && ")
+}
+
+// parseNetworkStats reads the counters of the rules in the output of 'iptables-save -c',
+// names maps the k8s names of the peers in the comments of the rules to their names
+func parseNetworkStats(output string, names map[string]string) *NetworkStats {
+	stats := &NetworkStats{Peers: make(map[string]Traffic, len(names))}
+	for _, name := range names {
+		stats.Peers[name] = Traffic{}
+	}
+
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 3 || !strings.HasPrefix(fields[0], "[") || fields[1] != "-A" {
+			continue
+		}
+		packets, bytes, ok := parseCounters(fields[0])
+		if !ok {
+			continue
+		}
+		var comment string
+		for j := 2; j < len(fields)-1; j++ {
+			if fields[j] == "--comment" {
+				comment = strings.Trim(fields[j+1], `"`)
+			}
+		}
+
+		var traffic Traffic
+		switch fields[2] {
+		case netAccountingInChain:
+			traffic = Traffic{BytesReceived: bytes, PacketsReceived: packets}
+		case netAccountingOutChain:
+			traffic = Traffic{BytesSent: bytes, PacketsSent: packets}
+		default:
+			continue
+		}
+
+		if comment == netAccountingTotal {
+			stats.Total.add(traffic)
+			continue
+		}
+		if name, ok := names[comment]; ok {
+			peer := stats.Peers[name]
+			peer.add(traffic)
+			stats.Peers[name] = peer
+		}
+	}
+	return stats
+}
+
+// parseCounters parses the counters of a rule in the format [packets:bytes]
+func parseCounters(counters string) (packets, bytes uint64, ok bool) {
+	p, b, found := strings.Cut(strings.Trim(counters, "[]"), ":")
+	if !found {
+		return 0, 0, false
+	}
+	packets, err := strconv.ParseUint(p, 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	bytes, err = strconv.ParseUint(b, 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	return packets, bytes, true
+}
+
+func (t *Traffic) add(other Traffic) {
+	t.BytesSent += other.BytesSent
+	t.BytesReceived += other.BytesReceived
+	t.PacketsSent += other.PacketsSent
+	t.PacketsReceived += other.PacketsReceived
+}
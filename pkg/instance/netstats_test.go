@@ -0,0 +1,47 @@
+package instance
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseNetworkStats(t *testing.T) {
+	t.Parallel()
+
+	output := `# Generated by iptables-save v1.8.10 (nf_tables)
+*filter
+:INPUT ACCEPT [120:9000]
+:KNUU_ACCT_IN - [0:0]
+:KNUU_ACCT_OUT - [0:0]
+[120:9000] -A INPUT -j KNUU_ACCT_IN
+[80:7000] -A OUTPUT -j KNUU_ACCT_OUT
+[120:9000] -A KNUU_ACCT_IN -m comment --comment knuu-total
+[10:1000] -A KNUU_ACCT_IN -s 10.0.0.5/32 -m comment --comment validator-1
+[80:7000] -A KNUU_ACCT_OUT -m comment --comment knuu-total
+[3:300] -A KNUU_ACCT_OUT -d 10.0.0.5/32 -m comment --comment validator-1
+[4:400] -A KNUU_ACCT_OUT -d 10.96.0.12/32 -m comment --comment validator-1
+[5:500] -A KNUU_ACCT_OUT -d 10.96.0.13/32 -m comment --comment "unknown"
+COMMIT
+`
+	stats := parseNetworkStats(output, map[string]string{"validator-1": "validator 1", "validator-2": "validator 2"})
+
+	assert.Equal(t, Traffic{BytesSent: 7000, BytesReceived: 9000, PacketsSent: 80, PacketsReceived: 120}, stats.Total)
+	assert.Equal(t, map[string]Traffic{
+		// the traffic sent to the pod and to the service of the peer is summed
+		"validator 1": {BytesSent: 700, BytesReceived: 1000, PacketsSent: 7, PacketsReceived: 10},
+		"validator 2": {},
+	}, stats.Peers)
+}
+
+func TestNetAccountingRulesCommand(t *testing.T) {
+	t.Parallel()
+
+	cmd := netAccountingRulesCommand(map[string][]string{"peer": {"10.0.0.5", "10.96.0.12"}})
+	assert.Contains(t, cmd, "iptables -I INPUT -j KNUU_ACCT_IN")
+	assert.Contains(t, cmd, "iptables -A KNUU_ACCT_IN -s 10.0.0.5 -m comment --comment peer")
+	assert.Contains(t, cmd, "iptables -A KNUU_ACCT_OUT -d 10.96.0.12 -m comment --comment peer")
+	// the previous chains are removed first, so that the counters restart from zero
+	assert.Less(t, strings.Index(cmd, "iptables -X KNUU_ACCT_IN"), strings.Index(cmd, "iptables -N KNUU_ACCT_IN"))
+}
@@ -0,0 +1,53 @@
+package instance
+
+import (
+	"context"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// NodeInfo describes the node an instance is scheduled on
+type NodeInfo struct {
+	Name string
+	// Zone is the value of the zone label of the node, the label given to InstanceGroup.DistributeAcrossZones
+	// or topology.kubernetes.io/zone, empty if the node has no zone
+	Zone string
+	// Region is the value of the label topology.kubernetes.io/region of the node, empty if the node has no region
+	Region string
+	Labels map[string]string
+	// Allocatable is the CPU, memory and other resources of the node available to the pods
+	Allocatable v1.ResourceList
+}
+
+// Node returns the node the pod of the instance is scheduled on, e.g. to check the placement of the instances
+// or to inject faults into the instances of a zone
+// This function can only be called in the state 'Started'
+func (i *Instance) Node(ctx context.Context) (*NodeInfo, error) {
+	if !i.allows(ActionOperate) {
+		return nil, ErrGettingNodeNotAllowed.WithParams(i.getState().String())
+	}
+
+	pod, err := i.getPod(ctx)
+	if err != nil {
+		return nil, ErrGettingPodFromReplicaSet.WithParams(i.k8sName).Wrap(err)
+	}
+	if pod.Spec.NodeName == "" {
+		return nil, ErrInstanceNotScheduled.WithParams(i.k8sName)
+	}
+	node, err := i.K8sCli.GetNode(ctx, pod.Spec.NodeName)
+	if err != nil {
+		return nil, ErrGettingInstanceNode.WithParams(i.k8sName).Wrap(err)
+	}
+
+	zoneLabel := i.zoneLabel
+	if zoneLabel == "" {
+		zoneLabel = v1.LabelTopologyZone
+	}
+	return &NodeInfo{
+		Name:        node.Name,
+		Zone:        node.Labels[zoneLabel],
+		Region:      node.Labels[v1.LabelTopologyRegion],
+		Labels:      node.Labels,
+		Allocatable: node.Status.Allocatable,
+	}, nil
+}
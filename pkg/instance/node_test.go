@@ -0,0 +1,53 @@
+package instance
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/celestiaorg/knuu/pkg/k8s/fake"
+	"github.com/celestiaorg/knuu/pkg/system"
+)
+
+func TestNode(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	allocatable := v1.ResourceList{v1.ResourceCPU: resource.MustParse("4"), v1.ResourceMemory: resource.MustParse("16Gi")}
+	k8sCli, err := fake.New(ctx, "test", &v1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "node-a", Labels: map[string]string{
+			v1.LabelTopologyZone:   "eu-west-1a",
+			v1.LabelTopologyRegion: "eu-west-1",
+		}},
+		Status: v1.NodeStatus{Allocatable: allocatable},
+	})
+	require.NoError(t, err)
+
+	i, err := New("validator", system.SystemDependencies{K8sCli: k8sCli}, WithImage("alpine"))
+	require.NoError(t, err)
+	require.NoError(t, i.SetRestartPolicy(v1.RestartPolicyNever))
+	_, err = i.Node(ctx)
+	assert.ErrorIs(t, err, ErrGettingNodeNotAllowed)
+
+	i.state = Started
+	pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: i.k8sName, Namespace: "test"}}
+	_, err = k8sCli.FakeClientset.CoreV1().Pods("test").Create(ctx, pod, metav1.CreateOptions{})
+	require.NoError(t, err)
+	_, err = i.Node(ctx)
+	assert.ErrorIs(t, err, ErrInstanceNotScheduled)
+
+	pod.Spec.NodeName = "node-a"
+	_, err = k8sCli.FakeClientset.CoreV1().Pods("test").Update(ctx, pod, metav1.UpdateOptions{})
+	require.NoError(t, err)
+	node, err := i.Node(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "node-a", node.Name)
+	assert.Equal(t, "eu-west-1a", node.Zone)
+	assert.Equal(t, "eu-west-1", node.Region)
+	assert.Equal(t, allocatable, node.Allocatable)
+}
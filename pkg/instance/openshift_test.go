@@ -0,0 +1,39 @@
+package instance
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/celestiaorg/knuu/pkg/k8s/fake"
+	"github.com/celestiaorg/knuu/pkg/system"
+)
+
+func TestSecurityContextConstraints(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	k8sCli, err := fake.New(ctx, "test")
+	require.NoError(t, err)
+	i, err := New("app", system.SystemDependencies{K8sCli: k8sCli, SecurityContextConstraints: "anyuid"}, WithImage("alpine"))
+	require.NoError(t, err)
+
+	tracker := newResourceTracker(i.k8sName)
+	require.NoError(t, i.deployPod(ctx, tracker))
+
+	bindings := k8sCli.FakeClientset.RbacV1().RoleBindings("test")
+	binding, err := bindings.Get(ctx, i.k8sName+"-scc", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "ClusterRole", binding.RoleRef.Kind)
+	assert.Equal(t, "system:openshift:scc:anyuid", binding.RoleRef.Name)
+	require.Len(t, binding.Subjects, 1)
+	assert.Equal(t, i.k8sName, binding.Subjects[0].Name)
+
+	require.NoError(t, i.destroyPod(ctx))
+	_, err = bindings.Get(ctx, i.k8sName+"-scc", metav1.GetOptions{})
+	assert.True(t, apierrs.IsNotFound(err))
+}
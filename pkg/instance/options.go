@@ -0,0 +1,253 @@
+package instance
+
+import (
+	"context"
+	"errors"
+	"sort"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// Option configures an instance created with New
+type Option func(*options)
+
+type options struct {
+	image          string
+	prebuilt       bool
+	command        []string
+	args           []string
+	portsTCP       []int
+	portsUDP       []int
+	env            map[string]string
+	memoryRequest  string
+	memoryLimit    string
+	cpuRequest     string
+	livenessProbe  *v1.Probe
+	readinessProbe *v1.Probe
+	startupProbe   *v1.Probe
+	setups         []func(*Instance) error
+	hooks          []lifecycleHook
+}
+
+// WithImage sets the image the instance is built from
+// It is required by all the other options
+func WithImage(image string) Option {
+	return func(o *options) {
+		o.image = image
+	}
+}
+
+// WithPrebuiltImage sets the image the instance runs as is, see Instance.SetPrebuiltImage
+// The instance is returned in the state 'Committed' and the other options are applied to it in that state.
+func WithPrebuiltImage(image string) Option {
+	return func(o *options) {
+		o.image = image
+		o.prebuilt = true
+	}
+}
+
+// WithCommand sets the command to run in the instance
+func WithCommand(command ...string) Option {
+	return func(o *options) {
+		o.command = command
+	}
+}
+
+// WithArgs sets the arguments passed to the instance
+func WithArgs(args ...string) Option {
+	return func(o *options) {
+		o.args = args
+	}
+}
+
+// WithPorts adds TCP ports to the instance
+func WithPorts(ports ...int) Option {
+	return func(o *options) {
+		o.portsTCP = append(o.portsTCP, ports...)
+	}
+}
+
+// WithPortsUDP adds UDP ports to the instance
+func WithPortsUDP(ports ...int) Option {
+	return func(o *options) {
+		o.portsUDP = append(o.portsUDP, ports...)
+	}
+}
+
+// WithEnv sets environment variables in the instance
+func WithEnv(env map[string]string) Option {
+	return func(o *options) {
+		if o.env == nil {
+			o.env = make(map[string]string, len(env))
+		}
+		for k, v := range env {
+			o.env[k] = v
+		}
+	}
+}
+
+// WithResources sets the memory request and limit and the CPU request of the instance
+// Empty values are not set
+func WithResources(memoryRequest, memoryLimit, cpuRequest string) Option {
+	return func(o *options) {
+		o.memoryRequest = memoryRequest
+		o.memoryLimit = memoryLimit
+		o.cpuRequest = cpuRequest
+	}
+}
+
+// WithProbes sets the liveness, readiness and startup probes of the instance
+// Nil probes are not set
+func WithProbes(liveness, readiness, startup *v1.Probe) Option {
+	return func(o *options) {
+		o.livenessProbe = liveness
+		o.readinessProbe = readiness
+		o.startupProbe = startup
+	}
+}
+
+// WithSetup calls the given function to configure the instance once the other options are applied,
+// e.g. to set what has no option such as the observability:
+//
+//	WithSetup(func(i *Instance) error { return i.SetOtelEndpoint(4318) })
+//
+// The functions are called in the order of the options, the instance is in the state 'Preparing', or 'Committed'
+// with WithPrebuiltImage.
+func WithSetup(fn func(*Instance) error) Option {
+	return func(o *options) {
+		o.setups = append(o.setups, fn)
+	}
+}
+
+// hasConfig returns true if any option besides the image is set
+func (o *options) hasConfig() bool {
+	return len(o.command) != 0 || len(o.args) != 0 ||
+		len(o.portsTCP) != 0 || len(o.portsUDP) != 0 || len(o.env) != 0 ||
+		o.memoryRequest != "" || o.memoryLimit != "" || o.cpuRequest != "" ||
+		o.livenessProbe != nil || o.readinessProbe != nil || o.startupProbe != nil ||
+		len(o.setups) != 0
+}
+
+// validate checks all the options and returns all the problems found
+func (o *options) validate() error {
+	var errs []error
+	if o.image == "" && o.hasConfig() {
+		errs = append(errs, ErrImageRequiredForOptions)
+	}
+
+	var outOfRange, duplicated []int
+	for _, ports := range [][]int{o.portsTCP, o.portsUDP} {
+		seen := make(map[int]bool, len(ports))
+		for _, port := range ports {
+			if validatePort(port) != nil {
+				outOfRange = append(outOfRange, port)
+				continue
+			}
+			if seen[port] {
+				duplicated = append(duplicated, port)
+			}
+			seen[port] = true
+		}
+	}
+	if len(outOfRange) != 0 {
+		errs = append(errs, ErrPortsOutOfRange.WithParams(outOfRange))
+	}
+	if len(duplicated) != 0 {
+		errs = append(errs, ErrPortsDuplicated.WithParams(duplicated))
+	}
+
+	var invalid []string
+	for _, q := range []string{o.memoryRequest, o.memoryLimit, o.cpuRequest} {
+		if q == "" {
+			continue
+		}
+		if _, err := resource.ParseQuantity(q); err != nil {
+			invalid = append(invalid, q)
+		}
+	}
+	if len(invalid) != 0 {
+		errs = append(errs, ErrInvalidResourceQuantities.WithParams(invalid))
+	}
+
+	for _, h := range o.hooks {
+		if err := h.event.validate(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// apply configures the instance with the options
+// The image is set first, as it moves the instance to the state 'Preparing' that is required by the other options
+func (o *options) apply(i *Instance) error {
+	for _, h := range o.hooks {
+		if err := i.AddLifecycleHook(h.event, h.hook); err != nil {
+			return err
+		}
+	}
+	if o.image == "" && !o.prebuilt {
+		return nil
+	}
+	i.mu.Lock()
+	var err error
+	if o.prebuilt {
+		err = i.usePrebuiltImage(context.Background(), o.image)
+	} else {
+		err = i.prepareImage(context.Background(), o.image)
+	}
+	i.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	if len(o.command) != 0 {
+		errs = append(errs, i.SetCommand(o.command...))
+	}
+	if len(o.args) != 0 {
+		errs = append(errs, i.SetArgs(o.args...))
+	}
+	for _, port := range o.portsTCP {
+		errs = append(errs, i.AddPortTCP(port))
+	}
+	for _, port := range o.portsUDP {
+		errs = append(errs, i.AddPortUDP(port))
+	}
+	// the variables are set in a stable order, as they are part of the image and its hash
+	keys := make([]string, 0, len(o.env))
+	for key := range o.env {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		errs = append(errs, i.SetEnvironmentVariable(key, o.env[key]))
+	}
+	if o.memoryRequest != "" || o.memoryLimit != "" {
+		errs = append(errs, i.SetMemory(o.memoryRequest, o.memoryLimit))
+	}
+	if o.cpuRequest != "" {
+		errs = append(errs, i.SetCPU(o.cpuRequest))
+	}
+	if o.livenessProbe != nil {
+		errs = append(errs, i.SetLivenessProbe(o.livenessProbe))
+	}
+	if o.readinessProbe != nil {
+		errs = append(errs, i.SetReadinessProbe(o.readinessProbe))
+	}
+	if o.startupProbe != nil {
+		errs = append(errs, i.SetStartupProbe(o.startupProbe))
+	}
+	for _, setup := range o.setups {
+		errs = append(errs, setup(i))
+	}
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
+	// the prebuilt instance is committed once configured, as Commit records the configured instances
+	if o.prebuilt {
+		i.recordCommit()
+	}
+	return nil
+}
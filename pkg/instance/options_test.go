@@ -0,0 +1,67 @@
+package instance
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+)
+
+func TestOptionsValidate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		opts     []Option
+		expected []error
+	}{
+		{
+			name: "valid options",
+			opts: []Option{
+				WithImage("alpine:latest"),
+				WithCommand("sleep", "infinity"),
+				WithPorts(8080, 9090),
+				WithPortsUDP(8080),
+				WithEnv(map[string]string{"KEY": "value"}),
+				WithResources("100Mi", "200Mi", "100m"),
+				WithProbes(&v1.Probe{}, nil, nil),
+			},
+		},
+		{
+			name: "no options",
+		},
+		{
+			name:     "options without image",
+			opts:     []Option{WithPorts(8080)},
+			expected: []error{ErrImageRequiredForOptions},
+		},
+		{
+			name: "all problems are reported",
+			opts: []Option{
+				WithImage("alpine:latest"),
+				WithPorts(0, 8080, 8080),
+				WithResources("lots", "", "100m"),
+			},
+			expected: []error{ErrPortsOutOfRange, ErrPortsDuplicated, ErrInvalidResourceQuantities},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := &options{}
+			for _, opt := range tt.opts {
+				opt(o)
+			}
+
+			err := o.validate()
+			if len(tt.expected) == 0 {
+				assert.NoError(t, err)
+				return
+			}
+			for _, expected := range tt.expected {
+				assert.True(t, errors.Is(err, expected), "expected %v in %v", expected, err)
+			}
+		})
+	}
+}
@@ -3,10 +3,34 @@ package instance
 import (
 	"context"
 	"fmt"
+	"maps"
+	"path"
+	"slices"
+	"time"
 
 	"gopkg.in/yaml.v3"
+	rbacv1 "k8s.io/api/rbac/v1"
+
+	"github.com/celestiaorg/knuu/pkg/k8s"
+)
+
+const (
+	// defaultOtelCollectionInterval is the default collection interval of the hostmetrics and kubeletstats receivers
+	defaultOtelCollectionInterval = 30 * time.Second
+	// nodeNameEnv is the environment variable of the otel collector set to the name of the node of the pod
+	nodeNameEnv = "K8S_NODE_NAME"
+	kubeletPort = 10250
 )
 
+// kubeletStatsPolicyRules grant the kubeletstats receiver to read the stats of the nodes
+var kubeletStatsPolicyRules = []rbacv1.PolicyRule{
+	{
+		APIGroups: []string{""},
+		Resources: []string{"nodes/stats"},
+		Verbs:     []string{"get"},
+	},
+}
+
 type OTelConfig struct {
 	Extensions Extensions `yaml:"extensions,omitempty"`
 	Receivers  Receivers  `yaml:"receivers,omitempty"`
@@ -17,6 +41,8 @@ type OTelConfig struct {
 
 type Extensions struct {
 	BasicAuthOTLP BasicAuthOTLP `yaml:"basicauth/otlp,omitempty"`
+	// BasicAuth are the basic authentications of the exporters added with AddOtelExporter, by identifier
+	BasicAuth map[string]BasicAuthOTLP `yaml:",inline"`
 }
 
 type BasicAuthOTLP struct {
@@ -29,9 +55,39 @@ type ClientAuth struct {
 }
 
 type Receivers struct {
-	OTLP       OTLP       `yaml:"otlp,omitempty"`
-	Prometheus Prometheus `yaml:"prometheus,omitempty"`
-	Jaeger     Jaeger     `yaml:"jaeger,omitempty"`
+	OTLP         OTLP          `yaml:"otlp,omitempty"`
+	Prometheus   Prometheus    `yaml:"prometheus,omitempty"`
+	Jaeger       Jaeger        `yaml:"jaeger,omitempty"`
+	HostMetrics  *HostMetrics  `yaml:"hostmetrics,omitempty"`
+	KubeletStats *KubeletStats `yaml:"kubeletstats,omitempty"`
+	FileLog      *FileLog      `yaml:"filelog,omitempty"`
+	// Sidecars are the receivers of the observable sidecars, by identifier
+	Sidecars map[string]any `yaml:",inline"`
+}
+
+type HostMetrics struct {
+	CollectionInterval string `yaml:"collection_interval,omitempty"`
+	// Scrapers are the scrapers enabled with their default configuration, by name
+	Scrapers map[string]struct{} `yaml:"scrapers"`
+}
+
+type KubeletStats struct {
+	CollectionInterval string   `yaml:"collection_interval,omitempty"`
+	AuthType           string   `yaml:"auth_type,omitempty"`
+	Endpoint           string   `yaml:"endpoint,omitempty"`
+	InsecureSkipVerify bool     `yaml:"insecure_skip_verify,omitempty"`
+	MetricGroups       []string `yaml:"metric_groups,omitempty"`
+}
+
+type FileLog struct {
+	Include         []string          `yaml:"include"`
+	StartAt         string            `yaml:"start_at,omitempty"`
+	IncludeFilePath bool              `yaml:"include_file_path,omitempty"`
+	Operators       []FileLogOperator `yaml:"operators,omitempty"`
+}
+
+type FileLogOperator struct {
+	Type string `yaml:"type"`
 }
 
 type OTLP struct {
@@ -91,11 +147,22 @@ type Exporters struct {
 	Jaeger                JaegerExporter                `yaml:"jaeger,omitempty"`
 	Prometheus            PrometheusExporter            `yaml:"prometheus,omitempty"`
 	PrometheusRemoteWrite PrometheusRemoteWriteExporter `yaml:"prometheusremotewrite,omitempty"`
+	// Additional are the exporters added with AddOtelExporter, by identifier
+	Additional map[string]ExporterSettings `yaml:",inline"`
+}
+
+// ExporterSettings are the settings of an exporter added with AddOtelExporter
+type ExporterSettings struct {
+	Endpoint string            `yaml:"endpoint"`
+	Auth     *OTLPAuth         `yaml:"auth,omitempty"`
+	Headers  map[string]string `yaml:"headers,omitempty"`
+	TLS      *TLS              `yaml:"tls,omitempty"`
 }
 
 type OTLPHTTPExporter struct {
 	Auth     OTLPAuth `yaml:"auth,omitempty"`
 	Endpoint string   `yaml:"endpoint,omitempty"`
+	TLS      *TLS     `yaml:"tls,omitempty"`
 }
 
 type OTLPAuth struct {
@@ -117,7 +184,12 @@ type PrometheusRemoteWriteExporter struct {
 }
 
 type TLS struct {
-	Insecure bool `yaml:"insecure,omitempty"`
+	Insecure           bool   `yaml:"insecure,omitempty"`
+	InsecureSkipVerify bool   `yaml:"insecure_skip_verify,omitempty"`
+	CAFile             string `yaml:"ca_file,omitempty"`
+	CertFile           string `yaml:"cert_file,omitempty"`
+	KeyFile            string `yaml:"key_file,omitempty"`
+	ServerName         string `yaml:"server_name_override,omitempty"`
 }
 
 type Service struct {
@@ -138,6 +210,7 @@ type MetricsTelemetry struct {
 type Pipelines struct {
 	Metrics Metrics `yaml:"metrics,omitempty"`
 	Traces  Traces  `yaml:"traces,omitempty"`
+	Logs    *Logs   `yaml:"logs,omitempty"`
 }
 
 type Metrics struct {
@@ -152,6 +225,12 @@ type Traces struct {
 	Processors []string `yaml:"processors,omitempty"`
 }
 
+type Logs struct {
+	Receivers  []string `yaml:"receivers,omitempty"`
+	Exporters  []string `yaml:"exporters,omitempty"`
+	Processors []string `yaml:"processors,omitempty"`
+}
+
 type Processors struct {
 	Batch         Batch         `yaml:"batch,omitempty"`
 	MemoryLimiter MemoryLimiter `yaml:"memory_limiter,omitempty"`
@@ -177,6 +256,10 @@ type Action struct {
 }
 
 func (i *Instance) createOtelCollectorInstance(ctx context.Context) (*Instance, error) {
+	if err := i.validateReceiverPorts(); err != nil {
+		return nil, err
+	}
+
 	otelAgent, err := New("otel-agent", i.SystemDependencies)
 	if err != nil {
 		return nil, ErrCreatingOtelAgentInstance.Wrap(err)
@@ -191,28 +274,40 @@ func (i *Instance) createOtelCollectorInstance(ctx context.Context) (*Instance,
 	if err := otelAgent.AddPortTCP(9090); err != nil {
 		return nil, ErrAddingOtelAgentPort.Wrap(err)
 	}
-	if err := otelAgent.SetCPU("100m"); err != nil {
-		return nil, ErrSettingOtelAgentCPU.Wrap(err)
+	if err := otelAgent.setResources(i.obsyConfig.otelCollectorRequest, i.obsyConfig.otelCollectorLimit); err != nil {
+		return nil, ErrSettingOtelAgentResources.Wrap(err)
+	}
+	if i.obsyConfig.kubeletStatsInterval != 0 {
+		otelAgent.fieldEnv = map[string]string{nodeNameEnv: "spec.nodeName"}
 	}
-	if err := otelAgent.SetMemory("100Mi", "200Mi"); err != nil {
-		return nil, ErrSettingOtelAgentMemory.Wrap(err)
+	for _, source := range i.otelSources() {
+		for _, dir := range source.logsSharedDirs() {
+			if !slices.ContainsFunc(otelAgent.sharedDirs, func(d *k8s.SharedDir) bool { return d.Name == dir.Name }) {
+				otelAgent.sharedDirs = append(otelAgent.sharedDirs, dir)
+			}
+		}
 	}
 	if err := otelAgent.Commit(); err != nil {
 		return nil, ErrCommittingOtelAgentInstance.Wrap(err)
 	}
 
-	config := OTelConfig{
-		Extensions: i.createExtensions(),
-		Receivers:  i.createReceivers(),
-		Exporters:  i.createExporters(),
-		Service:    i.createService(),
-		Processors: i.createProcessors(),
-	}
-
-	bytes, err := yaml.Marshal(config)
+	bytes, err := yaml.Marshal(i.createOtelConfig())
 	if err != nil {
 		return nil, ErrMarshalingYAML.Wrap(err)
 	}
+	// the TLS files hold the private keys of the clients, they are delivered through secrets instead of the
+	// ConfigMap of the files of the collector
+	tlsDirs := i.otelTLSSecretDirs()
+	dirs := make([]string, 0, len(tlsDirs))
+	for dir := range tlsDirs {
+		dirs = append(dirs, dir)
+	}
+	slices.Sort(dirs)
+	for _, dir := range dirs {
+		if err := otelAgent.AddSecretFiles(dir, tlsDirs[dir]); err != nil {
+			return nil, ErrAddingOtelAgentConfigFile.Wrap(err)
+		}
+	}
 
 	if err := otelAgent.AddFileBytes(bytes, "/etc/otel-agent.yaml", "0:0"); err != nil {
 		return nil, ErrAddingOtelAgentConfigFile.Wrap(err)
@@ -225,19 +320,67 @@ func (i *Instance) createOtelCollectorInstance(ctx context.Context) (*Instance,
 	return otelAgent, nil
 }
 
-func (i *Instance) createExtensions() Extensions {
-	if i.obsyConfig.otlpEndpoint == "" {
-		return Extensions{}
+// createOtelConfig returns the configuration of the otel collector sidecar
+func (i *Instance) createOtelConfig() OTelConfig {
+	return OTelConfig{
+		Extensions: i.createExtensions(),
+		Receivers:  i.createReceivers(),
+		Exporters:  i.createExporters(),
+		Service:    i.createService(),
+		Processors: i.createProcessors(),
+	}
+}
+
+// otelTLSFiles returns the content of the certificate files of the exporters, by path in the collector container
+func (i *Instance) otelTLSFiles() map[string][]byte {
+	files := make(map[string][]byte)
+	for exporterType, tls := range i.obsyConfig.exporterTLS {
+		maps.Copy(files, tls.files(otelTLSDirOf(string(exporterType))))
 	}
+	for _, exporter := range i.obsyConfig.exporters {
+		maps.Copy(files, exporter.TLS.files(otelTLSDirOf(exporter.id())))
+	}
+	return files
+}
+
+// otelTLSSecretDirs returns the files of otelTLSFiles by directory and file name, see AddSecretFiles
+func (i *Instance) otelTLSSecretDirs() map[string]map[string][]byte {
+	dirs := make(map[string]map[string][]byte)
+	for file, content := range i.otelTLSFiles() {
+		dir := path.Dir(file)
+		if dirs[dir] == nil {
+			dirs[dir] = make(map[string][]byte)
+		}
+		dirs[dir][path.Base(file)] = content
+	}
+	return dirs
+}
 
-	return Extensions{
-		BasicAuthOTLP: BasicAuthOTLP{
+func (i *Instance) createExtensions() Extensions {
+	extensions := Extensions{}
+	if i.obsyConfig.otlpEndpoint != "" {
+		extensions.BasicAuthOTLP = BasicAuthOTLP{
 			ClientAuth: ClientAuth{
 				Username: i.obsyConfig.otlpUsername,
 				Password: i.obsyConfig.otlpPassword,
 			},
-		},
+		}
+	}
+	for _, exporter := range i.obsyConfig.exporters {
+		if exporter.Username == "" {
+			continue
+		}
+		if extensions.BasicAuth == nil {
+			extensions.BasicAuth = make(map[string]BasicAuthOTLP)
+		}
+		extensions.BasicAuth[exporter.authenticator()] = BasicAuthOTLP{
+			ClientAuth: ClientAuth{
+				Username: exporter.Username,
+				Password: exporter.Password,
+			},
+		}
 	}
+	return extensions
 }
 
 func (i *Instance) createOtlpReceiver() OTLP {
@@ -250,29 +393,37 @@ func (i *Instance) createOtlpReceiver() OTLP {
 	}
 }
 
+func (i *Instance) createPrometheusScrapeConfig() ScrapeConfig {
+	return ScrapeConfig{
+		JobName:        i.obsyConfig.prometheusEndpointJobName,
+		ScrapeInterval: i.obsyConfig.prometheusEndpointScrapeInterval,
+		StaticConfigs: []StaticConfig{
+			{
+				Targets: []string{fmt.Sprintf("localhost:%d", i.obsyConfig.prometheusEndpointPort)},
+			},
+		},
+	}
+}
+
 func (i *Instance) createPrometheusReceiver() Prometheus {
+	var scrapeConfigs []ScrapeConfig
+	for _, source := range i.otelSources() {
+		if source.obsyConfig.prometheusEndpointPort != 0 {
+			scrapeConfigs = append(scrapeConfigs, source.createPrometheusScrapeConfig())
+		}
+	}
+	scrapeConfigs = append(scrapeConfigs, ScrapeConfig{
+		JobName:        "internal-telemetry",
+		ScrapeInterval: "10s",
+		StaticConfigs: []StaticConfig{
+			{
+				Targets: []string{"localhost:8888"},
+			},
+		},
+	})
 	return Prometheus{
 		Config: PrometheusConfig{
-			ScrapeConfigs: []ScrapeConfig{
-				{
-					JobName:        i.obsyConfig.prometheusEndpointJobName,
-					ScrapeInterval: i.obsyConfig.prometheusEndpointScrapeInterval,
-					StaticConfigs: []StaticConfig{
-						{
-							Targets: []string{fmt.Sprintf("localhost:%d", i.obsyConfig.prometheusEndpointPort)},
-						},
-					},
-				},
-				{
-					JobName:        "internal-telemetry",
-					ScrapeInterval: "10s",
-					StaticConfigs: []StaticConfig{
-						{
-							Targets: []string{"localhost:8888"},
-						},
-					},
-				},
-			},
+			ScrapeConfigs: scrapeConfigs,
 		},
 	}
 }
@@ -291,6 +442,31 @@ func (i *Instance) createJaegerReceiver() Jaeger {
 	}
 }
 
+func (i *Instance) createHostMetricsReceiver() *HostMetrics {
+	return &HostMetrics{
+		CollectionInterval: i.obsyConfig.hostMetricsInterval.String(),
+		Scrapers: map[string]struct{}{
+			"cpu":        {},
+			"memory":     {},
+			"load":       {},
+			"disk":       {},
+			"filesystem": {},
+			"network":    {},
+		},
+	}
+}
+
+func (i *Instance) createKubeletStatsReceiver() *KubeletStats {
+	return &KubeletStats{
+		CollectionInterval: i.obsyConfig.kubeletStatsInterval.String(),
+		AuthType:           "serviceAccount",
+		Endpoint:           fmt.Sprintf("https://${env:%s}:%d", nodeNameEnv, kubeletPort),
+		// the certificates of the kubelets are usually self signed or not issued for their node name
+		InsecureSkipVerify: true,
+		MetricGroups:       []string{"node", "pod", "container"},
+	}
+}
+
 func (i *Instance) createReceivers() Receivers {
 	receivers := Receivers{}
 
@@ -298,7 +474,7 @@ func (i *Instance) createReceivers() Receivers {
 		receivers.OTLP = i.createOtlpReceiver()
 	}
 
-	if i.obsyConfig.prometheusEndpointPort != 0 {
+	if i.scrapesPrometheus() {
 		receivers.Prometheus = i.createPrometheusReceiver()
 	}
 
@@ -306,24 +482,50 @@ func (i *Instance) createReceivers() Receivers {
 		receivers.Jaeger = i.createJaegerReceiver()
 	}
 
+	if i.obsyConfig.hostMetricsInterval != 0 {
+		receivers.HostMetrics = i.createHostMetricsReceiver()
+	}
+
+	if i.obsyConfig.kubeletStatsInterval != 0 {
+		receivers.KubeletStats = i.createKubeletStatsReceiver()
+	}
+
+	if len(i.obsyConfig.logsPaths) != 0 {
+		receivers.FileLog = i.createFileLogReceiver()
+	}
+
+	receivers.Sidecars = i.createSidecarReceivers()
+
 	return receivers
 }
 
 func (i *Instance) createOtlpHttpExporter() OTLPHTTPExporter {
-	return OTLPHTTPExporter{
+	exporter := OTLPHTTPExporter{
 		Auth: OTLPAuth{
 			Authenticator: "basicauth/otlp",
 		},
 		Endpoint: i.obsyConfig.otlpEndpoint,
 	}
+	if tls, ok := i.obsyConfig.exporterTLS[OtelExporterOTLPHTTP]; ok {
+		exporter.TLS = tls.settings(otelTLSDirOf(string(OtelExporterOTLPHTTP)))
+	}
+	return exporter
+}
+
+// exporterTLS returns the TLS settings of the exporter of the given type set with SetOtelExporterTLS, TLS
+// disabled by default
+func (i *Instance) exporterTLS(exporterType OtelExporterType) TLS {
+	tls, ok := i.obsyConfig.exporterTLS[exporterType]
+	if !ok {
+		return TLS{Insecure: true}
+	}
+	return *tls.settings(otelTLSDirOf(string(exporterType)))
 }
 
 func (i *Instance) createJaegerExporter() JaegerExporter {
 	return JaegerExporter{
 		Endpoint: i.obsyConfig.jaegerEndpoint,
-		TLS: TLS{
-			Insecure: true,
-		},
+		TLS:      i.exporterTLS(OtelExporterJaeger),
 	}
 }
 
@@ -336,9 +538,7 @@ func (i *Instance) createPrometheusExporter() PrometheusExporter {
 func (i *Instance) createPrometheusRemoteWriteExporter() PrometheusRemoteWriteExporter {
 	return PrometheusRemoteWriteExporter{
 		Endpoint: i.obsyConfig.prometheusRemoteWriteExporterEndpoint,
-		TLS: TLS{
-			Insecure: true,
-		},
+		TLS:      i.exporterTLS(OtelExporterPrometheusRemoteWrite),
 	}
 }
 
@@ -361,6 +561,13 @@ func (i *Instance) createExporters() Exporters {
 		exporters.PrometheusRemoteWrite = i.createPrometheusRemoteWriteExporter()
 	}
 
+	for _, exporter := range i.obsyConfig.exporters {
+		if exporters.Additional == nil {
+			exporters.Additional = make(map[string]ExporterSettings)
+		}
+		exporters.Additional[exporter.id()] = exporter.createExporter()
+	}
+
 	return exporters
 }
 
@@ -369,9 +576,16 @@ func (i *Instance) prepareMetricsForServicePipeline() Metrics {
 	if i.obsyConfig.otlpPort != 0 {
 		metrics.Receivers = append(metrics.Receivers, "otlp")
 	}
-	if i.obsyConfig.prometheusEndpointPort != 0 {
+	if i.scrapesPrometheus() {
 		metrics.Receivers = append(metrics.Receivers, "prometheus")
 	}
+	if i.obsyConfig.hostMetricsInterval != 0 {
+		metrics.Receivers = append(metrics.Receivers, "hostmetrics")
+	}
+	if i.obsyConfig.kubeletStatsInterval != 0 {
+		metrics.Receivers = append(metrics.Receivers, "kubeletstats")
+	}
+	metrics.Receivers = append(metrics.Receivers, i.sidecarReceiverIDs(OtelSignalMetrics)...)
 	if i.obsyConfig.otlpEndpoint != "" {
 		metrics.Exporters = append(metrics.Exporters, "otlphttp")
 	}
@@ -381,6 +595,7 @@ func (i *Instance) prepareMetricsForServicePipeline() Metrics {
 	if i.obsyConfig.prometheusRemoteWriteExporterEndpoint != "" {
 		metrics.Exporters = append(metrics.Exporters, "prometheusremotewrite")
 	}
+	metrics.Exporters = append(metrics.Exporters, i.otelExporterIDs(OtelSignalMetrics)...)
 	metrics.Processors = []string{"attributes"}
 	return metrics
 }
@@ -393,25 +608,46 @@ func (i *Instance) prepareTracesForServicePipeline() Traces {
 	if i.obsyConfig.jaegerGrpcPort != 0 || i.obsyConfig.jaegerThriftCompactPort != 0 || i.obsyConfig.jaegerThriftHttpPort != 0 {
 		traces.Receivers = append(traces.Receivers, "jaeger")
 	}
+	traces.Receivers = append(traces.Receivers, i.sidecarReceiverIDs(OtelSignalTraces)...)
 	if i.obsyConfig.otlpEndpoint != "" {
 		traces.Exporters = append(traces.Exporters, "otlphttp")
 	}
 	if i.obsyConfig.jaegerEndpoint != "" {
 		traces.Exporters = append(traces.Exporters, "jaeger")
 	}
+	traces.Exporters = append(traces.Exporters, i.otelExporterIDs(OtelSignalTraces)...)
 	traces.Processors = []string{"attributes"}
 	return traces
 }
 
+// otelExporterIDs returns the identifiers of the exporters added with AddOtelExporter the signal is routed to
+func (i *Instance) otelExporterIDs(signal OtelSignal) []string {
+	var ids []string
+	for _, exporter := range i.obsyConfig.exporters {
+		if exporter.exports(signal) {
+			ids = append(ids, exporter.id())
+		}
+	}
+	return ids
+}
+
 func (i *Instance) createService() Service {
 	var extensions []string
 	if i.obsyConfig.otlpEndpoint != "" {
 		extensions = append(extensions, "basicauth/otlp")
 	}
+	for _, exporter := range i.obsyConfig.exporters {
+		if exporter.Username != "" {
+			extensions = append(extensions, exporter.authenticator())
+		}
+	}
 
 	pipelines := Pipelines{}
 	pipelines.Metrics = i.prepareMetricsForServicePipeline()
 	pipelines.Traces = i.prepareTracesForServicePipeline()
+	if i.collectsLogs() {
+		pipelines.Logs = i.prepareLogsForServicePipeline()
+	}
 
 	telemetry := Telemetry{
 		Metrics: MetricsTelemetry{
@@ -0,0 +1,180 @@
+package instance
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// OtelExporterType is the type of an exporter of the OpenTelemetry collector
+type OtelExporterType string
+
+const (
+	OtelExporterOTLPHTTP              OtelExporterType = "otlphttp"
+	OtelExporterOTLPGRPC              OtelExporterType = "otlp"
+	OtelExporterJaeger                OtelExporterType = "jaeger"
+	OtelExporterPrometheusRemoteWrite OtelExporterType = "prometheusremotewrite"
+)
+
+// OtelSignal is a kind of telemetry routed by the pipelines of the OpenTelemetry collector
+type OtelSignal string
+
+const (
+	OtelSignalTraces  OtelSignal = "traces"
+	OtelSignalMetrics OtelSignal = "metrics"
+	OtelSignalLogs    OtelSignal = "logs"
+)
+
+// otelExporterSignals are the signals supported by each type of exporter
+var otelExporterSignals = map[OtelExporterType][]OtelSignal{
+	OtelExporterOTLPHTTP:              {OtelSignalTraces, OtelSignalMetrics, OtelSignalLogs},
+	OtelExporterOTLPGRPC:              {OtelSignalTraces, OtelSignalMetrics, OtelSignalLogs},
+	OtelExporterJaeger:                {OtelSignalTraces},
+	OtelExporterPrometheusRemoteWrite: {OtelSignalMetrics},
+}
+
+var otelExporterNameRegexp = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
+// otelTLSDir is the directory of the certificates of the exporters in the collector container
+const otelTLSDir = "/etc/otel-agent/tls"
+
+// OtelExporter is an exporter of the OpenTelemetry collector sidecar, in addition to the ones set with
+// SetOtlpExporter, SetJaegerExporter and SetPrometheusRemoteWriteExporter
+// Several exporters of the same type can be added with different names, e.g. to send the metrics to two
+// Prometheus remote writes.
+type OtelExporter struct {
+	// Name distinguishes the exporters of the same type, the exporter is named 'type/name' in the collector
+	Name     string
+	Type     OtelExporterType
+	Endpoint string
+	// Signals are the signals routed to the exporter, all the signals supported by its type if empty
+	Signals []OtelSignal
+	// Username and Password are sent with basic authentication, if Username is not empty
+	Username string
+	Password string
+	// Headers are added to the requests of the exporter, e.g. an API key
+	Headers map[string]string
+	TLS     OtelTLS
+}
+
+// OtelTLS is the TLS configuration of an exporter
+// The certificates are written to files of the collector sidecar, referenced by its configuration.
+type OtelTLS struct {
+	// Insecure disables TLS, e.g. for an endpoint in the cluster
+	Insecure bool
+	// InsecureSkipVerify does not verify the certificate of the endpoint
+	InsecureSkipVerify bool
+	// CA is the PEM encoded certificate authority of the endpoint, the system ones are used if empty
+	CA []byte
+	// Cert and Key are the PEM encoded client certificate and its key, for mutual TLS
+	Cert []byte
+	Key  []byte
+	// ServerName overrides the name of the server the certificate is verified against
+	ServerName string
+}
+
+func (t OtelTLS) isSet() bool {
+	return t.Insecure || t.InsecureSkipVerify || len(t.CA) != 0 || len(t.Cert) != 0 || len(t.Key) != 0 || t.ServerName != ""
+}
+
+func (t OtelTLS) validate() error {
+	if (len(t.Cert) == 0) != (len(t.Key) == 0) {
+		return fmt.Errorf("the client certificate and its key must be set together")
+	}
+	if t.Insecure && (len(t.CA) != 0 || len(t.Cert) != 0) {
+		return fmt.Errorf("certificates cannot be set when TLS is disabled")
+	}
+	return nil
+}
+
+// settings returns the TLS settings of the collector with the certificates in the given directory, see files
+func (t OtelTLS) settings(dir string) *TLS {
+	tls := &TLS{
+		Insecure:           t.Insecure,
+		InsecureSkipVerify: t.InsecureSkipVerify,
+		ServerName:         t.ServerName,
+	}
+	if len(t.CA) != 0 {
+		tls.CAFile = path.Join(dir, "ca.pem")
+	}
+	if len(t.Cert) != 0 {
+		tls.CertFile = path.Join(dir, "cert.pem")
+		tls.KeyFile = path.Join(dir, "key.pem")
+	}
+	return tls
+}
+
+// files returns the content of the certificate files in the given directory, by path
+func (t OtelTLS) files(dir string) map[string][]byte {
+	files := make(map[string][]byte)
+	if len(t.CA) != 0 {
+		files[path.Join(dir, "ca.pem")] = t.CA
+	}
+	if len(t.Cert) != 0 {
+		files[path.Join(dir, "cert.pem")] = t.Cert
+		files[path.Join(dir, "key.pem")] = t.Key
+	}
+	return files
+}
+
+// otelTLSDirOf returns the directory of the certificates of the exporter with the given identifier
+func otelTLSDirOf(id string) string {
+	return path.Join(otelTLSDir, strings.ReplaceAll(id, "/", "-"))
+}
+
+// id returns the identifier of the exporter in the configuration of the collector
+func (e OtelExporter) id() string {
+	return fmt.Sprintf("%s/%s", e.Type, e.Name)
+}
+
+// authenticator returns the identifier of the basic authentication extension of the exporter
+func (e OtelExporter) authenticator() string {
+	return "basicauth/" + e.Name
+}
+
+// exports returns true if the signal is routed to the exporter
+func (e OtelExporter) exports(signal OtelSignal) bool {
+	if len(e.Signals) == 0 {
+		return slices.Contains(otelExporterSignals[e.Type], signal)
+	}
+	return slices.Contains(e.Signals, signal)
+}
+
+func (e OtelExporter) validate() error {
+	if !otelExporterNameRegexp.MatchString(e.Name) {
+		return ErrInvalidOtelExporter.WithParams(e.Name, "the name must only contain letters, digits, '_' and '-'")
+	}
+	supported, ok := otelExporterSignals[e.Type]
+	if !ok {
+		return ErrInvalidOtelExporter.WithParams(e.Name, fmt.Sprintf("unknown type '%s'", e.Type))
+	}
+	if e.Endpoint == "" {
+		return ErrInvalidOtelExporter.WithParams(e.Name, "the endpoint is empty")
+	}
+	for _, signal := range e.Signals {
+		if !slices.Contains(supported, signal) {
+			return ErrInvalidOtelExporter.WithParams(e.Name, fmt.Sprintf("an exporter of type '%s' cannot export %s", e.Type, signal))
+		}
+	}
+	if err := e.TLS.validate(); err != nil {
+		return ErrInvalidOtelExporter.WithParams(e.Name, err.Error())
+	}
+	return nil
+}
+
+// createExporter returns the configuration of the exporter in the collector
+func (e OtelExporter) createExporter() ExporterSettings {
+	settings := ExporterSettings{
+		Endpoint: e.Endpoint,
+		Headers:  e.Headers,
+	}
+	if e.Username != "" {
+		settings.Auth = &OTLPAuth{Authenticator: e.authenticator()}
+	}
+	if e.TLS.isSet() {
+		settings.TLS = e.TLS.settings(otelTLSDirOf(e.id()))
+	}
+	return settings
+}
@@ -0,0 +1,151 @@
+package instance
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	"github.com/celestiaorg/knuu/pkg/k8s/fake"
+	"github.com/celestiaorg/knuu/pkg/system"
+)
+
+func TestAddOtelExporter(t *testing.T) {
+	t.Parallel()
+
+	k8sCli, err := fake.New(context.Background(), "test")
+	require.NoError(t, err)
+	i, err := New("validator", system.SystemDependencies{K8sCli: k8sCli})
+	require.NoError(t, err)
+	i.state = Preparing
+
+	require.NoError(t, i.SetOtelEndpoint(4318))
+	require.NoError(t, i.SetPrometheusEndpoint(26660, "validator", "15s"))
+	require.NoError(t, i.AddOtelExporter(OtelExporter{
+		Name:     "tracing",
+		Type:     OtelExporterJaeger,
+		Endpoint: "jaeger-collector:14250",
+		TLS:      OtelTLS{Insecure: true},
+	}))
+	require.NoError(t, i.AddOtelExporter(OtelExporter{
+		Name:     "local",
+		Type:     OtelExporterPrometheusRemoteWrite,
+		Endpoint: "http://prometheus:9090/api/v1/write",
+	}))
+	require.NoError(t, i.AddOtelExporter(OtelExporter{
+		Name:     "grafana-cloud",
+		Type:     OtelExporterPrometheusRemoteWrite,
+		Endpoint: "https://prometheus.grafana.net/api/prom/push",
+		Username: "user",
+		Password: "secret",
+		Headers:  map[string]string{"X-Scope-OrgID": "knuu"},
+		TLS:      OtelTLS{CA: []byte("-----BEGIN CERTIFICATE-----")},
+	}))
+
+	data, err := yaml.Marshal(i.createOtelConfig())
+	require.NoError(t, err)
+	var config map[string]any
+	require.NoError(t, yaml.Unmarshal(data, &config))
+
+	exporters := config["exporters"].(map[string]any)
+	assert.Equal(t, map[string]any{
+		"endpoint": "jaeger-collector:14250",
+		"tls":      map[string]any{"insecure": true},
+	}, exporters["jaeger/tracing"])
+	assert.Equal(t, map[string]any{"endpoint": "http://prometheus:9090/api/v1/write"}, exporters["prometheusremotewrite/local"])
+	assert.Equal(t, map[string]any{
+		"endpoint": "https://prometheus.grafana.net/api/prom/push",
+		"auth":     map[string]any{"authenticator": "basicauth/grafana-cloud"},
+		"headers":  map[string]any{"X-Scope-OrgID": "knuu"},
+		"tls":      map[string]any{"ca_file": "/etc/otel-agent/tls/prometheusremotewrite-grafana-cloud/ca.pem"},
+	}, exporters["prometheusremotewrite/grafana-cloud"])
+
+	extensions := config["extensions"].(map[string]any)
+	assert.Equal(t, map[string]any{"client_auth": map[string]any{"username": "user", "password": "secret"}}, extensions["basicauth/grafana-cloud"])
+
+	service := config["service"].(map[string]any)
+	assert.Equal(t, []any{"basicauth/grafana-cloud"}, service["extensions"])
+	pipelines := service["pipelines"].(map[string]any)
+	assert.Equal(t, []any{"jaeger/tracing"}, pipelines["traces"].(map[string]any)["exporters"])
+	assert.Equal(t, []any{"prometheusremotewrite/local", "prometheusremotewrite/grafana-cloud"}, pipelines["metrics"].(map[string]any)["exporters"])
+}
+
+func TestSetOtelExporterTLS(t *testing.T) {
+	t.Parallel()
+
+	k8sCli, err := fake.New(context.Background(), "test")
+	require.NoError(t, err)
+	i, err := New("validator", system.SystemDependencies{K8sCli: k8sCli})
+	require.NoError(t, err)
+	i.state = Preparing
+
+	require.NoError(t, i.SetOtelEndpoint(4318))
+	require.NoError(t, i.SetOtlpExporter("https://otlp.example.com", "user", "secret"))
+	require.NoError(t, i.SetJaegerExporter("jaeger-collector:14250"))
+	require.NoError(t, i.SetPrometheusRemoteWriteExporter("https://prometheus.example.com/api/v1/write"))
+	require.NoError(t, i.SetOtelExporterTLS(OtelExporterOTLPHTTP, OtelTLS{CA: []byte("ca")}))
+	require.NoError(t, i.SetOtelExporterTLS(OtelExporterPrometheusRemoteWrite, OtelTLS{
+		Cert:       []byte("cert"),
+		Key:        []byte("key"),
+		ServerName: "prometheus",
+	}))
+	require.NoError(t, i.AddOtelExporter(OtelExporter{
+		Name:     "tempo",
+		Type:     OtelExporterOTLPGRPC,
+		Endpoint: "tempo:4317",
+		TLS:      OtelTLS{InsecureSkipVerify: true, CA: []byte("tempo-ca")},
+	}))
+
+	config := i.createOtelConfig()
+	assert.Equal(t, &TLS{CAFile: "/etc/otel-agent/tls/otlphttp/ca.pem"}, config.Exporters.OTLPHTTP.TLS)
+	assert.Equal(t, TLS{Insecure: true}, config.Exporters.Jaeger.TLS, "TLS is disabled by default")
+	assert.Equal(t, TLS{
+		CertFile:   "/etc/otel-agent/tls/prometheusremotewrite/cert.pem",
+		KeyFile:    "/etc/otel-agent/tls/prometheusremotewrite/key.pem",
+		ServerName: "prometheus",
+	}, config.Exporters.PrometheusRemoteWrite.TLS)
+	assert.Equal(t, &TLS{InsecureSkipVerify: true, CAFile: "/etc/otel-agent/tls/otlp-tempo/ca.pem"}, config.Exporters.Additional["otlp/tempo"].TLS)
+
+	assert.Equal(t, map[string][]byte{
+		"/etc/otel-agent/tls/otlphttp/ca.pem":                []byte("ca"),
+		"/etc/otel-agent/tls/prometheusremotewrite/cert.pem": []byte("cert"),
+		"/etc/otel-agent/tls/prometheusremotewrite/key.pem":  []byte("key"),
+		"/etc/otel-agent/tls/otlp-tempo/ca.pem":              []byte("tempo-ca"),
+	}, i.otelTLSFiles())
+
+	// the private keys are delivered through secrets, by directory
+	assert.Equal(t, map[string][]byte{"cert.pem": []byte("cert"), "key.pem": []byte("key")},
+		i.otelTLSSecretDirs()["/etc/otel-agent/tls/prometheusremotewrite"])
+	assert.Len(t, i.otelTLSSecretDirs(), 3)
+
+	assert.ErrorIs(t, i.SetOtelExporterTLS(OtelExporterOTLPGRPC, OtelTLS{}), ErrInvalidOtelExporter, "otlp is only available with AddOtelExporter")
+	assert.ErrorIs(t, i.SetOtelExporterTLS(OtelExporterJaeger, OtelTLS{Cert: []byte("cert")}), ErrInvalidOtelExporter, "the key is missing")
+	assert.ErrorIs(t, i.SetOtelExporterTLS(OtelExporterJaeger, OtelTLS{Insecure: true, CA: []byte("ca")}), ErrInvalidOtelExporter)
+}
+
+func TestAddOtelExporterInvalid(t *testing.T) {
+	t.Parallel()
+
+	i, err := New("validator", system.SystemDependencies{})
+	require.NoError(t, err)
+	i.state = Preparing
+
+	for _, exporter := range []OtelExporter{
+		{Name: "", Type: OtelExporterOTLPHTTP, Endpoint: "http://tempo:4318"},
+		{Name: "tempo/1", Type: OtelExporterOTLPHTTP, Endpoint: "http://tempo:4318"},
+		{Name: "tempo", Type: "zipkin", Endpoint: "http://tempo:4318"},
+		{Name: "tempo", Type: OtelExporterOTLPHTTP},
+		{Name: "jaeger", Type: OtelExporterJaeger, Endpoint: "jaeger:14250", Signals: []OtelSignal{OtelSignalMetrics}},
+	} {
+		assert.ErrorIs(t, i.AddOtelExporter(exporter), ErrInvalidOtelExporter, exporter)
+	}
+
+	tempo := OtelExporter{Name: "tempo", Type: OtelExporterOTLPHTTP, Endpoint: "http://tempo:4318", Signals: []OtelSignal{OtelSignalTraces}}
+	require.NoError(t, i.AddOtelExporter(tempo))
+	assert.ErrorIs(t, i.AddOtelExporter(tempo), ErrOtelExporterAlreadyExists)
+
+	i.state = Started
+	assert.ErrorIs(t, i.AddOtelExporter(OtelExporter{Name: "other", Type: OtelExporterOTLPHTTP, Endpoint: "http://tempo:4318"}), ErrSettingNotAllowed)
+}
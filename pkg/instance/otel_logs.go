@@ -0,0 +1,98 @@
+package instance
+
+import (
+	"fmt"
+	"hash/fnv"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/celestiaorg/knuu/pkg/k8s"
+)
+
+// OtelLogParser is the parser of the lines of the log files collected by the OpenTelemetry collector sidecar
+type OtelLogParser string
+
+const (
+	// OtelLogParserNone ships each line as the body of a log record
+	OtelLogParserNone OtelLogParser = ""
+	// OtelLogParserJSON parses each line as a JSON object into the attributes of the log record
+	OtelLogParserJSON OtelLogParser = "json"
+	// OtelLogParserKeyValue parses each line of 'key=value' pairs, e.g. logfmt, into the attributes of the log record
+	OtelLogParserKeyValue OtelLogParser = "key_value"
+)
+
+// otelLogParserOperators are the filelog operators of each parser
+var otelLogParserOperators = map[OtelLogParser][]FileLogOperator{
+	OtelLogParserNone:     nil,
+	OtelLogParserJSON:     {{Type: "json_parser"}},
+	OtelLogParserKeyValue: {{Type: "key_value_parser"}},
+}
+
+// validateLogsCollection checks that the paths of the log files are absolute and that their directories, which are
+// shared with the collector sidecar, do not contain a glob pattern
+func validateLogsCollection(paths []string, parser OtelLogParser) error {
+	if len(paths) == 0 {
+		return ErrInvalidLogsCollection.WithParams("no log file path")
+	}
+	for _, p := range paths {
+		if !path.IsAbs(p) {
+			return ErrInvalidLogsCollection.WithParams(fmt.Sprintf("the path '%s' is not absolute", p))
+		}
+		if dir := path.Dir(p); dir == "/" || strings.ContainsAny(dir, "*?[") {
+			return ErrInvalidLogsCollection.WithParams(fmt.Sprintf("the directory of the path '%s' cannot be shared", p))
+		}
+	}
+	if _, ok := otelLogParserOperators[parser]; !ok {
+		return ErrInvalidLogsCollection.WithParams(fmt.Sprintf("unknown parser '%s'", parser))
+	}
+	return nil
+}
+
+// logsSharedDirs returns the directories of the log files collected from the instance, shared with the collector
+// sidecar through emptyDir volumes
+func (i *Instance) logsSharedDirs() []*k8s.SharedDir {
+	var dirs []string
+	for _, p := range i.obsyConfig.logsPaths {
+		if dir := path.Dir(p); !slices.Contains(dirs, dir) {
+			dirs = append(dirs, dir)
+		}
+	}
+	sharedDirs := make([]*k8s.SharedDir, 0, len(dirs))
+	for _, dir := range dirs {
+		sharedDirs = append(sharedDirs, &k8s.SharedDir{Name: logsSharedDirName(dir), Path: dir})
+	}
+	return sharedDirs
+}
+
+// logsSharedDirName returns the name of the volume of a directory of log files, the same for the instance and its
+// sidecars so that a directory shared by several containers is a single volume
+func logsSharedDirName(dir string) string {
+	h := fnv.New32a()
+	h.Write([]byte(dir))
+	return fmt.Sprintf("otel-logs-%08x", h.Sum32())
+}
+
+func (i *Instance) createFileLogReceiver() *FileLog {
+	return &FileLog{
+		Include:         i.obsyConfig.logsPaths,
+		StartAt:         "beginning",
+		IncludeFilePath: true,
+		Operators:       otelLogParserOperators[i.obsyConfig.logsParser],
+	}
+}
+
+func (i *Instance) prepareLogsForServicePipeline() *Logs {
+	logs := &Logs{
+		Processors: []string{"attributes"},
+	}
+	if len(i.obsyConfig.logsPaths) != 0 {
+		logs.Receivers = append(logs.Receivers, "filelog")
+	}
+	logs.Receivers = append(logs.Receivers, i.sidecarReceiverIDs(OtelSignalLogs)...)
+	if i.obsyConfig.otlpEndpoint != "" {
+		logs.Exporters = append(logs.Exporters, "otlphttp")
+	}
+	logs.Exporters = append(logs.Exporters, i.otelExporterIDs(OtelSignalLogs)...)
+	return logs
+}
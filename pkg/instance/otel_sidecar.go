@@ -0,0 +1,158 @@
+package instance
+
+import "slices"
+
+// observableSidecars returns the sidecars of the instance with observability enabled
+func (i *Instance) observableSidecars() []*Instance {
+	var sidecars []*Instance
+	for _, sidecar := range i.sidecars {
+		if sidecar.isObservabilityEnabled() {
+			sidecars = append(sidecars, sidecar)
+		}
+	}
+	return sidecars
+}
+
+// otelSources returns the instance and its observable sidecars, whose telemetry is collected by the collector
+// sidecar of the instance
+func (i *Instance) otelSources() []*Instance {
+	return append([]*Instance{i}, i.observableSidecars()...)
+}
+
+// isPodObservabilityEnabled returns true if the observability of the instance or of one of its sidecars is enabled,
+// in which case the collector sidecar is added to the pod
+func (i *Instance) isPodObservabilityEnabled() bool {
+	return i.isObservabilityEnabled() || len(i.observableSidecars()) != 0
+}
+
+// scrapesPrometheus returns true if the prometheus endpoint of the instance or of one of its sidecars is set
+func (i *Instance) scrapesPrometheus() bool {
+	for _, source := range i.otelSources() {
+		if source.obsyConfig.prometheusEndpointPort != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// collectsLogs returns true if log files of the instance or of one of its sidecars are collected
+func (i *Instance) collectsLogs() bool {
+	for _, source := range i.otelSources() {
+		if len(source.obsyConfig.logsPaths) != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// sidecarReceiverID returns the identifier of a receiver of the given type for the sidecar, e.g. 'otlp/<name>'
+func sidecarReceiverID(receiverType string, sidecar *Instance) string {
+	return receiverType + "/" + sidecar.k8sName
+}
+
+// receiverPorts returns the ports the OTLP or Jaeger receiver of the instance listens on, the unset ones being 0
+func (i *Instance) receiverPorts(receiverType string) []int {
+	if receiverType == "otlp" {
+		return []int{i.obsyConfig.otlpPort}
+	}
+	return []int{i.obsyConfig.jaegerGrpcPort, i.obsyConfig.jaegerThriftCompactPort, i.obsyConfig.jaegerThriftHttpPort}
+}
+
+// ownsReceiver returns true if the collector has an OTLP or Jaeger receiver of the given type for the sidecar
+// The sidecars whose receiver listens on the same ports as the one of the instance, or of a previous sidecar, share
+// it: the collector cannot listen twice on a port, and the telemetry of both is sent to it anyway.
+func (i *Instance) ownsReceiver(receiverType string, sidecar *Instance) bool {
+	ports := sidecar.receiverPorts(receiverType)
+	if ports[0] == 0 {
+		return false
+	}
+	for _, source := range i.otelSources() {
+		if source == sidecar {
+			return true
+		}
+		if slices.Equal(source.receiverPorts(receiverType), ports) {
+			return false
+		}
+	}
+	return true
+}
+
+// validateReceiverPorts returns an error if two receivers of the collector listen on the same port, e.g. the OTLP
+// receiver of a sidecar on the Jaeger port of the instance
+func (i *Instance) validateReceiverPorts() error {
+	receivers := make(map[int]string)
+	add := func(id string, ports []int) error {
+		for _, port := range ports {
+			if port == 0 {
+				continue
+			}
+			if other, ok := receivers[port]; ok {
+				return ErrOtelReceiverPortConflict.WithParams(port, other, id)
+			}
+			receivers[port] = id
+		}
+		return nil
+	}
+	for _, receiverType := range []string{"otlp", "jaeger"} {
+		if i.receiverPorts(receiverType)[0] != 0 {
+			if err := add(receiverType, i.receiverPorts(receiverType)); err != nil {
+				return err
+			}
+		}
+		for _, sidecar := range i.observableSidecars() {
+			if i.ownsReceiver(receiverType, sidecar) {
+				if err := add(sidecarReceiverID(receiverType, sidecar), sidecar.receiverPorts(receiverType)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// createSidecarReceivers returns the OTLP, Jaeger and filelog receivers of the observable sidecars, by identifier
+// The prometheus endpoints of the sidecars are scraped by the prometheus receiver of the instance, and the sidecars
+// sharing the OTLP or Jaeger ports of another source use its receiver, see ownsReceiver.
+func (i *Instance) createSidecarReceivers() map[string]any {
+	receivers := make(map[string]any)
+	for _, sidecar := range i.observableSidecars() {
+		if i.ownsReceiver("otlp", sidecar) {
+			receivers[sidecarReceiverID("otlp", sidecar)] = sidecar.createOtlpReceiver()
+		}
+		if i.ownsReceiver("jaeger", sidecar) {
+			receivers[sidecarReceiverID("jaeger", sidecar)] = sidecar.createJaegerReceiver()
+		}
+		if len(sidecar.obsyConfig.logsPaths) != 0 {
+			receivers[sidecarReceiverID("filelog", sidecar)] = sidecar.createFileLogReceiver()
+		}
+	}
+	if len(receivers) == 0 {
+		return nil
+	}
+	return receivers
+}
+
+// sidecarReceiverIDs returns the identifiers of the receivers of the observable sidecars of the given signal
+func (i *Instance) sidecarReceiverIDs(signal OtelSignal) []string {
+	var ids []string
+	for _, sidecar := range i.observableSidecars() {
+		switch signal {
+		case OtelSignalMetrics:
+			if i.ownsReceiver("otlp", sidecar) {
+				ids = append(ids, sidecarReceiverID("otlp", sidecar))
+			}
+		case OtelSignalTraces:
+			if i.ownsReceiver("otlp", sidecar) {
+				ids = append(ids, sidecarReceiverID("otlp", sidecar))
+			}
+			if i.ownsReceiver("jaeger", sidecar) {
+				ids = append(ids, sidecarReceiverID("jaeger", sidecar))
+			}
+		case OtelSignalLogs:
+			if len(sidecar.obsyConfig.logsPaths) != 0 {
+				ids = append(ids, sidecarReceiverID("filelog", sidecar))
+			}
+		}
+	}
+	return ids
+}
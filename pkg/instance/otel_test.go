@@ -0,0 +1,216 @@
+package instance
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+	v1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/celestiaorg/knuu/pkg/k8s"
+	"github.com/celestiaorg/knuu/pkg/k8s/fake"
+	"github.com/celestiaorg/knuu/pkg/system"
+)
+
+func TestOtelNodeMetrics(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	k8sCli, err := fake.New(ctx, "test")
+	require.NoError(t, err)
+	i, err := New("app", system.SystemDependencies{K8sCli: k8sCli}, WithImage("alpine"))
+	require.NoError(t, err)
+
+	require.NoError(t, i.EnableOtelHostMetrics(0))
+	require.NoError(t, i.EnableOtelKubeletStats(time.Minute))
+	assert.True(t, i.isObservabilityEnabled())
+
+	config := i.createOtelConfig()
+	require.NotNil(t, config.Receivers.HostMetrics)
+	assert.Equal(t, "30s", config.Receivers.HostMetrics.CollectionInterval)
+	assert.Contains(t, config.Receivers.HostMetrics.Scrapers, "cpu")
+	assert.Equal(t, &KubeletStats{
+		CollectionInterval: "1m0s",
+		AuthType:           "serviceAccount",
+		Endpoint:           "https://${env:K8S_NODE_NAME}:10250",
+		InsecureSkipVerify: true,
+		MetricGroups:       []string{"node", "pod", "container"},
+	}, config.Receivers.KubeletStats)
+	assert.Equal(t, []string{"hostmetrics", "kubeletstats"}, config.Service.Pipelines.Metrics.Receivers)
+
+	tracker := newResourceTracker(i.k8sName)
+	require.NoError(t, i.deployPod(ctx, tracker))
+
+	rbac := k8sCli.FakeClientset.RbacV1()
+	role, err := rbac.ClusterRoles().Get(ctx, i.k8sName+"-kubeletstats", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, kubeletStatsPolicyRules, role.Rules)
+	binding, err := rbac.ClusterRoleBindings().Get(ctx, i.k8sName+"-kubeletstats", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, role.Name, binding.RoleRef.Name)
+	require.Len(t, binding.Subjects, 1)
+	assert.Equal(t, i.k8sName, binding.Subjects[0].Name)
+
+	require.NoError(t, i.destroyPod(ctx))
+	_, err = rbac.ClusterRoles().Get(ctx, role.Name, metav1.GetOptions{})
+	assert.True(t, apierrs.IsNotFound(err))
+	_, err = rbac.ClusterRoleBindings().Get(ctx, binding.Name, metav1.GetOptions{})
+	assert.True(t, apierrs.IsNotFound(err))
+}
+
+func TestSetLogsCollection(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	k8sCli, err := fake.New(ctx, "test")
+	require.NoError(t, err)
+	i, err := New("app", system.SystemDependencies{K8sCli: k8sCli}, WithImage("alpine"))
+	require.NoError(t, err)
+
+	assert.Error(t, i.SetLogsCollection(nil, OtelLogParserNone))
+	assert.Error(t, i.SetLogsCollection([]string{"app.log"}, OtelLogParserNone), "the path must be absolute")
+	assert.Error(t, i.SetLogsCollection([]string{"/var/log/*/app.log"}, OtelLogParserNone), "the directory cannot be a pattern")
+	assert.Error(t, i.SetLogsCollection([]string{"/app.log"}, OtelLogParserNone), "the root cannot be shared")
+	assert.Error(t, i.SetLogsCollection([]string{"/var/log/app.log"}, "xml"))
+	assert.False(t, i.isObservabilityEnabled())
+
+	paths := []string{"/var/log/app/*.log", "/var/log/app/debug.log", "/tmp/audit.log"}
+	require.NoError(t, i.SetLogsCollection(paths, OtelLogParserJSON))
+	require.NoError(t, i.SetOtlpExporter("otlp.example.com", "user", "pass"))
+	require.NoError(t, i.AddOtelExporter(OtelExporter{Name: "loki", Type: OtelExporterOTLPHTTP, Endpoint: "https://loki.example.com/otlp"}))
+	require.NoError(t, i.AddOtelExporter(OtelExporter{Name: "tempo", Type: OtelExporterOTLPGRPC, Endpoint: "tempo:4317", Signals: []OtelSignal{OtelSignalTraces}}))
+	assert.True(t, i.isObservabilityEnabled())
+
+	config := i.createOtelConfig()
+	assert.Equal(t, &FileLog{
+		Include:         paths,
+		StartAt:         "beginning",
+		IncludeFilePath: true,
+		Operators:       []FileLogOperator{{Type: "json_parser"}},
+	}, config.Receivers.FileLog)
+	assert.Equal(t, &Logs{
+		Receivers:  []string{"filelog"},
+		Exporters:  []string{"otlphttp", "otlphttp/loki"},
+		Processors: []string{"attributes"},
+	}, config.Service.Pipelines.Logs)
+
+	assert.Equal(t, []*k8s.SharedDir{
+		{Name: logsSharedDirName("/var/log/app"), Path: "/var/log/app"},
+		{Name: logsSharedDirName("/tmp"), Path: "/tmp"},
+	}, i.logsSharedDirs())
+
+	i.state = Started
+	assert.Error(t, i.SetLogsCollection(paths, OtelLogParserNone))
+}
+
+func TestDeployPodSharedDirs(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	k8sCli, err := fake.New(ctx, "test")
+	require.NoError(t, err)
+	dirs := []*k8s.SharedDir{{Name: "otel-logs-0", Path: "/var/log/app"}}
+	pod, err := k8sCli.DeployPod(ctx, k8s.PodConfig{
+		Name:            "app",
+		ContainerConfig: k8s.ContainerConfig{Name: "app", Image: "alpine", SharedDirs: dirs},
+		SidecarConfigs:  []k8s.ContainerConfig{{Name: "otel-agent", Image: "otel", SharedDirs: dirs}},
+	}, false)
+	require.NoError(t, err)
+
+	require.Len(t, pod.Spec.Volumes, 1, "the directory is a single volume shared by the containers")
+	assert.Equal(t, "otel-logs-0", pod.Spec.Volumes[0].Name)
+	assert.NotNil(t, pod.Spec.Volumes[0].EmptyDir)
+	for _, container := range pod.Spec.Containers {
+		assert.Equal(t, []v1.VolumeMount{{Name: "otel-logs-0", MountPath: "/var/log/app"}}, container.VolumeMounts)
+	}
+}
+
+func TestOtelSidecarSources(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	k8sCli, err := fake.New(ctx, "test")
+	require.NoError(t, err)
+	i, err := New("app", system.SystemDependencies{K8sCli: k8sCli}, WithImage("alpine"))
+	require.NoError(t, err)
+	require.NoError(t, i.Commit())
+
+	shaper, err := New("shaper", system.SystemDependencies{K8sCli: k8sCli}, WithImage("alpine"))
+	require.NoError(t, err)
+	require.NoError(t, shaper.Commit())
+	plain, err := New("plain", system.SystemDependencies{K8sCli: k8sCli}, WithImage("alpine"))
+	require.NoError(t, err)
+	require.NoError(t, plain.Commit())
+	require.NoError(t, i.AddSidecar(shaper))
+	require.NoError(t, i.AddSidecar(plain))
+
+	assert.False(t, i.isPodObservabilityEnabled())
+	require.NoError(t, shaper.SetPrometheusEndpoint(9100, "shaper", "15s"))
+	require.NoError(t, shaper.SetOtelEndpoint(4319))
+	require.NoError(t, shaper.SetLogsCollection([]string{"/var/log/shaper.log"}, OtelLogParserKeyValue))
+	assert.False(t, i.isObservabilityEnabled())
+	assert.True(t, i.isPodObservabilityEnabled(), "the collector is added for an observable sidecar")
+	assert.Equal(t, []*Instance{i, shaper}, i.otelSources())
+
+	config := i.createOtelConfig()
+	scrapeConfigs := config.Receivers.Prometheus.Config.ScrapeConfigs
+	require.Len(t, scrapeConfigs, 2)
+	assert.Equal(t, "shaper", scrapeConfigs[0].JobName)
+	assert.Equal(t, []string{"localhost:9100"}, scrapeConfigs[0].StaticConfigs[0].Targets)
+
+	otlpID := "otlp/" + shaper.k8sName
+	filelogID := "filelog/" + shaper.k8sName
+	assert.Equal(t, map[string]any{
+		otlpID:    shaper.createOtlpReceiver(),
+		filelogID: shaper.createFileLogReceiver(),
+	}, config.Receivers.Sidecars)
+	assert.Equal(t, []string{"prometheus", otlpID}, config.Service.Pipelines.Metrics.Receivers)
+	assert.Equal(t, []string{otlpID}, config.Service.Pipelines.Traces.Receivers)
+	require.NotNil(t, config.Service.Pipelines.Logs)
+	assert.Equal(t, []string{filelogID}, config.Service.Pipelines.Logs.Receivers)
+	bytes, err := yaml.Marshal(config)
+	require.NoError(t, err)
+	assert.Contains(t, string(bytes), "\n    "+otlpID+":\n")
+
+	require.NoError(t, i.SetLogsCollection([]string{"/var/log/app.log"}, OtelLogParserNone))
+	config = i.createOtelConfig()
+	assert.Equal(t, []string{"filelog", filelogID}, config.Service.Pipelines.Logs.Receivers)
+}
+
+func TestOtelSidecarSharedPorts(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	k8sCli, err := fake.New(ctx, "test")
+	require.NoError(t, err)
+	newInstance := func(name string) *Instance {
+		inst, err := New(name, system.SystemDependencies{K8sCli: k8sCli}, WithImage("alpine"))
+		require.NoError(t, err)
+		require.NoError(t, inst.Commit())
+		return inst
+	}
+	i, same, other := newInstance("app"), newInstance("same"), newInstance("other")
+	require.NoError(t, i.AddSidecar(same))
+	require.NoError(t, i.AddSidecar(other))
+
+	// the sidecar sending to the OTLP port of the instance uses its receiver
+	require.NoError(t, i.SetOtelEndpoint(4318))
+	require.NoError(t, same.SetOtelEndpoint(4318))
+	require.NoError(t, other.SetOtelEndpoint(4319))
+	require.NoError(t, i.validateReceiverPorts())
+	config := i.createOtelConfig()
+	otherID := "otlp/" + other.k8sName
+	assert.Equal(t, map[string]any{otherID: other.createOtlpReceiver()}, config.Receivers.Sidecars)
+	assert.Equal(t, []string{"otlp", otherID}, config.Service.Pipelines.Traces.Receivers)
+
+	// the OTLP receiver of a sidecar cannot listen on the Jaeger port of the instance
+	require.NoError(t, i.SetJaegerEndpoint(4319, 6831, 14268))
+	assert.ErrorIs(t, i.validateReceiverPorts(), ErrOtelReceiverPortConflict)
+	_, err = i.createOtelCollectorInstance(ctx)
+	assert.ErrorIs(t, err, ErrOtelReceiverPortConflict)
+}
@@ -3,8 +3,6 @@ package instance
 import (
 	"context"
 	"fmt"
-
-	"github.com/sirupsen/logrus"
 )
 
 // InstancePool is a struct that represents a pool of instances
@@ -16,16 +14,22 @@ type InstancePool struct {
 // NewPool creates a pool of instances
 // This function can only be called in the state 'Committed'
 func (i *Instance) NewPool(amount int) (*InstancePool, error) {
-	if !i.IsInState(Committed) {
-		return nil, ErrCreatingPoolNotAllowed.WithParams(i.state.String())
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if !i.allows(ActionNewPool) {
+		return nil, ErrCreatingPoolNotAllowed.WithParams(i.getState().String())
 	}
 	instances := make([]*Instance, amount)
+	unlock := lockInstances(i.sidecars)
 	for j := 0; j < amount; j++ {
 		instances[j] = i.cloneWithSuffix(fmt.Sprintf("-%d", j))
 	}
+	unlock()
 
-	i.state = Destroyed
-	logrus.Debugf("Set state of instance '%s' to '%s'", i.name, i.state.String())
+	if err := i.transition(Destroyed); err != nil {
+		return nil, err
+	}
 
 	return &InstancePool{
 		instances: instances,
@@ -81,3 +85,29 @@ func (i *InstancePool) WaitInstancePoolIsRunning(ctx context.Context) error {
 	}
 	return nil
 }
+
+// EnableHPA enables a HorizontalPodAutoscaler for each instance in the instance pool, which scales the instance
+// between the given numbers of replicas on the given metric, see Instance.EnableHPA
+func (i *InstancePool) EnableHPA(minReplicas, maxReplicas int32, metric HPAMetric) error {
+	for _, instance := range i.instances {
+		err := instance.EnableHPA(minReplicas, maxReplicas, metric)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// HPAReplicas returns the current and the desired numbers of replicas of all instances in the instance pool, see
+// Instance.HPAReplicas
+func (i *InstancePool) HPAReplicas(ctx context.Context) (current, desired int32, err error) {
+	for _, instance := range i.instances {
+		c, d, err := instance.HPAReplicas(ctx)
+		if err != nil {
+			return 0, 0, err
+		}
+		current += c
+		desired += d
+	}
+	return current, desired, nil
+}
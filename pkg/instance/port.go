@@ -0,0 +1,186 @@
+package instance
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"github.com/celestiaorg/knuu/pkg/k8s"
+)
+
+// Port is a port of the instance exposed by its service
+type Port struct {
+	// Name of the port, defaults to '<protocol>-<port>'. It is also the name of the port of the container,
+	// so probes and services can refer to it.
+	Name string
+	// Protocol is TCP, UDP or SCTP, TCP by default
+	Protocol v1.Protocol
+	// Port is the port of the service
+	Port int
+	// TargetPort is the port the container listens on, defaults to Port
+	TargetPort int
+}
+
+// AddPort adds the given port to the instance
+// Unlike AddPortTCP and AddPortUDP, the port of the service can differ from the one of the container.
+// This function can be called in the states 'Preparing' and 'Committed'
+func (i *Instance) AddPort(port Port) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if !i.allows(ActionConfigure) {
+		return ErrAddingPortNotAllowed.WithParams(i.getState().String())
+	}
+	port, err := normalizePort(port)
+	if err != nil {
+		return err
+	}
+	for _, p := range i.servicePorts() {
+		if p.Name == port.Name {
+			return ErrPortNameAlreadyRegistered.WithParams(port.Name)
+		}
+		if p.Protocol == port.Protocol && p.Port == port.Port {
+			return ErrPortAlreadyRegistered.WithParams(port.Port)
+		}
+	}
+	i.ports = append(i.ports, port)
+	logrus.Debugf("Added %s port '%s' (%d -> %d) to instance '%s'", port.Protocol, port.Name, port.Port, port.TargetPort, i.name)
+	return nil
+}
+
+// AutoAddExposedPorts adds the ports exposed by the image of the instance with the EXPOSE directive,
+// so that the ports of the tests follow the Dockerfiles. The ports already added are skipped.
+// The TCP and UDP ports are added as with AddPortTCP and AddPortUDP, the SCTP ports as with AddPort.
+// This function can be called in the states 'Preparing' and 'Committed'
+func (i *Instance) AutoAddExposedPorts(ctx context.Context) error {
+	if !i.allows(ActionConfigure) {
+		return ErrAddingPortNotAllowed.WithParams(i.getState().String())
+	}
+	config, err := i.ImageConfig(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range config.ExposedPorts {
+		protocol := v1.Protocol(strings.ToUpper(p.Protocol))
+		if i.hasServicePort(protocol, p.Port) {
+			logrus.Debugf("Port '%d/%s' exposed by the image of instance '%s' is already added", p.Port, p.Protocol, i.name)
+			continue
+		}
+		switch protocol {
+		case v1.ProtocolTCP:
+			err = i.AddPortTCP(p.Port)
+		case v1.ProtocolUDP:
+			err = i.AddPortUDP(p.Port)
+		case v1.ProtocolSCTP:
+			err = i.AddPort(Port{Protocol: protocol, Port: p.Port})
+		default:
+			err = ErrInvalidPortProtocol.WithParams(p.Protocol)
+		}
+		if err != nil {
+			return ErrAddingExposedPort.WithParams(p.Port, p.Protocol, i.name).Wrap(err)
+		}
+	}
+	return nil
+}
+
+// normalizePort validates the port and sets its defaults
+func normalizePort(port Port) (Port, error) {
+	if port.Protocol == "" {
+		port.Protocol = v1.ProtocolTCP
+	}
+	switch port.Protocol {
+	case v1.ProtocolTCP, v1.ProtocolUDP, v1.ProtocolSCTP:
+	default:
+		return Port{}, ErrInvalidPortProtocol.WithParams(port.Protocol)
+	}
+	if port.TargetPort == 0 {
+		port.TargetPort = port.Port
+	}
+	if err := validatePort(port.Port); err != nil {
+		return Port{}, err
+	}
+	if err := validatePort(port.TargetPort); err != nil {
+		return Port{}, err
+	}
+	if port.Name == "" {
+		port.Name = defaultPortName(port.Protocol, port.Port)
+	}
+	if errs := validation.IsValidPortName(port.Name); len(errs) > 0 {
+		return Port{}, ErrInvalidPortName.WithParams(port.Name, strings.Join(errs, ", "))
+	}
+	return port, nil
+}
+
+func defaultPortName(protocol v1.Protocol, port int) string {
+	return fmt.Sprintf("%s-%d", strings.ToLower(string(protocol)), port)
+}
+
+// servicePorts returns the ports of the service of the instance, the ones added with
+// AddPortTCP and AddPortUDP first
+func (i *Instance) servicePorts() []k8s.ServicePort {
+	ports := make([]k8s.ServicePort, 0, len(i.portsTCP)+len(i.portsUDP)+len(i.ports))
+	for _, p := range i.portsTCP {
+		ports = append(ports, k8s.ServicePort{Name: defaultPortName(v1.ProtocolTCP, p), Protocol: v1.ProtocolTCP, Port: p, TargetPort: p})
+	}
+	for _, p := range i.portsUDP {
+		ports = append(ports, k8s.ServicePort{Name: defaultPortName(v1.ProtocolUDP, p), Protocol: v1.ProtocolUDP, Port: p, TargetPort: p})
+	}
+	for _, p := range i.ports {
+		ports = append(ports, k8s.ServicePort{Name: p.Name, Protocol: p.Protocol, Port: p.Port, TargetPort: p.TargetPort})
+	}
+	return ports
+}
+
+// hasServicePort returns true if the service of the instance has the given port for the given protocol,
+// whichever way it was added
+func (i *Instance) hasServicePort(protocol v1.Protocol, port int) bool {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	for _, p := range i.servicePorts() {
+		if p.Protocol == protocol && p.Port == port {
+			return true
+		}
+	}
+	return false
+}
+
+// isServicePortRegistered returns true if the service of the instance has the given port for the given protocol
+func (i *Instance) isServicePortRegistered(protocol v1.Protocol, port int) bool {
+	for _, p := range i.ports {
+		if p.Protocol == protocol && p.Port == port {
+			return true
+		}
+	}
+	return false
+}
+
+// isPortNameRegistered returns true if a port added with AddPort has the given name
+func (i *Instance) isPortNameRegistered(name string) bool {
+	for _, p := range i.ports {
+		if p.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// isContainerPortRegistered returns true if the container of the instance listens on the given port for the given protocol
+func (i *Instance) isContainerPortRegistered(protocol v1.Protocol, port int) bool {
+	switch {
+	case protocol == v1.ProtocolTCP && i.isTCPPortRegistered(port):
+		return true
+	case protocol == v1.ProtocolUDP && i.isUDPPortRegistered(port):
+		return true
+	}
+	for _, p := range i.ports {
+		if p.Protocol == protocol && p.TargetPort == port {
+			return true
+		}
+	}
+	return false
+}
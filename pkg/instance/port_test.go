@@ -0,0 +1,91 @@
+package instance
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/celestiaorg/knuu/pkg/k8s"
+	"github.com/celestiaorg/knuu/pkg/system"
+)
+
+func TestAddPort(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		port    Port
+		want    Port
+		wantErr error
+	}{
+		{name: "defaults", port: Port{Port: 26657}, want: Port{Name: "tcp-26657", Protocol: v1.ProtocolTCP, Port: 26657, TargetPort: 26657}},
+		{name: "sctp with target port", port: Port{Name: "signal", Protocol: v1.ProtocolSCTP, Port: 80, TargetPort: 3868},
+			want: Port{Name: "signal", Protocol: v1.ProtocolSCTP, Port: 80, TargetPort: 3868}},
+		{name: "invalid protocol", port: Port{Protocol: "QUIC", Port: 443}, wantErr: ErrInvalidPortProtocol},
+		{name: "invalid name", port: Port{Name: "Not_A_Port_Name", Port: 443}, wantErr: ErrInvalidPortName},
+		{name: "target port out of range", port: Port{Port: 443, TargetPort: 70000}, wantErr: ErrPortNumberOutOfRange},
+		{name: "name already registered", port: Port{Name: "tcp-8080", Port: 9000}, wantErr: ErrPortNameAlreadyRegistered},
+		{name: "port already registered", port: Port{Name: "http", Port: 8080}, wantErr: ErrPortAlreadyRegistered},
+		{name: "same port with another protocol", port: Port{Protocol: v1.ProtocolUDP, Port: 8080},
+			want: Port{Name: "udp-8080", Protocol: v1.ProtocolUDP, Port: 8080, TargetPort: 8080}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			i, err := New("test", system.SystemDependencies{}, WithImage("alpine"), WithPorts(8080))
+			require.NoError(t, err)
+			err = i.AddPort(tt.port)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, []Port{tt.want}, i.ports)
+		})
+	}
+}
+
+func TestAutoAddExposedPorts(t *testing.T) {
+	t.Parallel()
+	host := newTestRegistry(t, `{"ExposedPorts":{"8080/tcp":{},"26656/tcp":{},"9090/udp":{},"3868/sctp":{}}}`)
+
+	i, err := New("test", system.SystemDependencies{}, WithImage(host+"/app:v1"), WithPorts(8080))
+	require.NoError(t, err)
+	require.NoError(t, i.AutoAddExposedPorts(context.Background()))
+	assert.Equal(t, []int{8080, 26656}, i.portsTCP)
+	assert.Equal(t, []int{9090}, i.portsUDP)
+	assert.Equal(t, []Port{{Name: "sctp-3868", Protocol: v1.ProtocolSCTP, Port: 3868, TargetPort: 3868}}, i.ports)
+
+	// the ports are added once
+	require.NoError(t, i.AutoAddExposedPorts(context.Background()))
+	assert.Len(t, i.servicePorts(), 4)
+}
+
+func TestServiceAndContainerPorts(t *testing.T) {
+	t.Parallel()
+
+	i, err := New("test", system.SystemDependencies{}, WithImage("alpine"), WithPorts(8080))
+	require.NoError(t, err)
+	require.NoError(t, i.AddPort(Port{Name: "rpc", Port: 80, TargetPort: 26657}))
+
+	assert.Equal(t, []k8s.ServicePort{
+		{Name: "tcp-8080", Protocol: v1.ProtocolTCP, Port: 8080, TargetPort: 8080},
+		{Name: "rpc", Protocol: v1.ProtocolTCP, Port: 80, TargetPort: 26657},
+	}, i.servicePorts())
+
+	// the named port is declared in the container so that probes can refer to it
+	assert.Equal(t, []v1.ContainerPort{{Name: "rpc", ContainerPort: 26657, Protocol: v1.ProtocolTCP}}, i.containerPorts(false))
+
+	// the target port is the one of the container
+	assert.True(t, i.isContainerPortRegistered(v1.ProtocolTCP, 26657))
+	assert.False(t, i.isContainerPortRegistered(v1.ProtocolTCP, 80))
+	assert.ErrorIs(t, i.AddPortTCP(80), ErrPortAlreadyRegistered)
+
+	// the default name of the port is taken by a named port
+	require.NoError(t, i.AddPort(Port{Name: "udp-9090", Protocol: v1.ProtocolUDP, Port: 30000, TargetPort: 9091}))
+	assert.ErrorIs(t, i.AddPortUDP(9090), ErrPortNameAlreadyRegistered)
+}
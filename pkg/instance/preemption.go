@@ -0,0 +1,204 @@
+package instance
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+
+	"github.com/celestiaorg/knuu/pkg/clock"
+)
+
+// preemptibleLabel is the label of the pods of the preemptible instances
+const preemptibleLabel = "knuu.sh/preemptible"
+
+const (
+	defaultPreemptionInterval = 10 * time.Minute
+	// defaultPreemptionNotice is the notice given by the spot nodes of GKE, the ones of EKS give 2 minutes
+	defaultPreemptionNotice = 30 * time.Second
+	// preemptionReason is the reason of the condition set by the kubelet on the pods of a node that shuts down
+	preemptionReason = "TerminationByKubelet"
+)
+
+// spotTolerations tolerate the taints of the spot nodes of the main providers
+var spotTolerations = []v1.Toleration{
+	{Key: "cloud.google.com/gke-spot", Operator: v1.TolerationOpEqual, Value: "true", Effect: v1.TaintEffectNoSchedule},
+	{Key: "cloud.google.com/gke-preemptible", Operator: v1.TolerationOpEqual, Value: "true", Effect: v1.TaintEffectNoSchedule},
+	{Key: "kubernetes.azure.com/scalesetpriority", Operator: v1.TolerationOpEqual, Value: "spot", Effect: v1.TaintEffectNoSchedule},
+	{Key: "karpenter.sh/capacity-type", Operator: v1.TolerationOpEqual, Value: "spot", Effect: v1.TaintEffectNoSchedule},
+}
+
+// SetPreemptible marks the instance as tolerant to preemption: its pod tolerates the taints of the spot nodes of
+// GKE, AKS and Karpenter, and the given ones, and is labeled 'knuu.sh/preemptible=true', so it can be scheduled on
+// a spot node pool and preempted by a PreemptionSimulator.
+// The instance must be deployed as a ReplicaSet, i.e. restart its pod always, for its pod to be rescheduled.
+// This function can only be called in the states 'Preparing' and 'Committed'
+func (i *Instance) SetPreemptible(tolerations ...v1.Toleration) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if !i.allows(ActionConfigure) {
+		return ErrSettingPreemptibleNotAllowed.WithParams(i.getState().String())
+	}
+	if i.isSidecar {
+		return ErrSettingPreemptibleSidecar.WithParams(i.k8sName)
+	}
+	if !i.usesReplicaSet() {
+		return ErrPreemptibleWithoutReplicaSet.WithParams(i.k8sName, i.restartPolicy)
+	}
+
+	i.preemptible = true
+	i.tolerations = append(append([]v1.Toleration(nil), spotTolerations...), tolerations...)
+	logrus.Debugf("Set instance '%s' to be preemptible", i.k8sName)
+	return nil
+}
+
+// IsPreemptible returns true if the instance is tolerant to preemption, see SetPreemptible
+func (i *Instance) IsPreemptible() bool {
+	return i.preemptible
+}
+
+// Preempt simulates the preemption of the node of the instance: the pod is marked as disrupted by the shutdown of
+// its node, as the kubelet does, and deleted with the given notice as grace period. It returns the node the pod
+// was running on.
+// The pod is rescheduled by the ReplicaSet of the instance, which stays started. The instances with a plain pod,
+// whose restart policy is not 'Always', cannot be preempted as their pod would be gone for good.
+// This function can only be called in the state 'Started'
+func (i *Instance) Preempt(ctx context.Context, notice time.Duration) (string, error) {
+	if !i.allows(ActionOperate) {
+		return "", ErrPreemptingNotAllowed.WithParams(i.getState().String())
+	}
+	if i.isSidecar {
+		return "", ErrPreemptingSidecar.WithParams(i.k8sName)
+	}
+	if !i.usesReplicaSet() {
+		return "", ErrPreemptibleWithoutReplicaSet.WithParams(i.k8sName, i.restartPolicy)
+	}
+
+	pod, err := i.getPod(ctx)
+	if err != nil {
+		return "", ErrPreemptingInstance.WithParams(i.k8sName).Wrap(err)
+	}
+	condition := v1.PodCondition{
+		Type:               v1.DisruptionTarget,
+		Status:             v1.ConditionTrue,
+		Reason:             preemptionReason,
+		Message:            "Pod was terminated in response to the preemption of node '" + pod.Spec.NodeName + "'",
+		LastTransitionTime: metav1.Now(),
+	}
+	if err := i.K8sCli.SetPodCondition(ctx, pod.Name, condition); err != nil {
+		return "", ErrPreemptingInstance.WithParams(i.k8sName).Wrap(err)
+	}
+	gracePeriod := ptr.To(int64(notice / time.Second))
+	if err := i.K8sCli.DeletePodWithGracePeriod(ctx, pod.Name, gracePeriod); err != nil {
+		return "", ErrPreemptingInstance.WithParams(i.k8sName).Wrap(err)
+	}
+
+	logrus.Debugf("Preempted instance '%s' from node '%s'", i.k8sName, pod.Spec.NodeName)
+	return pod.Spec.NodeName, nil
+}
+
+// PreemptionEvent describes a preemption simulated by a PreemptionSimulator
+type PreemptionEvent struct {
+	Instance *Instance
+	Time     time.Time
+	// Node is the node the instance was preempted from
+	Node string
+	// Err is the error the preemption failed with
+	Err error
+}
+
+// PreemptionSimulator preempts the preemptible instances on a schedule, to test that the workloads destined for
+// spot node pools survive the preemption of their nodes
+// At each interval, it preempts the next started preemptible instance in turn, see Instance.Preempt.
+type PreemptionSimulator struct {
+	instances    func() []*Instance
+	interval     time.Duration
+	notice       time.Duration
+	onPreemption []func(PreemptionEvent)
+
+	// next is the turn of the next preemption, it is only accessed by the goroutine of Run
+	next int
+}
+
+// PreemptionOption configures a PreemptionSimulator
+type PreemptionOption func(*PreemptionSimulator)
+
+// WithPreemptionInterval sets the interval between two preemptions, 10m by default
+func WithPreemptionInterval(interval time.Duration) PreemptionOption {
+	return func(s *PreemptionSimulator) {
+		s.interval = interval
+	}
+}
+
+// WithPreemptionNotice sets the notice given to the preempted pods to shut down, 30s by default
+func WithPreemptionNotice(notice time.Duration) PreemptionOption {
+	return func(s *PreemptionSimulator) {
+		s.notice = notice
+	}
+}
+
+// OnPreemption adds a callback called for each preemption
+// The callbacks are called by the goroutine of Run, they should not block.
+func OnPreemption(fn func(PreemptionEvent)) PreemptionOption {
+	return func(s *PreemptionSimulator) {
+		s.onPreemption = append(s.onPreemption, fn)
+	}
+}
+
+// NewPreemptionSimulator returns a simulator preempting the instances returned by the given function, e.g. by
+// Supervise
+// The function is called at each interval, only its started preemptible instances are preempted.
+func NewPreemptionSimulator(instances func() []*Instance, opts ...PreemptionOption) *PreemptionSimulator {
+	s := &PreemptionSimulator{
+		instances: instances,
+		interval:  defaultPreemptionInterval,
+		notice:    defaultPreemptionNotice,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Run preempts an instance at each interval of the clock of the context until the context is done
+// It must not be called concurrently.
+func (s *PreemptionSimulator) Run(ctx context.Context) {
+	ticker := clock.FromContext(ctx).NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C():
+			s.preemptNext(ctx)
+		}
+	}
+}
+
+func (s *PreemptionSimulator) preemptNext(ctx context.Context) {
+	var candidates []*Instance
+	for _, i := range s.instances() {
+		// the restart policy may have been changed since SetPreemptible
+		if i.preemptible && !i.isSidecar && i.usesReplicaSet() && i.IsInState(Started) {
+			candidates = append(candidates, i)
+		}
+	}
+	if len(candidates) == 0 {
+		return
+	}
+	i := candidates[s.next%len(candidates)]
+	s.next++
+
+	event := PreemptionEvent{Instance: i, Time: clock.FromContext(ctx).Now()}
+	event.Node, event.Err = i.Preempt(ctx, s.notice)
+	if event.Err != nil {
+		logrus.Warnf("Error preempting instance '%s': %v", i.k8sName, event.Err)
+	}
+	for _, fn := range s.onPreemption {
+		fn(event)
+	}
+}
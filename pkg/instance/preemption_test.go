@@ -0,0 +1,127 @@
+package instance
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/celestiaorg/knuu/pkg/clock"
+	"github.com/celestiaorg/knuu/pkg/k8s/fake"
+	"github.com/celestiaorg/knuu/pkg/system"
+)
+
+func TestSetPreemptible(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	k8sCli, err := fake.New(ctx, "test")
+	require.NoError(t, err)
+	i, err := New("node", system.SystemDependencies{K8sCli: k8sCli}, WithImage("alpine"))
+	require.NoError(t, err)
+	assert.Empty(t, i.preparePodConfig().Tolerations)
+	assert.NotContains(t, i.getLabels(), preemptibleLabel)
+
+	custom := v1.Toleration{Key: "example.com/spot", Operator: v1.TolerationOpExists, Effect: v1.TaintEffectNoSchedule}
+	require.NoError(t, i.SetPreemptible(custom))
+	assert.True(t, i.IsPreemptible())
+	assert.Equal(t, "true", i.getLabels()[preemptibleLabel])
+	tolerations := i.preparePodConfig().Tolerations
+	assert.Len(t, tolerations, len(spotTolerations)+1)
+	assert.Contains(t, tolerations, custom)
+	assert.True(t, i.cloneWithSuffix("-clone").IsPreemptible())
+
+	sidecar, err := New("sidecar", system.SystemDependencies{K8sCli: k8sCli}, WithImage("alpine"))
+	require.NoError(t, err)
+	sidecar.isSidecar = true
+	assert.ErrorIs(t, sidecar.SetPreemptible(), ErrSettingPreemptibleSidecar)
+
+	// the plain pods are not rescheduled
+	never, err := New("never", system.SystemDependencies{K8sCli: k8sCli}, WithImage("alpine"))
+	require.NoError(t, err)
+	require.NoError(t, never.SetRestartPolicy(v1.RestartPolicyNever))
+	assert.ErrorIs(t, never.SetPreemptible(), ErrPreemptibleWithoutReplicaSet)
+
+	i.setState(Started)
+	assert.ErrorIs(t, i.SetPreemptible(), ErrSettingPreemptibleNotAllowed)
+}
+
+func TestPreemptionSimulator(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	k8sCli, err := fake.New(ctx, "test")
+	require.NoError(t, err)
+	pods := k8sCli.FakeClientset.CoreV1().Pods("test")
+	newInstance := func(name string, preemptible bool) *Instance {
+		i, err := New(name, system.SystemDependencies{K8sCli: k8sCli}, WithImage("alpine"))
+		require.NoError(t, err)
+		if preemptible {
+			require.NoError(t, i.SetPreemptible())
+		}
+		// the fake does not run the ReplicaSet controller, the pod is created alongside it
+		_, err = k8sCli.CreateReplicaSet(ctx, i.prepareReplicaSetConfig(), false)
+		require.NoError(t, err)
+		pod, err := k8sCli.DeployPod(ctx, i.preparePodConfig(), false)
+		require.NoError(t, err)
+		pod.Spec.NodeName = "spot-" + name
+		_, err = pods.Update(ctx, pod, metav1.UpdateOptions{})
+		require.NoError(t, err)
+		i.setState(Started)
+		return i
+	}
+	a, b, stable := newInstance("a", true), newInstance("b", true), newInstance("stable", false)
+	// the restart policy changed after SetPreemptible, the instance is skipped
+	plain := newInstance("plain", true)
+	plain.restartPolicy = v1.RestartPolicyNever
+	_, err = plain.Preempt(ctx, time.Second)
+	assert.ErrorIs(t, err, ErrPreemptibleWithoutReplicaSet)
+
+	fc := clock.NewFake(time.Now())
+	ctx, cancel := context.WithCancel(clock.WithClock(ctx, fc))
+	defer cancel()
+	events := make(chan PreemptionEvent, 1)
+	s := NewPreemptionSimulator(Supervise(a, b, stable, plain), WithPreemptionInterval(time.Minute), OnPreemption(func(e PreemptionEvent) {
+		events <- e
+	}))
+	go s.Run(ctx)
+
+	require.Eventually(t, fc.HasWaiters, time.Second, time.Millisecond)
+	fc.Step(time.Minute)
+	event := <-events
+	require.NoError(t, event.Err)
+	assert.Equal(t, a, event.Instance)
+	assert.Equal(t, "spot-a", event.Node)
+	_, err = pods.Get(ctx, a.k8sName, metav1.GetOptions{})
+	assert.Error(t, err)
+
+	// the pod is marked as disrupted by the shutdown of its node before being deleted
+	var patched bool
+	for _, action := range k8sCli.FakeClientset.Actions() {
+		if patch, ok := action.(k8stesting.PatchAction); ok && patch.GetName() == a.k8sName && patch.GetSubresource() == "status" {
+			assert.Contains(t, string(patch.GetPatch()), `"type":"DisruptionTarget"`)
+			patched = true
+		}
+	}
+	assert.True(t, patched)
+
+	fc.Step(time.Minute)
+	event = <-events
+	assert.Equal(t, b, event.Instance)
+
+	// the instances take turns, the one without pod fails to be preempted
+	fc.Step(time.Minute)
+	event = <-events
+	assert.Equal(t, a, event.Instance)
+	assert.ErrorIs(t, event.Err, ErrPreemptingInstance)
+
+	for _, i := range []*Instance{stable, plain} {
+		_, err = pods.Get(ctx, i.k8sName, metav1.GetOptions{})
+		assert.NoError(t, err)
+	}
+}
@@ -0,0 +1,186 @@
+package instance
+
+import (
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// Defaults of the probes created by the probe helpers
+const (
+	defaultProbeInitialDelaySeconds = 5
+	defaultProbePeriodSeconds       = 10
+	defaultProbeTimeoutSeconds      = 5
+	defaultProbeSuccessThreshold    = 1
+	defaultProbeFailureThreshold    = 3
+	// a startup probe gives the instance up to 5 minutes to start with the default period
+	defaultStartupProbeFailureThreshold = 30
+)
+
+// ProbeOption overrides a default of a probe created by the probe helpers
+type ProbeOption func(*v1.Probe)
+
+// WithProbeInitialDelay sets the number of seconds after the container has started before the probe is initiated
+func WithProbeInitialDelay(seconds int32) ProbeOption {
+	return func(p *v1.Probe) {
+		p.InitialDelaySeconds = seconds
+	}
+}
+
+// WithProbePeriod sets how often (in seconds) the probe is performed
+func WithProbePeriod(seconds int32) ProbeOption {
+	return func(p *v1.Probe) {
+		p.PeriodSeconds = seconds
+	}
+}
+
+// WithProbeTimeout sets the number of seconds after which the probe times out
+func WithProbeTimeout(seconds int32) ProbeOption {
+	return func(p *v1.Probe) {
+		p.TimeoutSeconds = seconds
+	}
+}
+
+// WithProbeSuccessThreshold sets the minimum consecutive successes for the probe to be considered successful after having failed
+// It must be 1 for liveness and startup probes
+func WithProbeSuccessThreshold(threshold int32) ProbeOption {
+	return func(p *v1.Probe) {
+		p.SuccessThreshold = threshold
+	}
+}
+
+// WithProbeFailureThreshold sets the minimum consecutive failures for the probe to be considered failed after having succeeded
+func WithProbeFailureThreshold(threshold int32) ProbeOption {
+	return func(p *v1.Probe) {
+		p.FailureThreshold = threshold
+	}
+}
+
+// HTTPProbe returns a probe that performs an HTTP GET request on the given port and path
+func HTTPProbe(port int, path string, opts ...ProbeOption) *v1.Probe {
+	return newProbe(v1.ProbeHandler{
+		HTTPGet: &v1.HTTPGetAction{
+			Path: path,
+			Port: intstr.FromInt(port),
+		},
+	}, opts...)
+}
+
+// TCPProbe returns a probe that opens a TCP connection to the given port
+func TCPProbe(port int, opts ...ProbeOption) *v1.Probe {
+	return newProbe(v1.ProbeHandler{
+		TCPSocket: &v1.TCPSocketAction{
+			Port: intstr.FromInt(port),
+		},
+	}, opts...)
+}
+
+// ExecProbe returns a probe that executes the given command in the container
+// The probe succeeds if the command exits with 0
+func ExecProbe(command []string, opts ...ProbeOption) *v1.Probe {
+	return newProbe(v1.ProbeHandler{
+		Exec: &v1.ExecAction{
+			Command: command,
+		},
+	}, opts...)
+}
+
+func newProbe(handler v1.ProbeHandler, opts ...ProbeOption) *v1.Probe {
+	probe := &v1.Probe{
+		ProbeHandler:        handler,
+		InitialDelaySeconds: defaultProbeInitialDelaySeconds,
+		PeriodSeconds:       defaultProbePeriodSeconds,
+		TimeoutSeconds:      defaultProbeTimeoutSeconds,
+		SuccessThreshold:    defaultProbeSuccessThreshold,
+		FailureThreshold:    defaultProbeFailureThreshold,
+	}
+	for _, opt := range opts {
+		opt(probe)
+	}
+	return probe
+}
+
+// startupProbeOptions prepends the defaults of startup probes to the given options
+func startupProbeOptions(opts []ProbeOption) []ProbeOption {
+	return append([]ProbeOption{WithProbeFailureThreshold(defaultStartupProbeFailureThreshold)}, opts...)
+}
+
+// SetHTTPLivenessProbe sets a liveness probe that performs an HTTP GET request on the given port and path
+// This function can only be called in the states 'Preparing' and 'Committed'
+func (i *Instance) SetHTTPLivenessProbe(port int, path string, opts ...ProbeOption) error {
+	if err := validatePort(port); err != nil {
+		return err
+	}
+	return i.SetLivenessProbe(HTTPProbe(port, path, opts...))
+}
+
+// SetHTTPReadinessProbe sets a readiness probe that performs an HTTP GET request on the given port and path
+// This function can only be called in the states 'Preparing' and 'Committed'
+func (i *Instance) SetHTTPReadinessProbe(port int, path string, opts ...ProbeOption) error {
+	if err := validatePort(port); err != nil {
+		return err
+	}
+	return i.SetReadinessProbe(HTTPProbe(port, path, opts...))
+}
+
+// SetHTTPStartupProbe sets a startup probe that performs an HTTP GET request on the given port and path
+// This function can only be called in the states 'Preparing' and 'Committed'
+func (i *Instance) SetHTTPStartupProbe(port int, path string, opts ...ProbeOption) error {
+	if err := validatePort(port); err != nil {
+		return err
+	}
+	return i.SetStartupProbe(HTTPProbe(port, path, startupProbeOptions(opts)...))
+}
+
+// SetTCPLivenessProbe sets a liveness probe that opens a TCP connection to the given port
+// This function can only be called in the states 'Preparing' and 'Committed'
+func (i *Instance) SetTCPLivenessProbe(port int, opts ...ProbeOption) error {
+	if err := validatePort(port); err != nil {
+		return err
+	}
+	return i.SetLivenessProbe(TCPProbe(port, opts...))
+}
+
+// SetTCPReadinessProbe sets a readiness probe that opens a TCP connection to the given port
+// This function can only be called in the states 'Preparing' and 'Committed'
+func (i *Instance) SetTCPReadinessProbe(port int, opts ...ProbeOption) error {
+	if err := validatePort(port); err != nil {
+		return err
+	}
+	return i.SetReadinessProbe(TCPProbe(port, opts...))
+}
+
+// SetTCPStartupProbe sets a startup probe that opens a TCP connection to the given port
+// This function can only be called in the states 'Preparing' and 'Committed'
+func (i *Instance) SetTCPStartupProbe(port int, opts ...ProbeOption) error {
+	if err := validatePort(port); err != nil {
+		return err
+	}
+	return i.SetStartupProbe(TCPProbe(port, startupProbeOptions(opts)...))
+}
+
+// SetExecLivenessProbe sets a liveness probe that executes the given command in the instance
+// This function can only be called in the states 'Preparing' and 'Committed'
+func (i *Instance) SetExecLivenessProbe(command []string, opts ...ProbeOption) error {
+	if len(command) == 0 {
+		return ErrProbeCommandEmpty
+	}
+	return i.SetLivenessProbe(ExecProbe(command, opts...))
+}
+
+// SetExecReadinessProbe sets a readiness probe that executes the given command in the instance
+// This function can only be called in the states 'Preparing' and 'Committed'
+func (i *Instance) SetExecReadinessProbe(command []string, opts ...ProbeOption) error {
+	if len(command) == 0 {
+		return ErrProbeCommandEmpty
+	}
+	return i.SetReadinessProbe(ExecProbe(command, opts...))
+}
+
+// SetExecStartupProbe sets a startup probe that executes the given command in the instance
+// This function can only be called in the states 'Preparing' and 'Committed'
+func (i *Instance) SetExecStartupProbe(command []string, opts ...ProbeOption) error {
+	if len(command) == 0 {
+		return ErrProbeCommandEmpty
+	}
+	return i.SetStartupProbe(ExecProbe(command, startupProbeOptions(opts)...))
+}
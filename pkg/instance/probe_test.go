@@ -0,0 +1,50 @@
+package instance
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+)
+
+func TestProbeHelpers(t *testing.T) {
+	t.Parallel()
+
+	i := &Instance{state: Committed}
+
+	require.NoError(t, i.SetHTTPLivenessProbe(8080, "/health", WithProbePeriod(2)))
+	require.NotNil(t, i.livenessProbe.HTTPGet)
+	assert.Equal(t, "/health", i.livenessProbe.HTTPGet.Path)
+	assert.Equal(t, 8080, i.livenessProbe.HTTPGet.Port.IntValue())
+	assert.Equal(t, int32(2), i.livenessProbe.PeriodSeconds)
+	assert.Equal(t, int32(defaultProbeFailureThreshold), i.livenessProbe.FailureThreshold)
+
+	require.NoError(t, i.SetTCPReadinessProbe(26657))
+	require.NotNil(t, i.readinessProbe.TCPSocket)
+	assert.Equal(t, int32(defaultProbeInitialDelaySeconds), i.readinessProbe.InitialDelaySeconds)
+
+	require.NoError(t, i.SetExecStartupProbe([]string{"cat", "/tmp/ready"}))
+	require.NotNil(t, i.startupProbe.Exec)
+	assert.Equal(t, int32(defaultStartupProbeFailureThreshold), i.startupProbe.FailureThreshold)
+
+	require.NoError(t, i.SetTCPStartupProbe(26657, WithProbeFailureThreshold(5)))
+	assert.Equal(t, int32(5), i.startupProbe.FailureThreshold, "options must override the startup defaults")
+
+	assert.Error(t, i.SetTCPLivenessProbe(0))
+	assert.Error(t, i.SetExecReadinessProbe(nil))
+}
+
+func TestProbeConstructors(t *testing.T) {
+	t.Parallel()
+
+	p := ExecProbe([]string{"true"}, WithProbeTimeout(1), WithProbeSuccessThreshold(2), WithProbeInitialDelay(0))
+	assert.Equal(t, &v1.Probe{
+		ProbeHandler:        v1.ProbeHandler{Exec: &v1.ExecAction{Command: []string{"true"}}},
+		InitialDelaySeconds: 0,
+		PeriodSeconds:       defaultProbePeriodSeconds,
+		TimeoutSeconds:      1,
+		SuccessThreshold:    2,
+		FailureThreshold:    defaultProbeFailureThreshold,
+	}, p)
+}
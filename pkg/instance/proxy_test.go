@@ -0,0 +1,103 @@
+package instance
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/celestiaorg/knuu/pkg/k8s/fake"
+	"github.com/celestiaorg/knuu/pkg/proxy"
+	"github.com/celestiaorg/knuu/pkg/system"
+)
+
+// fakeProxy records the hosts added to it
+type fakeProxy struct {
+	proxy.Proxy
+	services map[string]string
+}
+
+func (f *fakeProxy) AddHost(_ context.Context, serviceName, prefix string, _ int, opts ...proxy.HostOption) error {
+	if err := proxy.NewHostOptions(opts...).Validate(); err != nil {
+		return err
+	}
+	f.services[prefix] = serviceName
+	return nil
+}
+
+func (f *fakeProxy) URL(_ context.Context, prefix string) (string, error) {
+	return "http://proxy/" + prefix, nil
+}
+
+func (f *fakeProxy) SecureURL(_ context.Context, prefix string) (string, error) {
+	return "https://proxy/" + prefix, nil
+}
+
+func (f *fakeProxy) AddTCPHost(_ context.Context, serviceName, name string, port int) (string, error) {
+	f.services[name] = serviceName
+	return fmt.Sprintf("proxy:%d", 9000+len(f.services)-1), nil
+}
+
+func (f *fakeProxy) RemoveTCPHosts(_ context.Context, serviceName string) error {
+	for name, service := range f.services {
+		if service == serviceName {
+			delete(f.services, name)
+		}
+	}
+	return nil
+}
+
+func TestAddHost(t *testing.T) {
+	t.Parallel()
+
+	p := &fakeProxy{services: map[string]string{}}
+	i := &Instance{k8sName: "app", SystemDependencies: system.SystemDependencies{Proxy: p}}
+	ctx := context.Background()
+
+	host, err := i.AddHost(ctx, 8080)
+	require.NoError(t, err)
+	assert.Equal(t, "http://proxy/app-8080", host)
+
+	host, err = i.AddHost(ctx, 8443, proxy.WithTLS())
+	require.NoError(t, err)
+	assert.Equal(t, "https://proxy/app-8443", host)
+	assert.Equal(t, map[string]string{"app-8080": "app", "app-8443": "app"}, p.services)
+
+	_, err = i.AddHost(ctx, 9000, proxy.WithBasicAuth("user", ""))
+	assert.ErrorIs(t, err, ErrAddingToProxy)
+
+	i.Proxy = nil
+	_, err = i.AddHost(ctx, 8080)
+	assert.ErrorIs(t, err, ErrProxyNotInitialized)
+}
+
+func TestAddTCPHost(t *testing.T) {
+	t.Parallel()
+
+	p := &fakeProxy{services: map[string]string{}}
+	i := &Instance{k8sName: "app", SystemDependencies: system.SystemDependencies{Proxy: p}}
+
+	address, err := i.AddTCPHost(context.Background(), 26656)
+	require.NoError(t, err)
+	assert.Equal(t, "proxy:9000", address)
+	assert.Equal(t, map[string]string{"app-26656": "app"}, p.services)
+}
+
+func TestDestroyRemovesTCPHosts(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	k8sCli, err := fake.New(ctx, "test")
+	require.NoError(t, err)
+	p := &fakeProxy{services: map[string]string{"other-26656": "other"}}
+	i, err := New("app", system.SystemDependencies{K8sCli: k8sCli, Proxy: p}, WithImage("alpine"))
+	require.NoError(t, err)
+
+	_, err = i.AddTCPHost(ctx, 26656)
+	require.NoError(t, err)
+	i.state = Started
+	require.NoError(t, i.destroyResources(ctx))
+	assert.Equal(t, map[string]string{"other-26656": "other"}, p.services, "the ports of the proxy are released")
+}
@@ -0,0 +1,127 @@
+package instance
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// parseQuantity parses the given resource quantity, an empty quantity is valid and means that it is not set
+func parseQuantity(quantity string) (resource.Quantity, error) {
+	if quantity == "" {
+		return resource.Quantity{}, nil
+	}
+	q, err := resource.ParseQuantity(quantity)
+	if err != nil {
+		return resource.Quantity{}, ErrParsingResourceQuantity.WithParams(quantity).Wrap(err)
+	}
+	return q, nil
+}
+
+// Resources are amounts of CPU and memory of a container, as quantities like '100m' and '200Mi', e.g. the
+// resources of a sidecar added by knuu; an empty quantity is not set
+type Resources struct {
+	CPU    string
+	Memory string
+}
+
+// String returns the resources in the form 'cpu=100m memory=200Mi'
+func (r Resources) String() string {
+	return fmt.Sprintf("cpu=%s memory=%s", r.CPU, r.Memory)
+}
+
+// validateResources checks that the requested resources and their limits are valid quantities and that the limits
+// are not lower than the requests
+func validateResources(request, limit Resources) error {
+	if err := validateCPU(request.CPU, limit.CPU); err != nil {
+		return err
+	}
+	return validateMemory(request.Memory, limit.Memory)
+}
+
+// setResources sets the requested resources and their limits of the instance
+func (i *Instance) setResources(request, limit Resources) error {
+	if err := i.SetCPU(request.CPU); err != nil {
+		return err
+	}
+	if err := i.SetCPULimit(limit.CPU); err != nil {
+		return err
+	}
+	return i.SetMemory(request.Memory, limit.Memory)
+}
+
+// validateMemory checks that the memory request and limit are valid quantities
+// and that the limit is not lower than the request
+func validateMemory(request, limit string) error {
+	requestQuantity, err := parseQuantity(request)
+	if err != nil {
+		return err
+	}
+	limitQuantity, err := parseQuantity(limit)
+	if err != nil {
+		return err
+	}
+	if request != "" && limit != "" && limitQuantity.Cmp(requestQuantity) < 0 {
+		return ErrMemoryLimitLowerThanRequest.WithParams(limit, request)
+	}
+	return nil
+}
+
+// validateCPU checks that the CPU request and limit are valid quantities and that the limit is not lower than
+// the request
+func validateCPU(request, limit string) error {
+	requestQuantity, err := parseQuantity(request)
+	if err != nil {
+		return err
+	}
+	limitQuantity, err := parseQuantity(limit)
+	if err != nil {
+		return err
+	}
+	if request != "" && limit != "" && limitQuantity.Cmp(requestQuantity) < 0 {
+		return ErrCPULimitLowerThanRequest.WithParams(limit, request)
+	}
+	return nil
+}
+
+// quantityString returns the given quantity as a string, the zero quantity leaves the resource unset like the empty
+// string of SetMemory and SetCPU
+func quantityString(q resource.Quantity) string {
+	if q.IsZero() {
+		return ""
+	}
+	return q.String()
+}
+
+// SetMemoryQuantity sets the memory request and limit of the instance, a zero quantity leaves it unset
+// This function can only be called in the states 'Preparing' and 'Committed'
+func (i *Instance) SetMemoryQuantity(request, limit resource.Quantity) error {
+	return i.SetMemory(quantityString(request), quantityString(limit))
+}
+
+// SetMemoryMi sets the memory request and limit of the instance in mebibytes
+// This function can only be called in the states 'Preparing' and 'Committed'
+func (i *Instance) SetMemoryMi(request, limit int64) error {
+	return i.SetMemoryQuantity(
+		*resource.NewQuantity(request*1024*1024, resource.BinarySI),
+		*resource.NewQuantity(limit*1024*1024, resource.BinarySI),
+	)
+}
+
+// SetMemoryGi sets the memory request and limit of the instance in gibibytes
+// This function can only be called in the states 'Preparing' and 'Committed'
+func (i *Instance) SetMemoryGi(request, limit int64) error {
+	return i.SetMemoryMi(request*1024, limit*1024)
+}
+
+// SetCPUQuantity sets the CPU request of the instance, a zero quantity leaves it unset
+// This function can only be called in the states 'Preparing' and 'Committed'
+func (i *Instance) SetCPUQuantity(request resource.Quantity) error {
+	return i.SetCPU(quantityString(request))
+}
+
+// SetCPUMilli sets the CPU request of the instance in millicores
+// This function can only be called in the states 'Preparing' and 'Committed'
+func (i *Instance) SetCPUMilli(request int64) error {
+	return i.SetCPUQuantity(*resource.NewMilliQuantity(request, resource.DecimalSI))
+}
@@ -0,0 +1,134 @@
+package instance
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"github.com/celestiaorg/knuu/pkg/k8s/fake"
+	"github.com/celestiaorg/knuu/pkg/system"
+)
+
+func TestSetResourceQuantities(t *testing.T) {
+	t.Parallel()
+
+	i := &Instance{state: Committed}
+
+	require.NoError(t, i.SetMemoryGi(1, 2))
+	assert.Equal(t, "1Gi", i.memoryRequest)
+	assert.Equal(t, "2Gi", i.memoryLimit)
+
+	require.NoError(t, i.SetMemoryMi(512, 512))
+	assert.Equal(t, "512Mi", i.memoryRequest)
+
+	require.NoError(t, i.SetCPUMilli(500))
+	assert.Equal(t, "500m", i.cpuRequest)
+
+	require.NoError(t, i.SetMemory("100Mi", ""))
+	assert.Equal(t, "", i.memoryLimit)
+
+	assert.Error(t, i.SetMemory("lots", "200Mi"))
+	assert.Error(t, i.SetMemory("200Mi", "100Mi"), "the limit cannot be lower than the request")
+	assert.Error(t, i.SetCPU("fast"))
+	assert.Equal(t, "500m", i.cpuRequest, "an invalid quantity must not be set")
+
+	require.NoError(t, i.SetCPULimit("1"))
+	assert.Equal(t, "1", i.cpuLimit)
+	assert.Error(t, i.SetCPULimit("100m"), "the limit cannot be lower than the request")
+	assert.Error(t, i.SetCPU("2"), "the request cannot be higher than the limit")
+	assert.Equal(t, "1", i.cpuLimit)
+
+	// the zero quantities leave the resources unset
+	require.NoError(t, i.SetMemoryMi(256, 0))
+	assert.Equal(t, "256Mi", i.memoryRequest)
+	assert.Equal(t, "", i.memoryLimit)
+	require.NoError(t, i.SetMemoryGi(0, 0))
+	assert.Equal(t, "", i.memoryRequest)
+	require.NoError(t, i.SetCPUQuantity(resource.Quantity{}))
+	assert.Equal(t, "", i.cpuRequest)
+}
+
+func TestSetSidecarResources(t *testing.T) {
+	t.Parallel()
+
+	i, err := New("validator", system.SystemDependencies{})
+	require.NoError(t, err)
+	i.state = Preparing
+	assert.Equal(t, Resources{CPU: "100m", Memory: "100Mi"}, i.obsyConfig.otelCollectorRequest)
+	assert.Equal(t, Resources{Memory: "200Mi"}, i.obsyConfig.otelCollectorLimit)
+
+	request := Resources{CPU: "250m", Memory: "256Mi"}
+	limit := Resources{CPU: "500m", Memory: "512Mi"}
+	require.NoError(t, i.SetOtelCollectorResources(request, limit))
+	assert.Equal(t, request, i.obsyConfig.otelCollectorRequest)
+	assert.Equal(t, limit, i.obsyConfig.otelCollectorLimit)
+	assert.Error(t, i.SetOtelCollectorResources(limit, request), "the limits cannot be lower than the requests")
+
+	require.NoError(t, i.SetBitTwisterResources(request, limit))
+	assert.Equal(t, request, i.BitTwister.request)
+	assert.Equal(t, limit, i.BitTwister.limit)
+	assert.Error(t, i.SetBitTwisterResources(Resources{CPU: "fast"}, Resources{}))
+
+	sidecar := &Instance{state: Preparing}
+	require.NoError(t, sidecar.setResources(request, limit))
+	assert.Equal(t, ResourcesSpec{MemoryRequest: "256Mi", MemoryLimit: "512Mi", CPURequest: "250m", CPULimit: "500m"}, sidecar.Spec().Resources)
+
+	i.state = Started
+	assert.Error(t, i.SetOtelCollectorResources(request, limit))
+	assert.Error(t, i.SetBitTwisterResources(request, limit))
+}
+
+func TestDeployedResources(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	tests := []struct {
+		name             string
+		cpuRequest       string
+		cpuLimit         string
+		memoryRequest    string
+		memoryLimit      string
+		expectedRequests v1.ResourceList
+		expectedLimits   v1.ResourceList
+	}{
+		{
+			name: "nothing set",
+		},
+		{
+			name:             "request set, limit empty",
+			cpuRequest:       "100m",
+			memoryRequest:    "100Mi",
+			memoryLimit:      "200Mi",
+			expectedRequests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("100m"), v1.ResourceMemory: resource.MustParse("100Mi")},
+			expectedLimits:   v1.ResourceList{v1.ResourceMemory: resource.MustParse("200Mi")},
+		},
+		{
+			name:             "request and limit set",
+			cpuRequest:       "100m",
+			cpuLimit:         "1",
+			expectedRequests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("100m")},
+			expectedLimits:   v1.ResourceList{v1.ResourceCPU: resource.MustParse("1")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k8sCli, err := fake.New(ctx, "test")
+			require.NoError(t, err)
+			i, err := New("node", system.SystemDependencies{K8sCli: k8sCli}, WithImage("alpine"))
+			require.NoError(t, err)
+			i.cpuRequest, i.cpuLimit = tt.cpuRequest, tt.cpuLimit
+			i.memoryRequest, i.memoryLimit = tt.memoryRequest, tt.memoryLimit
+
+			rs, err := k8sCli.CreateReplicaSet(ctx, i.prepareReplicaSetConfig(), false)
+			require.NoError(t, err)
+			resources := rs.Spec.Template.Spec.Containers[0].Resources
+			assert.Equal(t, tt.expectedRequests, resources.Requests)
+			assert.Equal(t, tt.expectedLimits, resources.Limits)
+		})
+	}
+}
@@ -0,0 +1,17 @@
+package instance
+
+import (
+	rbacv1 "k8s.io/api/rbac/v1"
+
+	"github.com/celestiaorg/knuu/pkg/rbac"
+)
+
+// RBACReport compares the policy rules of the instance with the calls its service account made,
+// read from the audit log of the cluster, see rbac.ReadAuditLog
+// The minimal rules of the report can replace the rules of the instance in the next runs.
+func (i *Instance) RBACReport(events []rbac.AuditEvent) *rbac.Report {
+	i.mu.Lock()
+	granted := append([]rbacv1.PolicyRule(nil), i.policyRules...)
+	i.mu.Unlock()
+	return rbac.NewReport(rbac.ServiceAccountUser(i.K8sCli.Namespace(), i.k8sName), granted, events)
+}
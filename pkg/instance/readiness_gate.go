@@ -0,0 +1,188 @@
+package instance
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"github.com/celestiaorg/knuu/pkg/clock"
+)
+
+// readinessGateInterval is the interval between two evaluations of the readiness checks of an instance
+var readinessGateInterval = 2 * time.Second
+
+const (
+	readinessCheckPassed = "CheckPassed"
+	readinessCheckFailed = "CheckFailed"
+	readinessCheckError  = "CheckError"
+)
+
+// ReadinessCheck reports whether the instance satisfies the condition of a readiness gate, e.g. whether the node is
+// synced, peered or caught up. An error is reported as the message of the condition, which is then false.
+type ReadinessCheck func(ctx context.Context, i *Instance) (bool, error)
+
+type readinessGate struct {
+	conditionType v1.PodConditionType
+	check         ReadinessCheck
+}
+
+// AddReadinessGate adds a readiness gate of the given condition type to the pod of the instance, e.g.
+// 'knuu.sh/synced', so the pod is only ready, for its service and for the rollouts of its ReplicaSet, once the
+// check passes. Once the instance is started, knuu evaluates the checks of the instance at a regular interval and
+// sets the conditions of the pod accordingly, until the instance is stopped or destroyed, so Start and
+// WaitInstanceIsRunning wait for the checks to pass.
+// The checks are called with the instance unlocked, so they can use all its methods, e.g. ScrapeMetrics.
+// The readiness gates cannot be added to an instance scaled by a HorizontalPodAutoscaler, see EnableHPA.
+// This function can only be called in the state 'Preparing' or 'Committed'
+func (i *Instance) AddReadinessGate(conditionType string, check ReadinessCheck) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if !i.allows(ActionConfigure) {
+		return ErrAddingReadinessGateNotAllowed.WithParams(i.getState().String())
+	}
+	if i.isSidecar {
+		return ErrAddingReadinessGateToSidecar.WithParams(i.k8sName)
+	}
+	if i.hpa != nil {
+		return ErrHPAWithReadinessGates.WithParams(i.k8sName)
+	}
+	if errs := validation.IsQualifiedName(conditionType); len(errs) != 0 {
+		return ErrInvalidReadinessGate.WithParams(conditionType, strings.Join(errs, ", "))
+	}
+	if check == nil {
+		return ErrInvalidReadinessGate.WithParams(conditionType, "the check is nil")
+	}
+	for _, gate := range i.readinessGates {
+		if gate.conditionType == v1.PodConditionType(conditionType) {
+			return ErrInvalidReadinessGate.WithParams(conditionType, "it was already added")
+		}
+	}
+
+	i.readinessGates = append(i.readinessGates, readinessGate{conditionType: v1.PodConditionType(conditionType), check: check})
+	logrus.Debugf("Added readiness gate '%s' to instance '%s'", conditionType, i.k8sName)
+	return nil
+}
+
+// readinessGateTypes returns the condition types of the readiness gates of the pod of the instance
+func (i *Instance) readinessGateTypes() []v1.PodConditionType {
+	var types []v1.PodConditionType
+	for _, gate := range i.readinessGates {
+		types = append(types, gate.conditionType)
+	}
+	return types
+}
+
+// startReadinessGates starts the evaluation of the readiness checks of the instance in background, it is stopped by
+// stopReadinessGates
+// The evaluation outlives the given context, e.g. the context of Start. The caller must hold the lock of the instance.
+func (i *Instance) startReadinessGates(ctx context.Context) {
+	if len(i.readinessGates) == 0 {
+		return
+	}
+	i.stopReadinessGates()
+
+	ctx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	i.readinessCancel = cancel
+	gates := append([]readinessGate(nil), i.readinessGates...)
+	go func() {
+		ticker := clock.FromContext(ctx).NewTicker(readinessGateInterval)
+		defer ticker.Stop()
+		for {
+			i.evaluateReadinessGates(ctx, gates)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C():
+			}
+		}
+	}()
+	logrus.Debugf("Started the readiness gates of instance '%s'", i.k8sName)
+}
+
+// stopReadinessGates stops the evaluation of the readiness checks of the instance, if started
+// The caller must hold the lock of the instance.
+func (i *Instance) stopReadinessGates() {
+	if i.readinessCancel == nil {
+		return
+	}
+	i.readinessCancel()
+	i.readinessCancel = nil
+}
+
+// evaluateReadinessGates calls the readiness checks and sets the conditions of the pod whose status changed
+// The errors are logged, the conditions are set again at the next evaluation.
+func (i *Instance) evaluateReadinessGates(ctx context.Context, gates []readinessGate) {
+	if !i.IsInState(Started) {
+		return
+	}
+	pod, err := i.getPod(ctx)
+	if err != nil {
+		// the pod of a ReplicaSet can be recreated
+		logrus.Debugf("Error getting the pod of instance '%s' to evaluate its readiness gates: %v", i.k8sName, err)
+		return
+	}
+	for _, gate := range gates {
+		condition := evaluateReadinessGate(ctx, i, gate)
+		if ctx.Err() != nil {
+			return
+		}
+		if current, ok := podCondition(pod, gate.conditionType); ok && current.Status == condition.Status &&
+			current.Reason == condition.Reason && current.Message == condition.Message {
+			continue
+		}
+		if err := i.K8sCli.SetPodCondition(ctx, pod.Name, condition); err != nil {
+			if ctx.Err() == nil {
+				logrus.Warn(ErrSettingReadinessGate.WithParams(gate.conditionType, i.k8sName).Wrap(err))
+			}
+			continue
+		}
+		logrus.Debugf("Set readiness gate '%s' of instance '%s' to '%s'", gate.conditionType, i.k8sName, condition.Status)
+	}
+}
+
+// evaluateReadinessGate calls the readiness check of the gate and returns the resulting condition of the pod
+func evaluateReadinessGate(ctx context.Context, i *Instance, gate readinessGate) v1.PodCondition {
+	condition := v1.PodCondition{
+		Type:               gate.conditionType,
+		Status:             v1.ConditionFalse,
+		Reason:             readinessCheckFailed,
+		LastTransitionTime: metav1.Now(),
+	}
+	ready, err := gate.check(ctx, i)
+	switch {
+	case err != nil:
+		condition.Reason = readinessCheckError
+		condition.Message = err.Error()
+	case ready:
+		condition.Status = v1.ConditionTrue
+		condition.Reason = readinessCheckPassed
+	}
+	return condition
+}
+
+// podCondition returns the condition of the given type of the pod
+func podCondition(pod *v1.Pod, conditionType v1.PodConditionType) (v1.PodCondition, bool) {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == conditionType {
+			return condition, true
+		}
+	}
+	return v1.PodCondition{}, false
+}
+
+// readinessGatesPassed returns true if the conditions of all the readiness gates of the pod are true
+func readinessGatesPassed(pod *v1.Pod) bool {
+	for _, gate := range pod.Spec.ReadinessGates {
+		condition, ok := podCondition(pod, gate.ConditionType)
+		if !ok || condition.Status != v1.ConditionTrue {
+			return false
+		}
+	}
+	return true
+}
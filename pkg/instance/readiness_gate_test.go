@@ -0,0 +1,100 @@
+package instance
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/celestiaorg/knuu/pkg/k8s/fake"
+	"github.com/celestiaorg/knuu/pkg/system"
+)
+
+func TestAddReadinessGate(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	k8sCli, err := fake.New(ctx, "test")
+	require.NoError(t, err)
+	i, err := New("node", system.SystemDependencies{K8sCli: k8sCli}, WithImage("alpine"))
+	require.NoError(t, err)
+
+	synced := func(context.Context, *Instance) (bool, error) { return true, nil }
+	require.NoError(t, i.AddReadinessGate("knuu.sh/synced", synced))
+	assert.ErrorIs(t, i.AddReadinessGate("knuu.sh/synced", synced), ErrInvalidReadinessGate)
+	assert.ErrorIs(t, i.AddReadinessGate("knuu.sh/not synced", synced), ErrInvalidReadinessGate)
+	assert.ErrorIs(t, i.AddReadinessGate("knuu.sh/peered", nil), ErrInvalidReadinessGate)
+	assert.Equal(t, []v1.PodConditionType{"knuu.sh/synced"}, i.preparePodConfig().ReadinessGates)
+
+	sidecar, err := New("sidecar", system.SystemDependencies{K8sCli: k8sCli}, WithImage("alpine"))
+	require.NoError(t, err)
+	sidecar.isSidecar = true
+	assert.ErrorIs(t, sidecar.AddReadinessGate("knuu.sh/synced", synced), ErrAddingReadinessGateToSidecar)
+
+	i.setState(Started)
+	assert.ErrorIs(t, i.AddReadinessGate("knuu.sh/peered", synced), ErrAddingReadinessGateNotAllowed)
+}
+
+func TestReadinessGates(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	k8sCli, err := fake.New(ctx, "test")
+	require.NoError(t, err)
+	i, err := New("node", system.SystemDependencies{K8sCli: k8sCli}, WithImage("alpine"))
+	require.NoError(t, err)
+
+	var synced, peered atomic.Bool
+	require.NoError(t, i.AddReadinessGate("knuu.sh/synced", func(context.Context, *Instance) (bool, error) {
+		return synced.Load(), nil
+	}))
+	require.NoError(t, i.AddReadinessGate("knuu.sh/peered", func(context.Context, *Instance) (bool, error) {
+		if !peered.Load() {
+			return false, errors.New("no peers")
+		}
+		return true, nil
+	}))
+	i.restartPolicy = v1.RestartPolicyNever
+	_, err = k8sCli.DeployPod(ctx, i.preparePodConfig(), false)
+	require.NoError(t, err)
+	i.setState(Started)
+
+	conditions := func() map[v1.PodConditionType]v1.PodCondition {
+		pod, err := k8sCli.FakeClientset.CoreV1().Pods("test").Get(ctx, i.k8sName, metav1.GetOptions{})
+		require.NoError(t, err)
+		conditions := make(map[v1.PodConditionType]v1.PodCondition)
+		for _, condition := range pod.Status.Conditions {
+			conditions[condition.Type] = condition
+		}
+		return conditions
+	}
+	podReady := func() bool {
+		pod, err := k8sCli.FakeClientset.CoreV1().Pods("test").Get(ctx, i.k8sName, metav1.GetOptions{})
+		require.NoError(t, err)
+		return readinessGatesPassed(pod)
+	}
+
+	i.mu.Lock()
+	i.startReadinessGates(ctx)
+	i.mu.Unlock()
+	require.Eventually(t, func() bool { return len(conditions()) == 2 }, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, v1.ConditionFalse, conditions()["knuu.sh/synced"].Status)
+	assert.Equal(t, "no peers", conditions()["knuu.sh/peered"].Message)
+	assert.False(t, podReady())
+
+	synced.Store(true)
+	peered.Store(true)
+	require.Eventually(t, podReady, 10*time.Second, 10*time.Millisecond)
+	assert.Equal(t, readinessCheckPassed, conditions()["knuu.sh/peered"].Reason)
+
+	i.mu.Lock()
+	i.stopReadinessGates()
+	assert.Nil(t, i.readinessCancel)
+	i.mu.Unlock()
+}
@@ -0,0 +1,42 @@
+package instance
+
+import (
+	"time"
+
+	"github.com/celestiaorg/knuu/pkg/recording"
+)
+
+// recordReplayable records an operation of the instance that started at the given time in the recording of the
+// test, so that it can be replayed, see scenario.Runner.Replay
+// It is meant to be deferred with a pointer to the error returned by the operation. The operations of the
+// sidecars are not recorded, they are replayed with their instance, nor are the ones of the attached workloads,
+// which are not deployed by the replay.
+func (i *Instance) recordReplayable(op recording.Operation, start time.Time, err *error) {
+	if i.Recorder == nil || i.isSidecar || i.attached != nil {
+		return
+	}
+	op.Instance = i.name
+	i.Recorder.Record(op, start, *err)
+}
+
+// recordCommit records the configuration the instance was committed with in the recording of the test
+// The caller must hold the lock of the instance.
+func (i *Instance) recordCommit() {
+	if i.Recorder == nil || i.isSidecar {
+		return
+	}
+	s := i.spec()
+	i.Recorder.AddInstance(recording.Instance{
+		Name:          s.Name,
+		Image:         s.Image,
+		Command:       s.Command,
+		Args:          s.Args,
+		PortsTCP:      s.PortsTCP,
+		PortsUDP:      s.PortsUDP,
+		Env:           s.Env,
+		MemoryRequest: s.Resources.MemoryRequest,
+		MemoryLimit:   s.Resources.MemoryLimit,
+		CPURequest:    s.Resources.CPURequest,
+		BitTwister:    i.BitTwister.Enabled(),
+	})
+}
@@ -0,0 +1,37 @@
+package instance
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/celestiaorg/knuu/pkg/recording"
+)
+
+func TestRecordReplayable(t *testing.T) {
+	t.Parallel()
+
+	i, _ := startedInstanceWithArchive(t, "")
+	i.Recorder = recording.NewRecorder()
+
+	_, err := i.ExecuteCommand(context.Background(), "echo", "hello")
+	require.NoError(t, err)
+	// bitTwister is not enabled, so the fault fails
+	assert.Error(t, i.SetPacketLoss(10))
+	require.NoError(t, i.DisableNetwork(context.Background()))
+	require.NoError(t, i.EnableNetwork(context.Background()))
+
+	ops := i.Recorder.Recording().Operations
+	require.Len(t, ops, 4)
+	assert.Equal(t, "validator", ops[0].Instance)
+	assert.Equal(t, recording.KindExec, ops[0].Kind)
+	assert.Equal(t, []string{"echo", "hello"}, ops[0].Command)
+	assert.Empty(t, ops[0].Error)
+	assert.Equal(t, recording.KindPacketLoss, ops[1].Kind)
+	assert.Equal(t, int32(10), ops[1].PacketLoss)
+	assert.NotEmpty(t, ops[1].Error)
+	assert.Equal(t, recording.KindNetworkDisabled, ops[2].Kind)
+	assert.Equal(t, recording.KindNetworkEnabled, ops[3].Kind)
+}
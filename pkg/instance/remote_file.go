@@ -0,0 +1,94 @@
+package instance
+
+import (
+	"context"
+	"encoding/hex"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/celestiaorg/knuu/pkg/k8s"
+)
+
+// AddFileFromURL downloads the file at the given URL to dest when the instance starts, e.g. a multi-GB genesis snapshot
+// The file is fetched by an init container, so it is neither built into the image nor stored in a ConfigMap.
+// The checksum is the hex encoded SHA-256 of the file, the start fails if it does not match. It is not verified if empty.
+// The dest must be in a volume of the instance, the file is given to the owner of the volume.
+// This function can only be called in the states 'Preparing' and 'Committed'
+func (i *Instance) AddFileFromURL(fileURL, dest, checksum string) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if err := i.checkStateForAddingRemoteFile(); err != nil {
+		return err
+	}
+	u, err := url.Parse(fileURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return ErrInvalidFileURL.WithParams(fileURL)
+	}
+	checksum = strings.ToLower(checksum)
+	if checksum != "" {
+		if b, err := hex.DecodeString(checksum); err != nil || len(b) != 32 {
+			return ErrInvalidFileChecksum.WithParams(checksum)
+		}
+	}
+	if err := i.validateRemoteFileDest(dest); err != nil {
+		return err
+	}
+
+	i.remoteFiles = append(i.remoteFiles, &k8s.RemoteFile{URL: fileURL, Dest: dest, SHA256: checksum})
+	// the query is not logged, it contains the signature of presigned URLs
+	logrus.Debugf("Added file from '%s://%s%s' to '%s' in instance '%s'", u.Scheme, u.Host, u.Path, dest, i.name)
+	return nil
+}
+
+// AddFileFromObjectStore downloads the artifact with the given key to dest when the instance starts
+// The artifact is put with artifact.Store.Put before, it is fetched with a presigned URL that is valid for 24 hours.
+// The dest must be in a volume of the instance, the file is given to the owner of the volume.
+// This function can only be called in the states 'Preparing' and 'Committed'
+func (i *Instance) AddFileFromObjectStore(ctx context.Context, key, dest string) error {
+	if err := i.checkStateForAddingRemoteFile(); err != nil {
+		return err
+	}
+	if i.Artifacts == nil {
+		return ErrArtifactStoreNotInitialized
+	}
+
+	fileURL, err := i.Artifacts.URL(ctx, key)
+	if err != nil {
+		return ErrGettingArtifactURL.WithParams(key).Wrap(err)
+	}
+	return i.AddFileFromURL(fileURL, dest, "")
+}
+
+// checkStateForAddingRemoteFile checks if the current state allows adding a remote file
+func (i *Instance) checkStateForAddingRemoteFile() error {
+	if !i.allows(ActionConfigure) {
+		return ErrAddingRemoteFileNotAllowed.WithParams(i.getState().String())
+	}
+	if i.isSidecar {
+		return ErrAddingRemoteFileToSidecar
+	}
+	return nil
+}
+
+// validateRemoteFileDest checks that the dest of a remote file is an absolute path in a volume of the instance
+func (i *Instance) validateRemoteFileDest(dest string) error {
+	if dest == "" {
+		return ErrDestMustBeSet
+	}
+	if !filepath.IsAbs(dest) || filepath.Clean(dest) != dest {
+		return ErrInvalidRemoteFileDest.WithParams(dest)
+	}
+	if !i.isSubFolderOfVolumes(dest) {
+		return ErrFileIsNotSubFolderOfVolumes.WithParams(dest)
+	}
+	for _, f := range i.remoteFiles {
+		if f.Dest == dest {
+			return ErrRemoteFileAlreadyAdded.WithParams(dest)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package instance
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/celestiaorg/knuu/pkg/k8s/fake"
+	"github.com/celestiaorg/knuu/pkg/system"
+)
+
+const genesisChecksum = "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+
+func TestAddFileFromURL(t *testing.T) {
+	t.Parallel()
+
+	k8sCli, err := fake.New(context.Background(), "test")
+	require.NoError(t, err)
+	i, err := New("validator", system.SystemDependencies{K8sCli: k8sCli}, WithImage("alpine"))
+	require.NoError(t, err)
+
+	url := "https://snapshots.example.com/genesis.tar"
+	assert.ErrorIs(t, i.AddFileFromURL(url, "/data/genesis.tar", genesisChecksum), ErrFileIsNotSubFolderOfVolumes)
+	require.NoError(t, i.AddVolumeWithOwner("/data", "10Gi", 10001))
+
+	assert.ErrorIs(t, i.AddFileFromURL("file:///genesis.tar", "/data/genesis.tar", ""), ErrInvalidFileURL)
+	assert.ErrorIs(t, i.AddFileFromURL(url, "/data/genesis.tar", "sha256"), ErrInvalidFileChecksum)
+	assert.ErrorIs(t, i.AddFileFromURL(url, "/data/../genesis.tar", ""), ErrInvalidRemoteFileDest)
+	require.NoError(t, i.AddFileFromURL(url, "/data/genesis.tar", strings.ToUpper(genesisChecksum)))
+	assert.ErrorIs(t, i.AddFileFromURL(url, "/data/genesis.tar", ""), ErrRemoteFileAlreadyAdded)
+	assert.Equal(t, genesisChecksum, i.Spec().RemoteFiles[0].SHA256)
+
+	i.state = Started
+	assert.ErrorIs(t, i.AddFileFromURL(url, "/data/other.tar", ""), ErrAddingRemoteFileNotAllowed)
+}
+
+func TestRemoteFilesAreFetchedByInitContainer(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	k8sCli, err := fake.New(ctx, "test")
+	require.NoError(t, err)
+	i, err := New("validator", system.SystemDependencies{K8sCli: k8sCli}, WithImage("alpine"))
+	require.NoError(t, err)
+	require.NoError(t, i.AddVolumeWithOwner("/data", "10Gi", 10001))
+	require.NoError(t, i.AddFileFromURL("https://snapshots.example.com/genesis.tar", "/data/genesis.tar", genesisChecksum))
+
+	_, err = k8sCli.DeployPod(ctx, i.preparePodConfig(), true)
+	require.NoError(t, err)
+	pod, err := k8sCli.FakeClientset.CoreV1().Pods("test").Get(ctx, i.k8sName, metav1.GetOptions{})
+	require.NoError(t, err)
+
+	require.Len(t, pod.Spec.InitContainers, 2)
+	fetch := pod.Spec.InitContainers[1]
+	assert.Equal(t, i.k8sName+"-fetch", fetch.Name)
+	assert.Equal(t, "/knuu", fetch.VolumeMounts[0].MountPath)
+	command := fetch.Command[2]
+	assert.Contains(t, command, "curl -fsSL --retry 5 -o '/knuu/data/genesis.tar.download' 'https://snapshots.example.com/genesis.tar'")
+	assert.Contains(t, command, "echo '"+genesisChecksum+"  /knuu/data/genesis.tar.download' | sha256sum -c -")
+	assert.Contains(t, command, "chown 10001:10001 '/knuu/data/genesis.tar'")
+
+	// the files are only fetched when the pod is initialized
+	_, err = k8sCli.ReplacePod(ctx, i.preparePodConfig())
+	require.NoError(t, err)
+	pod, err = k8sCli.FakeClientset.CoreV1().Pods("test").Get(ctx, i.k8sName, metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, pod.Spec.InitContainers)
+}
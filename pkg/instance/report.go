@@ -0,0 +1,57 @@
+package instance
+
+import (
+	"context"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/celestiaorg/knuu/pkg/report"
+)
+
+// recordOperation records an operation of the instance that started at the given time in the report of the test
+// It is meant to be deferred with a pointer to the error returned by the operation.
+func (i *Instance) recordOperation(op report.Operation, start time.Time, message string, err *error) {
+	i.Reporter.Record(i.k8sName, op, start, message, *err)
+}
+
+// recordStartPhases records the schedule and ready phases of a start that deployed the instance at the given time
+// The end of the schedule phase is read from the conditions of the pod, if the pod was not scheduled
+// the error is attributed to the schedule phase.
+func (i *Instance) recordStartPhases(ctx context.Context, deployStart time.Time, err error) {
+	if i.Reporter == nil {
+		return
+	}
+	end := time.Now()
+	scheduled, ok := i.podScheduledTime(context.WithoutCancel(ctx))
+	if !ok {
+		i.Reporter.RecordPhase(i.k8sName, report.PhaseSchedule, deployStart, end, err)
+		return
+	}
+	// the conditions have a resolution of a second, so the time is bounded by the measured ones
+	if scheduled.Before(deployStart) {
+		scheduled = deployStart
+	}
+	if scheduled.After(end) {
+		scheduled = end
+	}
+	i.Reporter.RecordPhase(i.k8sName, report.PhaseSchedule, deployStart, scheduled, nil)
+	i.Reporter.RecordPhase(i.k8sName, report.PhaseReady, scheduled, end, err)
+}
+
+// podScheduledTime returns the time the pod of the instance was scheduled on a node
+func (i *Instance) podScheduledTime(ctx context.Context) (time.Time, bool) {
+	if i.K8sCli == nil {
+		return time.Time{}, false
+	}
+	pod, err := i.getPod(ctx)
+	if err != nil || pod == nil {
+		return time.Time{}, false
+	}
+	for _, cond := range pod.Status.Conditions {
+		if cond.Type == corev1.PodScheduled && cond.Status == corev1.ConditionTrue {
+			return cond.LastTransitionTime.Time, true
+		}
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,58 @@
+package instance
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/celestiaorg/knuu/pkg/report"
+	"github.com/celestiaorg/knuu/pkg/system"
+)
+
+func TestOperationsAreRecorded(t *testing.T) {
+	t.Parallel()
+
+	recorder := report.NewRecorder("test")
+	i := &Instance{
+		k8sName:            "test-instance",
+		state:              Committed,
+		SystemDependencies: system.SystemDependencies{Reporter: recorder},
+	}
+
+	// stopping is not allowed in the state 'Committed', the failure is recorded
+	require.Error(t, i.Stop(context.Background()))
+	require.Error(t, i.SetPacketLoss(10))
+
+	entries := recorder.Report().Entries
+	require.Len(t, entries, 2)
+	assert.Equal(t, report.OperationStop, entries[0].Operation)
+	assert.Equal(t, "test-instance", entries[0].Instance)
+	assert.True(t, entries[0].Failed())
+	assert.Equal(t, report.OperationFault, entries[1].Operation)
+	assert.Equal(t, "packet loss 10%", entries[1].Message)
+}
+
+func TestStartPhasesWithoutScheduledPod(t *testing.T) {
+	t.Parallel()
+
+	recorder := report.NewRecorder("test")
+	i := &Instance{
+		k8sName:            "test-instance",
+		SystemDependencies: system.SystemDependencies{Reporter: recorder},
+	}
+
+	// without a scheduled pod, the whole start is attributed to the schedule phase
+	deployStart := time.Now().Add(-time.Second)
+	i.recordStartPhases(context.Background(), deployStart, errors.New("timeout"))
+
+	phases := recorder.Report().Phases
+	require.Len(t, phases, 1)
+	assert.Equal(t, report.PhaseSchedule, phases[0].Phase)
+	assert.Equal(t, deployStart, phases[0].Start)
+	assert.GreaterOrEqual(t, phases[0].Duration, time.Second)
+	assert.Equal(t, "timeout", phases[0].Error)
+}
@@ -0,0 +1,160 @@
+package instance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Kinds of resources that are created for an instance
+const (
+	resourceService            = "service"
+	resourceVolume             = "persistentvolumeclaim"
+	resourceConfigMap          = "configmap"
+	resourceSecret             = "secret"
+	resourceServiceAccount     = "serviceaccount"
+	resourceRole               = "role"
+	resourceRoleBinding        = "rolebinding"
+	resourceClusterRole        = "clusterrole"
+	resourceClusterRoleBinding = "clusterrolebinding"
+	resourceReplicaSet         = "replicaset"
+	resourcePod                = "pod"
+	// resourceVerticalPodAutoscaler is the VerticalPodAutoscaler of an instance, see EnableVPA
+	resourceVerticalPodAutoscaler = "verticalpodautoscaler"
+	// resourceHorizontalPodAutoscaler is the HorizontalPodAutoscaler of an instance, see EnableHPA
+	resourceHorizontalPodAutoscaler = "horizontalpodautoscaler"
+)
+
+// ResourceFailure describes a resource that could not be created or rolled back
+type ResourceFailure struct {
+	// Resource is the resource in the form 'kind/name'
+	Resource string
+	// Err is the reason of the failure
+	Err error
+}
+
+// ResourceDeploymentError is returned when one or more resources of an instance
+// could not be created. It records which resources were created, which failed and why,
+// and the outcome of the rollback of the created resources.
+type ResourceDeploymentError struct {
+	Instance       string
+	Created        []string
+	Failed         []ResourceFailure
+	RolledBack     []string
+	RollbackFailed []ResourceFailure
+}
+
+// Error returns a summary of the deployment
+func (e *ResourceDeploymentError) Error() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "error deploying resources for instance '%s'", e.Instance)
+	fmt.Fprintf(&sb, ": failed [%s]", joinFailures(e.Failed))
+	fmt.Fprintf(&sb, ", created [%s]", strings.Join(e.Created, ", "))
+	fmt.Fprintf(&sb, ", rolled back [%s]", strings.Join(e.RolledBack, ", "))
+	if len(e.RollbackFailed) != 0 {
+		fmt.Fprintf(&sb, ", rollback failed [%s]", joinFailures(e.RollbackFailed))
+	}
+	return sb.String()
+}
+
+// Unwrap returns the errors of the failed resources, so that errors.Is and errors.As
+// can be used on the underlying causes.
+func (e *ResourceDeploymentError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Failed)+len(e.RollbackFailed))
+	for _, f := range e.Failed {
+		errs = append(errs, f.Err)
+	}
+	for _, f := range e.RollbackFailed {
+		errs = append(errs, f.Err)
+	}
+	return errs
+}
+
+func joinFailures(failures []ResourceFailure) string {
+	parts := make([]string, len(failures))
+	for i, f := range failures {
+		parts[i] = fmt.Sprintf("%s: %v", f.Resource, f.Err)
+	}
+	return strings.Join(parts, "; ")
+}
+
+// rollbackFunc removes a resource that has been created
+type rollbackFunc func(ctx context.Context) error
+
+type trackedResource struct {
+	resource string
+	rollback rollbackFunc
+}
+
+// resourceTracker keeps track of the resources created for an instance,
+// so that they can be rolled back when the creation of another resource fails.
+type resourceTracker struct {
+	instance string
+	created  []trackedResource
+	failed   []ResourceFailure
+}
+
+func newResourceTracker(instance string) *resourceTracker {
+	return &resourceTracker{instance: instance}
+}
+
+// run executes the given deploy function for the resource of the given kind and name.
+// The deploy function returns the function that rolls back the created resource,
+// which can be nil if there is nothing to roll back (e.g. a patched resource).
+// It returns true if the resource was created successfully.
+func (t *resourceTracker) run(kind, name string, deploy func() (rollbackFunc, error)) bool {
+	resource := kind + "/" + name
+	rollback, err := deploy()
+	if err != nil {
+		t.failed = append(t.failed, ResourceFailure{Resource: resource, Err: err})
+		logrus.Debugf("Failed to create %s for instance '%s': %v", resource, t.instance, err)
+		return false
+	}
+	t.created = append(t.created, trackedResource{resource: resource, rollback: rollback})
+	logrus.Debugf("Created %s for instance '%s'", resource, t.instance)
+	return true
+}
+
+// hasFailures returns true if the creation of any resource failed
+func (t *resourceTracker) hasFailures() bool {
+	return len(t.failed) != 0
+}
+
+// rollback removes the created resources in reverse order of creation and
+// returns a ResourceDeploymentError summarizing the deployment.
+func (t *resourceTracker) rollback(ctx context.Context) *ResourceDeploymentError {
+	dErr := &ResourceDeploymentError{
+		Instance: t.instance,
+		Failed:   t.failed,
+	}
+	for _, c := range t.created {
+		dErr.Created = append(dErr.Created, c.resource)
+	}
+
+	for j := len(t.created) - 1; j >= 0; j-- {
+		c := t.created[j]
+		if c.rollback == nil {
+			continue
+		}
+		if err := c.rollback(ctx); err != nil {
+			dErr.RollbackFailed = append(dErr.RollbackFailed, ResourceFailure{Resource: c.resource, Err: err})
+			logrus.Debugf("Failed to roll back %s for instance '%s': %v", c.resource, t.instance, err)
+			continue
+		}
+		dErr.RolledBack = append(dErr.RolledBack, c.resource)
+		logrus.Debugf("Rolled back %s for instance '%s'", c.resource, t.instance)
+	}
+	t.created = nil
+
+	return dErr
+}
+
+// IsResourceDeploymentError returns the ResourceDeploymentError from the chain of err, if any
+func IsResourceDeploymentError(err error) (*ResourceDeploymentError, bool) {
+	var dErr *ResourceDeploymentError
+	ok := errors.As(err, &dErr)
+	return dErr, ok
+}
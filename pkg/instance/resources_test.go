@@ -0,0 +1,80 @@
+package instance
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResourceTrackerRollback(t *testing.T) {
+	t.Parallel()
+
+	var (
+		ctx       = context.Background()
+		errDeploy = errors.New("deploy failed")
+		removed   []string
+		tracker   = newResourceTracker("test-instance")
+	)
+
+	remove := func(name string) rollbackFunc {
+		return func(ctx context.Context) error {
+			removed = append(removed, name)
+			return nil
+		}
+	}
+
+	assert.True(t, tracker.run(resourceService, "svc", func() (rollbackFunc, error) {
+		return remove("svc"), nil
+	}))
+	assert.True(t, tracker.run(resourceVolume, "pvc", func() (rollbackFunc, error) {
+		return remove("pvc"), nil
+	}))
+	assert.True(t, tracker.run(resourceReplicaSet, "rs", func() (rollbackFunc, error) {
+		return nil, nil
+	}))
+	assert.False(t, tracker.run(resourceConfigMap, "cm", func() (rollbackFunc, error) {
+		return nil, errDeploy
+	}))
+	require.True(t, tracker.hasFailures())
+
+	dErr := tracker.rollback(ctx)
+	require.NotNil(t, dErr)
+
+	assert.Equal(t, []string{"pvc", "svc"}, removed, "resources must be rolled back in reverse order")
+	assert.Equal(t, []string{"service/svc", "persistentvolumeclaim/pvc", "replicaset/rs"}, dErr.Created)
+	assert.Equal(t, []string{"persistentvolumeclaim/pvc", "service/svc"}, dErr.RolledBack)
+	require.Len(t, dErr.Failed, 1)
+	assert.Equal(t, "configmap/cm", dErr.Failed[0].Resource)
+	assert.Empty(t, dErr.RollbackFailed)
+	assert.ErrorIs(t, dErr, errDeploy)
+
+	got, ok := IsResourceDeploymentError(dErr)
+	assert.True(t, ok)
+	assert.Equal(t, dErr, got)
+}
+
+func TestResourceTrackerRollbackFailure(t *testing.T) {
+	t.Parallel()
+
+	var (
+		errRollback = errors.New("rollback failed")
+		tracker     = newResourceTracker("test-instance")
+	)
+
+	tracker.run(resourceService, "svc", func() (rollbackFunc, error) {
+		return func(ctx context.Context) error { return errRollback }, nil
+	})
+	tracker.run(resourceVolume, "pvc", func() (rollbackFunc, error) {
+		return nil, errors.New("deploy failed")
+	})
+
+	dErr := tracker.rollback(context.Background())
+	require.Len(t, dErr.RollbackFailed, 1)
+	assert.Equal(t, "service/svc", dErr.RollbackFailed[0].Resource)
+	assert.Empty(t, dErr.RolledBack)
+	assert.ErrorIs(t, dErr, errRollback)
+	assert.Contains(t, dErr.Error(), "rollback failed [service/svc: rollback failed]")
+}
@@ -0,0 +1,90 @@
+package instance
+
+import (
+	"context"
+	"maps"
+	"path"
+	"strconv"
+
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"github.com/celestiaorg/knuu/pkg/identity"
+	"github.com/celestiaorg/knuu/pkg/k8s"
+)
+
+// the identities of the instances are delivered through secrets
+var _ identity.Target = &Instance{}
+
+// secretDir is a directory of the instance whose files are delivered through a secret
+type secretDir struct {
+	dir   string
+	files map[string][]byte
+}
+
+// AddSecretFiles adds the given files, by file name, to the given directory of the instance through a secret
+// mounted when the instance is started, e.g. the private keys: unlike the files added with AddFileBytes, they are
+// not part of the image pushed to the registry, which may be public.
+// The directory is mounted read-only and hides its content in the image. Adding files to the same directory
+// again replaces them.
+// This function can only be called in the states 'Preparing' and 'Committed'
+func (i *Instance) AddSecretFiles(dir string, files map[string][]byte) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if !i.allows(ActionConfigure) {
+		return ErrAddingSecretFilesNotAllowed.WithParams(i.getState().String())
+	}
+	if !path.IsAbs(dir) {
+		return ErrSecretFilesDirNotAbsolute.WithParams(dir)
+	}
+	for name := range files {
+		if errs := validation.IsConfigMapKey(name); len(errs) != 0 {
+			return ErrInvalidSecretFileName.WithParams(name, errs)
+		}
+	}
+
+	dir = path.Clean(dir)
+	files = maps.Clone(files)
+	for j := range i.secretDirs {
+		if i.secretDirs[j].dir == dir {
+			i.secretDirs[j].files = files
+			return nil
+		}
+	}
+	i.secretDirs = append(i.secretDirs, secretDir{dir: dir, files: files})
+	logrus.Debugf("Added %d secret files to '%s' of instance '%s'", len(files), dir, i.name)
+	return nil
+}
+
+// secretFilesName returns the name of the secret holding the files of the nth secret directory of the instance
+func (i *Instance) secretFilesName(n int) string {
+	return i.k8sName + "-files-" + strconv.Itoa(n)
+}
+
+// secretFilesMounts returns the mounts of the secrets holding the files of the secret directories of the instance
+func (i *Instance) secretFilesMounts() []*k8s.SecretMount {
+	mounts := make([]*k8s.SecretMount, 0, len(i.secretDirs))
+	for n, sd := range i.secretDirs {
+		mounts = append(mounts, &k8s.SecretMount{Secret: i.secretFilesName(n), Path: sd.dir})
+	}
+	return mounts
+}
+
+// deploySecretFiles deploys the secret holding the files of the nth secret directory of the instance
+func (i *Instance) deploySecretFiles(ctx context.Context, n int) error {
+	if _, err := i.K8sCli.CreateSecret(ctx, i.secretFilesName(n), i.getLabels(), i.secretDirs[n].files); err != nil {
+		return ErrDeployingSecretFiles.WithParams(i.secretDirs[n].dir, i.name).Wrap(err)
+	}
+	logrus.Debugf("Deployed secret '%s'", i.secretFilesName(n))
+	return nil
+}
+
+// destroySecretFiles destroys the secret holding the files of the nth secret directory of the instance
+func (i *Instance) destroySecretFiles(ctx context.Context, n int) error {
+	if err := i.K8sCli.DeleteSecret(ctx, i.secretFilesName(n)); err != nil {
+		return ErrDestroyingSecretFiles.WithParams(i.secretDirs[n].dir, i.name).Wrap(err)
+	}
+	logrus.Debugf("Destroyed secret '%s'", i.secretFilesName(n))
+	return nil
+}
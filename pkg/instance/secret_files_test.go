@@ -0,0 +1,59 @@
+package instance
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/celestiaorg/knuu/pkg/identity"
+	"github.com/celestiaorg/knuu/pkg/k8s/fake"
+	"github.com/celestiaorg/knuu/pkg/system"
+)
+
+func TestAddSecretFiles(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	k8sCli, err := fake.New(ctx, "test")
+	require.NoError(t, err)
+	i, err := New("validator", system.SystemDependencies{K8sCli: k8sCli}, WithImage("alpine"))
+	require.NoError(t, err)
+
+	assert.ErrorIs(t, i.AddSecretFiles("keys", nil), ErrSecretFilesDirNotAbsolute)
+	assert.ErrorIs(t, i.AddSecretFiles("/keys", map[string][]byte{"a/b": nil}), ErrInvalidSecretFileName)
+
+	ca, err := identity.NewCA("knuu-test")
+	require.NoError(t, err)
+	id, err := ca.Inject(i, "")
+	require.NoError(t, err)
+	// the private keys are not part of the image pushed to the registry
+	assert.False(t, i.builderFactory.Changed())
+	require.NoError(t, i.Commit())
+
+	tracker := newResourceTracker(i.k8sName)
+	i.deployResources(ctx, tracker)
+	require.False(t, tracker.hasFailures())
+	secret, err := k8sCli.FakeClientset.CoreV1().Secrets(k8sCli.Namespace()).Get(ctx, i.secretFilesName(0), metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, id.Files(), secret.Data)
+
+	pod, err := k8sCli.DeployPod(ctx, i.preparePodConfig(), false)
+	require.NoError(t, err)
+	assert.Contains(t, pod.Spec.Containers[0].VolumeMounts, v1.VolumeMount{Name: i.secretFilesName(0), MountPath: identity.DefaultDir, ReadOnly: true})
+
+	// the identity is replaced
+	require.NoError(t, i.AddSecretFiles(identity.DefaultDir+"/", map[string][]byte{identity.CAFile: ca.CertPEM()}))
+	assert.Len(t, i.secretDirs, 1)
+	assert.Len(t, i.cloneWithSuffix("-1").secretDirs, 1)
+
+	require.NoError(t, i.destroySecretFiles(ctx, 0))
+	_, err = k8sCli.FakeClientset.CoreV1().Secrets(k8sCli.Namespace()).Get(ctx, i.secretFilesName(0), metav1.GetOptions{})
+	assert.Error(t, err)
+
+	i.setState(Started)
+	assert.ErrorIs(t, i.AddSecretFiles("/keys", nil), ErrAddingSecretFilesNotAllowed)
+}
@@ -0,0 +1,187 @@
+package instance
+
+import (
+	"context"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"github.com/celestiaorg/knuu/pkg/k8s"
+)
+
+// ServiceSpec describes an additional service of an instance, e.g. to expose a public RPC port apart from the P2P ports
+type ServiceSpec struct {
+	// Name of the service, the kubernetes service is named '<k8s name of the instance>-<Name>'
+	Name string
+	// Ports of the service, all the ports of the instance and its sidecars by default.
+	// A port with the name of a port of the instance exposes that port, its Port then remaps the port of the service.
+	// The other ports are new ports of the service, see Port.
+	Ports []Port
+	// Type of the service, ClusterIP by default
+	Type v1.ServiceType
+	// Selector of the pods of the service, the pod of the instance by default
+	Selector map[string]string
+}
+
+// AddService adds an additional service to the instance, it is deployed when the instance is started
+// The ports of the instance referred to by the service must be added before.
+// This function can only be called in the states 'Preparing' and 'Committed'
+func (i *Instance) AddService(spec ServiceSpec) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if !i.allows(ActionConfigure) {
+		return ErrAddingServiceNotAllowed.WithParams(i.getState().String())
+	}
+	if i.isSidecar {
+		return ErrAddingServiceToSidecar
+	}
+	if errs := validation.IsDNS1035Label(i.serviceK8sName(spec.Name)); len(errs) > 0 {
+		return ErrInvalidServiceName.WithParams(spec.Name, strings.Join(errs, ", "))
+	}
+	for _, s := range i.services {
+		if s.Name == spec.Name {
+			return ErrServiceAlreadyAdded.WithParams(spec.Name)
+		}
+	}
+	switch spec.Type {
+	case "":
+		spec.Type = v1.ServiceTypeClusterIP
+	case v1.ServiceTypeClusterIP, v1.ServiceTypeNodePort, v1.ServiceTypeLoadBalancer:
+	default:
+		return ErrInvalidServiceType.WithParams(spec.Type)
+	}
+
+	ports, err := i.resolveServicePorts(spec.Name, spec.Ports)
+	if err != nil {
+		return err
+	}
+	spec.Ports = ports
+	if spec.Selector != nil {
+		selector := make(map[string]string, len(spec.Selector))
+		for k, v := range spec.Selector {
+			selector[k] = v
+		}
+		spec.Selector = selector
+	}
+	i.services = append(i.services, spec)
+	logrus.Debugf("Added service '%s' to instance '%s'", spec.Name, i.name)
+	return nil
+}
+
+// GetServiceIP returns the cluster IP of the additional service with the given name
+// This function can only be called in the state 'Started'
+func (i *Instance) GetServiceIP(ctx context.Context, name string) (string, error) {
+	if !i.allows(ActionOperate) {
+		return "", ErrGettingServiceIPNotAllowed.WithParams(i.getState().String())
+	}
+	if !i.hasService(name) {
+		return "", ErrServiceNotFound.WithParams(name, i.k8sName)
+	}
+	return i.K8sCli.GetServiceIP(ctx, i.serviceK8sName(name))
+}
+
+// resolveServicePorts returns the ports of the service with the given ports, all the ports of the instance if empty
+func (i *Instance) resolveServicePorts(name string, ports []Port) ([]Port, error) {
+	instancePorts := i.servicePorts()
+	for _, sidecar := range i.sidecars {
+		instancePorts = append(instancePorts, sidecar.servicePorts()...)
+	}
+	if len(ports) == 0 {
+		for _, p := range instancePorts {
+			ports = append(ports, Port{Name: p.Name})
+		}
+	}
+	if len(ports) == 0 {
+		return nil, ErrServiceWithoutPorts.WithParams(name)
+	}
+
+	resolved := make([]Port, 0, len(ports))
+	for _, port := range ports {
+		p, err := resolveServicePort(port, instancePorts)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range resolved {
+			if r.Name == p.Name || (r.Protocol == p.Protocol && r.Port == p.Port) {
+				return nil, ErrServicePortDuplicated.WithParams(p.Name, name)
+			}
+		}
+		resolved = append(resolved, p)
+	}
+	return resolved, nil
+}
+
+// resolveServicePort returns the port of the instance with the name of the given port, or the given port if there is none
+func resolveServicePort(port Port, instancePorts []k8s.ServicePort) (Port, error) {
+	for _, p := range instancePorts {
+		if port.Name == "" || p.Name != port.Name {
+			continue
+		}
+		resolved := Port{Name: p.Name, Protocol: p.Protocol, Port: p.Port, TargetPort: p.TargetPort}
+		if port.Port != 0 {
+			if err := validatePort(port.Port); err != nil {
+				return Port{}, err
+			}
+			resolved.Port = port.Port
+		}
+		return resolved, nil
+	}
+	return normalizePort(port)
+}
+
+// deployServices deploys the additional services of the instance
+func (i *Instance) deployServices(ctx context.Context, tracker *resourceTracker) {
+	for _, spec := range i.services {
+		name := i.serviceK8sName(spec.Name)
+		config := k8s.ServiceConfig{
+			Name:     name,
+			Labels:   i.getLabels(),
+			Selector: spec.Selector,
+			Type:     spec.Type,
+		}
+		if config.Selector == nil {
+			config.Selector = i.getLabels()
+		}
+		for _, p := range spec.Ports {
+			config.Ports = append(config.Ports, k8s.ServicePort{Name: p.Name, Protocol: p.Protocol, Port: p.Port, TargetPort: p.TargetPort})
+		}
+		tracker.run(resourceService, name, func() (rollbackFunc, error) {
+			if _, err := i.K8sCli.DeployService(ctx, config); err != nil {
+				return nil, ErrDeployingService.WithParams(name).Wrap(err)
+			}
+			return func(ctx context.Context) error {
+				return i.K8sCli.DeleteService(ctx, name)
+			}, nil
+		})
+	}
+}
+
+// destroyServices destroys the additional services of the instance
+func (i *Instance) destroyServices(ctx context.Context) error {
+	for _, spec := range i.services {
+		name := i.serviceK8sName(spec.Name)
+		if err := i.K8sCli.DeleteService(ctx, name); err != nil {
+			return ErrDestroyingService.WithParams(name).Wrap(err)
+		}
+	}
+	return nil
+}
+
+func (i *Instance) hasService(name string) bool {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	for _, s := range i.services {
+		if s.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (i *Instance) serviceK8sName(name string) string {
+	return i.k8sName + "-" + name
+}
@@ -0,0 +1,25 @@
+package instance
+
+import (
+	"github.com/celestiaorg/knuu/pkg/system"
+)
+
+// serviceMeshAnnotations returns the annotations injecting the pod of the instance in the service mesh, nil if
+// there is no mesh
+// The application waits for the proxy of the mesh to be ready, otherwise its first connections fail.
+func (i *Instance) serviceMeshAnnotations() map[string]string {
+	switch i.ServiceMesh {
+	case system.ServiceMeshIstio:
+		return map[string]string{
+			"sidecar.istio.io/inject": "true",
+			"proxy.istio.io/config":   `{"holdApplicationUntilProxyStarts": true}`,
+		}
+	case system.ServiceMeshLinkerd:
+		return map[string]string{
+			"linkerd.io/inject":             "enabled",
+			"config.linkerd.io/proxy-await": "enabled",
+		}
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,37 @@
+package instance
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/celestiaorg/knuu/pkg/k8s/fake"
+	"github.com/celestiaorg/knuu/pkg/system"
+)
+
+func TestServiceMesh(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	k8sCli, err := fake.New(ctx, "test")
+	require.NoError(t, err)
+	plain, err := New("plain", system.SystemDependencies{K8sCli: k8sCli}, WithImage("alpine"))
+	require.NoError(t, err)
+	assert.Nil(t, plain.preparePodConfig().Annotations)
+
+	for mesh, key := range map[system.ServiceMesh]string{
+		system.ServiceMeshIstio:   "sidecar.istio.io/inject",
+		system.ServiceMeshLinkerd: "linkerd.io/inject",
+	} {
+		i, err := New("app", system.SystemDependencies{K8sCli: k8sCli, ServiceMesh: mesh}, WithImage("alpine"))
+		require.NoError(t, err)
+		assert.Contains(t, i.preparePodConfig().Annotations, key, mesh)
+
+		i.setState(Started)
+		assert.ErrorIs(t, i.DisableNetwork(ctx), ErrDisablingNetworkWithServiceMesh, mesh)
+		assert.ErrorIs(t, i.BlockExternalEgress(ctx, nil), ErrBlockingExternalEgressWithServiceMesh, mesh)
+	}
+	assert.False(t, system.ServiceMesh("consul").IsValid())
+}
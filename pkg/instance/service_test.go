@@ -0,0 +1,75 @@
+package instance
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/celestiaorg/knuu/pkg/k8s"
+	"github.com/celestiaorg/knuu/pkg/system"
+)
+
+// fakeServices records the deployed services, the other methods of the interface are not implemented
+type fakeServices struct {
+	k8s.KubeManager
+	deployed []k8s.ServiceConfig
+}
+
+func (f *fakeServices) DeployService(_ context.Context, config k8s.ServiceConfig) (*corev1.Service, error) {
+	f.deployed = append(f.deployed, config)
+	return &corev1.Service{}, nil
+}
+
+func TestAddService(t *testing.T) {
+	t.Parallel()
+
+	i, err := New("test", system.SystemDependencies{}, WithImage("alpine"), WithPorts(26656, 26657))
+	require.NoError(t, err)
+
+	// the RPC port of the instance is remapped to 80, the P2P port is not exposed
+	require.NoError(t, i.AddService(ServiceSpec{
+		Name:  "rpc",
+		Type:  corev1.ServiceTypeLoadBalancer,
+		Ports: []Port{{Name: "tcp-26657", Port: 80}, {Name: "metrics", Port: 9090}},
+	}))
+	require.Len(t, i.services, 1)
+	assert.Equal(t, []Port{
+		{Name: "tcp-26657", Protocol: corev1.ProtocolTCP, Port: 80, TargetPort: 26657},
+		{Name: "metrics", Protocol: corev1.ProtocolTCP, Port: 9090, TargetPort: 9090},
+	}, i.services[0].Ports)
+
+	// all the ports of the instance by default
+	require.NoError(t, i.AddService(ServiceSpec{Name: "internal"}))
+	assert.Len(t, i.services[1].Ports, 2)
+	assert.Equal(t, corev1.ServiceTypeClusterIP, i.services[1].Type)
+
+	assert.ErrorIs(t, i.AddService(ServiceSpec{Name: "rpc"}), ErrServiceAlreadyAdded)
+	assert.ErrorIs(t, i.AddService(ServiceSpec{Name: "Not_Valid"}), ErrInvalidServiceName)
+	assert.ErrorIs(t, i.AddService(ServiceSpec{Name: "ext", Type: corev1.ServiceTypeExternalName}), ErrInvalidServiceType)
+	assert.ErrorIs(t, i.AddService(ServiceSpec{
+		Name:  "dup",
+		Ports: []Port{{Name: "tcp-26656"}, {Name: "p2p", Port: 26656}},
+	}), ErrServicePortDuplicated)
+}
+
+func TestDeployServices(t *testing.T) {
+	t.Parallel()
+
+	services := &fakeServices{}
+	i, err := New("test", system.SystemDependencies{K8sCli: services}, WithImage("alpine"), WithPorts(26657))
+	require.NoError(t, err)
+	require.NoError(t, i.AddService(ServiceSpec{Name: "rpc", Selector: map[string]string{"role": "validator"}}))
+
+	tracker := newResourceTracker(i.k8sName)
+	i.deployServices(context.Background(), tracker)
+	require.False(t, tracker.hasFailures())
+
+	require.Len(t, services.deployed, 1)
+	config := services.deployed[0]
+	assert.Equal(t, i.k8sName+"-rpc", config.Name)
+	assert.Equal(t, map[string]string{"role": "validator"}, config.Selector)
+	assert.Equal(t, []k8s.ServicePort{{Name: "tcp-26657", Protocol: corev1.ProtocolTCP, Port: 26657, TargetPort: 26657}}, config.Ports)
+}
@@ -0,0 +1,95 @@
+package instance
+
+import (
+	"context"
+	"io"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// fileReaderName is the ephemeral sidecar that reads the files of the instances that cannot run cat
+	fileReaderName  = "knuu-file-reader"
+	fileReaderImage = "docker.io/library/busybox:1.36"
+)
+
+// defaultShell runs the commands of ExecuteCommand unless the instance has another shell
+var defaultShell = []string{"/bin/sh", "-c"}
+
+// SetShell sets the shell that runs the commands of ExecuteCommand, '/bin/sh -c' by default
+// The joined command is passed to the shell as its last argument, e.g. SetShell("powershell", "-Command") for Windows images.
+// Without arguments the instance has no shell, e.g. for distroless images: ExecuteCommand runs the command
+// with the exec API without joining its arguments, and the files are read through an ephemeral sidecar.
+// This function can only be called in the states 'Preparing', 'Committed' and 'Started'
+func (i *Instance) SetShell(shell ...string) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if !i.allows(ActionSetShell) {
+		return ErrSettingShellNotAllowed.WithParams(i.getState().String())
+	}
+	i.shell = append([]string(nil), shell...)
+	i.noShell = len(shell) == 0
+	logrus.Debugf("Set shell to '%v' in instance '%s'", shell, i.name)
+	return nil
+}
+
+// Shell returns the shell that runs the commands of ExecuteCommand, nil if the instance has no shell
+func (i *Instance) Shell() []string {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if i.noShell {
+		return nil
+	}
+	if len(i.shell) == 0 {
+		return append([]string(nil), defaultShell...)
+	}
+	return append([]string(nil), i.shell...)
+}
+
+// shellCommand returns the command that runs the given command with the shell of the instance
+func (i *Instance) shellCommand(command []string) []string {
+	shell := i.Shell()
+	if shell == nil {
+		return command
+	}
+	return append(shell, strings.Join(command, " "))
+}
+
+// isExecutableNotFound returns true if the error reports that the container runtime did not find the executable
+// The runtime reports it as 'exec: "<name>": executable file not found in $PATH' or 'exec: "<name>": stat <name>: no such file or directory'.
+func isExecutableNotFound(err error, name string) bool {
+	return err != nil && strings.Contains(err.Error(), `exec: "`+name+`"`)
+}
+
+// readFileWithoutCat reads the file through an ephemeral sidecar that shares the processes of the instance,
+// the file system of the container is available at /proc/1/root in the sidecar, unless the pod shares its process
+// namespace: PID 1 is then the pause container, so such files are not read.
+func (i *Instance) readFileWithoutCat(ctx context.Context, filePath string) (io.ReadCloser, error) {
+	if i.shareProcesses {
+		return nil, ErrReadingFileWithSharedProcesses.WithParams(i.k8sName)
+	}
+	pod, err := i.getPod(ctx)
+	if err != nil {
+		return nil, ErrGettingPodFromReplicaSet.WithParams(i.k8sName).Wrap(err)
+	}
+	if _, ok := ephemeralContainer(pod, fileReaderName); !ok {
+		err := i.AddEphemeralSidecar(ctx, EphemeralSidecar{
+			Name:           fileReaderName,
+			Image:          fileReaderImage,
+			Command:        []string{"/bin/sh", "-c", "while true; do sleep 3600; done"},
+			ShareProcesses: true,
+			Capabilities:   []string{"SYS_PTRACE"},
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	content, err := i.K8sCli.RunCommandInPod(ctx, pod.Name, fileReaderName, []string{"cat", "/proc/1/root/" + strings.TrimLeft(filePath, "/")})
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(strings.NewReader(content)), nil
+}
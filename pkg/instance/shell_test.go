@@ -0,0 +1,78 @@
+package instance
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/celestiaorg/knuu/pkg/system"
+)
+
+// fakeDistroless runs the commands of a container without shell nor cat, the files are read through the ephemeral sidecars
+type fakeDistroless struct {
+	*fakeKubelet
+	mu       sync.Mutex
+	commands map[string][][]string
+}
+
+func (f *fakeDistroless) RunCommandInPod(_ context.Context, _, containerName string, cmd []string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.commands[containerName] = append(f.commands[containerName], cmd)
+	if containerName == fileReaderName {
+		return "content of " + cmd[len(cmd)-1], nil
+	}
+	if cmd[0] == "/bin/sh" || cmd[0] == "cat" {
+		return "", errors.New(`exec: "` + cmd[0] + `": executable file not found in $PATH`)
+	}
+	return "ok", nil
+}
+
+func TestInstanceWithoutShell(t *testing.T) {
+	t.Parallel()
+
+	kubelet := &fakeDistroless{fakeKubelet: &fakeKubelet{pod: &v1.Pod{}}, commands: map[string][][]string{}}
+	i, err := New("app", system.SystemDependencies{K8sCli: kubelet}, WithImage("gcr.io/distroless/static"))
+	require.NoError(t, err)
+	i.state = Started
+	ctx := context.Background()
+
+	_, err = i.ExecuteCommand(ctx, "/app", "--version")
+	assert.ErrorIs(t, err, ErrInstanceShellNotFound)
+
+	// cat is not found, so the file is read through an ephemeral sidecar
+	rc, err := i.ReadFileFromRunningInstance(ctx, "/etc/app.toml")
+	require.NoError(t, err)
+	content, err := io.ReadAll(rc)
+	require.NoError(t, err)
+	assert.Equal(t, "content of /proc/1/root/etc/app.toml", string(content))
+	require.Len(t, kubelet.pod.Spec.EphemeralContainers, 1)
+	assert.Equal(t, i.k8sName, kubelet.pod.Spec.EphemeralContainers[0].TargetContainerName)
+
+	require.NoError(t, i.SetShell())
+	assert.Nil(t, i.Shell())
+	output, err := i.ExecuteCommand(ctx, "/app", "--version")
+	require.NoError(t, err)
+	assert.Equal(t, "ok", output)
+	assert.Equal(t, []string{"/app", "--version"}, kubelet.commands[i.k8sName][len(kubelet.commands[i.k8sName])-1])
+
+	// the reader is reused and cat is not tried anymore
+	_, err = i.GetFileBytes(ctx, "/etc/app.toml")
+	require.NoError(t, err)
+	assert.Len(t, kubelet.pod.Spec.EphemeralContainers, 1)
+	assert.Len(t, kubelet.commands[fileReaderName], 2)
+
+	_, err = i.ExecuteCommandWithOptions(ctx, ExecOptions{WorkDir: "/"}, "/app")
+	assert.ErrorIs(t, err, ErrInstanceWithoutShell)
+	_, err = i.FileExists(ctx, "/etc/app.toml")
+	assert.ErrorIs(t, err, ErrCheckingFileExists)
+
+	require.NoError(t, i.SetShell("powershell", "-Command"))
+	assert.Equal(t, []string{"powershell", "-Command", "Get-Item C:/app"}, i.shellCommand([]string{"Get-Item", "C:/app"}))
+}
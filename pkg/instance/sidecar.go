@@ -0,0 +1,141 @@
+package instance
+
+import (
+	"context"
+	"errors"
+
+	"github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/celestiaorg/knuu/pkg/retry"
+)
+
+// terminateMainProcessCommand is run in a sidecar container to terminate its main process
+// The init process of a PID namespace only receives the signals it handles, so SIGTERM is used. PID 1 is the pause
+// container when the pod shares its process namespace, so the command is not run in such pods.
+var terminateMainProcessCommand = []string{"/bin/sh", "-c", "kill -TERM 1"}
+
+// RestartSidecar restarts the container of the sidecar with the given name without redeploying the pod
+// The main process of the sidecar receives SIGTERM and the kubelet restarts the container, so
+// the process must exit on SIGTERM and the restart policy of the instance must not be Never.
+// The main process is signaled as PID 1 of the container, so the instance must not share its process namespace,
+// see EnableShareProcessNamespace.
+// It waits until the sidecar is ready again. Under the restart policy OnFailure, the container is not restarted if
+// the process exits with the code 0, which is reported as an error.
+// This function can only be called in the state 'Started'
+func (i *Instance) RestartSidecar(ctx context.Context, name string) error {
+	if !i.allows(ActionOperate) {
+		return ErrRestartingSidecarNotAllowed.WithParams(i.getState().String())
+	}
+	if i.restartPolicy == v1.RestartPolicyNever {
+		return ErrRestartingSidecarWithPolicyNever.WithParams(name, i.k8sName)
+	}
+	if i.shareProcesses {
+		return ErrSignalingSidecarWithSharedProcesses.WithParams(name, i.k8sName)
+	}
+	sidecar, err := i.findSidecar(name)
+	if err != nil {
+		return err
+	}
+
+	pod, err := i.getPod(ctx)
+	if err != nil {
+		return ErrGettingPodFromReplicaSet.WithParams(i.k8sName).Wrap(err)
+	}
+	status, ok := containerStatus(pod, sidecar.k8sName)
+	if !ok {
+		return ErrSidecarContainerNotFound.WithParams(sidecar.k8sName, pod.Name)
+	}
+	restarts := status.RestartCount
+
+	signalErr := sidecar.terminateMainProcess(ctx, pod.Name)
+	err = retry.Until(ctx, waitRunningPolicy, func(ctx context.Context) (bool, error) {
+		pod, err := i.getPod(ctx)
+		if err != nil {
+			return false, ErrGettingPodFromReplicaSet.WithParams(i.k8sName).Wrap(err)
+		}
+		status, ok := containerStatus(pod, sidecar.k8sName)
+		if ok && i.restartPolicy == v1.RestartPolicyOnFailure && status.State.Terminated != nil && status.State.Terminated.ExitCode == 0 {
+			return true, ErrSidecarExitedWithoutRestart.WithParams(sidecar.k8sName)
+		}
+		return ok && status.RestartCount > restarts && status.Ready, nil
+	})
+	if err != nil {
+		return ErrRestartingSidecar.WithParams(sidecar.k8sName).Wrap(errors.Join(signalErr, err))
+	}
+	logrus.Debugf("Restarted sidecar '%s' of instance '%s'", sidecar.k8sName, i.k8sName)
+	return nil
+}
+
+// StopSidecar stops the container of the sidecar with the given name without stopping the other containers of the pod
+// The main process of the sidecar receives SIGTERM, so it must exit on SIGTERM. The kubelet restarts the
+// containers of the pods with the restart policy Always or OnFailure, so the instance must use the policy Never.
+// The instance must not share its process namespace either, see RestartSidecar.
+// A stopped sidecar is started again with its instance.
+// It waits until the container terminated.
+// This function can only be called in the state 'Started'
+func (i *Instance) StopSidecar(ctx context.Context, name string) error {
+	if !i.allows(ActionOperate) {
+		return ErrStoppingSidecarNotAllowed.WithParams(i.getState().String())
+	}
+	if i.restartPolicy != v1.RestartPolicyNever {
+		return ErrStoppingSidecarRequiresPolicyNever.WithParams(name, i.k8sName)
+	}
+	if i.shareProcesses {
+		return ErrSignalingSidecarWithSharedProcesses.WithParams(name, i.k8sName)
+	}
+	sidecar, err := i.findSidecar(name)
+	if err != nil {
+		return err
+	}
+
+	pod, err := i.getPod(ctx)
+	if err != nil {
+		return ErrGettingPodFromReplicaSet.WithParams(i.k8sName).Wrap(err)
+	}
+
+	signalErr := sidecar.terminateMainProcess(ctx, pod.Name)
+	err = retry.Until(ctx, waitRunningPolicy, func(ctx context.Context) (bool, error) {
+		pod, err := i.getPod(ctx)
+		if err != nil {
+			return false, ErrGettingPodFromReplicaSet.WithParams(i.k8sName).Wrap(err)
+		}
+		status, ok := containerStatus(pod, sidecar.k8sName)
+		return ok && status.State.Terminated != nil, nil
+	})
+	if err != nil {
+		return ErrStoppingSidecar.WithParams(sidecar.k8sName).Wrap(errors.Join(signalErr, err))
+	}
+	logrus.Debugf("Stopped sidecar '%s' of instance '%s'", sidecar.k8sName, i.k8sName)
+	return nil
+}
+
+// findSidecar returns the sidecar of the instance with the given name or k8s name
+func (i *Instance) findSidecar(name string) (*Instance, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	for _, sidecar := range i.sidecars {
+		if sidecar.name == name || sidecar.k8sName == name {
+			return sidecar, nil
+		}
+	}
+	return nil, ErrSidecarNotFound.WithParams(name, i.k8sName)
+}
+
+// terminateMainProcess sends SIGTERM to the main process of the container of the sidecar
+// The command can fail when the container terminates before the output is streamed back,
+// so the error is only reported if the container does not terminate.
+func (i *Instance) terminateMainProcess(ctx context.Context, podName string) error {
+	_, err := i.K8sCli.RunCommandInPod(ctx, podName, i.k8sName, terminateMainProcessCommand)
+	return err
+}
+
+func containerStatus(pod *v1.Pod, name string) (v1.ContainerStatus, bool) {
+	for _, cs := range pod.Status.ContainerStatuses {
+		if cs.Name == name {
+			return cs, true
+		}
+	}
+	return v1.ContainerStatus{}, false
+}
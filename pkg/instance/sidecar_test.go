@@ -0,0 +1,115 @@
+package instance
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/celestiaorg/knuu/pkg/k8s"
+	"github.com/celestiaorg/knuu/pkg/system"
+)
+
+// fakeKubelet restarts the containers that receive a command, or terminates them with the code 0 if exitOnSignal is
+// set, the other methods of the interface are not implemented
+type fakeKubelet struct {
+	k8s.KubeManager
+	mu           sync.Mutex
+	pod          *v1.Pod
+	exitOnSignal bool
+}
+
+func (f *fakeKubelet) GetFirstPodFromReplicaSet(context.Context, string) (*v1.Pod, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.pod.DeepCopy(), nil
+}
+
+func (f *fakeKubelet) GetPod(ctx context.Context, name string) (*v1.Pod, error) {
+	return f.GetFirstPodFromReplicaSet(ctx, name)
+}
+
+func (f *fakeKubelet) RunCommandInPod(_ context.Context, _, containerName string, _ []string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for j, cs := range f.pod.Status.ContainerStatuses {
+		switch {
+		case cs.Name != containerName:
+		case f.exitOnSignal:
+			f.pod.Status.ContainerStatuses[j].Ready = false
+			f.pod.Status.ContainerStatuses[j].State = v1.ContainerState{Terminated: &v1.ContainerStateTerminated{Reason: "Completed"}}
+		default:
+			f.pod.Status.ContainerStatuses[j].RestartCount++
+		}
+	}
+	return "", nil
+}
+
+func TestRestartSidecar(t *testing.T) {
+	t.Parallel()
+
+	kubelet := &fakeKubelet{}
+	sysDeps := system.SystemDependencies{K8sCli: kubelet}
+	i, err := New("main", sysDeps, WithImage("alpine"))
+	require.NoError(t, err)
+	sidecar, err := New("shaper", sysDeps, WithImage("alpine"))
+	require.NoError(t, err)
+	sidecar.state = Committed
+	require.NoError(t, i.AddSidecar(sidecar))
+	kubelet.pod = &v1.Pod{Status: v1.PodStatus{ContainerStatuses: []v1.ContainerStatus{
+		{Name: i.k8sName, Ready: true},
+		{Name: sidecar.k8sName, Ready: true},
+	}}}
+
+	assert.ErrorIs(t, i.RestartSidecar(context.Background(), "shaper"), ErrRestartingSidecarNotAllowed)
+
+	i.state = Started
+	assert.ErrorIs(t, i.RestartSidecar(context.Background(), "unknown"), ErrSidecarNotFound)
+	require.NoError(t, i.RestartSidecar(context.Background(), "shaper"))
+
+	assert.Equal(t, int32(0), kubelet.pod.Status.ContainerStatuses[0].RestartCount)
+	assert.Equal(t, int32(1), kubelet.pod.Status.ContainerStatuses[1].RestartCount)
+
+	// the container would be restarted by the kubelet
+	assert.ErrorIs(t, i.StopSidecar(context.Background(), "shaper"), ErrStoppingSidecarRequiresPolicyNever)
+
+	// the container exiting with the code 0 is not restarted under the policy OnFailure
+	i.restartPolicy = v1.RestartPolicyOnFailure
+	kubelet.exitOnSignal = true
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	err = i.RestartSidecar(ctx, "shaper")
+	assert.ErrorIs(t, err, ErrRestartingSidecar)
+	assert.ErrorContains(t, err, "exited with the code 0")
+	assert.NoError(t, ctx.Err(), "the restart is not waited for")
+}
+
+func TestSignalSidecarWithSharedProcesses(t *testing.T) {
+	t.Parallel()
+
+	kubelet := &fakeDistroless{fakeKubelet: &fakeKubelet{pod: &v1.Pod{}}, commands: map[string][][]string{}}
+	sysDeps := system.SystemDependencies{K8sCli: kubelet}
+	i, err := New("main", sysDeps, WithImage("gcr.io/distroless/static"))
+	require.NoError(t, err)
+	sidecar, err := New("shaper", sysDeps, WithImage("alpine"))
+	require.NoError(t, err)
+	sidecar.state = Committed
+	require.NoError(t, i.AddSidecar(sidecar))
+	require.NoError(t, i.EnableShareProcessNamespace())
+	i.state = Started
+	ctx := context.Background()
+
+	// PID 1 is the pause container, neither the sidecar nor the reader of the files must signal or read it
+	assert.ErrorIs(t, i.RestartSidecar(ctx, "shaper"), ErrSignalingSidecarWithSharedProcesses)
+	_, err = i.ReadFileFromRunningInstance(ctx, "/etc/app.toml")
+	assert.ErrorIs(t, err, ErrReadingFileFromInstance)
+	assert.ErrorContains(t, err, "shares its process namespace")
+	i.restartPolicy = v1.RestartPolicyNever
+	assert.ErrorIs(t, i.StopSidecar(ctx, "shaper"), ErrSignalingSidecarWithSharedProcesses)
+	assert.Empty(t, kubelet.commands[sidecar.k8sName])
+	assert.Empty(t, kubelet.pod.Spec.EphemeralContainers)
+}
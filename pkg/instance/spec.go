@@ -0,0 +1,146 @@
+package instance
+
+import (
+	v1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+
+	"github.com/celestiaorg/knuu/pkg/k8s"
+)
+
+// InstanceSpec is a read-only snapshot of the configuration of an instance
+// Modifying it has no effect on the instance.
+type InstanceSpec struct {
+	Name      string
+	K8sName   string
+	Type      InstanceType
+	State     InstanceState
+	Image     string
+	Command   []string
+	Args      []string
+	PortsTCP  []int
+	PortsUDP  []int
+	Ports     []Port
+	Services  []ServiceSpec
+	Env       map[string]string
+	Volumes   []k8s.Volume
+	Files     []k8s.File
+	Resources ResourcesSpec
+
+	// WorkingDir is the directory the command runs in, the one of the image if empty
+	WorkingDir string
+	TTY        bool
+	Stdin      bool
+	// ShareProcesses is true if the instance and its sidecars share a process namespace
+	ShareProcesses bool
+
+	// RemoteFiles are downloaded into the volumes when the instance starts
+	RemoteFiles []k8s.RemoteFile
+
+	// HostPorts are the ports bound on the node, all the ports are bound with HostNetwork
+	HostNetwork bool
+	HostPorts   []int
+
+	LivenessProbe  *v1.Probe
+	ReadinessProbe *v1.Probe
+	StartupProbe   *v1.Probe
+
+	PolicyRules  []rbacv1.PolicyRule
+	Privileged   bool
+	Capabilities []string
+
+	// Parent is the name of the instance this instance is a sidecar of, empty if it is not a sidecar
+	Parent   string
+	Sidecars []InstanceSpec
+}
+
+// ResourcesSpec holds the resources requested by an instance, empty values are not set
+type ResourcesSpec struct {
+	MemoryRequest string
+	MemoryLimit   string
+	CPURequest    string
+	CPULimit      string
+}
+
+// Spec returns a copy of the effective configuration of the instance
+// The image is the image the instance is built from until it is committed, and the committed image afterwards.
+// Environment variables set while 'Preparing' are part of the image and are not included.
+func (i *Instance) Spec() InstanceSpec {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	return i.spec()
+}
+
+// spec returns the spec of the instance, the caller must hold the lock of the instance
+func (i *Instance) spec() InstanceSpec {
+	s := InstanceSpec{
+		Name:     i.name,
+		K8sName:  i.k8sName,
+		Type:     i.instanceType,
+		State:    i.getState(),
+		Image:    i.imageName,
+		Command:  append([]string(nil), i.command...),
+		Args:     append([]string(nil), i.args...),
+		PortsTCP: append([]int(nil), i.portsTCP...),
+		PortsUDP: append([]int(nil), i.portsUDP...),
+		Ports:    append([]Port(nil), i.ports...),
+		Env:      make(map[string]string, len(i.env)),
+		Resources: ResourcesSpec{
+			MemoryRequest: i.memoryRequest,
+			MemoryLimit:   i.memoryLimit,
+			CPURequest:    i.cpuRequest,
+			CPULimit:      i.cpuLimit,
+		},
+		LivenessProbe:  i.livenessProbe.DeepCopy(),
+		ReadinessProbe: i.readinessProbe.DeepCopy(),
+		StartupProbe:   i.startupProbe.DeepCopy(),
+		HostNetwork:    i.hostNetwork,
+		HostPorts:      append([]int(nil), i.hostPorts...),
+		WorkingDir:     i.workingDir,
+		TTY:            i.tty,
+		Stdin:          i.stdin,
+		ShareProcesses: i.shareProcesses,
+	}
+	if s.Image == "" && i.builderFactory != nil {
+		s.Image = i.builderFactory.ImageNameFrom()
+	}
+	for k, v := range i.env {
+		s.Env[k] = v
+	}
+	for _, svc := range i.services {
+		svc.Ports = append([]Port(nil), svc.Ports...)
+		if svc.Selector != nil {
+			selector := make(map[string]string, len(svc.Selector))
+			for k, v := range svc.Selector {
+				selector[k] = v
+			}
+			svc.Selector = selector
+		}
+		s.Services = append(s.Services, svc)
+	}
+	for _, v := range i.volumes {
+		s.Volumes = append(s.Volumes, *v)
+	}
+	for _, f := range i.files {
+		s.Files = append(s.Files, *f)
+	}
+	for _, f := range i.remoteFiles {
+		s.RemoteFiles = append(s.RemoteFiles, *f)
+	}
+	for _, r := range i.policyRules {
+		s.PolicyRules = append(s.PolicyRules, *r.DeepCopy())
+	}
+	if i.securityContext != nil {
+		s.Privileged = i.securityContext.privileged
+		s.Capabilities = append([]string(nil), i.securityContext.capabilitiesAdd...)
+	}
+	if i.parentInstance != nil {
+		s.Parent = i.parentInstance.name
+	}
+	for _, sidecar := range i.sidecars {
+		sidecar.mu.Lock()
+		s.Sidecars = append(s.Sidecars, sidecar.spec())
+		sidecar.mu.Unlock()
+	}
+	return s
+}
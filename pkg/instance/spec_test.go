@@ -0,0 +1,47 @@
+package instance
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/celestiaorg/knuu/pkg/k8s"
+)
+
+func TestSpec(t *testing.T) {
+	t.Parallel()
+
+	i := &Instance{
+		name:            "main",
+		k8sName:         "main-abc",
+		imageName:       "alpine:latest",
+		state:           Committed,
+		portsTCP:        []int{8080},
+		env:             map[string]string{"KEY": "value"},
+		volumes:         []*k8s.Volume{{Path: "/data", Size: "1Gi"}},
+		securityContext: &SecurityContext{capabilitiesAdd: []string{"NET_ADMIN"}},
+	}
+	sidecar := &Instance{name: "sidecar", k8sName: "sidecar-abc", state: Committed}
+	require.NoError(t, i.AddSidecar(sidecar))
+	require.NoError(t, i.SetHTTPLivenessProbe(8080, "/health"))
+
+	spec := i.Spec()
+	assert.Equal(t, "main", spec.Name)
+	assert.Equal(t, Committed, spec.State)
+	assert.Equal(t, "alpine:latest", spec.Image)
+	assert.Equal(t, []int{8080}, spec.PortsTCP)
+	assert.Equal(t, map[string]string{"KEY": "value"}, spec.Env)
+	assert.Equal(t, []k8s.Volume{{Path: "/data", Size: "1Gi"}}, spec.Volumes)
+	assert.Equal(t, []string{"NET_ADMIN"}, spec.Capabilities)
+	require.Len(t, spec.Sidecars, 1)
+	assert.Equal(t, "main", spec.Sidecars[0].Parent)
+
+	// the spec is a copy
+	spec.PortsTCP[0] = 9090
+	spec.Env["KEY"] = "changed"
+	spec.LivenessProbe.HTTPGet.Path = "/changed"
+	assert.Equal(t, []int{8080}, i.portsTCP)
+	assert.Equal(t, "value", i.env["KEY"])
+	assert.Equal(t, "/health", i.livenessProbe.HTTPGet.Path)
+}
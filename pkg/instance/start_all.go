@@ -0,0 +1,116 @@
+package instance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// StartFailurePolicy is what StartAll does with the instances it started when one of the instances fails to start
+type StartFailurePolicy int
+
+const (
+	// StartFailureStop stops the started instances, so they can be started again
+	StartFailureStop StartFailurePolicy = iota
+	// StartFailureDestroy destroys the started instances
+	StartFailureDestroy
+	// StartFailureKeep keeps the started instances running, e.g. to debug the failure
+	StartFailureKeep
+)
+
+func (p StartFailurePolicy) String() string {
+	switch p {
+	case StartFailureStop:
+		return "stop"
+	case StartFailureDestroy:
+		return "destroy"
+	case StartFailureKeep:
+		return "keep"
+	default:
+		return "unknown"
+	}
+}
+
+// StartAll starts the instances in parallel and waits for them to be ready, with all-or-nothing semantics: if one
+// of the instances fails to start, the instances that were started are stopped, see StartAllWithPolicy
+func StartAll(ctx context.Context, instances ...*Instance) error {
+	return StartAllWithPolicy(ctx, StartFailureStop, instances...)
+}
+
+// StartAllWithPolicy starts the instances in parallel and waits for them to be ready
+// If one of the instances fails to start, the instances started by the call, including the ones deployed but not
+// ready, are torn down according to the policy once all the starts are done, so a test does not go on with half of
+// its topology. The returned error joins the errors of the failed starts and of the teardown.
+// The instances must be in the state 'Committed' or 'Stopped'
+func StartAllWithPolicy(ctx context.Context, policy StartFailurePolicy, instances ...*Instance) error {
+	start := func(ctx context.Context, i *Instance) error {
+		return i.Start(ctx)
+	}
+	return startAll(ctx, instances, start, func(ctx context.Context, i *Instance) error {
+		switch policy {
+		case StartFailureStop:
+			return i.Stop(ctx)
+		case StartFailureDestroy:
+			return i.Destroy(ctx)
+		default:
+			return nil
+		}
+	})
+}
+
+// startAll starts the instances concurrently with start and tears down the started ones with teardown if one of
+// them fails to start
+func startAll(ctx context.Context, instances []*Instance, start, teardown func(context.Context, *Instance) error) error {
+	// the instances that are already started are not started by the call, so they are never torn down
+	wasStarted := make([]bool, len(instances))
+	for j, i := range instances {
+		wasStarted[j] = i.IsInState(Started)
+	}
+
+	startErrs := make([]error, len(instances))
+	var wg sync.WaitGroup
+	for j, i := range instances {
+		wg.Add(1)
+		go func(j int, i *Instance) {
+			defer wg.Done()
+			if err := start(ctx, i); err != nil {
+				startErrs[j] = fmt.Errorf("instance '%s': %w", i.name, err)
+			}
+		}(j, i)
+	}
+	wg.Wait()
+
+	failed := 0
+	for _, err := range startErrs {
+		if err != nil {
+			failed++
+		}
+	}
+	if failed == 0 {
+		return nil
+	}
+	logrus.Debugf("%d of %d instances failed to start, tearing down the started instances", failed, len(instances))
+
+	// the instances are torn down even if the context is the cause of the failure
+	teardownCtx := context.WithoutCancel(ctx)
+	teardownErrs := make([]error, len(instances))
+	for j, i := range instances {
+		// an instance that failed to start after its pod was deployed is in the state 'Started' too
+		if wasStarted[j] || !i.IsInState(Started) {
+			continue
+		}
+		wg.Add(1)
+		go func(j int, i *Instance) {
+			defer wg.Done()
+			if err := teardown(teardownCtx, i); err != nil {
+				teardownErrs[j] = fmt.Errorf("tearing down instance '%s': %w", i.name, err)
+			}
+		}(j, i)
+	}
+	wg.Wait()
+
+	return ErrStartingInstances.WithParams(failed, len(instances)).Wrap(errors.Join(append(startErrs, teardownErrs...)...))
+}
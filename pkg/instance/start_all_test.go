@@ -0,0 +1,100 @@
+package instance
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStartAll(t *testing.T) {
+	t.Parallel()
+
+	newInstances := func() (ok, failing, notReady, running *Instance) {
+		return &Instance{name: "ok", state: Committed},
+			&Instance{name: "failing", state: Committed},
+			&Instance{name: "not-ready", state: Stopped},
+			&Instance{name: "running", state: Started}
+	}
+	start := func(_ context.Context, i *Instance) error {
+		switch i.name {
+		case "failing":
+			return errors.New("image not found")
+		case "not-ready":
+			i.setState(Started)
+			return errors.New("timeout waiting for the instance to be running")
+		case "running":
+			return ErrStartingNotAllowed.WithParams(Started.String())
+		}
+		i.setState(Started)
+		return nil
+	}
+
+	t.Run("all started", func(t *testing.T) {
+		ok, _, _, _ := newInstances()
+		other := &Instance{name: "other", state: Committed}
+		err := startAll(context.Background(), []*Instance{ok, other}, start, func(context.Context, *Instance) error {
+			t.Fatal("nothing is torn down when all the instances start")
+			return nil
+		})
+		require.NoError(t, err)
+		assert.True(t, ok.IsInState(Started))
+		assert.True(t, other.IsInState(Started))
+	})
+
+	t.Run("failure tears down the started instances", func(t *testing.T) {
+		ok, failing, notReady, running := newInstances()
+		var (
+			mu       sync.Mutex
+			tornDown []string
+		)
+		teardown := func(_ context.Context, i *Instance) error {
+			mu.Lock()
+			defer mu.Unlock()
+			tornDown = append(tornDown, i.name)
+			if i.name == "not-ready" {
+				return errors.New("pod stuck in terminating")
+			}
+			i.setState(Stopped)
+			return nil
+		}
+
+		err := startAll(context.Background(), []*Instance{ok, failing, notReady, running}, start, teardown)
+		require.Error(t, err)
+		assert.True(t, errors.Is(err, ErrStartingInstances))
+		assert.Contains(t, err.Error(), "3 of 4 instances failed to start")
+		assert.Contains(t, err.Error(), "image not found")
+		assert.Contains(t, err.Error(), "pod stuck in terminating")
+
+		assert.ElementsMatch(t, []string{"ok", "not-ready"}, tornDown, "only the instances started by the call are torn down")
+		assert.True(t, ok.IsInState(Stopped))
+		assert.True(t, running.IsInState(Started))
+	})
+
+	t.Run("teardown ignores the cancellation of the context", func(t *testing.T) {
+		ok, failing, _, _ := newInstances()
+		ctx, cancel := context.WithCancel(context.Background())
+		err := startAll(ctx, []*Instance{ok, failing}, func(ctx context.Context, i *Instance) error {
+			err := start(ctx, i)
+			cancel()
+			return err
+		}, func(ctx context.Context, i *Instance) error {
+			return ctx.Err()
+		})
+		require.Error(t, err)
+		assert.NotContains(t, err.Error(), context.Canceled.Error())
+	})
+}
+
+func TestStartAllWithPolicyKeep(t *testing.T) {
+	t.Parallel()
+
+	failing := &Instance{name: "failing", state: Preparing}
+	err := StartAllWithPolicy(context.Background(), StartFailureKeep, failing)
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ErrStartingInstances))
+	assert.Equal(t, "keep", StartFailureKeep.String())
+}
@@ -1,5 +1,11 @@
 package instance
 
+import (
+	"slices"
+
+	"github.com/sirupsen/logrus"
+)
+
 // InstanceState represents the state of the instance
 type InstanceState int
 
@@ -11,22 +17,214 @@ const (
 	Started
 	Stopped
 	Destroyed
+	// Attached is the state of the handles on the workloads not created by knuu, see AttachDeployment
+	Attached
 )
 
 // String returns the string representation of the state
 func (s InstanceState) String() string {
-	if s < 0 || s > 5 {
+	if s < 0 || s > 6 {
 		return "Unknown"
 	}
-	return [...]string{"None", "Preparing", "Committed", "Started", "Stopped", "Destroyed"}[s]
+	return [...]string{"None", "Preparing", "Committed", "Started", "Stopped", "Destroyed", "Attached"}[s]
 }
 
 // IsInState checks if the instance is in one of the provided states
 func (i *Instance) IsInState(states ...InstanceState) bool {
+	current := i.getState()
 	for _, s := range states {
-		if i.state == s {
+		if current == s {
+			return true
+		}
+	}
+	return false
+}
+
+// State returns the current state of the instance
+func (i *Instance) State() InstanceState {
+	return i.getState()
+}
+
+// getState returns the current state of the instance
+func (i *Instance) getState() InstanceState {
+	i.stateMu.RLock()
+	defer i.stateMu.RUnlock()
+	return i.state
+}
+
+// setState sets the state of the instance without checking the transition nor calling the state change handlers,
+// see transition
+func (i *Instance) setState(state InstanceState) {
+	i.stateMu.Lock()
+	defer i.stateMu.Unlock()
+	i.state = state
+}
+
+// transitions is the state machine of the instances: the states an instance can move to from each state
+//
+//	None      -> Preparing            SetImage, SetGitRepo
+//	None      -> Committed            SetPrebuiltImage, the image is used as is
+//	Preparing -> Committed            Commit
+//	Committed -> Started              Start
+//	Committed -> Destroyed            NewPool, the instance is replaced by the instances of the pool
+//	Started   -> Stopped              Stop
+//	Started   -> Destroyed            Destroy
+//	Stopped   -> Started              Start
+//	Stopped   -> Destroyed            Destroy
+//
+// Destroyed is final, as is Attached: the workloads not created by knuu are never moved by it.
+var transitions = map[InstanceState][]InstanceState{
+	None:      {Preparing, Committed},
+	Preparing: {Committed},
+	Committed: {Started, Destroyed},
+	Started:   {Stopped, Destroyed},
+	Stopped:   {Started, Destroyed},
+}
+
+// CanTransition returns true if an instance can move from one state to the other, see transitions
+func CanTransition(from, to InstanceState) bool {
+	for _, s := range transitions[from] {
+		if s == to {
 			return true
 		}
 	}
 	return false
 }
+
+// InstanceAction is an operation on an instance that is only allowed in some states
+type InstanceAction string
+
+// Actions on the instances, the states they are allowed in are listed by actionStates
+const (
+	ActionSetImage         InstanceAction = "SetImage"
+	ActionSetGitRepo       InstanceAction = "SetGitRepo"
+	ActionSetPrebuiltImage InstanceAction = "SetPrebuiltImage"
+	// ActionConfigure covers the setters of the configuration of the instance, e.g. SetCommand or AddPort
+	ActionConfigure InstanceAction = "Configure"
+	// ActionBuild covers the setters applied to the image being built, e.g. SetUser
+	ActionBuild InstanceAction = "Build"
+	// ActionSetShell covers SetShell, which also applies to the commands of a started instance
+	ActionSetShell InstanceAction = "SetShell"
+	// ActionReadFiles covers GetFileBytes and ImageConfig, read from the image or from the running container
+	ActionReadFiles InstanceAction = "ReadFiles"
+	// ActionBlockEgress covers BlockExternalEgress and UnblockExternalEgress
+	ActionBlockEgress InstanceAction = "BlockEgress"
+	ActionCommit      InstanceAction = "Commit"
+	ActionClone       InstanceAction = "Clone"
+	ActionNewPool     InstanceAction = "NewPool"
+	ActionExecute     InstanceAction = "ExecuteCommand"
+	ActionLogs        InstanceAction = "Logs"
+	ActionPortForward InstanceAction = "PortForward"
+	ActionStart       InstanceAction = "Start"
+	// ActionShapeNetwork covers SetBandwidthLimit, SetLatencyAndJitter, SetPacketLoss, DisableNetwork and
+	// EnableNetwork
+	ActionShapeNetwork InstanceAction = "ShapeNetwork"
+	// ActionOperate covers the operations on the running pod of an instance deployed by knuu, e.g. Health,
+	// ScrapeMetrics, AddEphemeralSidecar, RestartSidecar or SetImageInstant
+	ActionOperate InstanceAction = "Operate"
+	// ActionCheckRunning covers IsRunning
+	ActionCheckRunning InstanceAction = "CheckRunning"
+	ActionStop         InstanceAction = "Stop"
+	// ActionWaitStopped covers WaitInstanceIsStopped
+	ActionWaitStopped InstanceAction = "WaitStopped"
+	ActionDestroy     InstanceAction = "Destroy"
+)
+
+// actionStates lists the states each action is allowed in, in the order of the lifecycle of an instance
+// The operations check it with allows before doing anything. IsInState remains for the branches on the current
+// state, e.g. SetImage building the image in the state 'None', and for picking the started instances of a group.
+var actionStates = []struct {
+	action InstanceAction
+	states []InstanceState
+}{
+	{ActionSetImage, []InstanceState{None, Started}},
+	{ActionSetGitRepo, []InstanceState{None}},
+	{ActionSetPrebuiltImage, []InstanceState{None}},
+	{ActionBuild, []InstanceState{Preparing}},
+	{ActionConfigure, []InstanceState{Preparing, Committed}},
+	{ActionSetShell, []InstanceState{Preparing, Committed, Started}},
+	{ActionReadFiles, []InstanceState{Preparing, Committed, Started}},
+	{ActionBlockEgress, []InstanceState{Committed, Started}},
+	{ActionCommit, []InstanceState{Preparing}},
+	{ActionClone, []InstanceState{Committed}},
+	{ActionNewPool, []InstanceState{Committed}},
+	{ActionExecute, []InstanceState{Preparing, Started, Attached}},
+	{ActionLogs, []InstanceState{Started, Attached}},
+	{ActionPortForward, []InstanceState{Started, Attached}},
+	{ActionStart, []InstanceState{Committed, Stopped}},
+	{ActionOperate, []InstanceState{Started}},
+	{ActionShapeNetwork, []InstanceState{Started, Attached}},
+	{ActionCheckRunning, []InstanceState{Started, Stopped}},
+	{ActionStop, []InstanceState{Started}},
+	{ActionWaitStopped, []InstanceState{Stopped}},
+	{ActionDestroy, []InstanceState{Started, Stopped, Destroyed}},
+}
+
+// AllowedActions returns the actions allowed in the current state of the instance
+func (i *Instance) AllowedActions() []InstanceAction {
+	current := i.getState()
+	var actions []InstanceAction
+	for _, a := range actionStates {
+		if slices.Contains(a.states, current) {
+			actions = append(actions, a.action)
+		}
+	}
+	return actions
+}
+
+// allows returns true if the action is allowed in the current state of the instance
+func (i *Instance) allows(action InstanceAction) bool {
+	for _, a := range actionStates {
+		if a.action == action {
+			return i.IsInState(a.states...)
+		}
+	}
+	return false
+}
+
+// StateChange describes a transition of an instance from a state to another
+type StateChange struct {
+	Instance *Instance
+	From     InstanceState
+	To       InstanceState
+}
+
+// StateChangeHandler is called with the transitions of an instance, see OnStateChange
+type StateChangeHandler func(StateChange)
+
+// OnStateChange adds a handler called at each transition of the instance
+// The handlers are called while the instance is locked, they must not call its methods, except State, and
+// should not block.
+// The handlers are inherited by the clones of the instance.
+func (i *Instance) OnStateChange(fn StateChangeHandler) {
+	i.stateMu.Lock()
+	defer i.stateMu.Unlock()
+	i.stateHandlers = append(i.stateHandlers, fn)
+}
+
+// cloneStateHandlers copies the state change handlers of the instance for its clone
+func (i *Instance) cloneStateHandlers() []StateChangeHandler {
+	i.stateMu.RLock()
+	defer i.stateMu.RUnlock()
+	return append([]StateChangeHandler(nil), i.stateHandlers...)
+}
+
+// transition moves the instance to the given state if the state machine allows it, see transitions, and calls
+// the state change handlers
+func (i *Instance) transition(to InstanceState) error {
+	i.stateMu.Lock()
+	from := i.state
+	if !CanTransition(from, to) {
+		i.stateMu.Unlock()
+		return ErrInvalidStateTransition.WithParams(i.k8sName, from.String(), to.String())
+	}
+	i.state = to
+	handlers := append([]StateChangeHandler(nil), i.stateHandlers...)
+	i.stateMu.Unlock()
+
+	logrus.Debugf("Set state of instance '%s' to '%s'", i.k8sName, to.String())
+	for _, fn := range handlers {
+		fn(StateChange{Instance: i, From: from, To: to})
+	}
+	return nil
+}
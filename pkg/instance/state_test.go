@@ -0,0 +1,96 @@
+package instance
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/celestiaorg/knuu/pkg/k8s/fake"
+	"github.com/celestiaorg/knuu/pkg/system"
+)
+
+func TestCanTransition(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, CanTransition(None, Preparing))
+	assert.True(t, CanTransition(Committed, Destroyed))
+	assert.True(t, CanTransition(Stopped, Started))
+	assert.True(t, CanTransition(None, Committed))
+	assert.False(t, CanTransition(None, Started))
+	assert.False(t, CanTransition(Committed, Stopped))
+	assert.False(t, CanTransition(Destroyed, Started))
+	assert.False(t, CanTransition(Started, Started))
+}
+
+func TestAllowedActions(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	k8sCli, err := fake.New(ctx, "test")
+	require.NoError(t, err)
+	i, err := New("node", system.SystemDependencies{K8sCli: k8sCli})
+	require.NoError(t, err)
+	assert.Equal(t, []InstanceAction{ActionSetImage, ActionSetGitRepo, ActionSetPrebuiltImage}, i.AllowedActions())
+
+	i.setState(Committed)
+	assert.Equal(t, []InstanceAction{
+		ActionConfigure, ActionSetShell, ActionReadFiles, ActionBlockEgress, ActionClone, ActionNewPool, ActionStart,
+	}, i.AllowedActions())
+
+	// the network can only be shaped while the instance is running
+	i.setState(Started)
+	assert.Contains(t, i.AllowedActions(), ActionShapeNetwork)
+	assert.ErrorIs(t, i.SetBandwidthLimit(1000), ErrSettingBandwidthLimitNotAllowedBitTwister)
+	i.setState(Committed)
+	assert.ErrorIs(t, i.SetBandwidthLimit(1000), ErrSettingBandwidthLimitNotAllowed)
+
+	// the operations on the running pod are not allowed once stopped
+	i.setState(Stopped)
+	assert.Equal(t, []InstanceAction{ActionStart, ActionCheckRunning, ActionWaitStopped, ActionDestroy}, i.AllowedActions())
+	_, err = i.Health(ctx)
+	assert.ErrorIs(t, err, ErrCheckingHealthNotAllowed)
+
+	i.setState(Destroyed)
+	assert.Equal(t, []InstanceAction{ActionDestroy}, i.AllowedActions())
+}
+
+func TestTransition(t *testing.T) {
+	t.Parallel()
+
+	i := &Instance{name: "node", k8sName: "node", state: Committed}
+	var changes []StateChange
+	i.OnStateChange(func(c StateChange) {
+		changes = append(changes, c)
+	})
+
+	require.NoError(t, i.transition(Started))
+	require.NoError(t, i.transition(Stopped))
+	assert.ErrorIs(t, i.transition(Committed), ErrInvalidStateTransition)
+	assert.True(t, i.IsInState(Stopped))
+	assert.Equal(t, []StateChange{
+		{Instance: i, From: Committed, To: Started},
+		{Instance: i, From: Started, To: Stopped},
+	}, changes)
+
+	// the clones inherit the handlers
+	clone := &Instance{name: "clone", k8sName: "clone", state: Stopped, stateHandlers: i.cloneStateHandlers()}
+	require.NoError(t, clone.transition(Destroyed))
+	require.Len(t, changes, 3)
+	assert.Equal(t, clone, changes[2].Instance)
+}
+
+func TestSetStateForSidecars(t *testing.T) {
+	t.Parallel()
+
+	following := &Instance{name: "following", k8sName: "following", state: Committed}
+	started := &Instance{name: "started", k8sName: "started", state: Started}
+	destroyed := &Instance{name: "destroyed", k8sName: "destroyed", state: Destroyed}
+
+	setStateForSidecars([]*Instance{following, started, destroyed}, Started)
+	assert.True(t, following.IsInState(Started))
+	assert.True(t, started.IsInState(Started))
+	// a sidecar that cannot move to the state of its parent is left as is
+	assert.True(t, destroyed.IsInState(Destroyed))
+}
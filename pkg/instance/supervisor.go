@@ -0,0 +1,208 @@
+package instance
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/celestiaorg/knuu/pkg/clock"
+)
+
+const (
+	defaultSupervisorInterval  = 10 * time.Second
+	defaultSupervisorThreshold = 3
+)
+
+// RestartPolicy defines what the supervisor does when an instance stays unhealthy
+type RestartPolicy int
+
+const (
+	// RestartNever only reports the unhealthy instances
+	RestartNever RestartPolicy = iota
+	// RestartOnFailure restarts the unhealthy instances, recreating their pod
+	RestartOnFailure
+)
+
+// TerminationEvent describes an unexpected termination of a supervised instance
+type TerminationEvent struct {
+	Instance *Instance
+	Time     time.Time
+	// Reason is the reason reported by kubernetes, e.g. OOMKilled or CrashLoopBackOff
+	Reason string
+	// Restarts is the number of times kubernetes restarted the container of the instance
+	Restarts int32
+	// Restarted is true if the supervisor restarted the instance
+	Restarted bool
+	// Err is the error the restart failed with, or ErrMaxRestartsReached
+	Err error
+}
+
+// Supervisor monitors started instances for long-running tests
+// An instance terminated unexpectedly if kubernetes restarted its container, or if it stayed
+// unhealthy for a number of consecutive checks, in which case it can be restarted depending on the policy.
+type Supervisor struct {
+	instances     func() []*Instance
+	interval      time.Duration
+	threshold     int
+	policy        RestartPolicy
+	maxRestarts   int
+	onTermination []func(TerminationEvent)
+
+	// states is only accessed by the goroutine of Run
+	states map[*Instance]*supervisedState
+}
+
+type supervisedState struct {
+	restarts  int32
+	failures  int
+	reported  bool
+	restarted int
+}
+
+// SupervisorOption configures a Supervisor
+type SupervisorOption func(*Supervisor)
+
+// WithCheckInterval sets the interval between two checks of the instances, 10s by default
+func WithCheckInterval(interval time.Duration) SupervisorOption {
+	return func(s *Supervisor) {
+		s.interval = interval
+	}
+}
+
+// WithFailureThreshold sets the number of consecutive failed checks after which an instance is considered terminated, 3 by default
+func WithFailureThreshold(threshold int) SupervisorOption {
+	return func(s *Supervisor) {
+		s.threshold = threshold
+	}
+}
+
+// WithRestartPolicy sets the policy applied to the terminated instances
+// maxRestarts bounds the number of restarts per instance, 0 means unlimited.
+func WithRestartPolicy(policy RestartPolicy, maxRestarts int) SupervisorOption {
+	return func(s *Supervisor) {
+		s.policy = policy
+		s.maxRestarts = maxRestarts
+	}
+}
+
+// OnTermination adds a callback called for each unexpected termination
+// The callbacks are called by the goroutine of Run, they should not block.
+func OnTermination(fn func(TerminationEvent)) SupervisorOption {
+	return func(s *Supervisor) {
+		s.onTermination = append(s.onTermination, fn)
+	}
+}
+
+// NewSupervisor returns a supervisor of the instances returned by the given function
+// The function is called at each check, so instances created later are supervised too.
+// Only the instances in the state 'Started' are checked, sidecars are checked with their parent.
+func NewSupervisor(instances func() []*Instance, opts ...SupervisorOption) *Supervisor {
+	s := &Supervisor{
+		instances: instances,
+		interval:  defaultSupervisorInterval,
+		threshold: defaultSupervisorThreshold,
+		states:    make(map[*Instance]*supervisedState),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Supervise returns a function that returns the given instances, to supervise a fixed set of instances
+func Supervise(instances ...*Instance) func() []*Instance {
+	return func() []*Instance {
+		return instances
+	}
+}
+
+// Run checks the instances at each interval of the clock of the context until the context is done
+// It must not be called concurrently.
+func (s *Supervisor) Run(ctx context.Context) {
+	ticker := clock.FromContext(ctx).NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C():
+			s.check(ctx)
+		}
+	}
+}
+
+func (s *Supervisor) check(ctx context.Context) {
+	for _, i := range s.instances() {
+		if i.isSidecar || !i.IsInState(Started) {
+			continue
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		s.checkInstance(ctx, i)
+	}
+}
+
+func (s *Supervisor) checkInstance(ctx context.Context, i *Instance) {
+	health, err := i.Health(ctx)
+	if err != nil {
+		logrus.Debugf("Error checking the health of instance '%s': %v", i.k8sName, err)
+		health = &Health{Reason: err.Error()}
+	}
+
+	st, ok := s.states[i]
+	if !ok {
+		st = &supervisedState{restarts: health.Restarts}
+		s.states[i] = st
+	}
+
+	if health.Restarts > st.restarts {
+		st.restarts = health.Restarts
+		s.notify(TerminationEvent{Instance: i, Time: clock.FromContext(ctx).Now(), Reason: health.Reason, Restarts: health.Restarts})
+	}
+
+	if health.Ready {
+		st.failures = 0
+		st.reported = false
+		return
+	}
+	st.failures++
+	if st.failures < s.threshold || st.reported {
+		return
+	}
+
+	event := TerminationEvent{Instance: i, Time: clock.FromContext(ctx).Now(), Reason: health.Reason, Restarts: health.Restarts}
+	st.reported = true
+	if s.policy == RestartOnFailure {
+		if s.maxRestarts > 0 && st.restarted >= s.maxRestarts {
+			event.Err = ErrMaxRestartsReached.WithParams(i.k8sName, st.restarted)
+		} else {
+			st.restarted++
+			event.Err = restart(ctx, i)
+			event.Restarted = event.Err == nil
+			// the restarted instance gets a new pod, so its restart count starts over
+			st.restarts = 0
+			st.failures = 0
+			st.reported = false
+		}
+	}
+	s.notify(event)
+}
+
+func (s *Supervisor) notify(event TerminationEvent) {
+	logrus.Warnf("Instance '%s' terminated unexpectedly: %s (restarted: %t)", event.Instance.k8sName, event.Reason, event.Restarted)
+	for _, fn := range s.onTermination {
+		fn(event)
+	}
+}
+
+func restart(ctx context.Context, i *Instance) error {
+	if err := i.Stop(ctx); err != nil {
+		return ErrRestartingInstance.WithParams(i.k8sName).Wrap(err)
+	}
+	if err := i.Start(ctx); err != nil {
+		return ErrRestartingInstance.WithParams(i.k8sName).Wrap(err)
+	}
+	return nil
+}
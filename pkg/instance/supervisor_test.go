@@ -0,0 +1,129 @@
+package instance
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/celestiaorg/knuu/pkg/clock"
+	"github.com/celestiaorg/knuu/pkg/k8s"
+	"github.com/celestiaorg/knuu/pkg/system"
+)
+
+// fakePods serves the pods of the instances, the other methods of the interface are not implemented
+type fakePods struct {
+	k8s.KubeManager
+	mu       sync.Mutex
+	statuses map[string]corev1.ContainerStatus
+	pods     map[string]*corev1.Pod
+}
+
+func (f *fakePods) GetPod(_ context.Context, name string) (*corev1.Pod, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.pods[name], nil
+}
+
+func (f *fakePods) setPod(name string, phase corev1.PodPhase, ready bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.pods == nil {
+		f.pods = make(map[string]*corev1.Pod)
+	}
+	f.pods[name] = &corev1.Pod{Status: corev1.PodStatus{
+		Phase:             phase,
+		ContainerStatuses: []corev1.ContainerStatus{{Name: name, Ready: ready}},
+	}}
+}
+
+func (f *fakePods) GetFirstPodFromReplicaSet(_ context.Context, name string) (*corev1.Pod, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return &corev1.Pod{Status: corev1.PodStatus{
+		ContainerStatuses: []corev1.ContainerStatus{f.statuses[name]},
+	}}, nil
+}
+
+func (f *fakePods) set(name string, ready bool, restarts int32, reason string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	cs := corev1.ContainerStatus{Name: name, Ready: ready, RestartCount: restarts}
+	if reason != "" {
+		cs.State.Waiting = &corev1.ContainerStateWaiting{Reason: reason}
+	}
+	f.statuses[name] = cs
+}
+
+func TestHealth(t *testing.T) {
+	t.Parallel()
+
+	pods := &fakePods{statuses: map[string]corev1.ContainerStatus{}}
+	i := &Instance{k8sName: "test", state: Started, SystemDependencies: system.SystemDependencies{K8sCli: pods}}
+
+	pods.set("test", false, 2, "CrashLoopBackOff")
+	h, err := i.Health(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, &Health{Ready: false, Restarts: 2, Reason: "CrashLoopBackOff"}, h)
+
+	i.state = Stopped
+	_, err = i.Health(context.Background())
+	assert.ErrorIs(t, err, ErrCheckingHealthNotAllowed)
+}
+
+func TestSupervisorDetectsTerminations(t *testing.T) {
+	t.Parallel()
+
+	pods := &fakePods{statuses: map[string]corev1.ContainerStatus{}}
+	sysDeps := system.SystemDependencies{K8sCli: pods}
+	started := &Instance{k8sName: "started", state: Started, SystemDependencies: sysDeps}
+	committed := &Instance{k8sName: "committed", state: Committed, SystemDependencies: sysDeps}
+
+	var events []TerminationEvent
+	s := NewSupervisor(Supervise(started, committed),
+		WithFailureThreshold(2),
+		OnTermination(func(e TerminationEvent) { events = append(events, e) }),
+	)
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	ctx := clock.WithClock(context.Background(), clock.NewFake(now))
+
+	pods.set("started", true, 0, "")
+	s.check(ctx)
+	assert.Empty(t, events)
+
+	// kubernetes restarted the container
+	pods.set("started", true, 1, "")
+	s.check(ctx)
+	require.Len(t, events, 1)
+	assert.Equal(t, int32(1), events[0].Restarts)
+	assert.False(t, events[0].Restarted)
+
+	// the instance is reported once when it stays unhealthy for the threshold
+	pods.set("started", false, 1, "CrashLoopBackOff")
+	s.check(ctx)
+	assert.Len(t, events, 1)
+	s.check(ctx)
+	require.Len(t, events, 2)
+	assert.Equal(t, "CrashLoopBackOff", events[1].Reason)
+	assert.Same(t, started, events[1].Instance)
+	s.check(ctx)
+	assert.Len(t, events, 2)
+
+	// it is reported again if it recovers and fails again
+	pods.set("started", true, 1, "")
+	s.check(ctx)
+	pods.set("started", false, 1, "Error")
+	s.check(ctx)
+	s.check(ctx)
+	require.Len(t, events, 3)
+	assert.Equal(t, "Error", events[2].Reason)
+
+	for _, e := range events {
+		assert.NotSame(t, committed, e.Instance)
+		assert.Equal(t, now, e.Time, "the events are timed with the clock of the context")
+	}
+}
@@ -0,0 +1,249 @@
+package instance
+
+import (
+	"archive/tar"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/celestiaorg/knuu/pkg/clock"
+)
+
+const defaultSyncInterval = time.Second
+
+// SyncResult reports a sync of a folder to the instance
+type SyncResult struct {
+	// Copied are the files copied to the instance, relative to the synced folder
+	Copied []string
+	// Deleted are the files deleted from the instance as they were deleted from the local folder
+	Deleted []string
+	Err     error
+}
+
+// SyncOption configures a sync
+type SyncOption func(*syncOptions)
+
+type syncOptions struct {
+	interval time.Duration
+	onSync   []func(SyncResult)
+}
+
+// WithSyncInterval sets the interval between two scans of the local folder, 1s by default
+func WithSyncInterval(interval time.Duration) SyncOption {
+	return func(o *syncOptions) {
+		o.interval = interval
+	}
+}
+
+// OnSync calls the given function after each sync copying or deleting files, failed or not
+// The function is called by the goroutine of the sync after the first one, it should not block.
+func OnSync(fn func(SyncResult)) SyncOption {
+	return func(o *syncOptions) {
+		o.onSync = append(o.onSync, fn)
+	}
+}
+
+// syncedFile is the state of a local file when it was last copied to the instance
+type syncedFile struct {
+	modTime time.Time
+	size    int64
+	mode    fs.FileMode
+}
+
+// folderSync is the state of a sync, only accessed by the goroutine of the sync
+type folderSync struct {
+	localPath  string
+	remotePath string
+	// pod is the pod the files were copied to, all the files are copied again to a new pod
+	pod   string
+	files map[string]syncedFile
+}
+
+// SyncFolder copies the folder at localPath to remotePath of the running instance, then scans it at each interval
+// and copies the changed files and deletes the deleted ones, so that the configs and binaries of the instance are
+// updated without rebuilding its image, e.g. in a development loop
+// The files are streamed as a tar archive, so the container must have sh and tar. Only the regular files are
+// synced, the symlinks and the other special files are skipped, and the processes of the instance are not
+// restarted. The first sync is done before returning and its error is returned, the next ones are done in
+// background until the context is done: their errors are logged and their files copied again by the next scan.
+// All the files are copied again when the pod of the instance is recreated.
+// This function can only be called in the state 'Started'
+func (i *Instance) SyncFolder(ctx context.Context, localPath, remotePath string, opts ...SyncOption) error {
+	if !i.allows(ActionOperate) {
+		return ErrSyncingFolderNotAllowed.WithParams(i.getState().String())
+	}
+	o := &syncOptions{interval: defaultSyncInterval}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	s := &folderSync{localPath: localPath, remotePath: remotePath, files: map[string]syncedFile{}}
+	if _, err := i.syncFolder(ctx, s); err != nil {
+		return ErrSyncingFolder.WithParams(localPath, i.k8sName).Wrap(err)
+	}
+	logrus.Debugf("Synced folder '%s' to '%s' of instance '%s'", localPath, remotePath, i.k8sName)
+
+	go func() {
+		ticker := clock.FromContext(ctx).NewTicker(o.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C():
+			}
+			result, err := i.syncFolder(ctx, s)
+			if err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				result.Err = ErrSyncingFolder.WithParams(localPath, i.k8sName).Wrap(err)
+				logrus.Warn(result.Err)
+			} else if len(result.Copied) == 0 && len(result.Deleted) == 0 {
+				continue
+			}
+			for _, fn := range o.onSync {
+				fn(result)
+			}
+		}
+	}()
+	return nil
+}
+
+// syncFolder copies the files changed since the last sync and deletes the deleted ones
+// The state of the sync is only updated if the sync succeeded, so that a failed sync is retried.
+func (i *Instance) syncFolder(ctx context.Context, s *folderSync) (SyncResult, error) {
+	current, err := scanFolder(s.localPath)
+	if err != nil {
+		return SyncResult{}, err
+	}
+	pod, err := i.getPod(ctx)
+	if err != nil {
+		return SyncResult{}, ErrGettingPodFromReplicaSet.WithParams(i.k8sName).Wrap(err)
+	}
+	previous := s.files
+	if pod.Name != s.pod {
+		previous = map[string]syncedFile{}
+	}
+
+	result := SyncResult{}
+	for name, f := range current {
+		if p, ok := previous[name]; !ok || p != f {
+			result.Copied = append(result.Copied, name)
+		}
+	}
+	for name := range previous {
+		if _, ok := current[name]; !ok {
+			result.Deleted = append(result.Deleted, name)
+		}
+	}
+	sort.Strings(result.Copied)
+	sort.Strings(result.Deleted)
+
+	if len(result.Copied) != 0 {
+		if err := i.copyFiles(ctx, pod.Name, s.localPath, s.remotePath, result.Copied); err != nil {
+			return result, err
+		}
+	}
+	if len(result.Deleted) != 0 {
+		cmd := []string{"rm", "-f", "--"}
+		for _, name := range result.Deleted {
+			cmd = append(cmd, path.Join(s.remotePath, name))
+		}
+		if _, err := i.K8sCli.RunCommandInPod(ctx, pod.Name, i.k8sName, cmd); err != nil {
+			return result, err
+		}
+	}
+	s.pod = pod.Name
+	s.files = current
+	return result, nil
+}
+
+// copyFiles streams the given files of the local folder to the remote folder as a tar archive
+func (i *Instance) copyFiles(ctx context.Context, pod, localPath, remotePath string, files []string) error {
+	return i.uploadTar(ctx, pod, extractScript(remotePath), func(tw *tar.Writer) error {
+		for _, name := range files {
+			if err := writeTarFile(tw, filepath.Join(localPath, filepath.FromSlash(name)), name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// uploadTar streams the tar archive written by write to the input of the given command run in the container
+func (i *Instance) uploadTar(ctx context.Context, pod, script string, write func(tw *tar.Writer) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	pr, pw := io.Pipe()
+	go func() {
+		tw := tar.NewWriter(pw)
+		err := write(tw)
+		if err == nil {
+			err = tw.Close()
+		}
+		pw.CloseWithError(err)
+	}()
+	err := i.K8sCli.StreamCommandToPod(ctx, pod, i.k8sName, []string{"sh", "-c", script}, pr)
+	// stop the archive if the command failed before reading it
+	pr.CloseWithError(err)
+	return err
+}
+
+// extractScript returns the script extracting the archive of its input to dir, created if needed
+func extractScript(dir string) string {
+	return fmt.Sprintf("mkdir -p %s && tar -C %s -xf -", quoteShell(dir), quoteShell(dir))
+}
+
+// scanFolder returns the regular files of the folder by their slash separated path relative to the folder
+func scanFolder(dir string) (map[string]syncedFile, error) {
+	files := make(map[string]syncedFile)
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || !d.Type().IsRegular() {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		name, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(name)] = syncedFile{modTime: info.ModTime(), size: info.Size(), mode: info.Mode()}
+		return nil
+	})
+	return files, err
+}
+
+// writeTarFile writes the local file to the archive with the given name
+func writeTarFile(tw *tar.Writer, file, name string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	hdr, err := tar.FileInfoHeader(info, "")
+	if err != nil {
+		return err
+	}
+	hdr.Name = name
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	_, err = io.CopyN(tw, f, hdr.Size)
+	return err
+}
@@ -0,0 +1,92 @@
+package instance
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/celestiaorg/knuu/pkg/clock"
+	"github.com/celestiaorg/knuu/pkg/k8s/fake"
+)
+
+func tarNames(t *testing.T, archive []byte) map[string]string {
+	files := make(map[string]string)
+	tr := tar.NewReader(bytes.NewReader(archive))
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return files
+		}
+		require.NoError(t, err)
+		content, err := io.ReadAll(tr)
+		require.NoError(t, err)
+		files[hdr.Name] = string(content)
+	}
+}
+
+func TestSyncFolder(t *testing.T) {
+	t.Parallel()
+
+	i, k8sCli := startedInstanceWithArchive(t, "")
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "sub"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "config.toml"), []byte("a"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "sub", "app"), []byte("bin"), 0o755))
+	require.NoError(t, os.Symlink("/etc/passwd", filepath.Join(dir, "latest")))
+
+	fc := clock.NewFake(time.Now())
+	ctx, cancel := context.WithCancel(clock.WithClock(context.Background(), fc))
+	defer cancel()
+	results := make(chan SyncResult, 1)
+	err := i.SyncFolder(ctx, dir, "/home/app", OnSync(func(r SyncResult) {
+		results <- r
+	}))
+	require.NoError(t, err)
+
+	commands := k8sCli.FakeExecutor.Commands()
+	require.Len(t, commands, 1)
+	assert.Equal(t, []string{"sh", "-c", "mkdir -p '/home/app' && tar -C '/home/app' -xf -"}, commands[0].Command)
+	assert.Equal(t, map[string]string{"config.toml": "a", "sub/app": "bin"}, tarNames(t, commands[0].Stdin))
+
+	// only the changes are synced by the next scans
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "config.toml"), []byte("ab"), 0o644))
+	require.NoError(t, os.Remove(filepath.Join(dir, "sub", "app")))
+	require.Eventually(t, fc.HasWaiters, time.Second, time.Millisecond)
+	fc.Step(defaultSyncInterval)
+
+	result := <-results
+	assert.NoError(t, result.Err)
+	assert.Equal(t, []string{"config.toml"}, result.Copied)
+	assert.Equal(t, []string{"sub/app"}, result.Deleted)
+	commands = k8sCli.FakeExecutor.Commands()
+	require.Len(t, commands, 3)
+	assert.Equal(t, map[string]string{"config.toml": "ab"}, tarNames(t, commands[1].Stdin))
+	assert.Equal(t, []string{"rm", "-f", "--", "/home/app/sub/app"}, commands[2].Command)
+
+	// a failed sync is retried by the next scan
+	k8sCli.FakeExecutor.Handler = func(fake.Command) (string, string, error) {
+		return "", "tar: read-only file system", errors.New("exit code 2")
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "config.toml"), []byte("abc"), 0o644))
+	fc.Step(defaultSyncInterval)
+	result = <-results
+	assert.ErrorIs(t, result.Err, ErrSyncingFolder)
+
+	k8sCli.FakeExecutor.Handler = nil
+	fc.Step(defaultSyncInterval)
+	result = <-results
+	assert.NoError(t, result.Err)
+	assert.Equal(t, []string{"config.toml"}, result.Copied)
+
+	i.state = Stopped
+	assert.ErrorIs(t, i.SyncFolder(ctx, dir, "/home/app"), ErrSyncingFolderNotAllowed)
+}
@@ -0,0 +1,84 @@
+package instance
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/celestiaorg/knuu/pkg/retry"
+)
+
+// waitTerminationPolicy is the policy used to wait for the container of an instance to terminate, it is only bound by the context
+var waitTerminationPolicy = retry.Constant(1 * time.Second)
+
+// Termination describes how the container of an instance terminated
+type Termination struct {
+	ExitCode int32
+	Signal   int32
+	// Reason is the reason reported by kubernetes, e.g. Completed, Error or OOMKilled
+	Reason string
+	// Message is the content of the termination message file of the container (/dev/termination-log by default)
+	Message    string
+	StartedAt  time.Time
+	FinishedAt time.Time
+}
+
+// Succeeded returns true if the container exited with the code 0
+func (t *Termination) Succeeded() bool {
+	return t.ExitCode == 0
+}
+
+// WaitForTermination waits until the container of the instance terminates and returns how it terminated
+// Use it with the restart policy Never or OnFailure, as with Always the container is restarted and
+// only its last termination is returned.
+// This function can only be called in the state 'Started'
+func (i *Instance) WaitForTermination(ctx context.Context) (*Termination, error) {
+	if !i.allows(ActionOperate) {
+		return nil, ErrWaitingForTerminationNotAllowed.WithParams(i.getState().String())
+	}
+
+	var termination *Termination
+	err := retry.Until(ctx, waitTerminationPolicy, func(ctx context.Context) (bool, error) {
+		pod, err := i.getPod(ctx)
+		if err != nil {
+			return false, ErrGettingPodFromReplicaSet.WithParams(i.k8sName).Wrap(err)
+		}
+		termination = i.terminationFromPod(pod)
+		return termination != nil, nil
+	})
+	if errors.Is(err, retry.ErrContextDone) {
+		return nil, ErrWaitingForTerminationTimeout.WithParams(i.k8sName).Wrap(err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return termination, nil
+}
+
+// terminationFromPod returns the termination of the container of the instance, nil if it did not terminate
+// The last termination of a restarted container is only returned while it is not running again, e.g. in a back-off.
+func (i *Instance) terminationFromPod(pod *v1.Pod) *Termination {
+	for _, cs := range pod.Status.ContainerStatuses {
+		if cs.Name != i.k8sName {
+			continue
+		}
+		state := cs.State.Terminated
+		if state == nil && cs.State.Running == nil {
+			state = cs.LastTerminationState.Terminated
+		}
+		if state == nil {
+			return nil
+		}
+		return &Termination{
+			ExitCode:   state.ExitCode,
+			Signal:     state.Signal,
+			Reason:     state.Reason,
+			Message:    state.Message,
+			StartedAt:  state.StartedAt.Time,
+			FinishedAt: state.FinishedAt.Time,
+		}
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package instance
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/celestiaorg/knuu/pkg/system"
+)
+
+func TestWaitForTermination(t *testing.T) {
+	t.Parallel()
+
+	pods := &fakePods{}
+	i, err := New("test", system.SystemDependencies{K8sCli: pods}, WithImage("alpine"))
+	require.NoError(t, err)
+	require.NoError(t, i.SetRestartPolicy(v1.RestartPolicyNever))
+
+	_, err = i.WaitForTermination(context.Background())
+	assert.ErrorIs(t, err, ErrWaitingForTerminationNotAllowed)
+
+	i.state = Started
+	pods.setPod(i.k8sName, v1.PodFailed, false)
+	pods.pods[i.k8sName].Status.ContainerStatuses[0].State.Terminated = &v1.ContainerStateTerminated{
+		ExitCode: 3,
+		Reason:   "Error",
+		Message:  "invalid configuration",
+	}
+
+	termination, err := i.WaitForTermination(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int32(3), termination.ExitCode)
+	assert.Equal(t, "Error", termination.Reason)
+	assert.Equal(t, "invalid configuration", termination.Message)
+	assert.False(t, termination.Succeeded())
+}
+
+func TestWaitForTerminationTimeout(t *testing.T) {
+	t.Parallel()
+
+	pods := &fakePods{}
+	i, err := New("test", system.SystemDependencies{K8sCli: pods}, WithImage("alpine"))
+	require.NoError(t, err)
+	require.NoError(t, i.SetRestartPolicy(v1.RestartPolicyNever))
+	i.state = Started
+	pods.setPod(i.k8sName, v1.PodRunning, true)
+	// the container restarted and is running again, its last termination is not waited for
+	pods.pods[i.k8sName].Status.ContainerStatuses[0].State.Running = &v1.ContainerStateRunning{}
+	pods.pods[i.k8sName].Status.ContainerStatuses[0].LastTerminationState.Terminated = &v1.ContainerStateTerminated{ExitCode: 1}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	_, err = i.WaitForTermination(ctx)
+	assert.ErrorIs(t, err, ErrWaitingForTerminationTimeout)
+}
@@ -0,0 +1,89 @@
+package instance
+
+import (
+	"context"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/celestiaorg/knuu/pkg/identity"
+	"github.com/celestiaorg/knuu/pkg/k8s"
+)
+
+// EnableTLS issues a TLS certificate for the instance with the CA of the scope when it is started, and mounts it
+// with its key and the certificate of the CA in identity.DefaultDir, see the file names of the identity package.
+// The certificate is valid for the name and the host name of the instance, localhost and the given hosts, and can
+// authenticate both a server and a client, so the services of the instances can use mutual TLS.
+// This function can only be called in the state 'Preparing' or 'Committed'
+func (i *Instance) EnableTLS(hosts ...string) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if !i.allows(ActionConfigure) {
+		return ErrEnablingTLSNotAllowed.WithParams(i.getState().String())
+	}
+	if i.CA == nil {
+		return ErrCANotSet.WithParams(i.name)
+	}
+	i.tlsHosts = append([]string{}, hosts...)
+	logrus.Debugf("Enabled TLS for instance '%s'", i.name)
+	return nil
+}
+
+// TLSIdentity returns the identity whose certificate is mounted in the instance, e.g. to get the certificate of
+// a client of the test with TLSCertificate
+// It is nil until the instance is started with TLS enabled.
+func (i *Instance) TLSIdentity() *identity.Identity {
+	return i.tlsIdentity
+}
+
+func (i *Instance) isTLSEnabled() bool {
+	return i.tlsHosts != nil
+}
+
+// tlsSecretName returns the name of the secret holding the identity of the instance
+func (i *Instance) tlsSecretName() string {
+	return i.k8sName + "-tls"
+}
+
+// tlsSecretMounts returns the mount of the TLS secret in the container of the instance, if TLS is enabled
+func (i *Instance) tlsSecretMounts() []*k8s.SecretMount {
+	if !i.isTLSEnabled() {
+		return nil
+	}
+	return []*k8s.SecretMount{{Secret: i.tlsSecretName(), Path: identity.DefaultDir}}
+}
+
+// tlsCertificateHosts returns the hosts the certificate of the instance is valid for
+// A sidecar is reached through the service of its parent instance, so its certificate is valid for its host name.
+func (i *Instance) tlsCertificateHosts() []string {
+	hostName := i.HostName()
+	if i.isSidecar && i.parentInstance != nil {
+		hostName = i.parentInstance.HostName()
+	}
+	return append([]string{hostName, "localhost", "127.0.0.1"}, i.tlsHosts...)
+}
+
+// deployTLSSecret issues the identity of the instance and deploys it as a secret
+func (i *Instance) deployTLSSecret(ctx context.Context) error {
+	id, err := i.CA.Issue(i.name, i.tlsCertificateHosts()...)
+	if err != nil {
+		return ErrIssuingTLSCertificate.WithParams(i.name).Wrap(err)
+	}
+	if _, err := i.K8sCli.CreateSecret(ctx, i.tlsSecretName(), i.getLabels(), id.Files()); err != nil {
+		return ErrDeployingTLSSecret.WithParams(i.name).Wrap(err)
+	}
+	i.tlsIdentity = id
+
+	logrus.Debugf("Deployed TLS secret '%s'", i.tlsSecretName())
+	return nil
+}
+
+// destroyTLSSecret destroys the secret holding the identity of the instance
+func (i *Instance) destroyTLSSecret(ctx context.Context) error {
+	if err := i.K8sCli.DeleteSecret(ctx, i.tlsSecretName()); err != nil {
+		return ErrDestroyingTLSSecret.WithParams(i.name).Wrap(err)
+	}
+
+	logrus.Debugf("Destroyed TLS secret '%s'", i.tlsSecretName())
+	return nil
+}
@@ -0,0 +1,72 @@
+package instance
+
+import (
+	"context"
+	"crypto/x509"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/celestiaorg/knuu/pkg/identity"
+	"github.com/celestiaorg/knuu/pkg/k8s/fake"
+	"github.com/celestiaorg/knuu/pkg/system"
+)
+
+func TestEnableTLS(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	k8sCli, err := fake.New(ctx, "test")
+	require.NoError(t, err)
+	noCA, err := New("no-ca", system.SystemDependencies{K8sCli: k8sCli}, WithImage("alpine"))
+	require.NoError(t, err)
+	assert.ErrorIs(t, noCA.EnableTLS(), ErrCANotSet)
+
+	ca, err := identity.NewCA("knuu-test")
+	require.NoError(t, err)
+	i, err := New("api", system.SystemDependencies{K8sCli: k8sCli, CA: ca}, WithImage("alpine"))
+	require.NoError(t, err)
+	require.NoError(t, i.EnableTLS("api.example.com"))
+	require.NoError(t, i.Commit())
+
+	tracker := newResourceTracker(i.k8sName)
+	i.deployResources(ctx, tracker)
+	require.False(t, tracker.hasFailures())
+
+	secret, err := k8sCli.FakeClientset.CoreV1().Secrets(k8sCli.Namespace()).Get(ctx, i.tlsSecretName(), metav1.GetOptions{})
+	require.NoError(t, err)
+	require.NotNil(t, i.TLSIdentity())
+	assert.Equal(t, i.TLSIdentity().Files(), secret.Data)
+
+	cert, err := i.TLSIdentity().TLSCertificate()
+	require.NoError(t, err)
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	require.NoError(t, err)
+	_, err = leaf.Verify(x509.VerifyOptions{
+		DNSName:   "api.example.com",
+		Roots:     ca.CertPool(),
+		KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+	assert.NoError(t, err)
+	assert.Contains(t, leaf.DNSNames, i.HostName())
+
+	podConfig := i.preparePodConfig()
+	pod, err := k8sCli.DeployPod(ctx, podConfig, false)
+	require.NoError(t, err)
+	assert.Contains(t, pod.Spec.Volumes, v1.Volume{
+		Name:         i.tlsSecretName(),
+		VolumeSource: v1.VolumeSource{Secret: &v1.SecretVolumeSource{SecretName: i.tlsSecretName()}},
+	})
+	assert.Contains(t, pod.Spec.Containers[0].VolumeMounts, v1.VolumeMount{Name: i.tlsSecretName(), MountPath: identity.DefaultDir, ReadOnly: true})
+
+	require.NoError(t, i.destroyTLSSecret(ctx))
+	_, err = k8sCli.FakeClientset.CoreV1().Secrets(k8sCli.Namespace()).Get(ctx, i.tlsSecretName(), metav1.GetOptions{})
+	assert.Error(t, err)
+
+	clone := i.cloneWithSuffix("-1")
+	assert.True(t, clone.isTLSEnabled())
+	assert.Nil(t, clone.TLSIdentity(), "the clone gets its own identity when it is started")
+}
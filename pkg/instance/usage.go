@@ -0,0 +1,80 @@
+package instance
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/celestiaorg/knuu/pkg/report"
+)
+
+// ResourceUsage returns the requested and the used CPU and memory of the instance and its sidecars,
+// the time its pod ran and the size of its image
+// The usage is unknown if the cluster has no metrics server, the image size if the node does not report it.
+// This function can only be called in the state 'Started'
+func (i *Instance) ResourceUsage(ctx context.Context) (*report.ResourceUsage, error) {
+	if !i.allows(ActionOperate) {
+		return nil, ErrGettingResourceUsageNotAllowed.WithParams(i.getState().String())
+	}
+
+	pod, err := i.getPod(ctx)
+	if err != nil {
+		return nil, ErrGettingPodFromReplicaSet.WithParams(i.k8sName).Wrap(err)
+	}
+
+	usage := &report.ResourceUsage{
+		Instance: i.k8sName,
+		Image:    i.imageName,
+	}
+	for _, c := range pod.Spec.Containers {
+		usage.CPURequestMillis += c.Resources.Requests.Cpu().MilliValue()
+		usage.MemoryRequestBytes += c.Resources.Requests.Memory().Value()
+	}
+	if pod.Status.StartTime != nil {
+		usage.Runtime = time.Since(pod.Status.StartTime.Time)
+	}
+
+	used, err := i.K8sCli.GetPodUsage(ctx, pod.Name)
+	if err != nil {
+		logrus.Debugf("Usage of instance '%s' is unknown: %v", i.k8sName, err)
+	} else {
+		usage.CPUUsedMillis = used.Cpu().MilliValue()
+		usage.MemoryUsedBytes = used.Memory().Value()
+		usage.UsageKnown = true
+	}
+
+	if pod.Spec.NodeName != "" {
+		node, err := i.K8sCli.GetNode(ctx, pod.Spec.NodeName)
+		if err != nil {
+			logrus.Debugf("Image size of instance '%s' is unknown: %v", i.k8sName, err)
+		} else {
+			usage.ImageSizeBytes = imageSize(node, pod, i.k8sName)
+		}
+	}
+	return usage, nil
+}
+
+// imageSize returns the size of the image of the given container as reported by the node, 0 if it is not reported
+func imageSize(node *v1.Node, pod *v1.Pod, container string) int64 {
+	names := make(map[string]bool)
+	for _, c := range pod.Spec.Containers {
+		if c.Name == container {
+			names[c.Image] = true
+		}
+	}
+	if status, ok := containerStatus(pod, container); ok {
+		names[status.Image] = true
+		names[status.ImageID] = true
+	}
+	delete(names, "")
+	for _, image := range node.Status.Images {
+		for _, name := range image.Names {
+			if names[name] {
+				return image.SizeBytes
+			}
+		}
+	}
+	return 0
+}
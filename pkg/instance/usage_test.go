@@ -0,0 +1,93 @@
+package instance
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/celestiaorg/knuu/pkg/k8s/fake"
+	"github.com/celestiaorg/knuu/pkg/system"
+)
+
+func TestResourceUsage(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	node := &v1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "node"},
+		Status: v1.NodeStatus{Images: []v1.ContainerImage{
+			{Names: []string{"docker.io/library/busybox:1.36"}, SizeBytes: 2 << 20},
+			{Names: []string{"docker.io/library/alpine@sha256:abc", "docker.io/library/alpine:3.19"}, SizeBytes: 7 << 20},
+		}},
+	}
+	k8sCli, err := fake.New(ctx, "test", node)
+	require.NoError(t, err)
+	k8sCli.AddAPIResources("metrics.k8s.io/v1beta1", "pods")
+	i, err := New("validator", system.SystemDependencies{K8sCli: k8sCli}, WithImage("alpine:3.19"))
+	require.NoError(t, err)
+	i.restartPolicy = v1.RestartPolicyNever
+	i.state = Started
+
+	requests := func(cpu, memory string) v1.ResourceRequirements {
+		return v1.ResourceRequirements{Requests: v1.ResourceList{
+			v1.ResourceCPU:    resource.MustParse(cpu),
+			v1.ResourceMemory: resource.MustParse(memory),
+		}}
+	}
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: i.k8sName},
+		Spec: v1.PodSpec{
+			NodeName: "node",
+			Containers: []v1.Container{
+				{Name: i.k8sName, Image: "alpine:3.19", Resources: requests("500m", "1Gi")},
+				{Name: "sidecar", Image: "busybox:1.36", Resources: requests("100m", "64Mi")},
+			},
+		},
+		Status: v1.PodStatus{
+			StartTime:         &metav1.Time{Time: time.Now().Add(-10 * time.Minute)},
+			ContainerStatuses: []v1.ContainerStatus{{Name: i.k8sName, ImageID: "docker.io/library/alpine@sha256:abc"}},
+		},
+	}
+	_, err = k8sCli.FakeClientset.CoreV1().Pods("test").Create(ctx, pod, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	// the usage is unknown until the metrics server reports it
+	usage, err := i.ResourceUsage(ctx)
+	require.NoError(t, err)
+	assert.False(t, usage.UsageKnown)
+	assert.Equal(t, int64(600), usage.CPURequestMillis)
+	assert.Equal(t, int64(1<<30+64<<20), usage.MemoryRequestBytes)
+	assert.Equal(t, int64(7<<20), usage.ImageSizeBytes)
+	assert.InDelta(t, 10, usage.RuntimeMinutes(), 1)
+
+	metrics := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "metrics.k8s.io/v1beta1",
+		"kind":       "PodMetrics",
+		"metadata":   map[string]interface{}{"name": i.k8sName, "namespace": "test"},
+		"containers": []interface{}{
+			map[string]interface{}{"name": i.k8sName, "usage": map[string]interface{}{"cpu": "120m", "memory": "256Mi"}},
+			map[string]interface{}{"name": "sidecar", "usage": map[string]interface{}{"cpu": "5m", "memory": "16Mi"}},
+		},
+	}}
+	gvr := schema.GroupVersionResource{Group: "metrics.k8s.io", Version: "v1beta1", Resource: "pods"}
+	_, err = k8sCli.FakeDynamicClient.Resource(gvr).Namespace("test").Create(ctx, metrics, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	usage, err = i.ResourceUsage(ctx)
+	require.NoError(t, err)
+	assert.True(t, usage.UsageKnown)
+	assert.Equal(t, int64(125), usage.CPUUsedMillis)
+	assert.Equal(t, int64(272<<20), usage.MemoryUsedBytes)
+
+	i.state = Stopped
+	_, err = i.ResourceUsage(ctx)
+	assert.ErrorIs(t, err, ErrGettingResourceUsageNotAllowed)
+}
@@ -0,0 +1,160 @@
+package instance
+
+import (
+	"context"
+
+	"github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// VPAMode is the update mode of the VerticalPodAutoscaler of an instance
+type VPAMode string
+
+const (
+	// VPAModeOff only computes the recommended resources of the instance, see VPARecommendation
+	VPAModeOff VPAMode = "Off"
+	// VPAModeInitial applies the recommended resources when the pod of the instance is recreated
+	VPAModeInitial VPAMode = "Initial"
+	// VPAModeRecreate evicts the pod of the instance to recreate it with the recommended resources once they differ
+	// significantly from its requests
+	VPAModeRecreate VPAMode = "Recreate"
+	// VPAModeAuto is VPAModeRecreate, until the autoscaler supports updating the resources of running pods
+	VPAModeAuto VPAMode = "Auto"
+)
+
+// vpaGVR is the resource of the VerticalPodAutoscalers, whose definition is installed with the autoscaler
+var vpaGVR = &schema.GroupVersionResource{
+	Group:    "autoscaling.k8s.io",
+	Version:  "v1",
+	Resource: "verticalpodautoscalers",
+}
+
+// EnableVPA deploys a VerticalPodAutoscaler with the given update mode for the instance when it is started, e.g.
+// so the memory of a node whose usage grows during a soak test is raised before the node is killed by the OOM killer.
+// The autoscaler scales the requests of the main container, the limits are scaled in proportion, and the resources
+// of the sidecars are left as is. Except in VPAModeOff, the pod is evicted to be recreated with its new resources, so
+// the state of the instance that is not in a volume is lost.
+// The autoscaler must be installed in the cluster, and the instance must be deployed as a ReplicaSet, i.e. with the
+// restart policy 'Always', for its pod to be recreated.
+// This function can only be called in the states 'Preparing' and 'Committed'
+func (i *Instance) EnableVPA(mode VPAMode) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if !i.allows(ActionConfigure) {
+		return ErrEnablingVPANotAllowed.WithParams(i.getState().String())
+	}
+	if i.isSidecar {
+		return ErrEnablingVPAOfSidecar.WithParams(i.k8sName)
+	}
+	switch mode {
+	case VPAModeOff, VPAModeInitial, VPAModeRecreate, VPAModeAuto:
+	default:
+		return ErrInvalidVPAMode.WithParams(mode)
+	}
+
+	i.vpaMode = mode
+	logrus.Debugf("Enabled VerticalPodAutoscaler in mode '%s' for instance '%s'", mode, i.k8sName)
+	return nil
+}
+
+// VPARecommendation returns the resources recommended for the main container of the instance by its
+// VerticalPodAutoscaler, which are empty until the autoscaler observed the instance long enough
+// This function can only be called in the state 'Started'
+func (i *Instance) VPARecommendation(ctx context.Context) (v1.ResourceList, error) {
+	if !i.allows(ActionOperate) {
+		return nil, ErrGettingVPARecommendationNotAllowed.WithParams(i.getState().String())
+	}
+	if i.vpaMode == "" {
+		return nil, ErrVPANotEnabled.WithParams(i.k8sName)
+	}
+
+	vpa, err := i.K8sCli.GetCustomResource(ctx, i.k8sName, vpaGVR)
+	if err != nil {
+		return nil, ErrGettingVPARecommendation.WithParams(i.k8sName).Wrap(err)
+	}
+	containers, _, err := unstructured.NestedSlice(vpa.Object, "status", "recommendation", "containerRecommendations")
+	if err != nil {
+		return nil, ErrGettingVPARecommendation.WithParams(i.k8sName).Wrap(err)
+	}
+	for _, c := range containers {
+		container, ok := c.(map[string]interface{})
+		if !ok || container["containerName"] != i.k8sName {
+			continue
+		}
+		target, _, err := unstructured.NestedStringMap(container, "target")
+		if err != nil {
+			return nil, ErrGettingVPARecommendation.WithParams(i.k8sName).Wrap(err)
+		}
+		recommendation := make(v1.ResourceList, len(target))
+		for name, value := range target {
+			quantity, err := resource.ParseQuantity(value)
+			if err != nil {
+				return nil, ErrGettingVPARecommendation.WithParams(i.k8sName).Wrap(err)
+			}
+			recommendation[v1.ResourceName(name)] = quantity
+		}
+		return recommendation, nil
+	}
+	return v1.ResourceList{}, nil
+}
+
+// vpaObject returns the VerticalPodAutoscaler of the instance, targeting its ReplicaSet
+// The JSON types are used as the object is copied as an unstructured object.
+func (i *Instance) vpaObject() map[string]interface{} {
+	updatePolicy := map[string]interface{}{"updateMode": string(i.vpaMode)}
+	if i.vpaMode == VPAModeRecreate || i.vpaMode == VPAModeAuto {
+		// the updater only evicts the pods of the controllers with 2 replicas by default
+		updatePolicy["minReplicas"] = int64(1)
+	}
+	return map[string]interface{}{
+		"kind": "VerticalPodAutoscaler",
+		"spec": map[string]interface{}{
+			"targetRef": map[string]interface{}{
+				"apiVersion": "apps/v1",
+				"kind":       "ReplicaSet",
+				"name":       i.k8sName,
+			},
+			"updatePolicy": updatePolicy,
+			"resourcePolicy": map[string]interface{}{
+				"containerPolicies": []interface{}{
+					map[string]interface{}{
+						"containerName":       i.k8sName,
+						"controlledResources": []interface{}{string(v1.ResourceCPU), string(v1.ResourceMemory)},
+					},
+					map[string]interface{}{
+						"containerName": "*",
+						"mode":          "Off",
+					},
+				},
+			},
+		},
+	}
+}
+
+// deployVPA deploys the VerticalPodAutoscaler of the instance
+func (i *Instance) deployVPA(ctx context.Context) error {
+	if !i.usesReplicaSet() {
+		return ErrVPARequiresReplicaSet.WithParams(i.k8sName, i.restartPolicy)
+	}
+	obj := i.vpaObject()
+	if err := i.CreateCustomResource(ctx, vpaGVR, &obj); err != nil {
+		return ErrDeployingVPA.WithParams(i.k8sName).Wrap(err)
+	}
+
+	logrus.Debugf("Deployed VerticalPodAutoscaler '%s' in mode '%s'", i.k8sName, i.vpaMode)
+	return nil
+}
+
+// destroyVPA destroys the VerticalPodAutoscaler of the instance
+func (i *Instance) destroyVPA(ctx context.Context) error {
+	if err := i.K8sCli.DeleteCustomResource(ctx, i.k8sName, vpaGVR); err != nil {
+		return ErrDestroyingVPA.WithParams(i.k8sName).Wrap(err)
+	}
+
+	logrus.Debugf("Destroyed VerticalPodAutoscaler '%s'", i.k8sName)
+	return nil
+}
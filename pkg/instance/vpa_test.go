@@ -0,0 +1,118 @@
+package instance
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/celestiaorg/knuu/pkg/k8s/fake"
+	"github.com/celestiaorg/knuu/pkg/system"
+)
+
+func TestEnableVPA(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	k8sCli, err := fake.New(ctx, "test")
+	require.NoError(t, err)
+	i, err := New("node", system.SystemDependencies{K8sCli: k8sCli}, WithImage("alpine"))
+	require.NoError(t, err)
+
+	assert.ErrorIs(t, i.EnableVPA("Sometimes"), ErrInvalidVPAMode)
+	require.NoError(t, i.EnableVPA(VPAModeRecreate))
+	assert.Equal(t, VPAModeRecreate, i.cloneWithSuffix("-clone").vpaMode)
+
+	sidecar, err := New("sidecar", system.SystemDependencies{K8sCli: k8sCli}, WithImage("alpine"))
+	require.NoError(t, err)
+	sidecar.isSidecar = true
+	assert.ErrorIs(t, sidecar.EnableVPA(VPAModeAuto), ErrEnablingVPAOfSidecar)
+
+	i.setState(Started)
+	assert.ErrorIs(t, i.EnableVPA(VPAModeOff), ErrEnablingVPANotAllowed)
+}
+
+func TestDeployVPA(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	k8sCli, err := fake.New(ctx, "test")
+	require.NoError(t, err)
+	i, err := New("node", system.SystemDependencies{K8sCli: k8sCli}, WithImage("alpine"))
+	require.NoError(t, err)
+	require.NoError(t, i.EnableVPA(VPAModeRecreate))
+
+	// the autoscaler is not installed
+	err = i.deployVPA(ctx)
+	assert.ErrorIs(t, err, ErrDeployingVPA)
+	assert.ErrorContains(t, err, "custom resource definition verticalpodautoscalers does not exist")
+
+	k8sCli.AddAPIResources("autoscaling.k8s.io/v1", "verticalpodautoscalers")
+	require.NoError(t, i.deployVPA(ctx))
+
+	vpas := k8sCli.FakeDynamicClient.Resource(*vpaGVR).Namespace("test")
+	vpa, err := vpas.Get(ctx, i.k8sName, metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "VerticalPodAutoscaler", vpa.GetKind())
+	target, _, err := unstructured.NestedStringMap(vpa.Object, "spec", "targetRef")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"apiVersion": "apps/v1", "kind": "ReplicaSet", "name": i.k8sName}, target)
+	mode, _, err := unstructured.NestedString(vpa.Object, "spec", "updatePolicy", "updateMode")
+	require.NoError(t, err)
+	assert.Equal(t, "Recreate", mode)
+	minReplicas, _, err := unstructured.NestedInt64(vpa.Object, "spec", "updatePolicy", "minReplicas")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), minReplicas)
+
+	// no recommendation until the recommender observed the instance
+	i.setState(Started)
+	recommendation, err := i.VPARecommendation(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, recommendation)
+
+	require.NoError(t, unstructured.SetNestedSlice(vpa.Object, []interface{}{
+		map[string]interface{}{
+			"containerName": "otel-agent",
+			"target":        map[string]interface{}{"cpu": "10m", "memory": "50Mi"},
+		},
+		map[string]interface{}{
+			"containerName": i.k8sName,
+			"target":        map[string]interface{}{"cpu": "250m", "memory": "1Gi"},
+		},
+	}, "status", "recommendation", "containerRecommendations"))
+	_, err = vpas.Update(ctx, vpa, metav1.UpdateOptions{})
+	require.NoError(t, err)
+
+	recommendation, err = i.VPARecommendation(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, v1.ResourceList{
+		v1.ResourceCPU:    resource.MustParse("250m"),
+		v1.ResourceMemory: resource.MustParse("1Gi"),
+	}, recommendation)
+
+	// destroying the autoscaler is idempotent, e.g. after a rollback
+	require.NoError(t, i.destroyVPA(ctx))
+	require.NoError(t, i.destroyVPA(ctx))
+	_, err = vpas.Get(ctx, i.k8sName, metav1.GetOptions{})
+	assert.Error(t, err)
+}
+
+func TestDeployVPARequiresReplicaSet(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	k8sCli, err := fake.New(ctx, "test")
+	require.NoError(t, err)
+	k8sCli.AddAPIResources("autoscaling.k8s.io/v1", "verticalpodautoscalers")
+	i, err := New("node", system.SystemDependencies{K8sCli: k8sCli}, WithImage("alpine"))
+	require.NoError(t, err)
+	require.NoError(t, i.EnableVPA(VPAModeOff))
+	i.restartPolicy = v1.RestartPolicyNever
+
+	assert.ErrorIs(t, i.deployVPA(ctx), ErrVPARequiresReplicaSet)
+}
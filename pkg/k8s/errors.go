@@ -36,6 +36,7 @@ var (
 	ErrParsingMemoryRequest            = errors.New("ErrorParsingMemoryRequest", "failed to parse memory request quantity '%s'")
 	ErrParsingMemoryLimit              = errors.New("ErrorParsingMemoryLimit", "failed to parse memory limit quantity '%s'")
 	ErrParsingCPURequest               = errors.New("ErrorParsingCPURequest", "failed to parse CPU request quantity '%s'")
+	ErrParsingCPULimit                 = errors.New("ErrorParsingCPULimit", "failed to parse CPU limit quantity '%s'")
 	ErrBuildingContainerVolumes        = errors.New("ErrorBuildingContainerVolumes", "failed to build container volumes")
 	ErrBuildingResources               = errors.New("ErrorBuildingResources", "failed to build resources")
 	ErrBuildingInitContainerVolumes    = errors.New("ErrorBuildingInitContainerVolumes", "failed to build init container volumes")
@@ -72,6 +73,7 @@ var (
 	ErrNamespaceRequired               = errors.New("NamespaceRequired", "namespace is required")
 	ErrServiceNameRequired             = errors.New("ServiceNameRequired", "service name is required")
 	ErrNoPortsSpecified                = errors.New("NoPortsSpecified", "no ports specified for service %s")
+	ErrDuplicateServicePortName        = errors.New("DuplicateServicePortName", "duplicate port name '%s' in service")
 	ErrRetrievingKubernetesConfig      = errors.New("RetrievingKubernetesConfig", "retrieving the Kubernetes config")
 	ErrCreatingClientset               = errors.New("CreatingClientset", "creating clientset for Kubernetes")
 	ErrCreatingDiscoveryClient         = errors.New("CreatingDiscoveryClient", "creating discovery client for Kubernetes")
@@ -79,6 +81,8 @@ var (
 	ErrGettingResourceList             = errors.New("GettingResourceList", "getting resource list for group version %s")
 	ErrResourceDoesNotExist            = errors.New("ResourceDoesNotExist", "resource %s does not exist in group version %s")
 	ErrCreatingCustomResource          = errors.New("CreatingCustomResource", "creating custom resource %s")
+	ErrGettingCustomResource           = errors.New("GettingCustomResource", "getting custom resource %s '%s'")
+	ErrDeletingCustomResource          = errors.New("DeletingCustomResource", "deleting custom resource %s '%s'")
 	ErrCreatingRole                    = errors.New("CreatingRole", "creating role %s")
 	ErrCreatingRoleBinding             = errors.New("CreatingRoleBinding", "creating role binding %s")
 	ErrCreatingRoleBindingFailed       = errors.New("CreatingRoleBindingFailed", "creating role binding %s failed")
@@ -93,8 +97,43 @@ var (
 	ErrWaitingForDeployment            = errors.New("WaitingForDeployment", "waiting for deployment %s to be ready")
 	ErrClusterRoleAlreadyExists        = errors.New("ClusterRoleAlreadyExists", "cluster role %s already exists")
 	ErrClusterRoleBindingAlreadyExists = errors.New("ClusterRoleBindingAlreadyExists", "cluster role binding %s already exists")
+	ErrListingClusterRoles             = errors.New("ListingClusterRoles", "error listing the cluster roles with labels %v")
+	ErrDeletingClusterRole             = errors.New("DeletingClusterRole", "error deleting cluster role %s")
+	ErrListingClusterRoleBindings      = errors.New("ListingClusterRoleBindings", "error listing the cluster role bindings with labels %v")
+	ErrDeletingClusterRoleBinding      = errors.New("DeletingClusterRoleBinding", "error deleting cluster role binding %s")
 	ErrCreateEndpoint                  = errors.New("CreateEndpoint", "failed to create endpoint for service %s")
 	ErrGetEndpoint                     = errors.New("GetEndpoint", "failed to get endpoint for service %s")
 	ErrUpdateEndpoint                  = errors.New("UpdateEndpoint", "failed to update endpoint for service %s")
 	ErrCheckingServiceReady            = errors.New("CheckingServiceReady", "failed to check if service %s is ready")
+	ErrWaitingForPodDeletion           = errors.New("WaitingForPodDeletion", "error waiting for pod %s to be deleted")
+	ErrPortForwardingCancelled         = errors.New("PortForwardingCancelled", "port forwarding cancelled before it was ready")
+	ErrStreamingPodLogs                = errors.New("StreamingPodLogs", "failed to stream logs of container %s in pod %s")
+	ErrAddingEphemeralContainer        = errors.New("AddingEphemeralContainer", "failed to add ephemeral container %s to pod %s")
+	ErrGeneratingNodeDebuggerName      = errors.New("GeneratingNodeDebuggerName", "failed to generate the name of the node debugger")
+	ErrCreatingNodeDebugger            = errors.New("CreatingNodeDebugger", "failed to create the debugger of node %s")
+	ErrWaitingForNodeDebugger          = errors.New("WaitingForNodeDebugger", "error waiting for debugger %s of node %s to run")
+	ErrGettingEndpointSlices           = errors.New("GettingEndpointSlices", "error getting the endpoint slices of service %s")
+	ErrTimeoutWaitingForEndpoints      = errors.New("TimeoutWaitingForEndpoints", "timed out waiting for %d ready endpoints of service %s")
+	ErrGettingNode                     = errors.New("GettingNode", "failed to get node %s")
+	ErrGettingPodUsage                 = errors.New("GettingPodUsage", "failed to get the usage of pod %s from the metrics server")
+	ErrDetectingCapabilities           = errors.New("DetectingCapabilities", "failed to detect the capabilities of the cluster")
+	ErrCapabilityNotSupported          = errors.New("CapabilityNotSupported", "the cluster (%s) does not support %s: %s")
+	ErrApplyingObject                  = errors.New("ApplyingObject", "error applying '%s'")
+	ErrListingPods                     = errors.New("ListingPods", "error listing pods")
+	ErrListingReplicaSets              = errors.New("ListingReplicaSets", "error listing ReplicaSets")
+	ErrAnnotatingPod                   = errors.New("AnnotatingPod", "error annotating pod %s")
+	ErrAnnotatingReplicaSet            = errors.New("AnnotatingReplicaSet", "error annotating ReplicaSet %s")
+	ErrProxyingToPod                   = errors.New("ProxyingToPod", "error proxying GET '%s' to port %d of pod '%s'")
+	ErrCreatingSecret                  = errors.New("CreatingSecret", "error creating secret %s")
+	ErrDeletingSecret                  = errors.New("DeletingSecret", "error deleting secret %s")
+	ErrCreatingWebhookConfiguration    = errors.New("CreatingWebhookConfiguration", "error creating validating webhook configuration %s")
+	ErrDeletingWebhookConfiguration    = errors.New("DeletingWebhookConfiguration", "error deleting validating webhook configuration %s")
+	ErrListingWebhookConfigurations    = errors.New("ListingWebhookConfigurations", "error listing the validating webhook configurations with labels %v")
+	ErrSettingPodCondition             = errors.New("SettingPodCondition", "error setting condition %s of pod %s")
+	ErrCreatingHorizontalPodAutoscaler = errors.New("CreatingHorizontalPodAutoscaler", "error creating HorizontalPodAutoscaler %s")
+	ErrGettingHorizontalPodAutoscaler  = errors.New("GettingHorizontalPodAutoscaler", "error getting HorizontalPodAutoscaler %s")
+	ErrDeletingHorizontalPodAutoscaler = errors.New("DeletingHorizontalPodAutoscaler", "error deleting HorizontalPodAutoscaler %s")
+	ErrListingDeployments              = errors.New("ListingDeployments", "error listing deployments")
+	ErrListingStatefulSets             = errors.New("ListingStatefulSets", "error listing StatefulSets")
+	ErrProbingPodSecurity              = errors.New("ProbingPodSecurity", "failed to probe the pod security of namespace '%s'")
 )
@@ -0,0 +1,172 @@
+// Package fake provides an in-memory k8s client to unit test the code using knuu without a cluster
+// The client is the one of knuu backed by the fake clientset and dynamic client of client-go, so the
+// resources are stored but no controller runs: the pods are never scheduled and their status only
+// changes when the test updates it through FakeClientset.
+package fake
+
+import (
+	"context"
+	"io"
+	"sync"
+
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/version"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/kubernetes/scheme"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/celestiaorg/knuu/pkg/k8s"
+)
+
+// Client is a k8s client whose clients are fakes, they are exposed to seed and inspect the resources
+type Client struct {
+	*k8s.Client
+	FakeClientset     *kubefake.Clientset
+	FakeDynamicClient *dynamicfake.FakeDynamicClient
+	FakeExecutor      *Executor
+}
+
+// ServerVersion is the version of kubernetes the fake cluster reports
+const ServerVersion = "v1.28.2"
+
+// New returns a client with the given namespace, holding the given objects
+// The cluster reports the version ServerVersion and serves the core resources, see AddAPIResources to serve more.
+func New(ctx context.Context, namespace string, objects ...runtime.Object) (*Client, error) {
+	c := &Client{
+		FakeClientset:     kubefake.NewSimpleClientset(objects...),
+		FakeDynamicClient: dynamicfake.NewSimpleDynamicClient(scheme.Scheme),
+		FakeExecutor:      &Executor{},
+	}
+	discovery := c.FakeClientset.Discovery().(*fakediscovery.FakeDiscovery)
+	discovery.FakedServerVersion = &version.Info{Major: "1", Minor: "28", GitVersion: ServerVersion}
+	c.AddAPIResources("v1", "pods", "pods/log", "pods/exec", "pods/ephemeralcontainers", "services", "configmaps")
+	c.FakeClientset.PrependReactor("patch", "*", applyReactor(c.FakeClientset.Tracker()))
+	client, err := k8s.NewWithClients(ctx, namespace, k8s.Clients{
+		Clientset: c.FakeClientset,
+		Dynamic:   c.FakeDynamicClient,
+		Executor:  c.FakeExecutor,
+	})
+	if err != nil {
+		return nil, err
+	}
+	c.Client = client
+	return c, nil
+}
+
+// AddAPIResources makes the cluster serve the given resources of the given group version, e.g. metrics.k8s.io/v1beta1
+// It must be called before the capabilities of the cluster are detected.
+func (c *Client) AddAPIResources(groupVersion string, resources ...string) {
+	list := &metav1.APIResourceList{GroupVersion: groupVersion}
+	for _, r := range resources {
+		list.APIResources = append(list.APIResources, metav1.APIResource{Name: r})
+	}
+	c.FakeClientset.Resources = append(c.FakeClientset.Resources, list)
+}
+
+// applyReactor creates the objects applied with server-side apply when they do not exist
+// The fake clientset handles the apply patches as strategic merge patches, which need an existing object.
+func applyReactor(tracker k8stesting.ObjectTracker) k8stesting.ReactionFunc {
+	return func(action k8stesting.Action) (bool, runtime.Object, error) {
+		patch, ok := action.(k8stesting.PatchAction)
+		if !ok || patch.GetPatchType() != types.ApplyPatchType || patch.GetSubresource() != "" {
+			return false, nil, nil
+		}
+		_, err := tracker.Get(patch.GetResource(), patch.GetNamespace(), patch.GetName())
+		if !apierrs.IsNotFound(err) {
+			return false, nil, nil
+		}
+		obj, _, err := scheme.Codecs.UniversalDeserializer().Decode(patch.GetPatch(), nil, nil)
+		if err != nil {
+			return true, nil, err
+		}
+		if err := tracker.Create(patch.GetResource(), obj, patch.GetNamespace()); err != nil {
+			return true, nil, err
+		}
+		return true, obj, nil
+	}
+}
+
+// Command is a command run by the executor
+type Command struct {
+	Namespace string
+	Pod       string
+	Container string
+	Command   []string
+	// Stdin is the input streamed to the command, nil if it has none
+	Stdin []byte
+}
+
+// PortForward is a port forwarded by the executor
+type PortForward struct {
+	Namespace  string
+	Pod        string
+	LocalPort  int
+	RemotePort int
+}
+
+// Executor records the commands and the forwarded ports instead of running them
+type Executor struct {
+	// Handler returns the output of the commands, it has no output if nil
+	// An error is returned as is, an output on stderr fails the command like a real one.
+	Handler func(cmd Command) (stdout, stderr string, err error)
+
+	mu       sync.Mutex
+	commands []Command
+	forwards []PortForward
+}
+
+var _ k8s.Executor = &Executor{}
+
+func (e *Executor) Exec(_ context.Context, namespace, podName, containerName string, cmd []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	command := Command{Namespace: namespace, Pod: podName, Container: containerName, Command: cmd}
+	if stdin != nil {
+		input, err := io.ReadAll(stdin)
+		if err != nil {
+			return err
+		}
+		command.Stdin = input
+	}
+	e.mu.Lock()
+	e.commands = append(e.commands, command)
+	handler := e.Handler
+	e.mu.Unlock()
+
+	if handler == nil {
+		return nil
+	}
+	out, errOut, err := handler(command)
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(stdout, out); err != nil {
+		return err
+	}
+	_, err = io.WriteString(stderr, errOut)
+	return err
+}
+
+func (e *Executor) PortForward(_ context.Context, namespace, podName string, localPort, remotePort int) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.forwards = append(e.forwards, PortForward{Namespace: namespace, Pod: podName, LocalPort: localPort, RemotePort: remotePort})
+	return nil
+}
+
+// Commands returns the commands run so far
+func (e *Executor) Commands() []Command {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]Command(nil), e.commands...)
+}
+
+// PortForwards returns the ports forwarded so far
+func (e *Executor) PortForwards() []PortForward {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]PortForward(nil), e.forwards...)
+}
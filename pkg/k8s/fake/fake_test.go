@@ -0,0 +1,228 @@
+package fake
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/version"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/celestiaorg/knuu/pkg/k8s"
+)
+
+func TestNewCreatesNamespace(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	c, err := New(ctx, "Test_Scope")
+	require.NoError(t, err)
+	assert.Equal(t, "test-scope", c.Namespace())
+	assert.True(t, c.NamespaceExists(ctx, "test-scope"))
+}
+
+func TestRunCommandInPod(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "test"}}
+	c, err := New(ctx, "test", pod)
+	require.NoError(t, err)
+
+	c.FakeExecutor.Handler = func(cmd Command) (string, string, error) {
+		switch cmd.Command[0] {
+		case "echo":
+			return cmd.Command[1], "", nil
+		case "ls":
+			return "", "no such file", nil
+		}
+		return "", "", errors.New("not found")
+	}
+
+	out, err := c.RunCommandInPod(ctx, "app", "app", []string{"echo", "hello"})
+	require.NoError(t, err)
+	assert.Equal(t, "hello", out)
+
+	_, err = c.RunCommandInPod(ctx, "app", "app", []string{"ls", "/missing"})
+	assert.ErrorIs(t, err, k8s.ErrCommandExecution)
+
+	// the commands only run in the existing pods
+	_, err = c.RunCommandInPod(ctx, "missing", "app", []string{"echo", "hello"})
+	assert.ErrorIs(t, err, k8s.ErrGettingPod)
+
+	require.Len(t, c.FakeExecutor.Commands(), 2)
+	assert.Equal(t, Command{Namespace: "test", Pod: "app", Container: "app", Command: []string{"echo", "hello"}}, c.FakeExecutor.Commands()[0])
+
+	require.NoError(t, c.PortForwardPod(ctx, "app", 8080, 80))
+	assert.Equal(t, []PortForward{{Namespace: "test", Pod: "app", LocalPort: 8080, RemotePort: 80}}, c.FakeExecutor.PortForwards())
+}
+
+func TestResourcesAreStored(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	c, err := New(ctx, "test")
+	require.NoError(t, err)
+
+	require.NoError(t, c.CreateEgressFirewall(ctx, "app-egress", map[string]string{"app": "app"}, []string{"203.0.113.0/24"}))
+	np, err := c.FakeClientset.NetworkingV1().NetworkPolicies("test").Get(ctx, "app-egress", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "203.0.113.0/24", np.Spec.Egress[1].To[0].IPBlock.CIDR)
+
+	// the firewall is replaced when it is created again
+	require.NoError(t, c.CreateEgressFirewall(ctx, "app-egress", map[string]string{"app": "app"}, nil))
+	np, err = c.FakeClientset.NetworkingV1().NetworkPolicies("test").Get(ctx, "app-egress", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Len(t, np.Spec.Egress, 1)
+
+	_, err = c.DeployService(ctx, k8s.ServiceConfig{Name: "app", Ports: []k8s.ServicePort{{Name: "tcp-8080", Port: 9000}, {Port: 8080}}})
+	assert.ErrorIs(t, err, k8s.ErrPreparingService)
+	assert.ErrorContains(t, err, "tcp-8080")
+}
+
+func TestDeleteClusterRolesBySelector(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	c, err := New(ctx, "test")
+	require.NoError(t, err)
+
+	scope := map[string]string{"knuu.sh/scope": "test"}
+	require.NoError(t, c.CreateClusterRole(ctx, "app-kubeletstats", scope, nil))
+	require.NoError(t, c.CreateClusterRoleBinding(ctx, "app-kubeletstats", scope, "app-kubeletstats", "app"))
+	require.NoError(t, c.CreateClusterRole(ctx, "other", map[string]string{"knuu.sh/scope": "other"}, nil))
+	require.NoError(t, c.CreateClusterRoleBinding(ctx, "other", nil, "other", "other"))
+
+	require.NoError(t, c.DeleteClusterRoleBindings(ctx, scope))
+	require.NoError(t, c.DeleteClusterRoles(ctx, scope))
+
+	roles, err := c.FakeClientset.RbacV1().ClusterRoles().List(ctx, metav1.ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, roles.Items, 1)
+	assert.Equal(t, "other", roles.Items[0].Name)
+	bindings, err := c.FakeClientset.RbacV1().ClusterRoleBindings().List(ctx, metav1.ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, bindings.Items, 1)
+	assert.Equal(t, "other", bindings.Items[0].Name)
+}
+
+func TestCapabilities(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	c, err := New(ctx, "test")
+	require.NoError(t, err)
+	c.AddAPIResources("gateway.networking.k8s.io/v1", "gateways", "httproutes")
+
+	caps, err := c.Capabilities(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, ServerVersion, caps.GitVersion)
+	assert.True(t, caps.AtLeast(28))
+	assert.True(t, caps.Has(k8s.CapabilityEphemeralContainers))
+	assert.True(t, caps.Has(k8s.CapabilityGatewayAPI))
+	assert.False(t, caps.Has(k8s.CapabilityRoutes))
+	assert.NoError(t, c.RequireCapability(ctx, k8s.CapabilityGatewayAPI))
+
+	err = c.RequireCapability(ctx, k8s.CapabilityMetrics)
+	assert.ErrorIs(t, err, k8s.ErrCapabilityNotSupported)
+	assert.ErrorContains(t, err, "install the metrics server")
+}
+
+func TestCapabilitiesOfOldClusters(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "test"}}
+	c, err := New(ctx, "test", pod)
+	require.NoError(t, err)
+	// a managed 1.24 cluster without ephemeral containers
+	c.FakeClientset.Resources = nil
+	c.AddAPIResources("v1", "pods")
+	c.FakeClientset.Discovery().(*fakediscovery.FakeDiscovery).FakedServerVersion = &version.Info{Major: "1", Minor: "24+", GitVersion: "v1.24.17-eks-1"}
+
+	caps, err := c.Capabilities(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 24, caps.Minor)
+	assert.False(t, caps.AtLeast(25))
+
+	_, err = c.AddEphemeralContainer(ctx, "app", k8s.EphemeralContainerConfig{Name: "debugger", Image: "busybox"})
+	assert.ErrorIs(t, err, k8s.ErrCapabilityNotSupported)
+	assert.ErrorContains(t, err, "the cluster (v1.24.17-eks-1) does not support EphemeralContainers")
+}
+
+func TestApply(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	// a service whose cluster IP and annotations are set by the cluster and a controller
+	existing := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "app",
+			Namespace:   "test",
+			Annotations: map[string]string{"controller.example.com/managed": "true"},
+		},
+		Spec: corev1.ServiceSpec{ClusterIP: "10.96.0.10", Ports: []corev1.ServicePort{{Name: "tcp-80", Port: 80}}},
+	}
+	c, err := New(ctx, "test", existing)
+	require.NoError(t, err)
+
+	svc, err := c.PatchService(ctx, "app", map[string]string{"app": "app"}, map[string]string{"app": "app"}, []int{8080}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "10.96.0.10", svc.Spec.ClusterIP)
+	assert.Equal(t, "true", svc.Annotations["controller.example.com/managed"])
+	assert.Equal(t, map[string]string{"app": "app"}, svc.Labels)
+
+	// the objects that do not exist are created
+	policy := &networkingv1.NetworkPolicy{ObjectMeta: metav1.ObjectMeta{Name: "deny-all", Namespace: "test"}}
+	_, err = k8s.Apply(ctx, c.FakeClientset.NetworkingV1().NetworkPolicies("test"), policy)
+	require.NoError(t, err)
+	_, err = c.FakeClientset.NetworkingV1().NetworkPolicies("test").Get(ctx, "deny-all", metav1.GetOptions{})
+	require.NoError(t, err)
+
+	var applied []k8stesting.PatchAction
+	for _, action := range c.FakeClientset.Actions() {
+		if patch, ok := action.(k8stesting.PatchAction); ok && patch.GetPatchType() == types.ApplyPatchType {
+			applied = append(applied, patch)
+		}
+	}
+	require.Len(t, applied, 2)
+	assert.Contains(t, string(applied[1].GetPatch()), `"kind":"NetworkPolicy","apiVersion":"networking.k8s.io/v1"`)
+
+	// an existing replica set is not overwritten by its creation, only by its replacement
+	labels := map[string]string{"app": "app"}
+	rsConfig := k8s.ReplicaSetConfig{Name: "app", Labels: labels, Replicas: 1, PodConfig: k8s.PodConfig{
+		Name: "app", Labels: labels, ContainerConfig: k8s.ContainerConfig{Name: "app", Image: "alpine"},
+	}}
+	_, err = c.CreateReplicaSet(ctx, rsConfig, false)
+	require.NoError(t, err)
+	_, err = c.CreateReplicaSet(ctx, rsConfig, false)
+	assert.ErrorIs(t, err, k8s.ErrCreatingReplicaSet)
+	assert.ErrorContains(t, err, "already exists")
+	_, err = c.ReplaceReplicaSet(ctx, rsConfig)
+	require.NoError(t, err)
+}
+
+func TestDebugNodeNotRunning(t *testing.T) {
+	t.Parallel()
+
+	c, err := New(context.Background(), "test")
+	require.NoError(t, err)
+
+	// the pod is never running on the fake, the privileged pod is deleted once the wait fails
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	_, err = c.DebugNode(ctx, "node-1", "busybox")
+	assert.ErrorIs(t, err, k8s.ErrWaitingForNodeDebugger)
+
+	pods, err := c.Clientset().CoreV1().Pods("test").List(context.Background(), metav1.ListOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, pods.Items)
+}
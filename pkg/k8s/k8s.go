@@ -7,6 +7,7 @@ import (
 	"path/filepath"
 	"regexp"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/sirupsen/logrus"
@@ -35,10 +36,25 @@ const (
 )
 
 type Client struct {
-	clientset       *kubernetes.Clientset
-	discoveryClient *discovery.DiscoveryClient
+	clientset       kubernetes.Interface
+	discoveryClient discovery.DiscoveryInterface
 	dynamicClient   dynamic.Interface
+	executor        Executor
 	namespace       string
+
+	// capabilities are detected once, see Capabilities
+	capabilitiesMu sync.Mutex
+	capabilities   *Capabilities
+}
+
+// Clients are the clients a Client uses to reach the cluster, see NewWithClients
+type Clients struct {
+	Clientset kubernetes.Interface
+	// Discovery is the discovery client of Clientset if nil
+	Discovery discovery.DiscoveryInterface
+	Dynamic   dynamic.Interface
+	// Executor runs the commands in the containers and forwards the ports of the pods
+	Executor Executor
 }
 
 var _ KubeManager = &Client{}
@@ -65,7 +81,41 @@ func New(ctx context.Context, namespace string) (*Client, error) {
 	if err != nil {
 		return nil, ErrCreatingDynamicClient.Wrap(err)
 	}
-	kc := &Client{clientset: cs, discoveryClient: dc, dynamicClient: dC}
+
+	return NewWithClients(ctx, namespace, Clients{
+		Clientset: cs,
+		Discovery: dc,
+		Dynamic:   dC,
+		Executor:  &spdyExecutor{config: config, clientset: cs},
+	})
+}
+
+// NewClientset returns a clientset of the cluster that is not bound to a namespace, e.g. to inspect a namespace
+// without creating it as New does
+func NewClientset() (kubernetes.Interface, error) {
+	config, err := getClusterConfig()
+	if err != nil {
+		return nil, ErrRetrievingKubernetesConfig.Wrap(err)
+	}
+	cs, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, ErrCreatingClientset.Wrap(err)
+	}
+	return cs, nil
+}
+
+// NewWithClients returns a client using the given clients, e.g. the fakes of the package fake in unit tests
+// The namespace is created if it does not exist.
+func NewWithClients(ctx context.Context, namespace string, clients Clients) (*Client, error) {
+	kc := &Client{
+		clientset:       clients.Clientset,
+		discoveryClient: clients.Discovery,
+		dynamicClient:   clients.Dynamic,
+		executor:        clients.Executor,
+	}
+	if kc.discoveryClient == nil {
+		kc.discoveryClient = clients.Clientset.Discovery()
+	}
 
 	namespace = SanitizeName(namespace)
 	kc.namespace = namespace
@@ -81,7 +131,7 @@ func New(ctx context.Context, namespace string) (*Client, error) {
 	return kc, nil
 }
 
-func (c *Client) Clientset() *kubernetes.Clientset {
+func (c *Client) Clientset() kubernetes.Interface {
 	return c.clientset
 }
 
@@ -93,6 +143,21 @@ func (c *Client) Namespace() string {
 	return c.namespace
 }
 
+// InNamespace returns a client of the given namespace sharing the clients of c, e.g. to reach the workloads knuu
+// did not deploy. Unlike New, the namespace is not created: it must exist.
+func (c *Client) InNamespace(ctx context.Context, namespace string) (KubeManager, error) {
+	if _, err := c.GetNamespace(ctx, namespace); err != nil {
+		return nil, err
+	}
+	return &Client{
+		clientset:       c.clientset,
+		discoveryClient: c.discoveryClient,
+		dynamicClient:   c.dynamicClient,
+		executor:        c.executor,
+		namespace:       namespace,
+	}, nil
+}
+
 // isClusterEnvironment checks if the program is running in a Kubernetes cluster.
 func isClusterEnvironment() bool {
 	return fileExists(tokenPath) && fileExists(certPath)
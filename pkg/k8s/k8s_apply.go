@@ -0,0 +1,61 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/ptr"
+)
+
+// FieldManager is the manager of the fields knuu applies, see Apply
+const FieldManager = "knuu"
+
+// Patcher is the typed client of a kind of resources, e.g. Clientset().CoreV1().Services(namespace)
+type Patcher[T runtime.Object] interface {
+	Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts metav1.PatchOptions, subresources ...string) (T, error)
+}
+
+// Apply creates or updates the given object with server-side apply, knuu owning the fields set in the object
+// The fields set by others, e.g. the cluster IP of a service or the annotations of a controller, are kept
+// and the fields knuu set before but not anymore are removed. The fields owned by others are taken over.
+func Apply[T runtime.Object](ctx context.Context, client Patcher[T], obj T) (T, error) {
+	var zero T
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return zero, ErrApplyingObject.WithParams("").Wrap(err)
+	}
+	name := accessor.GetName()
+
+	// the applied configuration must have its apiVersion and kind, which the typed objects usually lack
+	gvks, _, err := scheme.Scheme.ObjectKinds(obj)
+	if err != nil {
+		return zero, ErrApplyingObject.WithParams(name).Wrap(err)
+	}
+	obj = obj.DeepCopyObject().(T)
+	obj.GetObjectKind().SetGroupVersionKind(gvks[0])
+
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return zero, ErrApplyingObject.WithParams(name).Wrap(err)
+	}
+	applied, err := client.Patch(ctx, name, types.ApplyPatchType, data, metav1.PatchOptions{
+		FieldManager: FieldManager,
+		Force:        ptr.To(true),
+	})
+	if err != nil {
+		return zero, ErrApplyingObject.WithParams(name).Wrap(err)
+	}
+	return applied, nil
+}
+
+// annotationsPatch returns the merge patch adding the given annotations to an object
+func annotationsPatch(annotations map[string]string) ([]byte, error) {
+	return json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{"annotations": annotations},
+	})
+}
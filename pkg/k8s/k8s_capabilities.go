@@ -0,0 +1,155 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// Capability is a feature of the cluster that knuu uses but that not all supported clusters provide
+type Capability string
+
+const (
+	// CapabilityEphemeralContainers allows to attach containers to running pods, GA since kubernetes 1.25
+	CapabilityEphemeralContainers Capability = "EphemeralContainers"
+	// CapabilityGatewayAPI is the Gateway API (gateway.networking.k8s.io/v1), installed separately from kubernetes
+	CapabilityGatewayAPI Capability = "GatewayAPI"
+	// CapabilityMetrics is the resource metrics API (metrics.k8s.io/v1beta1), served by the metrics server
+	CapabilityMetrics Capability = "Metrics"
+	// CapabilityCustomMetrics is the custom metrics API (custom.metrics.k8s.io/v1beta1), served by a metrics adapter
+	CapabilityCustomMetrics Capability = "CustomMetrics"
+	// CapabilityRoutes is the route API of OpenShift (route.openshift.io/v1), served by OpenShift clusters only
+	CapabilityRoutes Capability = "Routes"
+)
+
+// apiCapabilities are the capabilities detected from a resource served by the cluster
+var apiCapabilities = map[Capability]struct {
+	groupVersion string
+	resource     string
+	hint         string
+}{
+	CapabilityEphemeralContainers: {"v1", "pods/ephemeralcontainers", "kubernetes 1.25 or later is required"},
+	CapabilityGatewayAPI:          {"gateway.networking.k8s.io/v1", "gateways", "install the CRDs of the Gateway API v1"},
+	CapabilityMetrics:             {"metrics.k8s.io/v1beta1", "pods", "install the metrics server"},
+	CapabilityRoutes:              {"route.openshift.io/v1", "routes", "routes are only served by OpenShift"},
+	// the custom metrics are the resources of the API, so it is detected from the group version
+	CapabilityCustomMetrics: {"custom.metrics.k8s.io/v1beta1", "", "install a custom metrics adapter, e.g. prometheus-adapter"},
+}
+
+// Capabilities are the version and the capabilities of a cluster
+type Capabilities struct {
+	// GitVersion is the version reported by the cluster, e.g. v1.28.3+k3s1
+	GitVersion string
+	Major      int
+	Minor      int
+
+	available map[Capability]bool
+	// errors are the errors of the discovery of the capabilities that could not be detected, e.g. an aggregated
+	// API whose service is unavailable
+	errors map[Capability]error
+}
+
+// Has returns true if the cluster provides the given capability
+func (c *Capabilities) Has(capability Capability) bool {
+	return c.available[capability]
+}
+
+// AtLeast returns true if the version of the cluster is at least 1.minor
+func (c *Capabilities) AtLeast(minor int) bool {
+	return c.Major > 1 || (c.Major == 1 && c.Minor >= minor)
+}
+
+// Require returns an error describing how to get the given capability if the cluster does not provide it
+func (c *Capabilities) Require(capability Capability) error {
+	if c.Has(capability) {
+		return nil
+	}
+	hint := "unknown capability"
+	if api, ok := apiCapabilities[capability]; ok {
+		hint = api.hint
+	}
+	if err, ok := c.errors[capability]; ok {
+		hint = fmt.Sprintf("%s, the discovery failed: %v", hint, err)
+	}
+	return ErrCapabilityNotSupported.WithParams(c.GitVersion, capability, hint)
+}
+
+// Capabilities detects the version and the capabilities of the cluster
+// The detection queries the discovery API once, the result is reused by the next calls. A capability whose API
+// cannot be discovered, e.g. as its aggregated API is unavailable, is not provided, the others are still detected.
+func (c *Client) Capabilities(ctx context.Context) (*Capabilities, error) {
+	c.capabilitiesMu.Lock()
+	defer c.capabilitiesMu.Unlock()
+	if c.capabilities != nil {
+		return c.capabilities, nil
+	}
+
+	info, err := c.discoveryClient.ServerVersion()
+	if err != nil {
+		return nil, ErrDetectingCapabilities.Wrap(err)
+	}
+	caps := &Capabilities{
+		GitVersion: info.GitVersion,
+		Major:      parseVersionNumber(info.Major),
+		Minor:      parseVersionNumber(info.Minor),
+		available:  make(map[Capability]bool),
+		errors:     make(map[Capability]error),
+	}
+	for capability, api := range apiCapabilities {
+		if ctx.Err() != nil {
+			return nil, ErrDetectingCapabilities.Wrap(ctx.Err())
+		}
+		served, err := c.servesResource(api.groupVersion, api.resource)
+		if err != nil {
+			logrus.Warnf("Cannot detect capability %s from %s, it is considered unavailable: %v", capability, api.groupVersion, err)
+			caps.errors[capability] = err
+		}
+		caps.available[capability] = served
+	}
+	c.capabilities = caps
+	return caps, nil
+}
+
+// RequireCapability returns an error describing how to get the given capability if the cluster does not provide it
+func (c *Client) RequireCapability(ctx context.Context, capability Capability) error {
+	caps, err := c.Capabilities(ctx)
+	if err != nil {
+		return err
+	}
+	return caps.Require(capability)
+}
+
+// servesResource returns true if the cluster serves the given resource of the given group version, or the group
+// version itself if the resource is empty
+func (c *Client) servesResource(groupVersion, resource string) (bool, error) {
+	list, err := c.discoveryClient.ServerResourcesForGroupVersion(groupVersion)
+	if apierrs.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if resource == "" {
+		return true, nil
+	}
+	for _, r := range list.APIResources {
+		if r.Name == resource {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// parseVersionNumber parses the leading digits of a version number, some providers add a suffix, e.g. 28+
+func parseVersionNumber(s string) int {
+	end := strings.IndexFunc(s, func(r rune) bool { return r < '0' || r > '9' })
+	if end >= 0 {
+		s = s[:end]
+	}
+	n, _ := strconv.Atoi(s)
+	return n
+}
@@ -6,22 +6,29 @@ import (
 	"strings"
 
 	"github.com/sirupsen/logrus"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 )
 
+// CreateCustomResource creates the custom resource with the given name and the spec of the given object
+// The kind of the resource is the one of the object if it has one, the resource of gvr otherwise.
 func (c *Client) CreateCustomResource(
 	ctx context.Context,
 	name string,
 	gvr *schema.GroupVersionResource,
 	obj *map[string]interface{},
 ) error {
+	kind := gvr.Resource
+	if k, ok := (*obj)["kind"].(string); ok && k != "" {
+		kind = k
+	}
 
 	resourceUnstructured := &unstructured.Unstructured{
 		Object: map[string]interface{}{
 			"apiVersion": gvr.GroupVersion().String(),
-			"kind":       gvr.Resource,
+			"kind":       kind,
 			"metadata": map[string]interface{}{
 				"name":      name,
 				"namespace": c.namespace,
@@ -30,7 +37,7 @@ func (c *Client) CreateCustomResource(
 		},
 	}
 
-	if _, err := c.dynamicClient.Resource(*gvr).Namespace(c.namespace).Create(context.TODO(), resourceUnstructured, metav1.CreateOptions{}); err != nil {
+	if _, err := c.dynamicClient.Resource(*gvr).Namespace(c.namespace).Create(ctx, resourceUnstructured, metav1.CreateOptions{}); err != nil {
 		return ErrCreatingCustomResource.WithParams(gvr.Resource).Wrap(err)
 	}
 
@@ -38,6 +45,26 @@ func (c *Client) CreateCustomResource(
 	return nil
 }
 
+// GetCustomResource returns the custom resource with the given name
+func (c *Client) GetCustomResource(ctx context.Context, name string, gvr *schema.GroupVersionResource) (*unstructured.Unstructured, error) {
+	resource, err := c.dynamicClient.Resource(*gvr).Namespace(c.namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, ErrGettingCustomResource.WithParams(gvr.Resource, name).Wrap(err)
+	}
+	return resource, nil
+}
+
+// DeleteCustomResource deletes the custom resource with the given name, it succeeds if the resource does not exist
+func (c *Client) DeleteCustomResource(ctx context.Context, name string, gvr *schema.GroupVersionResource) error {
+	err := c.dynamicClient.Resource(*gvr).Namespace(c.namespace).Delete(ctx, name, metav1.DeleteOptions{})
+	if err != nil && !apierrs.IsNotFound(err) {
+		return ErrDeletingCustomResource.WithParams(gvr.Resource, name).Wrap(err)
+	}
+
+	logrus.Debugf("CustomResource %s deleted", name)
+	return nil
+}
+
 func (c *Client) CustomResourceDefinitionExists(ctx context.Context, gvr *schema.GroupVersionResource) bool {
 	resourceList, err := c.discoveryClient.ServerResourcesForGroupVersion(gvr.GroupVersion().String())
 	if err != nil {
@@ -46,7 +73,7 @@ func (c *Client) CustomResourceDefinitionExists(ctx context.Context, gvr *schema
 
 	resourceExists := false
 	for _, resource := range resourceList.APIResources {
-		if strings.EqualFold(resource.Kind, gvr.Resource) {
+		if resource.Name == gvr.Resource || strings.EqualFold(resource.Kind, gvr.Resource) {
 			resourceExists = true
 			break
 		}
@@ -2,25 +2,42 @@ package k8s
 
 import (
 	"context"
-	"time"
 
+	appv1 "k8s.io/api/apps/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	"github.com/celestiaorg/knuu/pkg/retry"
 )
 
 func (c *Client) WaitForDeployment(ctx context.Context, name string) error {
-	for {
+	err := retry.Until(ctx, retry.Constant(waitRetry), func(ctx context.Context) (bool, error) {
 		deployment, err := c.clientset.AppsV1().Deployments(c.namespace).Get(ctx, name, metav1.GetOptions{})
-		if err == nil && deployment.Status.ReadyReplicas > 0 {
-			break
-		}
-
-		select {
-		case <-ctx.Done():
-			return ErrWaitingForDeployment.WithParams(name).Wrap(err)
-		case <-time.After(waitRetry):
-			// Retry after some seconds
-		}
+		return err == nil && deployment.Status.ReadyReplicas > 0, nil
+	})
+	if err != nil {
+		return ErrWaitingForDeployment.WithParams(name).Wrap(err)
 	}
 
 	return nil
 }
+
+// ListDeployments returns the deployments of the namespace matching the given labels
+func (c *Client) ListDeployments(ctx context.Context, selector map[string]string) ([]appv1.Deployment, error) {
+	listOpts := metav1.ListOptions{LabelSelector: labels.SelectorFromSet(selector).String()}
+	deployments, err := c.clientset.AppsV1().Deployments(c.namespace).List(ctx, listOpts)
+	if err != nil {
+		return nil, ErrListingDeployments.Wrap(err)
+	}
+	return deployments.Items, nil
+}
+
+// ListStatefulSets returns the statefulSets of the namespace matching the given labels
+func (c *Client) ListStatefulSets(ctx context.Context, selector map[string]string) ([]appv1.StatefulSet, error) {
+	listOpts := metav1.ListOptions{LabelSelector: labels.SelectorFromSet(selector).String()}
+	statefulSets, err := c.clientset.AppsV1().StatefulSets(c.namespace).List(ctx, listOpts)
+	if err != nil {
+		return nil, ErrListingStatefulSets.Wrap(err)
+	}
+	return statefulSets.Items, nil
+}
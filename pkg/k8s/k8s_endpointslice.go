@@ -0,0 +1,58 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/celestiaorg/knuu/pkg/retry"
+)
+
+// ReadyEndpoints returns the number of ready endpoints of the service with the given name
+// The endpoints are read from the EndpointSlices of the service, an endpoint without readiness is counted as ready.
+func (c *Client) ReadyEndpoints(ctx context.Context, service string) (int, error) {
+	slices, err := c.clientset.DiscoveryV1().EndpointSlices(c.namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: discoveryv1.LabelServiceName + "=" + service,
+	})
+	if err != nil {
+		return 0, ErrGettingEndpointSlices.WithParams(service).Wrap(err)
+	}
+	return countReadyEndpoints(slices.Items), nil
+}
+
+// WaitForEndpoints waits until the service with the given name has at least minReady ready endpoints
+func (c *Client) WaitForEndpoints(ctx context.Context, service string, minReady int) error {
+	err := retry.Until(ctx, retry.Constant(waitRetry), func(ctx context.Context) (bool, error) {
+		ready, err := c.ReadyEndpoints(ctx, service)
+		if err != nil {
+			return false, err
+		}
+		return ready >= minReady, nil
+	})
+	if errors.Is(err, retry.ErrContextDone) {
+		return ErrTimeoutWaitingForEndpoints.WithParams(minReady, service).Wrap(err)
+	}
+	return err
+}
+
+// countReadyEndpoints counts the ready endpoints of the slices
+// A pod with IPv4 and IPv6 addresses has an endpoint in a slice of each address type, so it is only counted once.
+func countReadyEndpoints(slices []discoveryv1.EndpointSlice) int {
+	ready := make(map[string]bool)
+	for _, slice := range slices {
+		for _, endpoint := range slice.Endpoints {
+			if endpoint.Conditions.Ready != nil && !*endpoint.Conditions.Ready {
+				continue
+			}
+			switch {
+			case endpoint.TargetRef != nil:
+				ready[endpoint.TargetRef.Kind+"/"+endpoint.TargetRef.Name] = true
+			case len(endpoint.Addresses) > 0:
+				ready[endpoint.Addresses[0]] = true
+			}
+		}
+	}
+	return len(ready)
+}
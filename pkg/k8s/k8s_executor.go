@@ -0,0 +1,131 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/portforward"
+	"k8s.io/client-go/tools/remotecommand"
+	"k8s.io/client-go/transport/spdy"
+)
+
+// Executor runs the commands in the containers and forwards the ports of the pods
+// These operations stream data from the kubelets, so they are not served by the clientset.
+type Executor interface {
+	// Exec runs the command in the container and writes its output streams to stdout and stderr
+	// stdin is streamed to the input of the command if not nil.
+	Exec(ctx context.Context, namespace, podName, containerName string, cmd []string, stdin io.Reader, stdout, stderr io.Writer) error
+	// PortForward forwards the local port to the port of the pod, it returns once the forwarding is ready
+	PortForward(ctx context.Context, namespace, podName string, localPort, remotePort int) error
+}
+
+// spdyExecutor streams the commands and the forwarded ports over SPDY connections to the API server
+type spdyExecutor struct {
+	config    *rest.Config
+	clientset kubernetes.Interface
+}
+
+var _ Executor = &spdyExecutor{}
+
+func (e *spdyExecutor) Exec(ctx context.Context, namespace, podName, containerName string, cmd []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	req := e.clientset.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Name(podName).
+		Namespace(namespace).
+		SubResource("exec").
+		VersionedParams(&v1.PodExecOptions{
+			Command:   cmd,
+			Container: containerName,
+			Stdin:     stdin != nil,
+			Stdout:    true,
+			Stderr:    true,
+			TTY:       false,
+		}, scheme.ParameterCodec)
+
+	// Create an executor for the command execution
+	exec, err := remotecommand.NewSPDYExecutor(e.config, "POST", req.URL())
+	if err != nil {
+		return ErrCreatingExecutor.Wrap(err)
+	}
+
+	err = exec.StreamWithContext(ctx, remotecommand.StreamOptions{
+		Stdin:  stdin,
+		Stdout: stdout,
+		Stderr: stderr,
+		Tty:    false,
+	})
+	if err != nil {
+		return ErrExecutingCommand.Wrap(err)
+	}
+	return nil
+}
+
+func (e *spdyExecutor) PortForward(ctx context.Context, namespace, podName string, localPort, remotePort int) error {
+	url := e.clientset.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Namespace(namespace).
+		Name(podName).
+		SubResource("portforward").
+		URL()
+
+	transport, upgrader, err := spdy.RoundTripperFor(e.config)
+	if err != nil {
+		return ErrCreatingRoundTripper.Wrap(err)
+	}
+
+	dialer := spdy.NewDialer(upgrader, &http.Client{Transport: transport}, "POST", url)
+
+	ports := []string{fmt.Sprintf("%d:%d", localPort, remotePort)}
+
+	stopChan := make(chan struct{}, 1)
+	readyChan := make(chan struct{})
+
+	var stdout, stderr io.Writer
+	// Create a new PortForwarder
+	pf, err := portforward.New(dialer, ports, stopChan, readyChan, stdout, stderr)
+	if err != nil {
+		return ErrCreatingPortForwarder.Wrap(err)
+	}
+	if stderr != nil {
+		return ErrPortForwarding.WithParams(stderr)
+	}
+	logrus.Debugf("Port forwarding from %d to %d", localPort, remotePort)
+	logrus.Debugf("Port forwarding stdout: %v", stdout)
+
+	errChan := make(chan error)
+
+	// Start the port forwarding
+	go func() {
+		if err := pf.ForwardPorts(); err != nil {
+			errChan <- err
+		} else {
+			close(errChan) // if there's no error, close the channel
+		}
+	}()
+
+	// Wait for the port forwarding to be ready or error to occur
+	select {
+	case <-readyChan:
+		// Ready to forward
+		logrus.Debugf("Port forwarding ready from %d to %d", localPort, remotePort)
+	case err := <-errChan:
+		// if there's an error, return it
+		return ErrForwardingPorts.Wrap(err)
+	case <-time.After(time.Second * 5):
+		close(stopChan)
+		return ErrPortForwardingTimeout
+	case <-ctx.Done():
+		close(stopChan)
+		return ErrPortForwardingCancelled.Wrap(ctx.Err())
+	}
+
+	return nil
+}
@@ -0,0 +1,51 @@
+package k8s
+
+import (
+	"context"
+
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// CreateHorizontalPodAutoscaler creates a HorizontalPodAutoscaler with the given spec
+func (c *Client) CreateHorizontalPodAutoscaler(
+	ctx context.Context,
+	name string,
+	labels map[string]string,
+	spec autoscalingv2.HorizontalPodAutoscalerSpec,
+) (*autoscalingv2.HorizontalPodAutoscaler, error) {
+	hpa := &autoscalingv2.HorizontalPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: c.namespace,
+			Labels:    labels,
+		},
+		Spec: spec,
+	}
+
+	created, err := c.clientset.AutoscalingV2().HorizontalPodAutoscalers(c.namespace).Create(ctx, hpa, metav1.CreateOptions{})
+	if err != nil {
+		return nil, ErrCreatingHorizontalPodAutoscaler.WithParams(name).Wrap(err)
+	}
+	return created, nil
+}
+
+// GetHorizontalPodAutoscaler returns the HorizontalPodAutoscaler with the given name
+func (c *Client) GetHorizontalPodAutoscaler(ctx context.Context, name string) (*autoscalingv2.HorizontalPodAutoscaler, error) {
+	hpa, err := c.clientset.AutoscalingV2().HorizontalPodAutoscalers(c.namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, ErrGettingHorizontalPodAutoscaler.WithParams(name).Wrap(err)
+	}
+	return hpa, nil
+}
+
+// DeleteHorizontalPodAutoscaler deletes the HorizontalPodAutoscaler with the given name, it succeeds if it does
+// not exist
+func (c *Client) DeleteHorizontalPodAutoscaler(ctx context.Context, name string) error {
+	err := c.clientset.AutoscalingV2().HorizontalPodAutoscalers(c.namespace).Delete(ctx, name, metav1.DeleteOptions{})
+	if err != nil && !apierrs.IsNotFound(err) {
+		return ErrDeletingHorizontalPodAutoscaler.WithParams(name).Wrap(err)
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package k8s
+
+import (
+	"context"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// podMetricsResource is the resource served by the metrics server for the usage of the pods
+var podMetricsResource = schema.GroupVersionResource{Group: "metrics.k8s.io", Version: "v1beta1", Resource: "pods"}
+
+// GetPodUsage returns the CPU and memory currently used by all the containers of the pod
+// The usage is reported by the metrics server, it fails on the clusters that do not run one.
+func (c *Client) GetPodUsage(ctx context.Context, podName string) (v1.ResourceList, error) {
+	if err := c.RequireCapability(ctx, CapabilityMetrics); err != nil {
+		return nil, err
+	}
+	metrics, err := c.dynamicClient.Resource(podMetricsResource).Namespace(c.namespace).Get(ctx, podName, metav1.GetOptions{})
+	if err != nil {
+		return nil, ErrGettingPodUsage.WithParams(podName).Wrap(err)
+	}
+	containers, _, err := unstructured.NestedSlice(metrics.Object, "containers")
+	if err != nil {
+		return nil, ErrGettingPodUsage.WithParams(podName).Wrap(err)
+	}
+
+	usage := v1.ResourceList{}
+	for _, container := range containers {
+		fields, ok := container.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		values, _, err := unstructured.NestedStringMap(fields, "usage")
+		if err != nil {
+			return nil, ErrGettingPodUsage.WithParams(podName).Wrap(err)
+		}
+		for name, value := range values {
+			q, err := resource.ParseQuantity(value)
+			if err != nil {
+				return nil, ErrGettingPodUsage.WithParams(podName).Wrap(err)
+			}
+			total := usage[v1.ResourceName(name)]
+			total.Add(q)
+			usage[v1.ResourceName(name)] = total
+		}
+	}
+	return usage, nil
+}
@@ -8,12 +8,26 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+// CreateNetworkPolicy creates or updates a network policy only allowing the traffic of the pods matching the selector
+// from and to the pods matching the ingress and egress selectors, nil to deny all the traffic in that direction
 func (c *Client) CreateNetworkPolicy(
 	ctx context.Context,
 	name string,
 	selectorMap,
 	ingressSelectorMap,
 	egressSelectorMap map[string]string,
+) error {
+	return c.CreateNetworkPolicyWithLabels(ctx, name, nil, selectorMap, ingressSelectorMap, egressSelectorMap)
+}
+
+// CreateNetworkPolicyWithLabels creates or updates the network policy like CreateNetworkPolicy, with the given labels
+func (c *Client) CreateNetworkPolicyWithLabels(
+	ctx context.Context,
+	name string,
+	labels,
+	selectorMap,
+	ingressSelectorMap,
+	egressSelectorMap map[string]string,
 ) error {
 	var ingress []v1.NetworkPolicyIngressRule
 	if ingressSelectorMap != nil {
@@ -49,6 +63,7 @@ func (c *Client) CreateNetworkPolicy(
 		ObjectMeta: metav1.ObjectMeta{
 			Namespace: c.namespace,
 			Name:      name,
+			Labels:    labels,
 		},
 		Spec: v1.NetworkPolicySpec{
 			PodSelector: metav1.LabelSelector{
@@ -63,7 +78,7 @@ func (c *Client) CreateNetworkPolicy(
 		},
 	}
 
-	_, err := c.clientset.NetworkingV1().NetworkPolicies(c.namespace).Create(ctx, np, metav1.CreateOptions{})
+	_, err := Apply(ctx, c.clientset.NetworkingV1().NetworkPolicies(c.namespace), np)
 	if err != nil {
 		return ErrCreatingNetworkPolicy.WithParams(name).Wrap(err)
 	}
@@ -71,6 +86,48 @@ func (c *Client) CreateNetworkPolicy(
 	return nil
 }
 
+// CreateEgressFirewall creates or replaces a network policy only allowing the pods matching the selector
+// to reach the pods of the cluster and the given CIDRs, their incoming traffic is not restricted
+func (c *Client) CreateEgressFirewall(ctx context.Context, name string, selectorMap map[string]string, allowCIDRs []string) error {
+	egress := []v1.NetworkPolicyEgressRule{
+		{
+			// an empty namespace selector matches the pods of all the namespaces, e.g. the DNS servers
+			To: []v1.NetworkPolicyPeer{
+				{
+					NamespaceSelector: &metav1.LabelSelector{},
+				},
+			},
+		},
+	}
+	if len(allowCIDRs) > 0 {
+		peers := make([]v1.NetworkPolicyPeer, 0, len(allowCIDRs))
+		for _, cidr := range allowCIDRs {
+			peers = append(peers, v1.NetworkPolicyPeer{IPBlock: &v1.IPBlock{CIDR: cidr}})
+		}
+		egress = append(egress, v1.NetworkPolicyEgressRule{To: peers})
+	}
+
+	np := &v1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: c.namespace,
+			Name:      name,
+		},
+		Spec: v1.NetworkPolicySpec{
+			PodSelector: metav1.LabelSelector{
+				MatchLabels: selectorMap,
+			},
+			PolicyTypes: []v1.PolicyType{v1.PolicyTypeEgress},
+			Egress:      egress,
+		},
+	}
+
+	_, err := Apply(ctx, c.clientset.NetworkingV1().NetworkPolicies(c.namespace), np)
+	if err != nil {
+		return ErrCreatingNetworkPolicy.WithParams(name).Wrap(err)
+	}
+	return nil
+}
+
 func (c *Client) DeleteNetworkPolicy(ctx context.Context, name string) error {
 	err := c.clientset.NetworkingV1().NetworkPolicies(c.namespace).Delete(ctx, name, metav1.DeleteOptions{})
 	if err != nil {
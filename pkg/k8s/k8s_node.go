@@ -0,0 +1,88 @@
+package k8s
+
+import (
+	"context"
+
+	"github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/celestiaorg/knuu/pkg/names"
+	"github.com/celestiaorg/knuu/pkg/retry"
+)
+
+const (
+	nodeDebuggerPrefix = "node-debugger"
+	// NodeDebuggerHostPath is the path the root filesystem of the node is mounted at in a node debugger pod
+	NodeDebuggerHostPath = "/host"
+)
+
+// DebugNode starts a privileged pod on the given node that shares the namespaces of the host, like kubectl debug node
+// The root filesystem of the node is mounted at NodeDebuggerHostPath. It waits until the pod is running,
+// commands are run in its container, named like the pod, with RunCommandInPod and it must be deleted with DeletePod.
+// The pod is deleted if it fails to run.
+func (c *Client) DebugNode(ctx context.Context, nodeName, image string) (*v1.Pod, error) {
+	name, err := names.NewRandomK8(nodeDebuggerPrefix)
+	if err != nil {
+		return nil, ErrGeneratingNodeDebuggerName.Wrap(err)
+	}
+
+	privileged := true
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: c.namespace,
+			Name:      name,
+			Labels:    map[string]string{"knuu.sh/type": nodeDebuggerPrefix},
+		},
+		Spec: v1.PodSpec{
+			NodeName:      nodeName,
+			HostPID:       true,
+			HostIPC:       true,
+			HostNetwork:   true,
+			RestartPolicy: v1.RestartPolicyNever,
+			// the debugger must be able to run on nodes that repel the other pods
+			Tolerations: []v1.Toleration{{Operator: v1.TolerationOpExists}},
+			Containers: []v1.Container{{
+				Name:            name,
+				Image:           image,
+				Command:         []string{"/bin/sh", "-c", "while true; do sleep 3600; done"},
+				SecurityContext: &v1.SecurityContext{Privileged: &privileged},
+				VolumeMounts:    []v1.VolumeMount{{Name: "host-root", MountPath: NodeDebuggerHostPath}},
+			}},
+			Volumes: []v1.Volume{{
+				Name:         "host-root",
+				VolumeSource: v1.VolumeSource{HostPath: &v1.HostPathVolumeSource{Path: "/"}},
+			}},
+		},
+	}
+	if _, err := c.clientset.CoreV1().Pods(c.namespace).Create(ctx, pod, metav1.CreateOptions{}); err != nil {
+		return nil, ErrCreatingNodeDebugger.WithParams(nodeName).Wrap(err)
+	}
+
+	var running *v1.Pod
+	err = retry.Until(ctx, retry.Constant(waitRetry), func(ctx context.Context) (bool, error) {
+		p, err := c.getPod(ctx, name)
+		if err != nil {
+			return false, nil
+		}
+		running = p
+		return p.Status.Phase == v1.PodRunning, nil
+	})
+	if err != nil {
+		// the privileged pod must not outlive the failed debugging session
+		if err := c.DeletePod(context.WithoutCancel(ctx), name); err != nil {
+			logrus.Warnf("Error deleting the node debugger '%s': %v", name, err)
+		}
+		return nil, ErrWaitingForNodeDebugger.WithParams(name, nodeName).Wrap(err)
+	}
+	return running, nil
+}
+
+// GetNode returns the node with the given name
+func (c *Client) GetNode(ctx context.Context, name string) (*v1.Node, error) {
+	node, err := c.clientset.CoreV1().Nodes().Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, ErrGettingNode.WithParams(name).Wrap(err)
+	}
+	return node, nil
+}
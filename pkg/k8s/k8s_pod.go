@@ -3,22 +3,23 @@ package k8s
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
-	"net/http"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/sirupsen/logrus"
 	v1 "k8s.io/api/core/v1"
-	apierrs "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/client-go/kubernetes/scheme"
-	"k8s.io/client-go/tools/portforward"
-	"k8s.io/client-go/tools/remotecommand"
-	"k8s.io/client-go/transport/spdy"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+
+	"github.com/celestiaorg/knuu/pkg/retry"
 )
 
 // the loops that keep checking something and wait for it to be done
@@ -30,21 +31,44 @@ const (
 	knuuPath = "/knuu"
 )
 
+// fetchImage is the image of the init container that downloads the remote files, it contains curl and sha256sum
+const fetchImage = "docker.io/curlimages/curl:8.7.1"
+
 type ContainerConfig struct {
 	Name            string              // Name to assign to the Container
 	Image           string              // Name of the container image to use for the container
 	Command         []string            // Command to run in the container
 	Args            []string            // Arguments to pass to the command in the container
+	WorkingDir      string              // Working directory of the command, the one of the image if empty
+	TTY             bool                // TTY allocates a terminal for the container
+	Stdin           bool                // Stdin keeps the stdin of the container open, e.g. to attach to it
 	Env             map[string]string   // Environment variables to set in the container
+	FieldEnv        map[string]string   // Environment variables set from fields of the Pod, e.g. spec.nodeName
 	Volumes         []*Volume           // Volumes to mount in the Pod
 	MemoryRequest   string              // Memory request for the container
 	MemoryLimit     string              // Memory limit for the container
 	CPURequest      string              // CPU request for the container
+	CPULimit        string              // CPU limit for the container
 	LivenessProbe   *v1.Probe           // Liveness probe for the container
 	ReadinessProbe  *v1.Probe           // Readiness probe for the container
 	StartupProbe    *v1.Probe           // Startup probe for the container
 	Files           []*File             // Files to add to the Pod
 	SecurityContext *v1.SecurityContext // Security context for the container
+	Ports           []v1.ContainerPort  // Ports declared by the container, e.g. the ones bound on the node
+	RemoteFiles     []*RemoteFile       // Files downloaded into the volumes of the Pod when it is initialized
+	SharedDirs      []*SharedDir        // Empty directories shared with the other containers of the Pod
+	Secrets         []*SecretMount      // Secrets mounted in the container
+}
+
+// EphemeralContainerConfig is the configuration of a container added to a running pod
+type EphemeralContainerConfig struct {
+	Name            string              // Name to assign to the Container
+	Image           string              // Name of the container image to use for the container
+	Command         []string            // Command to run in the container
+	Args            []string            // Arguments to pass to the command in the container
+	Env             map[string]string   // Environment variables to set in the container
+	TargetContainer string              // Container whose process namespace is shared, none if empty
+	SecurityContext *v1.SecurityContext // Security context for the container
 }
 
 type PodConfig struct {
@@ -56,6 +80,21 @@ type PodConfig struct {
 	ContainerConfig    ContainerConfig   // ContainerConfig for the Pod
 	SidecarConfigs     []ContainerConfig // SideCarConfigs for the Pod
 	Annotations        map[string]string // Annotations to apply to the Pod
+	RestartPolicy      v1.RestartPolicy  // RestartPolicy of the containers of the Pod, kubernetes defaults to Always
+	DNSPolicy          v1.DNSPolicy      // DNSPolicy of the Pod, kubernetes defaults to ClusterFirst
+	DNSConfig          *v1.PodDNSConfig  // DNSConfig is merged with the DNS policy, nil if not set
+	HostNetwork        bool              // HostNetwork makes the Pod use the network namespace of its node
+	ShareProcesses     bool              // ShareProcesses makes the containers of the Pod share a process namespace
+
+	// ReadinessGates are the types of the conditions that must be true, in addition to the readiness of the
+	// containers, for the Pod to be ready, see SetPodCondition
+	ReadinessGates []v1.PodConditionType
+
+	// TopologySpreadConstraints spread the Pod and the Pods it selects across the domains of the nodes, e.g. the zones
+	TopologySpreadConstraints []v1.TopologySpreadConstraint
+
+	// Tolerations allow the Pod to be scheduled on the nodes with matching taints, e.g. the spot nodes
+	Tolerations []v1.Toleration
 }
 
 type Volume struct {
@@ -64,11 +103,31 @@ type Volume struct {
 	Owner int64
 }
 
+// SharedDir is an emptyDir volume of the Pod mounted in each container that has a SharedDir of the same name,
+// e.g. to let a sidecar read the files written by the main container
+type SharedDir struct {
+	Name string
+	Path string
+}
+
+// SecretMount mounts the keys of a secret as files of a directory, read only
+type SecretMount struct {
+	Secret string
+	Path   string
+}
+
 type File struct {
 	Source string
 	Dest   string
 }
 
+// RemoteFile is a file downloaded by an init container, it is used for the files too large for a ConfigMap
+type RemoteFile struct {
+	URL    string
+	Dest   string
+	SHA256 string // hex encoded checksum of the file, it is not verified if empty
+}
+
 // DeployPod creates a new pod in the namespace that k8s client is initiate with if it doesn't already exist.
 func (c *Client) DeployPod(ctx context.Context, podConfig PodConfig, init bool) (*v1.Pod, error) {
 	pod, err := preparePod(podConfig, init)
@@ -106,25 +165,16 @@ func (c *Client) ReplacePodWithGracePeriod(ctx context.Context, podConfig PodCon
 	}
 
 	// Wait for the pod to be fully deleted
-PodCheckLoop:
-	for {
-		select {
-		case <-ctx.Done():
-			logrus.Errorf("Context cancelled while waiting for pod %s to delete", podConfig.Name)
-			return nil, ctx.Err()
-		case <-time.After(retryInterval):
-			_, err := c.getPod(ctx, podConfig.Name)
-			if err != nil {
-				if apierrs.IsNotFound(err) {
-					logrus.Debugf("Pod %s successfully deleted", podConfig.Name)
-					goto DeployPod
-				}
-				break PodCheckLoop
-			}
-		}
+	err := retry.Until(ctx, retry.Constant(retryInterval), func(ctx context.Context) (bool, error) {
+		_, err := c.getPod(ctx, podConfig.Name)
+		return err != nil, nil
+	})
+	if err != nil {
+		logrus.Errorf("Context cancelled while waiting for pod %s to delete", podConfig.Name)
+		return nil, ErrWaitingForPodDeletion.WithParams(podConfig.Name).Wrap(err)
 	}
+	logrus.Debugf("Pod %s successfully deleted", podConfig.Name)
 
-DeployPod:
 	// Deploy the new pod
 	pod, err := c.DeployPod(ctx, podConfig, false)
 	if err != nil {
@@ -168,48 +218,110 @@ func (c *Client) RunCommandInPod(
 		return "", ErrGettingPod.WithParams(podName).Wrap(err)
 	}
 
-	req := c.clientset.CoreV1().RESTClient().Post().
-		Resource("pods").
-		Name(podName).
-		Namespace(c.namespace).
-		SubResource("exec").
-		VersionedParams(&v1.PodExecOptions{
-			Command:   cmd,
-			Container: containerName,
-			Stdin:     false,
-			Stdout:    true,
-			Stderr:    true,
-			TTY:       false,
-		}, scheme.ParameterCodec)
-
-	// Create an executor for the command execution
-	k8sConfig, err := getClusterConfig()
+	// Execute the command and capture the output and error streams
+	var stdout, stderr bytes.Buffer
+	if err := c.executor.Exec(ctx, c.namespace, podName, containerName, cmd, nil, &stdout, &stderr); err != nil {
+		return "", err
+	}
+
+	// Check if there were any errors on the error stream
+	if stderr.Len() != 0 {
+		return "", ErrCommandExecution.WithParams(stderr.String())
+	}
+
+	return stdout.String(), nil
+}
+
+// StreamCommandInPod runs the command in the container and streams its output to stdout, e.g. to download large outputs
+// Unlike RunCommandInPod the output is not buffered, and the stderr of the command is only reported if the command fails.
+func (c *Client) StreamCommandInPod(ctx context.Context, podName, containerName string, cmd []string, stdout io.Writer) error {
+	_, err := c.getPod(ctx, podName)
 	if err != nil {
-		return "", ErrGettingK8sConfig.Wrap(err)
+		return ErrGettingPod.WithParams(podName).Wrap(err)
 	}
-	exec, err := remotecommand.NewSPDYExecutor(k8sConfig, "POST", req.URL())
+
+	var stderr bytes.Buffer
+	if err := c.executor.Exec(ctx, c.namespace, podName, containerName, cmd, nil, stdout, &stderr); err != nil {
+		if stderr.Len() != 0 {
+			return ErrCommandExecution.WithParams(stderr.String()).Wrap(err)
+		}
+		return err
+	}
+	return nil
+}
+
+// StreamCommandToPod runs the command in the container with stdin as its input, e.g. to upload an archive
+// The output of the command is discarded, its stderr is only reported if the command fails.
+func (c *Client) StreamCommandToPod(ctx context.Context, podName, containerName string, cmd []string, stdin io.Reader) error {
+	_, err := c.getPod(ctx, podName)
 	if err != nil {
-		return "", ErrCreatingExecutor.Wrap(err)
+		return ErrGettingPod.WithParams(podName).Wrap(err)
 	}
 
-	// Execute the command and capture the output and error streams
-	var stdout, stderr bytes.Buffer
-	err = exec.StreamWithContext(ctx, remotecommand.StreamOptions{
-		Stdout: &stdout,
-		Stderr: &stderr,
-		Tty:    false,
-	})
+	var stderr bytes.Buffer
+	if err := c.executor.Exec(ctx, c.namespace, podName, containerName, cmd, stdin, io.Discard, &stderr); err != nil {
+		if stderr.Len() != 0 {
+			return ErrCommandExecution.WithParams(stderr.String()).Wrap(err)
+		}
+		return err
+	}
+	return nil
+}
+
+// StreamPodLogs returns a stream of the logs of a container within a pod.
+// If follow is true, the stream stays open until the container stops or the context is cancelled.
+func (c *Client) StreamPodLogs(ctx context.Context, podName, containerName string, follow bool) (io.ReadCloser, error) {
+	return c.StreamPodLogsSince(ctx, podName, containerName, time.Time{}, follow)
+}
 
+// StreamPodLogsSince returns a stream of the logs of a container within a pod logged at or after the given time,
+// all of them if it is zero, each line prefixed with the RFC3339Nano time it was logged at and a space
+// The time is truncated to the second by the API server, so the lines logged in the same second before it are
+// streamed too.
+func (c *Client) StreamPodLogsSince(ctx context.Context, podName, containerName string, since time.Time, follow bool) (io.ReadCloser, error) {
+	opts := &v1.PodLogOptions{
+		Container:  containerName,
+		Follow:     follow,
+		Timestamps: true,
+	}
+	if !since.IsZero() {
+		opts.SinceTime = &metav1.Time{Time: since}
+	}
+	req := c.clientset.CoreV1().Pods(c.namespace).GetLogs(podName, opts)
+	stream, err := req.Stream(ctx)
 	if err != nil {
-		return "", ErrExecutingCommand.Wrap(err)
+		return nil, ErrStreamingPodLogs.WithParams(containerName, podName).Wrap(err)
 	}
+	return stream, nil
+}
 
-	// Check if there were any errors on the error stream
-	if stderr.Len() != 0 {
-		return "", ErrCommandExecution.WithParams(stderr.String())
+// AddEphemeralContainer adds an ephemeral container to a running pod
+// Ephemeral containers cannot be removed from a pod, they stay until the pod is deleted and are never restarted.
+func (c *Client) AddEphemeralContainer(ctx context.Context, podName string, config EphemeralContainerConfig) (*v1.Pod, error) {
+	if err := c.RequireCapability(ctx, CapabilityEphemeralContainers); err != nil {
+		return nil, err
+	}
+	pod, err := c.getPod(ctx, podName)
+	if err != nil {
+		return nil, err
 	}
 
-	return stdout.String(), nil
+	pod.Spec.EphemeralContainers = append(pod.Spec.EphemeralContainers, v1.EphemeralContainer{
+		EphemeralContainerCommon: v1.EphemeralContainerCommon{
+			Name:            config.Name,
+			Image:           config.Image,
+			Command:         config.Command,
+			Args:            config.Args,
+			Env:             buildEnv(config.Env, nil),
+			SecurityContext: config.SecurityContext,
+		},
+		TargetContainerName: config.TargetContainer,
+	})
+	updated, err := c.clientset.CoreV1().Pods(c.namespace).UpdateEphemeralContainers(ctx, podName, pod, metav1.UpdateOptions{})
+	if err != nil {
+		return nil, ErrAddingEphemeralContainer.WithParams(config.Name, podName).Wrap(err)
+	}
+	return updated, nil
 }
 
 func (c *Client) DeletePodWithGracePeriod(ctx context.Context, name string, gracePeriodSeconds *int64) error {
@@ -244,69 +356,64 @@ func (c *Client) PortForwardPod(
 	if err != nil {
 		return ErrGettingPod.WithParams(podName).Wrap(err)
 	}
+	return c.executor.PortForward(ctx, c.namespace, podName, localPort, remotePort)
+}
 
-	restConfig, err := getClusterConfig()
+// ProxyGetPod sends a GET request to the given HTTP port and path of the pod through the API server,
+// e.g. to read the metrics of a container without exposing or forwarding its port
+func (c *Client) ProxyGetPod(ctx context.Context, podName string, port int, path string) ([]byte, error) {
+	body, err := c.clientset.CoreV1().Pods(c.namespace).ProxyGet("http", podName, strconv.Itoa(port), path, nil).DoRaw(ctx)
 	if err != nil {
-		return ErrGettingClusterConfig.Wrap(err)
+		return nil, ErrProxyingToPod.WithParams(path, port, podName).Wrap(err)
 	}
+	return body, nil
+}
 
-	url := c.clientset.CoreV1().RESTClient().Post().
-		Resource("pods").
-		Namespace(c.namespace).
-		Name(podName).
-		SubResource("portforward").
-		URL()
-
-	transport, upgrader, err := spdy.RoundTripperFor(restConfig)
+// ListPods returns the pods of the namespace matching the given labels
+func (c *Client) ListPods(ctx context.Context, selector map[string]string) ([]v1.Pod, error) {
+	listOpts := metav1.ListOptions{LabelSelector: labels.SelectorFromSet(selector).String()}
+	pods, err := c.clientset.CoreV1().Pods(c.namespace).List(ctx, listOpts)
 	if err != nil {
-		return ErrCreatingRoundTripper.Wrap(err)
+		return nil, ErrListingPods.Wrap(err)
 	}
+	return pods.Items, nil
+}
 
-	dialer := spdy.NewDialer(upgrader, &http.Client{Transport: transport}, "POST", url)
-
-	ports := []string{fmt.Sprintf("%d:%d", localPort, remotePort)}
-
-	stopChan := make(chan struct{}, 1)
-	readyChan := make(chan struct{})
-
-	var stdout, stderr io.Writer
-	// Create a new PortForwarder
-	pf, err := portforward.New(dialer, ports, stopChan, readyChan, stdout, stderr)
+// AnnotatePod adds the given annotations to the pod, the other annotations are kept
+func (c *Client) AnnotatePod(ctx context.Context, name string, annotations map[string]string) error {
+	patch, err := annotationsPatch(annotations)
 	if err != nil {
-		return ErrCreatingPortForwarder.Wrap(err)
+		return ErrAnnotatingPod.WithParams(name).Wrap(err)
 	}
-	if stderr != nil {
-		return ErrPortForwarding.WithParams(stderr)
+	_, err = c.clientset.CoreV1().Pods(c.namespace).Patch(ctx, name, types.MergePatchType, patch, metav1.PatchOptions{})
+	if err != nil {
+		return ErrAnnotatingPod.WithParams(name).Wrap(err)
 	}
-	logrus.Debugf("Port forwarding from %d to %d", localPort, remotePort)
-	logrus.Debugf("Port forwarding stdout: %v", stdout)
-
-	errChan := make(chan error)
-
-	// Start the port forwarding
-	go func() {
-		if err := pf.ForwardPorts(); err != nil {
-			errChan <- err
-		} else {
-			close(errChan) // if there's no error, close the channel
-		}
-	}()
+	return nil
+}
 
-	// Wait for the port forwarding to be ready or error to occur
-	select {
-	case <-readyChan:
-		// Ready to forward
-		logrus.Debugf("Port forwarding ready from %d to %d", localPort, remotePort)
-	case err := <-errChan:
-		// if there's an error, return it
-		return ErrForwardingPorts.Wrap(err)
-	case <-time.After(time.Second * 5):
-		return ErrPortForwardingTimeout
+// SetPodCondition sets the condition of the status of the pod, replacing the condition of the same type, e.g. to
+// flip a readiness gate of the pod
+func (c *Client) SetPodCondition(ctx context.Context, name string, condition v1.PodCondition) error {
+	// the conditions are merged by type, so the other conditions of the pod are kept
+	patch, err := json.Marshal(map[string]interface{}{
+		"status": map[string]interface{}{"conditions": []v1.PodCondition{condition}},
+	})
+	if err != nil {
+		return ErrSettingPodCondition.WithParams(condition.Type, name).Wrap(err)
+	}
+	_, err = c.clientset.CoreV1().Pods(c.namespace).Patch(ctx, name, types.StrategicMergePatchType, patch, metav1.PatchOptions{}, "status")
+	if err != nil {
+		return ErrSettingPodCondition.WithParams(condition.Type, name).Wrap(err)
 	}
-
 	return nil
 }
 
+// GetPod returns the pod with the given name
+func (c *Client) GetPod(ctx context.Context, name string) (*v1.Pod, error) {
+	return c.getPod(ctx, name)
+}
+
 func (c *Client) getPod(ctx context.Context, name string) (*v1.Pod, error) {
 	pod, err := c.clientset.CoreV1().Pods(c.namespace).Get(ctx, name, metav1.GetOptions{})
 	if err != nil {
@@ -316,13 +423,20 @@ func (c *Client) getPod(ctx context.Context, name string) (*v1.Pod, error) {
 	return pod, nil
 }
 
-// buildEnv builds an environment variable configuration for a Pod based on the given map of key-value pairs.
-func buildEnv(envMap map[string]string) []v1.EnvVar {
-	envVars := make([]v1.EnvVar, 0, len(envMap))
+// buildEnv builds an environment variable configuration for a Pod based on the given map of key-value pairs
+// and the map of variables set from the given field paths of the Pod.
+func buildEnv(envMap, fieldEnvMap map[string]string) []v1.EnvVar {
+	envVars := make([]v1.EnvVar, 0, len(envMap)+len(fieldEnvMap))
 	for key, val := range envMap {
 		envVar := v1.EnvVar{Name: key, Value: val}
 		envVars = append(envVars, envVar)
 	}
+	for key, fieldPath := range fieldEnvMap {
+		envVars = append(envVars, v1.EnvVar{
+			Name:      key,
+			ValueFrom: &v1.EnvVarSource{FieldRef: &v1.ObjectFieldSelector{FieldPath: fieldPath}},
+		})
+	}
 	return envVars
 }
 
@@ -371,6 +485,44 @@ func buildPodVolumes(name string, volumesAmount, filesAmount int) ([]v1.Volume,
 	return podVolumes, nil
 }
 
+// buildSharedDirVolumes generates an emptyDir volume for each shared directory of the containers, once per name
+func buildSharedDirVolumes(configs []ContainerConfig) []v1.Volume {
+	var volumes []v1.Volume
+	seen := make(map[string]bool)
+	for _, config := range configs {
+		for _, dir := range config.SharedDirs {
+			if seen[dir.Name] {
+				continue
+			}
+			seen[dir.Name] = true
+			volumes = append(volumes, v1.Volume{
+				Name:         dir.Name,
+				VolumeSource: v1.VolumeSource{EmptyDir: &v1.EmptyDirVolumeSource{}},
+			})
+		}
+	}
+	return volumes
+}
+
+// buildSecretVolumes generates a volume for each secret mounted in the containers, once per secret
+func buildSecretVolumes(configs []ContainerConfig) []v1.Volume {
+	var volumes []v1.Volume
+	seen := make(map[string]bool)
+	for _, config := range configs {
+		for _, secret := range config.Secrets {
+			if seen[secret.Secret] {
+				continue
+			}
+			seen[secret.Secret] = true
+			volumes = append(volumes, v1.Volume{
+				Name:         secret.Secret,
+				VolumeSource: v1.VolumeSource{Secret: &v1.SecretVolumeSource{SecretName: secret.Secret}},
+			})
+		}
+	}
+	return volumes
+}
+
 // buildContainerVolumes generates a volume mount configuration for a container based on the given name and volumes.
 func buildContainerVolumes(name string, volumes []*Volume) ([]v1.VolumeMount, error) {
 	var containerVolumes []v1.VolumeMount
@@ -467,37 +619,39 @@ func buildInitContainerCommand(volumes []*Volume, files []*File) ([]string, erro
 }
 
 // buildResources generates a resource configuration for a container based on the given CPU and memory requests and limits.
-func buildResources(memoryRequest string, memoryLimit string, cpuRequest string) (v1.ResourceRequirements, error) {
-	resources := v1.ResourceRequirements{}
-
-	memoryRequestQuantity, err := resource.ParseQuantity(memoryRequest)
-	if err != nil {
-		if memoryRequest != "" {
-			return resources, ErrParsingMemoryRequest.WithParams(memoryRequest).Wrap(err)
+// The empty ones are not set, e.g. a CPU request without a limit does not limit the CPU of the container.
+func buildResources(memoryRequest, memoryLimit, cpuRequest, cpuLimit string) (v1.ResourceRequirements, error) {
+	resources := v1.ResourceRequirements{
+		Requests: v1.ResourceList{},
+		Limits:   v1.ResourceList{},
+	}
+
+	quantities := []struct {
+		list     v1.ResourceList
+		name     v1.ResourceName
+		value    string
+		parseErr *Error
+	}{
+		{resources.Requests, v1.ResourceMemory, memoryRequest, ErrParsingMemoryRequest},
+		{resources.Limits, v1.ResourceMemory, memoryLimit, ErrParsingMemoryLimit},
+		{resources.Requests, v1.ResourceCPU, cpuRequest, ErrParsingCPURequest},
+		{resources.Limits, v1.ResourceCPU, cpuLimit, ErrParsingCPULimit},
+	}
+	for _, q := range quantities {
+		if q.value == "" {
+			continue
 		}
-	}
-	memoryLimitQuantity, err := resource.ParseQuantity(memoryLimit)
-	if err != nil {
-		if memoryLimit != "" {
-			return resources, ErrParsingMemoryLimit.WithParams(memoryLimit).Wrap(err)
+		quantity, err := resource.ParseQuantity(q.value)
+		if err != nil {
+			return v1.ResourceRequirements{}, q.parseErr.WithParams(q.value).Wrap(err)
 		}
+		q.list[q.name] = quantity
 	}
-	cpuRequestQuantity, err := resource.ParseQuantity(cpuRequest)
-	if err != nil {
-		if cpuRequest != "" {
-			return resources, ErrParsingCPURequest.WithParams(cpuRequest).Wrap(err)
-		}
+	if len(resources.Requests) == 0 {
+		resources.Requests = nil
 	}
-
-	// If a resource is not set it will use the default value of 0 which is the same as not setting it at all.
-	resources = v1.ResourceRequirements{
-		Requests: v1.ResourceList{
-			v1.ResourceMemory: memoryRequestQuantity,
-			v1.ResourceCPU:    cpuRequestQuantity,
-		},
-		Limits: v1.ResourceList{
-			v1.ResourceMemory: memoryLimitQuantity,
-		},
+	if len(resources.Limits) == 0 {
+		resources.Limits = nil
 	}
 
 	return resources, nil
@@ -506,15 +660,28 @@ func buildResources(memoryRequest string, memoryLimit string, cpuRequest string)
 // prepareContainer creates a v1.Container from a given ContainerConfig.
 func prepareContainer(config ContainerConfig) (v1.Container, error) {
 	// Build environment variables from the given map
-	podEnv := buildEnv(config.Env)
+	podEnv := buildEnv(config.Env, config.FieldEnv)
 
 	// Build container volumes from the given map
 	containerVolumes, err := buildContainerVolumes(config.Name, config.Volumes)
 	if err != nil {
 		return v1.Container{}, ErrBuildingContainerVolumes.Wrap(err)
 	}
+	for _, dir := range config.SharedDirs {
+		containerVolumes = append(containerVolumes, v1.VolumeMount{
+			Name:      dir.Name,
+			MountPath: dir.Path,
+		})
+	}
+	for _, secret := range config.Secrets {
+		containerVolumes = append(containerVolumes, v1.VolumeMount{
+			Name:      secret.Secret,
+			MountPath: secret.Path,
+			ReadOnly:  true,
+		})
+	}
 
-	resources, err := buildResources(config.MemoryRequest, config.MemoryLimit, config.CPURequest)
+	resources, err := buildResources(config.MemoryRequest, config.MemoryLimit, config.CPURequest, config.CPULimit)
 	if err != nil {
 		return v1.Container{}, ErrBuildingResources.Wrap(err)
 	}
@@ -524,6 +691,9 @@ func prepareContainer(config ContainerConfig) (v1.Container, error) {
 		Image:           config.Image,
 		Command:         config.Command,
 		Args:            config.Args,
+		WorkingDir:      config.WorkingDir,
+		TTY:             config.TTY,
+		Stdin:           config.Stdin,
 		Env:             podEnv,
 		VolumeMounts:    containerVolumes,
 		Resources:       resources,
@@ -531,6 +701,7 @@ func prepareContainer(config ContainerConfig) (v1.Container, error) {
 		ReadinessProbe:  config.ReadinessProbe,
 		StartupProbe:    config.StartupProbe,
 		SecurityContext: config.SecurityContext,
+		Ports:           config.Ports,
 	}, nil
 }
 
@@ -564,6 +735,63 @@ func prepareInitContainers(config ContainerConfig, init bool) ([]v1.Container, e
 	}, nil
 }
 
+// prepareFetchContainer creates the init container that downloads the remote files into the volume of the container
+// It runs after the init container that copies the content of the image to the volume, so the remote files replace it.
+func prepareFetchContainer(config ContainerConfig, init bool) (v1.Container, bool) {
+	if !init || len(config.Volumes) == 0 || len(config.RemoteFiles) == 0 {
+		return v1.Container{}, false
+	}
+
+	user := int64(0)
+	return v1.Container{
+		Name:  config.Name + "-fetch",
+		Image: fetchImage,
+		SecurityContext: &v1.SecurityContext{
+			RunAsUser: &user,
+		},
+		Command: []string{"sh", "-c", buildFetchCommand(config.Volumes, config.RemoteFiles)},
+		VolumeMounts: []v1.VolumeMount{
+			{
+				Name:      config.Name,
+				MountPath: knuuPath,
+			},
+		},
+	}, true
+}
+
+// buildFetchCommand generates the command that downloads the remote files, verifies their checksum and gives them to the owner of their volume
+// A file is downloaded to a temporary file first, so a failed download never leaves a partial file at its destination.
+func buildFetchCommand(volumes []*Volume, files []*RemoteFile) string {
+	cmds := []string{"set -e"}
+	for _, file := range files {
+		dest := filepath.Join(knuuPath, file.Dest)
+		tmp := dest + ".download"
+		cmds = append(cmds,
+			fmt.Sprintf("mkdir -p %s", shellQuote(filepath.Dir(dest))),
+			fmt.Sprintf("curl -fsSL --retry 5 -o %s %s", shellQuote(tmp), shellQuote(file.URL)),
+		)
+		if file.SHA256 != "" {
+			cmds = append(cmds, fmt.Sprintf("echo %s | sha256sum -c -", shellQuote(file.SHA256+"  "+tmp)))
+		}
+		cmds = append(cmds, fmt.Sprintf("mv %s %s", shellQuote(tmp), shellQuote(dest)))
+		for _, volume := range volumes {
+			if strings.HasPrefix(file.Dest, volume.Path) {
+				cmds = append(cmds, fmt.Sprintf("chown %d:%d %s", volume.Owner, volume.Owner, shellQuote(dest)))
+				break
+			}
+		}
+	}
+
+	fullCommand := strings.Join(cmds, " && ")
+	logrus.Debugf("Fetch container command: %s", fullCommand)
+	return fullCommand
+}
+
+// shellQuote quotes the given string for sh
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'"'"'`) + "'"
+}
+
 // preparePodVolumes prepares pod volumes
 func preparePodVolumes(config ContainerConfig) ([]v1.Volume, error) {
 	podVolumes, err := buildPodVolumes(config.Name, len(config.Volumes), len(config.Files))
@@ -593,6 +821,9 @@ func preparePodSpec(spec PodConfig, init bool) (v1.PodSpec, error) {
 	if err != nil {
 		return v1.PodSpec{}, ErrPreparingInitContainer.Wrap(err)
 	}
+	if fetchContainer, ok := prepareFetchContainer(spec.ContainerConfig, init); ok {
+		initContainers = append(initContainers, fetchContainer)
+	}
 
 	// Prepare volumes
 	podVolumes, err := preparePodVolumes(spec.ContainerConfig)
@@ -606,6 +837,19 @@ func preparePodSpec(spec PodConfig, init bool) (v1.PodSpec, error) {
 		InitContainers:     initContainers,
 		Containers:         []v1.Container{mainContainer},
 		Volumes:            podVolumes,
+		RestartPolicy:      spec.RestartPolicy,
+		DNSPolicy:          spec.DNSPolicy,
+		DNSConfig:          spec.DNSConfig,
+		HostNetwork:        spec.HostNetwork,
+		Tolerations:        spec.Tolerations,
+
+		TopologySpreadConstraints: spec.TopologySpreadConstraints,
+	}
+	if spec.ShareProcesses {
+		podSpec.ShareProcessNamespace = ptr.To(true)
+	}
+	for _, gate := range spec.ReadinessGates {
+		podSpec.ReadinessGates = append(podSpec.ReadinessGates, v1.PodReadinessGate{ConditionType: gate})
 	}
 
 	// Prepare sidecar containers and append to the pod spec
@@ -624,6 +868,10 @@ func preparePodSpec(spec PodConfig, init bool) (v1.PodSpec, error) {
 		podSpec.Volumes = append(podSpec.Volumes, sidecarVolumes...)
 	}
 
+	containerConfigs := append([]ContainerConfig{spec.ContainerConfig}, spec.SidecarConfigs...)
+	podSpec.Volumes = append(podSpec.Volumes, buildSharedDirVolumes(containerConfigs)...)
+	podSpec.Volumes = append(podSpec.Volumes, buildSecretVolumes(containerConfigs)...)
+
 	return podSpec, nil
 }
 
@@ -0,0 +1,75 @@
+package k8s
+
+import (
+	"context"
+
+	v1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+)
+
+const (
+	podSecurityProbeImage = "docker.io/library/busybox:1.36"
+	netAdminCapability    = "NET_ADMIN"
+)
+
+// PodSecurity is what the admission of the namespace allows the containers of the pods to run with, see
+// ProbePodSecurity
+type PodSecurity struct {
+	// Privileged is true if the containers can be privileged
+	Privileged bool
+	// NetAdmin is true if the containers can be granted the NET_ADMIN capability without being privileged
+	NetAdmin bool
+	// PrivilegedDenial and NetAdminDenial are the messages of the admission rejecting the pods, empty if admitted
+	PrivilegedDenial string
+	NetAdminDenial   string
+}
+
+// ProbePodSecurity asks the admission of the namespace whether the containers can be privileged and whether they
+// can be granted the NET_ADMIN capability, e.g. to shape the traffic of the pods
+// The probe pods are created in dry-run mode: they go through the admission, e.g. the Pod Security Admission or
+// the policy webhooks, but they are neither persisted nor scheduled.
+func (c *Client) ProbePodSecurity(ctx context.Context) (*PodSecurity, error) {
+	var (
+		ps  PodSecurity
+		err error
+	)
+	privileged := &v1.SecurityContext{Privileged: ptr.To(true)}
+	ps.Privileged, ps.PrivilegedDenial, err = c.admitsPod(ctx, "knuu-probe-privileged", privileged)
+	if err != nil {
+		return nil, ErrProbingPodSecurity.WithParams(c.namespace).Wrap(err)
+	}
+	netAdmin := &v1.SecurityContext{Capabilities: &v1.Capabilities{Add: []v1.Capability{netAdminCapability}}}
+	ps.NetAdmin, ps.NetAdminDenial, err = c.admitsPod(ctx, "knuu-probe-net-admin", netAdmin)
+	if err != nil {
+		return nil, ErrProbingPodSecurity.WithParams(c.namespace).Wrap(err)
+	}
+	return &ps, nil
+}
+
+// admitsPod creates a pod with the given security context in dry-run mode and returns whether it is admitted, or
+// the message of its rejection
+func (c *Client) admitsPod(ctx context.Context, name string, securityContext *v1.SecurityContext) (bool, string, error) {
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: c.namespace},
+		Spec: v1.PodSpec{
+			RestartPolicy: v1.RestartPolicyNever,
+			Containers: []v1.Container{{
+				Name:            name,
+				Image:           podSecurityProbeImage,
+				SecurityContext: securityContext,
+			}},
+		},
+	}
+	_, err := c.clientset.CoreV1().Pods(c.namespace).Create(ctx, pod, metav1.CreateOptions{DryRun: []string{metav1.DryRunAll}})
+	switch {
+	case err == nil:
+		return true, "", nil
+	// the Pod Security Admission forbids the pods, the policy webhooks deny them with various codes
+	case apierrs.IsForbidden(err) || apierrs.IsInvalid(err) || apierrs.IsBadRequest(err):
+		return false, err.Error(), nil
+	default:
+		return false, "", err
+	}
+}
@@ -2,14 +2,18 @@ package k8s
 
 import (
 	"context"
-	"time"
 
 	appv1 "k8s.io/api/apps/v1"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
 
 	"github.com/sirupsen/logrus"
+
+	"github.com/celestiaorg/knuu/pkg/retry"
 )
 
 type ReplicaSetConfig struct {
@@ -18,6 +22,9 @@ type ReplicaSetConfig struct {
 	Labels    map[string]string // Labels to apply to the ReplicaSet, key/value represents the name/value of the label
 	Replicas  int32             // Replicas is the number of replicas
 	PodConfig PodConfig         // PodConfig represents the pod configuration
+	// Autoscaled leaves the number of replicas to an autoscaler, so that applying the configuration does not reset
+	// it, the ReplicaSet is created with 1 replica
+	Autoscaled bool
 }
 
 // CreateReplicaSet creates a new replicaSet in namespace that k8s is initialized with if it doesn't already exist.
@@ -37,6 +44,22 @@ func (c *Client) CreateReplicaSet(ctx context.Context, rsConfig ReplicaSetConfig
 	return createdRs, nil
 }
 
+// applyReplicaSet applies the configuration to the replicaSet, creating it if it doesn't exist, see Apply
+func (c *Client) applyReplicaSet(ctx context.Context, rsConfig ReplicaSetConfig) (*appv1.ReplicaSet, error) {
+	rsConfig.Namespace = c.namespace
+	rs, err := prepareReplicaSet(rsConfig, false)
+	if err != nil {
+		return nil, ErrPreparingPod.Wrap(err)
+	}
+
+	appliedRs, err := Apply(ctx, c.clientset.AppsV1().ReplicaSets(c.namespace), rs)
+	if err != nil {
+		return nil, ErrCreatingReplicaSet.Wrap(err)
+	}
+
+	return appliedRs, nil
+}
+
 func (c *Client) ReplaceReplicaSetWithGracePeriod(ctx context.Context, ReplicaSetConfig ReplicaSetConfig, gracePeriod *int64) (*appv1.ReplicaSet, error) {
 	logrus.Debugf("Replacing ReplicaSet %s", ReplicaSetConfig.Name)
 
@@ -46,29 +69,19 @@ func (c *Client) ReplaceReplicaSetWithGracePeriod(ctx context.Context, ReplicaSe
 	}
 
 	// Wait for the ReplicaSet to be fully deleted
-	ticker := time.NewTicker(100 * time.Millisecond)
-	defer ticker.Stop()
-
-	deleted := false
-	for !deleted {
-		select {
-		case <-ctx.Done():
-			return nil, ctx.Err()
-		case <-ticker.C:
-			exists, err := c.ReplicaSetExists(ctx, ReplicaSetConfig.Name)
-			if err != nil {
-				return nil, ErrCheckingReplicaSetExists.WithParams(ReplicaSetConfig.Name).Wrap(err)
-			}
-			if !exists {
-				// ReplicaSet has been deleted
-				deleted = true
-			}
-			// If ReplicaSet still exists, wait for the next tick
+	err := retry.Until(ctx, retry.Constant(retryInterval), func(ctx context.Context) (bool, error) {
+		exists, err := c.ReplicaSetExists(ctx, ReplicaSetConfig.Name)
+		if err != nil {
+			return false, ErrCheckingReplicaSetExists.WithParams(ReplicaSetConfig.Name).Wrap(err)
 		}
+		return !exists, nil
+	})
+	if err != nil {
+		return nil, ErrWaitingForReplicaSet.Wrap(err)
 	}
 
-	// Deploy the new replicaSet
-	replicaSet, err := c.CreateReplicaSet(ctx, ReplicaSetConfig, false)
+	// Deploy the new replicaSet, a replicaSet recreated meanwhile by another client is taken over
+	replicaSet, err := c.applyReplicaSet(ctx, ReplicaSetConfig)
 	if err != nil {
 		return nil, ErrDeployingReplicaSet.Wrap(err)
 	}
@@ -87,8 +100,8 @@ func (c *Client) IsReplicaSetRunning(ctx context.Context, name string) (bool, er
 		return false, ErrGettingPod.WithParams(name).Wrap(err)
 	}
 
-	// Check if the ReplicaSet is running
-	return rs.Status.ReadyReplicas == *rs.Spec.Replicas, nil
+	// Check if the ReplicaSet is running, the replicas default to 1
+	return rs.Status.ReadyReplicas == ptr.Deref(rs.Spec.Replicas, 1), nil
 }
 
 func (c *Client) DeleteReplicaSetWithGracePeriod(ctx context.Context, name string, gracePeriodSeconds *int64) error {
@@ -133,6 +146,30 @@ func (c *Client) GetFirstPodFromReplicaSet(ctx context.Context, name string) (*v
 	return c.getPod(ctx, pods.Items[0].Name)
 }
 
+// ListReplicaSets returns the replicaSets of the namespace matching the given labels
+func (c *Client) ListReplicaSets(ctx context.Context, selector map[string]string) ([]appv1.ReplicaSet, error) {
+	listOpts := metav1.ListOptions{LabelSelector: labels.SelectorFromSet(selector).String()}
+	replicaSets, err := c.clientset.AppsV1().ReplicaSets(c.namespace).List(ctx, listOpts)
+	if err != nil {
+		return nil, ErrListingReplicaSets.Wrap(err)
+	}
+	return replicaSets.Items, nil
+}
+
+// AnnotateReplicaSet adds the given annotations to the replicaSet, the other annotations are kept
+// The pods of the replicaSet are not annotated.
+func (c *Client) AnnotateReplicaSet(ctx context.Context, name string, annotations map[string]string) error {
+	patch, err := annotationsPatch(annotations)
+	if err != nil {
+		return ErrAnnotatingReplicaSet.WithParams(name).Wrap(err)
+	}
+	_, err = c.clientset.AppsV1().ReplicaSets(c.namespace).Patch(ctx, name, types.MergePatchType, patch, metav1.PatchOptions{})
+	if err != nil {
+		return ErrAnnotatingReplicaSet.WithParams(name).Wrap(err)
+	}
+	return nil
+}
+
 func (c *Client) getReplicaSet(ctx context.Context, name string) (*appv1.ReplicaSet, error) {
 	rs, err := c.clientset.AppsV1().ReplicaSets(c.namespace).Get(ctx, name, metav1.GetOptions{})
 	if err != nil {
@@ -149,6 +186,10 @@ func prepareReplicaSet(rsConf ReplicaSetConfig, init bool) (*appv1.ReplicaSet, e
 		return nil, ErrPreparingPodSpec.Wrap(err)
 	}
 
+	replicas := &rsConf.Replicas
+	if rsConf.Autoscaled {
+		replicas = nil
+	}
 	rs := &appv1.ReplicaSet{
 		ObjectMeta: metav1.ObjectMeta{
 			Namespace: rsConf.Namespace,
@@ -156,7 +197,7 @@ func prepareReplicaSet(rsConf ReplicaSetConfig, init bool) (*appv1.ReplicaSet, e
 			Labels:    rsConf.Labels,
 		},
 		Spec: appv1.ReplicaSetSpec{
-			Replicas: &rsConf.Replicas,
+			Replicas: replicas,
 			Selector: &metav1.LabelSelector{MatchLabels: rsConf.Labels},
 			Template: v1.PodTemplateSpec{
 				ObjectMeta: metav1.ObjectMeta{
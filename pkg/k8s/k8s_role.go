@@ -6,6 +6,7 @@ import (
 	rbacv1 "k8s.io/api/rbac/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 )
 
 func (c *Client) CreateRole(
@@ -56,3 +57,18 @@ func (c *Client) CreateClusterRole(
 func (c *Client) DeleteClusterRole(ctx context.Context, name string) error {
 	return c.clientset.RbacV1().ClusterRoles().Delete(ctx, name, metav1.DeleteOptions{})
 }
+
+// DeleteClusterRoles deletes the cluster roles with the given labels, the cluster roles already deleted are ignored
+func (c *Client) DeleteClusterRoles(ctx context.Context, selector map[string]string) error {
+	roles := c.clientset.RbacV1().ClusterRoles()
+	list, err := roles.List(ctx, metav1.ListOptions{LabelSelector: labels.SelectorFromSet(selector).String()})
+	if err != nil {
+		return ErrListingClusterRoles.WithParams(selector).Wrap(err)
+	}
+	for _, role := range list.Items {
+		if err := roles.Delete(ctx, role.Name, metav1.DeleteOptions{}); err != nil && !errors.IsNotFound(err) {
+			return ErrDeletingClusterRole.WithParams(role.Name).Wrap(err)
+		}
+	}
+	return nil
+}
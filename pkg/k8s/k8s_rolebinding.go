@@ -6,8 +6,17 @@ import (
 	rbacv1 "k8s.io/api/rbac/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 )
 
+// sccClusterRolePrefix prefixes the cluster roles OpenShift creates to grant the use of each SecurityContextConstraints
+const sccClusterRolePrefix = "system:openshift:scc:"
+
+// SCCClusterRole returns the cluster role granting the use of the given OpenShift SecurityContextConstraints, e.g. anyuid
+func SCCClusterRole(scc string) string {
+	return sccClusterRolePrefix + scc
+}
+
 func (c *Client) CreateRoleBinding(
 	ctx context.Context,
 	name string,
@@ -37,6 +46,38 @@ func (c *Client) CreateRoleBinding(
 	return err
 }
 
+// CreateRoleBindingToClusterRole binds the given cluster role to the service account in the namespace only,
+// e.g. to grant the use of an OpenShift SecurityContextConstraints
+func (c *Client) CreateRoleBindingToClusterRole(
+	ctx context.Context,
+	name string,
+	labels map[string]string,
+	clusterRole, serviceAccount string,
+) error {
+	roleBinding := &rbacv1.RoleBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: c.namespace,
+			Labels:    labels,
+		},
+		RoleRef: rbacv1.RoleRef{
+			Kind:     "ClusterRole",
+			Name:     clusterRole,
+			APIGroup: rbacv1.GroupName,
+		},
+		Subjects: []rbacv1.Subject{
+			{
+				Kind:      "ServiceAccount",
+				Name:      serviceAccount,
+				Namespace: c.namespace,
+			},
+		},
+	}
+
+	_, err := c.clientset.RbacV1().RoleBindings(c.namespace).Create(ctx, roleBinding, metav1.CreateOptions{})
+	return err
+}
+
 func (c *Client) DeleteRoleBinding(ctx context.Context, name string) error {
 	return c.clientset.RbacV1().RoleBindings(c.namespace).Delete(ctx, name, metav1.DeleteOptions{})
 }
@@ -78,3 +119,19 @@ func (c *Client) CreateClusterRoleBinding(
 func (c *Client) DeleteClusterRoleBinding(ctx context.Context, name string) error {
 	return c.clientset.RbacV1().ClusterRoleBindings().Delete(ctx, name, metav1.DeleteOptions{})
 }
+
+// DeleteClusterRoleBindings deletes the cluster role bindings with the given labels, the cluster role bindings
+// already deleted are ignored
+func (c *Client) DeleteClusterRoleBindings(ctx context.Context, selector map[string]string) error {
+	bindings := c.clientset.RbacV1().ClusterRoleBindings()
+	list, err := bindings.List(ctx, metav1.ListOptions{LabelSelector: labels.SelectorFromSet(selector).String()})
+	if err != nil {
+		return ErrListingClusterRoleBindings.WithParams(selector).Wrap(err)
+	}
+	for _, binding := range list.Items {
+		if err := bindings.Delete(ctx, binding.Name, metav1.DeleteOptions{}); err != nil && !errors.IsNotFound(err) {
+			return ErrDeletingClusterRoleBinding.WithParams(binding.Name).Wrap(err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+package k8s
+
+import (
+	"context"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// CreateSecret creates an opaque secret with the given data, e.g. the keys and certificates of an instance
+func (c *Client) CreateSecret(ctx context.Context, name string, labels map[string]string, data map[string][]byte) (*v1.Secret, error) {
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: c.namespace,
+			Labels:    labels,
+		},
+		Type: v1.SecretTypeOpaque,
+		Data: data,
+	}
+
+	created, err := c.clientset.CoreV1().Secrets(c.namespace).Create(ctx, secret, metav1.CreateOptions{})
+	if err != nil {
+		return nil, ErrCreatingSecret.WithParams(name).Wrap(err)
+	}
+	return created, nil
+}
+
+// DeleteSecret deletes the secret with the given name
+func (c *Client) DeleteSecret(ctx context.Context, name string) error {
+	if err := c.clientset.CoreV1().Secrets(c.namespace).Delete(ctx, name, metav1.DeleteOptions{}); err != nil {
+		return ErrDeletingSecret.WithParams(name).Wrap(err)
+	}
+	return nil
+}
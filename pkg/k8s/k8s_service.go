@@ -2,16 +2,37 @@ package k8s
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net"
-	"time"
+	"slices"
+	"strings"
 
 	"github.com/sirupsen/logrus"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
+
+	"github.com/celestiaorg/knuu/pkg/retry"
 )
 
+// ServicePort is a port of a service forwarded to the pods selected by the service
+type ServicePort struct {
+	Name       string      // Name of the port, defaults to '<protocol>-<port>'
+	Protocol   v1.Protocol // Protocol of the port, defaults to TCP
+	Port       int         // Port of the service
+	TargetPort int         // Port of the pods, defaults to Port
+}
+
+// ServiceConfig is the configuration of a service
+type ServiceConfig struct {
+	Name     string            // Name of the service
+	Labels   map[string]string // Labels to apply to the service
+	Selector map[string]string // Selector of the pods the traffic is forwarded to
+	Ports    []ServicePort     // Ports of the service
+	Type     v1.ServiceType    // Type of the service, defaults to ClusterIP
+}
+
 func (c *Client) GetService(ctx context.Context, name string) (*v1.Service, error) {
 	svc, err := c.clientset.CoreV1().Services(c.namespace).Get(ctx, name, metav1.GetOptions{})
 	if err != nil {
@@ -20,6 +41,7 @@ func (c *Client) GetService(ctx context.Context, name string) (*v1.Service, erro
 	return svc, nil
 }
 
+// CreateService creates the service forwarding the given TCP and UDP ports, see DeployService for the other ports
 func (c *Client) CreateService(
 	ctx context.Context,
 	name string,
@@ -28,7 +50,13 @@ func (c *Client) CreateService(
 	portsTCP,
 	portsUDP []int,
 ) (*v1.Service, error) {
-	svc, err := prepareService(c.namespace, name, labels, selectorMap, portsTCP, portsUDP)
+	return c.DeployService(ctx, ServiceConfig{Name: name, Labels: labels, Selector: selectorMap, Ports: servicePorts(portsTCP, portsUDP)})
+}
+
+// DeployService creates the service with the given configuration
+func (c *Client) DeployService(ctx context.Context, config ServiceConfig) (*v1.Service, error) {
+	name := config.Name
+	svc, err := prepareService(c.namespace, config)
 	if err != nil {
 		return nil, ErrPreparingService.WithParams(name).Wrap(err)
 	}
@@ -41,6 +69,7 @@ func (c *Client) CreateService(
 	return serv, nil
 }
 
+// PatchService applies the given labels, selector and TCP and UDP ports to the service, see ApplyService
 func (c *Client) PatchService(
 	ctx context.Context,
 	name string,
@@ -49,12 +78,19 @@ func (c *Client) PatchService(
 	portsTCP,
 	portsUDP []int,
 ) (*v1.Service, error) {
-	svc, err := prepareService(c.namespace, name, labels, selectorMap, portsTCP, portsUDP)
+	return c.ApplyService(ctx, ServiceConfig{Name: name, Labels: labels, Selector: selectorMap, Ports: servicePorts(portsTCP, portsUDP)})
+}
+
+// ApplyService applies the given configuration to the service, see Apply
+// The fields set by the cluster, e.g. the cluster IP and the node ports, are kept.
+func (c *Client) ApplyService(ctx context.Context, config ServiceConfig) (*v1.Service, error) {
+	name := config.Name
+	svc, err := prepareService(c.namespace, config)
 	if err != nil {
 		return nil, ErrPreparingService.WithParams(name).Wrap(err)
 	}
 
-	serv, err := c.clientset.CoreV1().Services(c.namespace).Update(ctx, svc, metav1.UpdateOptions{})
+	serv, err := Apply(ctx, c.clientset.CoreV1().Services(c.namespace), svc)
 	if err != nil {
 		return nil, ErrPatchingService.WithParams(name).Wrap(err)
 	}
@@ -86,32 +122,48 @@ func (c *Client) GetServiceIP(ctx context.Context, name string) (string, error)
 	return svc.Spec.ClusterIP, nil
 }
 
-func buildPorts(tcpPorts, udpPorts []int) []v1.ServicePort {
-	ports := make([]v1.ServicePort, 0, len(tcpPorts)+len(udpPorts))
-	for _, port := range tcpPorts {
-		ports = append(ports, v1.ServicePort{
-			Name:       fmt.Sprintf("tcp-%d", port),
-			Protocol:   v1.ProtocolTCP,
-			Port:       int32(port),
-			TargetPort: intstr.FromInt(port),
-		})
+// servicePorts returns the TCP and UDP ports with their default names
+func servicePorts(portsTCP, portsUDP []int) []ServicePort {
+	ports := make([]ServicePort, 0, len(portsTCP)+len(portsUDP))
+	for _, port := range portsTCP {
+		ports = append(ports, ServicePort{Protocol: v1.ProtocolTCP, Port: port})
 	}
-	for _, port := range udpPorts {
-		ports = append(ports, v1.ServicePort{
-			Name:       fmt.Sprintf("udp-%d", port),
-			Protocol:   v1.ProtocolUDP,
-			Port:       int32(port),
-			TargetPort: intstr.FromInt(port),
-		})
+	for _, port := range portsUDP {
+		ports = append(ports, ServicePort{Protocol: v1.ProtocolUDP, Port: port})
 	}
 	return ports
 }
 
-func prepareService(
-	namespace, name string,
-	labels, selectorMap map[string]string,
-	tcpPorts, udpPorts []int,
-) (*v1.Service, error) {
+func buildPorts(ports []ServicePort) ([]v1.ServicePort, error) {
+	servicePorts := make([]v1.ServicePort, 0, len(ports))
+	for _, port := range ports {
+		protocol := port.Protocol
+		if protocol == "" {
+			protocol = v1.ProtocolTCP
+		}
+		name := port.Name
+		if name == "" {
+			name = fmt.Sprintf("%s-%d", strings.ToLower(string(protocol)), port.Port)
+		}
+		if slices.ContainsFunc(servicePorts, func(p v1.ServicePort) bool { return p.Name == name }) {
+			return nil, ErrDuplicateServicePortName.WithParams(name)
+		}
+		targetPort := port.TargetPort
+		if targetPort == 0 {
+			targetPort = port.Port
+		}
+		servicePorts = append(servicePorts, v1.ServicePort{
+			Name:       name,
+			Protocol:   protocol,
+			Port:       int32(port.Port),
+			TargetPort: intstr.FromInt(targetPort),
+		})
+	}
+	return servicePorts, nil
+}
+
+func prepareService(namespace string, config ServiceConfig) (*v1.Service, error) {
+	name, labels, selectorMap := config.Name, config.Labels, config.Selector
 	if namespace == "" {
 		return nil, ErrNamespaceRequired
 	}
@@ -125,11 +177,19 @@ func prepareService(
 		selectorMap = make(map[string]string)
 	}
 
-	servicePorts := buildPorts(tcpPorts, udpPorts)
+	servicePorts, err := buildPorts(config.Ports)
+	if err != nil {
+		return nil, err
+	}
 	if len(servicePorts) == 0 {
 		return nil, ErrNoPortsSpecified.WithParams(name)
 	}
 
+	serviceType := config.Type
+	if serviceType == "" {
+		serviceType = v1.ServiceTypeClusterIP
+	}
+
 	svc := &v1.Service{
 		ObjectMeta: metav1.ObjectMeta{
 			Namespace: namespace,
@@ -139,44 +199,41 @@ func prepareService(
 		Spec: v1.ServiceSpec{
 			Ports:    servicePorts,
 			Selector: selectorMap,
-			Type:     v1.ServiceTypeClusterIP,
+			Type:     serviceType,
 		},
 	}
 	return svc, nil
 }
 
+// WaitForService waits until the service has a ready endpoint and, for the services exposed
+// outside of the cluster, until it is reachable
 func (c *Client) WaitForService(ctx context.Context, name string) error {
-	ticker := time.NewTicker(waitRetry)
-	defer ticker.Stop()
-
-	for {
-		select {
-		case <-ctx.Done():
-			return ErrTimeoutWaitingForServiceReady
-
-		case <-ticker.C:
-			ready, err := c.isServiceReady(ctx, name)
-			if err != nil {
-				return ErrCheckingServiceReady.WithParams(name).Wrap(err)
-			}
-			if !ready {
-				continue
-			}
-
-			// Check if service is reachable
-			endpoint, err := c.GetServiceEndpoint(ctx, name)
-			if err != nil {
-				return ErrGettingServiceEndpoint.WithParams(name).Wrap(err)
-			}
-
-			if err := checkServiceConnectivity(endpoint); err != nil {
-				continue
-			}
+	err := retry.Until(ctx, retry.Constant(waitRetry), func(ctx context.Context) (bool, error) {
+		service, ready, err := c.isServiceReady(ctx, name)
+		if err != nil {
+			return false, ErrCheckingServiceReady.WithParams(name).Wrap(err)
+		}
+		if !ready {
+			return false, nil
+		}
+		// a cluster IP is only reachable from the cluster
+		if service.Spec.Type != v1.ServiceTypeLoadBalancer && service.Spec.Type != v1.ServiceTypeNodePort {
+			return true, nil
+		}
 
-			// Service is reachable
-			return nil
+		// Check if service is reachable
+		endpoint, err := c.GetServiceEndpoint(ctx, name)
+		if err != nil {
+			return false, ErrGettingServiceEndpoint.WithParams(name).Wrap(err)
 		}
+
+		// Service is reachable
+		return checkServiceConnectivity(endpoint) == nil, nil
+	})
+	if errors.Is(err, retry.ErrContextDone) {
+		return ErrTimeoutWaitingForServiceReady.WithParams(name).Wrap(err)
 	}
+	return err
 }
 
 func (c *Client) GetServiceEndpoint(ctx context.Context, name string) (string, error) {
@@ -226,17 +283,26 @@ func checkServiceConnectivity(serviceEndpoint string) error {
 	return nil // success
 }
 
-func (c *Client) isServiceReady(ctx context.Context, name string) (bool, error) {
+// isServiceReady returns the service and whether it has a ready endpoint and, depending on its type,
+// an ingress or a node port
+func (c *Client) isServiceReady(ctx context.Context, name string) (*v1.Service, bool, error) {
 	service, err := c.GetService(ctx, name)
 	if err != nil {
-		return false, ErrGettingService.WithParams(name).Wrap(err)
+		return nil, false, ErrGettingService.WithParams(name).Wrap(err)
+	}
+	endpoints, err := c.ReadyEndpoints(ctx, name)
+	if err != nil {
+		return nil, false, err
+	}
+	if endpoints == 0 {
+		return service, false, nil
 	}
 	switch service.Spec.Type {
 	case v1.ServiceTypeLoadBalancer:
-		return len(service.Status.LoadBalancer.Ingress) > 0, nil
+		return service, len(service.Status.LoadBalancer.Ingress) > 0, nil
 	case v1.ServiceTypeNodePort:
-		return service.Spec.Ports[0].NodePort != 0, nil
+		return service, service.Spec.Ports[0].NodePort != 0, nil
 	default:
-		return len(service.Spec.ExternalIPs) > 0, nil
+		return service, true, nil
 	}
 }
@@ -0,0 +1,68 @@
+package k8s
+
+import (
+	"context"
+
+	admissionv1 "k8s.io/api/admissionregistration/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// CreateValidatingWebhookConfiguration registers the given admission webhooks with the API server
+// The configuration is cluster scoped, so its name must be unique in the cluster. It is owned by the namespace of the
+// client, so it is garbage collected once the namespace is deleted, e.g. by the timeout handler.
+func (c *Client) CreateValidatingWebhookConfiguration(
+	ctx context.Context,
+	name string,
+	labels map[string]string,
+	webhooks []admissionv1.ValidatingWebhook,
+) (*admissionv1.ValidatingWebhookConfiguration, error) {
+	namespace, err := c.GetNamespace(ctx, c.namespace)
+	if err != nil {
+		return nil, ErrCreatingWebhookConfiguration.WithParams(name).Wrap(err)
+	}
+	config := &admissionv1.ValidatingWebhookConfiguration{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: labels,
+			OwnerReferences: []metav1.OwnerReference{{
+				APIVersion: "v1",
+				Kind:       "Namespace",
+				Name:       namespace.Name,
+				UID:        namespace.UID,
+			}},
+		},
+		Webhooks: webhooks,
+	}
+	created, err := c.clientset.AdmissionregistrationV1().ValidatingWebhookConfigurations().Create(ctx, config, metav1.CreateOptions{})
+	if err != nil {
+		return nil, ErrCreatingWebhookConfiguration.WithParams(name).Wrap(err)
+	}
+	return created, nil
+}
+
+// DeleteValidatingWebhookConfiguration unregisters the admission webhooks of the given configuration
+func (c *Client) DeleteValidatingWebhookConfiguration(ctx context.Context, name string) error {
+	err := c.clientset.AdmissionregistrationV1().ValidatingWebhookConfigurations().Delete(ctx, name, metav1.DeleteOptions{})
+	if err != nil {
+		return ErrDeletingWebhookConfiguration.WithParams(name).Wrap(err)
+	}
+	return nil
+}
+
+// DeleteValidatingWebhookConfigurations unregisters the admission webhooks of the configurations with the given
+// labels, the configurations already deleted are ignored
+func (c *Client) DeleteValidatingWebhookConfigurations(ctx context.Context, selector map[string]string) error {
+	configs := c.clientset.AdmissionregistrationV1().ValidatingWebhookConfigurations()
+	list, err := configs.List(ctx, metav1.ListOptions{LabelSelector: labels.SelectorFromSet(selector).String()})
+	if err != nil {
+		return ErrListingWebhookConfigurations.WithParams(selector).Wrap(err)
+	}
+	for _, config := range list.Items {
+		if err := configs.Delete(ctx, config.Name, metav1.DeleteOptions{}); err != nil && !apierrs.IsNotFound(err) {
+			return ErrDeletingWebhookConfiguration.WithParams(config.Name).Wrap(err)
+		}
+	}
+	return nil
+}
@@ -2,36 +2,59 @@ package k8s
 
 import (
 	"context"
+	"io"
+	"time"
 
+	admissionv1 "k8s.io/api/admissionregistration/v1"
 	appv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
 	corev1 "k8s.io/api/core/v1"
 	netv1 "k8s.io/api/networking/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 )
 
 type KubeManager interface {
-	Clientset() *kubernetes.Clientset
+	AddEphemeralContainer(ctx context.Context, podName string, config EphemeralContainerConfig) (*corev1.Pod, error)
+	AnnotatePod(ctx context.Context, name string, annotations map[string]string) error
+	AnnotateReplicaSet(ctx context.Context, name string, annotations map[string]string) error
+	ApplyService(ctx context.Context, config ServiceConfig) (*corev1.Service, error)
+	Capabilities(ctx context.Context) (*Capabilities, error)
+	Clientset() kubernetes.Interface
 	CreateClusterRole(ctx context.Context, name string, labels map[string]string, policyRules []rbacv1.PolicyRule) error
 	CreateClusterRoleBinding(ctx context.Context, name string, labels map[string]string, clusterRole, serviceAccount string) error
 	CreateConfigMap(ctx context.Context, name string, labels, data map[string]string) (*corev1.ConfigMap, error)
 	CreateCustomResource(ctx context.Context, name string, gvr *schema.GroupVersionResource, obj *map[string]interface{}) error
 	CreateDaemonSet(ctx context.Context, name string, labels map[string]string, initContainers []corev1.Container, containers []corev1.Container) (*appv1.DaemonSet, error)
+	CreateEgressFirewall(ctx context.Context, name string, selectorMap map[string]string, allowCIDRs []string) error
+	CreateHorizontalPodAutoscaler(ctx context.Context, name string, labels map[string]string, spec autoscalingv2.HorizontalPodAutoscalerSpec) (*autoscalingv2.HorizontalPodAutoscaler, error)
 	CreateNamespace(ctx context.Context, name string) error
 	CreateNetworkPolicy(ctx context.Context, name string, selectorMap, ingressSelectorMap, egressSelectorMap map[string]string) error
+	CreateNetworkPolicyWithLabels(ctx context.Context, name string, labels, selectorMap, ingressSelectorMap, egressSelectorMap map[string]string) error
 	CreatePersistentVolumeClaim(ctx context.Context, name string, labels map[string]string, size resource.Quantity) error
 	CreateReplicaSet(ctx context.Context, rsConfig ReplicaSetConfig, init bool) (*appv1.ReplicaSet, error)
 	CreateRole(ctx context.Context, name string, labels map[string]string, policyRules []rbacv1.PolicyRule) error
 	CreateRoleBinding(ctx context.Context, name string, labels map[string]string, role, serviceAccount string) error
+	CreateRoleBindingToClusterRole(ctx context.Context, name string, labels map[string]string, clusterRole, serviceAccount string) error
+	CreateSecret(ctx context.Context, name string, labels map[string]string, data map[string][]byte) (*corev1.Secret, error)
 	CreateService(ctx context.Context, name string, labels, selectorMap map[string]string, portsTCP, portsUDP []int) (*corev1.Service, error)
 	CreateServiceAccount(ctx context.Context, name string, labels map[string]string) error
+	CreateValidatingWebhookConfiguration(ctx context.Context, name string, labels map[string]string, webhooks []admissionv1.ValidatingWebhook) (*admissionv1.ValidatingWebhookConfiguration, error)
 	CustomResourceDefinitionExists(ctx context.Context, gvr *schema.GroupVersionResource) bool
 	DaemonSetExists(ctx context.Context, name string) (bool, error)
+	DebugNode(ctx context.Context, nodeName, image string) (*corev1.Pod, error)
+	DeleteClusterRole(ctx context.Context, name string) error
+	DeleteClusterRoleBinding(ctx context.Context, name string) error
+	DeleteClusterRoleBindings(ctx context.Context, selector map[string]string) error
+	DeleteClusterRoles(ctx context.Context, selector map[string]string) error
 	DeleteConfigMap(ctx context.Context, name string) error
+	DeleteCustomResource(ctx context.Context, name string, gvr *schema.GroupVersionResource) error
 	DeleteDaemonSet(ctx context.Context, name string) error
+	DeleteHorizontalPodAutoscaler(ctx context.Context, name string) error
 	DeleteNamespace(ctx context.Context, name string) error
 	DeleteNetworkPolicy(ctx context.Context, name string) error
 	DeletePersistentVolumeClaim(ctx context.Context, name string) error
@@ -41,20 +64,34 @@ type KubeManager interface {
 	DeleteReplicaSetWithGracePeriod(ctx context.Context, name string, gracePeriodSeconds *int64) error
 	DeleteRole(ctx context.Context, name string) error
 	DeleteRoleBinding(ctx context.Context, name string) error
+	DeleteSecret(ctx context.Context, name string) error
 	DeleteService(ctx context.Context, name string) error
 	DeleteServiceAccount(ctx context.Context, name string) error
+	DeleteValidatingWebhookConfiguration(ctx context.Context, name string) error
+	DeleteValidatingWebhookConfigurations(ctx context.Context, selector map[string]string) error
 	DeployPod(ctx context.Context, podConfig PodConfig, init bool) (*corev1.Pod, error)
+	DeployService(ctx context.Context, config ServiceConfig) (*corev1.Service, error)
 	DynamicClient() dynamic.Interface
 	GetConfigMap(ctx context.Context, name string) (*corev1.ConfigMap, error)
+	GetCustomResource(ctx context.Context, name string, gvr *schema.GroupVersionResource) (*unstructured.Unstructured, error)
 	GetDaemonSet(ctx context.Context, name string) (*appv1.DaemonSet, error)
 	GetFirstPodFromReplicaSet(ctx context.Context, name string) (*corev1.Pod, error)
+	GetHorizontalPodAutoscaler(ctx context.Context, name string) (*autoscalingv2.HorizontalPodAutoscaler, error)
 	GetNamespace(ctx context.Context, name string) (*corev1.Namespace, error)
 	GetNetworkPolicy(ctx context.Context, name string) (*netv1.NetworkPolicy, error)
+	GetNode(ctx context.Context, name string) (*corev1.Node, error)
+	GetPod(ctx context.Context, name string) (*corev1.Pod, error)
+	GetPodUsage(ctx context.Context, podName string) (corev1.ResourceList, error)
 	GetService(ctx context.Context, name string) (*corev1.Service, error)
 	GetServiceEndpoint(ctx context.Context, name string) (string, error)
 	GetServiceIP(ctx context.Context, name string) (string, error)
+	InNamespace(ctx context.Context, namespace string) (KubeManager, error)
 	IsPodRunning(ctx context.Context, name string) (bool, error)
 	IsReplicaSetRunning(ctx context.Context, name string) (bool, error)
+	ListDeployments(ctx context.Context, selector map[string]string) ([]appv1.Deployment, error)
+	ListPods(ctx context.Context, selector map[string]string) ([]corev1.Pod, error)
+	ListReplicaSets(ctx context.Context, selector map[string]string) ([]appv1.ReplicaSet, error)
+	ListStatefulSets(ctx context.Context, selector map[string]string) ([]appv1.StatefulSet, error)
 	Namespace() string
 	NamespaceExists(ctx context.Context, name string) bool
 	NetworkPolicyExists(ctx context.Context, name string) bool
@@ -62,17 +99,27 @@ type KubeManager interface {
 	NewVolume(path, size string, owner int64) *Volume
 	PatchService(ctx context.Context, name string, labels, selectorMap map[string]string, portsTCP, portsUDP []int) (*corev1.Service, error)
 	PortForwardPod(ctx context.Context, podName string, localPort, remotePort int) error
+	ProbePodSecurity(ctx context.Context) (*PodSecurity, error)
+	ProxyGetPod(ctx context.Context, podName string, port int, path string) ([]byte, error)
+	ReadyEndpoints(ctx context.Context, service string) (int, error)
 	ReplicaSetExists(ctx context.Context, name string) (bool, error)
 	ReplacePod(ctx context.Context, podConfig PodConfig) (*corev1.Pod, error)
 	ReplacePodWithGracePeriod(ctx context.Context, podConfig PodConfig, gracePeriod *int64) (*corev1.Pod, error)
 	ReplaceReplicaSet(ctx context.Context, ReplicaSetConfig ReplicaSetConfig) (*appv1.ReplicaSet, error)
 	ReplaceReplicaSetWithGracePeriod(ctx context.Context, ReplicaSetConfig ReplicaSetConfig, gracePeriod *int64) (*appv1.ReplicaSet, error)
+	RequireCapability(ctx context.Context, capability Capability) error
 	RunCommandInPod(ctx context.Context, podName, containerName string, cmd []string) (string, error)
+	SetPodCondition(ctx context.Context, name string, condition corev1.PodCondition) error
+	StreamCommandInPod(ctx context.Context, podName, containerName string, cmd []string, stdout io.Writer) error
+	StreamCommandToPod(ctx context.Context, podName, containerName string, cmd []string, stdin io.Reader) error
+	StreamPodLogs(ctx context.Context, podName, containerName string, follow bool) (io.ReadCloser, error)
+	StreamPodLogsSince(ctx context.Context, podName, containerName string, since time.Time, follow bool) (io.ReadCloser, error)
 	getPersistentVolumeClaim(ctx context.Context, name string) (*corev1.PersistentVolumeClaim, error)
 	getPod(ctx context.Context, name string) (*corev1.Pod, error)
 	getReplicaSet(ctx context.Context, name string) (*appv1.ReplicaSet, error)
 	ConfigMapExists(ctx context.Context, name string) (bool, error)
 	UpdateDaemonSet(ctx context.Context, name string, labels map[string]string, initContainers []corev1.Container, containers []corev1.Container) (*appv1.DaemonSet, error)
 	WaitForDeployment(ctx context.Context, name string) error
+	WaitForEndpoints(ctx context.Context, service string, minReady int) error
 	WaitForService(ctx context.Context, name string) error
 }
@@ -0,0 +1,73 @@
+package knuu
+
+import (
+	"context"
+
+	"github.com/celestiaorg/knuu/pkg/instance"
+)
+
+// AttachOption configures AttachToExisting
+type AttachOption func(*attachOptions)
+
+type attachOptions struct {
+	namespace string
+}
+
+// WithAttachNamespace attaches to the workloads of the given namespace, which must exist, rather than the ones of
+// the namespace of the test
+func WithAttachNamespace(namespace string) AttachOption {
+	return func(o *attachOptions) {
+		o.namespace = namespace
+	}
+}
+
+// AttachToExisting returns handles on the Deployments and StatefulSets of the namespace matching the given labels,
+// e.g. the infrastructure deployed by other tools, so that the test can execute commands in their pods, read their
+// logs, forward their ports and disable their network, see instance.AttachDeployment
+// The workloads are looked up in the namespace of the test, or the one given with WithAttachNamespace, where the
+// handles execute the commands and create the network policies.
+// The handles are not instances of knuu: they are not returned by Instances and the workloads are left as is by
+// CleanUp.
+func (k *Knuu) AttachToExisting(ctx context.Context, selector map[string]string, opts ...AttachOption) ([]*instance.Instance, error) {
+	var o attachOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	sysDeps := k.SystemDependencies
+	if o.namespace != "" && o.namespace != k.K8sCli.Namespace() {
+		k8sCli, err := k.K8sCli.InNamespace(ctx, o.namespace)
+		if err != nil {
+			return nil, ErrAttachingToExisting.WithParams(selector).Wrap(err)
+		}
+		sysDeps.K8sCli = k8sCli
+	}
+
+	deployments, err := sysDeps.K8sCli.ListDeployments(ctx, selector)
+	if err != nil {
+		return nil, ErrAttachingToExisting.WithParams(selector).Wrap(err)
+	}
+	statefulSets, err := sysDeps.K8sCli.ListStatefulSets(ctx, selector)
+	if err != nil {
+		return nil, ErrAttachingToExisting.WithParams(selector).Wrap(err)
+	}
+	if len(deployments)+len(statefulSets) == 0 {
+		return nil, ErrNothingToAttach.WithParams(selector, sysDeps.K8sCli.Namespace())
+	}
+
+	instances := make([]*instance.Instance, 0, len(deployments)+len(statefulSets))
+	for _, deployment := range deployments {
+		i, err := instance.AttachDeployment(&deployment, sysDeps)
+		if err != nil {
+			return nil, ErrAttachingToExisting.WithParams(selector).Wrap(err)
+		}
+		instances = append(instances, i)
+	}
+	for _, statefulSet := range statefulSets {
+		i, err := instance.AttachStatefulSet(&statefulSet, sysDeps)
+		if err != nil {
+			return nil, ErrAttachingToExisting.WithParams(selector).Wrap(err)
+		}
+		instances = append(instances, i)
+	}
+	return instances, nil
+}
@@ -0,0 +1,90 @@
+package knuu
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/celestiaorg/knuu/pkg/instance"
+	"github.com/celestiaorg/knuu/pkg/k8s/fake"
+	"github.com/celestiaorg/knuu/pkg/system"
+)
+
+func TestAttachToExisting(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	infra := map[string]string{"part-of": "infra"}
+	selector := &metav1.LabelSelector{MatchLabels: map[string]string{"app": "db"}}
+	template := v1.PodTemplateSpec{Spec: v1.PodSpec{Containers: []v1.Container{{Name: "db"}}}}
+	k8sCli, err := fake.New(ctx, "test",
+		&appv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "test", Labels: infra},
+			Spec:       appv1.DeploymentSpec{Selector: selector, Template: template},
+		},
+		&appv1.StatefulSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "test", Labels: infra},
+			Spec:       appv1.StatefulSetSpec{Selector: selector, Template: template},
+		},
+		&appv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "test"},
+			Spec:       appv1.DeploymentSpec{Selector: selector, Template: template},
+		},
+	)
+	require.NoError(t, err)
+	k := &Knuu{SystemDependencies: system.SystemDependencies{K8sCli: k8sCli, Logger: logrus.New(), TestScope: "test"}}
+
+	instances, err := k.AttachToExisting(ctx, infra)
+	require.NoError(t, err)
+	require.Len(t, instances, 2)
+	for _, i := range instances {
+		assert.True(t, i.IsAttached())
+		assert.Equal(t, instance.Attached, i.State())
+	}
+	assert.Empty(t, k.Instances())
+
+	_, err = k.AttachToExisting(ctx, map[string]string{"part-of": "nothing"})
+	assert.ErrorIs(t, err, ErrNothingToAttach)
+
+	_, err = k.AttachToExisting(ctx, infra, WithAttachNamespace("missing"))
+	assert.ErrorIs(t, err, ErrAttachingToExisting)
+}
+
+func TestAttachToExistingInNamespace(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	infra := map[string]string{"part-of": "infra"}
+	k8sCli, err := fake.New(ctx, "test",
+		&v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "infra"}},
+		&appv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "infra", Labels: infra},
+			Spec: appv1.DeploymentSpec{
+				Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "api"}},
+				Template: v1.PodTemplateSpec{Spec: v1.PodSpec{Containers: []v1.Container{{Name: "api"}}}},
+			},
+		},
+	)
+	require.NoError(t, err)
+	k := &Knuu{SystemDependencies: system.SystemDependencies{K8sCli: k8sCli, Logger: logrus.New(), TestScope: "test"}}
+
+	_, err = k.AttachToExisting(ctx, infra)
+	assert.ErrorIs(t, err, ErrNothingToAttach)
+
+	instances, err := k.AttachToExisting(ctx, infra, WithAttachNamespace("infra"))
+	require.NoError(t, err)
+	require.Len(t, instances, 1)
+
+	// the network policy is created in the namespace of the workload
+	require.NoError(t, instances[0].DisableNetwork(ctx))
+	_, err = k8sCli.Clientset().NetworkingV1().NetworkPolicies("infra").Get(ctx, "knuu-disable-network-api", metav1.GetOptions{})
+	assert.NoError(t, err)
+	_, err = k8sCli.Clientset().NetworkingV1().NetworkPolicies("test").Get(ctx, "knuu-disable-network-api", metav1.GetOptions{})
+	assert.Error(t, err)
+}
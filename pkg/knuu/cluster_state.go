@@ -0,0 +1,407 @@
+package knuu
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/yaml"
+
+	"github.com/celestiaorg/knuu/pkg/k8s"
+)
+
+// redactedValue replaces the values of the secrets in the cluster state
+const redactedValue = "<redacted>"
+
+// CaptureClusterState writes the state of the test with the given scope to dest as a gzipped tar archive, to attach
+// to a bug report against knuu or the system under test. It can be called once the test failed, e.g. by its CI job,
+// as long as the namespace of the test was not deleted.
+// The archive is a directory named after the scope with:
+//   - summary.yaml, the number of captured objects by kind
+//   - objects/<kind>/<name>.yaml, the objects managed by knuu for the test, without their managed fields and with
+//     the values of the secrets redacted
+//   - events.yaml, the events of the namespace of the test, by time
+//   - logs/<pod>/<container>.log, the logs of the containers of the pods, and <container>.previous.log for the
+//     restarted containers
+//   - nodes/<node>.yaml, the conditions, taints and resources of the nodes
+//   - errors.txt, the parts that could not be captured, e.g. the logs of a container that never started
+func CaptureClusterState(ctx context.Context, scope, dest string) error {
+	clientset, err := k8s.NewClientset()
+	if err != nil {
+		return ErrCapturingClusterState.WithParams(scope).Wrap(err)
+	}
+	return captureScopeState(ctx, clientset, scope, dest)
+}
+
+// captureScopeState captures the state of the test with the given scope, whose namespace must exist: unlike
+// k8s.New, it is not created, so that capturing the state of a cleaned up test fails rather than leaking a namespace
+func captureScopeState(ctx context.Context, clientset kubernetes.Interface, scope, dest string) error {
+	namespace := k8s.SanitizeName(scope)
+	if _, err := clientset.CoreV1().Namespaces().Get(ctx, namespace, metav1.GetOptions{}); err != nil {
+		if apierrs.IsNotFound(err) {
+			return ErrClusterStateNamespaceNotFound.WithParams(namespace)
+		}
+		return ErrCapturingClusterState.WithParams(scope).Wrap(err)
+	}
+	return captureClusterState(ctx, clientset, namespace, dest)
+}
+
+// CaptureClusterState writes the state of the test to dest as a gzipped tar archive, see CaptureClusterState
+func (k *Knuu) CaptureClusterState(ctx context.Context, dest string) error {
+	return captureClusterState(ctx, k.K8sCli.Clientset(), k.K8sCli.Namespace(), dest)
+}
+
+// clusterState is the state of a test captured for a bug report, by path in the archive
+type clusterState struct {
+	clientset kubernetes.Interface
+	// scope is the scope of the test, which is also its namespace
+	scope string
+
+	files  map[string][]byte
+	counts map[string]int
+	errs   []string
+}
+
+// clusterStateSummary is the summary of the captured state
+type clusterStateSummary struct {
+	Scope      string         `json:"scope"`
+	CapturedAt time.Time      `json:"capturedAt"`
+	Objects    map[string]int `json:"objects"`
+	Errors     int            `json:"errors"`
+}
+
+// nodeState is the part of a node relevant to the pods scheduled on it
+type nodeState struct {
+	Name          string                `json:"name"`
+	Labels        map[string]string     `json:"labels,omitempty"`
+	Unschedulable bool                  `json:"unschedulable,omitempty"`
+	Taints        []v1.Taint            `json:"taints,omitempty"`
+	Conditions    []v1.NodeCondition    `json:"conditions"`
+	Capacity      v1.ResourceList       `json:"capacity,omitempty"`
+	Allocatable   v1.ResourceList       `json:"allocatable,omitempty"`
+	NodeInfo      v1.NodeSystemInfo     `json:"nodeInfo"`
+	Addresses     []v1.NodeAddress      `json:"addresses,omitempty"`
+	Images        int                   `json:"images"`
+	Volumes       []v1.UniqueVolumeName `json:"volumesInUse,omitempty"`
+}
+
+func captureClusterState(ctx context.Context, clientset kubernetes.Interface, scope, dest string) error {
+	s := &clusterState{
+		clientset: clientset,
+		scope:     scope,
+		files:     make(map[string][]byte),
+		counts:    make(map[string]int),
+	}
+	capturedAt := time.Now()
+
+	pods := s.captureObjects(ctx)
+	s.captureEvents(ctx)
+	s.captureLogs(ctx, pods)
+	s.captureNodes(ctx)
+	if ctx.Err() != nil {
+		return ErrCapturingClusterState.WithParams(scope).Wrap(ctx.Err())
+	}
+
+	s.add("summary.yaml", clusterStateSummary{
+		Scope:      scope,
+		CapturedAt: capturedAt.UTC(),
+		Objects:    s.counts,
+		Errors:     len(s.errs),
+	})
+	if len(s.errs) != 0 {
+		s.files["errors.txt"] = []byte(strings.Join(s.errs, "\n") + "\n")
+	}
+	if err := s.write(dest, capturedAt); err != nil {
+		return ErrWritingClusterState.WithParams(dest).Wrap(err)
+	}
+	return nil
+}
+
+// objectKind is a kind of the objects knuu creates for the tests, with the function listing them
+type objectKind struct {
+	kind string
+	list func(ctx context.Context, opts metav1.ListOptions) (runtime.Object, error)
+}
+
+// objectKinds returns the kinds of the objects knuu creates for the tests
+func (s *clusterState) objectKinds() []objectKind {
+	cs, ns := s.clientset, s.scope
+	return []objectKind{
+		{"pods", func(ctx context.Context, o metav1.ListOptions) (runtime.Object, error) {
+			return listed(cs.CoreV1().Pods(ns).List(ctx, o))
+		}},
+		{"replicasets", func(ctx context.Context, o metav1.ListOptions) (runtime.Object, error) {
+			return listed(cs.AppsV1().ReplicaSets(ns).List(ctx, o))
+		}},
+		{"daemonsets", func(ctx context.Context, o metav1.ListOptions) (runtime.Object, error) {
+			return listed(cs.AppsV1().DaemonSets(ns).List(ctx, o))
+		}},
+		{"services", func(ctx context.Context, o metav1.ListOptions) (runtime.Object, error) {
+			return listed(cs.CoreV1().Services(ns).List(ctx, o))
+		}},
+		{"configmaps", func(ctx context.Context, o metav1.ListOptions) (runtime.Object, error) {
+			return listed(cs.CoreV1().ConfigMaps(ns).List(ctx, o))
+		}},
+		{"secrets", func(ctx context.Context, o metav1.ListOptions) (runtime.Object, error) {
+			return listed(cs.CoreV1().Secrets(ns).List(ctx, o))
+		}},
+		{"persistentvolumeclaims", func(ctx context.Context, o metav1.ListOptions) (runtime.Object, error) {
+			return listed(cs.CoreV1().PersistentVolumeClaims(ns).List(ctx, o))
+		}},
+		{"serviceaccounts", func(ctx context.Context, o metav1.ListOptions) (runtime.Object, error) {
+			return listed(cs.CoreV1().ServiceAccounts(ns).List(ctx, o))
+		}},
+		{"roles", func(ctx context.Context, o metav1.ListOptions) (runtime.Object, error) {
+			return listed(cs.RbacV1().Roles(ns).List(ctx, o))
+		}},
+		{"rolebindings", func(ctx context.Context, o metav1.ListOptions) (runtime.Object, error) {
+			return listed(cs.RbacV1().RoleBindings(ns).List(ctx, o))
+		}},
+		{"clusterroles", func(ctx context.Context, o metav1.ListOptions) (runtime.Object, error) {
+			return listed(cs.RbacV1().ClusterRoles().List(ctx, o))
+		}},
+		{"clusterrolebindings", func(ctx context.Context, o metav1.ListOptions) (runtime.Object, error) {
+			return listed(cs.RbacV1().ClusterRoleBindings().List(ctx, o))
+		}},
+		{"networkpolicies", func(ctx context.Context, o metav1.ListOptions) (runtime.Object, error) {
+			return listed(cs.NetworkingV1().NetworkPolicies(ns).List(ctx, o))
+		}},
+		{"horizontalpodautoscalers", func(ctx context.Context, o metav1.ListOptions) (runtime.Object, error) {
+			return listed(cs.AutoscalingV2().HorizontalPodAutoscalers(ns).List(ctx, o))
+		}},
+	}
+}
+
+// listed returns the typed list as an object
+func listed[T runtime.Object](list T, err error) (runtime.Object, error) {
+	return list, err
+}
+
+// captureObjects captures the objects managed by knuu for the test and returns the pods
+func (s *clusterState) captureObjects(ctx context.Context) []v1.Pod {
+	selector := labels.SelectorFromSet(map[string]string{scopeLabel: s.scope, managedByLabel: "knuu"}).String()
+	var pods []v1.Pod
+	for _, kind := range s.objectKinds() {
+		list, err := kind.list(ctx, metav1.ListOptions{LabelSelector: selector})
+		if err != nil {
+			s.fail("listing %s: %v", kind.kind, err)
+			continue
+		}
+		if podList, ok := list.(*v1.PodList); ok {
+			pods = podList.Items
+		}
+		items, err := meta.ExtractList(list)
+		if err != nil {
+			s.fail("listing %s: %v", kind.kind, err)
+			continue
+		}
+		for _, item := range items {
+			s.addObject(kind.kind, item)
+		}
+		s.counts[kind.kind] = len(items)
+	}
+	return pods
+}
+
+// addObject adds the YAML of the object, with its kind and without its managed fields, to the state
+func (s *clusterState) addObject(kind string, obj runtime.Object) {
+	obj = obj.DeepCopyObject()
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		s.fail("reading %s: %v", kind, err)
+		return
+	}
+	accessor.SetManagedFields(nil)
+	if gvks, _, err := scheme.Scheme.ObjectKinds(obj); err == nil {
+		obj.GetObjectKind().SetGroupVersionKind(gvks[0])
+	}
+	if secret, ok := obj.(*v1.Secret); ok {
+		redactSecret(secret)
+	}
+	s.add(path.Join("objects", kind, accessor.GetName()+".yaml"), obj)
+}
+
+// redactSecret replaces the values of the secret, the keys are kept
+func redactSecret(secret *v1.Secret) {
+	for key := range secret.Data {
+		secret.Data[key] = []byte(redactedValue)
+	}
+	for key := range secret.StringData {
+		secret.StringData[key] = redactedValue
+	}
+	delete(secret.Annotations, v1.LastAppliedConfigAnnotation)
+}
+
+// captureEvents captures the events of the namespace of the test, the events of the objects knuu does not manage,
+// e.g. the pods of the image builder, are relevant too
+func (s *clusterState) captureEvents(ctx context.Context) {
+	events, err := s.clientset.CoreV1().Events(s.scope).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		s.fail("listing events: %v", err)
+		return
+	}
+	sort.SliceStable(events.Items, func(a, b int) bool {
+		return eventTime(events.Items[a]).Before(eventTime(events.Items[b]))
+	})
+	for i := range events.Items {
+		events.Items[i].ManagedFields = nil
+	}
+	s.add("events.yaml", events.Items)
+	s.counts["events"] = len(events.Items)
+}
+
+// eventTime returns the last time the event occurred
+func eventTime(e v1.Event) time.Time {
+	switch {
+	case !e.LastTimestamp.IsZero():
+		return e.LastTimestamp.Time
+	case !e.EventTime.IsZero():
+		return e.EventTime.Time
+	default:
+		return e.CreationTimestamp.Time
+	}
+}
+
+// captureLogs captures the logs of the init containers and the containers of the pods
+func (s *clusterState) captureLogs(ctx context.Context, pods []v1.Pod) {
+	for _, pod := range pods {
+		statuses := append(append([]v1.ContainerStatus(nil), pod.Status.InitContainerStatuses...), pod.Status.ContainerStatuses...)
+		restarts := make(map[string]int32, len(statuses))
+		for _, status := range statuses {
+			restarts[status.Name] = status.RestartCount
+		}
+		containers := append(append([]v1.Container(nil), pod.Spec.InitContainers...), pod.Spec.Containers...)
+		for _, container := range containers {
+			s.captureLog(ctx, pod.Name, container.Name, false)
+			if restarts[container.Name] > 0 {
+				s.captureLog(ctx, pod.Name, container.Name, true)
+			}
+		}
+	}
+}
+
+func (s *clusterState) captureLog(ctx context.Context, pod, container string, previous bool) {
+	name := container + ".log"
+	if previous {
+		name = container + ".previous.log"
+	}
+	stream, err := s.clientset.CoreV1().Pods(s.scope).GetLogs(pod, &v1.PodLogOptions{Container: container, Previous: previous}).Stream(ctx)
+	if err != nil {
+		s.fail("getting logs %s/%s: %v", pod, name, err)
+		return
+	}
+	defer stream.Close()
+	logs, err := io.ReadAll(stream)
+	if err != nil {
+		s.fail("reading logs %s/%s: %v", pod, name, err)
+	}
+	s.files[path.Join("logs", pod, name)] = logs
+	s.counts["logs"]++
+}
+
+// captureNodes captures the state of the nodes of the cluster
+func (s *clusterState) captureNodes(ctx context.Context) {
+	nodes, err := s.clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		s.fail("listing nodes: %v", err)
+		return
+	}
+	for _, node := range nodes.Items {
+		s.add(path.Join("nodes", node.Name+".yaml"), nodeState{
+			Name:          node.Name,
+			Labels:        node.Labels,
+			Unschedulable: node.Spec.Unschedulable,
+			Taints:        node.Spec.Taints,
+			Conditions:    node.Status.Conditions,
+			Capacity:      node.Status.Capacity,
+			Allocatable:   node.Status.Allocatable,
+			NodeInfo:      node.Status.NodeInfo,
+			Addresses:     node.Status.Addresses,
+			Images:        len(node.Status.Images),
+			Volumes:       node.Status.VolumesInUse,
+		})
+	}
+	s.counts["nodes"] = len(nodes.Items)
+}
+
+// add adds the YAML of the value to the state, the error is recorded if it cannot be marshalled
+func (s *clusterState) add(name string, value interface{}) {
+	data, err := yaml.Marshal(value)
+	if err != nil {
+		s.fail("marshalling %s: %v", name, err)
+		return
+	}
+	s.files[name] = data
+}
+
+// fail records a part of the state that could not be captured
+func (s *clusterState) fail(format string, args ...interface{}) {
+	s.errs = append(s.errs, fmt.Sprintf(format, args...))
+}
+
+// write writes the files of the state to a gzipped tar archive, in a directory named after the scope
+// The archive is written to a temporary file renamed to dest, so that dest is never a partial archive.
+func (s *clusterState) write(dest string, modTime time.Time) error {
+	if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(dest), filepath.Base(dest)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	names := make([]string, 0, len(s.files))
+	for name := range s.files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	gz := gzip.NewWriter(tmp)
+	tw := tar.NewWriter(gz)
+	for _, name := range names {
+		data := s.files[name]
+		header := &tar.Header{
+			Name:    path.Join(s.scope, name),
+			Mode:    0o644,
+			Size:    int64(len(data)),
+			ModTime: modTime,
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			tmp.Close()
+			return err
+		}
+		if _, err := tw.Write(data); err != nil {
+			tmp.Close()
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), dest)
+}
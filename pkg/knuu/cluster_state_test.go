@@ -0,0 +1,124 @@
+package knuu
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/celestiaorg/knuu/pkg/k8s/fake"
+	"github.com/celestiaorg/knuu/pkg/system"
+)
+
+// readArchive returns the files of the gzipped tar archive by name
+func readArchive(t *testing.T, path string) map[string]string {
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	require.NoError(t, err)
+	tr := tar.NewReader(gz)
+
+	files := make(map[string]string)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return files
+		}
+		require.NoError(t, err)
+		data, err := io.ReadAll(tr)
+		require.NoError(t, err)
+		files[header.Name] = string(data)
+	}
+}
+
+func TestCaptureClusterState(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	pod := &v1.Pod{
+		ObjectMeta: knuuMeta("validator-x1", "validator", currentRun, ""),
+		Spec: v1.PodSpec{
+			InitContainers: []v1.Container{{Name: "init"}},
+			Containers:     []v1.Container{{Name: "validator"}},
+		},
+		Status: v1.PodStatus{ContainerStatuses: []v1.ContainerStatus{{Name: "validator", RestartCount: 1}}},
+	}
+	pod.ManagedFields = []metav1.ManagedFieldsEntry{{Manager: "knuu"}}
+	objects := []runtime.Object{
+		pod,
+		&v1.Secret{ObjectMeta: knuuMeta("validator-tls", "validator", currentRun, ""), Data: map[string][]byte{"tls.key": []byte("private")}},
+		// the objects of the namespace that knuu does not manage are not captured
+		&v1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "kube-root-ca.crt", Namespace: "test"}},
+		&v1.Event{
+			ObjectMeta:     metav1.ObjectMeta{Name: "validator-x1.1", Namespace: "test"},
+			InvolvedObject: v1.ObjectReference{Kind: "Pod", Name: "validator-x1"},
+			Reason:         "BackOff",
+		},
+		&v1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: "node-1"},
+			Status:     v1.NodeStatus{Conditions: []v1.NodeCondition{{Type: v1.NodeMemoryPressure, Status: v1.ConditionTrue}}},
+		},
+	}
+	k8sCli, err := fake.New(ctx, "test", objects...)
+	require.NoError(t, err)
+	k := &Knuu{SystemDependencies: system.SystemDependencies{K8sCli: k8sCli, Logger: logrus.New(), TestScope: "test"}}
+
+	dest := filepath.Join(t.TempDir(), "reports", "state.tar.gz")
+	require.NoError(t, k.CaptureClusterState(ctx, dest))
+	files := readArchive(t, dest)
+
+	podYAML := files["test/objects/pods/validator-x1.yaml"]
+	assert.Contains(t, podYAML, "kind: Pod")
+	assert.NotContains(t, podYAML, "managedFields")
+	secretYAML := files["test/objects/secrets/validator-tls.yaml"]
+	assert.Contains(t, secretYAML, "tls.key")
+	assert.NotContains(t, secretYAML, "cHJpdmF0ZQ") // base64 of the value
+	assert.NotContains(t, files, "test/objects/configmaps/kube-root-ca.crt.yaml")
+
+	assert.Contains(t, files["test/events.yaml"], "reason: BackOff")
+	assert.Contains(t, files["test/nodes/node-1.yaml"], "type: MemoryPressure")
+
+	// the previous logs are only captured for the restarted containers
+	assert.Contains(t, files, "test/logs/validator-x1/init.log")
+	assert.Contains(t, files, "test/logs/validator-x1/validator.log")
+	assert.Contains(t, files, "test/logs/validator-x1/validator.previous.log")
+	assert.NotContains(t, files, "test/logs/validator-x1/init.previous.log")
+
+	summary := files["test/summary.yaml"]
+	assert.Contains(t, summary, "pods: 1")
+	assert.Contains(t, summary, "secrets: 1")
+	assert.Contains(t, summary, "configmaps: 0")
+	assert.Contains(t, summary, "errors: 0")
+	assert.NotContains(t, files, "test/errors.txt")
+}
+
+func TestCaptureScopeState(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	k8sCli, err := fake.New(ctx, "test")
+	require.NoError(t, err)
+	clientset := k8sCli.Clientset()
+
+	dest := filepath.Join(t.TempDir(), "state.tar.gz")
+	require.NoError(t, captureScopeState(ctx, clientset, "Test", dest))
+	assert.Contains(t, readArchive(t, dest), "test/summary.yaml")
+
+	// the namespace of a cleaned up test is not recreated
+	err = captureScopeState(ctx, clientset, "cleaned-up", filepath.Join(t.TempDir(), "state.tar.gz"))
+	assert.ErrorIs(t, err, ErrClusterStateNamespaceNotFound)
+	_, err = clientset.CoreV1().Namespaces().Get(ctx, "cleaned-up", metav1.GetOptions{})
+	assert.True(t, apierrs.IsNotFound(err))
+}
@@ -195,14 +195,36 @@ var (
 	ErrCustomResourceDefinitionDoesNotExist      = errors.New("CustomResourceDefinitionDoesNotExist", "custom resource definition %s does not exist")
 	ErrFileIsNotSubFolderOfVolumes               = errors.New("FileIsNotSubFolderOfVolumes", "the file '%s' is not a sub folder of any added volume")
 	ErrCannotInitializeKnuu                      = errors.New("CannotInitializeKnuu", "cannot initialize knuu")
-	ErrCannotDeployTraefik                       = errors.New("CannotDeployTraefik", "cannot deploy Traefik")
 	ErrGettingBitTwisterPath                     = errors.New("GettingBitTwisterPath", "error getting BitTwister path")
 	ErrFailedToAddHostToTraefik                  = errors.New("FailedToAddHostToTraefik", "failed to add host to traefik")
 	ErrParentInstanceIsNil                       = errors.New("ParentInstanceIsNil", "parent instance is nil for the sidecar '%s'")
 	ErrFailedToGetIP                             = errors.New("FailedToGetIP", "failed to get IP for service %s")
 	ErrNoParentInstance                          = errors.New("NoParentInstance", "no parent instance for the sidecar '%s'")
 	ErrAddingToProxy                             = errors.New("AddingToTraefikProxy", "error adding '%s' to traefik proxy for service '%s'")
-	ErrCannotGetTraefikEndpoint                  = errors.New("CannotGetTraefikEndpoint", "cannot get traefik endpoint")
 	ErrGettingProxyURL                           = errors.New("GettingProxyURL", "error getting proxy URL for service '%s'")
 	ErrTraefikAPINotAvailable                    = errors.New("TraefikAPINotAvailable", "traefik API is not available")
+	ErrCannotLoadImageCache                      = errors.New("CannotLoadImageCache", "cannot load image cache")
+	ErrCreatingLogsDir                           = errors.New("CreatingLogsDir", "error creating logs directory '%s'")
+	ErrCollectingLogs                            = errors.New("CollectingLogs", "error collecting logs of instance '%s'")
+	ErrCannotDeployProxy                         = errors.New("CannotDeployProxy", "cannot deploy the proxy")
+	ErrCannotGetProxyEndpoint                    = errors.New("CannotGetProxyEndpoint", "cannot get the proxy endpoint")
+	ErrRecordingResourceUsage                    = errors.New("RecordingResourceUsage", "error recording the resource usage of instance '%s'")
+	ErrCreatingRBACReport                        = errors.New("CreatingRBACReport", "error creating the RBAC report")
+	ErrCannotGrantSecurityContextConstraints     = errors.New("CannotGrantSecurityContextConstraints", "cannot grant the SecurityContextConstraints '%s' to the service account '%s'")
+	ErrPreflightFailed                           = errors.New("PreflightFailed", "the cluster failed the preflight checks: %s")
+	ErrCannotReconcile                           = errors.New("CannotReconcile", "cannot reconcile the orphaned resources")
+	ErrCannotDeployInClusterRegistry             = errors.New("CannotDeployInClusterRegistry", "cannot deploy the in-cluster registry")
+	ErrImageLoaderNeedsDocker                    = errors.New("ImageLoaderNeedsDocker", "the images can only be loaded onto the nodes when built with docker, not with %s")
+	ErrSnapshottingMetrics                       = errors.New("SnapshottingMetrics", "error snapshotting the metrics of instance '%s'")
+	ErrCannotCreateCA                            = errors.New("CannotCreateCA", "cannot create the CA of the scope")
+	ErrUnknownServiceMesh                        = errors.New("UnknownServiceMesh", "unknown service mesh '%s'")
+	ErrCannotLoadUsageHistory                    = errors.New("CannotLoadUsageHistory", "cannot load the usage history")
+	ErrUsageHistoryNotEnabled                    = errors.New("UsageHistoryNotEnabled", "the usage history is not enabled, see WithUsageHistory")
+	ErrLearnedResourcesNeedHistory               = errors.New("LearnedResourcesNeedHistory", "the learned resources need the usage history, see WithUsageHistory")
+	ErrCapturingClusterState                     = errors.New("CapturingClusterState", "error capturing the cluster state of scope '%s'")
+	ErrWritingClusterState                       = errors.New("WritingClusterState", "error writing the cluster state to '%s'")
+	ErrClusterStateNamespaceNotFound             = errors.New("ClusterStateNamespaceNotFound", "namespace '%s' of the test to capture the state of does not exist")
+	ErrAttachingToExisting                       = errors.New("AttachingToExisting", "error attaching to the workloads matching '%v'")
+	ErrNothingToAttach                           = errors.New("NothingToAttach", "no Deployment or StatefulSet matches '%v' in namespace '%s'")
+	ErrRunningImage                              = errors.New("RunningImage", "error running image '%s' as instance '%s'")
 )
@@ -4,12 +4,70 @@ package knuu
 import (
 	"context"
 
+	"github.com/celestiaorg/knuu/pkg/benchmark"
+	"github.com/celestiaorg/knuu/pkg/dnsserver"
 	"github.com/celestiaorg/knuu/pkg/instance"
+	"github.com/celestiaorg/knuu/pkg/loadgen"
 	"github.com/celestiaorg/knuu/pkg/preloader"
 )
 
-func (k *Knuu) NewInstance(name string) (*instance.Instance, error) {
-	return instance.New(name, k.SystemDependencies)
+func (k *Knuu) NewInstance(name string, opts ...instance.Option) (*instance.Instance, error) {
+	k.warnNameCollision(name)
+	if len(k.hooks) != 0 {
+		opts = append(append([]instance.Option(nil), k.hooks...), opts...)
+	}
+	if k.learnResources {
+		opts = append([]instance.Option{instance.WithLifecycleHook(instance.BeforeStart, k.applyLearnedResources)}, opts...)
+	}
+	i, err := instance.New(name, k.SystemDependencies, opts...)
+	if err != nil {
+		return nil, err
+	}
+	k.instancesMu.Lock()
+	defer k.instancesMu.Unlock()
+	k.instances = append(k.instances, i)
+	return i, nil
+}
+
+// NewInstanceGroup returns a group of instances that share the given options, see instance.InstanceGroup
+// The instances of the group are created with NewInstance.
+func (k *Knuu) NewInstanceGroup(name string, opts ...instance.Option) *instance.InstanceGroup {
+	return instance.NewGroup(name, k.NewInstance, opts...)
+}
+
+// StartAll starts the instances in parallel and stops the started ones if one of them fails to start, see
+// instance.StartAll
+func StartAll(ctx context.Context, instances ...*instance.Instance) error {
+	return instance.StartAll(ctx, instances...)
+}
+
+// StartAllWithPolicy starts the instances in parallel and tears down the started ones according to the policy if
+// one of them fails to start, see instance.StartAllWithPolicy
+func StartAllWithPolicy(ctx context.Context, policy instance.StartFailurePolicy, instances ...*instance.Instance) error {
+	return instance.StartAllWithPolicy(ctx, policy, instances...)
+}
+
+// ExecOnAll executes the command in the instances concurrently with bounded parallelism, see instance.ExecOnAll
+func ExecOnAll(ctx context.Context, instances []*instance.Instance, command []string, opts ...instance.ExecOption) instance.ExecResults {
+	return instance.ExecOnAll(ctx, instances, command, opts...)
+}
+
+// Instances returns the instances created with NewInstance, in all states
+func (k *Knuu) Instances() []*instance.Instance {
+	k.instancesMu.Lock()
+	defer k.instancesMu.Unlock()
+	return append([]*instance.Instance(nil), k.instances...)
+}
+
+// NewSupervisor returns a supervisor of the started instances created with NewInstance
+// Run it in a goroutine for the duration of the test.
+func (k *Knuu) NewSupervisor(opts ...instance.SupervisorOption) *instance.Supervisor {
+	return instance.NewSupervisor(k.Instances, opts...)
+}
+
+// NewInstanceBuilder returns a builder to configure a new instance with chained calls
+func (k *Knuu) NewInstanceBuilder(name string) *instance.InstanceBuilder {
+	return instance.NewBuilder(name, k.SystemDependencies)
 }
 
 func (k *Knuu) NewExecutor(ctx context.Context) (*instance.Executor, error) {
@@ -19,3 +77,19 @@ func (k *Knuu) NewExecutor(ctx context.Context) (*instance.Executor, error) {
 func (k *Knuu) NewPreloader() (*preloader.Preloader, error) {
 	return preloader.New(k.SystemDependencies)
 }
+
+// NewDNSServer creates and starts a DNS server to inject resolution failures, see dnsserver.Server
+func (k *Knuu) NewDNSServer(ctx context.Context) (*dnsserver.Server, error) {
+	return dnsserver.New(ctx, k.SystemDependencies, "")
+}
+
+// NewLoadGenerator creates and starts a load generator with the given name, see loadgen.Generator
+func (k *Knuu) NewLoadGenerator(ctx context.Context, name string) (*loadgen.Generator, error) {
+	return loadgen.New(ctx, name, k.SystemDependencies, "")
+}
+
+// NewBenchmark returns a benchmark recorded in the report of the test, see benchmark.Benchmark
+func (k *Knuu) NewBenchmark(name string, generator benchmark.LoadGenerator, attack loadgen.Attack, opts ...benchmark.Option) *benchmark.Benchmark {
+	opts = append([]benchmark.Option{benchmark.WithReporter(k.Reporter)}, opts...)
+	return benchmark.New(name, generator, attack, opts...)
+}
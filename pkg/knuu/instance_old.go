@@ -21,7 +21,7 @@ import (
 )
 
 type Instance struct {
-	instance.Instance
+	*instance.Instance
 }
 
 type Executor struct {
@@ -53,7 +53,7 @@ func NewInstance(name string) (*Instance, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &Instance{*i}, nil
+	return &Instance{i}, nil
 }
 
 // Deprecated: Use the new package knuu instead.
@@ -200,7 +200,7 @@ func (i *Instance) SetStartupProbe(startupProbe *v1.Probe) error {
 
 // Deprecated: Use the new package knuu instead.
 func (i *Instance) AddSidecar(sidecar *Instance) error {
-	return i.Instance.AddSidecar(&sidecar.Instance)
+	return i.Instance.AddSidecar(sidecar.Instance)
 }
 
 // Deprecated: Use the new package knuu instead.
@@ -329,7 +329,7 @@ func (i *Instance) Clone() (*Instance, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &Instance{Instance: *newInst}, nil
+	return &Instance{Instance: newInst}, nil
 }
 
 // Deprecated: Use the new package knuu instead.
@@ -338,7 +338,7 @@ func (i *Instance) CloneWithName(name string) (*Instance, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &Instance{*newInst}, nil
+	return &Instance{newInst}, nil
 }
 
 // Deprecated: Use the new package knuu instead.
@@ -362,7 +362,7 @@ func NewExecutor() (*Executor, error) {
 	}
 	return &Executor{
 		Instance: &Instance{
-			Instance: *e.Instance,
+			Instance: e.Instance,
 		},
 	}, nil
 }
@@ -381,7 +381,7 @@ func (i *Instance) Destroy() error {
 func BatchDestroy(instances ...*Instance) error {
 	ins := make([]*instance.Instance, len(instances))
 	for i, instance := range instances {
-		ins[i] = &instance.Instance
+		ins[i] = instance.Instance
 	}
 	return instance.BatchDestroy(context.Background(), ins...)
 }
@@ -420,7 +420,7 @@ func (i *InstancePool) Instances() []*Instance {
 	instances := i.InstancePool.Instances()
 	newInstances := make([]*Instance, len(instances))
 	for i, instance := range instances {
-		newInstances[i] = &Instance{*instance}
+		newInstances[i] = &Instance{instance}
 	}
 	return newInstances
 }
@@ -10,35 +10,84 @@ import (
 	"runtime"
 	"strconv"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 
 	"github.com/joho/godotenv"
 	"github.com/sirupsen/logrus"
 	rbacv1 "k8s.io/api/rbac/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
 
+	"github.com/celestiaorg/knuu/pkg/annotation"
+	"github.com/celestiaorg/knuu/pkg/artifact"
+	"github.com/celestiaorg/knuu/pkg/budget"
 	"github.com/celestiaorg/knuu/pkg/builder"
+	"github.com/celestiaorg/knuu/pkg/builder/docker"
 	"github.com/celestiaorg/knuu/pkg/builder/kaniko"
+	"github.com/celestiaorg/knuu/pkg/builder/loader"
+	"github.com/celestiaorg/knuu/pkg/builder/registry"
+	"github.com/celestiaorg/knuu/pkg/identity"
 	"github.com/celestiaorg/knuu/pkg/instance"
 	"github.com/celestiaorg/knuu/pkg/k8s"
 	"github.com/celestiaorg/knuu/pkg/minio"
+	"github.com/celestiaorg/knuu/pkg/proxy"
+	"github.com/celestiaorg/knuu/pkg/proxy/route"
+	"github.com/celestiaorg/knuu/pkg/recording"
+	"github.com/celestiaorg/knuu/pkg/report"
+	"github.com/celestiaorg/knuu/pkg/sizing"
 	"github.com/celestiaorg/knuu/pkg/system"
 	"github.com/celestiaorg/knuu/pkg/traefik"
 )
 
 const (
+	// LocalImageRegistry names the images loaded onto the nodes, which are never pulled from it
+	LocalImageRegistry = "knuu.local"
+
 	defaultTimeout     = 60 * time.Minute
 	timeoutHandlerName = "timeout-handler"
 	// FIXME: use supported kubernetes version images (use of latest could break) (https://github.com/celestiaorg/knuu/issues/116)
 	timeoutHandlerImage = "docker.io/bitnami/kubectl:latest"
 
 	TimeFormat = "20060102T150405Z"
+
+	// DefaultSecurityContextConstraints is granted to the pods on OpenShift, it allows the images running as any user
+	DefaultSecurityContextConstraints = "anyuid"
+	// defaultServiceAccount runs the pods of knuu that are not instances, e.g. the image builders and minio
+	defaultServiceAccount = "default"
+	sccRoleBindingName    = "knuu-scc"
 )
 
 type Knuu struct {
 	system.SystemDependencies
-	timeout      time.Duration
-	proxyEnabled bool
+	timeout        time.Duration
+	proxyEnabled   bool
+	newProxy       proxy.Factory
+	imageCacheSize int
+	imageCacheFile string
+	openShift      bool
+	orphanPolicy   *OrphanPolicy
+	// registryNodePort is the node port of the in-cluster registry, nil to push the images to ttl.sh
+	registryNodePort *int32
+	// loadImages loads the built images onto the nodes with imageLoader instead of pushing them
+	loadImages  bool
+	imageLoader builder.ImageLoader
+
+	instancesMu sync.Mutex
+	instances   []*instance.Instance
+
+	// hooks are the options adding the lifecycle hooks of the scope to the instances
+	hooks []instance.Option
+
+	// orphans are the orphaned resources kept by Reconcile
+	orphansMu sync.Mutex
+	orphans   []Orphan
+
+	// usageHistory is the peak usage of the instances in the runs of the test, sampled until stopSampling is called
+	usageHistoryFile string
+	learnResources   bool
+	usageHistory     *sizing.History
+	stopSampling     context.CancelFunc
 }
 
 type Option func(*Knuu)
@@ -62,7 +111,7 @@ func WithTimeout(timeout time.Duration) Option {
 	}
 }
 
-func WithMinio(minio *minio.Minio) Option {
+func WithMinio(minio minio.Client) Option {
 	return func(k *Knuu) {
 		k.MinioCli = minio
 	}
@@ -80,12 +129,177 @@ func WithLogger(logger *logrus.Logger) Option {
 	}
 }
 
+// WithProxyEnabled deploys a Traefik proxy to expose the instances with Instance.AddHost
 func WithProxyEnabled() Option {
 	return func(k *Knuu) {
 		k.proxyEnabled = true
 	}
 }
 
+// WithProxy exposes the instances with the proxy created by the given factory instead of Traefik,
+// e.g. nginx.New or gateway.New to use the ingress controller or the gateway the cluster already runs.
+func WithProxy(newProxy proxy.Factory) Option {
+	return func(k *Knuu) {
+		k.proxyEnabled = true
+		k.newProxy = newProxy
+	}
+}
+
+// WithOpenShift runs the test on an OpenShift cluster, whose restricted SecurityContextConstraints reject most images
+// The pods are granted the given SecurityContextConstraints, DefaultSecurityContextConstraints if empty, e.g. privileged
+// to debug the nodes or to use BitTwister. The instances are exposed with routes, unless WithProxy sets another proxy.
+func WithOpenShift(scc string) Option {
+	return func(k *Knuu) {
+		if scc == "" {
+			scc = DefaultSecurityContextConstraints
+		}
+		k.openShift = true
+		k.SecurityContextConstraints = scc
+	}
+}
+
+// WithServiceMesh injects the pods of the instances in the given service mesh, e.g. for a project deployed under
+// Istio or Linkerd, which must be installed in the cluster
+// The network policies of knuu conflict with the policies of the mesh, so DisableNetwork and BlockExternalEgress
+// fail under a mesh.
+func WithServiceMesh(mesh system.ServiceMesh) Option {
+	return func(k *Knuu) {
+		k.ServiceMesh = mesh
+	}
+}
+
+// WithInClusterRegistry deploys a registry in the cluster and pushes the images built for the instances to it
+// instead of ttl.sh, which is slow and flaky from CI. The nodes pull the images from localhost on the given node port,
+// registry.DefaultNodePort if 0, so containerd must reach localhost with http, see cluster.WithInClusterRegistry for kind.
+// The images built with docker instead of kaniko are pushed from the host, which must reach the node port on localhost.
+func WithInClusterRegistry(nodePort int32) Option {
+	return func(k *Knuu) {
+		k.registryNodePort = &nodePort
+	}
+}
+
+// WithImageLoader loads the images built for the instances onto the nodes instead of pushing them to a registry,
+// e.g. on air-gapped clusters. The images are built with docker, which saves them into archives loaded by the given
+// loader: cluster.Kind loads them with kind, nil loads them with a privileged daemon set, see loader.DaemonSet.
+// The images are named after LocalImageRegistry, which does not exist, so the image signer cannot sign them.
+func WithImageLoader(l builder.ImageLoader) Option {
+	return func(k *Knuu) {
+		k.loadImages = true
+		k.imageLoader = l
+	}
+}
+
+// WithOrphanPolicy reconciles the resources left in the namespace of the test by the previous runs with the same
+// scope when knuu is created, before any resource is created, see Knuu.Reconcile
+func WithOrphanPolicy(policy OrphanPolicy) Option {
+	return func(k *Knuu) {
+		k.orphanPolicy = &policy
+	}
+}
+
+// WithImageCacheSize sets the maximum number of built images that are remembered for reuse.
+// When the cache is full, the least recently used image is evicted.
+func WithImageCacheSize(size int) Option {
+	return func(k *Knuu) {
+		k.imageCacheSize = size
+	}
+}
+
+// WithImageCacheFile persists the image cache to the given file, so that the images
+// built by a previous run of the tests are reused instead of being built again.
+func WithImageCacheFile(path string) Option {
+	return func(k *Knuu) {
+		k.imageCacheFile = path
+	}
+}
+
+// WithPostBuildHook adds a hook that checks the images built for the instances, e.g. builder.CommandHook to scan them
+// An image rejected by a hook fails the commit of the instance.
+func WithPostBuildHook(hook builder.PostBuildHook) Option {
+	return func(k *Knuu) {
+		k.PostBuildHooks = append(k.PostBuildHooks, hook)
+	}
+}
+
+// WithImageSigner signs the images built for the instances once they pass the post build hooks, e.g. with cosign.New
+func WithImageSigner(signer builder.ImageSigner) Option {
+	return func(k *Knuu) {
+		k.ImageSigner = signer
+	}
+}
+
+// WithImageVerifier verifies the signature of the images set on the instances, e.g. with cosign.New
+// An instance cannot use an image that fails the verification.
+func WithImageVerifier(verifier builder.ImageVerifier) Option {
+	return func(k *Knuu) {
+		k.ImageVerifier = verifier
+	}
+}
+
+// WithRegistry sets the client getting the configuration of the images of the instances, e.g. to set the
+// credentials of a private registry or the platform of the nodes, see Instance.ImageConfig
+func WithRegistry(client *registry.Client) Option {
+	return func(k *Knuu) {
+		k.Registry = client
+	}
+}
+
+// WithReporter sets the recorder of the operations of the test, a new one is created by default
+func WithReporter(reporter *report.Recorder) Option {
+	return func(k *Knuu) {
+		k.Reporter = reporter
+	}
+}
+
+// WithRecorder records the operations on the instances in the given recorder, so that they can be replayed
+// with Replay, e.g. to reproduce a bug found in a long test
+func WithRecorder(recorder *recording.Recorder) Option {
+	return func(k *Knuu) {
+		k.Recorder = recorder
+	}
+}
+
+// WithAnnotator marks the faults injected in the instances, e.g. a disabled network or a latency, on the timeline
+// of the observability tools with the given annotator, see annotation.Grafana and annotation.OTel
+func WithAnnotator(annotator annotation.Annotator) Option {
+	return func(k *Knuu) {
+		k.Annotator = annotator
+	}
+}
+
+// WithLifecycleHook adds a hook called at the given event of the lifecycle of each instance created with
+// NewInstance, before the hooks of the instance, e.g. to seed the data of all the instances of the test
+func WithLifecycleHook(event instance.LifecycleEvent, hook instance.LifecycleHook) Option {
+	return func(k *Knuu) {
+		k.hooks = append(k.hooks, instance.WithLifecycleHook(event, hook))
+	}
+}
+
+// WithUsageHistory samples the usage of the started instances with the metrics API and adds their peak usage to
+// the history in the given file at CleanUp, by instance name, to suggest their resources, see SuggestResources
+func WithUsageHistory(path string) Option {
+	return func(k *Knuu) {
+		k.usageHistoryFile = path
+	}
+}
+
+// WithLearnedResources requests the resources learned from the usage history of the previous runs for the
+// instances whose CPU or memory request is not set, when they are started for the first time, see WithUsageHistory
+// The usage is measured by pod, so the instances with sidecars are not sized.
+func WithLearnedResources() Option {
+	return func(k *Knuu) {
+		k.learnResources = true
+	}
+}
+
+// WithCA issues the TLS certificates of the instances with the given CA, e.g. to share the CA of several scopes,
+// instead of a CA created for the scope
+func WithCA(ca *identity.CA) Option {
+	return func(k *Knuu) {
+		k.CA = ca
+	}
+}
+
 func New(ctx context.Context, opts ...Option) (*Knuu, error) {
 	if err := godotenv.Load(); err != nil {
 		if !os.IsNotExist(err) {
@@ -101,6 +315,13 @@ func New(ctx context.Context, opts ...Option) (*Knuu, error) {
 
 	k.StartTime = time.Now().UTC().Format(TimeFormat)
 
+	if !k.ServiceMesh.IsValid() {
+		return nil, ErrUnknownServiceMesh.WithParams(k.ServiceMesh)
+	}
+	if k.learnResources && k.usageHistoryFile == "" {
+		return nil, ErrLearnedResourcesNeedHistory
+	}
+
 	// handle default values
 	if k.Logger == nil {
 		k.Logger = defaultLogger()
@@ -115,6 +336,18 @@ func New(ctx context.Context, opts ...Option) (*Knuu, error) {
 		k.timeout = defaultTimeout
 	}
 
+	if k.CA == nil {
+		var err error
+		k.CA, err = identity.NewCA("knuu-" + k.TestScope)
+		if err != nil {
+			return nil, ErrCannotCreateCA.Wrap(err)
+		}
+	}
+
+	if k.Reporter == nil {
+		k.Reporter = report.NewRecorder(k.TestScope)
+	}
+
 	if k.K8sCli == nil {
 		var err error
 		k.K8sCli, err = k8s.New(ctx, k.TestScope)
@@ -123,41 +356,91 @@ func New(ctx context.Context, opts ...Option) (*Knuu, error) {
 		}
 	}
 
+	if k.openShift {
+		if err := k.grantSecurityContextConstraints(ctx); err != nil {
+			return nil, err
+		}
+		k.proxyEnabled = true
+		if k.newProxy == nil {
+			k.newProxy = route.New()
+		}
+	}
+
 	if k.MinioCli == nil {
-		// TODO: minio also needs a little refactor to accept k8s obj instead
-		k.MinioCli = &minio.Minio{
-			Clientset: k.K8sCli.Clientset(),
-			Namespace: k.K8sCli.Namespace(),
+		k.MinioCli = minio.New(k.K8sCli.Clientset(), k.K8sCli.Namespace())
+	}
+
+	if k.Artifacts == nil {
+		k.Artifacts = artifact.NewStore(k.MinioCli)
+	}
+
+	if k.loadImages {
+		if err := k.useImageLoader(); err != nil {
+			return nil, err
 		}
 	}
 
 	if k.ImageBuilder == nil {
-		// TODO: Also here for kaniko
-		k.ImageBuilder = &kaniko.Kaniko{
-			K8sClientset: k.K8sCli.Clientset(),
-			K8sNamespace: k.K8sCli.Namespace(),
-			Minio:        k.MinioCli,
+		k.ImageBuilder = kaniko.New(k.K8sCli.Clientset(), k.K8sCli.Namespace(), k.MinioCli)
+	}
+
+	if k.registryNodePort != nil {
+		if err := k.deployInClusterRegistry(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	if k.usageHistoryFile != "" {
+		var err error
+		k.usageHistory, err = sizing.Load(k.usageHistoryFile, 0)
+		if err != nil {
+			return nil, ErrCannotLoadUsageHistory.Wrap(err)
+		}
+	}
+
+	if k.ImageCache == nil {
+		if k.imageCacheFile == "" {
+			k.ImageCache = system.NewImageCache(k.imageCacheSize)
+		} else {
+			var err error
+			k.ImageCache, err = system.NewPersistentImageCache(k.imageCacheSize, k.imageCacheFile, system.DefaultPersistedImageTTL)
+			if err != nil {
+				return nil, ErrCannotLoadImageCache.Wrap(err)
+			}
 		}
 	}
 
 	if k.proxyEnabled {
-		k.Proxy = &traefik.Traefik{
-			K8s: k.K8sCli,
+		if k.newProxy == nil {
+			k.newProxy = func(k8sCli k8s.KubeManager) proxy.Proxy {
+				return &traefik.Traefik{K8s: k8sCli}
+			}
 		}
+		k.Proxy = k.newProxy(k.K8sCli)
 		if err := k.Proxy.Deploy(ctx); err != nil {
-			return nil, ErrCannotDeployTraefik.Wrap(err)
+			return nil, ErrCannotDeployProxy.Wrap(err)
 		}
 		endpoint, err := k.Proxy.Endpoint(ctx)
 		if err != nil {
-			return nil, ErrCannotGetTraefikEndpoint.Wrap(err)
+			return nil, ErrCannotGetProxyEndpoint.Wrap(err)
 		}
 		k.Logger.Debugf("Proxy endpoint: %s", endpoint)
 	}
 
+	if k.orphanPolicy != nil {
+		if _, err := k.Reconcile(ctx, *k.orphanPolicy); err != nil {
+			return nil, err
+		}
+	}
+
 	if err := k.handleTimeout(ctx); err != nil {
 		return nil, ErrCannotHandleTimeout.Wrap(err)
 	}
 
+	if k.usageHistory != nil {
+		k.startSampling(ctx)
+	}
+
 	return k, nil
 }
 
@@ -165,8 +448,104 @@ func (k *Knuu) Scope() string {
 	return k.TestScope
 }
 
+// ImageCacheStats returns the statistics of the image cache, useful to debug the reuse of built images
+func (k *Knuu) ImageCacheStats() system.ImageCacheStats {
+	return k.ImageCache.Stats()
+}
+
+// ArtifactStore returns the store of the artifacts of the test, see Instance.UploadArtifact
+// The artifacts are kept in the namespace of the test, download them before CleanUp.
+func (k *Knuu) ArtifactStore() *artifact.Store {
+	return k.Artifacts
+}
+
+// CleanUp snapshots the metrics and records the resource usage of the started instances and deletes the namespace of
+// the test. The snapshots of the metrics, see SnapshotAllMetrics, only outlive the namespace with the minio of another
+// namespace, see WithMinio.
+// The admission webhooks of the test, see webhook.Deploy, are unregistered first, so that they do not intercept the
+// deletion of the namespace, and its cluster roles and their bindings are deleted, they would outlive the namespace.
+// Save the report afterwards to include the usage. The peak usage is added to the usage history, see WithUsageHistory.
+// The cleanup is accounted in the teardown phase of the budget of the context, see WithDeadline.
 func (k *Knuu) CleanUp(ctx context.Context) error {
-	return k.K8sCli.DeleteNamespace(ctx, k.TestScope)
+	ctx, span := budget.Begin(ctx, budget.PhaseTeardown)
+	if err := k.SnapshotAllMetrics(ctx); err != nil {
+		k.Logger.Warnf("Error snapshotting the metrics: %v", err)
+	}
+	if err := k.RecordResourceUsage(ctx); err != nil {
+		k.Logger.Warnf("Error recording the resource usage: %v", err)
+	}
+	if err := k.saveUsageHistory(); err != nil {
+		k.Logger.Warnf("Error saving the usage history: %v", err)
+	}
+	// the configurations are cluster scoped, they are garbage collected after the namespace otherwise
+	scope := map[string]string{scopeLabel: k.TestScope}
+	if err := k.K8sCli.DeleteValidatingWebhookConfigurations(ctx, scope); err != nil {
+		k.Logger.Warnf("Error unregistering the webhooks: %v", err)
+	}
+	// the cluster roles, e.g. of the kubeletstats receivers, are cluster scoped and never garbage collected
+	if err := k.K8sCli.DeleteClusterRoleBindings(ctx, scope); err != nil {
+		k.Logger.Warnf("Error deleting the cluster role bindings: %v", err)
+	}
+	if err := k.K8sCli.DeleteClusterRoles(ctx, scope); err != nil {
+		k.Logger.Warnf("Error deleting the cluster roles: %v", err)
+	}
+	return span.End(k.K8sCli.DeleteNamespace(ctx, k.TestScope))
+}
+
+// WithDeadline returns a copy of the context bounded by the given time budget, which the instances started with
+// the context enforce across their build (see Instance.CommitWithContext), deploy, readiness and teardown
+// e.g. WithDeadline(ctx, budget.Budget{Total: 20 * time.Minute, Build: 5 * time.Minute, Teardown: 2 * time.Minute}).
+// An operation that fails once the time of its phase is spent returns a *budget.OverrunError reporting the phase
+// that overran and the time spent in each phase, e.g. "build exceeded 5m0s (spent: build 5m0s, ...)".
+func WithDeadline(ctx context.Context, b budget.Budget) (context.Context, context.CancelFunc) {
+	return budget.WithBudget(ctx, b)
+}
+
+// grantSecurityContextConstraints grants the SecurityContextConstraints to the default service account of the namespace,
+// which runs the pods of knuu, the instances are granted them by their own service account
+func (k *Knuu) grantSecurityContextConstraints(ctx context.Context) error {
+	clusterRole := k8s.SCCClusterRole(k.SecurityContextConstraints)
+	err := k.K8sCli.CreateRoleBindingToClusterRole(ctx, sccRoleBindingName, nil, clusterRole, defaultServiceAccount)
+	if err != nil && !apierrs.IsAlreadyExists(err) {
+		return ErrCannotGrantSecurityContextConstraints.WithParams(k.SecurityContextConstraints, defaultServiceAccount).Wrap(err)
+	}
+	return nil
+}
+
+// deployInClusterRegistry deploys the in-cluster registry and makes it the registry of the built images,
+// the kaniko builds push them to its service as they do not reach it on localhost
+func (k *Knuu) deployInClusterRegistry(ctx context.Context) error {
+	r := registry.NewInCluster(k.K8sCli.Clientset(), *k.registryNodePort)
+	if err := r.Deploy(ctx); err != nil {
+		return ErrCannotDeployInClusterRegistry.Wrap(err)
+	}
+	k.ImageRegistry = r.Host()
+	k.TransientImageRegistry = true
+	if kb, ok := k.ImageBuilder.(*kaniko.Kaniko); ok {
+		kb.AddRegistryAlias(r.Host(), r.ServiceHost())
+	}
+	k.Logger.Debugf("Pushing the images to the in-cluster registry %s", k.ImageRegistry)
+	return nil
+}
+
+// useImageLoader makes the docker builder load the built images onto the nodes, kaniko cannot as it builds them in the cluster
+func (k *Knuu) useImageLoader() error {
+	if k.ImageBuilder == nil {
+		k.ImageBuilder = &docker.Docker{K8sClientset: k.K8sCli.Clientset(), K8sNamespace: k.K8sCli.Namespace()}
+	}
+	db, ok := k.ImageBuilder.(*docker.Docker)
+	if !ok {
+		return ErrImageLoaderNeedsDocker.WithParams(fmt.Sprintf("%T", k.ImageBuilder))
+	}
+	if k.imageLoader == nil {
+		k.imageLoader = loader.NewDaemonSet(k.K8sCli.Clientset(), k.K8sCli.Namespace(), k.MinioCli)
+	}
+	db.Loader = k.imageLoader
+	if k.ImageRegistry == "" {
+		k.ImageRegistry = LocalImageRegistry
+	}
+	k.TransientImageRegistry = true
+	return nil
 }
 
 func (k *Knuu) HandleStopSignal() {
@@ -204,7 +583,7 @@ func (k *Knuu) handleTimeout(ctx context.Context) error {
 	// Collects all resources (pods, services, etc.) within the specified namespace that match a specific label, excluding certain types,
 	// and then deletes them. This is useful for cleaning up specific test resources before proceeding to delete the namespace.
 	commands = append(commands,
-		fmt.Sprintf("kubectl get all,pvc,netpol,roles,serviceaccounts,rolebindings,configmaps -l knuu.sh/scope=%s -n %s -o json | jq -r '.items[] | select(.metadata.labels.\"knuu.sh/type\" != \"%s\") | \"\\(.kind)/\\(.metadata.name)\"' | xargs -r kubectl delete -n %s",
+		fmt.Sprintf("kubectl get all,pvc,netpol,roles,serviceaccounts,rolebindings,configmaps,secrets -l knuu.sh/scope=%s -n %s -o json | jq -r '.items[] | select(.metadata.labels.\"knuu.sh/type\" != \"%s\") | \"\\(.kind)/\\(.metadata.name)\"' | xargs -r kubectl delete -n %s",
 			k.TestScope, k.K8sCli.Namespace(), instance.TimeoutHandlerInstance.String(), k.K8sCli.Namespace()))
 
 	// Delete the namespace as it was created by knuu.
@@ -213,7 +592,7 @@ func (k *Knuu) handleTimeout(ctx context.Context) error {
 
 	// Delete all labeled resources within the namespace.
 	// Unlike the previous command that excludes certain types, this command ensures that everything remaining is deleted.
-	commands = append(commands, fmt.Sprintf("kubectl delete all,pvc,netpol,roles,serviceaccounts,rolebindings,configmaps -l knuu.sh/scope=%s -n %s", k.TestScope, k.K8sCli.Namespace()))
+	commands = append(commands, fmt.Sprintf("kubectl delete all,pvc,netpol,roles,serviceaccounts,rolebindings,configmaps,secrets -l knuu.sh/scope=%s -n %s", k.TestScope, k.K8sCli.Namespace()))
 
 	finalCmd := strings.Join(commands, " && ")
 
@@ -81,11 +81,7 @@ func InitializeWithScope(testScope string) error {
 	builderType := os.Getenv("KNUU_BUILDER")
 	switch builderType {
 	case "kubernetes":
-		tmpKnuu.ImageBuilder = &kaniko.Kaniko{
-			K8sClientset: tmpKnuu.K8sCli.Clientset(),
-			K8sNamespace: tmpKnuu.K8sCli.Namespace(),
-			Minio:        tmpKnuu.MinioCli,
-		}
+		tmpKnuu.ImageBuilder = kaniko.New(tmpKnuu.K8sCli.Clientset(), tmpKnuu.K8sCli.Namespace(), tmpKnuu.MinioCli)
 	case "docker", "":
 		tmpKnuu.ImageBuilder = &docker.Docker{
 			K8sClientset: tmpKnuu.K8sCli.Clientset(),
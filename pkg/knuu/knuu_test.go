@@ -26,7 +26,7 @@ type mockK8s struct {
 	mock.Mock
 }
 
-func (m *mockK8s) Clientset() *kubernetes.Clientset {
+func (m *mockK8s) Clientset() kubernetes.Interface {
 	return &kubernetes.Clientset{}
 }
 
@@ -74,6 +74,7 @@ func TestNew(t *testing.T) {
 				assert.NotNil(t, k.K8sCli)
 				assert.NotNil(t, k.MinioCli)
 				assert.NotNil(t, k.ImageBuilder)
+				assert.NotNil(t, k.ImageCache)
 				assert.Equal(t, defaultTimeout, k.timeout)
 			},
 		},
@@ -132,6 +133,17 @@ func TestNew(t *testing.T) {
 				assert.NotNil(t, k.ImageBuilder)
 			},
 		},
+		{
+			name: "With custom Image cache size",
+			options: []Option{
+				WithImageCacheSize(10),
+			},
+			expectError: false,
+			validateFunc: func(t *testing.T, k *Knuu) {
+				assert.NotNil(t, k)
+				assert.Equal(t, 10, k.ImageCacheStats().MaxSize)
+			},
+		},
 	}
 
 	for _, tc := range tt {
@@ -0,0 +1,72 @@
+package knuu
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/celestiaorg/knuu/pkg/minio"
+	"github.com/celestiaorg/knuu/pkg/system"
+)
+
+// fakeMinio keeps the pushed files in memory
+type fakeMinio struct {
+	minio.Client
+	deployed    bool
+	deployCalls int
+	files       map[string]string
+}
+
+func (f *fakeMinio) IsMinioDeployed(_ context.Context) (bool, error) {
+	return f.deployed, nil
+}
+
+func (f *fakeMinio) DeployMinio(_ context.Context) error {
+	f.deployed = true
+	f.deployCalls++
+	return nil
+}
+
+func (f *fakeMinio) PushToMinio(_ context.Context, localReader io.Reader, minioFilePath, bucketName string) error {
+	data, err := io.ReadAll(localReader)
+	if err != nil {
+		return err
+	}
+	if f.files == nil {
+		f.files = make(map[string]string)
+	}
+	f.files[bucketName+"/"+minioFilePath] = string(data)
+	return nil
+}
+
+func (f *fakeMinio) GetMinioURL(_ context.Context, minioFilePath, bucketName string) (string, error) {
+	return "http://minio.test/" + bucketName + "/" + minioFilePath, nil
+}
+
+func TestPushFileToMinio(t *testing.T) {
+	t.Parallel()
+
+	m := &fakeMinio{}
+	k := &Knuu{SystemDependencies: system.SystemDependencies{MinioCli: m}}
+	ctx := context.Background()
+
+	require.NoError(t, k.PushFileToMinio(ctx, "genesis.json", strings.NewReader("{}")))
+	url, err := k.GetMinioURL(ctx, "genesis.json")
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, m.deployCalls, "minio is deployed once")
+	assert.Equal(t, "{}", m.files[minioBucketName+"/genesis.json"])
+	assert.Equal(t, "http://minio.test/"+minioBucketName+"/genesis.json", url)
+}
+
+func TestPushFileToMinioNotInitialized(t *testing.T) {
+	t.Parallel()
+
+	k := &Knuu{}
+	err := k.PushFileToMinio(context.Background(), "genesis.json", strings.NewReader("{}"))
+	assert.ErrorIs(t, err, ErrMinioNotInitialized)
+}
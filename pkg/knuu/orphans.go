@@ -0,0 +1,180 @@
+package knuu
+
+import (
+	"context"
+	"fmt"
+
+	appv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	scopeLabel       = "knuu.sh/scope"
+	testStartedLabel = "knuu.sh/test-started"
+	nameLabel        = "knuu.sh/name"
+	managedByLabel   = "k8s.kubernetes.io/managed-by"
+	// adoptedByAnnotation is set to the start time of the run that adopted a resource of another run
+	adoptedByAnnotation = "knuu.sh/adopted-by"
+
+	kindPod        = "Pod"
+	kindReplicaSet = "ReplicaSet"
+)
+
+// OrphanPolicy is what Reconcile does with the orphaned resources
+type OrphanPolicy int
+
+const (
+	// OrphanWarn only logs the orphaned resources
+	OrphanWarn OrphanPolicy = iota
+	// OrphanAdopt keeps the orphaned resources running and marks them as resources of the current run,
+	// they are deleted with the namespace of the test by the current run
+	OrphanAdopt
+	// OrphanDelete deletes the orphaned resources
+	OrphanDelete
+)
+
+func (p OrphanPolicy) String() string {
+	switch p {
+	case OrphanAdopt:
+		return "adopt"
+	case OrphanDelete:
+		return "delete"
+	default:
+		return "warn"
+	}
+}
+
+// Orphan is a pod or a replicaSet of knuu that the current run did not create, or whose replicaSet is gone
+type Orphan struct {
+	// Kind is Pod or ReplicaSet
+	Kind string
+	Name string
+	// Instance is the name of the instance the resource was created for
+	Instance string
+	// TestStarted is the start time of the run that created the resource
+	TestStarted string
+	Reason      string
+}
+
+func (o Orphan) String() string {
+	return fmt.Sprintf("%s/%s of instance '%s' (%s)", o.Kind, o.Name, o.Instance, o.Reason)
+}
+
+// ReconcileReport lists the orphaned resources found by Reconcile and what was done with them
+type ReconcileReport struct {
+	Policy  OrphanPolicy
+	Orphans []Orphan
+}
+
+// Reconcile finds the pods and the replicaSets of knuu in the namespace of the test that the current run
+// does not manage: the ones left by a previous run with the same scope and the pods whose replicaSet was
+// deleted. They are logged, adopted or deleted depending on the policy.
+// The orphans that are kept are compared with the names of the instances created afterwards, so that the
+// collisions of names are warned about. Beware that the timeout handler of a previous run deletes the
+// resources of the scope when its timeout expires, whatever the policy.
+func (k *Knuu) Reconcile(ctx context.Context, policy OrphanPolicy) (*ReconcileReport, error) {
+	selector := map[string]string{scopeLabel: k.TestScope, managedByLabel: "knuu"}
+	replicaSets, err := k.K8sCli.ListReplicaSets(ctx, selector)
+	if err != nil {
+		return nil, ErrCannotReconcile.Wrap(err)
+	}
+	pods, err := k.K8sCli.ListPods(ctx, selector)
+	if err != nil {
+		return nil, ErrCannotReconcile.Wrap(err)
+	}
+
+	report := &ReconcileReport{Policy: policy, Orphans: k.findOrphans(replicaSets, pods)}
+	for _, o := range report.Orphans {
+		if err := k.reconcileOrphan(ctx, o, policy); err != nil {
+			return report, ErrCannotReconcile.Wrap(err)
+		}
+	}
+
+	if policy != OrphanDelete {
+		k.orphansMu.Lock()
+		k.orphans = append(k.orphans, report.Orphans...)
+		k.orphansMu.Unlock()
+	}
+	return report, nil
+}
+
+// findOrphans returns the orphans among the given resources
+// The pods of an orphaned replicaSet are not orphans themselves, they follow their replicaSet.
+func (k *Knuu) findOrphans(replicaSets []appv1.ReplicaSet, pods []v1.Pod) []Orphan {
+	var orphans []Orphan
+	existing := make(map[string]bool)
+	for _, rs := range replicaSets {
+		existing[rs.Name] = true
+		if k.ownsResource(rs.ObjectMeta) {
+			continue
+		}
+		orphans = append(orphans, newOrphan(kindReplicaSet, rs.ObjectMeta, "left by the run started at "+rs.Labels[testStartedLabel]))
+	}
+
+	for _, pod := range pods {
+		owner := metav1.GetControllerOf(&pod)
+		switch {
+		case owner != nil && owner.Kind == kindReplicaSet && !existing[owner.Name]:
+			orphans = append(orphans, newOrphan(kindPod, pod.ObjectMeta, fmt.Sprintf("its replicaSet '%s' was deleted", owner.Name)))
+		case owner == nil && !k.ownsResource(pod.ObjectMeta):
+			orphans = append(orphans, newOrphan(kindPod, pod.ObjectMeta, "left by the run started at "+pod.Labels[testStartedLabel]))
+		}
+	}
+	return orphans
+}
+
+// ownsResource returns true if the resource was created or adopted by the current run
+func (k *Knuu) ownsResource(meta metav1.ObjectMeta) bool {
+	return meta.Labels[testStartedLabel] == k.StartTime || meta.Annotations[adoptedByAnnotation] == k.StartTime
+}
+
+func newOrphan(kind string, meta metav1.ObjectMeta, reason string) Orphan {
+	return Orphan{
+		Kind:        kind,
+		Name:        meta.Name,
+		Instance:    meta.Labels[nameLabel],
+		TestStarted: meta.Labels[testStartedLabel],
+		Reason:      reason,
+	}
+}
+
+func (k *Knuu) reconcileOrphan(ctx context.Context, o Orphan, policy OrphanPolicy) error {
+	switch policy {
+	case OrphanAdopt:
+		annotations := map[string]string{adoptedByAnnotation: k.StartTime}
+		annotate := k.K8sCli.AnnotatePod
+		if o.Kind == kindReplicaSet {
+			annotate = k.K8sCli.AnnotateReplicaSet
+		}
+		if err := annotate(ctx, o.Name, annotations); err != nil {
+			return err
+		}
+		k.Logger.Infof("Adopted the orphan %s", o)
+	case OrphanDelete:
+		grace := int64(0)
+		deleteResource := k.K8sCli.DeletePodWithGracePeriod
+		if o.Kind == kindReplicaSet {
+			deleteResource = k.K8sCli.DeleteReplicaSetWithGracePeriod
+		}
+		if err := deleteResource(ctx, o.Name, &grace); err != nil {
+			return err
+		}
+		k.Logger.Infof("Deleted the orphan %s", o)
+	default:
+		k.Logger.Warnf("Found the orphan %s", o)
+	}
+	return nil
+}
+
+// warnNameCollision warns if an orphan that was kept belongs to an instance with the given name,
+// as both are then selected by the label knuu.sh/name
+func (k *Knuu) warnNameCollision(name string) {
+	k.orphansMu.Lock()
+	defer k.orphansMu.Unlock()
+	for _, o := range k.orphans {
+		if o.Instance == name {
+			k.Logger.Warnf("The instance '%s' collides with the orphan %s, select the instances by their label knuu.sh/k8s-name", name, o)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package knuu
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	logtest "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/ptr"
+
+	"github.com/celestiaorg/knuu/pkg/k8s/fake"
+	"github.com/celestiaorg/knuu/pkg/system"
+)
+
+const (
+	previousRun = "20240101T000000Z"
+	currentRun  = "20240102T000000Z"
+)
+
+func knuuMeta(name, instanceName, testStarted string, owner string) metav1.ObjectMeta {
+	meta := metav1.ObjectMeta{
+		Name:      name,
+		Namespace: "test",
+		Labels: map[string]string{
+			scopeLabel:       "test",
+			managedByLabel:   "knuu",
+			nameLabel:        instanceName,
+			testStartedLabel: testStarted,
+		},
+	}
+	if owner != "" {
+		meta.OwnerReferences = []metav1.OwnerReference{{Kind: kindReplicaSet, Name: owner, Controller: ptr.To(true)}}
+	}
+	return meta
+}
+
+// orphanedResources returns the resources of a previous run and of the current run
+func orphanedResources() []runtime.Object {
+	return []runtime.Object{
+		&appv1.ReplicaSet{ObjectMeta: knuuMeta("validator-old", "validator", previousRun, "")},
+		&v1.Pod{ObjectMeta: knuuMeta("validator-old-x1", "validator", previousRun, "validator-old")},
+		&v1.Pod{ObjectMeta: knuuMeta("bridge-old", "bridge", previousRun, "")},
+		&v1.Pod{ObjectMeta: knuuMeta("full-x1", "full", currentRun, "full")},
+		&appv1.ReplicaSet{ObjectMeta: knuuMeta("light", "light", currentRun, "")},
+		&v1.Pod{ObjectMeta: knuuMeta("light-x1", "light", currentRun, "light")},
+	}
+}
+
+func newReconcileKnuu(t *testing.T) (*Knuu, *fake.Client, *logtest.Hook) {
+	k8sCli, err := fake.New(context.Background(), "test", orphanedResources()...)
+	require.NoError(t, err)
+	logger, hook := logtest.NewNullLogger()
+	logger.SetLevel(logrus.DebugLevel)
+	k := &Knuu{SystemDependencies: system.SystemDependencies{
+		K8sCli:    k8sCli,
+		Logger:    logger,
+		TestScope: "test",
+		StartTime: currentRun,
+	}}
+	return k, k8sCli, hook
+}
+
+func TestReconcile(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	k, _, hook := newReconcileKnuu(t)
+	report, err := k.Reconcile(ctx, OrphanWarn)
+	require.NoError(t, err)
+	assert.Equal(t, []Orphan{
+		{Kind: kindReplicaSet, Name: "validator-old", Instance: "validator", TestStarted: previousRun, Reason: "left by the run started at " + previousRun},
+		{Kind: kindPod, Name: "bridge-old", Instance: "bridge", TestStarted: previousRun, Reason: "left by the run started at " + previousRun},
+		{Kind: kindPod, Name: "full-x1", Instance: "full", TestStarted: currentRun, Reason: "its replicaSet 'full' was deleted"},
+	}, report.Orphans)
+	assert.Len(t, hook.AllEntries(), 3)
+
+	// the orphans that are kept collide with the new instances of the same name
+	hook.Reset()
+	_, err = k.NewInstance("validator")
+	require.NoError(t, err)
+	require.Len(t, hook.AllEntries(), 1)
+	assert.Equal(t, logrus.WarnLevel, hook.LastEntry().Level)
+	assert.Contains(t, hook.LastEntry().Message, "collides with the orphan ReplicaSet/validator-old")
+}
+
+func TestReconcileAdopt(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	k, k8sCli, _ := newReconcileKnuu(t)
+	_, err := k.Reconcile(ctx, OrphanAdopt)
+	require.NoError(t, err)
+
+	rs, err := k8sCli.FakeClientset.AppsV1().ReplicaSets("test").Get(ctx, "validator-old", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, currentRun, rs.Annotations[adoptedByAnnotation])
+
+	// the adopted resources are not orphans anymore, except the pods whose replicaSet is gone
+	report, err := k.Reconcile(ctx, OrphanWarn)
+	require.NoError(t, err)
+	require.Len(t, report.Orphans, 1)
+	assert.Equal(t, "full-x1", report.Orphans[0].Name)
+}
+
+func TestReconcileDelete(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	k, k8sCli, _ := newReconcileKnuu(t)
+	_, err := k.Reconcile(ctx, OrphanDelete)
+	require.NoError(t, err)
+
+	replicaSets, err := k8sCli.ListReplicaSets(ctx, nil)
+	require.NoError(t, err)
+	require.Len(t, replicaSets, 1)
+	assert.Equal(t, "light", replicaSets[0].Name)
+
+	pods, err := k8sCli.ListPods(ctx, nil)
+	require.NoError(t, err)
+	var names []string
+	for _, pod := range pods {
+		names = append(names, pod.Name)
+	}
+	// the fake clientset does not delete the pods of the deleted replicaSets
+	assert.ElementsMatch(t, []string{"validator-old-x1", "light-x1"}, names)
+	assert.Empty(t, k.orphans)
+}
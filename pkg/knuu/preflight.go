@@ -0,0 +1,325 @@
+package knuu
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	v1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/celestiaorg/knuu/pkg/retry"
+)
+
+const (
+	// PreflightRegistry is the registry the images built by knuu are pushed to and pulled from by the nodes
+	PreflightRegistry = "ttl.sh"
+
+	preflightPodName  = "knuu-preflight-registry"
+	preflightPodImage = "docker.io/library/busybox:1.36"
+	// defaultStorageClassAnnotation marks the storage class used by the volumes that do not set one
+	defaultStorageClassAnnotation = "storageclass.kubernetes.io/is-default-class"
+	kubeSystemNamespace           = "kube-system"
+)
+
+// registryCheckPolicy bounds the time to pull the image of the registry check and to connect to the registry
+var registryCheckPolicy = retry.Constant(1 * time.Second).WithTimeout(2 * time.Minute)
+
+// policyEnforcingCNIs are the network plugins known to enforce the network policies, found by the name of their daemon set
+var policyEnforcingCNIs = []string{"calico", "cilium", "antrea", "weave-net", "kube-router", "canal"}
+
+// preflightPermission is a permission knuu needs in the namespace of the test, or on the cluster if cluster is true
+type preflightPermission struct {
+	group       string
+	resource    string
+	subresource string
+	verb        string
+	cluster     bool
+}
+
+func (p preflightPermission) String() string {
+	resource := p.resource
+	if p.subresource != "" {
+		resource += "/" + p.subresource
+	}
+	if p.group != "" {
+		resource += "." + p.group
+	}
+	return p.verb + " " + resource
+}
+
+var preflightPermissions = []preflightPermission{
+	{resource: "pods", verb: "create"},
+	{resource: "pods", verb: "delete"},
+	{resource: "pods", verb: "list"},
+	{resource: "pods", subresource: "log", verb: "get"},
+	{resource: "pods", subresource: "exec", verb: "create"},
+	{resource: "pods", subresource: "portforward", verb: "create"},
+	{resource: "services", verb: "create"},
+	{resource: "configmaps", verb: "create"},
+	{resource: "persistentvolumeclaims", verb: "create"},
+	{resource: "serviceaccounts", verb: "create"},
+	{group: "apps", resource: "replicasets", verb: "create"},
+	{group: "rbac.authorization.k8s.io", resource: "roles", verb: "create"},
+	{group: "rbac.authorization.k8s.io", resource: "rolebindings", verb: "create"},
+	{group: "networking.k8s.io", resource: "networkpolicies", verb: "create"},
+	{resource: "namespaces", verb: "delete", cluster: true},
+}
+
+// PreflightStatus is the outcome of a preflight check
+type PreflightStatus string
+
+const (
+	PreflightPassed PreflightStatus = "passed"
+	// PreflightWarning means that only some features of knuu will not work, e.g. the volumes
+	PreflightWarning PreflightStatus = "warning"
+	PreflightFailed  PreflightStatus = "failed"
+)
+
+// PreflightCheck is the outcome of a check of the cluster
+type PreflightCheck struct {
+	Name    string
+	Status  PreflightStatus
+	Message string
+}
+
+// PreflightReport lists the checks of the cluster made by Knuu.Preflight
+type PreflightReport struct {
+	Checks []PreflightCheck
+}
+
+// Passed returns true if no check failed, the warnings are allowed
+func (r *PreflightReport) Passed() bool {
+	return len(r.failed()) == 0
+}
+
+// Err returns an error naming the failed checks, nil if no check failed
+func (r *PreflightReport) Err() error {
+	failed := r.failed()
+	if len(failed) == 0 {
+		return nil
+	}
+	return ErrPreflightFailed.WithParams(strings.Join(failed, ", "))
+}
+
+// String returns a line per check
+func (r *PreflightReport) String() string {
+	var sb strings.Builder
+	for _, c := range r.Checks {
+		fmt.Fprintf(&sb, "%-8s %s: %s\n", c.Status, c.Name, c.Message)
+	}
+	return sb.String()
+}
+
+func (r *PreflightReport) failed() []string {
+	var failed []string
+	for _, c := range r.Checks {
+		if c.Status == PreflightFailed {
+			failed = append(failed, c.Name)
+		}
+	}
+	return failed
+}
+
+func (r *PreflightReport) add(name string, status PreflightStatus, format string, args ...interface{}) {
+	r.Checks = append(r.Checks, PreflightCheck{Name: name, Status: status, Message: fmt.Sprintf(format, args...)})
+}
+
+// Preflight checks that the cluster can run the tests: that it is reachable, that the current identity has the
+// permissions knuu needs, that the volumes have a default storage class, that the nodes reach the registry of
+// the built images, that the network policies are enforced and whether the traffic of the instances can be shaped
+// Call it right after New, so that a misconfigured cluster fails the tests in seconds rather than midway, and so
+// that the probed pod security gates the instances created next, see Instance.EnableBitTwister.
+// The report is always returned, the error names the failed checks.
+func (k *Knuu) Preflight(ctx context.Context) (*PreflightReport, error) {
+	report := &PreflightReport{}
+
+	caps, err := k.K8sCli.Capabilities(ctx)
+	if err != nil {
+		// the other checks need the cluster
+		report.add("connectivity", PreflightFailed, "cannot reach the cluster: %v", err)
+		k.logPreflight(report)
+		return report, report.Err()
+	}
+	report.add("connectivity", PreflightPassed, "kubernetes %s", caps.GitVersion)
+
+	k.checkPermissions(ctx, report)
+	k.checkStorageClass(ctx, report)
+	k.checkRegistry(ctx, report)
+	k.checkNetworkPolicies(ctx, report)
+	k.checkNetworkShaping(ctx, report)
+
+	k.logPreflight(report)
+	return report, report.Err()
+}
+
+func (k *Knuu) logPreflight(report *PreflightReport) {
+	for _, c := range report.Checks {
+		switch c.Status {
+		case PreflightPassed:
+			k.Logger.Infof("Preflight check %s passed: %s", c.Name, c.Message)
+		case PreflightWarning:
+			k.Logger.Warnf("Preflight check %s: %s", c.Name, c.Message)
+		default:
+			k.Logger.Errorf("Preflight check %s failed: %s", c.Name, c.Message)
+		}
+	}
+}
+
+// checkPermissions asks the cluster whether the current identity has the permissions knuu needs
+func (k *Knuu) checkPermissions(ctx context.Context, report *PreflightReport) {
+	var denied []string
+	for _, p := range preflightPermissions {
+		namespace := k.K8sCli.Namespace()
+		if p.cluster {
+			namespace = ""
+		}
+		review := &authorizationv1.SelfSubjectAccessReview{
+			Spec: authorizationv1.SelfSubjectAccessReviewSpec{
+				ResourceAttributes: &authorizationv1.ResourceAttributes{
+					Namespace:   namespace,
+					Group:       p.group,
+					Resource:    p.resource,
+					Subresource: p.subresource,
+					Verb:        p.verb,
+				},
+			},
+		}
+		review, err := k.K8sCli.Clientset().AuthorizationV1().SelfSubjectAccessReviews().Create(ctx, review, metav1.CreateOptions{})
+		if err != nil {
+			report.add("rbac", PreflightFailed, "cannot review the permissions: %v", err)
+			return
+		}
+		if !review.Status.Allowed {
+			denied = append(denied, p.String())
+		}
+	}
+	if len(denied) != 0 {
+		report.add("rbac", PreflightFailed, "the current identity cannot %s", strings.Join(denied, ", "))
+		return
+	}
+	report.add("rbac", PreflightPassed, "the current identity has the %d permissions knuu needs", len(preflightPermissions))
+}
+
+// checkStorageClass checks that the volumes of the instances, which do not set a storage class, can be provisioned
+func (k *Knuu) checkStorageClass(ctx context.Context, report *PreflightReport) {
+	classes, err := k.K8sCli.Clientset().StorageV1().StorageClasses().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		report.add("storage-class", PreflightWarning, "cannot list the storage classes: %v", err)
+		return
+	}
+	for _, class := range classes.Items {
+		if isDefaultStorageClass(class) {
+			report.add("storage-class", PreflightPassed, "default storage class '%s'", class.Name)
+			return
+		}
+	}
+	report.add("storage-class", PreflightWarning, "no default storage class, the volumes of the instances will not be provisioned")
+}
+
+func isDefaultStorageClass(class storagev1.StorageClass) bool {
+	return class.Annotations[defaultStorageClassAnnotation] == "true"
+}
+
+// checkRegistry runs a pod connecting to the registry, so that it checks both the pulls from Docker Hub and the registry
+// The in-cluster registry and the images loaded onto the nodes are not checked, New fails if the registry is not ready.
+func (k *Knuu) checkRegistry(ctx context.Context, report *PreflightReport) {
+	if k.ImageRegistry != "" {
+		report.add("registry", PreflightPassed, "the built images are not pushed to %s", PreflightRegistry)
+		return
+	}
+	pods := k.K8sCli.Clientset().CoreV1().Pods(k.K8sCli.Namespace())
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: preflightPodName, Namespace: k.K8sCli.Namespace()},
+		Spec: v1.PodSpec{
+			RestartPolicy: v1.RestartPolicyNever,
+			Containers: []v1.Container{{
+				Name:    preflightPodName,
+				Image:   preflightPodImage,
+				Command: []string{"sh", "-c", fmt.Sprintf("nc -w 10 %s 443 </dev/null", PreflightRegistry)},
+			}},
+		},
+	}
+	if _, err := pods.Create(ctx, pod, metav1.CreateOptions{}); err != nil {
+		report.add("registry", PreflightFailed, "cannot create the pod checking the registry: %v", err)
+		return
+	}
+	defer func() {
+		grace := int64(0)
+		if err := pods.Delete(ctx, preflightPodName, metav1.DeleteOptions{GracePeriodSeconds: &grace}); err != nil {
+			k.Logger.Warnf("Error deleting the pod '%s': %v", preflightPodName, err)
+		}
+	}()
+
+	var terminated *v1.ContainerStateTerminated
+	waitingReason := ""
+	err := retry.Until(ctx, registryCheckPolicy, func(ctx context.Context) (bool, error) {
+		pod, err := pods.Get(ctx, preflightPodName, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		for _, cs := range pod.Status.ContainerStatuses {
+			if cs.State.Waiting != nil {
+				waitingReason = cs.State.Waiting.Reason
+			}
+			terminated = cs.State.Terminated
+		}
+		return terminated != nil, nil
+	})
+	switch {
+	case err != nil && waitingReason != "":
+		report.add("registry", PreflightFailed, "the pod checking the registry is %s, the nodes may not reach Docker Hub", waitingReason)
+	case err != nil:
+		report.add("registry", PreflightFailed, "the pod checking the registry did not complete: %v", err)
+	case terminated.ExitCode != 0:
+		report.add("registry", PreflightFailed, "the nodes cannot connect to %s:443", PreflightRegistry)
+	default:
+		report.add("registry", PreflightPassed, "the nodes reach %s", PreflightRegistry)
+	}
+}
+
+// checkNetworkPolicies looks for a network plugin enforcing the network policies among the daemon sets of kube-system
+// Without one the network policies of knuu, e.g. of Instance.DisableNetwork, are silently ignored.
+func (k *Knuu) checkNetworkPolicies(ctx context.Context, report *PreflightReport) {
+	daemonSets, err := k.K8sCli.Clientset().AppsV1().DaemonSets(kubeSystemNamespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		report.add("network-policy", PreflightWarning, "cannot list the daemon sets of %s: %v", kubeSystemNamespace, err)
+		return
+	}
+	for _, ds := range daemonSets.Items {
+		for _, cni := range policyEnforcingCNIs {
+			if strings.Contains(ds.Name, cni) {
+				report.add("network-policy", PreflightPassed, "the network plugin %s enforces the network policies", cni)
+				return
+			}
+		}
+	}
+	report.add("network-policy", PreflightWarning, "no network plugin known to enforce the network policies found, "+
+		"the network of the instances may not be disabled")
+}
+
+// checkNetworkShaping probes whether the containers can be privileged or granted the NET_ADMIN capability, which
+// BitTwister needs to shape the traffic of the instances
+// The probed pod security is kept, so that EnableBitTwister fails right away rather than when the instance starts.
+func (k *Knuu) checkNetworkShaping(ctx context.Context, report *PreflightReport) {
+	ps, err := k.K8sCli.ProbePodSecurity(ctx)
+	if err != nil {
+		report.add("network-shaping", PreflightWarning, "cannot probe the pod security: %v", err)
+		return
+	}
+	k.PodSecurity = ps
+	switch {
+	case ps.Privileged:
+		report.add("network-shaping", PreflightPassed, "the pods can be privileged, BitTwister can shape the traffic")
+	case ps.NetAdmin:
+		report.add("network-shaping", PreflightWarning, "the pods cannot be privileged (%s), "+
+			"BitTwister must run with the NET_ADMIN capability only, see BitTwister.SetPrivileged(false), "+
+			"and can only shape the latency and jitter", ps.PrivilegedDenial)
+	default:
+		report.add("network-shaping", PreflightWarning, "the pods can neither be privileged nor granted the NET_ADMIN "+
+			"capability (%s), the traffic of the instances cannot be shaped", ps.NetAdminDenial)
+	}
+}
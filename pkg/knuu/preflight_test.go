@@ -0,0 +1,132 @@
+package knuu
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appv1 "k8s.io/api/apps/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	v1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/celestiaorg/knuu/pkg/k8s"
+	"github.com/celestiaorg/knuu/pkg/k8s/fake"
+	"github.com/celestiaorg/knuu/pkg/system"
+)
+
+// allowAllBut allows the access reviews except the ones of the given subresource
+func allowAllBut(subresource string) k8stesting.ReactionFunc {
+	return func(action k8stesting.Action) (bool, runtime.Object, error) {
+		review := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SelfSubjectAccessReview)
+		review.Status.Allowed = review.Spec.ResourceAttributes.Subresource != subresource
+		return true, review, nil
+	}
+}
+
+// forbidPrivileged rejects the privileged pods like the Pod Security Admission of a 'baseline' namespace
+func forbidPrivileged(action k8stesting.Action) (bool, runtime.Object, error) {
+	pod := action.(k8stesting.CreateAction).GetObject().(*v1.Pod)
+	for _, c := range pod.Spec.Containers {
+		if c.SecurityContext != nil && c.SecurityContext.Privileged != nil && *c.SecurityContext.Privileged {
+			return true, nil, apierrs.NewForbidden(v1.Resource("pods"), pod.Name, errors.New("violates PodSecurity \"baseline:latest\": privileged"))
+		}
+	}
+	return false, nil, nil
+}
+
+// completeRegistryCheck terminates the container of the pod checking the registry with the given exit code once it exists
+func completeRegistryCheck(ctx context.Context, k8sCli *fake.Client, exitCode int32) {
+	pods := k8sCli.FakeClientset.CoreV1().Pods("test")
+	for ctx.Err() == nil {
+		pod, err := pods.Get(ctx, preflightPodName, metav1.GetOptions{})
+		if err != nil {
+			time.Sleep(10 * time.Millisecond)
+			continue
+		}
+		pod.Status.ContainerStatuses = []v1.ContainerStatus{{
+			Name:  preflightPodName,
+			State: v1.ContainerState{Terminated: &v1.ContainerStateTerminated{ExitCode: exitCode}},
+		}}
+		if _, err := pods.UpdateStatus(ctx, pod, metav1.UpdateOptions{}); err == nil {
+			return
+		}
+	}
+}
+
+func TestPreflight(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	k8sCli, err := fake.New(ctx, "test",
+		&storagev1.StorageClass{ObjectMeta: metav1.ObjectMeta{
+			Name:        "standard",
+			Annotations: map[string]string{defaultStorageClassAnnotation: "true"},
+		}},
+		&appv1.DaemonSet{ObjectMeta: metav1.ObjectMeta{Name: "calico-node", Namespace: kubeSystemNamespace}},
+	)
+	require.NoError(t, err)
+	k8sCli.FakeClientset.PrependReactor("create", "selfsubjectaccessreviews", allowAllBut("none"))
+	k := &Knuu{SystemDependencies: system.SystemDependencies{K8sCli: k8sCli, Logger: logrus.New()}}
+
+	go completeRegistryCheck(ctx, k8sCli, 0)
+	report, err := k.Preflight(ctx)
+	require.NoError(t, err)
+	assert.True(t, report.Passed())
+	assert.Equal(t, []PreflightCheck{
+		{Name: "connectivity", Status: PreflightPassed, Message: "kubernetes " + fake.ServerVersion},
+		{Name: "rbac", Status: PreflightPassed, Message: "the current identity has the 15 permissions knuu needs"},
+		{Name: "storage-class", Status: PreflightPassed, Message: "default storage class 'standard'"},
+		{Name: "registry", Status: PreflightPassed, Message: "the nodes reach ttl.sh"},
+		{Name: "network-policy", Status: PreflightPassed, Message: "the network plugin calico enforces the network policies"},
+		{Name: "network-shaping", Status: PreflightPassed, Message: "the pods can be privileged, BitTwister can shape the traffic"},
+	}, report.Checks)
+	assert.Equal(t, &k8s.PodSecurity{Privileged: true, NetAdmin: true}, k.PodSecurity)
+
+	// the pod checking the registry is deleted
+	_, err = k8sCli.FakeClientset.CoreV1().Pods("test").Get(ctx, preflightPodName, metav1.GetOptions{})
+	assert.Error(t, err)
+}
+
+func TestPreflightFailures(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	k8sCli, err := fake.New(ctx, "test")
+	require.NoError(t, err)
+	k8sCli.FakeClientset.PrependReactor("create", "selfsubjectaccessreviews", allowAllBut("exec"))
+	k8sCli.FakeClientset.PrependReactor("create", "pods", forbidPrivileged)
+	k := &Knuu{SystemDependencies: system.SystemDependencies{K8sCli: k8sCli, Logger: logrus.New()}}
+
+	go completeRegistryCheck(ctx, k8sCli, 1)
+	report, err := k.Preflight(ctx)
+	assert.ErrorIs(t, err, ErrPreflightFailed)
+	assert.ErrorContains(t, err, "rbac, registry")
+	assert.False(t, report.Passed())
+
+	statuses := make(map[string]PreflightStatus)
+	for _, c := range report.Checks {
+		statuses[c.Name] = c.Status
+	}
+	assert.Equal(t, map[string]PreflightStatus{
+		"connectivity":    PreflightPassed,
+		"rbac":            PreflightFailed,
+		"storage-class":   PreflightWarning,
+		"registry":        PreflightFailed,
+		"network-policy":  PreflightWarning,
+		"network-shaping": PreflightWarning,
+	}, statuses)
+	assert.Equal(t, "the current identity cannot create pods/exec", report.Checks[1].Message)
+	assert.Contains(t, report.String(), "failed   registry: the nodes cannot connect to ttl.sh:443\n")
+	assert.Contains(t, report.String(), `violates PodSecurity "baseline:latest": privileged`)
+	assert.False(t, k.PodSecurity.Privileged)
+	assert.True(t, k.PodSecurity.NetAdmin)
+}
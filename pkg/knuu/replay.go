@@ -0,0 +1,15 @@
+package knuu
+
+import (
+	"context"
+
+	"github.com/celestiaorg/knuu/pkg/recording"
+	"github.com/celestiaorg/knuu/pkg/scenario"
+)
+
+// Replay creates the instances of the recording and executes its operations at the time they were recorded,
+// optionally faster with scenario.WithSpeed, see scenario.Runner.Replay
+// The replayed operations are part of the report of the test, and of its recording if WithRecorder is set.
+func (k *Knuu) Replay(ctx context.Context, rec *recording.Recording, opts ...scenario.ReplayOption) (*scenario.Result, error) {
+	return scenario.NewRunner(k, scenario.WithReporter(k.Reporter)).Replay(ctx, rec, opts...)
+}
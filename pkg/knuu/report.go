@@ -0,0 +1,133 @@
+package knuu
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/celestiaorg/knuu/pkg/instance"
+	"github.com/celestiaorg/knuu/pkg/rbac"
+	"github.com/celestiaorg/knuu/pkg/report"
+)
+
+// Report returns the timeline of the operations executed so far and the collected artifacts
+func (k *Knuu) Report() report.Report {
+	return k.Reporter.Report()
+}
+
+// SaveReport writes the JSON and HTML reports of the test, and the JUnit and OTLP timings
+// of the setup phases of the instances, in the given directory
+// Collect the logs in the same directory beforehand, so that the reports link to them.
+func (k *Knuu) SaveReport(dir string) error {
+	return k.Reporter.Save(dir)
+}
+
+// RecordResourceUsage adds the resource accounting of the started instances to the report and logs a summary of it,
+// so that the wasteful configurations stand out. It is called by CleanUp.
+func (k *Knuu) RecordResourceUsage(ctx context.Context) error {
+	for _, inst := range k.Instances() {
+		if !inst.IsInState(instance.Started) {
+			continue
+		}
+		usage, err := inst.ResourceUsage(ctx)
+		if err != nil {
+			return ErrRecordingResourceUsage.WithParams(inst.Spec().K8sName).Wrap(err)
+		}
+		k.Reporter.RecordResourceUsage(*usage)
+		k.observeUsage(inst, *usage)
+		k.Logger.Infof("Resource usage of %s", usage.Summary())
+	}
+	return nil
+}
+
+// RBACReport compares the policy rules of the instances with the calls their service accounts made,
+// read from the audit log of the cluster, to find the rules broader than needed
+// The instances that have no rules and made no calls are not reported.
+func (k *Knuu) RBACReport(auditLog io.Reader) ([]*rbac.Report, error) {
+	events, err := rbac.ReadAuditLog(auditLog)
+	if err != nil {
+		return nil, ErrCreatingRBACReport.Wrap(err)
+	}
+
+	var reports []*rbac.Report
+	for _, inst := range k.Instances() {
+		r := inst.RBACReport(events)
+		if len(r.Granted) == 0 && len(r.Minimal) == 0 {
+			continue
+		}
+		reports = append(reports, r)
+	}
+	return reports, nil
+}
+
+// CollectLogs saves the current logs of the given instances in the directory, and adds them to the report
+// Instances that are not started are skipped.
+func (k *Knuu) CollectLogs(ctx context.Context, dir string, instances ...*instance.Instance) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return ErrCreatingLogsDir.WithParams(dir).Wrap(err)
+	}
+
+	for _, inst := range instances {
+		if !inst.IsInState(instance.Started) {
+			continue
+		}
+		spec := inst.Spec()
+		path := filepath.Join(dir, spec.K8sName+".log")
+		if err := saveLogs(ctx, inst, path); err != nil {
+			return ErrCollectingLogs.WithParams(spec.K8sName).Wrap(err)
+		}
+		k.Reporter.AddArtifact(report.Artifact{
+			Name:     filepath.Base(path),
+			Kind:     report.ArtifactLogs,
+			Instance: spec.K8sName,
+			Path:     path,
+		})
+	}
+	return nil
+}
+
+func saveLogs(ctx context.Context, inst *instance.Instance, path string) error {
+	logs, err := inst.Logs(ctx, false)
+	if err != nil {
+		return err
+	}
+	defer logs.Close()
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, logs); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// SnapshotAllMetrics scrapes the Prometheus endpoints of the started instances, see Instance.SetPrometheusEndpoint,
+// and stores their metric families as JSON in the artifact store under '<instance>/metrics.json'
+// It is called by CleanUp, call it beforehand to download the snapshots with the artifacts. It stops at the first error.
+func (k *Knuu) SnapshotAllMetrics(ctx context.Context) error {
+	for _, inst := range k.Instances() {
+		port := inst.PrometheusEndpointPort()
+		if port == 0 || !inst.IsInState(instance.Started) {
+			continue
+		}
+		name := inst.Spec().K8sName
+		families, err := inst.ScrapeMetrics(ctx, port, "")
+		if err != nil {
+			return ErrSnapshottingMetrics.WithParams(name).Wrap(err)
+		}
+		data, err := json.Marshal(families)
+		if err != nil {
+			return ErrSnapshottingMetrics.WithParams(name).Wrap(err)
+		}
+		if err := k.Artifacts.Put(ctx, name+"/metrics.json", bytes.NewReader(data)); err != nil {
+			return ErrSnapshottingMetrics.WithParams(name).Wrap(err)
+		}
+	}
+	return nil
+}
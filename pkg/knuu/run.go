@@ -0,0 +1,61 @@
+package knuu
+
+import (
+	"context"
+
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/celestiaorg/knuu/pkg/instance"
+)
+
+// RunOptions configures an instance run by RunImage, the zero value runs the image with its own command
+type RunOptions struct {
+	Command  []string
+	Args     []string
+	Env      map[string]string
+	PortsTCP []int
+	PortsUDP []int
+
+	MemoryRequest string
+	MemoryLimit   string
+	CPURequest    string
+
+	// ReadinessProbe is waited for before RunImage returns, unless NoWait is set
+	ReadinessProbe *v1.Probe
+	// NoWait returns once the instance is deployed, without waiting for it to be running
+	NoWait bool
+
+	// Options are applied after the fields above, e.g. instance.WithSetup for what has no field
+	Options []instance.Option
+}
+
+// RunImage creates an instance running the given image as is, and starts it: the image is not built, so no
+// builder is needed, see instance.WithPrebuiltImage
+// The instance is returned even if it fails to start, e.g. to read its logs, and it is destroyed by CleanUp like
+// the other instances created with NewInstance.
+func (k *Knuu) RunImage(ctx context.Context, name, image string, opts RunOptions) (*instance.Instance, error) {
+	instanceOpts := append([]instance.Option{
+		instance.WithPrebuiltImage(image),
+		instance.WithCommand(opts.Command...),
+		instance.WithArgs(opts.Args...),
+		instance.WithEnv(opts.Env),
+		instance.WithPorts(opts.PortsTCP...),
+		instance.WithPortsUDP(opts.PortsUDP...),
+		instance.WithResources(opts.MemoryRequest, opts.MemoryLimit, opts.CPURequest),
+		instance.WithProbes(nil, opts.ReadinessProbe, nil),
+	}, opts.Options...)
+
+	i, err := k.NewInstance(name, instanceOpts...)
+	if err != nil {
+		return nil, ErrRunningImage.WithParams(image, name).Wrap(err)
+	}
+
+	start := i.Start
+	if opts.NoWait {
+		start = i.StartWithoutWait
+	}
+	if err := start(ctx); err != nil {
+		return i, ErrRunningImage.WithParams(image, name).Wrap(err)
+	}
+	return i, nil
+}
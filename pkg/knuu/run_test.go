@@ -0,0 +1,47 @@
+package knuu
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/celestiaorg/knuu/pkg/instance"
+	"github.com/celestiaorg/knuu/pkg/k8s/fake"
+	"github.com/celestiaorg/knuu/pkg/system"
+)
+
+func TestRunImage(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	k8sCli, err := fake.New(ctx, "test")
+	require.NoError(t, err)
+	k := &Knuu{SystemDependencies: system.SystemDependencies{K8sCli: k8sCli, Logger: logrus.New(), TestScope: "test"}}
+
+	i, err := k.RunImage(ctx, "redis", "redis:7", RunOptions{
+		Args:     []string{"--port", "6380"},
+		Env:      map[string]string{"REDIS_ARGS": "--save 60 1"},
+		PortsTCP: []int{6380},
+		NoWait:   true,
+	})
+	require.NoError(t, err)
+	assert.True(t, i.IsInState(instance.Started))
+	assert.Equal(t, []*instance.Instance{i}, k.Instances())
+
+	rs, err := k8sCli.FakeClientset.AppsV1().ReplicaSets("test").Get(ctx, i.HostName(), metav1.GetOptions{})
+	require.NoError(t, err)
+	container := rs.Spec.Template.Spec.Containers[0]
+	assert.Equal(t, "redis:7", container.Image)
+	assert.Equal(t, []string{"--port", "6380"}, container.Args)
+	svc, err := k8sCli.FakeClientset.CoreV1().Services("test").Get(ctx, i.HostName(), metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, int32(6380), svc.Spec.Ports[0].Port)
+
+	_, err = k.RunImage(ctx, "empty", "", RunOptions{})
+	assert.ErrorIs(t, err, ErrRunningImage)
+	assert.ErrorContains(t, err, "prebuilt image")
+}
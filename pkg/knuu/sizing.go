@@ -0,0 +1,109 @@
+package knuu
+
+import (
+	"context"
+	"time"
+
+	"github.com/celestiaorg/knuu/pkg/clock"
+	"github.com/celestiaorg/knuu/pkg/instance"
+	"github.com/celestiaorg/knuu/pkg/report"
+	"github.com/celestiaorg/knuu/pkg/sizing"
+)
+
+// usageSampleInterval is the interval between two samples of the usage of the instances for the usage history
+const usageSampleInterval = 30 * time.Second
+
+// SuggestResources returns the instances whose CPU or memory is over or under provisioned compared to their peak
+// usage in the runs of the usage history, including the current one, with the suggested requests, and logs them
+func (k *Knuu) SuggestResources() ([]sizing.Suggestion, error) {
+	if k.usageHistory == nil {
+		return nil, ErrUsageHistoryNotEnabled
+	}
+	var suggestions []sizing.Suggestion
+	for _, s := range k.usageHistory.Suggest() {
+		if !s.Misprovisioned() {
+			continue
+		}
+		k.Logger.Infof("Resources of %s", s.Summary())
+		suggestions = append(suggestions, s)
+	}
+	return suggestions, nil
+}
+
+// startSampling samples the usage of the started instances in background until the usage history is saved
+// The sampling outlives the given context, e.g. the one of New.
+func (k *Knuu) startSampling(ctx context.Context) {
+	ctx, k.stopSampling = context.WithCancel(context.WithoutCancel(ctx))
+	go func() {
+		ticker := clock.FromContext(ctx).NewTicker(usageSampleInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C():
+			}
+			for _, inst := range k.Instances() {
+				if !inst.IsInState(instance.Started) {
+					continue
+				}
+				usage, err := inst.ResourceUsage(ctx)
+				if err != nil {
+					k.Logger.Debugf("Error sampling the usage of instance '%s': %v", inst.Name(), err)
+					continue
+				}
+				k.observeUsage(inst, *usage)
+			}
+		}
+	}()
+}
+
+// observeUsage adds a sample of the usage of the instance to the usage history, if enabled
+// The usage of a pod is the one of its instance, so the sidecars are not observed.
+func (k *Knuu) observeUsage(inst *instance.Instance, usage report.ResourceUsage) {
+	if k.usageHistory == nil || inst.Spec().Parent != "" {
+		return
+	}
+	k.usageHistory.Observe(inst.Name(), usage)
+}
+
+// saveUsageHistory stops the sampling and adds the peak usage of the current run to the usage history, if enabled
+func (k *Knuu) saveUsageHistory() error {
+	if k.usageHistory == nil {
+		return nil
+	}
+	if k.stopSampling != nil {
+		k.stopSampling()
+	}
+	return k.usageHistory.Save()
+}
+
+// applyLearnedResources requests the resources learned from the usage history for the instance, for the CPU and the
+// memory whose request is not set
+// The resources cannot be changed once the instance was started, so a stopped instance is not sized again.
+func (k *Knuu) applyLearnedResources(_ context.Context, inst *instance.Instance) error {
+	if !inst.IsInState(instance.Committed) {
+		return nil
+	}
+	spec := inst.Spec()
+	if len(spec.Sidecars) != 0 {
+		return nil
+	}
+	learned, ok := k.usageHistory.Learned(spec.Name)
+	if !ok {
+		return nil
+	}
+	// a learned request above the limit set for the instance is not applied
+	if spec.Resources.CPURequest == "" {
+		if err := inst.SetCPU(learned.CPU()); err != nil {
+			k.Logger.Debugf("Cannot request the learned CPU of instance '%s': %v", spec.Name, err)
+		}
+	}
+	if spec.Resources.MemoryRequest == "" {
+		if err := inst.SetMemory(learned.Memory(), spec.Resources.MemoryLimit); err != nil {
+			k.Logger.Debugf("Cannot request the learned memory of instance '%s': %v", spec.Name, err)
+		}
+	}
+	k.Logger.Debugf("Requested the learned resources of instance '%s': %s CPU and %s memory", spec.Name, learned.CPU(), learned.Memory())
+	return nil
+}
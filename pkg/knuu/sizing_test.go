@@ -0,0 +1,61 @@
+package knuu
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/celestiaorg/knuu/pkg/instance"
+	"github.com/celestiaorg/knuu/pkg/k8s/fake"
+	"github.com/celestiaorg/knuu/pkg/report"
+	"github.com/celestiaorg/knuu/pkg/sizing"
+	"github.com/celestiaorg/knuu/pkg/system"
+)
+
+func TestLearnedResources(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	path := filepath.Join(t.TempDir(), "history.json")
+	previous, err := sizing.Load(path, 0)
+	require.NoError(t, err)
+	for _, name := range []string{"validator", "bridge"} {
+		previous.Observe(name, report.ResourceUsage{CPURequestMillis: 4000, CPUUsedMillis: 100, MemoryUsedBytes: 100 << 20, UsageKnown: true})
+	}
+	require.NoError(t, previous.Save())
+
+	k8sCli, err := fake.New(ctx, "test")
+	require.NoError(t, err)
+	history, err := sizing.Load(path, 0)
+	require.NoError(t, err)
+	k := &Knuu{
+		SystemDependencies: system.SystemDependencies{K8sCli: k8sCli, Logger: logrus.New(), TestScope: "test"},
+		learnResources:     true,
+		usageHistory:       history,
+	}
+
+	suggestions, err := k.SuggestResources()
+	require.NoError(t, err)
+	require.Len(t, suggestions, 2)
+	assert.Equal(t, sizing.ProvisioningOver, suggestions[0].CPU)
+
+	// the requests set for the instance are kept
+	validator, err := k.NewInstance("validator", instance.WithImage("alpine"), instance.WithResources("", "", "250m"))
+	require.NoError(t, err)
+	require.NoError(t, validator.Commit())
+	require.NoError(t, k.applyLearnedResources(ctx, validator))
+	assert.Equal(t, instance.ResourcesSpec{CPURequest: "250m", MemoryRequest: "120Mi"}, validator.Spec().Resources)
+
+	light, err := k.NewInstance("light", instance.WithImage("alpine"))
+	require.NoError(t, err)
+	require.NoError(t, light.Commit())
+	require.NoError(t, k.applyLearnedResources(ctx, light))
+	assert.Equal(t, instance.ResourcesSpec{}, light.Spec().Resources)
+
+	_, err = (&Knuu{}).SuggestResources()
+	assert.ErrorIs(t, err, ErrUsageHistoryNotEnabled)
+}
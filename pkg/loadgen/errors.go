@@ -0,0 +1,22 @@
+package loadgen
+
+import (
+	"github.com/celestiaorg/knuu/pkg/errors"
+)
+
+type Error = errors.Error
+
+var (
+	ErrCreatingLoadGenerator   = errors.New("CreatingLoadGenerator", "error creating the load generator '%s'")
+	ErrStartingLoadGenerator   = errors.New("StartingLoadGenerator", "error starting the load generator '%s'")
+	ErrDestroyingLoadGenerator = errors.New("DestroyingLoadGenerator", "error destroying the load generator '%s'")
+	ErrAttackWithoutTargets    = errors.New("AttackWithoutTargets", "attack has no targets")
+	ErrTargetWithoutURL        = errors.New("TargetWithoutURL", "target of the attack has no URL")
+	ErrAttackWithoutStages     = errors.New("AttackWithoutStages", "attack has no stages")
+	ErrInvalidStage            = errors.New("InvalidStage", "stage %d must have a positive rate and duration, it has %d requests per second for %s")
+	ErrUnsortedBuckets         = errors.New("UnsortedBuckets", "buckets of the histogram must be sorted in increasing order")
+	ErrMarshallingTargets      = errors.New("MarshallingTargets", "error marshalling the targets of the attack")
+	ErrRunningAttack           = errors.New("RunningAttack", "error running the attack of the load generator '%s'")
+	ErrFetchingAttackReport    = errors.New("FetchingAttackReport", "error fetching the report of the attack of the load generator '%s'")
+	ErrParsingAttackReport     = errors.New("ParsingAttackReport", "error parsing the report of the attack")
+)
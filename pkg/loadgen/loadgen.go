@@ -0,0 +1,378 @@
+// Package loadgen provides a load generator instance to send HTTP traffic to the instances of a test at a
+// controlled rate and fetch the latencies back, so that the tests do not need their own load test containers.
+// The load is generated with vegeta (https://github.com/tsenart/vegeta).
+package loadgen
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/celestiaorg/knuu/pkg/instance"
+	"github.com/celestiaorg/knuu/pkg/system"
+)
+
+const (
+	// DefaultImage is the image of the load generator, it must have vegeta, sh and base64
+	DefaultImage = "docker.io/peterevans/vegeta:6.9.1"
+
+	runDir      = "/tmp/knuu-loadgen"
+	targetsFile = runDir + "/targets.json"
+)
+
+// DefaultBuckets are the bounds of the latency histogram if the attack does not set them
+var DefaultBuckets = []time.Duration{
+	0,
+	time.Millisecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	25 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	5 * time.Second,
+}
+
+// Target is a request sent by the load generator
+type Target struct {
+	// Method is GET if empty
+	Method string
+	// URL is the URL of the request, e.g. 'http://server:8080/status', the services of the instances are resolved
+	URL    string
+	Header map[string][]string
+	Body   []byte
+}
+
+// Stage sends the targets at a constant rate for the given duration
+type Stage struct {
+	// Rate is the number of requests per second
+	Rate     int
+	Duration time.Duration
+}
+
+// Ramp returns the stages increasing the rate from 'from' to 'to' requests per second in the given number of
+// steps of equal duration, e.g. to find the rate at which an instance starts failing
+func Ramp(from, to int, duration time.Duration, steps int) []Stage {
+	if steps < 1 {
+		steps = 1
+	}
+	stages := make([]Stage, 0, steps)
+	for step := 0; step < steps; step++ {
+		rate := from
+		if steps > 1 {
+			rate = from + (to-from)*step/(steps-1)
+		}
+		stages = append(stages, Stage{Rate: rate, Duration: duration / time.Duration(steps)})
+	}
+	return stages
+}
+
+// Attack is a load test run by the load generator
+// The targets are sent in a round robin, at the rate of each stage in turn.
+type Attack struct {
+	Targets []Target
+	Stages  []Stage
+	// Timeout is the timeout of each request, 30s by default
+	Timeout time.Duration
+	// Buckets are the lower bounds of the buckets of the latency histogram, DefaultBuckets if empty
+	Buckets []time.Duration
+}
+
+// Latencies are the latencies of the requests of an attack
+type Latencies struct {
+	Min  time.Duration `json:"min"`
+	Mean time.Duration `json:"mean"`
+	P50  time.Duration `json:"p50"`
+	P90  time.Duration `json:"p90"`
+	P95  time.Duration `json:"p95"`
+	P99  time.Duration `json:"p99"`
+	Max  time.Duration `json:"max"`
+}
+
+// Bucket is a bucket of the latency histogram, the requests with a latency in [From, To)
+// The last bucket has no upper bound and its To is 0.
+type Bucket struct {
+	From  time.Duration `json:"from"`
+	To    time.Duration `json:"to"`
+	Count uint64        `json:"count"`
+}
+
+// Result is the outcome of an attack
+type Result struct {
+	Requests uint64 `json:"requests"`
+	// Rate is the rate the requests were sent at, in requests per second
+	Rate float64 `json:"rate"`
+	// Throughput is the rate of the successful requests, in requests per second
+	Throughput float64 `json:"throughput"`
+	// Success is the ratio of the requests that succeeded, between 0 and 1
+	Success   float64   `json:"success"`
+	Latencies Latencies `json:"latencies"`
+	Histogram []Bucket  `json:"histogram"`
+	// StatusCodes counts the requests by status code, 0 for the requests that got no response
+	StatusCodes map[string]uint64 `json:"statusCodes"`
+	// Errors are the distinct errors of the failed requests
+	Errors []string `json:"errors,omitempty"`
+}
+
+// Generator is a load generator running in the cluster
+type Generator struct {
+	instance *instance.Instance
+
+	// mu serializes the attacks, which share the files of the load generator
+	mu sync.Mutex
+}
+
+// New creates and starts a load generator with the given name and image, DefaultImage if empty
+func New(ctx context.Context, name string, sysDeps system.SystemDependencies, image string) (*Generator, error) {
+	if image == "" {
+		image = DefaultImage
+	}
+	inst, err := instance.New(name, sysDeps,
+		instance.WithImage(image),
+		instance.WithCommand("sleep", "infinity"),
+	)
+	if err != nil {
+		return nil, ErrCreatingLoadGenerator.WithParams(name).Wrap(err)
+	}
+	if err := inst.Commit(); err != nil {
+		return nil, ErrStartingLoadGenerator.WithParams(inst.Name()).Wrap(err)
+	}
+	// the load generator is destroyed if it fails to start after its pod was deployed
+	if err := instance.StartAllWithPolicy(ctx, instance.StartFailureDestroy, inst); err != nil {
+		return nil, ErrStartingLoadGenerator.WithParams(inst.Name()).Wrap(err)
+	}
+	return &Generator{instance: inst}, nil
+}
+
+// Instance returns the instance of the load generator
+func (g *Generator) Instance() *instance.Instance {
+	return g.instance
+}
+
+// Run runs the attack and returns its result once all its stages are done
+// The attacks of a load generator run one at a time, use several load generators to send more load.
+func (g *Generator) Run(ctx context.Context, attack Attack) (*Result, error) {
+	if err := attack.validate(); err != nil {
+		return nil, err
+	}
+	script, err := attackScript(attack)
+	if err != nil {
+		return nil, err
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	logrus.Debugf("Running attack of %d stages with load generator '%s'", len(attack.Stages), g.instance.Name())
+	if _, err := g.instance.ExecuteCommand(ctx, script); err != nil {
+		return nil, ErrRunningAttack.WithParams(g.instance.Name()).Wrap(err)
+	}
+
+	results := resultFiles(len(attack.Stages))
+	report, err := g.instance.ExecuteCommand(ctx, "vegeta report -type=json "+results)
+	if err != nil {
+		return nil, ErrFetchingAttackReport.WithParams(g.instance.Name()).Wrap(err)
+	}
+	result, err := parseReport(report)
+	if err != nil {
+		return nil, err
+	}
+
+	buckets := attack.Buckets
+	if len(buckets) == 0 {
+		buckets = DefaultBuckets
+	}
+	hist, err := g.instance.ExecuteCommand(ctx, fmt.Sprintf("vegeta report -type='%s' %s", histType(buckets), results))
+	if err != nil {
+		return nil, ErrFetchingAttackReport.WithParams(g.instance.Name()).Wrap(err)
+	}
+	result.Histogram, err = parseHistogram(hist)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Destroy destroys the load generator
+func (g *Generator) Destroy(ctx context.Context) error {
+	if err := g.instance.Destroy(ctx); err != nil {
+		return ErrDestroyingLoadGenerator.WithParams(g.instance.Name()).Wrap(err)
+	}
+	return nil
+}
+
+func (a Attack) validate() error {
+	if len(a.Targets) == 0 {
+		return ErrAttackWithoutTargets
+	}
+	for _, t := range a.Targets {
+		if t.URL == "" {
+			return ErrTargetWithoutURL
+		}
+	}
+	if len(a.Stages) == 0 {
+		return ErrAttackWithoutStages
+	}
+	for index, s := range a.Stages {
+		if s.Rate <= 0 || s.Duration <= 0 {
+			return ErrInvalidStage.WithParams(index, s.Rate, s.Duration)
+		}
+	}
+	for index := 1; index < len(a.Buckets); index++ {
+		if a.Buckets[index] <= a.Buckets[index-1] {
+			return ErrUnsortedBuckets
+		}
+	}
+	return nil
+}
+
+// target is a target in the JSON format of vegeta
+type target struct {
+	Method string              `json:"method"`
+	URL    string              `json:"url"`
+	Header map[string][]string `json:"header,omitempty"`
+	// Body is encoded in base64 by encoding/json, as expected by vegeta
+	Body []byte `json:"body,omitempty"`
+}
+
+// attackScript returns the shell script writing the targets and running the stages of the attack in turn,
+// each stage writes its results to its own file
+func attackScript(a Attack) (string, error) {
+	var targets strings.Builder
+	for _, t := range a.Targets {
+		method := t.Method
+		if method == "" {
+			method = "GET"
+		}
+		line, err := json.Marshal(target{Method: method, URL: t.URL, Header: t.Header, Body: t.Body})
+		if err != nil {
+			return "", ErrMarshallingTargets.Wrap(err)
+		}
+		targets.Write(line)
+		targets.WriteByte('\n')
+	}
+	encoded := base64.StdEncoding.EncodeToString([]byte(targets.String()))
+
+	timeout := a.Timeout
+	if timeout == 0 {
+		timeout = 30 * time.Second
+	}
+	script := []string{
+		fmt.Sprintf("rm -rf %s && mkdir -p %s", runDir, runDir),
+		fmt.Sprintf("echo %s | base64 -d > %s", encoded, targetsFile),
+	}
+	for index, s := range a.Stages {
+		script = append(script, fmt.Sprintf("vegeta attack -format=json -targets=%s -rate=%d/1s -duration=%s -timeout=%s -output=%s",
+			targetsFile, s.Rate, s.Duration, timeout, resultFile(index)))
+	}
+	return strings.Join(script, " && "), nil
+}
+
+func resultFile(stage int) string {
+	return fmt.Sprintf("%s/%d.bin", runDir, stage)
+}
+
+// resultFiles returns the result files of the stages, separated by spaces
+func resultFiles(stages int) string {
+	files := make([]string, 0, stages)
+	for index := 0; index < stages; index++ {
+		files = append(files, resultFile(index))
+	}
+	return strings.Join(files, " ")
+}
+
+// histType returns the type of the vegeta report of the histogram with the given buckets, e.g. 'hist[0s,10ms]'
+func histType(buckets []time.Duration) string {
+	bounds := make([]string, 0, len(buckets))
+	for _, b := range buckets {
+		bounds = append(bounds, b.String())
+	}
+	return "hist[" + strings.Join(bounds, ",") + "]"
+}
+
+// report is the JSON report of vegeta, the latencies are in nanoseconds
+type report struct {
+	Latencies struct {
+		Mean int64 `json:"mean"`
+		P50  int64 `json:"50th"`
+		P90  int64 `json:"90th"`
+		P95  int64 `json:"95th"`
+		P99  int64 `json:"99th"`
+		Max  int64 `json:"max"`
+		Min  int64 `json:"min"`
+	} `json:"latencies"`
+	Requests    uint64            `json:"requests"`
+	Rate        float64           `json:"rate"`
+	Throughput  float64           `json:"throughput"`
+	Success     float64           `json:"success"`
+	StatusCodes map[string]uint64 `json:"status_codes"`
+	Errors      []string          `json:"errors"`
+}
+
+func parseReport(output string) (*Result, error) {
+	var r report
+	if err := json.Unmarshal([]byte(output), &r); err != nil {
+		return nil, ErrParsingAttackReport.Wrap(err)
+	}
+	return &Result{
+		Requests:   r.Requests,
+		Rate:       r.Rate,
+		Throughput: r.Throughput,
+		Success:    r.Success,
+		Latencies: Latencies{
+			Min:  time.Duration(r.Latencies.Min),
+			Mean: time.Duration(r.Latencies.Mean),
+			P50:  time.Duration(r.Latencies.P50),
+			P90:  time.Duration(r.Latencies.P90),
+			P95:  time.Duration(r.Latencies.P95),
+			P99:  time.Duration(r.Latencies.P99),
+			Max:  time.Duration(r.Latencies.Max),
+		},
+		StatusCodes: r.StatusCodes,
+		Errors:      r.Errors,
+	}, nil
+}
+
+// bucketLine matches a bucket of the histogram report of vegeta, e.g. '[10ms,  20ms]  6  60.00%  ######'
+var bucketLine = regexp.MustCompile(`^\[\s*([^,\s]+),\s*([^\]\s]+)\]\s+(\d+)`)
+
+func parseHistogram(output string) ([]Bucket, error) {
+	var buckets []Bucket
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		m := bucketLine.FindStringSubmatch(strings.TrimSpace(scanner.Text()))
+		if m == nil {
+			continue
+		}
+		from, err := time.ParseDuration(m[1])
+		if err != nil {
+			return nil, ErrParsingAttackReport.Wrap(err)
+		}
+		var to time.Duration
+		if m[2] != "+Inf" {
+			if to, err = time.ParseDuration(m[2]); err != nil {
+				return nil, ErrParsingAttackReport.Wrap(err)
+			}
+		}
+		count, err := strconv.ParseUint(m[3], 10, 64)
+		if err != nil {
+			return nil, ErrParsingAttackReport.Wrap(err)
+		}
+		buckets = append(buckets, Bucket{From: from, To: to, Count: count})
+	}
+	if len(buckets) == 0 {
+		return nil, ErrParsingAttackReport.Wrap(fmt.Errorf("no buckets in the histogram: %q", output))
+	}
+	return buckets, nil
+}
@@ -0,0 +1,118 @@
+package loadgen
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRamp(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, []Stage{
+		{Rate: 10, Duration: 10 * time.Second},
+		{Rate: 55, Duration: 10 * time.Second},
+		{Rate: 100, Duration: 10 * time.Second},
+	}, Ramp(10, 100, 30*time.Second, 3))
+	assert.Equal(t, []Stage{{Rate: 10, Duration: time.Minute}}, Ramp(10, 100, time.Minute, 0))
+}
+
+func TestAttackValidate(t *testing.T) {
+	t.Parallel()
+
+	valid := Attack{
+		Targets: []Target{{URL: "http://server:8080"}},
+		Stages:  []Stage{{Rate: 10, Duration: time.Second}},
+	}
+	require.NoError(t, valid.validate())
+
+	tests := []struct {
+		name   string
+		change func(a *Attack)
+		want   error
+	}{
+		{"no targets", func(a *Attack) { a.Targets = nil }, ErrAttackWithoutTargets},
+		{"no URL", func(a *Attack) { a.Targets = []Target{{Method: "POST"}} }, ErrTargetWithoutURL},
+		{"no stages", func(a *Attack) { a.Stages = nil }, ErrAttackWithoutStages},
+		{"zero rate", func(a *Attack) { a.Stages = []Stage{{Duration: time.Second}} }, ErrInvalidStage},
+		{"unsorted buckets", func(a *Attack) { a.Buckets = []time.Duration{0, time.Second, time.Millisecond} }, ErrUnsortedBuckets},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := valid
+			tt.change(&a)
+			assert.ErrorIs(t, a.validate(), tt.want)
+		})
+	}
+}
+
+func TestAttackScript(t *testing.T) {
+	t.Parallel()
+
+	script, err := attackScript(Attack{
+		Targets: []Target{
+			{URL: "http://server:8080/status"},
+			{Method: "POST", URL: "http://server:8080/tx", Header: map[string][]string{"Content-Type": {"application/json"}}, Body: []byte(`{}`)},
+		},
+		Stages: Ramp(10, 20, 2*time.Minute, 2),
+	})
+	require.NoError(t, err)
+
+	commands := strings.Split(script, " && ")
+	require.Len(t, commands, 5)
+	targets, err := base64.StdEncoding.DecodeString(strings.Fields(commands[2])[1])
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(targets)), "\n")
+	require.Len(t, lines, 2)
+	assert.JSONEq(t, `{"method": "GET", "url": "http://server:8080/status"}`, lines[0])
+	assert.JSONEq(t, `{"method": "POST", "url": "http://server:8080/tx", "header": {"Content-Type": ["application/json"]}, "body": "e30="}`, lines[1])
+	assert.Equal(t, "vegeta attack -format=json -targets="+targetsFile+" -rate=10/1s -duration=1m0s -timeout=30s -output="+runDir+"/0.bin", commands[3])
+	assert.Equal(t, "vegeta attack -format=json -targets="+targetsFile+" -rate=20/1s -duration=1m0s -timeout=30s -output="+runDir+"/1.bin", commands[4])
+	assert.Equal(t, runDir+"/0.bin "+runDir+"/1.bin", resultFiles(2))
+}
+
+func TestParseReport(t *testing.T) {
+	t.Parallel()
+
+	result, err := parseReport(`{
+		"latencies": {"total": 5000000000, "mean": 5000000, "50th": 4000000, "90th": 8000000, "95th": 9000000, "99th": 12000000, "max": 20000000, "min": 1000000},
+		"requests": 1000, "rate": 100.1, "throughput": 99.5, "success": 0.99,
+		"status_codes": {"200": 990, "0": 10},
+		"errors": ["dial tcp: connection refused"]
+	}`)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1000), result.Requests)
+	assert.Equal(t, 0.99, result.Success)
+	assert.Equal(t, 4*time.Millisecond, result.Latencies.P50)
+	assert.Equal(t, 12*time.Millisecond, result.Latencies.P99)
+	assert.Equal(t, map[string]uint64{"200": 990, "0": 10}, result.StatusCodes)
+	assert.Equal(t, []string{"dial tcp: connection refused"}, result.Errors)
+
+	_, err = parseReport("vegeta: not found")
+	assert.ErrorIs(t, err, ErrParsingAttackReport)
+}
+
+func TestParseHistogram(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "hist[0s,10ms,1s]", histType([]time.Duration{0, 10 * time.Millisecond, time.Second}))
+
+	buckets, err := parseHistogram(`Bucket           #    %       Histogram
+[0s,     10ms]   400  40.00%  ##############################
+[10ms,   1s]     590  59.00%  ############################################
+[1s,     +Inf]   10   1.00%
+`)
+	require.NoError(t, err)
+	assert.Equal(t, []Bucket{
+		{From: 0, To: 10 * time.Millisecond, Count: 400},
+		{From: 10 * time.Millisecond, To: time.Second, Count: 590},
+		{From: time.Second, Count: 10},
+	}, buckets)
+
+	_, err = parseHistogram("")
+	assert.ErrorIs(t, err, ErrParsingAttackReport)
+}
@@ -40,4 +40,7 @@ var (
 	ErrMinioFailedToListPersistentVolumes       = errors.New("MinioFailedToListPersistentVolumes", "failed to list PersistentVolumes")
 	ErrMinioFailedToCreatePersistentVolume      = errors.New("MinioFailedToCreatePersistentVolume", "failed to create PersistentVolume")
 	ErrMinioFailedToCreatePersistentVolumeClaim = errors.New("MinioFailedToCreatePersistentVolumeClaim", "failed to create PersistentVolumeClaim")
+	ErrMinioFailedToFindFile                    = errors.New("MinioFailedToFindFile", "failed to find file '%s' in bucket '%s'")
+	ErrMinioFailedToDownloadData                = errors.New("MinioFailedToDownloadData", "failed to download data from Minio")
+	ErrMinioFailedToListFiles                   = errors.New("MinioFailedToListFiles", "failed to list files in Minio")
 )
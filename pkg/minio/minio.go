@@ -17,6 +17,8 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/client-go/kubernetes"
+
+	"github.com/celestiaorg/knuu/pkg/retry"
 )
 
 const (
@@ -41,11 +43,32 @@ const (
 	deploymentMinioLabel = "minio"
 )
 
+// Client stores files in an object storage that the pods of the cluster can download from
+type Client interface {
+	DeployMinio(ctx context.Context) error
+	IsMinioDeployed(ctx context.Context) (bool, error)
+	PushToMinio(ctx context.Context, localReader io.Reader, minioFilePath, bucketName string) error
+	DeleteFromMinio(ctx context.Context, minioFilePath, bucketName string) error
+	GetMinioURL(ctx context.Context, minioFilePath, bucketName string) (string, error)
+	GetFromMinio(ctx context.Context, minioFilePath, bucketName string) (io.ReadCloser, error)
+	ListMinio(ctx context.Context, prefix, bucketName string) ([]string, error)
+}
+
 type Minio struct {
 	Clientset kubernetes.Interface
 	Namespace string
 }
 
+var _ Client = &Minio{}
+
+// New returns a client of the minio deployed in the given namespace
+func New(clientset kubernetes.Interface, namespace string) *Minio {
+	return &Minio{
+		Clientset: clientset,
+		Namespace: namespace,
+	}
+}
+
 func (m *Minio) DeployMinio(ctx context.Context) error {
 	if err := m.createOrUpdateDeployment(ctx); err != nil {
 		return ErrMinioFailedToStart.Wrap(err)
@@ -245,6 +268,66 @@ func (m *Minio) GetMinioURL(ctx context.Context, minioFilePath, bucketName strin
 	return presignedURL.String(), nil
 }
 
+// GetFromMinio returns the content of a file in Minio and fails if the content does not exist
+func (m *Minio) GetFromMinio(ctx context.Context, minioFilePath, bucketName string) (io.ReadCloser, error) {
+	cli, err := m.client(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// GetObject is lazy, the object is stat first so that a missing file is reported here
+	if _, err := cli.StatObject(ctx, bucketName, minioFilePath, miniogo.StatObjectOptions{}); err != nil {
+		return nil, ErrMinioFailedToFindFile.WithParams(minioFilePath, bucketName).Wrap(err)
+	}
+
+	obj, err := cli.GetObject(ctx, bucketName, minioFilePath, miniogo.GetObjectOptions{})
+	if err != nil {
+		return nil, ErrMinioFailedToDownloadData.Wrap(err)
+	}
+	return obj, nil
+}
+
+// ListMinio returns the paths of the files of a bucket that start with the given prefix, sorted by path
+func (m *Minio) ListMinio(ctx context.Context, prefix, bucketName string) ([]string, error) {
+	cli, err := m.client(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	exists, err := cli.BucketExists(ctx, bucketName)
+	if err != nil {
+		return nil, ErrMinioFailedToCheckBucket.Wrap(err)
+	}
+	if !exists {
+		return nil, nil
+	}
+
+	var paths []string
+	for obj := range cli.ListObjects(ctx, bucketName, miniogo.ListObjectsOptions{Prefix: prefix, Recursive: true}) {
+		if obj.Err != nil {
+			return nil, ErrMinioFailedToListFiles.Wrap(obj.Err)
+		}
+		paths = append(paths, obj.Key)
+	}
+	return paths, nil
+}
+
+func (m *Minio) client(ctx context.Context) (*miniogo.Client, error) {
+	endpoint, err := m.getEndpoint(ctx)
+	if err != nil {
+		return nil, ErrMinioFailedToGetEndpoint.Wrap(err)
+	}
+
+	cli, err := miniogo.New(endpoint, &miniogo.Options{
+		Creds:  credentials.NewStaticV4(rootUser, rootPassword, ""),
+		Secure: false,
+	})
+	if err != nil {
+		return nil, ErrMinioFailedToInitializeClient.Wrap(err)
+	}
+	return cli, nil
+}
+
 func (m *Minio) createOrUpdateService(ctx context.Context) error {
 	serviceClient := m.Clientset.CoreV1().Services(m.Namespace)
 
@@ -351,63 +434,49 @@ func (m *Minio) getEndpoint(ctx context.Context) (string, error) {
 }
 
 func (m *Minio) waitForMinio(ctx context.Context) error {
-	for {
+	err := retry.Until(ctx, retry.Constant(waitRetry), func(ctx context.Context) (bool, error) {
 		deployment, err := m.Clientset.AppsV1().Deployments(m.Namespace).Get(ctx, DeploymentName, metav1.GetOptions{})
-		if err == nil && deployment.Status.ReadyReplicas > 0 {
-			break
-		}
-
-		select {
-		case <-ctx.Done():
-			return ErrMinioTimeoutWaitingForReady
-		case <-time.After(waitRetry):
-			// Retry after some seconds
-		}
+		return err == nil && deployment.Status.ReadyReplicas > 0, nil
+	})
+	if err != nil {
+		return ErrMinioTimeoutWaitingForReady.Wrap(err)
 	}
 
 	return nil
 }
 
 func (m *Minio) waitForMinioService(ctx context.Context) error {
-	for {
+	err := retry.Until(ctx, retry.Constant(waitRetry), func(ctx context.Context) (bool, error) {
 		service, err := m.Clientset.CoreV1().Services(m.Namespace).Get(ctx, ServiceName, metav1.GetOptions{})
 		if err != nil {
-			return ErrMinioFailedToGetService.Wrap(err)
+			return false, ErrMinioFailedToGetService.Wrap(err)
 		}
 
 		if service.Spec.Type == v1.ServiceTypeLoadBalancer {
 			if len(service.Status.LoadBalancer.Ingress) == 0 {
-				time.Sleep(waitRetry)
-				continue // Wait until the LoadBalancer IP is available
+				return false, nil // Wait until the LoadBalancer IP is available
 			}
 		} else if service.Spec.Type == v1.ServiceTypeNodePort {
 			if service.Spec.Ports[0].NodePort == 0 {
-				return ErrMinioNodePortNotSet
+				return false, ErrMinioNodePortNotSet
 			}
 		} else if len(service.Spec.ExternalIPs) == 0 {
-			return ErrMinioExternalIPsNotSet
+			return false, ErrMinioExternalIPsNotSet
 		}
 
 		// Check if Minio is reachable
 		endpoint, err := m.getEndpoint(ctx)
 		if err != nil {
-			return ErrMinioFailedToGetEndpoint.Wrap(err)
+			return false, ErrMinioFailedToGetEndpoint.Wrap(err)
 		}
 
-		if err := checkServiceConnectivity(endpoint); err != nil {
-			time.Sleep(waitRetry) // Retry after some seconds if Minio is not reachable
-			continue
-		}
-
-		break // Minio is reachable, exit the loop
-	}
-
-	select {
-	case <-ctx.Done():
-		return ErrMinioTimeoutWaitingForServiceReady
-	default:
-		return nil
+		// Retry if Minio is not reachable
+		return checkServiceConnectivity(endpoint) == nil, nil
+	})
+	if err != nil && ctx.Err() != nil {
+		return ErrMinioTimeoutWaitingForServiceReady.Wrap(err)
 	}
+	return err
 }
 
 func checkServiceConnectivity(serviceEndpoint string) error {
@@ -0,0 +1,19 @@
+package proxy
+
+import (
+	"github.com/celestiaorg/knuu/pkg/errors"
+)
+
+type Error = errors.Error
+
+var (
+	ErrCertificateWithoutKey  = errors.New("CertificateWithoutKey", "the certificate and the key of the TLS config must be set together")
+	ErrInvalidCertificate     = errors.New("InvalidCertificate", "invalid TLS certificate or key")
+	ErrBasicAuthUsernameEmpty = errors.New("BasicAuthUsernameEmpty", "the username of the basic auth must not be empty")
+	ErrBasicAuthPasswordEmpty = errors.New("BasicAuthPasswordEmpty", "the password of the basic auth must not be empty")
+	ErrInvalidBearerToken     = errors.New("InvalidBearerToken", "invalid bearer token, it must only contain the characters allowed by RFC 6750")
+	ErrGeneratingKey          = errors.New("GeneratingKey", "error generating the key of the certificate")
+	ErrGeneratingSerialNumber = errors.New("GeneratingSerialNumber", "error generating the serial number of the certificate")
+	ErrCreatingCertificate    = errors.New("CreatingCertificate", "error creating the certificate")
+	ErrMarshallingKey         = errors.New("MarshallingKey", "error marshalling the key of the certificate")
+)
@@ -0,0 +1,24 @@
+package gateway
+
+import (
+	"github.com/celestiaorg/knuu/pkg/errors"
+)
+
+type Error = errors.Error
+
+var (
+	ErrClientNotInitialized    = errors.New("GatewayClientNotInitialized", "gateway proxy client not initialized")
+	ErrGatewayNameEmpty        = errors.New("GatewayNameEmpty", "the name of the gateway must be set")
+	ErrGettingGateway          = errors.New("GettingGateway", "error getting gateway '%s' in namespace '%s'")
+	ErrListenerNotFound        = errors.New("ListenerNotFound", "no listener with the protocol %s found in gateway '%s'")
+	ErrGatewayAddressNotFound  = errors.New("GatewayAddressNotFound", "gateway '%s' has no address")
+	ErrInvalidHostOptions      = errors.New("InvalidHostOptions", "invalid options of host '%s'")
+	ErrAuthNotSupported        = errors.New("AuthNotSupported", "authentication is not supported by the gateway proxy")
+	ErrCertificateNotSupported = errors.New("CertificateNotSupported", "the certificates are configured on the listeners of the gateway, only WithTLS is supported")
+	ErrGeneratingRandomK8sName = errors.New("GeneratingRandomK8sName", "error generating random K8s name")
+	ErrHTTPRouteCreationFailed = errors.New("HTTPRouteCreationFailed", "error creating HTTPRoute '%s'")
+	ErrGRPCNotSupported        = errors.New("GRPCNotSupported", "gRPC hosts are not supported by the gateway proxy, use AddTCPHost instead")
+	ErrNoTCPListenerAvailable  = errors.New("NoTCPListenerAvailable", "gateway '%s' has no free TCP listener")
+	ErrTCPRouteCreationFailed  = errors.New("TCPRouteCreationFailed", "error creating TCPRoute '%s'")
+	ErrTCPRouteDeletionFailed  = errors.New("TCPRouteDeletionFailed", "error deleting TCPRoute '%s'")
+)
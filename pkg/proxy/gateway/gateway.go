@@ -0,0 +1,447 @@
+// Package gateway exposes the instances through a Gateway API gateway that already runs in the cluster
+package gateway
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/celestiaorg/knuu/pkg/k8s"
+	"github.com/celestiaorg/knuu/pkg/names"
+	"github.com/celestiaorg/knuu/pkg/proxy"
+)
+
+const (
+	apiGroup   = "gateway.networking.k8s.io"
+	apiVersion = "v1"
+
+	protocolHTTP  = "HTTP"
+	protocolHTTPS = "HTTPS"
+	protocolTCP   = "TCP"
+
+	appLabel      = "app"
+	appLabelValue = "knuu-gateway-proxy"
+)
+
+var (
+	gatewayResource   = schema.GroupVersionResource{Group: apiGroup, Version: apiVersion, Resource: "gateways"}
+	httpRouteResource = schema.GroupVersionResource{Group: apiGroup, Version: apiVersion, Resource: "httproutes"}
+	// TCPRoute is only part of the experimental channel of the Gateway API
+	tcpRouteResource = schema.GroupVersionResource{Group: apiGroup, Version: "v1alpha2", Resource: "tcproutes"}
+)
+
+// Gateway attaches an HTTPRoute per host to a gateway of the cluster
+// The listeners of the gateway must allow the routes of the namespace of the test, and
+// TLS is terminated by its HTTPS listener with the certificate configured on it.
+type Gateway struct {
+	K8s k8s.KubeManager
+	// Name and Namespace identify the gateway, the namespace of the test if Namespace is empty
+	Name      string
+	Namespace string
+	// HTTPListener and HTTPSListener are the names of the listeners the routes attach to,
+	// the first listener with the matching protocol if empty
+	HTTPListener  string
+	HTTPSListener string
+
+	// tcpMu guards the TCP listeners assigned to hosts, a TCP listener forwards all its connections to one route
+	tcpMu        sync.Mutex
+	tcpListeners map[string]tcpHost
+}
+
+// tcpHost is the route attached to a TCP listener of the gateway, forwarding its connections to a service
+type tcpHost struct {
+	route   string
+	service string
+}
+
+var _ proxy.Proxy = &Gateway{}
+
+// Option configures the gateway proxy
+type Option func(*Gateway)
+
+// WithListeners sets the names of the HTTP and HTTPS listeners of the gateway
+func WithListeners(http, https string) Option {
+	return func(g *Gateway) {
+		g.HTTPListener = http
+		g.HTTPSListener = https
+	}
+}
+
+// New returns a factory of proxies using the gateway with the given namespace and name, to use with knuu.WithProxy
+func New(namespace, name string, opts ...Option) proxy.Factory {
+	return func(k8sCli k8s.KubeManager) proxy.Proxy {
+		g := &Gateway{K8s: k8sCli, Namespace: namespace, Name: name}
+		for _, opt := range opts {
+			opt(g)
+		}
+		return g
+	}
+}
+
+// Deploy checks that the gateway exists and has an HTTP listener, the gateway is not deployed by knuu
+func (g *Gateway) Deploy(ctx context.Context) error {
+	if g.K8s == nil {
+		return ErrClientNotInitialized
+	}
+	if err := g.K8s.RequireCapability(ctx, k8s.CapabilityGatewayAPI); err != nil {
+		return err
+	}
+	gw, err := g.gateway(ctx)
+	if err != nil {
+		return err
+	}
+	_, err = findListener(gw, protocolHTTP, g.HTTPListener)
+	return err
+}
+
+// Endpoint returns the address of the HTTP listener of the gateway
+func (g *Gateway) Endpoint(ctx context.Context) (string, error) {
+	gw, err := g.gateway(ctx)
+	if err != nil {
+		return "", err
+	}
+	return endpoint(gw, protocolHTTP, g.HTTPListener)
+}
+
+func (g *Gateway) URL(ctx context.Context, prefix string) (string, error) {
+	endpoint, err := g.Endpoint(ctx)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("http://%s/%s", endpoint, prefix), nil
+}
+
+func (g *Gateway) SecureURL(ctx context.Context, prefix string) (string, error) {
+	gw, err := g.gateway(ctx)
+	if err != nil {
+		return "", err
+	}
+	endpoint, err := endpoint(gw, protocolHTTPS, g.HTTPSListener)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("https://%s/%s", endpoint, prefix), nil
+}
+
+// AddHost creates an HTTPRoute routing the given prefix to the port of the service
+// With TLS the route attaches to the HTTPS listener, custom certificates and authentication are not supported.
+// The gateway picks the protocol of the backend from the app protocol of the service port,
+// so gRPC is not supported either, use AddTCPHost for gRPC servers.
+func (g *Gateway) AddHost(ctx context.Context, serviceName, prefix string, port int, opts ...proxy.HostOption) error {
+	options := proxy.NewHostOptions(opts...)
+	if err := options.Validate(); err != nil {
+		return ErrInvalidHostOptions.WithParams(prefix).Wrap(err)
+	}
+	if options.BasicAuth != nil || options.BearerToken != "" {
+		return ErrAuthNotSupported
+	}
+	if options.TLS != nil && !options.TLS.SelfSigned() {
+		return ErrCertificateNotSupported
+	}
+	if options.GRPC {
+		return ErrGRPCNotSupported
+	}
+
+	gw, err := g.gateway(ctx)
+	if err != nil {
+		return err
+	}
+	protocol, listener := protocolHTTP, g.HTTPListener
+	if options.TLS != nil {
+		protocol, listener = protocolHTTPS, g.HTTPSListener
+	}
+	sectionName, err := findListener(gw, protocol, listener)
+	if err != nil {
+		return err
+	}
+
+	name, err := names.NewRandomK8("route-" + prefix)
+	if err != nil {
+		return ErrGeneratingRandomK8sName.Wrap(err)
+	}
+	route := httpRoute{
+		name:             name,
+		namespace:        g.K8s.Namespace(),
+		gatewayName:      g.Name,
+		gatewayNamespace: g.namespace(),
+		sectionName:      sectionName,
+		serviceName:      serviceName,
+		prefix:           prefix,
+		port:             port,
+	}
+	_, err = g.K8s.DynamicClient().Resource(httpRouteResource).Namespace(route.namespace).
+		Create(ctx, route.object(), metav1.CreateOptions{})
+	if err != nil {
+		return ErrHTTPRouteCreationFailed.WithParams(name).Wrap(err)
+	}
+	return nil
+}
+
+// AddTCPHost attaches a TCPRoute to the next free TCP listener of the gateway and returns the address of the listener
+// The gateway must run an implementation supporting the experimental TCPRoute.
+func (g *Gateway) AddTCPHost(ctx context.Context, serviceName, name string, port int) (string, error) {
+	gw, err := g.gateway(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	g.tcpMu.Lock()
+	defer g.tcpMu.Unlock()
+
+	listener, ok := freeTCPListener(gw, g.tcpListeners)
+	if !ok {
+		return "", ErrNoTCPListenerAvailable.WithParams(gw.GetName())
+	}
+	address, err := endpoint(gw, protocolTCP, listener)
+	if err != nil {
+		return "", err
+	}
+
+	routeName, err := names.NewRandomK8("tcp-route-" + name)
+	if err != nil {
+		return "", ErrGeneratingRandomK8sName.Wrap(err)
+	}
+	route := tcpRoute{
+		name:             routeName,
+		namespace:        g.K8s.Namespace(),
+		gatewayName:      g.Name,
+		gatewayNamespace: g.namespace(),
+		sectionName:      listener,
+		serviceName:      serviceName,
+		port:             port,
+	}
+	_, err = g.K8s.DynamicClient().Resource(tcpRouteResource).Namespace(route.namespace).
+		Create(ctx, route.object(), metav1.CreateOptions{})
+	if err != nil {
+		return "", ErrTCPRouteCreationFailed.WithParams(routeName).Wrap(err)
+	}
+
+	if g.tcpListeners == nil {
+		g.tcpListeners = make(map[string]tcpHost)
+	}
+	g.tcpListeners[listener] = tcpHost{route: routeName, service: serviceName}
+	return address, nil
+}
+
+// RemoveTCPHosts deletes the TCPRoutes of the TCP hosts of the service and releases their listeners
+func (g *Gateway) RemoveTCPHosts(ctx context.Context, serviceName string) error {
+	if g.K8s == nil {
+		return ErrClientNotInitialized
+	}
+
+	g.tcpMu.Lock()
+	defer g.tcpMu.Unlock()
+
+	for listener, h := range g.tcpListeners {
+		if h.service != serviceName {
+			continue
+		}
+		err := g.K8s.DynamicClient().Resource(tcpRouteResource).Namespace(g.K8s.Namespace()).
+			Delete(ctx, h.route, metav1.DeleteOptions{})
+		if err != nil && !apierrs.IsNotFound(err) {
+			return ErrTCPRouteDeletionFailed.WithParams(h.route).Wrap(err)
+		}
+		delete(g.tcpListeners, listener)
+	}
+	return nil
+}
+
+func (g *Gateway) namespace() string {
+	if g.Namespace == "" {
+		return g.K8s.Namespace()
+	}
+	return g.Namespace
+}
+
+func (g *Gateway) gateway(ctx context.Context) (*unstructured.Unstructured, error) {
+	if g.K8s == nil {
+		return nil, ErrClientNotInitialized
+	}
+	if g.Name == "" {
+		return nil, ErrGatewayNameEmpty
+	}
+	gw, err := g.K8s.DynamicClient().Resource(gatewayResource).Namespace(g.namespace()).Get(ctx, g.Name, metav1.GetOptions{})
+	if err != nil {
+		return nil, ErrGettingGateway.WithParams(g.Name, g.namespace()).Wrap(err)
+	}
+	return gw, nil
+}
+
+// findListener returns the name of the listener of the gateway with the given protocol,
+// the listener must have the given name if it is not empty
+func findListener(gw *unstructured.Unstructured, protocol, name string) (string, error) {
+	listeners, _, _ := unstructured.NestedSlice(gw.Object, "spec", "listeners")
+	for _, l := range listeners {
+		listener, ok := l.(map[string]interface{})
+		if !ok || listener["protocol"] != protocol {
+			continue
+		}
+		if listenerName, _ := listener["name"].(string); name == "" || listenerName == name {
+			return listenerName, nil
+		}
+	}
+	return "", ErrListenerNotFound.WithParams(protocol, gw.GetName())
+}
+
+// freeTCPListener returns the name of the first TCP listener of the gateway that is not assigned
+func freeTCPListener(gw *unstructured.Unstructured, assigned map[string]tcpHost) (string, bool) {
+	listeners, _, _ := unstructured.NestedSlice(gw.Object, "spec", "listeners")
+	for _, l := range listeners {
+		listener, ok := l.(map[string]interface{})
+		if !ok || listener["protocol"] != protocolTCP {
+			continue
+		}
+		name, _ := listener["name"].(string)
+		if _, ok := assigned[name]; !ok {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// endpoint returns the first address of the gateway with the port of the listener with the given protocol
+func endpoint(gw *unstructured.Unstructured, protocol, name string) (string, error) {
+	listenerName, err := findListener(gw, protocol, name)
+	if err != nil {
+		return "", err
+	}
+	var port int64
+	listeners, _, _ := unstructured.NestedSlice(gw.Object, "spec", "listeners")
+	for _, l := range listeners {
+		if listener, ok := l.(map[string]interface{}); ok && listener["name"] == listenerName {
+			port, _, _ = unstructured.NestedInt64(listener, "port")
+		}
+	}
+
+	addresses, _, _ := unstructured.NestedSlice(gw.Object, "status", "addresses")
+	for _, a := range addresses {
+		address, ok := a.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if value, _ := address["value"].(string); value != "" {
+			return net.JoinHostPort(value, fmt.Sprint(port)), nil
+		}
+	}
+	return "", ErrGatewayAddressNotFound.WithParams(gw.GetName())
+}
+
+// httpRoute describes the HTTPRoute of a host
+type httpRoute struct {
+	name             string
+	namespace        string
+	gatewayName      string
+	gatewayNamespace string
+	sectionName      string
+	serviceName      string
+	prefix           string
+	port             int
+}
+
+// object returns the HTTPRoute, the prefix is stripped by a URLRewrite filter
+func (r httpRoute) object() *unstructured.Unstructured {
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": apiGroup + "/" + apiVersion,
+			"kind":       "HTTPRoute",
+			"metadata": map[string]interface{}{
+				"name":      r.name,
+				"namespace": r.namespace,
+				"labels": map[string]interface{}{
+					appLabel: appLabelValue,
+				},
+			},
+			"spec": map[string]interface{}{
+				"parentRefs": []interface{}{
+					map[string]interface{}{
+						"name":        r.gatewayName,
+						"namespace":   r.gatewayNamespace,
+						"sectionName": r.sectionName,
+					},
+				},
+				"rules": []interface{}{
+					map[string]interface{}{
+						"matches": []interface{}{
+							map[string]interface{}{
+								"path": map[string]interface{}{
+									"type":  "PathPrefix",
+									"value": "/" + strings.Trim(r.prefix, "/"),
+								},
+							},
+						},
+						"filters": []interface{}{
+							map[string]interface{}{
+								"type": "URLRewrite",
+								"urlRewrite": map[string]interface{}{
+									"path": map[string]interface{}{
+										"type":               "ReplacePrefixMatch",
+										"replacePrefixMatch": "/",
+									},
+								},
+							},
+						},
+						"backendRefs": []interface{}{
+							map[string]interface{}{
+								"name": r.serviceName,
+								"port": int64(r.port),
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// tcpRoute describes the TCPRoute of a TCP host
+type tcpRoute struct {
+	name             string
+	namespace        string
+	gatewayName      string
+	gatewayNamespace string
+	sectionName      string
+	serviceName      string
+	port             int
+}
+
+func (r tcpRoute) object() *unstructured.Unstructured {
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": apiGroup + "/" + tcpRouteResource.Version,
+			"kind":       "TCPRoute",
+			"metadata": map[string]interface{}{
+				"name":      r.name,
+				"namespace": r.namespace,
+				"labels": map[string]interface{}{
+					appLabel: appLabelValue,
+				},
+			},
+			"spec": map[string]interface{}{
+				"parentRefs": []interface{}{
+					map[string]interface{}{
+						"name":        r.gatewayName,
+						"namespace":   r.gatewayNamespace,
+						"sectionName": r.sectionName,
+					},
+				},
+				"rules": []interface{}{
+					map[string]interface{}{
+						"backendRefs": []interface{}{
+							map[string]interface{}{
+								"name": r.serviceName,
+								"port": int64(r.port),
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,139 @@
+package gateway
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func testGateway() *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"metadata": map[string]interface{}{"name": "gw"},
+		"spec": map[string]interface{}{
+			"listeners": []interface{}{
+				map[string]interface{}{"name": "web", "protocol": "HTTP", "port": int64(8080)},
+				map[string]interface{}{"name": "websecure", "protocol": "HTTPS", "port": int64(8443)},
+				map[string]interface{}{"name": "websecure-alt", "protocol": "HTTPS", "port": int64(9443)},
+				map[string]interface{}{"name": "rpc", "protocol": "TCP", "port": int64(26657)},
+				map[string]interface{}{"name": "p2p", "protocol": "TCP", "port": int64(26656)},
+			},
+		},
+		"status": map[string]interface{}{
+			"addresses": []interface{}{
+				map[string]interface{}{"type": "IPAddress", "value": "203.0.113.1"},
+			},
+		},
+	}}
+}
+
+func TestFindListener(t *testing.T) {
+	t.Parallel()
+
+	gw := testGateway()
+
+	name, err := findListener(gw, protocolHTTPS, "")
+	require.NoError(t, err)
+	assert.Equal(t, "websecure", name)
+
+	name, err = findListener(gw, protocolHTTPS, "websecure-alt")
+	require.NoError(t, err)
+	assert.Equal(t, "websecure-alt", name)
+
+	_, err = findListener(gw, protocolHTTP, "websecure")
+	assert.ErrorIs(t, err, ErrListenerNotFound)
+}
+
+func TestEndpoint(t *testing.T) {
+	t.Parallel()
+
+	gw := testGateway()
+
+	e, err := endpoint(gw, protocolHTTP, "")
+	require.NoError(t, err)
+	assert.Equal(t, "203.0.113.1:8080", e)
+
+	e, err = endpoint(gw, protocolHTTPS, "websecure-alt")
+	require.NoError(t, err)
+	assert.Equal(t, "203.0.113.1:9443", e)
+
+	unstructured.RemoveNestedField(gw.Object, "status")
+	_, err = endpoint(gw, protocolHTTP, "")
+	assert.ErrorIs(t, err, ErrGatewayAddressNotFound)
+}
+
+func TestHTTPRoute(t *testing.T) {
+	t.Parallel()
+
+	route := httpRoute{
+		name:             "route-app-8080",
+		namespace:        "test",
+		gatewayName:      "gw",
+		gatewayNamespace: "infra",
+		sectionName:      "web",
+		serviceName:      "app",
+		prefix:           "app-8080",
+		port:             8080,
+	}
+	obj := route.object()
+	assert.Equal(t, "HTTPRoute", obj.GetKind())
+
+	parents, _, _ := unstructured.NestedSlice(obj.Object, "spec", "parentRefs")
+	require.Len(t, parents, 1)
+	assert.Equal(t, map[string]interface{}{"name": "gw", "namespace": "infra", "sectionName": "web"}, parents[0])
+
+	rules, _, _ := unstructured.NestedSlice(obj.Object, "spec", "rules")
+	require.Len(t, rules, 1)
+	rule := rules[0].(map[string]interface{})
+	path, _, _ := unstructured.NestedString(rule["matches"].([]interface{})[0].(map[string]interface{}), "path", "value")
+	assert.Equal(t, "/app-8080", path)
+	rewrite, _, _ := unstructured.NestedString(rule["filters"].([]interface{})[0].(map[string]interface{}), "urlRewrite", "path", "replacePrefixMatch")
+	assert.Equal(t, "/", rewrite)
+	assert.Equal(t, map[string]interface{}{"name": "app", "port": int64(8080)}, rule["backendRefs"].([]interface{})[0])
+}
+
+func TestFreeTCPListener(t *testing.T) {
+	t.Parallel()
+
+	gw := testGateway()
+
+	name, ok := freeTCPListener(gw, nil)
+	require.True(t, ok)
+	assert.Equal(t, "rpc", name)
+
+	name, ok = freeTCPListener(gw, map[string]tcpHost{"rpc": {route: "tcp-route-a", service: "a"}})
+	require.True(t, ok)
+	assert.Equal(t, "p2p", name)
+
+	_, ok = freeTCPListener(gw, map[string]tcpHost{"rpc": {route: "tcp-route-a", service: "a"}, "p2p": {route: "tcp-route-b", service: "b"}})
+	assert.False(t, ok)
+
+	e, err := endpoint(gw, protocolTCP, "p2p")
+	require.NoError(t, err)
+	assert.Equal(t, "203.0.113.1:26656", e)
+}
+
+func TestTCPRoute(t *testing.T) {
+	t.Parallel()
+
+	route := tcpRoute{
+		name:             "tcp-route-app-26657",
+		namespace:        "test",
+		gatewayName:      "gw",
+		gatewayNamespace: "infra",
+		sectionName:      "rpc",
+		serviceName:      "app",
+		port:             26657,
+	}
+	obj := route.object()
+	assert.Equal(t, "gateway.networking.k8s.io/v1alpha2", obj.GetAPIVersion())
+	assert.Equal(t, "TCPRoute", obj.GetKind())
+
+	parents, _, _ := unstructured.NestedSlice(obj.Object, "spec", "parentRefs")
+	require.Len(t, parents, 1)
+	assert.Equal(t, "rpc", parents[0].(map[string]interface{})["sectionName"])
+	rules, _, _ := unstructured.NestedSlice(obj.Object, "spec", "rules")
+	require.Len(t, rules, 1)
+	assert.Equal(t, map[string]interface{}{"name": "app", "port": int64(26657)}, rules[0].(map[string]interface{})["backendRefs"].([]interface{})[0])
+}
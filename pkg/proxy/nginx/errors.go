@@ -0,0 +1,23 @@
+package nginx
+
+import (
+	"github.com/celestiaorg/knuu/pkg/errors"
+)
+
+type Error = errors.Error
+
+var (
+	ErrClientNotInitialized      = errors.New("NginxClientNotInitialized", "nginx proxy client not initialized")
+	ErrIngressClassNotFound      = errors.New("IngressClassNotFound", "ingress class '%s' not found, an ingress-nginx controller must run in the cluster")
+	ErrGettingControllerService  = errors.New("GettingControllerService", "error getting the service '%s' of the ingress controller in namespace '%s'")
+	ErrControllerPortNotFound    = errors.New("ControllerPortNotFound", "port '%s' not found in the service '%s' of the ingress controller")
+	ErrControllerAddressNotFound = errors.New("ControllerAddressNotFound", "no external address found for the service '%s' of the ingress controller")
+	ErrGettingNodes              = errors.New("GettingNodes", "error getting the nodes")
+	ErrInvalidHostOptions        = errors.New("InvalidHostOptions", "invalid options of host '%s'")
+	ErrBearerTokenNotSupported   = errors.New("BearerTokenNotSupported", "bearer tokens are not supported by the nginx proxy")
+	ErrGeneratingRandomK8sName   = errors.New("GeneratingRandomK8sName", "error generating random K8s name")
+	ErrGeneratingCertificate     = errors.New("GeneratingCertificate", "error generating the self-signed certificate of the proxy")
+	ErrSecretCreationFailed      = errors.New("NginxSecretCreationFailed", "error creating secret '%s'")
+	ErrIngressCreationFailed     = errors.New("NginxIngressCreationFailed", "error creating ingress '%s'")
+	ErrTCPHostNotSupported       = errors.New("TCPHostNotSupported", "TCP hosts are not supported by the nginx proxy, expose the port with a LoadBalancer service instead")
+)
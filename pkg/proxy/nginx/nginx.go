@@ -0,0 +1,395 @@
+// Package nginx exposes the instances through an ingress-nginx controller that already runs in the cluster
+package nginx
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+
+	"github.com/celestiaorg/knuu/pkg/k8s"
+	"github.com/celestiaorg/knuu/pkg/names"
+	"github.com/celestiaorg/knuu/pkg/proxy"
+)
+
+const (
+	DefaultIngressClass        = "nginx"
+	DefaultControllerNamespace = "ingress-nginx"
+	DefaultControllerService   = "ingress-nginx-controller"
+
+	httpPortName  = "http"
+	httpsPortName = "https"
+
+	annotationPrefix   = "nginx.ingress.kubernetes.io/"
+	appLabel           = "app"
+	appLabelValue      = "knuu-nginx-proxy"
+	selfSignedValidity = 7 * 24 * time.Hour
+	// streamTimeout is the time in seconds a WebSocket or a stream can stay idle before nginx closes it
+	streamTimeout = "3600"
+)
+
+// Nginx creates an Ingress per host, served by the ingress-nginx controller of the cluster
+type Nginx struct {
+	K8s k8s.KubeManager
+	// IngressClass is the class of the ingresses, DefaultIngressClass if empty
+	IngressClass string
+	// ControllerNamespace and ControllerService locate the service of the controller
+	// the endpoint of the proxy is read from, the defaults are the ones of the ingress-nginx chart
+	ControllerNamespace string
+	ControllerService   string
+
+	// the self-signed certificate is created with the first host that needs it
+	certMu   sync.Mutex
+	certPEM  []byte
+	certName string
+}
+
+var _ proxy.Proxy = &Nginx{}
+
+// Option configures the nginx proxy
+type Option func(*Nginx)
+
+// WithIngressClass sets the class of the ingresses
+func WithIngressClass(class string) Option {
+	return func(n *Nginx) {
+		n.IngressClass = class
+	}
+}
+
+// WithController sets the namespace and the name of the service of the ingress controller
+func WithController(namespace, service string) Option {
+	return func(n *Nginx) {
+		n.ControllerNamespace = namespace
+		n.ControllerService = service
+	}
+}
+
+// New returns a factory of nginx proxies to use with knuu.WithProxy
+func New(opts ...Option) proxy.Factory {
+	return func(k8sCli k8s.KubeManager) proxy.Proxy {
+		n := &Nginx{K8s: k8sCli}
+		for _, opt := range opts {
+			opt(n)
+		}
+		return n
+	}
+}
+
+// Deploy checks that the ingress class of the controller exists, the controller is not deployed by knuu
+func (n *Nginx) Deploy(ctx context.Context) error {
+	if n.K8s == nil {
+		return ErrClientNotInitialized
+	}
+	_, err := n.K8s.Clientset().NetworkingV1().IngressClasses().Get(ctx, n.ingressClass(), metav1.GetOptions{})
+	if err != nil {
+		return ErrIngressClassNotFound.WithParams(n.ingressClass()).Wrap(err)
+	}
+	return nil
+}
+
+// Endpoint returns the address of the HTTP port of the controller
+func (n *Nginx) Endpoint(ctx context.Context) (string, error) {
+	return n.address(ctx, httpPortName)
+}
+
+func (n *Nginx) URL(ctx context.Context, prefix string) (string, error) {
+	endpoint, err := n.address(ctx, httpPortName)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("http://%s/%s", endpoint, prefix), nil
+}
+
+func (n *Nginx) SecureURL(ctx context.Context, prefix string) (string, error) {
+	endpoint, err := n.address(ctx, httpsPortName)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("https://%s/%s", endpoint, prefix), nil
+}
+
+// AddHost creates an ingress routing the given prefix to the port of the service
+// Bearer tokens are not supported, as ingress-nginx can only check them with snippets that are disabled by default.
+// nginx only accepts HTTP/2 over TLS, so the clients of the gRPC hosts must use WithTLS.
+func (n *Nginx) AddHost(ctx context.Context, serviceName, prefix string, port int, opts ...proxy.HostOption) error {
+	if n.K8s == nil {
+		return ErrClientNotInitialized
+	}
+	options := proxy.NewHostOptions(opts...)
+	if err := options.Validate(); err != nil {
+		return ErrInvalidHostOptions.WithParams(prefix).Wrap(err)
+	}
+	if options.BearerToken != "" {
+		return ErrBearerTokenNotSupported
+	}
+
+	name, err := names.NewRandomK8("ingress-" + prefix)
+	if err != nil {
+		return ErrGeneratingRandomK8sName.Wrap(err)
+	}
+	route := ingressRoute{
+		name:         name,
+		namespace:    n.K8s.Namespace(),
+		ingressClass: n.ingressClass(),
+		serviceName:  serviceName,
+		prefix:       prefix,
+		port:         port,
+		grpc:         options.GRPC,
+	}
+	if options.BasicAuth != nil {
+		route.authSecret = name + "-auth"
+		err := n.createSecret(ctx, route.authSecret, v1.SecretTypeOpaque, map[string][]byte{
+			// ingress-nginx reads the credentials in the htpasswd format, {PLAIN} avoids hashing them here
+			"auth": []byte(options.BasicAuth.Username + ":{PLAIN}" + options.BasicAuth.Password),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	if options.TLS != nil {
+		if route.tlsSecret, err = n.tlsSecret(ctx, prefix, options.TLS); err != nil {
+			return err
+		}
+	}
+
+	ingress := route.ingress()
+	if _, err := n.K8s.Clientset().NetworkingV1().Ingresses(route.namespace).Create(ctx, ingress, metav1.CreateOptions{}); err != nil {
+		return ErrIngressCreationFailed.WithParams(name).Wrap(err)
+	}
+	return nil
+}
+
+// AddTCPHost is not supported, exposing a TCP port with ingress-nginx requires to change the configuration
+// and the service of the controller shared by the cluster. Expose the port with a LoadBalancer service instead.
+func (n *Nginx) AddTCPHost(_ context.Context, _, _ string, _ int) (string, error) {
+	return "", ErrTCPHostNotSupported
+}
+
+// RemoveTCPHosts does nothing, no TCP host is added, see AddTCPHost
+func (n *Nginx) RemoveTCPHosts(context.Context, string) error {
+	return nil
+}
+
+// Certificate returns the PEM encoded self-signed certificate of the proxy, nil until a host uses it
+func (n *Nginx) Certificate() []byte {
+	n.certMu.Lock()
+	defer n.certMu.Unlock()
+	return n.certPEM
+}
+
+func (n *Nginx) ingressClass() string {
+	if n.IngressClass == "" {
+		return DefaultIngressClass
+	}
+	return n.IngressClass
+}
+
+func (n *Nginx) controller() (namespace, service string) {
+	namespace, service = n.ControllerNamespace, n.ControllerService
+	if namespace == "" {
+		namespace = DefaultControllerNamespace
+	}
+	if service == "" {
+		service = DefaultControllerService
+	}
+	return namespace, service
+}
+
+// address returns the external address of the port of the controller with the given name
+func (n *Nginx) address(ctx context.Context, portName string) (string, error) {
+	if n.K8s == nil {
+		return "", ErrClientNotInitialized
+	}
+	namespace, name := n.controller()
+	svc, err := n.K8s.Clientset().CoreV1().Services(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return "", ErrGettingControllerService.WithParams(name, namespace).Wrap(err)
+	}
+	port, ok := servicePort(svc, portName)
+	if !ok {
+		return "", ErrControllerPortNotFound.WithParams(portName, name)
+	}
+
+	if svc.Spec.Type == v1.ServiceTypeLoadBalancer {
+		for _, ingress := range svc.Status.LoadBalancer.Ingress {
+			host := ingress.IP
+			if host == "" {
+				host = ingress.Hostname
+			}
+			if host != "" {
+				return net.JoinHostPort(host, fmt.Sprint(port.Port)), nil
+			}
+		}
+	}
+	if port.NodePort != 0 {
+		nodes, err := n.K8s.Clientset().CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return "", ErrGettingNodes.Wrap(err)
+		}
+		if host := nodeAddress(nodes.Items); host != "" {
+			return net.JoinHostPort(host, fmt.Sprint(port.NodePort)), nil
+		}
+	}
+	return "", ErrControllerAddressNotFound.WithParams(name)
+}
+
+// tlsSecret returns the name of the secret of the certificate of the host with the given prefix
+func (n *Nginx) tlsSecret(ctx context.Context, prefix string, config *proxy.TLS) (string, error) {
+	if !config.SelfSigned() {
+		name, err := names.NewRandomK8("tls-" + prefix)
+		if err != nil {
+			return "", ErrGeneratingRandomK8sName.Wrap(err)
+		}
+		err = n.createSecret(ctx, name, v1.SecretTypeTLS, map[string][]byte{
+			v1.TLSCertKey:       config.CertPEM,
+			v1.TLSPrivateKeyKey: config.KeyPEM,
+		})
+		return name, err
+	}
+
+	n.certMu.Lock()
+	defer n.certMu.Unlock()
+
+	if n.certName != "" {
+		return n.certName, nil
+	}
+	endpoint, err := n.address(ctx, httpsPortName)
+	if err != nil {
+		return "", err
+	}
+	host, _, err := net.SplitHostPort(endpoint)
+	if err != nil {
+		return "", ErrControllerAddressNotFound.WithParams(endpoint).Wrap(err)
+	}
+	certPEM, keyPEM, err := proxy.SelfSignedCertificate([]string{host}, selfSignedValidity)
+	if err != nil {
+		return "", ErrGeneratingCertificate.Wrap(err)
+	}
+	name, err := names.NewRandomK8("nginx-tls")
+	if err != nil {
+		return "", ErrGeneratingRandomK8sName.Wrap(err)
+	}
+	err = n.createSecret(ctx, name, v1.SecretTypeTLS, map[string][]byte{
+		v1.TLSCertKey:       certPEM,
+		v1.TLSPrivateKeyKey: keyPEM,
+	})
+	if err != nil {
+		return "", err
+	}
+	n.certPEM, n.certName = certPEM, name
+	return name, nil
+}
+
+func (n *Nginx) createSecret(ctx context.Context, name string, secretType v1.SecretType, data map[string][]byte) error {
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: n.K8s.Namespace(),
+			Labels:    map[string]string{appLabel: appLabelValue},
+		},
+		Type: secretType,
+		Data: data,
+	}
+	if _, err := n.K8s.Clientset().CoreV1().Secrets(n.K8s.Namespace()).Create(ctx, secret, metav1.CreateOptions{}); err != nil {
+		return ErrSecretCreationFailed.WithParams(name).Wrap(err)
+	}
+	return nil
+}
+
+// ingressRoute describes the ingress of a host
+type ingressRoute struct {
+	name         string
+	namespace    string
+	ingressClass string
+	serviceName  string
+	prefix       string
+	port         int
+	authSecret   string
+	tlsSecret    string
+	grpc         bool
+}
+
+// ingress returns the ingress of the route, the prefix is stripped with a regex path and a rewrite
+func (r ingressRoute) ingress() *networkingv1.Ingress {
+	annotations := map[string]string{
+		annotationPrefix + "use-regex":      "true",
+		annotationPrefix + "rewrite-target": "/$2",
+		// the WebSocket upgrades are forwarded by default, the timeouts keep the idle connections open
+		annotationPrefix + "proxy-read-timeout": streamTimeout,
+		annotationPrefix + "proxy-send-timeout": streamTimeout,
+	}
+	if r.grpc {
+		annotations[annotationPrefix+"backend-protocol"] = "GRPC"
+	}
+	if r.authSecret != "" {
+		annotations[annotationPrefix+"auth-type"] = "basic"
+		annotations[annotationPrefix+"auth-secret"] = r.authSecret
+		annotations[annotationPrefix+"auth-realm"] = r.prefix
+	}
+
+	ingress := &networkingv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        r.name,
+			Namespace:   r.namespace,
+			Labels:      map[string]string{appLabel: appLabelValue},
+			Annotations: annotations,
+		},
+		Spec: networkingv1.IngressSpec{
+			IngressClassName: ptr.To(r.ingressClass),
+			Rules: []networkingv1.IngressRule{{
+				IngressRuleValue: networkingv1.IngressRuleValue{
+					HTTP: &networkingv1.HTTPIngressRuleValue{
+						Paths: []networkingv1.HTTPIngressPath{{
+							Path:     "/" + strings.Trim(r.prefix, "/") + "(/|$)(.*)",
+							PathType: ptr.To(networkingv1.PathTypeImplementationSpecific),
+							Backend: networkingv1.IngressBackend{
+								Service: &networkingv1.IngressServiceBackend{
+									Name: r.serviceName,
+									Port: networkingv1.ServiceBackendPort{Number: int32(r.port)},
+								},
+							},
+						}},
+					},
+				},
+			}},
+		},
+	}
+	if r.tlsSecret != "" {
+		ingress.Spec.TLS = []networkingv1.IngressTLS{{SecretName: r.tlsSecret}}
+	}
+	return ingress
+}
+
+func servicePort(svc *v1.Service, name string) (v1.ServicePort, bool) {
+	for _, p := range svc.Spec.Ports {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return v1.ServicePort{}, false
+}
+
+// nodeAddress returns the external address of the first node that has one, or its internal address
+func nodeAddress(nodes []v1.Node) string {
+	var internal string
+	for _, node := range nodes {
+		for _, address := range node.Status.Addresses {
+			switch address.Type {
+			case v1.NodeExternalIP:
+				return address.Address
+			case v1.NodeInternalIP:
+				if internal == "" {
+					internal = address.Address
+				}
+			}
+		}
+	}
+	return internal
+}
@@ -0,0 +1,59 @@
+package nginx
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+)
+
+func TestIngressRoute(t *testing.T) {
+	t.Parallel()
+
+	route := ingressRoute{
+		name:         "ingress-app-8080",
+		namespace:    "test",
+		ingressClass: DefaultIngressClass,
+		serviceName:  "app",
+		prefix:       "app-8080",
+		port:         8080,
+	}
+	ingress := route.ingress()
+	assert.Equal(t, "/$2", ingress.Annotations[annotationPrefix+"rewrite-target"])
+	assert.NotContains(t, ingress.Annotations, annotationPrefix+"auth-type")
+	assert.NotContains(t, ingress.Annotations, annotationPrefix+"backend-protocol")
+	assert.Equal(t, streamTimeout, ingress.Annotations[annotationPrefix+"proxy-read-timeout"])
+	assert.Empty(t, ingress.Spec.TLS)
+	require.Len(t, ingress.Spec.Rules, 1)
+	path := ingress.Spec.Rules[0].HTTP.Paths[0]
+	assert.Equal(t, "/app-8080(/|$)(.*)", path.Path)
+	assert.Equal(t, networkingv1.PathTypeImplementationSpecific, *path.PathType)
+	assert.Equal(t, "app", path.Backend.Service.Name)
+	assert.Equal(t, int32(8080), path.Backend.Service.Port.Number)
+	assert.Equal(t, DefaultIngressClass, *ingress.Spec.IngressClassName)
+
+	route.authSecret = "auth"
+	route.tlsSecret = "tls"
+	route.grpc = true
+	ingress = route.ingress()
+	assert.Equal(t, "GRPC", ingress.Annotations[annotationPrefix+"backend-protocol"])
+	assert.Equal(t, "basic", ingress.Annotations[annotationPrefix+"auth-type"])
+	assert.Equal(t, "auth", ingress.Annotations[annotationPrefix+"auth-secret"])
+	assert.Equal(t, []networkingv1.IngressTLS{{SecretName: "tls"}}, ingress.Spec.TLS)
+}
+
+func TestNodeAddress(t *testing.T) {
+	t.Parallel()
+
+	internal := v1.Node{Status: v1.NodeStatus{Addresses: []v1.NodeAddress{{Type: v1.NodeInternalIP, Address: "10.0.0.1"}}}}
+	external := v1.Node{Status: v1.NodeStatus{Addresses: []v1.NodeAddress{
+		{Type: v1.NodeInternalIP, Address: "10.0.0.2"},
+		{Type: v1.NodeExternalIP, Address: "203.0.113.2"},
+	}}}
+
+	assert.Equal(t, "10.0.0.1", nodeAddress([]v1.Node{internal}))
+	assert.Equal(t, "203.0.113.2", nodeAddress([]v1.Node{internal, external}))
+	assert.Empty(t, nodeAddress(nil))
+}
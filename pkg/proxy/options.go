@@ -0,0 +1,113 @@
+// Package proxy defines the proxy of knuu and the options of the hosts exposed through it
+package proxy
+
+import (
+	"crypto/tls"
+	"regexp"
+)
+
+// bearerTokenRegex matches the token68 syntax of RFC 6750
+var bearerTokenRegex = regexp.MustCompile(`^[A-Za-z0-9\-._~+/]+=*$`)
+
+// HostOptions are the options of a host exposed through the proxy
+type HostOptions struct {
+	// TLS terminates TLS at the proxy, the host is served over plain HTTP if nil
+	TLS *TLS
+	// BasicAuth requires the clients to authenticate with a username and a password
+	BasicAuth *BasicAuth
+	// BearerToken requires the clients to send the header 'Authorization: Bearer <token>'
+	BearerToken string
+	// GRPC forwards the requests to the service with HTTP/2 over cleartext (h2c), as gRPC servers expect
+	// The standard gRPC clients cannot add the path prefix of the host to their requests, expose
+	// the port with Proxy.AddTCPHost for them.
+	GRPC bool
+}
+
+// TLS is the certificate served for a host
+// A self-signed certificate of the proxy is served if the certificate and the key are empty.
+type TLS struct {
+	CertPEM []byte
+	KeyPEM  []byte
+}
+
+// SelfSigned returns true if the proxy serves its self-signed certificate
+func (t *TLS) SelfSigned() bool {
+	return len(t.CertPEM) == 0 && len(t.KeyPEM) == 0
+}
+
+// BasicAuth are the credentials of the clients of a host
+type BasicAuth struct {
+	Username string
+	Password string
+}
+
+// HostOption configures a host exposed through the proxy
+type HostOption func(*HostOptions)
+
+// WithTLS serves the host over HTTPS with the self-signed certificate of the proxy
+func WithTLS() HostOption {
+	return func(o *HostOptions) {
+		o.TLS = &TLS{}
+	}
+}
+
+// WithCertificate serves the host over HTTPS with the given PEM encoded certificate and key
+func WithCertificate(certPEM, keyPEM []byte) HostOption {
+	return func(o *HostOptions) {
+		o.TLS = &TLS{CertPEM: certPEM, KeyPEM: keyPEM}
+	}
+}
+
+// WithBasicAuth requires the clients of the host to authenticate with the given credentials
+func WithBasicAuth(username, password string) HostOption {
+	return func(o *HostOptions) {
+		o.BasicAuth = &BasicAuth{Username: username, Password: password}
+	}
+}
+
+// WithBearerToken requires the clients of the host to send the given bearer token
+func WithBearerToken(token string) HostOption {
+	return func(o *HostOptions) {
+		o.BearerToken = token
+	}
+}
+
+// WithGRPC forwards the requests of the host to a gRPC or any other HTTP/2 cleartext server
+func WithGRPC() HostOption {
+	return func(o *HostOptions) {
+		o.GRPC = true
+	}
+}
+
+// NewHostOptions applies the given options
+func NewHostOptions(opts ...HostOption) HostOptions {
+	o := HostOptions{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// Validate checks the options
+func (o HostOptions) Validate() error {
+	if o.TLS != nil && !o.TLS.SelfSigned() {
+		if len(o.TLS.CertPEM) == 0 || len(o.TLS.KeyPEM) == 0 {
+			return ErrCertificateWithoutKey
+		}
+		if _, err := tls.X509KeyPair(o.TLS.CertPEM, o.TLS.KeyPEM); err != nil {
+			return ErrInvalidCertificate.Wrap(err)
+		}
+	}
+	if o.BasicAuth != nil {
+		if o.BasicAuth.Username == "" {
+			return ErrBasicAuthUsernameEmpty
+		}
+		if o.BasicAuth.Password == "" {
+			return ErrBasicAuthPasswordEmpty
+		}
+	}
+	if o.BearerToken != "" && !bearerTokenRegex.MatchString(o.BearerToken) {
+		return ErrInvalidBearerToken
+	}
+	return nil
+}
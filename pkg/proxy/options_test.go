@@ -0,0 +1,66 @@
+package proxy
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHostOptionsValidate(t *testing.T) {
+	t.Parallel()
+
+	certPEM, keyPEM, err := SelfSignedCertificate([]string{"localhost"}, time.Hour)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		opts    []HostOption
+		wantErr error
+	}{
+		{name: "no options"},
+		{name: "self-signed", opts: []HostOption{WithTLS()}},
+		{name: "certificate", opts: []HostOption{WithCertificate(certPEM, keyPEM)}},
+		{name: "certificate without key", opts: []HostOption{WithCertificate(certPEM, nil)}, wantErr: ErrCertificateWithoutKey},
+		{name: "invalid certificate", opts: []HostOption{WithCertificate(keyPEM, certPEM)}, wantErr: ErrInvalidCertificate},
+		{name: "basic auth", opts: []HostOption{WithBasicAuth("user", "secret")}},
+		{name: "basic auth without password", opts: []HostOption{WithBasicAuth("user", "")}, wantErr: ErrBasicAuthPasswordEmpty},
+		{name: "bearer token", opts: []HostOption{WithBearerToken("dGVzdC10b2tlbg==")}},
+		{name: "bearer token breaking the rule", opts: []HostOption{WithBearerToken("a`) || PathPrefix(`/")}, wantErr: ErrInvalidBearerToken},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := NewHostOptions(tt.opts...).Validate()
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestSelfSignedCertificate(t *testing.T) {
+	t.Parallel()
+
+	certPEM, keyPEM, err := SelfSignedCertificate([]string{"10.0.0.1", "proxy.test"}, time.Hour)
+	require.NoError(t, err)
+
+	pair, err := tls.X509KeyPair(certPEM, keyPEM)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(pair.Certificate[0])
+	require.NoError(t, err)
+
+	// the certificate can be trusted as its own root
+	roots := x509.NewCertPool()
+	roots.AddCert(cert)
+	for _, host := range []string{"10.0.0.1", "proxy.test"} {
+		_, err := cert.Verify(x509.VerifyOptions{DNSName: host, Roots: roots})
+		assert.NoError(t, err, host)
+	}
+}
@@ -0,0 +1,33 @@
+package proxy
+
+import (
+	"context"
+
+	"github.com/celestiaorg/knuu/pkg/k8s"
+)
+
+// Proxy exposes the services of the instances outside of the cluster under a path prefix
+// Implementations: traefik.Traefik deploys its own proxy, nginx.Nginx uses an ingress-nginx controller
+// running in the cluster, gateway.Gateway attaches routes to a Gateway API gateway of the cluster and
+// route.Route creates routes served by the router of an OpenShift cluster.
+type Proxy interface {
+	// Deploy deploys the proxy or checks that the proxy of the cluster can be used
+	Deploy(ctx context.Context) error
+	// Endpoint returns the address of the proxy reachable from outside of the cluster, in the form host:port
+	Endpoint(ctx context.Context) (string, error)
+	// AddHost exposes the given port of the service under the given path prefix, the prefix is stripped
+	// The WebSocket upgrades are forwarded to the service.
+	AddHost(ctx context.Context, serviceName, prefix string, port int, opts ...HostOption) error
+	// AddTCPHost exposes the given port of the service on a dedicated port of the proxy and returns
+	// its address in the form host:port, the connections are forwarded as they are
+	AddTCPHost(ctx context.Context, serviceName, name string, port int) (string, error)
+	// RemoveTCPHosts removes the TCP hosts of the service and releases their ports of the proxy
+	RemoveTCPHosts(ctx context.Context, serviceName string) error
+	// URL returns the URL of the host with the given prefix served over HTTP
+	URL(ctx context.Context, prefix string) (string, error)
+	// SecureURL returns the URL of the host with the given prefix served over HTTPS
+	SecureURL(ctx context.Context, prefix string) (string, error)
+}
+
+// Factory creates a proxy using the given kubernetes client
+type Factory func(k8sCli k8s.KubeManager) Proxy
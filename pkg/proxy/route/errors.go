@@ -0,0 +1,19 @@
+package route
+
+import (
+	"github.com/celestiaorg/knuu/pkg/errors"
+)
+
+type Error = errors.Error
+
+var (
+	ErrClientNotInitialized    = errors.New("RouteClientNotInitialized", "route proxy client not initialized")
+	ErrGettingIngressDomain    = errors.New("GettingIngressDomain", "error getting the domain of the routes from the ingress config '%s', set it with WithDomain")
+	ErrIngressDomainEmpty      = errors.New("IngressDomainEmpty", "the ingress config '%s' has no domain, set it with WithDomain")
+	ErrInvalidHostOptions      = errors.New("InvalidHostOptions", "invalid options of host '%s'")
+	ErrAuthNotSupported        = errors.New("AuthNotSupported", "authentication is not supported by the route proxy")
+	ErrGRPCNotSupported        = errors.New("GRPCNotSupported", "gRPC hosts are not supported by the route proxy, expose the port with a LoadBalancer service instead")
+	ErrGeneratingRandomK8sName = errors.New("GeneratingRandomK8sName", "error generating random K8s name")
+	ErrRouteCreationFailed     = errors.New("RouteCreationFailed", "error creating route '%s'")
+	ErrTCPHostNotSupported     = errors.New("TCPHostNotSupported", "TCP hosts are not supported by the route proxy, expose the port with a LoadBalancer service instead")
+)
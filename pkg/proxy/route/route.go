@@ -0,0 +1,256 @@
+// Package route exposes the instances through the router of an OpenShift cluster
+package route
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/celestiaorg/knuu/pkg/k8s"
+	"github.com/celestiaorg/knuu/pkg/names"
+	"github.com/celestiaorg/knuu/pkg/proxy"
+)
+
+const (
+	apiGroup   = "route.openshift.io"
+	apiVersion = "v1"
+
+	// ingressConfigName is the cluster wide ingress config holding the default domain of the routes
+	ingressConfigName = "cluster"
+	// rewriteTargetAnnotation makes the router replace the path of the route in the requests
+	rewriteTargetAnnotation = "haproxy.router.openshift.io/rewrite-target"
+	// timeoutAnnotation is the time a WebSocket or a stream can stay idle before the router closes it
+	timeoutAnnotation = "haproxy.router.openshift.io/timeout"
+	streamTimeout     = "1h"
+
+	httpPort  = "80"
+	httpsPort = "443"
+
+	appLabel      = "app"
+	appLabelValue = "knuu-route-proxy"
+)
+
+var (
+	routeResource         = schema.GroupVersionResource{Group: apiGroup, Version: apiVersion, Resource: "routes"}
+	ingressConfigResource = schema.GroupVersionResource{Group: "config.openshift.io", Version: "v1", Resource: "ingresses"}
+)
+
+// Route creates an OpenShift Route per host, served by the router of the cluster
+// All the routes of a test share the host 'knuu-<namespace>.<domain>' and are told apart by their path.
+type Route struct {
+	K8s k8s.KubeManager
+	// Domain is the domain of the routes, the default domain of the cluster if empty
+	Domain string
+
+	// the default domain is read once from the ingress config of the cluster
+	domainMu sync.Mutex
+	domain   string
+}
+
+var _ proxy.Proxy = &Route{}
+
+// Option configures the route proxy
+type Option func(*Route)
+
+// WithDomain sets the domain of the routes, e.g. apps.example.com, for the users that cannot read the ingress config
+func WithDomain(domain string) Option {
+	return func(r *Route) {
+		r.Domain = domain
+	}
+}
+
+// New returns a factory of route proxies to use with knuu.WithProxy
+func New(opts ...Option) proxy.Factory {
+	return func(k8sCli k8s.KubeManager) proxy.Proxy {
+		r := &Route{K8s: k8sCli}
+		for _, opt := range opts {
+			opt(r)
+		}
+		return r
+	}
+}
+
+// Deploy checks that the cluster serves routes and finds their domain, the router is not deployed by knuu
+func (r *Route) Deploy(ctx context.Context) error {
+	if r.K8s == nil {
+		return ErrClientNotInitialized
+	}
+	if err := r.K8s.RequireCapability(ctx, k8s.CapabilityRoutes); err != nil {
+		return err
+	}
+	_, err := r.host(ctx)
+	return err
+}
+
+// Endpoint returns the address of the HTTP port of the router for the host of the test
+func (r *Route) Endpoint(ctx context.Context) (string, error) {
+	host, err := r.host(ctx)
+	if err != nil {
+		return "", err
+	}
+	return net.JoinHostPort(host, httpPort), nil
+}
+
+func (r *Route) URL(ctx context.Context, prefix string) (string, error) {
+	host, err := r.host(ctx)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("http://%s/%s", host, prefix), nil
+}
+
+func (r *Route) SecureURL(ctx context.Context, prefix string) (string, error) {
+	host, err := r.host(ctx)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("https://%s/%s", host, prefix), nil
+}
+
+// AddHost creates a route forwarding the given prefix to the port of the service, the router strips the prefix
+// With TLS the router terminates it with the given certificate, or the default certificate of the router,
+// and the host stays reachable over plain HTTP.
+func (r *Route) AddHost(ctx context.Context, serviceName, prefix string, port int, opts ...proxy.HostOption) error {
+	options := proxy.NewHostOptions(opts...)
+	if err := options.Validate(); err != nil {
+		return ErrInvalidHostOptions.WithParams(prefix).Wrap(err)
+	}
+	if options.BasicAuth != nil || options.BearerToken != "" {
+		return ErrAuthNotSupported
+	}
+	if options.GRPC {
+		return ErrGRPCNotSupported
+	}
+
+	host, err := r.host(ctx)
+	if err != nil {
+		return err
+	}
+	name, err := names.NewRandomK8("route-" + prefix)
+	if err != nil {
+		return ErrGeneratingRandomK8sName.Wrap(err)
+	}
+	rt := route{
+		name:        name,
+		namespace:   r.K8s.Namespace(),
+		host:        host,
+		serviceName: serviceName,
+		prefix:      prefix,
+		port:        port,
+		tls:         options.TLS,
+	}
+	_, err = r.K8s.DynamicClient().Resource(routeResource).Namespace(rt.namespace).
+		Create(ctx, rt.object(), metav1.CreateOptions{})
+	if err != nil {
+		return ErrRouteCreationFailed.WithParams(name).Wrap(err)
+	}
+	return nil
+}
+
+// AddTCPHost is not supported, the router only forwards HTTP and TLS with SNI
+func (r *Route) AddTCPHost(context.Context, string, string, int) (string, error) {
+	return "", ErrTCPHostNotSupported
+}
+
+// RemoveTCPHosts does nothing, no TCP host is added, see AddTCPHost
+func (r *Route) RemoveTCPHosts(context.Context, string) error {
+	return nil
+}
+
+// host returns the host shared by the routes of the test
+func (r *Route) host(ctx context.Context) (string, error) {
+	if r.K8s == nil {
+		return "", ErrClientNotInitialized
+	}
+	domain, err := r.routesDomain(ctx)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("knuu-%s.%s", r.K8s.Namespace(), domain), nil
+}
+
+// routesDomain returns the domain of the routes, read from the ingress config of the cluster if not set
+func (r *Route) routesDomain(ctx context.Context) (string, error) {
+	if r.Domain != "" {
+		return r.Domain, nil
+	}
+
+	r.domainMu.Lock()
+	defer r.domainMu.Unlock()
+	if r.domain != "" {
+		return r.domain, nil
+	}
+	config, err := r.K8s.DynamicClient().Resource(ingressConfigResource).Get(ctx, ingressConfigName, metav1.GetOptions{})
+	if err != nil {
+		return "", ErrGettingIngressDomain.WithParams(ingressConfigName).Wrap(err)
+	}
+	domain, _, _ := unstructured.NestedString(config.Object, "spec", "domain")
+	if domain == "" {
+		return "", ErrIngressDomainEmpty.WithParams(ingressConfigName)
+	}
+	r.domain = domain
+	return domain, nil
+}
+
+// route describes the Route of a host
+type route struct {
+	name        string
+	namespace   string
+	host        string
+	serviceName string
+	prefix      string
+	port        int
+	tls         *proxy.TLS
+}
+
+func (r route) object() *unstructured.Unstructured {
+	spec := map[string]interface{}{
+		"host": r.host,
+		"path": "/" + strings.Trim(r.prefix, "/"),
+		"to": map[string]interface{}{
+			"kind":   "Service",
+			"name":   r.serviceName,
+			"weight": int64(100),
+		},
+		"port": map[string]interface{}{
+			"targetPort": int64(r.port),
+		},
+	}
+	if r.tls != nil {
+		tls := map[string]interface{}{
+			"termination": "edge",
+			// the hosts with TLS stay reachable over HTTP, like with the other proxies
+			"insecureEdgeTerminationPolicy": "Allow",
+		}
+		if !r.tls.SelfSigned() {
+			tls["certificate"] = string(r.tls.CertPEM)
+			tls["key"] = string(r.tls.KeyPEM)
+		}
+		spec["tls"] = tls
+	}
+
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": apiGroup + "/" + apiVersion,
+			"kind":       "Route",
+			"metadata": map[string]interface{}{
+				"name":      r.name,
+				"namespace": r.namespace,
+				"labels": map[string]interface{}{
+					appLabel: appLabelValue,
+				},
+				"annotations": map[string]interface{}{
+					rewriteTargetAnnotation: "/",
+					timeoutAnnotation:       streamTimeout,
+				},
+			},
+			"spec": spec,
+		},
+	}
+}
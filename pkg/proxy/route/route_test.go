@@ -0,0 +1,108 @@
+package route
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/celestiaorg/knuu/pkg/k8s"
+	"github.com/celestiaorg/knuu/pkg/k8s/fake"
+	"github.com/celestiaorg/knuu/pkg/proxy"
+)
+
+func TestRouteObject(t *testing.T) {
+	t.Parallel()
+
+	rt := route{
+		name:        "route-app-8080",
+		namespace:   "test",
+		host:        "knuu-test.apps.example.com",
+		serviceName: "app",
+		prefix:      "app-8080",
+		port:        8080,
+	}
+	obj := rt.object()
+	assert.Equal(t, "Route", obj.GetKind())
+	assert.Equal(t, "/", obj.GetAnnotations()[rewriteTargetAnnotation])
+
+	path, _, _ := unstructured.NestedString(obj.Object, "spec", "path")
+	assert.Equal(t, "/app-8080", path)
+	service, _, _ := unstructured.NestedString(obj.Object, "spec", "to", "name")
+	assert.Equal(t, "app", service)
+	port, _, _ := unstructured.NestedInt64(obj.Object, "spec", "port", "targetPort")
+	assert.Equal(t, int64(8080), port)
+	_, found, _ := unstructured.NestedMap(obj.Object, "spec", "tls")
+	assert.False(t, found)
+
+	rt.tls = &proxy.TLS{CertPEM: []byte("cert"), KeyPEM: []byte("key")}
+	tls, _, _ := unstructured.NestedStringMap(rt.object().Object, "spec", "tls")
+	assert.Equal(t, map[string]string{
+		"termination":                   "edge",
+		"insecureEdgeTerminationPolicy": "Allow",
+		"certificate":                   "cert",
+		"key":                           "key",
+	}, tls)
+}
+
+func TestRoute(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	k8sCli, err := fake.New(ctx, "test")
+	require.NoError(t, err)
+	r := New()(k8sCli)
+
+	// not an OpenShift cluster
+	assert.ErrorIs(t, r.Deploy(ctx), k8s.ErrCapabilityNotSupported)
+
+	k8sCli, err = fake.New(ctx, "test")
+	require.NoError(t, err)
+	k8sCli.AddAPIResources("route.openshift.io/v1", "routes")
+	r = New()(k8sCli)
+	assert.ErrorIs(t, r.Deploy(ctx), ErrGettingIngressDomain)
+
+	config := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "config.openshift.io/v1",
+		"kind":       "Ingress",
+		"metadata":   map[string]interface{}{"name": ingressConfigName},
+		"spec":       map[string]interface{}{"domain": "apps.example.com"},
+	}}
+	_, err = k8sCli.FakeDynamicClient.Resource(ingressConfigResource).Create(ctx, config, metav1.CreateOptions{})
+	require.NoError(t, err)
+	require.NoError(t, r.Deploy(ctx))
+
+	endpoint, err := r.Endpoint(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "knuu-test.apps.example.com:80", endpoint)
+	url, err := r.SecureURL(ctx, "app-8080")
+	require.NoError(t, err)
+	assert.Equal(t, "https://knuu-test.apps.example.com/app-8080", url)
+
+	require.NoError(t, r.AddHost(ctx, "app", "app-8080", 8080, proxy.WithTLS()))
+	var created []*unstructured.Unstructured
+	for _, action := range k8sCli.FakeDynamicClient.Actions() {
+		if create, ok := action.(k8stesting.CreateAction); ok && action.GetResource() == routeResource {
+			created = append(created, create.GetObject().(*unstructured.Unstructured))
+		}
+	}
+	require.Len(t, created, 1)
+	host, _, _ := unstructured.NestedString(created[0].Object, "spec", "host")
+	assert.Equal(t, "knuu-test.apps.example.com", host)
+	termination, _, _ := unstructured.NestedString(created[0].Object, "spec", "tls", "termination")
+	assert.Equal(t, "edge", termination)
+
+	assert.ErrorIs(t, r.AddHost(ctx, "app", "app-9090", 9090, proxy.WithBearerToken("token")), ErrAuthNotSupported)
+	_, err = r.AddTCPHost(ctx, "app", "p2p", 26656)
+	assert.ErrorIs(t, err, ErrTCPHostNotSupported)
+
+	// the domain is not read from the cluster if it is set
+	r = New(WithDomain("apps.other.com"))(k8sCli)
+	url, err = r.URL(ctx, "app-8080")
+	require.NoError(t, err)
+	assert.Equal(t, "http://knuu-test.apps.other.com/app-8080", url)
+}
@@ -0,0 +1,11 @@
+package rbac
+
+import (
+	"github.com/celestiaorg/knuu/pkg/errors"
+)
+
+type Error = errors.Error
+
+var (
+	ErrReadingAuditLog = errors.New("ReadingAuditLog", "error reading the audit log at line %d")
+)
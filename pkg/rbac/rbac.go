@@ -0,0 +1,190 @@
+// Package rbac computes the minimal RBAC rules of the service accounts of the instances from the audit log of the cluster
+// The API server records the calls in its audit log when it runs with an audit policy that logs them at the
+// level Metadata, e.g. kind with the audit-policy-file and audit-log-path flags of the kube-apiserver.
+package rbac
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"sort"
+	"strings"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+)
+
+// stageResponseComplete is the stage of the audit events recorded once the response is sent
+const stageResponseComplete = "ResponseComplete"
+
+// AuditEvent is the part of an event of the audit log (audit.k8s.io/v1) used to compute the rules
+type AuditEvent struct {
+	Stage      string     `json:"stage"`
+	Verb       string     `json:"verb"`
+	RequestURI string     `json:"requestURI"`
+	User       AuditUser  `json:"user"`
+	ObjectRef  *ObjectRef `json:"objectRef,omitempty"`
+}
+
+// AuditUser is the user that made the call
+type AuditUser struct {
+	Username string `json:"username"`
+}
+
+// ObjectRef is the object of a call, it is nil for the calls to non-resource URLs such as /healthz
+type ObjectRef struct {
+	Resource    string `json:"resource"`
+	Subresource string `json:"subresource,omitempty"`
+	Namespace   string `json:"namespace,omitempty"`
+	Name        string `json:"name,omitempty"`
+	APIGroup    string `json:"apiGroup,omitempty"`
+}
+
+// resource returns the resource of the call as written in the rules, e.g. pods/log
+func (o *ObjectRef) resource() string {
+	if o.Subresource == "" {
+		return o.Resource
+	}
+	return o.Resource + "/" + o.Subresource
+}
+
+// ServiceAccountUser returns the name of the user of the given service account in the audit events
+func ServiceAccountUser(namespace, name string) string {
+	return "system:serviceaccount:" + namespace + ":" + name
+}
+
+// ReadAuditLog reads the events of an audit log written by the log backend, one JSON event per line
+func ReadAuditLog(r io.Reader) ([]AuditEvent, error) {
+	var events []AuditEvent
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(strings.TrimSpace(scanner.Text())) == 0 {
+			continue
+		}
+		var e AuditEvent
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return nil, ErrReadingAuditLog.WithParams(line).Wrap(err)
+		}
+		events = append(events, e)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, ErrReadingAuditLog.WithParams(0).Wrap(err)
+	}
+	return events, nil
+}
+
+// Report compares the rules granted to a service account with the calls it made
+type Report struct {
+	ServiceAccount string `json:"serviceAccount"`
+	// Granted are the rules of the role of the service account
+	Granted []rbacv1.PolicyRule `json:"granted"`
+	// Minimal are the rules that allow exactly the calls made by the service account
+	Minimal []rbacv1.PolicyRule `json:"minimal"`
+	// Unused are the granted rules that allowed none of the calls
+	Unused []rbacv1.PolicyRule `json:"unused,omitempty"`
+}
+
+// NewReport returns the report of the service account of the given user, see ServiceAccountUser
+func NewReport(user string, granted []rbacv1.PolicyRule, events []AuditEvent) *Report {
+	calls := callsOf(user, events)
+	r := &Report{
+		ServiceAccount: user,
+		Granted:        granted,
+		Minimal:        MinimalRules(calls),
+	}
+	for _, rule := range granted {
+		used := false
+		for _, e := range calls {
+			if Allows(rule, e) {
+				used = true
+				break
+			}
+		}
+		if !used {
+			r.Unused = append(r.Unused, rule)
+		}
+	}
+	return r
+}
+
+// callsOf returns the completed calls of the given user
+func callsOf(user string, events []AuditEvent) []AuditEvent {
+	var calls []AuditEvent
+	for _, e := range events {
+		// the calls are recorded at each stage, e.g. RequestReceived and ResponseComplete
+		if e.User.Username != user || (e.Stage != "" && e.Stage != stageResponseComplete) {
+			continue
+		}
+		calls = append(calls, e)
+	}
+	return calls
+}
+
+// MinimalRules returns the rules that allow exactly the given calls, a rule per API group and resource
+func MinimalRules(calls []AuditEvent) []rbacv1.PolicyRule {
+	type key struct{ group, resource string }
+	verbs := make(map[key]map[string]bool)
+	urls := make(map[string]map[string]bool)
+	for _, e := range calls {
+		if e.ObjectRef == nil {
+			if urls[e.RequestURI] == nil {
+				urls[e.RequestURI] = make(map[string]bool)
+			}
+			urls[e.RequestURI][e.Verb] = true
+			continue
+		}
+		k := key{e.ObjectRef.APIGroup, e.ObjectRef.resource()}
+		if verbs[k] == nil {
+			verbs[k] = make(map[string]bool)
+		}
+		verbs[k][e.Verb] = true
+	}
+
+	rules := make([]rbacv1.PolicyRule, 0, len(verbs)+len(urls))
+	for k, v := range verbs {
+		rules = append(rules, rbacv1.PolicyRule{APIGroups: []string{k.group}, Resources: []string{k.resource}, Verbs: sortedKeys(v)})
+	}
+	for url, v := range urls {
+		rules = append(rules, rbacv1.PolicyRule{NonResourceURLs: []string{url}, Verbs: sortedKeys(v)})
+	}
+	sort.Slice(rules, func(a, b int) bool {
+		return ruleKey(rules[a]) < ruleKey(rules[b])
+	})
+	return rules
+}
+
+// Allows returns true if the rule allows the given call
+func Allows(rule rbacv1.PolicyRule, e AuditEvent) bool {
+	if !matches(rule.Verbs, e.Verb) {
+		return false
+	}
+	if e.ObjectRef == nil {
+		return matches(rule.NonResourceURLs, e.RequestURI)
+	}
+	if !matches(rule.APIGroups, e.ObjectRef.APIGroup) || !matches(rule.Resources, e.ObjectRef.resource()) {
+		return false
+	}
+	return len(rule.ResourceNames) == 0 || matches(rule.ResourceNames, e.ObjectRef.Name)
+}
+
+func matches(values []string, value string) bool {
+	for _, v := range values {
+		if v == rbacv1.VerbAll || v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func ruleKey(r rbacv1.PolicyRule) string {
+	return strings.Join(r.APIGroups, ",") + "/" + strings.Join(r.Resources, ",") + strings.Join(r.NonResourceURLs, ",")
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,54 @@
+package rbac
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	rbacv1 "k8s.io/api/rbac/v1"
+)
+
+const auditLog = `{"stage":"RequestReceived","verb":"get","user":{"username":"system:serviceaccount:test:validator"},"objectRef":{"resource":"pods","namespace":"test","name":"bridge"}}
+{"stage":"ResponseComplete","verb":"get","user":{"username":"system:serviceaccount:test:validator"},"objectRef":{"resource":"pods","namespace":"test","name":"bridge"}}
+{"stage":"ResponseComplete","verb":"list","user":{"username":"system:serviceaccount:test:validator"},"objectRef":{"resource":"pods","namespace":"test"}}
+{"stage":"ResponseComplete","verb":"get","user":{"username":"system:serviceaccount:test:validator"},"objectRef":{"resource":"pods","subresource":"log","namespace":"test","name":"bridge"}}
+{"stage":"ResponseComplete","verb":"get","requestURI":"/healthz","user":{"username":"system:serviceaccount:test:validator"}}
+
+{"stage":"ResponseComplete","verb":"delete","user":{"username":"system:serviceaccount:test:bridge"},"objectRef":{"resource":"deployments","apiGroup":"apps","namespace":"test"}}
+`
+
+func TestReport(t *testing.T) {
+	t.Parallel()
+
+	events, err := ReadAuditLog(strings.NewReader(auditLog))
+	require.NoError(t, err)
+	require.Len(t, events, 6)
+
+	granted := []rbacv1.PolicyRule{
+		{APIGroups: []string{""}, Resources: []string{"pods", "pods/log"}, Verbs: []string{"*"}},
+		{APIGroups: []string{"apps"}, Resources: []string{"deployments"}, Verbs: []string{"get", "delete"}},
+	}
+	r := NewReport(ServiceAccountUser("test", "validator"), granted, events)
+	assert.Equal(t, "system:serviceaccount:test:validator", r.ServiceAccount)
+	assert.Equal(t, []rbacv1.PolicyRule{
+		{NonResourceURLs: []string{"/healthz"}, Verbs: []string{"get"}},
+		{APIGroups: []string{""}, Resources: []string{"pods"}, Verbs: []string{"get", "list"}},
+		{APIGroups: []string{""}, Resources: []string{"pods/log"}, Verbs: []string{"get"}},
+	}, r.Minimal)
+	assert.Equal(t, granted[1:], r.Unused)
+
+	_, err = ReadAuditLog(strings.NewReader("{}\nnot json\n"))
+	assert.ErrorIs(t, err, ErrReadingAuditLog)
+}
+
+func TestAllows(t *testing.T) {
+	t.Parallel()
+
+	get := AuditEvent{Verb: "get", ObjectRef: &ObjectRef{Resource: "configmaps", Name: "genesis"}}
+	assert.True(t, Allows(rbacv1.PolicyRule{APIGroups: []string{"*"}, Resources: []string{"*"}, Verbs: []string{"*"}}, get))
+	assert.True(t, Allows(rbacv1.PolicyRule{APIGroups: []string{""}, Resources: []string{"configmaps"}, ResourceNames: []string{"genesis"}, Verbs: []string{"get"}}, get))
+	assert.False(t, Allows(rbacv1.PolicyRule{APIGroups: []string{""}, Resources: []string{"configmaps"}, ResourceNames: []string{"other"}, Verbs: []string{"get"}}, get))
+	assert.False(t, Allows(rbacv1.PolicyRule{APIGroups: []string{""}, Resources: []string{"configmaps"}, Verbs: []string{"list"}}, get))
+	assert.False(t, Allows(rbacv1.PolicyRule{APIGroups: []string{"apps"}, Resources: []string{"configmaps"}, Verbs: []string{"get"}}, get))
+}
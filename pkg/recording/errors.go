@@ -0,0 +1,12 @@
+package recording
+
+import (
+	"github.com/celestiaorg/knuu/pkg/errors"
+)
+
+type Error = errors.Error
+
+var (
+	ErrWritingRecording = errors.New("WritingRecording", "error writing recording file '%s'")
+	ErrReadingRecording = errors.New("ReadingRecording", "error reading recording file '%s'")
+)
@@ -0,0 +1,161 @@
+// Package recording records the operations knuu executes on the instances of a test with their parameters and
+// timestamps, so that the same sequence can be replayed to reproduce a bug found in a long scenario, see
+// scenario.Runner.Replay.
+//
+// A recording is saved as YAML:
+//
+//	startTime: 2024-05-02T10:00:00Z
+//	instances:
+//	  - name: server
+//	    image: nginx:latest
+//	    portsTCP: [80]
+//	    bitTwister: true
+//	operations:
+//	  - {at: 1s, instance: server, kind: start}
+//	  - {at: 12s, instance: server, kind: latency, latency: 200ms}
+//	  - {at: 40s, instance: server, kind: exec, command: [nginx, -s, reload]}
+package recording
+
+import (
+	"os"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Kind is the kind of a recorded operation
+type Kind string
+
+const (
+	KindStart Kind = "start"
+	KindStop  Kind = "stop"
+	KindExec  Kind = "exec"
+	// KindLatency sets the latency and the jitter of the instance
+	KindLatency    Kind = "latency"
+	KindPacketLoss Kind = "packetLoss"
+	KindBandwidth  Kind = "bandwidth"
+	// KindNetworkDisabled and KindNetworkEnabled cut the network of the instance and restore it
+	KindNetworkDisabled Kind = "networkDisabled"
+	KindNetworkEnabled  Kind = "networkEnabled"
+)
+
+// Recording is the sequence of the operations executed on the instances of a test
+type Recording struct {
+	StartTime time.Time `yaml:"startTime"`
+	// Instances are the instances committed during the test, in order
+	Instances  []Instance  `yaml:"instances"`
+	Operations []Operation `yaml:"operations"`
+}
+
+// Instance is the configuration of a committed instance, with the image it was committed with
+type Instance struct {
+	Name          string            `yaml:"name"`
+	Image         string            `yaml:"image"`
+	Command       []string          `yaml:"command,omitempty"`
+	Args          []string          `yaml:"args,omitempty"`
+	PortsTCP      []int             `yaml:"portsTCP,omitempty"`
+	PortsUDP      []int             `yaml:"portsUDP,omitempty"`
+	Env           map[string]string `yaml:"env,omitempty"`
+	MemoryRequest string            `yaml:"memoryRequest,omitempty"`
+	MemoryLimit   string            `yaml:"memoryLimit,omitempty"`
+	CPURequest    string            `yaml:"cpuRequest,omitempty"`
+	BitTwister    bool              `yaml:"bitTwister,omitempty"`
+}
+
+// Operation is an operation executed on an instance, only the parameters of its kind are set
+type Operation struct {
+	// At is the time the operation started, since the start of the recording
+	At       time.Duration `yaml:"at"`
+	Duration time.Duration `yaml:"duration,omitempty"`
+	Instance string        `yaml:"instance"`
+	Kind     Kind          `yaml:"kind"`
+
+	Command    []string      `yaml:"command,omitempty"`
+	Latency    time.Duration `yaml:"latency,omitempty"`
+	Jitter     time.Duration `yaml:"jitter,omitempty"`
+	PacketLoss int32         `yaml:"packetLoss,omitempty"`
+	// Bandwidth is the bandwidth limit in bits per second
+	Bandwidth int64 `yaml:"bandwidth,omitempty"`
+
+	// Error is the message of the error the operation failed with, empty if it succeeded
+	Error string `yaml:"error,omitempty"`
+}
+
+// Recorder records the operations of a test
+// It is safe for concurrent use. A nil *Recorder records nothing.
+type Recorder struct {
+	mu        sync.Mutex
+	recording Recording
+}
+
+// NewRecorder returns a recorder starting now
+func NewRecorder() *Recorder {
+	return &Recorder{recording: Recording{StartTime: time.Now()}}
+}
+
+// AddInstance records a committed instance
+func (r *Recorder) AddInstance(inst Instance) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.recording.Instances = append(r.recording.Instances, inst)
+}
+
+// Record records an operation that started at the given time and ended now
+// If err is not nil, the operation is recorded as failed.
+func (r *Recorder) Record(op Operation, start time.Time, err error) {
+	if r == nil {
+		return
+	}
+	op.Duration = time.Since(start)
+	// the message is captured now, as knuu errors can be modified when they are returned again
+	if err != nil {
+		op.Error = err.Error()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	op.At = start.Sub(r.recording.StartTime)
+	r.recording.Operations = append(r.recording.Operations, op)
+}
+
+// Recording returns a snapshot of the operations recorded so far, in the order they were recorded
+func (r *Recorder) Recording() *Recording {
+	if r == nil {
+		return &Recording{}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	rec := r.recording
+	rec.Instances = append([]Instance(nil), rec.Instances...)
+	rec.Operations = append([]Operation(nil), rec.Operations...)
+	return &rec
+}
+
+// Save writes the recording to the given path as YAML
+func (r *Recorder) Save(path string) error {
+	data, err := yaml.Marshal(r.Recording())
+	if err != nil {
+		return ErrWritingRecording.WithParams(path).Wrap(err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return ErrWritingRecording.WithParams(path).Wrap(err)
+	}
+	return nil
+}
+
+// Load reads the recording saved at the given path
+func Load(path string) (*Recording, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, ErrReadingRecording.WithParams(path).Wrap(err)
+	}
+	rec := &Recording{}
+	if err := yaml.Unmarshal(data, rec); err != nil {
+		return nil, ErrReadingRecording.WithParams(path).Wrap(err)
+	}
+	return rec, nil
+}
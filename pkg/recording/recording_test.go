@@ -0,0 +1,49 @@
+package recording
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecorder(t *testing.T) {
+	t.Parallel()
+
+	r := NewRecorder()
+	r.AddInstance(Instance{Name: "server", Image: "nginx:latest", PortsTCP: []int{80}, BitTwister: true})
+	start := r.Recording().StartTime.Add(time.Second)
+	r.Record(Operation{Instance: "server", Kind: KindStart}, start, nil)
+	r.Record(Operation{Instance: "server", Kind: KindLatency, Latency: 200 * time.Millisecond}, start.Add(time.Second), errors.New("not ready"))
+
+	rec := r.Recording()
+	require.Len(t, rec.Instances, 1)
+	require.Len(t, rec.Operations, 2)
+	assert.Equal(t, time.Second, rec.Operations[0].At)
+	assert.Empty(t, rec.Operations[0].Error)
+	assert.Equal(t, 2*time.Second, rec.Operations[1].At)
+	assert.Equal(t, "not ready", rec.Operations[1].Error)
+
+	path := filepath.Join(t.TempDir(), "recording.yaml")
+	require.NoError(t, r.Save(path))
+	loaded, err := Load(path)
+	require.NoError(t, err)
+	assert.True(t, rec.StartTime.Equal(loaded.StartTime))
+	assert.Equal(t, rec.Instances, loaded.Instances)
+	assert.Equal(t, rec.Operations, loaded.Operations)
+
+	_, err = Load(filepath.Join(t.TempDir(), "missing.yaml"))
+	assert.ErrorIs(t, err, ErrReadingRecording)
+}
+
+func TestNilRecorder(t *testing.T) {
+	t.Parallel()
+
+	var r *Recorder
+	r.AddInstance(Instance{Name: "server"})
+	r.Record(Operation{Instance: "server", Kind: KindStop}, time.Now(), nil)
+	assert.Empty(t, r.Recording().Operations)
+}
@@ -0,0 +1,220 @@
+package remote
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/celestiaorg/knuu/pkg/errors"
+)
+
+// Client drives the instances of a Server
+// Errors returned by the server keep their code, so they can be checked with errors.Is against the errors of this package.
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	token      string
+}
+
+// ClientOption configures a Client
+type ClientOption func(*Client)
+
+// WithHTTPClient sets the HTTP client used to send the requests
+// The client must not have a timeout if logs or events are followed.
+func WithHTTPClient(httpClient *http.Client) ClientOption {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithToken sets the bearer token sent with every request, required if the server is started with WithRequiredToken
+func WithToken(token string) ClientOption {
+	return func(c *Client) {
+		c.token = token
+	}
+}
+
+// NewClient returns a client for the server listening at the given URL
+func NewClient(baseURL string, opts ...ClientOption) *Client {
+	c := &Client{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		httpClient: http.DefaultClient,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// CreateInstance creates and commits an instance
+func (c *Client) CreateInstance(ctx context.Context, req CreateInstanceRequest) (*InstanceStatus, error) {
+	status := &InstanceStatus{}
+	if err := c.do(ctx, http.MethodPost, pathInstances, req, status); err != nil {
+		return nil, err
+	}
+	return status, nil
+}
+
+// ListInstances returns all the instances of the server
+func (c *Client) ListInstances(ctx context.Context) ([]InstanceStatus, error) {
+	var statuses []InstanceStatus
+	if err := c.do(ctx, http.MethodGet, pathInstances, nil, &statuses); err != nil {
+		return nil, err
+	}
+	return statuses, nil
+}
+
+// GetInstance returns the instance with the given ID
+func (c *Client) GetInstance(ctx context.Context, id string) (*InstanceStatus, error) {
+	status := &InstanceStatus{}
+	if err := c.do(ctx, http.MethodGet, instancePath(id), nil, status); err != nil {
+		return nil, err
+	}
+	return status, nil
+}
+
+// ConfigureInstance changes the configuration of a committed instance
+func (c *Client) ConfigureInstance(ctx context.Context, id string, req ConfigureInstanceRequest) (*InstanceStatus, error) {
+	status := &InstanceStatus{}
+	if err := c.do(ctx, http.MethodPost, instancePath(id)+"/configure", req, status); err != nil {
+		return nil, err
+	}
+	return status, nil
+}
+
+// StartInstance starts the instance and waits until it is running
+func (c *Client) StartInstance(ctx context.Context, id string) (*InstanceStatus, error) {
+	status := &InstanceStatus{}
+	if err := c.do(ctx, http.MethodPost, instancePath(id)+"/start", nil, status); err != nil {
+		return nil, err
+	}
+	return status, nil
+}
+
+// StopInstance stops the instance
+func (c *Client) StopInstance(ctx context.Context, id string) (*InstanceStatus, error) {
+	status := &InstanceStatus{}
+	if err := c.do(ctx, http.MethodPost, instancePath(id)+"/stop", nil, status); err != nil {
+		return nil, err
+	}
+	return status, nil
+}
+
+// DestroyInstance destroys the instance
+func (c *Client) DestroyInstance(ctx context.Context, id string) error {
+	return c.do(ctx, http.MethodDelete, instancePath(id), nil, nil)
+}
+
+// ExecuteCommand executes the command in the instance and returns its output
+func (c *Client) ExecuteCommand(ctx context.Context, id string, command ...string) (string, error) {
+	resp := &ExecResponse{}
+	if err := c.do(ctx, http.MethodPost, instancePath(id)+"/exec", ExecRequest{Command: command}, resp); err != nil {
+		return "", err
+	}
+	return resp.Output, nil
+}
+
+// Logs returns a stream of the logs of the instance
+// If follow is true, the stream stays open until the instance stops or the context is cancelled.
+// The caller must close the stream.
+func (c *Client) Logs(ctx context.Context, id string, follow bool) (io.ReadCloser, error) {
+	path := instancePath(id) + "/logs?follow=" + strconv.FormatBool(follow)
+	resp, err := c.send(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// WatchEvents calls handle for every event of the server until the context is cancelled or the server closes the stream
+// Only the events that happen after the call are received.
+func (c *Client) WatchEvents(ctx context.Context, handle func(Event)) error {
+	resp, err := c.send(ctx, http.MethodGet, pathEvents, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		var event Event
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			return requestError(ErrDecodingResponse, err, http.MethodGet, pathEvents)
+		}
+		handle(event)
+	}
+	if ctx.Err() != nil {
+		return nil
+	}
+	if err := scanner.Err(); err != nil {
+		return requestError(ErrDecodingResponse, err, http.MethodGet, pathEvents)
+	}
+	return nil
+}
+
+// do sends the request with body encoded as JSON and decodes the response into out, if it is not nil
+func (c *Client) do(ctx context.Context, method, path string, body, out interface{}) error {
+	resp, err := c.send(ctx, method, path, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return requestError(ErrDecodingResponse, err, method, path)
+	}
+	return nil
+}
+
+// send sends the request and returns the response if it succeeded
+// The error returned by the server is returned otherwise.
+func (c *Client) send(ctx context.Context, method, path string, body interface{}) (*http.Response, error) {
+	var reqBody io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, requestError(ErrCreatingRequest, err, method, path)
+		}
+		reqBody = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reqBody)
+	if err != nil {
+		return nil, requestError(ErrCreatingRequest, err, method, path)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, requestError(ErrSendingRequest, err, method, path)
+	}
+	if resp.StatusCode < http.StatusBadRequest {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+
+	var errResp errorResponse
+	if err := json.NewDecoder(resp.Body).Decode(&errResp); err != nil || errResp.Code == "" {
+		return nil, requestError(ErrRequestFailed, nil, method, path, resp.StatusCode)
+	}
+	// the message is already formatted by the server
+	return nil, errors.New(errResp.Code, strings.ReplaceAll(errResp.Message, "%", "%%"))
+}
+
+func instancePath(id string) string {
+	return pathInstances + "/" + url.PathEscape(id)
+}
@@ -0,0 +1,36 @@
+package remote
+
+import (
+	"github.com/celestiaorg/knuu/pkg/errors"
+)
+
+type Error = errors.Error
+
+var (
+	ErrInstanceNotFound      = errors.New("InstanceNotFound", "instance '%s' not found")
+	ErrDecodingRequest       = errors.New("DecodingRequest", "error decoding the request")
+	ErrCreatingInstance      = errors.New("CreatingInstance", "error creating instance '%s'")
+	ErrConfiguringInstance   = errors.New("ConfiguringInstance", "error configuring instance '%s'")
+	ErrUnauthorized          = errors.New("Unauthorized", "missing or invalid token")
+	ErrCommittingInstance    = errors.New("CommittingInstance", "error committing instance '%s'")
+	ErrStartingInstance      = errors.New("StartingInstance", "error starting instance '%s'")
+	ErrStoppingInstance      = errors.New("StoppingInstance", "error stopping instance '%s'")
+	ErrDestroyingInstance    = errors.New("DestroyingInstance", "error destroying instance '%s'")
+	ErrExecutingCommand      = errors.New("ExecutingCommand", "error executing command in instance '%s'")
+	ErrGettingLogs           = errors.New("GettingLogs", "error getting logs of instance '%s'")
+	ErrStreamingNotSupported = errors.New("StreamingNotSupported", "the response writer does not support streaming")
+	ErrCreatingRequest       = errors.New("CreatingRequest", "error creating request %s %s")
+	ErrSendingRequest        = errors.New("SendingRequest", "error sending request %s %s")
+	ErrDecodingResponse      = errors.New("DecodingResponse", "error decoding response of %s %s")
+	ErrRequestFailed         = errors.New("RequestFailed", "request %s %s failed with status %d")
+)
+
+// requestError returns a new error with the code and message of base
+// The server and the client handle concurrent requests, so they do not set params on the shared errors.
+func requestError(base *Error, cause error, params ...interface{}) *Error {
+	err := errors.New(base.Code(), base.Message()).WithParams(params...)
+	if cause != nil {
+		err = err.Wrap(cause)
+	}
+	return err
+}
@@ -0,0 +1,95 @@
+// Package remote runs knuu as a service in the cluster and drives it over HTTP,
+// so that tests running outside of the cluster do not need credentials for it.
+//
+// The API is plain HTTP with JSON bodies, the logs and the events are streamed as newline-delimited JSON, rather
+// than gRPC on purpose: it needs no generated code nor new dependencies, tests written in any language or curl can
+// drive it, and it goes through the ingresses and the proxies of the clusters, which do not all forward HTTP/2.
+package remote
+
+import (
+	"time"
+)
+
+// Paths of the API served by Server
+const (
+	pathInstances = "/v1/instances"
+	pathEvents    = "/v1/events"
+	pathHealth    = "/healthz"
+)
+
+// CreateInstanceRequest describes an instance to create
+// The instance is committed when it is created, so it can be started right away.
+type CreateInstanceRequest struct {
+	Name          string            `json:"name"`
+	Image         string            `json:"image"`
+	Command       []string          `json:"command,omitempty"`
+	Args          []string          `json:"args,omitempty"`
+	PortsTCP      []int             `json:"portsTCP,omitempty"`
+	PortsUDP      []int             `json:"portsUDP,omitempty"`
+	Env           map[string]string `json:"env,omitempty"`
+	MemoryRequest string            `json:"memoryRequest,omitempty"`
+	MemoryLimit   string            `json:"memoryLimit,omitempty"`
+	CPURequest    string            `json:"cpuRequest,omitempty"`
+}
+
+// ConfigureInstanceRequest changes the configuration of a committed instance
+// Empty fields are left unchanged, ports and environment variables are added to the existing ones.
+// The changes are applied when the instance is started.
+type ConfigureInstanceRequest struct {
+	Command       []string          `json:"command,omitempty"`
+	Args          []string          `json:"args,omitempty"`
+	PortsTCP      []int             `json:"portsTCP,omitempty"`
+	PortsUDP      []int             `json:"portsUDP,omitempty"`
+	Env           map[string]string `json:"env,omitempty"`
+	MemoryRequest string            `json:"memoryRequest,omitempty"`
+	MemoryLimit   string            `json:"memoryLimit,omitempty"`
+	CPURequest    string            `json:"cpuRequest,omitempty"`
+}
+
+// InstanceStatus describes an instance managed by the server
+// ID is the unique name of the instance in the cluster, it is used to address the instance.
+type InstanceStatus struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	State    string `json:"state"`
+	Image    string `json:"image"`
+	PortsTCP []int  `json:"portsTCP,omitempty"`
+	PortsUDP []int  `json:"portsUDP,omitempty"`
+}
+
+// ExecRequest is a command to execute in an instance
+type ExecRequest struct {
+	Command []string `json:"command"`
+}
+
+// ExecResponse is the output of a command executed in an instance
+type ExecResponse struct {
+	Output string `json:"output"`
+}
+
+// EventType is the kind of operation an event reports
+type EventType string
+
+const (
+	EventCreated    EventType = "created"
+	EventConfigured EventType = "configured"
+	EventStarted    EventType = "started"
+	EventStopped    EventType = "stopped"
+	EventDestroyed  EventType = "destroyed"
+	EventExecuted   EventType = "executed"
+	EventFailed     EventType = "failed"
+)
+
+// Event reports an operation executed by the server on an instance
+type Event struct {
+	Time     time.Time `json:"time"`
+	Instance string    `json:"instance"`
+	Type     EventType `json:"type"`
+	Message  string    `json:"message,omitempty"`
+}
+
+// errorResponse is the body of the responses of failed requests
+type errorResponse struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
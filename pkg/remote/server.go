@@ -0,0 +1,480 @@
+package remote
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/celestiaorg/knuu/pkg/instance"
+)
+
+// eventBufferSize is the number of events kept for a subscriber that does not keep up
+// Events are dropped for that subscriber once its buffer is full.
+const eventBufferSize = 64
+
+// InstanceFactory creates the instances managed by the server, it is implemented by knuu.Knuu
+type InstanceFactory interface {
+	NewInstance(name string, opts ...instance.Option) (*instance.Instance, error)
+}
+
+// Server executes the instance operations requested by clients
+// It is meant to run in the cluster with the credentials of knuu, and it serves:
+//
+//	GET    /healthz                      report that the server is up
+//	POST   /v1/instances                 create and commit an instance
+//	GET    /v1/instances                 list the instances
+//	GET    /v1/instances/{id}            get an instance
+//	DELETE /v1/instances/{id}            destroy an instance
+//	POST   /v1/instances/{id}/configure  change the configuration of a committed instance
+//	POST   /v1/instances/{id}/start      start an instance and wait until it is running
+//	POST   /v1/instances/{id}/stop       stop an instance
+//	POST   /v1/instances/{id}/exec       execute a command in an instance
+//	GET    /v1/instances/{id}/logs       stream the logs of an instance, ?follow=true keeps the stream open
+//	GET    /v1/events                    stream the events of all the instances as JSON lines
+//
+// All the endpoints except /healthz require the token set with WithRequiredToken, if any.
+type Server struct {
+	factory InstanceFactory
+	mux     *http.ServeMux
+	token   string
+
+	mu        sync.RWMutex
+	instances map[string]*instance.Instance
+
+	subscribersMu sync.Mutex
+	subscribers   map[chan Event]struct{}
+}
+
+var _ http.Handler = &Server{}
+
+// ServerOption configures a Server
+type ServerOption func(*Server)
+
+// WithRequiredToken makes the server reject the requests that do not carry the given bearer token
+// in their Authorization header. Without it, the server accepts all the requests.
+func WithRequiredToken(token string) ServerOption {
+	return func(s *Server) {
+		s.token = token
+	}
+}
+
+// NewServer returns a server that creates its instances with the given factory
+func NewServer(factory InstanceFactory, opts ...ServerOption) *Server {
+	s := &Server{
+		factory:     factory,
+		mux:         http.NewServeMux(),
+		instances:   make(map[string]*instance.Instance),
+		subscribers: make(map[chan Event]struct{}),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	s.mux.HandleFunc("POST "+pathInstances, s.handleCreate)
+	s.mux.HandleFunc("GET "+pathInstances, s.handleList)
+	s.mux.HandleFunc("GET "+pathInstances+"/{id}", s.handleGet)
+	s.mux.HandleFunc("DELETE "+pathInstances+"/{id}", s.handleDestroy)
+	s.mux.HandleFunc("POST "+pathInstances+"/{id}/configure", s.handleConfigure)
+	s.mux.HandleFunc("POST "+pathInstances+"/{id}/start", s.handleStart)
+	s.mux.HandleFunc("POST "+pathInstances+"/{id}/stop", s.handleStop)
+	s.mux.HandleFunc("POST "+pathInstances+"/{id}/exec", s.handleExec)
+	s.mux.HandleFunc("GET "+pathInstances+"/{id}/logs", s.handleLogs)
+	s.mux.HandleFunc("GET "+pathEvents, s.handleEvents)
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == pathHealth {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	if !s.authorized(r) {
+		writeError(w, http.StatusUnauthorized, ErrUnauthorized)
+		return
+	}
+	s.mux.ServeHTTP(w, r)
+}
+
+// authorized returns true if the request carries the token required by the server, if any
+func (s *Server) authorized(r *http.Request) bool {
+	if s.token == "" {
+		return true
+	}
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) == 1
+}
+
+// Destroy destroys all the instances managed by the server
+// It is meant to be called when the server shuts down.
+func (s *Server) Destroy(ctx context.Context) error {
+	s.mu.Lock()
+	instances := make([]*instance.Instance, 0, len(s.instances))
+	for id, inst := range s.instances {
+		// instances that were never started have no resources in the cluster
+		if inst.IsInState(instance.Started, instance.Stopped) {
+			instances = append(instances, inst)
+		}
+		delete(s.instances, id)
+	}
+	s.mu.Unlock()
+
+	return instance.BatchDestroy(ctx, instances...)
+}
+
+func (s *Server) handleCreate(w http.ResponseWriter, r *http.Request) {
+	var req CreateInstanceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, requestError(ErrDecodingRequest, err))
+		return
+	}
+	if req.Image == "" {
+		writeError(w, http.StatusBadRequest, requestError(ErrCreatingInstance, instance.ErrImageRequiredForOptions, req.Name))
+		return
+	}
+
+	inst, err := s.factory.NewInstance(req.Name,
+		instance.WithImage(req.Image),
+		instance.WithCommand(req.Command...),
+		instance.WithArgs(req.Args...),
+		instance.WithPorts(req.PortsTCP...),
+		instance.WithPortsUDP(req.PortsUDP...),
+		instance.WithEnv(req.Env),
+		instance.WithResources(req.MemoryRequest, req.MemoryLimit, req.CPURequest),
+	)
+	if err != nil {
+		s.publish(req.Name, EventFailed, err.Error())
+		writeError(w, http.StatusBadRequest, requestError(ErrCreatingInstance, err, req.Name))
+		return
+	}
+	if err := inst.Commit(); err != nil {
+		s.publish(req.Name, EventFailed, err.Error())
+		writeError(w, http.StatusInternalServerError, requestError(ErrCommittingInstance, err, req.Name))
+		return
+	}
+
+	status := instanceStatus(inst)
+	s.mu.Lock()
+	s.instances[status.ID] = inst
+	s.mu.Unlock()
+
+	s.publish(status.ID, EventCreated, "")
+	writeJSON(w, http.StatusCreated, status)
+}
+
+func (s *Server) handleList(w http.ResponseWriter, _ *http.Request) {
+	s.mu.RLock()
+	statuses := make([]InstanceStatus, 0, len(s.instances))
+	for _, inst := range s.instances {
+		statuses = append(statuses, instanceStatus(inst))
+	}
+	s.mu.RUnlock()
+
+	sort.Slice(statuses, func(a, b int) bool {
+		return statuses[a].ID < statuses[b].ID
+	})
+	writeJSON(w, http.StatusOK, statuses)
+}
+
+func (s *Server) handleGet(w http.ResponseWriter, r *http.Request) {
+	inst, ok := s.lookup(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, instanceStatus(inst))
+}
+
+func (s *Server) handleDestroy(w http.ResponseWriter, r *http.Request) {
+	inst, ok := s.lookup(w, r)
+	if !ok {
+		return
+	}
+	id := r.PathValue("id")
+
+	if err := inst.Destroy(r.Context()); err != nil {
+		s.publish(id, EventFailed, err.Error())
+		writeError(w, http.StatusInternalServerError, requestError(ErrDestroyingInstance, err, id))
+		return
+	}
+
+	s.mu.Lock()
+	delete(s.instances, id)
+	s.mu.Unlock()
+
+	s.publish(id, EventDestroyed, "")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleConfigure(w http.ResponseWriter, r *http.Request) {
+	inst, ok := s.lookup(w, r)
+	if !ok {
+		return
+	}
+	id := r.PathValue("id")
+
+	var req ConfigureInstanceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, requestError(ErrDecodingRequest, err))
+		return
+	}
+
+	if err := configure(inst, req); err != nil {
+		s.publish(id, EventFailed, err.Error())
+		writeError(w, http.StatusBadRequest, requestError(ErrConfiguringInstance, err, id))
+		return
+	}
+
+	s.publish(id, EventConfigured, "")
+	writeJSON(w, http.StatusOK, instanceStatus(inst))
+}
+
+// configure applies all the fields of the request and returns all the failures
+func configure(inst *instance.Instance, req ConfigureInstanceRequest) error {
+	var errs []error
+	if len(req.Command) != 0 {
+		errs = append(errs, inst.SetCommand(req.Command...))
+	}
+	if len(req.Args) != 0 {
+		errs = append(errs, inst.SetArgs(req.Args...))
+	}
+	for _, port := range req.PortsTCP {
+		errs = append(errs, inst.AddPortTCP(port))
+	}
+	for _, port := range req.PortsUDP {
+		errs = append(errs, inst.AddPortUDP(port))
+	}
+	for key, value := range req.Env {
+		errs = append(errs, inst.SetEnvironmentVariable(key, value))
+	}
+	if req.MemoryRequest != "" || req.MemoryLimit != "" {
+		errs = append(errs, inst.SetMemory(req.MemoryRequest, req.MemoryLimit))
+	}
+	if req.CPURequest != "" {
+		errs = append(errs, inst.SetCPU(req.CPURequest))
+	}
+	return errors.Join(errs...)
+}
+
+func (s *Server) handleStart(w http.ResponseWriter, r *http.Request) {
+	inst, ok := s.lookup(w, r)
+	if !ok {
+		return
+	}
+	id := r.PathValue("id")
+
+	if err := inst.Start(r.Context()); err != nil {
+		s.publish(id, EventFailed, err.Error())
+		writeError(w, http.StatusInternalServerError, requestError(ErrStartingInstance, err, id))
+		return
+	}
+
+	s.publish(id, EventStarted, "")
+	writeJSON(w, http.StatusOK, instanceStatus(inst))
+}
+
+func (s *Server) handleStop(w http.ResponseWriter, r *http.Request) {
+	inst, ok := s.lookup(w, r)
+	if !ok {
+		return
+	}
+	id := r.PathValue("id")
+
+	if err := inst.Stop(r.Context()); err != nil {
+		s.publish(id, EventFailed, err.Error())
+		writeError(w, http.StatusInternalServerError, requestError(ErrStoppingInstance, err, id))
+		return
+	}
+
+	s.publish(id, EventStopped, "")
+	writeJSON(w, http.StatusOK, instanceStatus(inst))
+}
+
+func (s *Server) handleExec(w http.ResponseWriter, r *http.Request) {
+	inst, ok := s.lookup(w, r)
+	if !ok {
+		return
+	}
+	id := r.PathValue("id")
+
+	var req ExecRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, requestError(ErrDecodingRequest, err))
+		return
+	}
+
+	output, err := inst.ExecuteCommand(r.Context(), req.Command...)
+	if err != nil {
+		s.publish(id, EventFailed, err.Error())
+		writeError(w, http.StatusInternalServerError, requestError(ErrExecutingCommand, err, id))
+		return
+	}
+
+	s.publish(id, EventExecuted, fmt.Sprintf("%v", req.Command))
+	writeJSON(w, http.StatusOK, ExecResponse{Output: output})
+}
+
+func (s *Server) handleLogs(w http.ResponseWriter, r *http.Request) {
+	inst, ok := s.lookup(w, r)
+	if !ok {
+		return
+	}
+	id := r.PathValue("id")
+	follow, _ := strconv.ParseBool(r.URL.Query().Get("follow"))
+
+	flusher, ok := w.(http.Flusher)
+	if follow && !ok {
+		writeError(w, http.StatusInternalServerError, ErrStreamingNotSupported)
+		return
+	}
+
+	logs, err := inst.Logs(r.Context(), follow)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, requestError(ErrGettingLogs, err, id))
+		return
+	}
+	defer logs.Close()
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	if !follow {
+		if _, err := io.Copy(w, logs); err != nil {
+			logrus.Debugf("Error sending logs of instance '%s': %v", id, err)
+		}
+		return
+	}
+
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := logs.Read(buf)
+		if n > 0 {
+			if _, err := w.Write(buf[:n]); err != nil {
+				logrus.Debugf("Error sending logs of instance '%s': %v", id, err)
+				return
+			}
+			flusher.Flush()
+		}
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				logrus.Debugf("Error reading logs of instance '%s': %v", id, err)
+			}
+			return
+		}
+	}
+}
+
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, ErrStreamingNotSupported)
+		return
+	}
+
+	events := s.subscribe()
+	defer s.unsubscribe(events)
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	enc := json.NewEncoder(w)
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event := <-events:
+			if err := enc.Encode(event); err != nil {
+				logrus.Debugf("Error sending event: %v", err)
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// lookup returns the instance addressed by the request, or writes a not found error
+func (s *Server) lookup(w http.ResponseWriter, r *http.Request) (*instance.Instance, bool) {
+	id := r.PathValue("id")
+
+	s.mu.RLock()
+	inst, ok := s.instances[id]
+	s.mu.RUnlock()
+
+	if !ok {
+		writeError(w, http.StatusNotFound, requestError(ErrInstanceNotFound, nil, id))
+	}
+	return inst, ok
+}
+
+func (s *Server) subscribe() chan Event {
+	events := make(chan Event, eventBufferSize)
+
+	s.subscribersMu.Lock()
+	s.subscribers[events] = struct{}{}
+	s.subscribersMu.Unlock()
+	return events
+}
+
+func (s *Server) unsubscribe(events chan Event) {
+	s.subscribersMu.Lock()
+	delete(s.subscribers, events)
+	s.subscribersMu.Unlock()
+}
+
+// publish sends an event to all the subscribers without blocking
+func (s *Server) publish(instanceID string, eventType EventType, message string) {
+	event := Event{
+		Time:     time.Now(),
+		Instance: instanceID,
+		Type:     eventType,
+		Message:  message,
+	}
+
+	s.subscribersMu.Lock()
+	defer s.subscribersMu.Unlock()
+	for events := range s.subscribers {
+		select {
+		case events <- event:
+		default:
+			logrus.Debugf("Dropping event '%s' of instance '%s' for a slow subscriber", eventType, instanceID)
+		}
+	}
+}
+
+func instanceStatus(inst *instance.Instance) InstanceStatus {
+	spec := inst.Spec()
+	return InstanceStatus{
+		ID:       spec.K8sName,
+		Name:     spec.Name,
+		State:    spec.State.String(),
+		Image:    spec.Image,
+		PortsTCP: spec.PortsTCP,
+		PortsUDP: spec.PortsUDP,
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		logrus.Debugf("Error writing response: %v", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	resp := errorResponse{Code: "Internal", Message: err.Error()}
+	var knuuErr *Error
+	if errors.As(err, &knuuErr) {
+		resp.Code = knuuErr.Code()
+	}
+	writeJSON(w, status, resp)
+}
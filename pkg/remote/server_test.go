@@ -0,0 +1,252 @@
+package remote
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/celestiaorg/knuu/pkg/instance"
+	"github.com/celestiaorg/knuu/pkg/system"
+)
+
+type testFactory struct{}
+
+func (testFactory) NewInstance(name string, opts ...instance.Option) (*instance.Instance, error) {
+	return instance.New(name, system.SystemDependencies{}, opts...)
+}
+
+func newTestServer(t *testing.T) (*Server, *Client) {
+	t.Helper()
+	s := NewServer(testFactory{})
+	ts := httptest.NewServer(s)
+	t.Cleanup(ts.Close)
+	return s, NewClient(ts.URL)
+}
+
+func TestServerInstances(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	_, c := newTestServer(t)
+
+	created, err := c.CreateInstance(ctx, CreateInstanceRequest{
+		Name:     "test",
+		Image:    "alpine:latest",
+		Command:  []string{"sleep", "infinity"},
+		PortsTCP: []int{8080},
+	})
+	require.NoError(t, err)
+	assert.NotEmpty(t, created.ID)
+	assert.Equal(t, "test", created.Name)
+	assert.Equal(t, instance.Committed.String(), created.State)
+	assert.Equal(t, "alpine:latest", created.Image)
+	assert.Equal(t, []int{8080}, created.PortsTCP)
+
+	got, err := c.GetInstance(ctx, created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, created, got)
+
+	list, err := c.ListInstances(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []InstanceStatus{*created}, list)
+
+	// commands can only be executed in started instances
+	_, err = c.ExecuteCommand(ctx, created.ID, "echo", "test")
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ErrExecutingCommand))
+	assert.Contains(t, err.Error(), "executing command is only allowed in state 'Preparing' or 'Started'")
+}
+
+func TestServerErrors(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	_, c := newTestServer(t)
+
+	tests := []struct {
+		name     string
+		call     func() error
+		expected error
+	}{
+		{
+			name: "unknown instance",
+			call: func() error {
+				_, err := c.GetInstance(ctx, "unknown")
+				return err
+			},
+			expected: ErrInstanceNotFound,
+		},
+		{
+			name: "start unknown instance",
+			call: func() error {
+				_, err := c.StartInstance(ctx, "unknown")
+				return err
+			},
+			expected: ErrInstanceNotFound,
+		},
+		{
+			name: "destroy unknown instance",
+			call: func() error {
+				return c.DestroyInstance(ctx, "unknown")
+			},
+			expected: ErrInstanceNotFound,
+		},
+		{
+			name: "missing image",
+			call: func() error {
+				_, err := c.CreateInstance(ctx, CreateInstanceRequest{Name: "test"})
+				return err
+			},
+			expected: ErrCreatingInstance,
+		},
+		{
+			name: "invalid options",
+			call: func() error {
+				_, err := c.CreateInstance(ctx, CreateInstanceRequest{
+					Name:     "test",
+					Image:    "alpine:latest",
+					PortsTCP: []int{0},
+				})
+				return err
+			},
+			expected: ErrCreatingInstance,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.call()
+			require.Error(t, err)
+			assert.True(t, errors.Is(err, tt.expected), "expected %v, got %v", tt.expected, err)
+		})
+	}
+}
+
+func TestServerEvents(t *testing.T) {
+	t.Parallel()
+	s, c := newTestServer(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	events := make(chan Event, 1)
+	watchErr := make(chan error, 1)
+	go func() {
+		watchErr <- c.WatchEvents(ctx, func(e Event) {
+			events <- e
+		})
+	}()
+
+	// wait for the client to be subscribed, as events are only sent to current subscribers
+	require.Eventually(t, func() bool {
+		s.subscribersMu.Lock()
+		defer s.subscribersMu.Unlock()
+		return len(s.subscribers) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+
+	created, err := c.CreateInstance(ctx, CreateInstanceRequest{Name: "test", Image: "alpine:latest"})
+	require.NoError(t, err)
+
+	select {
+	case e := <-events:
+		assert.Equal(t, created.ID, e.Instance)
+		assert.Equal(t, EventCreated, e.Type)
+	case <-ctx.Done():
+		t.Fatal("timeout waiting for the event")
+	}
+
+	cancel()
+	assert.NoError(t, <-watchErr)
+}
+
+func TestServerConfigure(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	_, c := newTestServer(t)
+
+	created, err := c.CreateInstance(ctx, CreateInstanceRequest{Name: "test", Image: "alpine:latest"})
+	require.NoError(t, err)
+
+	configured, err := c.ConfigureInstance(ctx, created.ID, ConfigureInstanceRequest{
+		PortsTCP: []int{8080},
+		PortsUDP: []int{9090},
+		Env:      map[string]string{"KEY": "value"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []int{8080}, configured.PortsTCP)
+	assert.Equal(t, []int{9090}, configured.PortsUDP)
+
+	// all the failures are reported
+	_, err = c.ConfigureInstance(ctx, created.ID, ConfigureInstanceRequest{
+		PortsTCP:      []int{8080},
+		MemoryRequest: "lots",
+	})
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ErrConfiguringInstance))
+	assert.Contains(t, err.Error(), "8080")
+	assert.Contains(t, err.Error(), "lots")
+}
+
+func TestServerToken(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	ts := httptest.NewServer(NewServer(testFactory{}, WithRequiredToken("secret")))
+	t.Cleanup(ts.Close)
+
+	tests := []struct {
+		name     string
+		opts     []ClientOption
+		expected error
+	}{
+		{
+			name:     "no token",
+			expected: ErrUnauthorized,
+		},
+		{
+			name:     "wrong token",
+			opts:     []ClientOption{WithToken("wrong")},
+			expected: ErrUnauthorized,
+		},
+		{
+			name: "valid token",
+			opts: []ClientOption{WithToken("secret")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewClient(ts.URL, tt.opts...).ListInstances(ctx)
+			if tt.expected == nil {
+				assert.NoError(t, err)
+				return
+			}
+			assert.True(t, errors.Is(err, tt.expected), "expected %v, got %v", tt.expected, err)
+		})
+	}
+
+	// the health check does not require the token
+	resp, err := http.Get(ts.URL + pathHealth)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestServerDestroy(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	s, c := newTestServer(t)
+
+	_, err := c.CreateInstance(ctx, CreateInstanceRequest{Name: "test", Image: "alpine:latest"})
+	require.NoError(t, err)
+
+	// committed instances have nothing to destroy
+	require.NoError(t, s.Destroy(ctx))
+
+	list, err := c.ListInstances(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, list)
+}
@@ -0,0 +1,13 @@
+package report
+
+import (
+	"github.com/celestiaorg/knuu/pkg/errors"
+)
+
+type Error = errors.Error
+
+var (
+	ErrWritingReport     = errors.New("WritingReport", "error writing report")
+	ErrCreatingReportDir = errors.New("CreatingReportDir", "error creating report directory '%s'")
+	ErrWritingReportFile = errors.New("WritingReportFile", "error writing report file '%s'")
+)
@@ -0,0 +1,317 @@
+package report
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// Phase is a phase of the setup of an instance
+type Phase string
+
+const (
+	// PhaseBuild is the build of the image of the instance, the builders push the image
+	// to the registry as the last step of the build, so it includes the push
+	PhaseBuild Phase = "build"
+	// PhaseSchedule lasts from the deployment of the resources of the instance until its pod is scheduled on a node
+	PhaseSchedule Phase = "schedule"
+	// PhaseReady lasts from the scheduling of the pod until the instance is running
+	PhaseReady Phase = "ready"
+)
+
+// PhaseTiming is the duration of a setup phase of an instance
+type PhaseTiming struct {
+	Instance string        `json:"instance"`
+	Phase    Phase         `json:"phase"`
+	Start    time.Time     `json:"start"`
+	Duration time.Duration `json:"duration"`
+	// Error is the message of the error the phase failed with, empty if it succeeded
+	Error string `json:"error,omitempty"`
+}
+
+// Failed returns true if the phase failed
+func (p PhaseTiming) Failed() bool {
+	return p.Error != ""
+}
+
+// End returns the time the phase ended
+func (p PhaseTiming) End() time.Time {
+	return p.Start.Add(p.Duration)
+}
+
+// RecordPhase adds a setup phase of an instance that lasted from start to end
+// If err is not nil, the phase is recorded as failed.
+func (r *Recorder) RecordPhase(instance string, phase Phase, start, end time.Time, err error) {
+	if r == nil {
+		return
+	}
+	p := PhaseTiming{
+		Instance: instance,
+		Phase:    phase,
+		Start:    start,
+		Duration: end.Sub(start),
+	}
+	if err != nil {
+		p.Error = err.Error()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.phases = append(r.phases, p)
+}
+
+type junitTestSuites struct {
+	XMLName xml.Name         `xml:"testsuites"`
+	Name    string           `xml:"name,attr"`
+	Tests   int              `xml:"tests,attr"`
+	Fails   int              `xml:"failures,attr"`
+	Time    string           `xml:"time,attr"`
+	Suites  []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Fails     int             `xml:"failures,attr"`
+	Time      string          `xml:"time,attr"`
+	Timestamp string          `xml:"timestamp,attr"`
+	Cases     []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// WriteJUnit writes the setup phases as a JUnit XML report
+// There is a test suite per instance and a test case per phase, so CI dashboards track the duration of each phase.
+func (rep Report) WriteJUnit(w io.Writer) error {
+	suites := junitTestSuites{Name: rep.Scope}
+	index := make(map[string]int)
+	var durations []time.Duration
+	var total time.Duration
+	for _, p := range rep.Phases {
+		i, ok := index[p.Instance]
+		if !ok {
+			i = len(suites.Suites)
+			index[p.Instance] = i
+			suites.Suites = append(suites.Suites, junitTestSuite{
+				Name:      p.Instance,
+				Timestamp: p.Start.UTC().Format("2006-01-02T15:04:05"),
+			})
+			durations = append(durations, 0)
+		}
+		tc := junitTestCase{
+			Name:      string(p.Phase),
+			ClassName: p.Instance,
+			Time:      seconds(p.Duration),
+		}
+		suite := &suites.Suites[i]
+		if p.Failed() {
+			tc.Failure = &junitFailure{Message: p.Error, Text: p.Error}
+			suite.Fails++
+			suites.Fails++
+		}
+		suite.Cases = append(suite.Cases, tc)
+		suite.Tests++
+		suites.Tests++
+		durations[i] += p.Duration
+		total += p.Duration
+	}
+	for i, d := range durations {
+		suites.Suites[i].Time = seconds(d)
+	}
+	suites.Time = seconds(total)
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return ErrWritingReport.Wrap(err)
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(suites); err != nil {
+		return ErrWritingReport.Wrap(err)
+	}
+	if _, err := io.WriteString(w, "\n"); err != nil {
+		return ErrWritingReport.Wrap(err)
+	}
+	return nil
+}
+
+func seconds(d time.Duration) string {
+	return strconv.FormatFloat(d.Seconds(), 'f', 3, 64)
+}
+
+// The types below follow the JSON encoding of the OTLP trace protocol,
+// so the file can be sent as is to the /v1/traces endpoint of a collector.
+type otlpTraces struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpAttribute `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpSpan struct {
+	TraceID           string          `json:"traceId"`
+	SpanID            string          `json:"spanId"`
+	ParentSpanID      string          `json:"parentSpanId,omitempty"`
+	Name              string          `json:"name"`
+	Kind              int             `json:"kind"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+	Attributes        []otlpAttribute `json:"attributes,omitempty"`
+	Status            otlpStatus      `json:"status"`
+}
+
+type otlpAttribute struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+type otlpValue struct {
+	StringValue string `json:"stringValue"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+const (
+	otlpSpanKindInternal = 1
+	otlpStatusOk         = 1
+	otlpStatusError      = 2
+	otlpServiceName      = "knuu"
+)
+
+// WriteOTLP writes the setup phases as OTLP/JSON traces
+// The test is a trace, each instance is a span whose children are its phases.
+// The IDs are derived from the scope and the instance names, so they are stable for a given report.
+func (rep Report) WriteOTLP(w io.Writer) error {
+	traceID := otlpID(16, rep.Scope, rep.StartTime.String())
+
+	// the span of an instance covers all its phases
+	type instanceSpan struct {
+		span       otlpSpan
+		start, end time.Time
+	}
+	var instances []*instanceSpan
+	byName := make(map[string]*instanceSpan)
+	var phases []otlpSpan
+	for _, p := range rep.Phases {
+		parent, ok := byName[p.Instance]
+		if !ok {
+			parent = &instanceSpan{
+				span: otlpSpan{
+					TraceID:    traceID,
+					SpanID:     otlpID(8, rep.Scope, p.Instance),
+					Name:       "setup " + p.Instance,
+					Kind:       otlpSpanKindInternal,
+					Attributes: []otlpAttribute{attribute("knuu.instance", p.Instance)},
+					Status:     otlpStatus{Code: otlpStatusOk},
+				},
+				start: p.Start,
+				end:   p.End(),
+			}
+			byName[p.Instance] = parent
+			instances = append(instances, parent)
+		}
+		if p.Start.Before(parent.start) {
+			parent.start = p.Start
+		}
+		if p.End().After(parent.end) {
+			parent.end = p.End()
+		}
+
+		status := otlpStatus{Code: otlpStatusOk}
+		if p.Failed() {
+			status = otlpStatus{Code: otlpStatusError, Message: p.Error}
+			parent.span.Status = otlpStatus{Code: otlpStatusError, Message: fmt.Sprintf("phase %s failed", p.Phase)}
+		}
+		phases = append(phases, otlpSpan{
+			TraceID:           traceID,
+			SpanID:            otlpID(8, rep.Scope, p.Instance, string(p.Phase), p.Start.String()),
+			ParentSpanID:      parent.span.SpanID,
+			Name:              string(p.Phase),
+			Kind:              otlpSpanKindInternal,
+			StartTimeUnixNano: unixNano(p.Start),
+			EndTimeUnixNano:   unixNano(p.End()),
+			Attributes: []otlpAttribute{
+				attribute("knuu.instance", p.Instance),
+				attribute("knuu.phase", string(p.Phase)),
+			},
+			Status: status,
+		})
+	}
+
+	spans := make([]otlpSpan, 0, len(instances)+len(phases))
+	for _, inst := range instances {
+		inst.span.StartTimeUnixNano = unixNano(inst.start)
+		inst.span.EndTimeUnixNano = unixNano(inst.end)
+		spans = append(spans, inst.span)
+	}
+	spans = append(spans, phases...)
+
+	traces := otlpTraces{ResourceSpans: []otlpResourceSpans{{
+		Resource: otlpResource{Attributes: []otlpAttribute{
+			attribute("service.name", otlpServiceName),
+			attribute("knuu.scope", rep.Scope),
+		}},
+		ScopeSpans: []otlpScopeSpans{{
+			Scope: otlpScope{Name: otlpServiceName},
+			Spans: spans,
+		}},
+	}}}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(traces); err != nil {
+		return ErrWritingReport.Wrap(err)
+	}
+	return nil
+}
+
+func attribute(key, value string) otlpAttribute {
+	return otlpAttribute{Key: key, Value: otlpValue{StringValue: value}}
+}
+
+// otlpID returns a hex encoded ID of the given size in bytes derived from the given values
+func otlpID(size int, values ...string) string {
+	h := sha256.New()
+	for _, v := range values {
+		h.Write([]byte(v))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil)[:size])
+}
+
+// unixNano returns the time in nanoseconds as a string, as OTLP/JSON encodes 64-bit integers as strings
+func unixNano(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
@@ -0,0 +1,97 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func phasesReport() Report {
+	r := NewRecorder("scope")
+	start := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	r.RecordPhase("a", PhaseBuild, start, start.Add(30*time.Second), nil)
+	r.RecordPhase("a", PhaseSchedule, start.Add(30*time.Second), start.Add(32*time.Second), nil)
+	r.RecordPhase("a", PhaseReady, start.Add(32*time.Second), start.Add(40*time.Second), nil)
+	r.RecordPhase("b", PhaseSchedule, start.Add(time.Second), start.Add(time.Minute), errors.New("unschedulable"))
+	return r.Report()
+}
+
+func TestReportWriteJUnit(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	require.NoError(t, phasesReport().WriteJUnit(&buf))
+
+	var suites junitTestSuites
+	require.NoError(t, xml.Unmarshal(buf.Bytes(), &suites))
+	assert.Equal(t, "scope", suites.Name)
+	assert.Equal(t, 4, suites.Tests)
+	assert.Equal(t, 1, suites.Fails)
+	require.Len(t, suites.Suites, 2)
+
+	a := suites.Suites[0]
+	assert.Equal(t, "a", a.Name)
+	assert.Equal(t, "40.000", a.Time)
+	require.Len(t, a.Cases, 3)
+	assert.Equal(t, "build", a.Cases[0].Name)
+	assert.Equal(t, "30.000", a.Cases[0].Time)
+	assert.Nil(t, a.Cases[0].Failure)
+
+	b := suites.Suites[1]
+	require.Len(t, b.Cases, 1)
+	require.NotNil(t, b.Cases[0].Failure)
+	assert.Equal(t, "unschedulable", b.Cases[0].Failure.Message)
+}
+
+func TestReportWriteOTLP(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	require.NoError(t, phasesReport().WriteOTLP(&buf))
+
+	var traces otlpTraces
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &traces))
+	require.Len(t, traces.ResourceSpans, 1)
+	require.Len(t, traces.ResourceSpans[0].ScopeSpans, 1)
+	spans := traces.ResourceSpans[0].ScopeSpans[0].Spans
+	// a span per instance followed by a span per phase
+	require.Len(t, spans, 6)
+
+	a := spans[0]
+	assert.Equal(t, "setup a", a.Name)
+	assert.Len(t, a.TraceID, 32)
+	assert.Len(t, a.SpanID, 16)
+	assert.Equal(t, otlpStatusOk, a.Status.Code)
+	start := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	assert.Equal(t, unixNano(start), a.StartTimeUnixNano)
+	assert.Equal(t, unixNano(start.Add(40*time.Second)), a.EndTimeUnixNano)
+
+	b := spans[1]
+	assert.Equal(t, otlpStatusError, b.Status.Code)
+
+	for _, phase := range spans[2:] {
+		assert.Equal(t, a.TraceID, phase.TraceID)
+		assert.NotEmpty(t, phase.ParentSpanID)
+	}
+	assert.Equal(t, "build", spans[2].Name)
+	assert.Equal(t, a.SpanID, spans[2].ParentSpanID)
+}
+
+func TestReportWritePhasesEmpty(t *testing.T) {
+	t.Parallel()
+
+	rep := NewRecorder("scope").Report()
+	var buf bytes.Buffer
+	require.NoError(t, rep.WriteJUnit(&buf))
+	assert.Contains(t, buf.String(), `tests="0"`)
+
+	buf.Reset()
+	require.NoError(t, rep.WriteOTLP(&buf))
+	assert.Contains(t, buf.String(), `"spans": []`)
+}
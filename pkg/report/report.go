@@ -0,0 +1,281 @@
+// Package report records the timeline of the operations knuu executes on the instances of a test,
+// and writes it as a JSON or HTML report that can be attached to CI runs.
+package report
+
+import (
+	"encoding/json"
+	"html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// File names of the reports written by Save
+const (
+	JSONFileName  = "report.json"
+	HTMLFileName  = "report.html"
+	JUnitFileName = "setup-phases.junit.xml"
+	OTLPFileName  = "setup-phases.otlp.json"
+)
+
+// Operation is the kind of an operation of the timeline
+type Operation string
+
+const (
+	OperationBuild     Operation = "build"
+	OperationStart     Operation = "start"
+	OperationStop      Operation = "stop"
+	OperationDestroy   Operation = "destroy"
+	OperationFault     Operation = "fault"
+	OperationStep      Operation = "step"
+	OperationBenchmark Operation = "benchmark"
+)
+
+// ArtifactKind is the kind of a file collected during a test
+type ArtifactKind string
+
+const (
+	ArtifactLogs    ArtifactKind = "logs"
+	ArtifactMetrics ArtifactKind = "metrics"
+	ArtifactOther   ArtifactKind = "other"
+)
+
+// Entry is an operation of the timeline
+type Entry struct {
+	Time      time.Time     `json:"time"`
+	Instance  string        `json:"instance,omitempty"`
+	Operation Operation     `json:"operation"`
+	Duration  time.Duration `json:"duration"`
+	Message   string        `json:"message,omitempty"`
+	// Error is the message of the error the operation failed with, empty if it succeeded
+	Error string `json:"error,omitempty"`
+}
+
+// Failed returns true if the operation failed
+func (e Entry) Failed() bool {
+	return e.Error != ""
+}
+
+// Artifact is a file collected during a test, such as the logs of an instance
+// Path is relative to the directory of the report when the report is saved in the same directory.
+type Artifact struct {
+	Name     string       `json:"name"`
+	Kind     ArtifactKind `json:"kind"`
+	Instance string       `json:"instance,omitempty"`
+	Path     string       `json:"path"`
+}
+
+// Report is a snapshot of the timeline and the artifacts of a test
+type Report struct {
+	Scope     string        `json:"scope"`
+	StartTime time.Time     `json:"startTime"`
+	EndTime   time.Time     `json:"endTime"`
+	Duration  time.Duration `json:"duration"`
+	Failures  int           `json:"failures"`
+	Entries   []Entry       `json:"entries"`
+	Phases    []PhaseTiming `json:"phases,omitempty"`
+	Artifacts []Artifact    `json:"artifacts,omitempty"`
+
+	Resources []ResourceUsage `json:"resources,omitempty"`
+}
+
+// Recorder records the operations of a test
+// It is safe for concurrent use. A nil *Recorder records nothing.
+type Recorder struct {
+	mu        sync.Mutex
+	scope     string
+	startTime time.Time
+	entries   []Entry
+	phases    []PhaseTiming
+	artifacts []Artifact
+	resources []ResourceUsage
+}
+
+// NewRecorder returns a recorder for the test with the given scope
+func NewRecorder(scope string) *Recorder {
+	return &Recorder{
+		scope:     scope,
+		startTime: time.Now(),
+	}
+}
+
+// Record adds an operation that started at the given time and ended now
+// If err is not nil, the operation is recorded as failed.
+func (r *Recorder) Record(instance string, op Operation, start time.Time, message string, err error) {
+	if r == nil {
+		return
+	}
+	e := Entry{
+		Time:      start,
+		Instance:  instance,
+		Operation: op,
+		Duration:  time.Since(start),
+		Message:   message,
+	}
+	// the message is captured now, as knuu errors can be modified when they are returned again
+	if err != nil {
+		e.Error = err.Error()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries = append(r.entries, e)
+}
+
+// AddArtifact adds a file collected during the test to the report
+func (r *Recorder) AddArtifact(a Artifact) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.artifacts = append(r.artifacts, a)
+}
+
+// Report returns a snapshot of the operations recorded so far, ordered by start time
+func (r *Recorder) Report() Report {
+	if r == nil {
+		return Report{}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rep := Report{
+		Scope:     r.scope,
+		StartTime: r.startTime,
+		EndTime:   time.Now(),
+		Entries:   append([]Entry(nil), r.entries...),
+		Phases:    append([]PhaseTiming(nil), r.phases...),
+		Artifacts: append([]Artifact(nil), r.artifacts...),
+		Resources: append([]ResourceUsage(nil), r.resources...),
+	}
+	rep.Duration = rep.EndTime.Sub(rep.StartTime)
+	sort.SliceStable(rep.Entries, func(a, b int) bool {
+		return rep.Entries[a].Time.Before(rep.Entries[b].Time)
+	})
+	sort.SliceStable(rep.Phases, func(a, b int) bool {
+		return rep.Phases[a].Start.Before(rep.Phases[b].Start)
+	})
+	sortResources(rep.Resources)
+	for _, e := range rep.Entries {
+		if e.Failed() {
+			rep.Failures++
+		}
+	}
+	return rep
+}
+
+// WriteJSON writes the report as JSON
+func (rep Report) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(rep); err != nil {
+		return ErrWritingReport.Wrap(err)
+	}
+	return nil
+}
+
+// WriteHTML writes the report as a standalone HTML page
+func (rep Report) WriteHTML(w io.Writer) error {
+	if err := htmlTemplate.Execute(w, rep); err != nil {
+		return ErrWritingReport.Wrap(err)
+	}
+	return nil
+}
+
+// Save writes the JSON, HTML, JUnit and OTLP reports in the given directory, creating it if needed
+// Artifacts saved in the same directory are linked relatively, so the directory can be archived as is.
+func (r *Recorder) Save(dir string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return ErrCreatingReportDir.WithParams(dir).Wrap(err)
+	}
+
+	rep := r.Report()
+	for i, a := range rep.Artifacts {
+		if rel, err := filepath.Rel(dir, a.Path); err == nil && filepath.IsLocal(rel) {
+			rep.Artifacts[i].Path = rel
+		}
+	}
+
+	for name, write := range map[string]func(io.Writer) error{
+		JSONFileName:  rep.WriteJSON,
+		HTMLFileName:  rep.WriteHTML,
+		JUnitFileName: rep.WriteJUnit,
+		OTLPFileName:  rep.WriteOTLP,
+	} {
+		if err := writeFile(filepath.Join(dir, name), write); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeFile(path string, write func(io.Writer) error) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return ErrWritingReportFile.WithParams(path).Wrap(err)
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		return ErrWritingReportFile.WithParams(path).Wrap(err)
+	}
+	if err := f.Close(); err != nil {
+		return ErrWritingReportFile.WithParams(path).Wrap(err)
+	}
+	return nil
+}
+
+var htmlTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>knuu report {{.Scope}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; width: 100%; }
+th, td { border: 1px solid #ddd; padding: 4px 8px; text-align: left; vertical-align: top; }
+tr.failed { background: #fdd; }
+</style>
+</head>
+<body>
+<h1>knuu report {{.Scope}}</h1>
+<p>From {{.StartTime.Format "2006-01-02 15:04:05 MST"}} to {{.EndTime.Format "2006-01-02 15:04:05 MST"}} ({{.Duration}}), {{.Failures}} failed operations.</p>
+<h2>Timeline</h2>
+<table>
+<tr><th>Time</th><th>Instance</th><th>Operation</th><th>Duration</th><th>Details</th></tr>
+{{- range .Entries}}
+<tr{{if .Failed}} class="failed"{{end}}><td>{{.Time.Format "15:04:05.000"}}</td><td>{{.Instance}}</td><td>{{.Operation}}</td><td>{{.Duration}}</td><td>{{.Message}}{{if .Failed}}<br><b>Error:</b> {{.Error}}{{end}}</td></tr>
+{{- end}}
+</table>
+{{- if .Phases}}
+<h2>Setup phases</h2>
+<table>
+<tr><th>Start</th><th>Instance</th><th>Phase</th><th>Duration</th><th>Error</th></tr>
+{{- range .Phases}}
+<tr{{if .Failed}} class="failed"{{end}}><td>{{.Start.Format "15:04:05.000"}}</td><td>{{.Instance}}</td><td>{{.Phase}}</td><td>{{.Duration}}</td><td>{{.Error}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+{{- if .Resources}}
+<h2>Resources</h2>
+<table>
+<tr><th>Instance</th><th>Image</th><th>Image size (bytes)</th><th>CPU requested/used (millicores)</th><th>Memory requested/used (bytes)</th><th>Runtime (minutes)</th></tr>
+{{- range .Resources}}
+<tr><td>{{.Instance}}</td><td>{{.Image}}</td><td>{{.ImageSizeBytes}}</td><td>{{.CPURequestMillis}} / {{if .UsageKnown}}{{.CPUUsedMillis}}{{else}}unknown{{end}}</td><td>{{.MemoryRequestBytes}} / {{if .UsageKnown}}{{.MemoryUsedBytes}}{{else}}unknown{{end}}</td><td>{{printf "%.1f" .RuntimeMinutes}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+{{- if .Artifacts}}
+<h2>Artifacts</h2>
+<ul>
+{{- range .Artifacts}}
+<li><a href="{{.Path}}">{{.Name}}</a> ({{.Kind}}{{if .Instance}}, {{.Instance}}{{end}})</li>
+{{- end}}
+</ul>
+{{- end}}
+</body>
+</html>
+`))
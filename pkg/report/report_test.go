@@ -0,0 +1,80 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecorderReport(t *testing.T) {
+	t.Parallel()
+
+	r := NewRecorder("scope")
+	now := time.Now()
+	r.Record("b", OperationStart, now, "", nil)
+	r.Record("a", OperationBuild, now.Add(-time.Second), "image", nil)
+	r.Record("b", OperationFault, now.Add(time.Second), "latency", errors.New("boom"))
+
+	rep := r.Report()
+	assert.Equal(t, "scope", rep.Scope)
+	assert.Equal(t, 1, rep.Failures)
+	require.Len(t, rep.Entries, 3)
+	// the entries are ordered by start time
+	assert.Equal(t, OperationBuild, rep.Entries[0].Operation)
+	assert.Equal(t, OperationStart, rep.Entries[1].Operation)
+	assert.Equal(t, OperationFault, rep.Entries[2].Operation)
+	assert.Equal(t, "boom", rep.Entries[2].Error)
+	assert.True(t, rep.Entries[2].Failed())
+}
+
+func TestNilRecorder(t *testing.T) {
+	t.Parallel()
+
+	var r *Recorder
+	r.Record("a", OperationStart, time.Now(), "", nil)
+	r.AddArtifact(Artifact{Name: "a.log"})
+	assert.Empty(t, r.Report().Entries)
+}
+
+func TestRecorderSave(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	r := NewRecorder("scope")
+	r.Record("a", OperationStart, time.Now(), "<script>", errors.New("failed"))
+	r.AddArtifact(Artifact{Name: "a.log", Kind: ArtifactLogs, Instance: "a", Path: filepath.Join(dir, "logs", "a.log")})
+	r.AddArtifact(Artifact{Name: "metrics", Kind: ArtifactMetrics, Path: "/elsewhere/metrics.json"})
+
+	require.NoError(t, r.Save(dir))
+
+	data, err := os.ReadFile(filepath.Join(dir, JSONFileName))
+	require.NoError(t, err)
+	var rep Report
+	require.NoError(t, json.Unmarshal(data, &rep))
+	assert.Equal(t, 1, rep.Failures)
+	require.Len(t, rep.Artifacts, 2)
+	// artifacts in the directory of the report are linked relatively
+	assert.Equal(t, filepath.Join("logs", "a.log"), rep.Artifacts[0].Path)
+	assert.Equal(t, "/elsewhere/metrics.json", rep.Artifacts[1].Path)
+
+	html, err := os.ReadFile(filepath.Join(dir, HTMLFileName))
+	require.NoError(t, err)
+	assert.Contains(t, string(html), `<a href="logs/a.log">a.log</a>`)
+	assert.Contains(t, string(html), `class="failed"`)
+	assert.NotContains(t, string(html), "<script>")
+}
+
+func TestReportWriteHTMLEmpty(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	require.NoError(t, NewRecorder("scope").Report().WriteHTML(&buf))
+	assert.NotContains(t, buf.String(), "Artifacts")
+}
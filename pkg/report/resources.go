@@ -0,0 +1,74 @@
+package report
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// ResourceUsage is the resource accounting of an instance, recorded when the test is torn down
+// The used CPU and memory are the ones reported by the metrics server at that time, they are unknown
+// if the cluster has no metrics server.
+type ResourceUsage struct {
+	Instance           string        `json:"instance"`
+	Image              string        `json:"image"`
+	ImageSizeBytes     int64         `json:"imageSizeBytes,omitempty"`
+	CPURequestMillis   int64         `json:"cpuRequestMillis"`
+	CPUUsedMillis      int64         `json:"cpuUsedMillis"`
+	MemoryRequestBytes int64         `json:"memoryRequestBytes"`
+	MemoryUsedBytes    int64         `json:"memoryUsedBytes"`
+	UsageKnown         bool          `json:"usageKnown"`
+	Runtime            time.Duration `json:"runtime"`
+}
+
+// RuntimeMinutes returns the time the pod of the instance ran, in minutes
+func (u ResourceUsage) RuntimeMinutes() float64 {
+	return u.Runtime.Minutes()
+}
+
+// Summary returns a one line summary of the usage, e.g. for the logs
+func (u ResourceUsage) Summary() string {
+	used := "unknown usage"
+	if u.UsageKnown {
+		used = fmt.Sprintf("used %dm CPU and %s memory", u.CPUUsedMillis, formatBytes(u.MemoryUsedBytes))
+	}
+	return fmt.Sprintf("%s: requested %dm CPU and %s memory, %s, ran %.1f minutes, image %s (%s)",
+		u.Instance, u.CPURequestMillis, formatBytes(u.MemoryRequestBytes), used, u.RuntimeMinutes(), u.Image, formatBytes(u.ImageSizeBytes))
+}
+
+// RecordResourceUsage adds the resource accounting of an instance to the report
+// It replaces the usage recorded before for the same instance.
+func (r *Recorder) RecordResourceUsage(u ResourceUsage) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for j, recorded := range r.resources {
+		if recorded.Instance == u.Instance {
+			r.resources[j] = u
+			return
+		}
+	}
+	r.resources = append(r.resources, u)
+}
+
+// sortResources orders the usages by instance name
+func sortResources(resources []ResourceUsage) {
+	sort.Slice(resources, func(a, b int) bool {
+		return resources[a].Instance < resources[b].Instance
+	})
+}
+
+func formatBytes(b int64) string {
+	const unit = 1024
+	if b < unit {
+		return fmt.Sprintf("%dB", b)
+	}
+	div, exp := int64(unit), 0
+	for n := b / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f%ciB", float64(b)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,42 @@
+package report
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordResourceUsage(t *testing.T) {
+	t.Parallel()
+
+	r := NewRecorder("scope")
+	r.RecordResourceUsage(ResourceUsage{Instance: "validator", CPURequestMillis: 500})
+	r.RecordResourceUsage(ResourceUsage{Instance: "bridge", Runtime: 90 * time.Second})
+	r.RecordResourceUsage(ResourceUsage{
+		Instance:           "validator",
+		Image:              "celestia-app:v1",
+		ImageSizeBytes:     150 << 20,
+		CPURequestMillis:   2000,
+		CPUUsedMillis:      120,
+		MemoryRequestBytes: 4 << 30,
+		MemoryUsedBytes:    512 << 20,
+		UsageKnown:         true,
+		Runtime:            30 * time.Minute,
+	})
+
+	rep := r.Report()
+	require.Len(t, rep.Resources, 2)
+	assert.Equal(t, "bridge", rep.Resources[0].Instance)
+	assert.Equal(t, 1.5, rep.Resources[0].RuntimeMinutes())
+	assert.Equal(t, int64(2000), rep.Resources[1].CPURequestMillis, "the last usage of an instance is kept")
+	assert.Equal(t, "validator: requested 2000m CPU and 4.0GiB memory, used 120m CPU and 512.0MiB memory, ran 30.0 minutes, image celestia-app:v1 (150.0MiB)", rep.Resources[1].Summary())
+	assert.Contains(t, rep.Resources[0].Summary(), "unknown usage")
+
+	var buf bytes.Buffer
+	require.NoError(t, rep.WriteHTML(&buf))
+	assert.Contains(t, buf.String(), "<td>2000 / 120</td>")
+	assert.Contains(t, buf.String(), "<td>0 / unknown</td>")
+}
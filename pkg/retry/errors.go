@@ -0,0 +1,12 @@
+package retry
+
+import (
+	"github.com/celestiaorg/knuu/pkg/errors"
+)
+
+type Error = errors.Error
+
+var (
+	ErrMaxAttemptsReached = errors.New("MaxAttemptsReached", "giving up after %d attempts")
+	ErrContextDone        = errors.New("ContextDone", "context done after %d attempts")
+)
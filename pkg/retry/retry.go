@@ -0,0 +1,151 @@
+// Package retry provides context-aware helpers to retry operations and to wait
+// for conditions, using configurable backoff policies.
+// The waits use the clock of the context, see clock.WithClock.
+package retry
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/celestiaorg/knuu/pkg/clock"
+)
+
+// Backoff returns the duration to wait after the given attempt (starting at 1) before the next one
+type Backoff interface {
+	Next(attempt int) time.Duration
+}
+
+// ConstantBackoff waits the same interval between all attempts
+type ConstantBackoff struct {
+	Interval time.Duration
+}
+
+func (b ConstantBackoff) Next(int) time.Duration {
+	return b.Interval
+}
+
+// ExponentialBackoff multiplies the interval by Multiplier after each attempt,
+// starting at Initial and never exceeding Max (if set).
+type ExponentialBackoff struct {
+	Initial    time.Duration
+	Max        time.Duration
+	Multiplier float64
+}
+
+func (b ExponentialBackoff) Next(attempt int) time.Duration {
+	multiplier := b.Multiplier
+	if multiplier < 1 {
+		multiplier = 1
+	}
+	next := float64(b.Initial) * math.Pow(multiplier, float64(attempt-1))
+	if b.Max > 0 && next > float64(b.Max) {
+		return b.Max
+	}
+	return time.Duration(next)
+}
+
+// Policy defines how an operation is retried
+type Policy struct {
+	// Backoff defines the wait time between the attempts
+	Backoff Backoff
+	// MaxAttempts is the maximum number of attempts, 0 means no limit
+	MaxAttempts int
+	// Timeout is the maximum total time of all attempts, 0 means only the context is respected
+	Timeout time.Duration
+}
+
+// Constant returns a policy that waits the given interval between the attempts
+func Constant(interval time.Duration) Policy {
+	return Policy{Backoff: ConstantBackoff{Interval: interval}}
+}
+
+// Exponential returns a policy that doubles the wait time after each attempt,
+// starting at initial and never exceeding max.
+func Exponential(initial, max time.Duration) Policy {
+	return Policy{Backoff: ExponentialBackoff{Initial: initial, Max: max, Multiplier: 2}}
+}
+
+// WithMaxAttempts returns a copy of the policy with the given maximum number of attempts
+func (p Policy) WithMaxAttempts(attempts int) Policy {
+	p.MaxAttempts = attempts
+	return p
+}
+
+// WithTimeout returns a copy of the policy with the given total timeout
+func (p Policy) WithTimeout(timeout time.Duration) Policy {
+	p.Timeout = timeout
+	return p
+}
+
+// Do calls fn until it succeeds, the maximum number of attempts is reached or the context is done.
+// The error of the last attempt is wrapped in the returned error.
+func Do(ctx context.Context, p Policy, fn func(ctx context.Context) error) error {
+	var lastErr error
+	err := run(ctx, p, func(ctx context.Context) (bool, error) {
+		lastErr = fn(ctx)
+		return lastErr == nil, nil
+	})
+	if err == nil {
+		return nil
+	}
+	if lastErr != nil {
+		return err.Wrap(lastErr)
+	}
+	return err
+}
+
+// Until calls cond until it reports done, it returns an error, the maximum number
+// of attempts is reached or the context is done.
+// An error returned by cond stops the retries and is returned as is.
+func Until(ctx context.Context, p Policy, cond func(ctx context.Context) (done bool, err error)) error {
+	var condErr error
+	err := run(ctx, p, func(ctx context.Context) (bool, error) {
+		done, err := cond(ctx)
+		if err != nil {
+			condErr = err
+			return true, err
+		}
+		return done, nil
+	})
+	if condErr != nil {
+		return condErr
+	}
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func run(ctx context.Context, p Policy, fn func(ctx context.Context) (bool, error)) *Error {
+	if p.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = clock.WithTimeout(ctx, p.Timeout)
+		defer cancel()
+	}
+	backoff := p.Backoff
+	if backoff == nil {
+		backoff = ConstantBackoff{}
+	}
+
+	for attempt := 1; ; attempt++ {
+		if ctx.Err() != nil {
+			return ErrContextDone.WithParams(attempt - 1).Wrap(ctx.Err())
+		}
+		done, err := fn(ctx)
+		if done || err != nil {
+			return nil
+		}
+		if p.MaxAttempts > 0 && attempt >= p.MaxAttempts {
+			return ErrMaxAttemptsReached.WithParams(attempt)
+		}
+
+		timer := clock.FromContext(ctx).NewTimer(backoff.Next(attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ErrContextDone.WithParams(attempt).Wrap(ctx.Err())
+		case <-timer.C():
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/celestiaorg/knuu/pkg/clock"
+)
+
+func TestExponentialBackoff(t *testing.T) {
+	t.Parallel()
+	b := ExponentialBackoff{Initial: 10 * time.Millisecond, Max: 50 * time.Millisecond, Multiplier: 2}
+
+	assert.Equal(t, 10*time.Millisecond, b.Next(1))
+	assert.Equal(t, 20*time.Millisecond, b.Next(2))
+	assert.Equal(t, 40*time.Millisecond, b.Next(3))
+	assert.Equal(t, 50*time.Millisecond, b.Next(4))
+}
+
+func TestDo(t *testing.T) {
+	t.Parallel()
+
+	t.Run("SucceedsAfterRetries", func(t *testing.T) {
+		attempts := 0
+		err := Do(context.Background(), Constant(time.Millisecond).WithMaxAttempts(5), func(ctx context.Context) error {
+			attempts++
+			if attempts < 3 {
+				return errors.New("not yet")
+			}
+			return nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 3, attempts)
+	})
+
+	t.Run("MaxAttemptsReached", func(t *testing.T) {
+		attempts := 0
+		err := Do(context.Background(), Constant(time.Millisecond).WithMaxAttempts(3), func(ctx context.Context) error {
+			attempts++
+			return errors.New("always failing")
+		})
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrMaxAttemptsReached)
+		assert.Equal(t, 3, attempts)
+	})
+
+	t.Run("ContextCancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		err := Do(ctx, Constant(time.Millisecond), func(ctx context.Context) error {
+			t.Fatal("must not be called with a cancelled context")
+			return nil
+		})
+		assert.ErrorIs(t, err, ErrContextDone)
+	})
+}
+
+func TestUntil(t *testing.T) {
+	t.Parallel()
+
+	t.Run("ConditionMet", func(t *testing.T) {
+		attempts := 0
+		err := Until(context.Background(), Constant(time.Millisecond), func(ctx context.Context) (bool, error) {
+			attempts++
+			return attempts == 2, nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 2, attempts)
+	})
+
+	t.Run("ConditionError", func(t *testing.T) {
+		errCond := errors.New("condition failed")
+		err := Until(context.Background(), Constant(time.Millisecond), func(ctx context.Context) (bool, error) {
+			return false, errCond
+		})
+		assert.Equal(t, errCond, err)
+	})
+
+	t.Run("Timeout", func(t *testing.T) {
+		err := Until(context.Background(), Constant(time.Millisecond).WithTimeout(20*time.Millisecond), func(ctx context.Context) (bool, error) {
+			return false, nil
+		})
+		assert.ErrorIs(t, err, ErrContextDone)
+	})
+}
+
+func TestUntilWithFakeClock(t *testing.T) {
+	t.Parallel()
+
+	fake := clock.NewFake(time.Unix(0, 0))
+	ctx := clock.WithClock(context.Background(), fake)
+
+	var attempts atomic.Int32
+	done := make(chan error)
+	go func() {
+		done <- Until(ctx, Constant(time.Minute).WithTimeout(time.Hour), func(context.Context) (bool, error) {
+			attempts.Add(1)
+			return false, nil
+		})
+	}()
+
+	// the timeout of the policy is reached when the clock is stepped, not after an hour
+	require.Eventually(t, func() bool { return attempts.Load() > 0 }, time.Second, time.Millisecond)
+	fake.Step(time.Hour)
+	err := <-done
+	assert.ErrorIs(t, err, ErrContextDone)
+}
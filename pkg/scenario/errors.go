@@ -0,0 +1,31 @@
+package scenario
+
+import (
+	"github.com/celestiaorg/knuu/pkg/errors"
+)
+
+type Error = errors.Error
+
+var (
+	ErrReadingScenario         = errors.New("ReadingScenario", "error reading scenario file '%s'")
+	ErrParsingScenario         = errors.New("ParsingScenario", "error parsing scenario")
+	ErrInstanceNameEmpty       = errors.New("InstanceNameEmpty", "instance %d has no name")
+	ErrInstanceNameDuplicated  = errors.New("InstanceNameDuplicated", "instance '%s' is defined more than once")
+	ErrInstanceImageEmpty      = errors.New("InstanceImageEmpty", "instance '%s' has no image")
+	ErrStepActionCount         = errors.New("StepActionCount", "step %d must have exactly one action, it has %d")
+	ErrStepUnknownInstance     = errors.New("StepUnknownInstance", "step %d refers to unknown instance '%s'")
+	ErrStepCommandEmpty        = errors.New("StepCommandEmpty", "step %d has an empty command")
+	ErrStepLogPatternEmpty     = errors.New("StepLogPatternEmpty", "step %d waits for an empty log pattern")
+	ErrStepFaultEmpty          = errors.New("StepFaultEmpty", "step %d does not inject any fault")
+	ErrStepFaultWithoutTwister = errors.New("StepFaultWithoutTwister", "step %d injects a fault in instance '%s' that does not enable bitTwister")
+	ErrCreatingInstance        = errors.New("CreatingInstance", "error creating instance '%s'")
+	ErrEnablingBitTwister      = errors.New("EnablingBitTwister", "error enabling bitTwister in instance '%s'")
+	ErrCommittingInstance      = errors.New("CommittingInstance", "error committing instance '%s'")
+	ErrStepFailed              = errors.New("StepFailed", "step %d (%s) failed")
+	ErrLogPatternNotFound      = errors.New("LogPatternNotFound", "'%s' not found in the logs of instance '%s'")
+	ErrOutputDoesNotContain    = errors.New("OutputDoesNotContain", "output of the command does not contain '%s': %s")
+	ErrOutputContains          = errors.New("OutputContains", "output of the command contains '%s': %s")
+	ErrDestroyingInstances     = errors.New("DestroyingInstances", "error destroying the instances of the scenario")
+	ErrReplayUnknownInstance   = errors.New("ReplayUnknownInstance", "recorded operation refers to unknown instance '%s'")
+	ErrReplayUnknownOperation  = errors.New("ReplayUnknownOperation", "unknown recorded operation '%s'")
+)
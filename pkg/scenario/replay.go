@@ -0,0 +1,149 @@
+package scenario
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/celestiaorg/knuu/pkg/clock"
+	"github.com/celestiaorg/knuu/pkg/instance"
+	"github.com/celestiaorg/knuu/pkg/recording"
+	"github.com/celestiaorg/knuu/pkg/report"
+)
+
+// ReplayOption configures a replay
+type ReplayOption func(*replayOptions)
+
+type replayOptions struct {
+	speed float64
+}
+
+// WithSpeed replays the operations the given times faster than they were recorded, e.g. 10 to wait a tenth of
+// the recorded time between two operations, 0 to run them without waiting
+// The operations are replayed at the recorded speed by default.
+func WithSpeed(speed float64) ReplayOption {
+	return func(o *replayOptions) {
+		o.speed = speed
+	}
+}
+
+// Replay creates the instances of the recording and executes its operations in order, at the time they were
+// executed since the start of the recording, so that a bug found in a long scenario can be reproduced
+// The instances are created from the images they were committed with, the images built by the recorded test
+// are replayed by name. Operations that failed when they were recorded are expected to fail again: their error
+// is logged and the replay continues. The replay stops at the first other failed operation, and the instances
+// are destroyed in all cases. The result contains the operations that were replayed, including the failed one.
+// The waits run on the clock of the context.
+func (r *Runner) Replay(ctx context.Context, rec *recording.Recording, opts ...ReplayOption) (*Result, error) {
+	o := &replayOptions{speed: 1}
+	for _, opt := range opts {
+		opt(o)
+	}
+	result := &Result{Scenario: "replay"}
+	c := clock.FromContext(ctx)
+
+	instances := make(map[string]*instance.Instance, len(rec.Instances))
+	defer func() {
+		// the instances are destroyed even if the replay was cancelled
+		if err := destroyInstances(context.WithoutCancel(ctx), instances); err != nil {
+			logrus.Errorf("Error destroying the replayed instances: %v", err)
+		}
+	}()
+
+	for _, def := range rec.Instances {
+		inst, err := r.createInstance(Instance{
+			Name:          def.Name,
+			Image:         def.Image,
+			Command:       def.Command,
+			Args:          def.Args,
+			PortsTCP:      def.PortsTCP,
+			PortsUDP:      def.PortsUDP,
+			Env:           def.Env,
+			MemoryRequest: def.MemoryRequest,
+			MemoryLimit:   def.MemoryLimit,
+			CPURequest:    def.CPURequest,
+			BitTwister:    def.BitTwister,
+		})
+		if err != nil {
+			return result, err
+		}
+		instances[def.Name] = inst
+	}
+
+	start := c.Now()
+	for index, op := range rec.Operations {
+		if o.speed > 0 {
+			if wait := time.Duration(float64(op.At)/o.speed) - c.Since(start); wait > 0 {
+				if err := clock.Sleep(ctx, wait); err != nil {
+					return result, err
+				}
+			}
+		}
+
+		desc := describeOperation(op)
+		logrus.Infof("Replay: operation %d: %s", index, desc)
+		sr := StepResult{Index: index, Step: desc, Start: c.Now()}
+		err := replayOperation(ctx, op, instances)
+		sr.Duration = c.Since(sr.Start)
+		if err != nil && op.Error != "" {
+			logrus.Warnf("Error replaying operation %d (%s), which failed when recorded with '%s': %v", index, desc, op.Error, err)
+			err = nil
+		}
+		sr.Err = err
+		result.Steps = append(result.Steps, sr)
+		r.reporter.Record("", report.OperationStep, sr.Start, fmt.Sprintf("replay: operation %d: %s", index, desc), err)
+		if err != nil {
+			return result, ErrStepFailed.WithParams(index, desc).Wrap(err)
+		}
+	}
+	return result, nil
+}
+
+func replayOperation(ctx context.Context, op recording.Operation, instances map[string]*instance.Instance) error {
+	inst, ok := instances[op.Instance]
+	if !ok {
+		return ErrReplayUnknownInstance.WithParams(op.Instance)
+	}
+	switch op.Kind {
+	case recording.KindStart:
+		return inst.Start(ctx)
+	case recording.KindStop:
+		return inst.Stop(ctx)
+	case recording.KindExec:
+		_, err := inst.ExecuteCommand(ctx, op.Command...)
+		return err
+	case recording.KindLatency:
+		return inst.SetLatencyAndJitter(op.Latency.Milliseconds(), op.Jitter.Milliseconds())
+	case recording.KindPacketLoss:
+		return inst.SetPacketLoss(op.PacketLoss)
+	case recording.KindBandwidth:
+		return inst.SetBandwidthLimit(op.Bandwidth)
+	case recording.KindNetworkDisabled:
+		return inst.DisableNetwork(ctx)
+	case recording.KindNetworkEnabled:
+		return inst.EnableNetwork(ctx)
+	}
+	return ErrReplayUnknownOperation.WithParams(op.Kind)
+}
+
+// describeOperation describes the operation in a single line
+func describeOperation(op recording.Operation) string {
+	switch op.Kind {
+	case recording.KindExec:
+		return fmt.Sprintf("exec '%s' in %s", strings.Join(op.Command, " "), op.Instance)
+	case recording.KindLatency:
+		return fmt.Sprintf("set latency %s and jitter %s in %s", op.Latency, op.Jitter, op.Instance)
+	case recording.KindPacketLoss:
+		return fmt.Sprintf("set packet loss %d%% in %s", op.PacketLoss, op.Instance)
+	case recording.KindBandwidth:
+		return fmt.Sprintf("set bandwidth limit %d bps in %s", op.Bandwidth, op.Instance)
+	case recording.KindNetworkDisabled:
+		return fmt.Sprintf("disable the network of %s", op.Instance)
+	case recording.KindNetworkEnabled:
+		return fmt.Sprintf("enable the network of %s", op.Instance)
+	}
+	return fmt.Sprintf("%s %s", op.Kind, op.Instance)
+}
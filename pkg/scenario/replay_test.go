@@ -0,0 +1,73 @@
+package scenario
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/celestiaorg/knuu/pkg/clock"
+	"github.com/celestiaorg/knuu/pkg/recording"
+)
+
+func TestRunnerReplay(t *testing.T) {
+	rec := &recording.Recording{
+		Instances: []recording.Instance{{Name: "client", Image: "alpine:latest"}},
+		Operations: []recording.Operation{
+			// the instance is not started, so the commands fail as they did when recorded
+			{Instance: "client", Kind: recording.KindExec, Command: []string{"true"}, Error: "not started"},
+			{Instance: "client", Kind: recording.KindExec, Command: []string{"true"}},
+			{Instance: "client", Kind: recording.KindStop},
+		},
+	}
+
+	result, err := NewRunner(testFactory{}).Replay(context.Background(), rec, WithSpeed(0))
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ErrStepFailed))
+
+	require.Len(t, result.Steps, 2)
+	assert.NoError(t, result.Steps[0].Err)
+	assert.Equal(t, "exec 'true' in client", result.Steps[0].Step)
+	assert.Error(t, result.Steps[1].Err)
+}
+
+func TestRunnerReplayUnknownInstance(t *testing.T) {
+	rec := &recording.Recording{Operations: []recording.Operation{{Instance: "client", Kind: recording.KindStart}}}
+
+	_, err := NewRunner(testFactory{}).Replay(context.Background(), rec)
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ErrStepFailed))
+	assert.Contains(t, err.Error(), "unknown instance 'client'")
+}
+
+func TestRunnerReplayWithSpeed(t *testing.T) {
+	rec := &recording.Recording{
+		Instances: []recording.Instance{{Name: "client", Image: "alpine:latest"}},
+		Operations: []recording.Operation{
+			{At: time.Hour, Instance: "client", Kind: recording.KindExec, Command: []string{"true"}, Error: "not started"},
+		},
+	}
+
+	fake := clock.NewFake(time.Unix(0, 0))
+	ctx := clock.WithClock(context.Background(), fake)
+
+	type outcome struct {
+		result *Result
+		err    error
+	}
+	done := make(chan outcome)
+	go func() {
+		result, err := NewRunner(testFactory{}).Replay(ctx, rec, WithSpeed(2))
+		done <- outcome{result, err}
+	}()
+
+	require.Eventually(t, fake.HasWaiters, time.Second, time.Millisecond)
+	fake.Step(30 * time.Minute)
+	o := <-done
+	require.NoError(t, o.err)
+	require.Len(t, o.result.Steps, 1)
+	assert.Equal(t, time.Unix(0, 0).Add(30*time.Minute), o.result.Steps[0].Start)
+}
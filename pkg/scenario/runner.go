@@ -0,0 +1,231 @@
+package scenario
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/celestiaorg/knuu/pkg/clock"
+	"github.com/celestiaorg/knuu/pkg/instance"
+	"github.com/celestiaorg/knuu/pkg/report"
+)
+
+// InstanceFactory creates the instances of the scenarios, it is implemented by knuu.Knuu
+type InstanceFactory interface {
+	NewInstance(name string, opts ...instance.Option) (*instance.Instance, error)
+}
+
+// Runner runs scenarios with the instances created by its factory
+type Runner struct {
+	factory  InstanceFactory
+	reporter *report.Recorder
+}
+
+// RunnerOption configures a Runner
+type RunnerOption func(*Runner)
+
+// WithReporter records the steps of the scenarios in the given recorder
+func WithReporter(reporter *report.Recorder) RunnerOption {
+	return func(r *Runner) {
+		r.reporter = reporter
+	}
+}
+
+// Result is the outcome of the steps of a scenario that were run
+type Result struct {
+	Scenario string
+	Steps    []StepResult
+}
+
+// StepResult is the outcome of a single step
+type StepResult struct {
+	Index    int
+	Step     string
+	Start    time.Time
+	Duration time.Duration
+	// Err is nil if the step succeeded
+	Err error
+}
+
+// Failed returns true if a step of the scenario failed
+func (r *Result) Failed() bool {
+	for _, step := range r.Steps {
+		if step.Err != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// NewRunner returns a runner that creates its instances with the given factory
+func NewRunner(factory InstanceFactory, opts ...RunnerOption) *Runner {
+	r := &Runner{factory: factory}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Run creates the instances of the scenario and runs its steps in order
+// It stops at the first failed step, and the instances are destroyed in all cases.
+// The result contains the steps that were run, including the failed one.
+// The steps are timed and the sleeps and waits run on the clock of the context.
+func (r *Runner) Run(ctx context.Context, s *Scenario) (*Result, error) {
+	result := &Result{Scenario: s.Name}
+	c := clock.FromContext(ctx)
+
+	instances := make(map[string]*instance.Instance, len(s.Instances))
+	defer func() {
+		// the instances are destroyed even if the scenario was cancelled
+		if err := destroyInstances(context.WithoutCancel(ctx), instances); err != nil {
+			logrus.Errorf("Error destroying the instances of scenario '%s': %v", s.Name, err)
+		}
+	}()
+
+	for _, def := range s.Instances {
+		inst, err := r.createInstance(def)
+		if err != nil {
+			return result, err
+		}
+		instances[def.Name] = inst
+	}
+
+	for index, step := range s.Steps {
+		logrus.Infof("Scenario '%s': step %d: %s", s.Name, index, step)
+		sr := StepResult{Index: index, Step: step.String(), Start: c.Now()}
+		err := runStep(ctx, step, instances)
+		sr.Duration = c.Since(sr.Start)
+		sr.Err = err
+		result.Steps = append(result.Steps, sr)
+		r.reporter.Record("", report.OperationStep, sr.Start, fmt.Sprintf("%s: step %d: %s", s.Name, index, sr.Step), err)
+		if err != nil {
+			return result, ErrStepFailed.WithParams(index, step.String()).Wrap(err)
+		}
+	}
+	return result, nil
+}
+
+func (r *Runner) createInstance(def Instance) (*instance.Instance, error) {
+	inst, err := r.factory.NewInstance(def.Name,
+		instance.WithImage(def.Image),
+		instance.WithCommand(def.Command...),
+		instance.WithArgs(def.Args...),
+		instance.WithPorts(def.PortsTCP...),
+		instance.WithPortsUDP(def.PortsUDP...),
+		instance.WithEnv(def.Env),
+		instance.WithResources(def.MemoryRequest, def.MemoryLimit, def.CPURequest),
+	)
+	if err != nil {
+		return nil, ErrCreatingInstance.WithParams(def.Name).Wrap(err)
+	}
+	if def.BitTwister {
+		if err := inst.EnableBitTwister(); err != nil {
+			return nil, ErrEnablingBitTwister.WithParams(def.Name).Wrap(err)
+		}
+	}
+	if err := inst.Commit(); err != nil {
+		return nil, ErrCommittingInstance.WithParams(def.Name).Wrap(err)
+	}
+	return inst, nil
+}
+
+func runStep(ctx context.Context, step Step, instances map[string]*instance.Instance) error {
+	switch {
+	case len(step.Start) != 0:
+		for _, name := range step.Start {
+			if err := instances[name].Start(ctx); err != nil {
+				return err
+			}
+		}
+	case len(step.Stop) != 0:
+		for _, name := range step.Stop {
+			if err := instances[name].Stop(ctx); err != nil {
+				return err
+			}
+		}
+	case step.WaitForLog != nil:
+		return waitForLog(ctx, instances[step.WaitForLog.Instance], step.WaitForLog)
+	case step.Exec != nil:
+		return execute(ctx, instances[step.Exec.Instance], step.Exec)
+	case step.Fault != nil:
+		return injectFault(instances[step.Fault.Instance], step.Fault)
+	case step.Sleep != 0:
+		return clock.Sleep(ctx, step.Sleep)
+	}
+	return nil
+}
+
+func waitForLog(ctx context.Context, inst *instance.Instance, step *WaitForLogStep) error {
+	timeout := step.Timeout
+	if timeout == 0 {
+		timeout = DefaultWaitForLogTimeout
+	}
+	ctx, cancel := clock.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	logs, err := inst.Logs(ctx, true)
+	if err != nil {
+		return err
+	}
+	defer logs.Close()
+
+	scanner := bufio.NewScanner(logs)
+	for scanner.Scan() {
+		if strings.Contains(scanner.Text(), step.Contains) {
+			return nil
+		}
+	}
+	return ErrLogPatternNotFound.WithParams(step.Contains, step.Instance)
+}
+
+func execute(ctx context.Context, inst *instance.Instance, step *ExecStep) error {
+	output, err := inst.ExecuteCommand(ctx, step.Command...)
+	if err != nil {
+		return err
+	}
+	if step.Expect.Contains != "" && !strings.Contains(output, step.Expect.Contains) {
+		return ErrOutputDoesNotContain.WithParams(step.Expect.Contains, output)
+	}
+	if step.Expect.NotContains != "" && strings.Contains(output, step.Expect.NotContains) {
+		return ErrOutputContains.WithParams(step.Expect.NotContains, output)
+	}
+	return nil
+}
+
+func injectFault(inst *instance.Instance, step *FaultStep) error {
+	if step.Latency != 0 || step.Jitter != 0 {
+		if err := inst.SetLatencyAndJitter(step.Latency.Milliseconds(), step.Jitter.Milliseconds()); err != nil {
+			return err
+		}
+	}
+	if step.PacketLoss != 0 {
+		if err := inst.SetPacketLoss(step.PacketLoss); err != nil {
+			return err
+		}
+	}
+	if step.Bandwidth != 0 {
+		if err := inst.SetBandwidthLimit(step.Bandwidth); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// destroyInstances destroys the instances that were started
+// Instances that were never started have no resources in the cluster.
+func destroyInstances(ctx context.Context, instances map[string]*instance.Instance) error {
+	var started []*instance.Instance
+	for _, inst := range instances {
+		if inst.IsInState(instance.Started, instance.Stopped) {
+			started = append(started, inst)
+		}
+	}
+	if err := instance.BatchDestroy(ctx, started...); err != nil {
+		return ErrDestroyingInstances.Wrap(err)
+	}
+	return nil
+}
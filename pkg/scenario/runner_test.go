@@ -0,0 +1,85 @@
+package scenario
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/celestiaorg/knuu/pkg/clock"
+	"github.com/celestiaorg/knuu/pkg/instance"
+	"github.com/celestiaorg/knuu/pkg/system"
+)
+
+type testFactory struct{}
+
+func (testFactory) NewInstance(name string, opts ...instance.Option) (*instance.Instance, error) {
+	return instance.New(name, system.SystemDependencies{}, opts...)
+}
+
+func TestRunnerRun(t *testing.T) {
+	s := &Scenario{
+		Name:      "test",
+		Instances: []Instance{{Name: "client", Image: "alpine:latest"}},
+		Steps: []Step{
+			{Sleep: time.Millisecond},
+			// the instance is not started, so the command cannot be executed
+			{Exec: &ExecStep{Instance: "client", Command: []string{"true"}}},
+			{Sleep: time.Millisecond},
+		},
+	}
+
+	result, err := NewRunner(testFactory{}).Run(context.Background(), s)
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ErrStepFailed))
+
+	require.Len(t, result.Steps, 2)
+	assert.True(t, result.Failed())
+	assert.NoError(t, result.Steps[0].Err)
+	assert.Equal(t, "sleep 1ms", result.Steps[0].Step)
+	assert.Error(t, result.Steps[1].Err)
+	assert.Equal(t, 1, result.Steps[1].Index)
+}
+
+func TestRunnerInvalidInstance(t *testing.T) {
+	s := &Scenario{
+		Instances: []Instance{{Name: "client", Image: "alpine:latest", PortsTCP: []int{0}}},
+		Steps:     []Step{{Sleep: time.Millisecond}},
+	}
+
+	result, err := NewRunner(testFactory{}).Run(context.Background(), s)
+	assert.True(t, errors.Is(err, ErrCreatingInstance))
+	assert.Empty(t, result.Steps)
+}
+
+func TestRunnerRunWithFakeClock(t *testing.T) {
+	s := &Scenario{
+		Name:      "test",
+		Instances: []Instance{{Name: "client", Image: "alpine:latest"}},
+		Steps:     []Step{{Sleep: time.Hour}},
+	}
+
+	fake := clock.NewFake(time.Unix(0, 0))
+	ctx := clock.WithClock(context.Background(), fake)
+
+	type outcome struct {
+		result *Result
+		err    error
+	}
+	done := make(chan outcome)
+	go func() {
+		result, err := NewRunner(testFactory{}).Run(ctx, s)
+		done <- outcome{result, err}
+	}()
+
+	require.Eventually(t, fake.HasWaiters, time.Second, time.Millisecond)
+	fake.Step(time.Hour)
+	o := <-done
+	require.NoError(t, o.err)
+	require.Len(t, o.result.Steps, 1)
+	assert.Equal(t, time.Unix(0, 0), o.result.Steps[0].Start)
+	assert.Equal(t, time.Hour, o.result.Steps[0].Duration)
+}
@@ -0,0 +1,224 @@
+// Package scenario runs declarative test scenarios, so that chaos and e2e tests can be written
+// as YAML files instead of Go code.
+//
+// A scenario defines the instances of the test and the steps executed against them:
+//
+//	name: ping
+//	instances:
+//	  - name: server
+//	    image: nginx:latest
+//	    portsTCP: [80]
+//	    bitTwister: true
+//	  - name: client
+//	    image: alpine:latest
+//	    command: ["sleep", "infinity"]
+//	steps:
+//	  - start: [server, client]
+//	  - waitForLog: {instance: server, contains: "start worker processes", timeout: 1m}
+//	  - fault: {instance: server, latency: 200ms}
+//	  - exec:
+//	      instance: client
+//	      command: ["wget", "-qO-", "server"]
+//	      expect: {contains: "Welcome to nginx"}
+//	  - sleep: 10s
+//	  - stop: [client]
+package scenario
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Scenario is a set of instances and the steps executed against them
+type Scenario struct {
+	Name      string     `yaml:"name"`
+	Instances []Instance `yaml:"instances"`
+	Steps     []Step     `yaml:"steps"`
+}
+
+// Instance describes an instance of a scenario
+// The instance is created and committed before the first step, and destroyed after the last one.
+type Instance struct {
+	Name          string            `yaml:"name"`
+	Image         string            `yaml:"image"`
+	Command       []string          `yaml:"command"`
+	Args          []string          `yaml:"args"`
+	PortsTCP      []int             `yaml:"portsTCP"`
+	PortsUDP      []int             `yaml:"portsUDP"`
+	Env           map[string]string `yaml:"env"`
+	MemoryRequest string            `yaml:"memoryRequest"`
+	MemoryLimit   string            `yaml:"memoryLimit"`
+	CPURequest    string            `yaml:"cpuRequest"`
+	// BitTwister must be enabled to inject network faults in the instance
+	BitTwister bool `yaml:"bitTwister"`
+}
+
+// Step is a single action of a scenario, exactly one of the fields must be set
+type Step struct {
+	Start      []string        `yaml:"start"`
+	Stop       []string        `yaml:"stop"`
+	WaitForLog *WaitForLogStep `yaml:"waitForLog"`
+	Exec       *ExecStep       `yaml:"exec"`
+	Fault      *FaultStep      `yaml:"fault"`
+	Sleep      time.Duration   `yaml:"sleep"`
+}
+
+// WaitForLogStep waits until a line of the logs of the instance contains the given text
+type WaitForLogStep struct {
+	Instance string `yaml:"instance"`
+	Contains string `yaml:"contains"`
+	// Timeout defaults to DefaultWaitForLogTimeout
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+// ExecStep executes a command in the instance and checks its output
+type ExecStep struct {
+	Instance string     `yaml:"instance"`
+	Command  []string   `yaml:"command"`
+	Expect   ExecExpect `yaml:"expect"`
+}
+
+// ExecExpect are the assertions on the output of a command, empty values are not checked
+type ExecExpect struct {
+	Contains    string `yaml:"contains"`
+	NotContains string `yaml:"notContains"`
+}
+
+// FaultStep injects network faults in the instance with bitTwister, zero values are not injected
+type FaultStep struct {
+	Instance   string        `yaml:"instance"`
+	Latency    time.Duration `yaml:"latency"`
+	Jitter     time.Duration `yaml:"jitter"`
+	PacketLoss int32         `yaml:"packetLoss"`
+	// Bandwidth is the bandwidth limit in bits per second
+	Bandwidth int64 `yaml:"bandwidth"`
+}
+
+// DefaultWaitForLogTimeout is the time waited for a log line if the step does not set a timeout
+const DefaultWaitForLogTimeout = 5 * time.Minute
+
+// Load reads and validates the scenario file at the given path
+func Load(path string) (*Scenario, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, ErrReadingScenario.WithParams(path).Wrap(err)
+	}
+	return Parse(data)
+}
+
+// Parse decodes and validates a scenario
+func Parse(data []byte) (*Scenario, error) {
+	s := &Scenario{}
+	if err := yaml.Unmarshal(data, s); err != nil {
+		return nil, ErrParsingScenario.Wrap(err)
+	}
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Validate checks that the scenario can be run, it returns the first problem found
+func (s *Scenario) Validate() error {
+	instances := make(map[string]Instance, len(s.Instances))
+	for index, inst := range s.Instances {
+		if inst.Name == "" {
+			return ErrInstanceNameEmpty.WithParams(index)
+		}
+		if _, ok := instances[inst.Name]; ok {
+			return ErrInstanceNameDuplicated.WithParams(inst.Name)
+		}
+		if inst.Image == "" {
+			return ErrInstanceImageEmpty.WithParams(inst.Name)
+		}
+		instances[inst.Name] = inst
+	}
+
+	for index, step := range s.Steps {
+		if count := step.actionCount(); count != 1 {
+			return ErrStepActionCount.WithParams(index, count)
+		}
+		for _, name := range step.instances() {
+			if _, ok := instances[name]; !ok {
+				return ErrStepUnknownInstance.WithParams(index, name)
+			}
+		}
+
+		switch {
+		case step.WaitForLog != nil:
+			if step.WaitForLog.Contains == "" {
+				return ErrStepLogPatternEmpty.WithParams(index)
+			}
+		case step.Exec != nil:
+			if len(step.Exec.Command) == 0 {
+				return ErrStepCommandEmpty.WithParams(index)
+			}
+		case step.Fault != nil:
+			f := step.Fault
+			if f.Latency == 0 && f.Jitter == 0 && f.PacketLoss == 0 && f.Bandwidth == 0 {
+				return ErrStepFaultEmpty.WithParams(index)
+			}
+			if !instances[f.Instance].BitTwister {
+				return ErrStepFaultWithoutTwister.WithParams(index, f.Instance)
+			}
+		}
+	}
+	return nil
+}
+
+func (s Step) actionCount() int {
+	count := 0
+	for _, set := range []bool{
+		len(s.Start) != 0,
+		len(s.Stop) != 0,
+		s.WaitForLog != nil,
+		s.Exec != nil,
+		s.Fault != nil,
+		s.Sleep != 0,
+	} {
+		if set {
+			count++
+		}
+	}
+	return count
+}
+
+// instances returns the names of the instances the step refers to
+func (s Step) instances() []string {
+	switch {
+	case len(s.Start) != 0:
+		return s.Start
+	case len(s.Stop) != 0:
+		return s.Stop
+	case s.WaitForLog != nil:
+		return []string{s.WaitForLog.Instance}
+	case s.Exec != nil:
+		return []string{s.Exec.Instance}
+	case s.Fault != nil:
+		return []string{s.Fault.Instance}
+	}
+	return nil
+}
+
+// String describes the step in a single line
+func (s Step) String() string {
+	switch {
+	case len(s.Start) != 0:
+		return fmt.Sprintf("start %s", strings.Join(s.Start, ", "))
+	case len(s.Stop) != 0:
+		return fmt.Sprintf("stop %s", strings.Join(s.Stop, ", "))
+	case s.WaitForLog != nil:
+		return fmt.Sprintf("wait for '%s' in the logs of %s", s.WaitForLog.Contains, s.WaitForLog.Instance)
+	case s.Exec != nil:
+		return fmt.Sprintf("exec '%s' in %s", strings.Join(s.Exec.Command, " "), s.Exec.Instance)
+	case s.Fault != nil:
+		return fmt.Sprintf("inject faults in %s", s.Fault.Instance)
+	case s.Sleep != 0:
+		return fmt.Sprintf("sleep %s", s.Sleep)
+	}
+	return "empty step"
+}
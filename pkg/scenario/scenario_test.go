@@ -0,0 +1,144 @@
+package scenario
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const validScenario = `
+name: ping
+instances:
+  - name: server
+    image: nginx:latest
+    portsTCP: [80]
+    bitTwister: true
+  - name: client
+    image: alpine:latest
+    command: ["sleep", "infinity"]
+steps:
+  - start: [server, client]
+  - waitForLog: {instance: server, contains: "ready", timeout: 1m}
+  - fault: {instance: server, latency: 200ms, packetLoss: 10}
+  - exec:
+      instance: client
+      command: ["wget", "-qO-", "server"]
+      expect: {contains: "Welcome"}
+  - sleep: 10s
+  - stop: [client]
+`
+
+func TestParse(t *testing.T) {
+	s, err := Parse([]byte(validScenario))
+	require.NoError(t, err)
+
+	assert.Equal(t, "ping", s.Name)
+	require.Len(t, s.Instances, 2)
+	assert.Equal(t, []int{80}, s.Instances[0].PortsTCP)
+	assert.True(t, s.Instances[0].BitTwister)
+	require.Len(t, s.Steps, 6)
+	assert.Equal(t, []string{"server", "client"}, s.Steps[0].Start)
+	assert.Equal(t, time.Minute, s.Steps[1].WaitForLog.Timeout)
+	assert.Equal(t, 200*time.Millisecond, s.Steps[2].Fault.Latency)
+	assert.Equal(t, int32(10), s.Steps[2].Fault.PacketLoss)
+	assert.Equal(t, "Welcome", s.Steps[3].Exec.Expect.Contains)
+	assert.Equal(t, 10*time.Second, s.Steps[4].Sleep)
+	assert.Equal(t, "exec 'wget -qO- server' in client", s.Steps[3].String())
+}
+
+func TestValidate(t *testing.T) {
+	instances := []Instance{
+		{Name: "server", Image: "nginx:latest", BitTwister: true},
+		{Name: "client", Image: "alpine:latest"},
+	}
+
+	tests := []struct {
+		name      string
+		instances []Instance
+		steps     []Step
+		expected  error
+	}{
+		{
+			name:      "instance without name",
+			instances: []Instance{{Image: "alpine:latest"}},
+			expected:  ErrInstanceNameEmpty,
+		},
+		{
+			name:      "duplicated instance",
+			instances: []Instance{{Name: "a", Image: "alpine:latest"}, {Name: "a", Image: "alpine:latest"}},
+			expected:  ErrInstanceNameDuplicated,
+		},
+		{
+			name:      "instance without image",
+			instances: []Instance{{Name: "a"}},
+			expected:  ErrInstanceImageEmpty,
+		},
+		{
+			name:     "step without action",
+			steps:    []Step{{}},
+			expected: ErrStepActionCount,
+		},
+		{
+			name:     "step with two actions",
+			steps:    []Step{{Start: []string{"server"}, Sleep: time.Second}},
+			expected: ErrStepActionCount,
+		},
+		{
+			name:     "unknown instance",
+			steps:    []Step{{Stop: []string{"unknown"}}},
+			expected: ErrStepUnknownInstance,
+		},
+		{
+			name:     "empty command",
+			steps:    []Step{{Exec: &ExecStep{Instance: "client"}}},
+			expected: ErrStepCommandEmpty,
+		},
+		{
+			name:     "empty log pattern",
+			steps:    []Step{{WaitForLog: &WaitForLogStep{Instance: "client"}}},
+			expected: ErrStepLogPatternEmpty,
+		},
+		{
+			name:     "empty fault",
+			steps:    []Step{{Fault: &FaultStep{Instance: "server"}}},
+			expected: ErrStepFaultEmpty,
+		},
+		{
+			name:     "fault without bitTwister",
+			steps:    []Step{{Fault: &FaultStep{Instance: "client", PacketLoss: 10}}},
+			expected: ErrStepFaultWithoutTwister,
+		},
+		{
+			name: "valid steps",
+			steps: []Step{
+				{Start: []string{"server", "client"}},
+				{Fault: &FaultStep{Instance: "server", Bandwidth: 1000}},
+				{Sleep: time.Second},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Scenario{Instances: tt.instances, Steps: tt.steps}
+			if s.Instances == nil {
+				s.Instances = instances
+			}
+
+			err := s.Validate()
+			if tt.expected == nil {
+				assert.NoError(t, err)
+				return
+			}
+			assert.True(t, errors.Is(err, tt.expected), "expected %v, got %v", tt.expected, err)
+		})
+	}
+}
+
+func TestParseInvalidYAML(t *testing.T) {
+	_, err := Parse([]byte("steps: {"))
+	assert.True(t, errors.Is(err, ErrParsingScenario))
+}
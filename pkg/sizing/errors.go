@@ -0,0 +1,13 @@
+package sizing
+
+import (
+	"github.com/celestiaorg/knuu/pkg/errors"
+)
+
+type Error = errors.Error
+
+var (
+	ErrReadingHistoryFile = errors.New("ReadingHistoryFile", "error reading usage history file '%s'")
+	ErrParsingHistoryFile = errors.New("ParsingHistoryFile", "error parsing usage history file '%s'")
+	ErrWritingHistoryFile = errors.New("WritingHistoryFile", "error writing usage history file '%s'")
+)
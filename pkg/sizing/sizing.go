@@ -0,0 +1,288 @@
+// Package sizing learns the resources of the instances from their peak usage in the previous runs of a test, to
+// report the over and under provisioned instances and to request the learned resources in the next runs.
+// The history of the runs is kept in a file by instance name, as the names are stable across the runs.
+package sizing
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/celestiaorg/knuu/pkg/report"
+)
+
+const (
+	// DefaultMaxRuns is the number of runs kept by instance when the given maximum is not positive
+	DefaultMaxRuns = 10
+	// Headroom is the share of the peak usage added to it to suggest the requests
+	Headroom = 0.2
+	// overProvisionedFactor is the factor of the suggested request above which a request is over provisioned
+	overProvisionedFactor = 2
+
+	cpuStepMillis = 10
+	memoryStep    = 1 << 20
+)
+
+// Resources are an amount of CPU and memory
+type Resources struct {
+	CPUMillis   int64 `json:"cpuMillis"`
+	MemoryBytes int64 `json:"memoryBytes"`
+}
+
+// CPU returns the CPU as a quantity, e.g. '250m' for instance.Instance.SetCPU
+func (r Resources) CPU() string {
+	return fmt.Sprintf("%dm", r.CPUMillis)
+}
+
+// Memory returns the memory as a quantity, e.g. '512Mi' for instance.Instance.SetMemory
+func (r Resources) Memory() string {
+	if r.MemoryBytes%memoryStep == 0 {
+		return fmt.Sprintf("%dMi", r.MemoryBytes/memoryStep)
+	}
+	return fmt.Sprintf("%d", r.MemoryBytes)
+}
+
+// Run is the requested and the peak resources of an instance in a run of the test
+type Run struct {
+	Time      time.Time `json:"time"`
+	Requested Resources `json:"requested"`
+	Peak      Resources `json:"peak"`
+}
+
+// History is the peak usage of the instances in the previous runs of the test and in the current one
+type History struct {
+	path    string
+	maxRuns int
+
+	mu sync.Mutex
+	// runs are the previous runs by instance name, from the oldest, current the current ones
+	runs    map[string][]Run
+	current map[string]*Run
+}
+
+// Load returns the history persisted in the given file, empty if the file does not exist
+// At most maxRuns runs are kept by instance, DefaultMaxRuns if it is not positive.
+func Load(path string, maxRuns int) (*History, error) {
+	if maxRuns <= 0 {
+		maxRuns = DefaultMaxRuns
+	}
+	h := &History{
+		path:    path,
+		maxRuns: maxRuns,
+		runs:    make(map[string][]Run),
+		current: make(map[string]*Run),
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return h, nil
+	}
+	if err != nil {
+		return nil, ErrReadingHistoryFile.WithParams(path).Wrap(err)
+	}
+	if err := json.Unmarshal(data, &h.runs); err != nil {
+		return nil, ErrParsingHistoryFile.WithParams(path).Wrap(err)
+	}
+	logrus.Debugf("Loaded the usage history of %d instances from '%s'", len(h.runs), path)
+	return h, nil
+}
+
+// Observe records a sample of the usage of the instance with the given name in the current run, the peak of the
+// run is the maximum of its samples
+// The samples whose usage is unknown are ignored.
+func (h *History) Observe(name string, usage report.ResourceUsage) {
+	if !usage.UsageKnown {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	run, ok := h.current[name]
+	if !ok {
+		run = &Run{Time: time.Now()}
+		h.current[name] = run
+	}
+	run.Requested = Resources{CPUMillis: usage.CPURequestMillis, MemoryBytes: usage.MemoryRequestBytes}
+	run.Peak.CPUMillis = max(run.Peak.CPUMillis, usage.CPUUsedMillis)
+	run.Peak.MemoryBytes = max(run.Peak.MemoryBytes, usage.MemoryUsedBytes)
+}
+
+// Learned returns the resources suggested for the instance with the given name by its previous runs, false if it
+// has none
+func (h *History) Learned(name string) (Resources, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	runs := h.runs[name]
+	if len(runs) == 0 {
+		return Resources{}, false
+	}
+	return suggest(peak(runs)), true
+}
+
+// Save adds the current runs to the history and writes it to its file, the current runs are then reset
+// The file is replaced atomically, so that concurrent processes never read a partial file.
+func (h *History) Save() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for name, run := range h.current {
+		runs := append(h.runs[name], *run)
+		if len(runs) > h.maxRuns {
+			runs = runs[len(runs)-h.maxRuns:]
+		}
+		h.runs[name] = runs
+	}
+	h.current = make(map[string]*Run)
+
+	data, err := json.Marshal(h.runs)
+	if err != nil {
+		return ErrWritingHistoryFile.WithParams(h.path).Wrap(err)
+	}
+	if err := os.MkdirAll(filepath.Dir(h.path), 0o755); err != nil {
+		return ErrWritingHistoryFile.WithParams(h.path).Wrap(err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(h.path), filepath.Base(h.path)+".*.tmp")
+	if err != nil {
+		return ErrWritingHistoryFile.WithParams(h.path).Wrap(err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return ErrWritingHistoryFile.WithParams(h.path).Wrap(err)
+	}
+	if err := tmp.Close(); err != nil {
+		return ErrWritingHistoryFile.WithParams(h.path).Wrap(err)
+	}
+	if err := os.Rename(tmp.Name(), h.path); err != nil {
+		return ErrWritingHistoryFile.WithParams(h.path).Wrap(err)
+	}
+	return nil
+}
+
+// Provisioning tells how a request compares to the peak usage
+type Provisioning string
+
+const (
+	// ProvisioningRight is a request between the peak usage and twice the suggested request
+	ProvisioningRight Provisioning = "right"
+	// ProvisioningOver is a request above twice the suggested request, which wastes the capacity of the cluster
+	ProvisioningOver Provisioning = "over"
+	// ProvisioningUnder is a request below the peak usage, the instance can be evicted or starved on a busy node
+	ProvisioningUnder Provisioning = "under"
+	// ProvisioningUnset is a request that is not set, the instance is scheduled as if it used nothing
+	ProvisioningUnset Provisioning = "unset"
+)
+
+// Suggestion compares the requests of an instance in its latest run with its peak usage across the runs
+type Suggestion struct {
+	Instance string
+	Runs     int
+	// Requested are the requests of the latest run
+	Requested Resources
+	Peak      Resources
+	Suggested Resources
+	CPU       Provisioning
+	Memory    Provisioning
+}
+
+// Misprovisioned returns true if the CPU or the memory of the instance is not right provisioned
+func (s Suggestion) Misprovisioned() bool {
+	return s.CPU != ProvisioningRight || s.Memory != ProvisioningRight
+}
+
+// Summary returns a one line summary of the suggestion, e.g. for the logs
+func (s Suggestion) Summary() string {
+	return fmt.Sprintf("%s: CPU %s provisioned (requested %dm, peak %dm, suggested %dm), memory %s provisioned "+
+		"(requested %s, peak %s, suggested %s), over %d runs",
+		s.Instance, s.CPU, s.Requested.CPUMillis, s.Peak.CPUMillis, s.Suggested.CPUMillis, s.Memory,
+		formatMiB(s.Requested.MemoryBytes), formatMiB(s.Peak.MemoryBytes), formatMiB(s.Suggested.MemoryBytes), s.Runs)
+}
+
+// Suggest returns the suggestions for the instances of the previous runs and of the current one, by instance name
+func (h *History) Suggest() []Suggestion {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	names := make(map[string]bool, len(h.runs)+len(h.current))
+	for name := range h.runs {
+		names[name] = true
+	}
+	for name := range h.current {
+		names[name] = true
+	}
+
+	suggestions := make([]Suggestion, 0, len(names))
+	for name := range names {
+		runs := h.runs[name]
+		if run, ok := h.current[name]; ok {
+			runs = append(append([]Run(nil), runs...), *run)
+		}
+		if len(runs) == 0 {
+			continue
+		}
+		requested := runs[len(runs)-1].Requested
+		p := peak(runs)
+		suggested := suggest(p)
+		suggestions = append(suggestions, Suggestion{
+			Instance:  name,
+			Runs:      len(runs),
+			Requested: requested,
+			Peak:      p,
+			Suggested: suggested,
+			CPU:       classify(requested.CPUMillis, p.CPUMillis, suggested.CPUMillis),
+			Memory:    classify(requested.MemoryBytes, p.MemoryBytes, suggested.MemoryBytes),
+		})
+	}
+	sort.Slice(suggestions, func(a, b int) bool {
+		return suggestions[a].Instance < suggestions[b].Instance
+	})
+	return suggestions
+}
+
+// peak returns the peak usage across the runs
+func peak(runs []Run) Resources {
+	var p Resources
+	for _, run := range runs {
+		p.CPUMillis = max(p.CPUMillis, run.Peak.CPUMillis)
+		p.MemoryBytes = max(p.MemoryBytes, run.Peak.MemoryBytes)
+	}
+	return p
+}
+
+// suggest returns the peak usage with the headroom, rounded up to 10m of CPU and to a MiB of memory
+func suggest(p Resources) Resources {
+	return Resources{
+		CPUMillis:   roundUp(float64(p.CPUMillis)*(1+Headroom), cpuStepMillis),
+		MemoryBytes: roundUp(float64(p.MemoryBytes)*(1+Headroom), memoryStep),
+	}
+}
+
+func roundUp(v float64, step int64) int64 {
+	return max(int64(math.Ceil(v/float64(step))), 1) * step
+}
+
+func classify(requested, peak, suggested int64) Provisioning {
+	switch {
+	case requested == 0:
+		return ProvisioningUnset
+	case requested < peak:
+		return ProvisioningUnder
+	case requested > overProvisionedFactor*suggested:
+		return ProvisioningOver
+	default:
+		return ProvisioningRight
+	}
+}
+
+func formatMiB(b int64) string {
+	return fmt.Sprintf("%.1fMiB", float64(b)/memoryStep)
+}
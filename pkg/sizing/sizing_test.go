@@ -0,0 +1,76 @@
+package sizing
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/celestiaorg/knuu/pkg/report"
+)
+
+func usage(cpuRequest, memoryRequest, cpuUsed, memoryUsed int64) report.ResourceUsage {
+	return report.ResourceUsage{
+		CPURequestMillis:   cpuRequest,
+		MemoryRequestBytes: memoryRequest,
+		CPUUsedMillis:      cpuUsed,
+		MemoryUsedBytes:    memoryUsed,
+		UsageKnown:         true,
+	}
+}
+
+func TestHistory(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "sizing", "history.json")
+	h, err := Load(path, 2)
+	require.NoError(t, err)
+	_, ok := h.Learned("validator")
+	assert.False(t, ok)
+
+	// the peak of a run is the maximum of its samples
+	h.Observe("validator", usage(2000, 4<<30, 200, 300<<20))
+	h.Observe("validator", usage(2000, 4<<30, 450, 200<<20))
+	h.Observe("validator", report.ResourceUsage{CPUUsedMillis: 5000})
+	h.Observe("bridge", usage(100, 100<<20, 150, 50<<20))
+	h.Observe("light", usage(0, 0, 10, 10<<20))
+	require.NoError(t, h.Save())
+
+	h, err = Load(path, 2)
+	require.NoError(t, err)
+	learned, ok := h.Learned("validator")
+	require.True(t, ok)
+	assert.Equal(t, Resources{CPUMillis: 540, MemoryBytes: 360 << 20}, learned)
+	assert.Equal(t, "540m", learned.CPU())
+	assert.Equal(t, "360Mi", learned.Memory())
+
+	suggestions := h.Suggest()
+	require.Len(t, suggestions, 3)
+	assert.Equal(t, "bridge", suggestions[0].Instance)
+	assert.Equal(t, ProvisioningUnder, suggestions[0].CPU)
+	assert.Equal(t, ProvisioningRight, suggestions[0].Memory)
+	assert.Equal(t, ProvisioningUnset, suggestions[1].CPU)
+	assert.Equal(t, ProvisioningOver, suggestions[2].CPU)
+	assert.Equal(t, ProvisioningOver, suggestions[2].Memory)
+	assert.True(t, suggestions[2].Misprovisioned())
+	assert.Contains(t, suggestions[2].Summary(), "validator: CPU over provisioned (requested 2000m, peak 450m, suggested 540m)")
+
+	// the current run is suggested with the previous ones, and only the last runs are kept
+	h.Observe("validator", usage(600, 400<<20, 500, 100<<20))
+	suggestions = h.Suggest()
+	assert.Equal(t, 2, suggestions[2].Runs)
+	assert.Equal(t, ProvisioningRight, suggestions[2].CPU)
+	require.NoError(t, h.Save())
+	h.Observe("validator", usage(600, 400<<20, 100, 100<<20))
+	require.NoError(t, h.Save())
+	h, err = Load(path, 2)
+	require.NoError(t, err)
+	learned, _ = h.Learned("validator")
+	assert.Equal(t, int64(600), learned.CPUMillis)
+
+	require.NoError(t, os.WriteFile(path, []byte("{"), 0o644))
+	_, err = Load(path, 2)
+	assert.ErrorIs(t, err, ErrParsingHistoryFile)
+}
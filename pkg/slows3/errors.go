@@ -0,0 +1,16 @@
+package slows3
+
+import (
+	"github.com/celestiaorg/knuu/pkg/errors"
+)
+
+type Error = errors.Error
+
+var (
+	ErrCreatingStorage   = errors.New("CreatingStorage", "error creating the object storage")
+	ErrStartingStorage   = errors.New("StartingStorage", "error starting the object storage '%s'")
+	ErrInvalidShaping    = errors.New("InvalidShaping", "invalid shaping of the object storage: %s")
+	ErrAttachingShaper   = errors.New("AttachingShaper", "error attaching the traffic shaper to the object storage '%s'")
+	ErrShapingStorage    = errors.New("ShapingStorage", "error shaping the traffic of the object storage '%s'")
+	ErrDestroyingStorage = errors.New("DestroyingStorage", "error destroying the object storage '%s'")
+)
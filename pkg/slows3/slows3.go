@@ -0,0 +1,239 @@
+// Package slows3 provides an S3 compatible object storage whose network can be degraded, e.g. to test how a data
+// availability layer or a backup system behaves when its storage backend is slow.
+// The storage is a MinIO server running as an instance, its traffic is shaped by a netem queue set by a sidecar.
+package slows3
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	"github.com/celestiaorg/knuu/pkg/instance"
+	"github.com/celestiaorg/knuu/pkg/system"
+)
+
+const (
+	// DefaultImage is the image of the MinIO server
+	DefaultImage = "docker.io/minio/minio:RELEASE.2024-03-30T09-41-56Z"
+	// DefaultAccessKey and DefaultSecretKey are the credentials of the storage when none are given
+	DefaultAccessKey = "minioadmin"
+	DefaultSecretKey = "minioadmin"
+
+	serverName = "knuu-s3"
+	// Port is the port of the S3 API of the storage
+	Port    = 9000
+	dataDir = "/data"
+
+	// shaperSidecar is the ephemeral sidecar setting the queue of the network interface of the storage
+	shaperSidecar    = "knuu-s3-shaper"
+	networkInterface = "eth0"
+)
+
+// Config is the configuration of the storage, the zero value is a storage with the default image and credentials
+// whose traffic is not shaped
+type Config struct {
+	// Image is the image of the MinIO server, DefaultImage if empty
+	Image string
+	// AccessKey and SecretKey are the credentials of the storage, DefaultAccessKey and DefaultSecretKey if empty
+	AccessKey string
+	SecretKey string
+	// Shaping is the shaping of the traffic of the storage once it is started
+	Shaping Shaping
+}
+
+// Shaping degrades the traffic sent by the storage
+// The latency delays each packet, so it adds to the round trip of all the requests, and the bandwidth limits the
+// throughput of the downloads. The uploads are only slowed down by the latency of their acknowledgements.
+type Shaping struct {
+	// Latency is the delay added to the packets sent by the storage, none if 0
+	Latency time.Duration
+	// Bandwidth is the maximum throughput of the storage in bits per second, unlimited if 0
+	Bandwidth int64
+}
+
+func (s Shaping) validate() error {
+	if s.Latency < 0 {
+		return ErrInvalidShaping.WithParams("the latency must not be negative")
+	}
+	if s.Bandwidth < 0 {
+		return ErrInvalidShaping.WithParams("the bandwidth must not be negative")
+	}
+	return nil
+}
+
+func (s Shaping) isZero() bool {
+	return s.Latency == 0 && s.Bandwidth == 0
+}
+
+// Storage is an S3 compatible object storage running in the cluster
+type Storage struct {
+	instance  *instance.Instance
+	accessKey string
+	secretKey string
+
+	mu       sync.Mutex
+	attached bool
+	shaping  Shaping
+}
+
+// New creates and starts an object storage with the given configuration
+func New(ctx context.Context, sysDeps system.SystemDependencies, config Config) (*Storage, error) {
+	if err := config.Shaping.validate(); err != nil {
+		return nil, err
+	}
+	if config.Image == "" {
+		config.Image = DefaultImage
+	}
+	if config.AccessKey == "" {
+		config.AccessKey = DefaultAccessKey
+	}
+	if config.SecretKey == "" {
+		config.SecretKey = DefaultSecretKey
+	}
+
+	inst, err := instance.New(serverName, sysDeps,
+		instance.WithImage(config.Image),
+		instance.WithCommand("minio", "server", dataDir, "--address", fmt.Sprintf(":%d", Port)),
+		instance.WithPorts(Port),
+		instance.WithEnv(map[string]string{
+			"MINIO_ROOT_USER":     config.AccessKey,
+			"MINIO_ROOT_PASSWORD": config.SecretKey,
+		}),
+		instance.WithProbes(nil, readinessProbe(), nil),
+	)
+	if err != nil {
+		return nil, ErrCreatingStorage.Wrap(err)
+	}
+	if err := inst.Commit(); err != nil {
+		return nil, ErrStartingStorage.WithParams(inst.Name()).Wrap(err)
+	}
+	// the storage is destroyed if it fails to start after its pod was deployed
+	if err := instance.StartAllWithPolicy(ctx, instance.StartFailureDestroy, inst); err != nil {
+		return nil, ErrStartingStorage.WithParams(inst.Name()).Wrap(err)
+	}
+
+	s := &Storage{
+		instance:  inst,
+		accessKey: config.AccessKey,
+		secretKey: config.SecretKey,
+	}
+	if !config.Shaping.isZero() {
+		if err := s.Shape(ctx, config.Shaping); err != nil {
+			if err := inst.Destroy(context.WithoutCancel(ctx)); err != nil {
+				logrus.Warnf("Error destroying the storage '%s': %v", inst.Name(), err)
+			}
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// Instance returns the instance of the MinIO server
+func (s *Storage) Instance() *instance.Instance {
+	return s.instance
+}
+
+// Endpoint returns the endpoint of the S3 API for the instances, e.g. 'http://knuu-s3-1a2b3c4d:9000'
+func (s *Storage) Endpoint() string {
+	return fmt.Sprintf("http://%s:%d", s.instance.HostName(), Port)
+}
+
+// Credentials returns the access key and the secret key of the storage
+func (s *Storage) Credentials() (accessKey, secretKey string) {
+	return s.accessKey, s.secretKey
+}
+
+// Shaping returns the current shaping of the traffic of the storage
+func (s *Storage) Shaping() Shaping {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.shaping
+}
+
+// Shape replaces the shaping of the traffic of the storage, the zero Shaping restores its full speed
+func (s *Storage) Shape(ctx context.Context, shaping Shaping) error {
+	if err := shaping.validate(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.attachShaper(ctx); err != nil {
+		return err
+	}
+	if _, err := s.instance.ExecuteCommandInEphemeralSidecar(ctx, shaperSidecar, shapeCommand(shaping)); err != nil {
+		return ErrShapingStorage.WithParams(s.instance.Name()).Wrap(err)
+	}
+	s.shaping = shaping
+	logrus.Debugf("Shaped the traffic of object storage '%s': latency %s, bandwidth %d bps", s.instance.Name(), shaping.Latency, shaping.Bandwidth)
+	return nil
+}
+
+// Reset restores the full speed of the storage
+func (s *Storage) Reset(ctx context.Context) error {
+	return s.Shape(ctx, Shaping{})
+}
+
+// Destroy destroys the storage and its data
+func (s *Storage) Destroy(ctx context.Context) error {
+	if err := s.instance.Destroy(ctx); err != nil {
+		return ErrDestroyingStorage.WithParams(s.instance.Name()).Wrap(err)
+	}
+	return nil
+}
+
+// attachShaper attaches the sidecar setting the queue, once, as the ephemeral sidecars cannot be removed
+// Unlike BitTwister, which applies one fault at a time, a netem queue combines the latency and the bandwidth.
+func (s *Storage) attachShaper(ctx context.Context) error {
+	if s.attached {
+		return nil
+	}
+	err := s.instance.AddEphemeralSidecar(ctx, instance.EphemeralSidecar{
+		Name:         shaperSidecar,
+		Image:        instance.DefaultDebugImage,
+		Command:      []string{"/bin/sh", "-c", "trap 'exit 0' TERM; while true; do sleep 1; done"},
+		Capabilities: []string{"NET_ADMIN"},
+	})
+	if err != nil {
+		return ErrAttachingShaper.WithParams(s.instance.Name()).Wrap(err)
+	}
+	s.attached = true
+	return nil
+}
+
+// shapeCommand returns the shell command replacing the root queue of the network interface of the pod with one
+// applying the shaping, or removing it if there is no shaping
+func shapeCommand(shaping Shaping) string {
+	if shaping.isZero() {
+		return fmt.Sprintf("{ tc qdisc del dev %s root 2>/dev/null; true; }", networkInterface)
+	}
+	args := []string{"tc", "qdisc", "replace", "dev", networkInterface, "root", "netem"}
+	if shaping.Latency != 0 {
+		args = append(args, "delay", fmt.Sprintf("%dus", shaping.Latency.Microseconds()))
+	}
+	if shaping.Bandwidth != 0 {
+		// tc reads 'bps' as bytes per second, the bandwidth is in bits per second
+		args = append(args, "rate", fmt.Sprintf("%dbit", shaping.Bandwidth))
+	}
+	return strings.Join(args, " ")
+}
+
+// readinessProbe checks that the server is ready to serve the requests
+func readinessProbe() *v1.Probe {
+	return &v1.Probe{
+		ProbeHandler: v1.ProbeHandler{
+			HTTPGet: &v1.HTTPGetAction{
+				Path: "/minio/health/ready",
+				Port: intstr.FromInt32(Port),
+			},
+		},
+		PeriodSeconds: 2,
+	}
+}
@@ -0,0 +1,52 @@
+package slows3
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShapeCommand(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		shaping Shaping
+		want    string
+	}{
+		{
+			name:    "latency and bandwidth",
+			shaping: Shaping{Latency: 150 * time.Millisecond, Bandwidth: 10_000_000},
+			want:    "tc qdisc replace dev eth0 root netem delay 150000us rate 10000000bit",
+		},
+		{
+			name:    "latency only",
+			shaping: Shaping{Latency: 2 * time.Millisecond},
+			want:    "tc qdisc replace dev eth0 root netem delay 2000us",
+		},
+		{
+			name:    "bandwidth only",
+			shaping: Shaping{Bandwidth: 1_000_000},
+			want:    "tc qdisc replace dev eth0 root netem rate 1000000bit",
+		},
+		{
+			name:    "none",
+			shaping: Shaping{},
+			want:    "{ tc qdisc del dev eth0 root 2>/dev/null; true; }",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, shapeCommand(tt.shaping))
+		})
+	}
+}
+
+func TestShapingValidate(t *testing.T) {
+	t.Parallel()
+
+	assert.NoError(t, Shaping{Latency: time.Second, Bandwidth: 1}.validate())
+	assert.ErrorIs(t, Shaping{Latency: -time.Second}.validate(), ErrInvalidShaping)
+	assert.ErrorIs(t, Shaping{Bandwidth: -1}.validate(), ErrInvalidShaping)
+}
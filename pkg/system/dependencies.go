@@ -3,18 +3,57 @@ package system
 import (
 	"github.com/sirupsen/logrus"
 
+	"github.com/celestiaorg/knuu/pkg/annotation"
+	"github.com/celestiaorg/knuu/pkg/artifact"
 	"github.com/celestiaorg/knuu/pkg/builder"
+	"github.com/celestiaorg/knuu/pkg/builder/registry"
+	"github.com/celestiaorg/knuu/pkg/identity"
 	"github.com/celestiaorg/knuu/pkg/k8s"
 	"github.com/celestiaorg/knuu/pkg/minio"
-	"github.com/celestiaorg/knuu/pkg/traefik"
+	"github.com/celestiaorg/knuu/pkg/proxy"
+	"github.com/celestiaorg/knuu/pkg/recording"
+	"github.com/celestiaorg/knuu/pkg/report"
 )
 
 type SystemDependencies struct {
 	ImageBuilder builder.Builder
 	K8sCli       k8s.KubeManager
-	MinioCli     *minio.Minio
+	MinioCli     minio.Client
+	Artifacts    *artifact.Store
 	Logger       *logrus.Logger
-	Proxy        *traefik.Traefik
-	TestScope    string
-	StartTime    string
+	Proxy        proxy.Proxy
+	ImageCache   *ImageCache
+	Reporter     *report.Recorder
+	// Recorder records the operations on the instances so that they can be replayed, nil to not record them
+	Recorder *recording.Recorder
+	// Annotator marks the faults injected in the instances on the timeline of the observability tools, nil to not
+	// mark them
+	Annotator annotation.Annotator
+	TestScope string
+	StartTime string
+
+	// PostBuildHooks check the images built and pushed for the instances, see builder.PostBuildHook
+	PostBuildHooks []builder.PostBuildHook
+	// ImageSigner signs the images built and pushed for the instances, nil to not sign them
+	ImageSigner builder.ImageSigner
+	// ImageVerifier verifies the images set on the instances, nil to not verify them
+	ImageVerifier builder.ImageVerifier
+	// ImageRegistry is the registry the images built for the instances are pushed to, ttl.sh if empty
+	ImageRegistry string
+	// TransientImageRegistry is true if the images pushed to ImageRegistry do not outlive the test, e.g. the
+	// in-cluster registry or the images loaded onto the nodes, so that they are not reused by other tests
+	TransientImageRegistry bool
+	// Registry gets the configuration of the images of the instances, registry.New() if nil
+	Registry *registry.Client
+	// SecurityContextConstraints is the OpenShift SecurityContextConstraints granted to the service accounts
+	// of the instances, e.g. anyuid or privileged, empty if the cluster is not an OpenShift cluster
+	SecurityContextConstraints string
+	// ServiceMesh is the mesh the pods of the instances are injected in, none if empty
+	// The features of knuu relying on network policies are disabled, as the mesh enforces its own policies.
+	ServiceMesh ServiceMesh
+	// CA issues the TLS certificates of the instances with TLS enabled, see Instance.EnableTLS
+	CA *identity.CA
+	// PodSecurity is what the containers of the instances can run with, probed by Knuu.Preflight, nil if not
+	// probed
+	PodSecurity *k8s.PodSecurity
 }
@@ -0,0 +1,13 @@
+package system
+
+import (
+	"github.com/celestiaorg/knuu/pkg/errors"
+)
+
+type Error = errors.Error
+
+var (
+	ErrReadingImageCacheFile = errors.New("ReadingImageCacheFile", "error reading image cache file '%s'")
+	ErrParsingImageCacheFile = errors.New("ParsingImageCacheFile", "error parsing image cache file '%s'")
+	ErrWritingImageCacheFile = errors.New("WritingImageCacheFile", "error writing image cache file '%s'")
+)
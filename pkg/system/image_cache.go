@@ -0,0 +1,235 @@
+package system
+
+import (
+	"container/list"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// DefaultImageCacheSize is the default maximum number of images kept in the image cache
+	DefaultImageCacheSize = 256
+	// DefaultPersistedImageTTL is the default time a persisted image is reused for.
+	// Built images are pushed to ttl.sh with an expiry of 24h, so they are not reused after that.
+	DefaultPersistedImageTTL = 23 * time.Hour
+)
+
+// ImageCache maps the hash of an image build to the name of the pushed image,
+// so that instances with the same build can reuse the image.
+// When the cache is full, the least recently used image is evicted.
+// A cache can be persisted to a file, so that images are reused across processes.
+// It is safe for concurrent use. A nil *ImageCache never holds any image.
+type ImageCache struct {
+	mu      sync.Mutex
+	maxSize int
+	order   *list.List // most recently used at the front
+	entries map[string]*list.Element
+	stats   ImageCacheStats
+
+	// path is the file the cache is persisted to, empty if the cache is not persisted
+	path string
+	// ttl is the time an image is reused for after it is added, zero means forever
+	ttl time.Duration
+}
+
+// ImageCacheStats holds statistics about the usage of an image cache
+type ImageCacheStats struct {
+	Size      int
+	MaxSize   int
+	Hits      uint64
+	Misses    uint64
+	Evictions uint64
+	Expired   uint64
+}
+
+type imageCacheEntry struct {
+	Hash      string    `json:"hash"`
+	ImageName string    `json:"imageName"`
+	CreatedAt time.Time `json:"createdAt"`
+
+	// transient entries are not persisted, see SetTransient
+	transient bool
+}
+
+// NewImageCache creates an image cache holding at most maxSize images.
+// If maxSize is not positive, DefaultImageCacheSize is used.
+func NewImageCache(maxSize int) *ImageCache {
+	if maxSize <= 0 {
+		maxSize = DefaultImageCacheSize
+	}
+	return &ImageCache{
+		maxSize: maxSize,
+		order:   list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+// NewPersistentImageCache creates an image cache that is persisted to the given file.
+// The images in the file that are not expired are loaded, and the file is updated every time an image is added.
+// Images are reused for at most ttl, if ttl is not positive DefaultPersistedImageTTL is used.
+func NewPersistentImageCache(maxSize int, path string, ttl time.Duration) (*ImageCache, error) {
+	if ttl <= 0 {
+		ttl = DefaultPersistedImageTTL
+	}
+	c := NewImageCache(maxSize)
+	c.path = path
+	c.ttl = ttl
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, ErrReadingImageCacheFile.WithParams(path).Wrap(err)
+	}
+
+	var entries []*imageCacheEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, ErrParsingImageCacheFile.WithParams(path).Wrap(err)
+	}
+	// entries are stored from the least to the most recently used
+	for _, e := range entries {
+		if c.expired(e) {
+			continue
+		}
+		c.entries[e.Hash] = c.order.PushFront(e)
+	}
+	c.evict()
+	logrus.Debugf("Loaded %d images from image cache file '%s'", c.order.Len(), path)
+
+	return c, nil
+}
+
+// Get returns the image name for the given image hash, if it is cached
+func (c *ImageCache) Get(hash string) (imageName string, ok bool) {
+	if c == nil {
+		return "", false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[hash]
+	if !ok {
+		c.stats.Misses++
+		return "", false
+	}
+	entry := elem.Value.(*imageCacheEntry)
+	if c.expired(entry) {
+		c.remove(elem)
+		c.stats.Expired++
+		c.stats.Misses++
+		return "", false
+	}
+	c.stats.Hits++
+	c.order.MoveToFront(elem)
+	return entry.ImageName, true
+}
+
+// Set adds or updates the image name for the given image hash
+func (c *ImageCache) Set(hash, imageName string) {
+	c.set(hash, imageName, false)
+}
+
+// SetTransient adds or updates the image name for the given image hash like Set, but the image is only reused by
+// this process: it is never persisted, e.g. the images of a registry that does not outlive the test
+func (c *ImageCache) SetTransient(hash, imageName string) {
+	c.set(hash, imageName, true)
+}
+
+func (c *ImageCache) set(hash, imageName string, transient bool) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry := &imageCacheEntry{Hash: hash, ImageName: imageName, CreatedAt: time.Now(), transient: transient}
+	if elem, ok := c.entries[hash]; ok {
+		elem.Value = entry
+		c.order.MoveToFront(elem)
+	} else {
+		c.entries[hash] = c.order.PushFront(entry)
+		c.evict()
+	}
+
+	// persistence is best effort, a failure only means that the image is not reused by other processes
+	if err := c.save(); err != nil {
+		logrus.Warnf("Failed to persist image cache: %v", err)
+	}
+}
+
+// Stats returns the current statistics of the cache
+func (c *ImageCache) Stats() ImageCacheStats {
+	if c == nil {
+		return ImageCacheStats{}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := c.stats
+	stats.Size = c.order.Len()
+	stats.MaxSize = c.maxSize
+	return stats
+}
+
+func (c *ImageCache) expired(e *imageCacheEntry) bool {
+	return c.ttl > 0 && time.Since(e.CreatedAt) > c.ttl
+}
+
+func (c *ImageCache) remove(elem *list.Element) {
+	c.order.Remove(elem)
+	delete(c.entries, elem.Value.(*imageCacheEntry).Hash)
+}
+
+// evict removes the least recently used images until the cache fits in its maximum size
+func (c *ImageCache) evict() {
+	for c.order.Len() > c.maxSize {
+		c.remove(c.order.Back())
+		c.stats.Evictions++
+	}
+}
+
+// save writes the cache to its file, if it is persisted.
+// The file is replaced atomically, so that concurrent processes never read a partial file.
+func (c *ImageCache) save() error {
+	if c.path == "" {
+		return nil
+	}
+
+	entries := make([]*imageCacheEntry, 0, c.order.Len())
+	for elem := c.order.Back(); elem != nil; elem = elem.Prev() {
+		if entry := elem.Value.(*imageCacheEntry); !entry.transient {
+			entries = append(entries, entry)
+		}
+	}
+	data, err := json.Marshal(entries)
+	if err != nil {
+		return ErrWritingImageCacheFile.WithParams(c.path).Wrap(err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o755); err != nil {
+		return ErrWritingImageCacheFile.WithParams(c.path).Wrap(err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(c.path), filepath.Base(c.path)+".*.tmp")
+	if err != nil {
+		return ErrWritingImageCacheFile.WithParams(c.path).Wrap(err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return ErrWritingImageCacheFile.WithParams(c.path).Wrap(err)
+	}
+	if err := tmp.Close(); err != nil {
+		return ErrWritingImageCacheFile.WithParams(c.path).Wrap(err)
+	}
+	if err := os.Rename(tmp.Name(), c.path); err != nil {
+		return ErrWritingImageCacheFile.WithParams(c.path).Wrap(err)
+	}
+	return nil
+}
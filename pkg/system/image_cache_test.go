@@ -0,0 +1,117 @@
+package system
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImageCacheEviction(t *testing.T) {
+	t.Parallel()
+
+	c := NewImageCache(2)
+	c.Set("a", "image-a")
+	c.Set("b", "image-b")
+
+	// use "a", so that "b" is the least recently used
+	name, ok := c.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, "image-a", name)
+
+	c.Set("c", "image-c")
+
+	_, ok = c.Get("b")
+	assert.False(t, ok, "least recently used image must be evicted")
+	_, ok = c.Get("a")
+	assert.True(t, ok)
+	_, ok = c.Get("c")
+	assert.True(t, ok)
+
+	assert.Equal(t, ImageCacheStats{
+		Size:      2,
+		MaxSize:   2,
+		Hits:      3,
+		Misses:    1,
+		Evictions: 1,
+	}, c.Stats())
+}
+
+func TestImageCacheConcurrent(t *testing.T) {
+	t.Parallel()
+
+	const workers = 50
+
+	c := NewImageCache(workers / 2)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			hash := fmt.Sprintf("hash-%d", w)
+			c.Set(hash, "image")
+			c.Get(hash)
+		}(w)
+	}
+	wg.Wait()
+
+	stats := c.Stats()
+	assert.Equal(t, workers/2, stats.Size)
+	assert.Equal(t, uint64(workers/2), stats.Evictions)
+	assert.Equal(t, uint64(workers), stats.Hits+stats.Misses)
+}
+
+func TestImageCacheNil(t *testing.T) {
+	t.Parallel()
+
+	var c *ImageCache
+	c.Set("a", "image-a")
+	_, ok := c.Get("a")
+	assert.False(t, ok)
+	assert.Equal(t, ImageCacheStats{}, c.Stats())
+}
+
+func TestPersistentImageCache(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "images.json")
+
+	c, err := NewPersistentImageCache(10, path, time.Hour)
+	require.NoError(t, err)
+	c.Set("a", "image-a")
+	c.Set("b", "image-b")
+	c.SetTransient("c", "localhost:30500/image-c:knuu")
+	name, ok := c.Get("c")
+	assert.True(t, ok)
+	assert.Equal(t, "localhost:30500/image-c:knuu", name)
+
+	// a new process loads the images pushed by the previous one
+	reloaded, err := NewPersistentImageCache(10, path, time.Hour)
+	require.NoError(t, err)
+	name, ok = reloaded.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, "image-a", name)
+	assert.Equal(t, 2, reloaded.Stats().Size, "the transient images are not persisted")
+
+	// expired images are not reused
+	expired, err := NewPersistentImageCache(10, path, time.Nanosecond)
+	require.NoError(t, err)
+	_, ok = expired.Get("a")
+	assert.False(t, ok)
+	assert.Equal(t, 0, expired.Stats().Size)
+}
+
+func TestPersistentImageCacheInvalidFile(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "images.json")
+	require.NoError(t, os.WriteFile(path, []byte("not json"), 0o644))
+
+	_, err := NewPersistentImageCache(10, path, time.Hour)
+	assert.Error(t, err)
+}
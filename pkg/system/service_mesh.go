@@ -0,0 +1,24 @@
+package system
+
+// ServiceMesh is the service mesh the pods of the instances are injected in, e.g. to run the tests of a project
+// deployed under a mesh the same way it is deployed
+type ServiceMesh string
+
+const (
+	// ServiceMeshNone does not inject the pods in a mesh
+	ServiceMeshNone ServiceMesh = ""
+	// ServiceMeshIstio injects the pods with the sidecar of Istio
+	ServiceMeshIstio ServiceMesh = "istio"
+	// ServiceMeshLinkerd injects the pods with the proxy of Linkerd
+	ServiceMeshLinkerd ServiceMesh = "linkerd"
+)
+
+// IsValid returns true if the mesh is supported
+func (m ServiceMesh) IsValid() bool {
+	switch m {
+	case ServiceMeshNone, ServiceMeshIstio, ServiceMeshLinkerd:
+		return true
+	default:
+		return false
+	}
+}
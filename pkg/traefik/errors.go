@@ -24,4 +24,10 @@ var (
 	ErrTraefikIngressRouteCreationFailed = errors.New("TraefikIngressRouteCreationFailed", "error creating Traefik ingress route")
 	ErrGeneratingRandomK8sName           = errors.New("GeneratingRandomK8sName", "error generating random K8s name")
 	ErrTraefikFailedToParseQuantity      = errors.New("TraefikFailedToParseQuantity", "error parsing resource quantity")
+	ErrInvalidHostOptions                = errors.New("InvalidHostOptions", "invalid options of host '%s'")
+	ErrGeneratingCertificate             = errors.New("GeneratingCertificate", "error generating the self-signed certificate of the proxy")
+	ErrTraefikSecretCreationFailed       = errors.New("TraefikSecretCreationFailed", "error creating Traefik secret '%s'")
+	ErrNoTCPEntrypointAvailable          = errors.New("NoTCPEntrypointAvailable", "all the %d TCP entrypoints of the proxy are assigned")
+	ErrTraefikTCPRouteCreationFailed     = errors.New("TraefikTCPRouteCreationFailed", "error creating Traefik TCP ingress route '%s'")
+	ErrTraefikTCPRouteDeletionFailed     = errors.New("TraefikTCPRouteDeletionFailed", "error deleting Traefik TCP ingress route '%s'")
 )
@@ -0,0 +1,125 @@
+package traefik
+
+import (
+	"context"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/celestiaorg/knuu/pkg/names"
+	"github.com/celestiaorg/knuu/pkg/proxy"
+)
+
+// selfSignedValidity is the validity of the self-signed certificate of the proxy
+const selfSignedValidity = 7 * 24 * time.Hour
+
+// Certificate returns the PEM encoded self-signed certificate of the proxy, nil until a host uses it
+// Clients of the hosts served with the self-signed certificate add it to their root CAs.
+func (t *Traefik) Certificate() []byte {
+	t.certMu.Lock()
+	defer t.certMu.Unlock()
+	return t.certPEM
+}
+
+// tlsSecret returns the name of the secret of the certificate of the host with the given prefix
+func (t *Traefik) tlsSecret(ctx context.Context, prefix string, config *proxy.TLS) (string, error) {
+	if !config.SelfSigned() {
+		name, err := names.NewRandomK8("tls-" + prefix)
+		if err != nil {
+			return "", ErrGeneratingRandomK8sName.Wrap(err)
+		}
+		err = t.createSecret(ctx, name, v1.SecretTypeTLS, map[string][]byte{
+			v1.TLSCertKey:       config.CertPEM,
+			v1.TLSPrivateKeyKey: config.KeyPEM,
+		})
+		return name, err
+	}
+
+	t.certMu.Lock()
+	defer t.certMu.Unlock()
+
+	if t.certName != "" {
+		return t.certName, nil
+	}
+	host, err := t.host(ctx)
+	if err != nil {
+		return "", err
+	}
+	certPEM, keyPEM, err := proxy.SelfSignedCertificate([]string{host}, selfSignedValidity)
+	if err != nil {
+		return "", ErrGeneratingCertificate.Wrap(err)
+	}
+	name, err := names.NewRandomK8("traefik-tls")
+	if err != nil {
+		return "", ErrGeneratingRandomK8sName.Wrap(err)
+	}
+	err = t.createSecret(ctx, name, v1.SecretTypeTLS, map[string][]byte{
+		v1.TLSCertKey:       certPEM,
+		v1.TLSPrivateKeyKey: keyPEM,
+	})
+	if err != nil {
+		return "", err
+	}
+	t.certPEM, t.certName = certPEM, name
+	return name, nil
+}
+
+// createBasicAuthMiddleware creates a middleware checking the given credentials and returns its name
+func (t *Traefik) createBasicAuthMiddleware(ctx context.Context, prefix string, auth *proxy.BasicAuth) (string, error) {
+	name, err := names.NewRandomK8("auth-" + prefix)
+	if err != nil {
+		return "", ErrGeneratingRandomK8sName.Wrap(err)
+	}
+	err = t.createSecret(ctx, name, v1.SecretTypeBasicAuth, map[string][]byte{
+		v1.BasicAuthUsernameKey: []byte(auth.Username),
+		v1.BasicAuthPasswordKey: []byte(auth.Password),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	middleware := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": traefikAPIGroupVersion,
+			"kind":       "Middleware",
+			"metadata": map[string]interface{}{
+				"name":      name,
+				"namespace": t.K8s.Namespace(),
+			},
+			"spec": map[string]interface{}{
+				"basicAuth": map[string]interface{}{
+					"secret": name,
+				},
+			},
+		},
+	}
+	middlewareResource := schema.GroupVersionResource{
+		Group:    "traefik.io",
+		Version:  "v1alpha1",
+		Resource: "middlewares",
+	}
+	_, err = t.K8s.DynamicClient().Resource(middlewareResource).Namespace(t.K8s.Namespace()).Create(ctx, middleware, metav1.CreateOptions{})
+	if err != nil {
+		return "", ErrTraefikMiddlewareCreationFailed.Wrap(err)
+	}
+	return name, nil
+}
+
+func (t *Traefik) createSecret(ctx context.Context, name string, secretType v1.SecretType, data map[string][]byte) error {
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: t.K8s.Namespace(),
+			Labels:    map[string]string{appLabel: appLabelValue},
+		},
+		Type: secretType,
+		Data: data,
+	}
+	if _, err := t.K8s.Clientset().CoreV1().Secrets(t.K8s.Namespace()).Create(ctx, secret, metav1.CreateOptions{}); err != nil {
+		return ErrTraefikSecretCreationFailed.WithParams(name).Wrap(err)
+	}
+	return nil
+}
@@ -0,0 +1,177 @@
+package traefik
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	v1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	"github.com/celestiaorg/knuu/pkg/names"
+)
+
+const (
+	// TCPPort is the first of the TCPEntrypoints ports of the proxy, each of them serves one TCP host
+	TCPPort        = 9000
+	TCPEntrypoints = 10
+)
+
+var ingressRouteTCPGVR = schema.GroupVersionResource{
+	Group:    "traefik.io",
+	Version:  "v1alpha1",
+	Resource: "ingressroutetcps",
+}
+
+// tcpHost is the route forwarding the connections of a TCP entrypoint to a service
+type tcpHost struct {
+	route   string
+	service string
+}
+
+// AddTCPHost forwards the connections to the next free TCP entrypoint of the proxy to the given port of the service
+func (t *Traefik) AddTCPHost(ctx context.Context, serviceName, name string, port int) (string, error) {
+	if t.K8s == nil {
+		return "", ErrTraefikClientNotInitialized
+	}
+
+	t.tcpMu.Lock()
+	defer t.tcpMu.Unlock()
+
+	entrypoint, ok := freeTCPEntrypoint(t.tcpHosts)
+	if !ok {
+		return "", ErrNoTCPEntrypointAvailable.WithParams(TCPEntrypoints)
+	}
+	host, err := t.host(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	routeName, err := names.NewRandomK8("tcp-route-" + name)
+	if err != nil {
+		return "", ErrGeneratingRandomK8sName.Wrap(err)
+	}
+	_, err = t.K8s.DynamicClient().Resource(ingressRouteTCPGVR).Namespace(t.K8s.Namespace()).
+		Create(ctx, ingressRouteTCP(routeName, t.K8s.Namespace(), entrypoint, serviceName, port), metav1.CreateOptions{})
+	if err != nil {
+		return "", ErrTraefikTCPRouteCreationFailed.WithParams(routeName).Wrap(err)
+	}
+
+	if t.tcpHosts == nil {
+		t.tcpHosts = make(map[int]tcpHost)
+	}
+	t.tcpHosts[entrypoint] = tcpHost{route: routeName, service: serviceName}
+	return net.JoinHostPort(host, fmt.Sprint(TCPPort+entrypoint)), nil
+}
+
+// RemoveTCPHosts deletes the routes of the TCP hosts of the service and releases their entrypoints
+func (t *Traefik) RemoveTCPHosts(ctx context.Context, serviceName string) error {
+	if t.K8s == nil {
+		return ErrTraefikClientNotInitialized
+	}
+
+	t.tcpMu.Lock()
+	defer t.tcpMu.Unlock()
+
+	for entrypoint, h := range t.tcpHosts {
+		if h.service != serviceName {
+			continue
+		}
+		err := t.K8s.DynamicClient().Resource(ingressRouteTCPGVR).Namespace(t.K8s.Namespace()).
+			Delete(ctx, h.route, metav1.DeleteOptions{})
+		if err != nil && !apierrs.IsNotFound(err) {
+			return ErrTraefikTCPRouteDeletionFailed.WithParams(h.route).Wrap(err)
+		}
+		delete(t.tcpHosts, entrypoint)
+	}
+	return nil
+}
+
+// freeTCPEntrypoint returns the first TCP entrypoint that is not assigned
+func freeTCPEntrypoint(assigned map[int]tcpHost) (int, bool) {
+	for e := 0; e < TCPEntrypoints; e++ {
+		if _, ok := assigned[e]; !ok {
+			return e, true
+		}
+	}
+	return 0, false
+}
+
+// ingressRouteTCP returns the route forwarding any connection of the given entrypoint to the port of the service
+func ingressRouteTCP(name, namespace string, entrypoint int, serviceName string, port int) *unstructured.Unstructured {
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": traefikAPIGroupVersion,
+			"kind":       "IngressRouteTCP",
+			"metadata": map[string]interface{}{
+				"name":      name,
+				"namespace": namespace,
+			},
+			"spec": map[string]interface{}{
+				"entryPoints": []interface{}{tcpEntrypointName(entrypoint)},
+				"routes": []interface{}{
+					map[string]interface{}{
+						// the connections without TLS have no SNI, so only the catch-all rule matches them
+						"match": "HostSNI(`*`)",
+						"services": []interface{}{
+							map[string]interface{}{
+								"name": serviceName,
+								"port": int64(port),
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func tcpEntrypointName(entrypoint int) string {
+	return fmt.Sprintf("tcp%d", entrypoint)
+}
+
+// args returns the arguments of traefik, they define its entrypoints
+// The read timeout of the HTTP entrypoints is disabled so that WebSocket and streaming connections stay open.
+func args() []string {
+	args := []string{
+		"--api.insecure=true",
+		"--providers.kubernetesIngress",
+		"--providers.kubernetesCRD",
+		fmt.Sprintf("--entrypoints.web.Address=:%d", Port),
+		fmt.Sprintf("--entrypoints.websecure.Address=:%d", PortSecure),
+		"--entrypoints.web.transport.respondingTimeouts.readTimeout=0",
+		"--entrypoints.websecure.transport.respondingTimeouts.readTimeout=0",
+	}
+	for e := 0; e < TCPEntrypoints; e++ {
+		args = append(args, fmt.Sprintf("--entrypoints.%s.Address=:%d/tcp", tcpEntrypointName(e), TCPPort+e))
+	}
+	return args
+}
+
+func containerPorts() []v1.ContainerPort {
+	ports := []v1.ContainerPort{
+		{ContainerPort: Port, Name: "web"},
+		{ContainerPort: PortSecure, Name: "websecure"},
+	}
+	for e := 0; e < TCPEntrypoints; e++ {
+		ports = append(ports, v1.ContainerPort{ContainerPort: int32(TCPPort + e), Name: tcpEntrypointName(e)})
+	}
+	return ports
+}
+
+func servicePorts() []v1.ServicePort {
+	ports := make([]v1.ServicePort, 0, len(containerPorts()))
+	for _, p := range containerPorts() {
+		ports = append(ports, v1.ServicePort{
+			Name:       p.Name,
+			Protocol:   v1.ProtocolTCP,
+			Port:       p.ContainerPort,
+			TargetPort: intstr.FromInt32(p.ContainerPort),
+		})
+	}
+	return ports
+}
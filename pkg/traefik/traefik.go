@@ -3,6 +3,8 @@ package traefik
 import (
 	"context"
 	"fmt"
+	"net"
+	"sync"
 	"time"
 
 	"github.com/sirupsen/logrus"
@@ -13,11 +15,11 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
-	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/utils/ptr"
 
 	"github.com/celestiaorg/knuu/pkg/k8s"
 	"github.com/celestiaorg/knuu/pkg/names"
+	"github.com/celestiaorg/knuu/pkg/proxy"
 )
 
 const (
@@ -43,8 +45,19 @@ const (
 type Traefik struct {
 	K8s      k8s.KubeManager
 	endpoint string
+
+	// the self-signed certificate is created with the first host that needs it
+	certMu   sync.Mutex
+	certPEM  []byte
+	certName string
+
+	// tcpMu guards the TCP entrypoints assigned to hosts, by entrypoint
+	tcpMu    sync.Mutex
+	tcpHosts map[int]tcpHost
 }
 
+var _ proxy.Proxy = &Traefik{}
+
 func (t *Traefik) Deploy(ctx context.Context) error {
 	if t.K8s == nil {
 		return ErrTraefikClientNotInitialized
@@ -134,17 +147,8 @@ func (t *Traefik) Deploy(ctx context.Context) error {
 						{
 							Name:  containerName,
 							Image: image,
-							Args: []string{
-								"--api.insecure=true",
-								"--providers.kubernetesIngress",
-								"--providers.kubernetesCRD",
-								fmt.Sprintf("--entrypoints.web.Address=:%d", Port),
-								fmt.Sprintf("--entrypoints.websecure.Address=:%d", PortSecure),
-							},
-							Ports: []v1.ContainerPort{
-								{ContainerPort: Port, Name: "web"},
-								{ContainerPort: PortSecure, Name: "websecure"},
-							},
+							Args:  args(),
+							Ports: containerPorts(),
 							Resources: v1.ResourceRequirements{
 								Requests: v1.ResourceList{
 									v1.ResourceCPU:    cpuReq,
@@ -199,6 +203,30 @@ func (t *Traefik) URL(ctx context.Context, prefix string) (string, error) {
 	return fmt.Sprintf("http://%s/%s", t.endpoint, prefix), nil
 }
 
+// SecureURL returns the URL of the host with the given prefix served over HTTPS
+func (t *Traefik) SecureURL(ctx context.Context, prefix string) (string, error) {
+	host, err := t.host(ctx)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("https://%s/%s", net.JoinHostPort(host, fmt.Sprint(PortSecure)), prefix), nil
+}
+
+// host returns the IP or the name of the endpoint of the proxy
+func (t *Traefik) host(ctx context.Context) (string, error) {
+	if t.endpoint == "" {
+		var err error
+		if t.endpoint, err = t.Endpoint(ctx); err != nil {
+			return "", ErrTraefikIPNotFound.Wrap(err)
+		}
+	}
+	host, _, err := net.SplitHostPort(t.endpoint)
+	if err != nil {
+		return "", ErrTraefikIPNotFound.Wrap(err)
+	}
+	return host, nil
+}
+
 func (t *Traefik) Endpoint(ctx context.Context) (string, error) {
 	if t.K8s == nil {
 		return "", ErrTraefikClientNotInitialized
@@ -206,7 +234,15 @@ func (t *Traefik) Endpoint(ctx context.Context) (string, error) {
 	return t.K8s.GetServiceEndpoint(ctx, traefikServiceName)
 }
 
-func (t *Traefik) AddHost(ctx context.Context, serviceName, prefix string, portTCP int) error {
+// AddHost exposes the given port of the service under the given path prefix
+// By default the host is served over plain HTTP without authentication, see the proxy options.
+// Hosts with TLS are served on the secure entrypoint, see SecureURL.
+func (t *Traefik) AddHost(ctx context.Context, serviceName, prefix string, portTCP int, opts ...proxy.HostOption) error {
+	options := proxy.NewHostOptions(opts...)
+	if err := options.Validate(); err != nil {
+		return ErrInvalidHostOptions.WithParams(prefix).Wrap(err)
+	}
+
 	middlewareName, err := names.NewRandomK8("strip-" + prefix)
 	if err != nil {
 		return ErrGeneratingRandomK8sName.Wrap(err)
@@ -217,7 +253,27 @@ func (t *Traefik) AddHost(ctx context.Context, serviceName, prefix string, portT
 		return err
 	}
 
-	return t.createIngressRoute(ctx, serviceName, prefix, middlewareName, portTCP)
+	route := ingressRoute{
+		serviceName: serviceName,
+		prefix:      prefix,
+		port:        portTCP,
+		middlewares: []string{middlewareName},
+		bearerToken: options.BearerToken,
+		grpc:        options.GRPC,
+	}
+	if options.BasicAuth != nil {
+		authMiddleware, err := t.createBasicAuthMiddleware(ctx, prefix, options.BasicAuth)
+		if err != nil {
+			return err
+		}
+		route.middlewares = append(route.middlewares, authMiddleware)
+	}
+	if options.TLS != nil {
+		if route.tlsSecret, err = t.tlsSecret(ctx, prefix, options.TLS); err != nil {
+			return err
+		}
+	}
+	return t.createIngressRoute(ctx, route)
 }
 
 // TODO: need to update the k8s pkg to handle service creation in more custom way
@@ -232,21 +288,8 @@ func (t *Traefik) createService(ctx context.Context) error {
 		},
 		Spec: v1.ServiceSpec{
 			Selector: map[string]string{appLabel: appLabelValue},
-			Ports: []v1.ServicePort{
-				{
-					Name:       "web",
-					Protocol:   v1.ProtocolTCP,
-					Port:       Port,
-					TargetPort: intstr.FromInt(Port),
-				},
-				{
-					Name:       "websecure",
-					Protocol:   v1.ProtocolTCP,
-					Port:       PortSecure,
-					TargetPort: intstr.FromInt(PortSecure),
-				},
-			},
-			Type: v1.ServiceTypeLoadBalancer,
+			Ports:    servicePorts(),
+			Type:     v1.ServiceTypeLoadBalancer,
 		},
 	}
 
@@ -288,23 +331,78 @@ func (t *Traefik) createMiddleware(ctx context.Context, serviceName, middlewareN
 	return nil
 }
 
-func (t *Traefik) createIngressRoute(
-	ctx context.Context,
-	serviceName, prefix string,
-	middlewareName string,
-	port int,
-) error {
+// ingressRoute is a route of the proxy to a port of a service
+type ingressRoute struct {
+	serviceName string
+	prefix      string
+	port        int
+	middlewares []string
+	// tlsSecret is the secret of the certificate of the route, the route is served over plain HTTP if empty
+	tlsSecret string
+	// bearerToken is the token the requests must have to match the route, any request matches if empty
+	bearerToken string
+	// grpc forwards the requests to the service with HTTP/2 over cleartext
+	grpc bool
+}
+
+// service returns the service the requests of the route are forwarded to
+func (r ingressRoute) service() map[string]interface{} {
+	service := map[string]interface{}{
+		"name": r.serviceName,
+		"port": r.port,
+	}
+	if r.grpc {
+		service["scheme"] = "h2c"
+	}
+	return service
+}
+
+// rule returns the rule matching the requests of the route
+// The requests without the bearer token do not match any route, so they are answered with 404.
+func (r ingressRoute) rule() string {
+	rule := fmt.Sprintf("PathPrefix(`/%s`)", r.prefix)
+	if r.bearerToken != "" {
+		rule += fmt.Sprintf(" && Header(`Authorization`, `Bearer %s`)", r.bearerToken)
+	}
+	return rule
+}
+
+func (t *Traefik) createIngressRoute(ctx context.Context, route ingressRoute) error {
 	ingressRouteGVR := schema.GroupVersionResource{
 		Group:    "traefik.io",
 		Version:  "v1alpha1",
 		Resource: "ingressroutes",
 	}
 
-	ingressRouteName, err := names.NewRandomK8("ing-route-" + prefix)
+	ingressRouteName, err := names.NewRandomK8("ing-route-" + route.prefix)
 	if err != nil {
 		return ErrTraefikIngressRouteCreationFailed.Wrap(err)
 	}
 
+	middlewares := make([]interface{}, 0, len(route.middlewares))
+	for _, name := range route.middlewares {
+		middlewares = append(middlewares, map[string]interface{}{
+			"name": name,
+		})
+	}
+	spec := map[string]interface{}{
+		"entryPoints": []string{"web"},
+		"routes": []interface{}{
+			map[string]interface{}{
+				"match":       route.rule(),
+				"kind":        "Rule",
+				"services":    []interface{}{route.service()},
+				"middlewares": middlewares,
+			},
+		},
+	}
+	if route.tlsSecret != "" {
+		spec["entryPoints"] = []string{"websecure"}
+		spec["tls"] = map[string]interface{}{
+			"secretName": route.tlsSecret,
+		}
+	}
+
 	ingressRoute := &unstructured.Unstructured{
 		Object: map[string]interface{}{
 			"apiVersion": "traefik.io/v1alpha1",
@@ -313,26 +411,7 @@ func (t *Traefik) createIngressRoute(
 				"name":      ingressRouteName,
 				"namespace": t.K8s.Namespace(),
 			},
-			"spec": map[string]interface{}{
-				"entryPoints": []string{"web"},
-				"routes": []interface{}{
-					map[string]interface{}{
-						"match": fmt.Sprintf("PathPrefix(`/%s`)", prefix),
-						"kind":  "Rule",
-						"services": []interface{}{
-							map[string]interface{}{
-								"name": serviceName,
-								"port": port,
-							},
-						},
-						"middlewares": []interface{}{
-							map[string]interface{}{
-								"name": middlewareName,
-							},
-						},
-					},
-				},
-			},
+			"spec": spec,
 		},
 	}
 
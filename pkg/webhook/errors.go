@@ -0,0 +1,17 @@
+package webhook
+
+import (
+	"github.com/celestiaorg/knuu/pkg/errors"
+)
+
+type Error = errors.Error
+
+var (
+	ErrInvalidConfig          = errors.New("InvalidConfig", "invalid webhook configuration: %s")
+	ErrPreparingWebhook       = errors.New("PreparingWebhook", "error preparing the webhook instance '%s'")
+	ErrStartingWebhook        = errors.New("StartingWebhook", "error starting the webhook instance '%s'")
+	ErrRegisteringWebhook     = errors.New("RegisteringWebhook", "error registering the webhook of instance '%s'")
+	ErrUnregisteringWebhook   = errors.New("UnregisteringWebhook", "error unregistering the webhook of instance '%s'")
+	ErrCANotSet               = errors.New("CANotSet", "no CA is set to issue the certificate of the webhook '%s'")
+	ErrInstanceAlreadyStarted = errors.New("InstanceAlreadyStarted", "the webhook instance '%s' must not be started, it is started by Deploy")
+)
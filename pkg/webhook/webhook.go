@@ -0,0 +1,251 @@
+// Package webhook deploys an admission webhook running in an instance and registers it with the API server, so
+// that the resources created in the namespace of the test go through it, e.g. to test the webhook of a controller
+// alongside the other instances of the test.
+// The certificate of the webhook is issued by the CA of the scope and mounted in identity.DefaultDir, see
+// Instance.EnableTLS, so the webhook serves TLS with identity.CertFile and identity.KeyFile.
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	admissionv1 "k8s.io/api/admissionregistration/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/celestiaorg/knuu/pkg/instance"
+)
+
+const (
+	// DefaultPort is the port the webhook serves TLS on when none is given
+	DefaultPort = 8443
+	// DefaultPath is the path of the webhook when none is given
+	DefaultPath = "/validate"
+	// DefaultTimeout is the time the API server waits for the webhook when none is given
+	DefaultTimeout = 10 * time.Second
+
+	// namespaceNameLabel is set by the API server on all the namespaces to their name
+	namespaceNameLabel = "kubernetes.io/metadata.name"
+	// scopeLabel, nameLabel and k8sNameLabel are set by knuu on the resources of the instances
+	scopeLabel   = "knuu.sh/scope"
+	nameLabel    = "knuu.sh/name"
+	k8sNameLabel = "knuu.sh/k8s-name"
+)
+
+// Config describes how the API server calls the webhook
+type Config struct {
+	// Port is the port the webhook serves TLS on, DefaultPort if 0, it is added to the instance
+	Port int
+	// Path is the path of the webhook, DefaultPath if empty
+	Path string
+	// Rules are the operations on the resources sent to the webhook, at least one is required
+	Rules []admissionv1.RuleWithOperations
+	// FailurePolicy is what the API server does when the webhook cannot be called, Fail if empty
+	FailurePolicy admissionv1.FailurePolicyType
+	// ObjectSelector restricts the resources sent to the webhook by their labels, e.g. to the resources created by
+	// the test, all the resources of the namespace if nil
+	// The resources of the webhook instance itself are never sent to it, e.g. its pod when it is restarted.
+	ObjectSelector *metav1.LabelSelector
+	// Timeout is the time the API server waits for the webhook, DefaultTimeout if 0, at most 30 seconds
+	Timeout time.Duration
+}
+
+func (c *Config) setDefaults() {
+	if c.Port == 0 {
+		c.Port = DefaultPort
+	}
+	if c.Path == "" {
+		c.Path = DefaultPath
+	}
+	if c.FailurePolicy == "" {
+		c.FailurePolicy = admissionv1.Fail
+	}
+	if c.Timeout == 0 {
+		c.Timeout = DefaultTimeout
+	}
+}
+
+func (c *Config) validate() error {
+	if len(c.Rules) == 0 {
+		return ErrInvalidConfig.WithParams("no rules")
+	}
+	if c.Port < 1 || c.Port > 65535 {
+		return ErrInvalidConfig.WithParams(fmt.Sprintf("invalid port %d", c.Port))
+	}
+	if c.Timeout < time.Second || c.Timeout > 30*time.Second {
+		return ErrInvalidConfig.WithParams(fmt.Sprintf("the timeout %s is not between 1 and 30 seconds", c.Timeout))
+	}
+	return nil
+}
+
+// Webhook is an admission webhook registered with the API server
+type Webhook struct {
+	instance *instance.Instance
+	name     string
+	webhooks []admissionv1.ValidatingWebhook
+
+	mu sync.Mutex
+	// unregistered is set by Unregister, the webhook is not registered again when the instance is restarted
+	unregistered bool
+}
+
+// Deploy enables TLS on the instance, starts it and registers it as a validating admission webhook of the
+// resources of the namespace of the test
+// Only the resources of the namespace are sent to the webhook, so a webhook left registered by a failed test does
+// not affect the rest of the cluster, and its configuration is deleted with the namespace, see Knuu.CleanUp.
+// The webhook is unregistered when the instance is stopped or destroyed, so that the API server does not call it
+// while it is down, and registered again when the instance is restarted.
+// The instance must be in the state 'Committed'
+func Deploy(ctx context.Context, inst *instance.Instance, config Config) (*Webhook, error) {
+	config.setDefaults()
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+	if inst.IsInState(instance.Started) {
+		return nil, ErrInstanceAlreadyStarted.WithParams(inst.Name())
+	}
+	if inst.CA == nil {
+		return nil, ErrCANotSet.WithParams(inst.Name())
+	}
+
+	namespace := inst.K8sCli.Namespace()
+	// the API server calls the webhook with the name of its service in the namespace
+	serviceHost := fmt.Sprintf("%s.%s.svc", inst.HostName(), namespace)
+	if err := inst.EnableTLS(serviceHost, serviceHost+".cluster.local"); err != nil {
+		return nil, ErrPreparingWebhook.WithParams(inst.Name()).Wrap(err)
+	}
+	if err := inst.AddPortTCP(config.Port); err != nil {
+		return nil, ErrPreparingWebhook.WithParams(inst.Name()).Wrap(err)
+	}
+
+	name := configurationName(namespace, inst.HostName())
+	w := &Webhook{
+		instance: inst,
+		name:     name,
+		webhooks: []admissionv1.ValidatingWebhook{validatingWebhook(name, namespace, inst, config)},
+	}
+	hooks := []struct {
+		event instance.LifecycleEvent
+		hook  instance.LifecycleHook
+	}{
+		{instance.AfterStart, w.registerHook},
+		{instance.BeforeStop, w.unregisterHook},
+		{instance.BeforeDestroy, w.unregisterHook},
+	}
+	for _, h := range hooks {
+		if err := inst.AddLifecycleHook(h.event, h.hook); err != nil {
+			return nil, ErrPreparingWebhook.WithParams(inst.Name()).Wrap(err)
+		}
+	}
+	if err := inst.Start(ctx); err != nil {
+		return nil, ErrStartingWebhook.WithParams(inst.Name()).Wrap(err)
+	}
+	return w, nil
+}
+
+// Instance returns the instance of the webhook
+func (w *Webhook) Instance() *instance.Instance {
+	return w.instance
+}
+
+// Name returns the name of the ValidatingWebhookConfiguration of the webhook
+func (w *Webhook) Name() string {
+	return w.name
+}
+
+// Unregister removes the webhook from the API server, the instance keeps running
+func (w *Webhook) Unregister(ctx context.Context) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.unregistered = true
+	return w.unregister(ctx)
+}
+
+// registerHook registers the webhook when its instance is started, unless it was unregistered with Unregister
+// The hooks are inherited by the clones of the instance, which are not registered.
+func (w *Webhook) registerHook(ctx context.Context, inst *instance.Instance) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if inst != w.instance || w.unregistered {
+		return nil
+	}
+	labels := map[string]string{scopeLabel: inst.TestScope, nameLabel: inst.Name()}
+	if _, err := inst.K8sCli.CreateValidatingWebhookConfiguration(ctx, w.name, labels, w.webhooks); err != nil {
+		return ErrRegisteringWebhook.WithParams(inst.Name()).Wrap(err)
+	}
+	logrus.Debugf("Registered webhook '%s' of instance '%s'", w.name, inst.Name())
+	return nil
+}
+
+// unregisterHook unregisters the webhook before its instance is stopped or destroyed
+func (w *Webhook) unregisterHook(ctx context.Context, inst *instance.Instance) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if inst != w.instance {
+		return nil
+	}
+	return w.unregister(ctx)
+}
+
+// unregister deletes the configuration of the webhook, if it is registered
+func (w *Webhook) unregister(ctx context.Context) error {
+	labels := map[string]string{scopeLabel: w.instance.TestScope, nameLabel: w.instance.Name()}
+	if err := w.instance.K8sCli.DeleteValidatingWebhookConfigurations(ctx, labels); err != nil {
+		return ErrUnregisteringWebhook.WithParams(w.instance.Name()).Wrap(err)
+	}
+	logrus.Debugf("Unregistered webhook '%s' of instance '%s'", w.name, w.instance.Name())
+	return nil
+}
+
+// configurationName returns the name of the configuration of the webhook, unique in the cluster as the
+// configurations are cluster scoped
+func configurationName(namespace, service string) string {
+	return namespace + "-" + service
+}
+
+// validatingWebhook returns the webhook calling the service of the instance for the resources of the namespace,
+// except the ones of the instance
+func validatingWebhook(name, namespace string, inst *instance.Instance, config Config) admissionv1.ValidatingWebhook {
+	objectSelector := &metav1.LabelSelector{}
+	if config.ObjectSelector != nil {
+		objectSelector = config.ObjectSelector.DeepCopy()
+	}
+	objectSelector.MatchExpressions = append(objectSelector.MatchExpressions, metav1.LabelSelectorRequirement{
+		Key:      k8sNameLabel,
+		Operator: metav1.LabelSelectorOpNotIn,
+		Values:   []string{inst.HostName()},
+	})
+
+	port := int32(config.Port)
+	path := config.Path
+	failurePolicy := config.FailurePolicy
+	sideEffects := admissionv1.SideEffectClassNone
+	timeout := int32(config.Timeout / time.Second)
+	return admissionv1.ValidatingWebhook{
+		// the name of a webhook must be a fully qualified domain name
+		Name: name + ".knuu.sh",
+		ClientConfig: admissionv1.WebhookClientConfig{
+			Service: &admissionv1.ServiceReference{
+				Namespace: namespace,
+				Name:      inst.HostName(),
+				Path:      &path,
+				Port:      &port,
+			},
+			CABundle: inst.CA.CertPEM(),
+		},
+		Rules:         config.Rules,
+		FailurePolicy: &failurePolicy,
+		NamespaceSelector: &metav1.LabelSelector{
+			MatchLabels: map[string]string{namespaceNameLabel: namespace},
+		},
+		ObjectSelector:          objectSelector,
+		SideEffects:             &sideEffects,
+		TimeoutSeconds:          &timeout,
+		AdmissionReviewVersions: []string{"v1"},
+	}
+}
@@ -0,0 +1,159 @@
+package webhook
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	admissionv1 "k8s.io/api/admissionregistration/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/celestiaorg/knuu/pkg/identity"
+	"github.com/celestiaorg/knuu/pkg/instance"
+	"github.com/celestiaorg/knuu/pkg/k8s/fake"
+	"github.com/celestiaorg/knuu/pkg/system"
+)
+
+var podRules = []admissionv1.RuleWithOperations{{
+	Operations: []admissionv1.OperationType{admissionv1.Create},
+	Rule: admissionv1.Rule{
+		APIGroups:   []string{""},
+		APIVersions: []string{"v1"},
+		Resources:   []string{"pods"},
+	},
+}}
+
+func TestConfigValidate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		config  Config
+		wantErr bool
+	}{
+		{name: "defaults", config: Config{Rules: podRules}},
+		{name: "no rules", config: Config{}, wantErr: true},
+		{name: "invalid port", config: Config{Rules: podRules, Port: 70000}, wantErr: true},
+		{name: "timeout too long", config: Config{Rules: podRules, Timeout: time.Minute}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.config.setDefaults()
+			err := tt.config.validate()
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidConfig)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestValidatingWebhook(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	k8sCli, err := fake.New(ctx, "test")
+	require.NoError(t, err)
+	ca, err := identity.NewCA("knuu-test")
+	require.NoError(t, err)
+	inst, err := instance.New("controller", system.SystemDependencies{K8sCli: k8sCli, CA: ca}, instance.WithImage("alpine"))
+	require.NoError(t, err)
+
+	selector := &metav1.LabelSelector{MatchLabels: map[string]string{"app": "test"}}
+	config := Config{Rules: podRules, FailurePolicy: admissionv1.Ignore, ObjectSelector: selector}
+	config.setDefaults()
+	name := configurationName("test", inst.HostName())
+	webhook := validatingWebhook(name, "test", inst, config)
+
+	assert.Equal(t, name+".knuu.sh", webhook.Name)
+	require.NotNil(t, webhook.ClientConfig.Service)
+	assert.Equal(t, inst.HostName(), webhook.ClientConfig.Service.Name)
+	assert.Equal(t, int32(DefaultPort), *webhook.ClientConfig.Service.Port)
+	assert.Equal(t, DefaultPath, *webhook.ClientConfig.Service.Path)
+	assert.Equal(t, ca.CertPEM(), webhook.ClientConfig.CABundle)
+	assert.Equal(t, admissionv1.Ignore, *webhook.FailurePolicy)
+	assert.Equal(t, int32(10), *webhook.TimeoutSeconds)
+	assert.Equal(t, map[string]string{namespaceNameLabel: "test"}, webhook.NamespaceSelector.MatchLabels,
+		"only the resources of the namespace of the test go through the webhook")
+	// the webhook does not intercept its own pod
+	assert.Equal(t, map[string]string{"app": "test"}, webhook.ObjectSelector.MatchLabels)
+	assert.Equal(t, []metav1.LabelSelectorRequirement{{Key: k8sNameLabel, Operator: metav1.LabelSelectorOpNotIn, Values: []string{inst.HostName()}}},
+		webhook.ObjectSelector.MatchExpressions)
+	assert.Empty(t, selector.MatchExpressions, "the selector of the configuration is not modified")
+
+	_, err = k8sCli.CreateValidatingWebhookConfiguration(ctx, name, nil, []admissionv1.ValidatingWebhook{webhook})
+	require.NoError(t, err)
+	created, err := k8sCli.FakeClientset.AdmissionregistrationV1().ValidatingWebhookConfigurations().Get(ctx, name, metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Len(t, created.Webhooks, 1)
+	// the configuration is garbage collected with the namespace
+	require.Len(t, created.OwnerReferences, 1)
+	assert.Equal(t, "Namespace", created.OwnerReferences[0].Kind)
+	assert.Equal(t, "test", created.OwnerReferences[0].Name)
+	require.NoError(t, k8sCli.DeleteValidatingWebhookConfiguration(ctx, name))
+}
+
+func TestWebhookLifecycle(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	k8sCli, err := fake.New(ctx, "test")
+	require.NoError(t, err)
+	ca, err := identity.NewCA("knuu-test")
+	require.NoError(t, err)
+	inst, err := instance.New("controller", system.SystemDependencies{K8sCli: k8sCli, CA: ca, TestScope: "test"}, instance.WithImage("alpine"))
+	require.NoError(t, err)
+	require.NoError(t, inst.Commit())
+	config := Config{Rules: podRules}
+	config.setDefaults()
+	name := configurationName("test", inst.HostName())
+	w := &Webhook{instance: inst, name: name, webhooks: []admissionv1.ValidatingWebhook{validatingWebhook(name, "test", inst, config)}}
+	configs := k8sCli.FakeClientset.AdmissionregistrationV1().ValidatingWebhookConfigurations()
+	registered := func() bool {
+		_, err := configs.Get(ctx, name, metav1.GetOptions{})
+		return err == nil
+	}
+
+	// the instance is started
+	require.NoError(t, w.registerHook(ctx, inst))
+	assert.True(t, registered())
+	// the hooks are inherited by the clones of the instance
+	clone, err := inst.CloneWithName("controller-clone")
+	require.NoError(t, err)
+	require.NoError(t, w.unregisterHook(ctx, clone))
+	assert.True(t, registered())
+	// the instance is stopped, then destroyed
+	require.NoError(t, w.unregisterHook(ctx, inst))
+	assert.False(t, registered())
+	require.NoError(t, w.unregisterHook(ctx, inst))
+
+	// the instance is restarted
+	require.NoError(t, w.registerHook(ctx, inst))
+	require.NoError(t, w.Unregister(ctx))
+	assert.False(t, registered())
+	require.NoError(t, w.registerHook(ctx, inst))
+	assert.False(t, registered(), "an unregistered webhook is not registered again")
+
+	// the cleanup of the scope unregisters the webhooks
+	w.unregistered = false
+	require.NoError(t, w.registerHook(ctx, inst))
+	require.NoError(t, k8sCli.DeleteValidatingWebhookConfigurations(ctx, map[string]string{scopeLabel: "test"}))
+	assert.False(t, registered())
+}
+
+func TestDeployWithoutCA(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	k8sCli, err := fake.New(ctx, "test")
+	require.NoError(t, err)
+	inst, err := instance.New("controller", system.SystemDependencies{K8sCli: k8sCli}, instance.WithImage("alpine"))
+	require.NoError(t, err)
+	require.NoError(t, inst.Commit())
+
+	_, err = Deploy(ctx, inst, Config{Rules: podRules})
+	assert.ErrorIs(t, err, ErrCANotSet)
+}